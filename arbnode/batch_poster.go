@@ -46,9 +46,12 @@ import (
 )
 
 var (
-	batchPosterWalletBalance      = metrics.NewRegisteredGaugeFloat64("arb/batchposter/wallet/balanceether", nil)
-	batchPosterGasRefunderBalance = metrics.NewRegisteredGaugeFloat64("arb/batchposter/gasrefunder/balanceether", nil)
-	batchPosterSimpleRedisLockKey = "node.batch-poster.redis-lock.simple-lock-key"
+	batchPosterWalletBalance       = metrics.NewRegisteredGaugeFloat64("arb/batchposter/wallet/balanceether", nil)
+	batchPosterGasRefunderBalance  = metrics.NewRegisteredGaugeFloat64("arb/batchposter/gasrefunder/balanceether", nil)
+	batchPosterDASStoredCounter    = metrics.NewRegisteredCounter("arb/batchposter/das/stored", nil)
+	batchPosterDASFallbackCounter  = metrics.NewRegisteredCounter("arb/batchposter/das/fallback", nil)
+	batchPosterDASDualWriteCounter = metrics.NewRegisteredCounter("arb/batchposter/das/dualwrite", nil)
+	batchPosterSimpleRedisLockKey  = "node.batch-poster.redis-lock.simple-lock-key"
 )
 
 type batchPosterPosition struct {
@@ -111,17 +114,19 @@ type BatchPosterConfig struct {
 	// Batch post polling interval.
 	PollInterval time.Duration `koanf:"poll-interval" reload:"hot"`
 	// Batch posting error delay.
-	ErrorDelay         time.Duration               `koanf:"error-delay" reload:"hot"`
-	CompressionLevel   int                         `koanf:"compression-level" reload:"hot"`
-	DASRetentionPeriod time.Duration               `koanf:"das-retention-period" reload:"hot"`
-	GasRefunderAddress string                      `koanf:"gas-refunder-address" reload:"hot"`
-	DataPoster         dataposter.DataPosterConfig `koanf:"data-poster" reload:"hot"`
-	RedisUrl           string                      `koanf:"redis-url"`
-	RedisLock          redislock.SimpleCfg         `koanf:"redis-lock" reload:"hot"`
-	ExtraBatchGas      uint64                      `koanf:"extra-batch-gas" reload:"hot"`
-	ParentChainWallet  genericconf.WalletConfig    `koanf:"parent-chain-wallet"`
-	L1BlockBound       string                      `koanf:"l1-block-bound" reload:"hot"`
-	L1BlockBoundBypass time.Duration               `koanf:"l1-block-bound-bypass" reload:"hot"`
+	ErrorDelay             time.Duration               `koanf:"error-delay" reload:"hot"`
+	CompressionLevel       int                         `koanf:"compression-level" reload:"hot"`
+	DASRetentionPeriod     time.Duration               `koanf:"das-retention-period" reload:"hot"`
+	DASStoreTimeout        time.Duration               `koanf:"das-store-timeout" reload:"hot"`
+	DASDualWriteUntilBatch uint64                      `koanf:"das-dual-write-until-batch" reload:"hot"`
+	GasRefunderAddress     string                      `koanf:"gas-refunder-address" reload:"hot"`
+	DataPoster             dataposter.DataPosterConfig `koanf:"data-poster" reload:"hot"`
+	RedisUrl               string                      `koanf:"redis-url"`
+	RedisLock              redislock.SimpleCfg         `koanf:"redis-lock" reload:"hot"`
+	ExtraBatchGas          uint64                      `koanf:"extra-batch-gas" reload:"hot"`
+	ParentChainWallet      genericconf.WalletConfig    `koanf:"parent-chain-wallet"`
+	L1BlockBound           string                      `koanf:"l1-block-bound" reload:"hot"`
+	L1BlockBoundBypass     time.Duration               `koanf:"l1-block-bound-bypass" reload:"hot"`
 
 	gasRefunder  common.Address
 	l1BlockBound l1BlockBound
@@ -163,6 +168,8 @@ func BatchPosterConfigAddOptions(prefix string, f *pflag.FlagSet) {
 	f.Duration(prefix+".error-delay", DefaultBatchPosterConfig.ErrorDelay, "how long to delay after error posting batch")
 	f.Int(prefix+".compression-level", DefaultBatchPosterConfig.CompressionLevel, "batch compression level")
 	f.Duration(prefix+".das-retention-period", DefaultBatchPosterConfig.DASRetentionPeriod, "In AnyTrust mode, the period which DASes are requested to retain the stored batches.")
+	f.Duration(prefix+".das-store-timeout", DefaultBatchPosterConfig.DASStoreTimeout, "In AnyTrust mode, how long to wait for the DAS committee to sign a batch before falling back to storing the data on chain, unless that is disabled (0 to only rely on the DAS request timeout)")
+	f.Uint64(prefix+".das-dual-write-until-batch", DefaultBatchPosterConfig.DASDualWriteUntilBatch, "In AnyTrust mode, store batches with sequence numbers below this in the DAS and also post their full data on chain, so the committee can be exercised before the chain relies on it (0 to disable)")
 	f.String(prefix+".gas-refunder-address", DefaultBatchPosterConfig.GasRefunderAddress, "The gas refunder contract address (optional)")
 	f.Uint64(prefix+".extra-batch-gas", DefaultBatchPosterConfig.ExtraBatchGas, "use this much more gas than estimation says is necessary to post batches")
 	f.String(prefix+".redis-url", DefaultBatchPosterConfig.RedisUrl, "if non-empty, the Redis URL to store queued transactions in")
@@ -1024,15 +1031,34 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) (bool, error)
 			return false, fmt.Errorf("%w: nonce changed from %d to %d while creating batch", storage.ErrStorageRace, nonce, gotNonce)
 		}
 
-		cert, err := b.daWriter.Store(ctx, sequencerMsg, uint64(time.Now().Add(config.DASRetentionPeriod).Unix()), []byte{}) // b.daWriter will append signature if enabled
-		if errors.Is(err, das.BatchToDasFailed) {
+		storeCtx := ctx
+		if config.DASStoreTimeout > 0 {
+			var cancel context.CancelFunc
+			storeCtx, cancel = context.WithTimeout(ctx, config.DASStoreTimeout)
+			defer cancel()
+		}
+		cert, err := b.daWriter.Store(storeCtx, sequencerMsg, uint64(time.Now().Add(config.DASRetentionPeriod).Unix()), []byte{}) // b.daWriter will append signature if enabled
+		// Hitting our own deadline means the committee couldn't sign in time.
+		storeTimedOut := err != nil && ctx.Err() == nil && errors.Is(storeCtx.Err(), context.DeadlineExceeded)
+		dualWrite := batchPosition.NextSeqNum < config.DASDualWriteUntilBatch
+		if dualWrite && (errors.Is(err, das.BatchToDasFailed) || storeTimedOut) {
+			// The data is posted on chain regardless, so this is only a
+			// sign the committee isn't ready to be relied on.
+			log.Warn("Unable to batch to DAS while dual-writing", "sequenceNumber", batchPosition.NextSeqNum, "timedOut", storeTimedOut, "err", err)
+		} else if errors.Is(err, das.BatchToDasFailed) || storeTimedOut {
 			if config.DisableDasFallbackStoreDataOnChain {
-				return false, errors.New("unable to batch to DAS and fallback storing data on chain is disabled")
+				return false, fmt.Errorf("unable to batch to DAS and fallback storing data on chain is disabled: %w", err)
 			}
-			log.Warn("Falling back to storing data on chain", "err", err)
+			batchPosterDASFallbackCounter.Inc(1)
+			log.Warn("Falling back to storing data on chain", "sequenceNumber", batchPosition.NextSeqNum, "size", len(sequencerMsg), "timedOut", storeTimedOut, "err", err)
 		} else if err != nil {
 			return false, err
+		} else if dualWrite {
+			batchPosterDASStoredCounter.Inc(1)
+			batchPosterDASDualWriteCounter.Inc(1)
+			log.Info("Stored batch in DAS, also posting its data on chain", "sequenceNumber", batchPosition.NextSeqNum, "dataHash", common.Hash(cert.DataHash), "size", len(sequencerMsg))
 		} else {
+			batchPosterDASStoredCounter.Inc(1)
 			sequencerMsg = das.Serialize(cert)
 		}
 	}
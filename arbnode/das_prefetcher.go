@@ -0,0 +1,118 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/arbutil"
+)
+
+// dasPrefetcher fetches the DAS payloads of the batches being added to the
+// inbox ahead of the multiplexer, up to a fixed number of batches at a time,
+// so that catching up isn't bound by the latency of fetching each batch in
+// turn. It is also the DataAvailabilityReader the multiplexer reads from,
+// serving the prefetched data.
+type dasPrefetcher struct {
+	arbstate.DataAvailabilityReader
+
+	mutex   sync.Mutex
+	fetched map[common.Hash][]byte
+
+	// ready is closed for each batch once it has been prefetched, after which
+	// the multiplexer may use it.
+	ready  map[uint64]chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newDASPrefetcher(
+	ctx context.Context,
+	reader arbstate.DataAvailabilityReader,
+	client arbutil.L1Interface,
+	batches []*SequencerInboxBatch,
+	concurrency int,
+) *dasPrefetcher {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &dasPrefetcher{
+		DataAvailabilityReader: reader,
+		fetched:                make(map[common.Hash][]byte),
+		ready:                  make(map[uint64]chan struct{}, len(batches)),
+		cancel:                 cancel,
+	}
+	for _, batch := range batches {
+		p.ready[batch.SequenceNumber] = make(chan struct{})
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		slots := make(chan struct{}, concurrency)
+		for _, batch := range batches {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			p.wg.Add(1)
+			go func(batch *SequencerInboxBatch) {
+				defer p.wg.Done()
+				defer func() { <-slots }()
+				defer close(p.ready[batch.SequenceNumber])
+				p.prefetch(ctx, client, batch)
+			}(batch)
+		}
+	}()
+	return p
+}
+
+func (p *dasPrefetcher) prefetch(ctx context.Context, client arbutil.L1Interface, batch *SequencerInboxBatch) {
+	data, err := batch.Serialize(ctx, client)
+	if err != nil || len(data) <= 40 || !arbstate.IsDASMessageHeaderByte(data[40]) {
+		return
+	}
+	if _, err := arbstate.RecoverPayloadFromDasBatch(ctx, batch.SequenceNumber, data, p, nil, arbstate.KeysetValidate); err != nil && ctx.Err() == nil {
+		log.Debug("failed to prefetch DAS batch, it will be fetched again", "batch", batch.SequenceNumber, "err", err)
+	}
+}
+
+// wait blocks until the batch has been prefetched, since batches mustn't be
+// serialized concurrently.
+func (p *dasPrefetcher) wait(ctx context.Context, seqNum uint64) {
+	ready, ok := p.ready[seqNum]
+	if !ok {
+		return
+	}
+	select {
+	case <-ready:
+	case <-ctx.Done():
+	}
+}
+
+// stop cancels any prefetching still in progress, and waits for it to finish.
+func (p *dasPrefetcher) stop() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+func (p *dasPrefetcher) GetByHash(ctx context.Context, hash common.Hash) ([]byte, error) {
+	p.mutex.Lock()
+	data, ok := p.fetched[hash]
+	p.mutex.Unlock()
+	if ok {
+		return data, nil
+	}
+	data, err := p.DataAvailabilityReader.GetByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	p.mutex.Lock()
+	p.fetched[hash] = data
+	p.mutex.Unlock()
+	return data, nil
+}
@@ -38,12 +38,15 @@ type InboxTracker struct {
 	mutex      sync.Mutex
 	validator  *staker.BlockValidator
 	das        arbstate.DataAvailabilityReader
+	// Number of batches whose DAS payloads are fetched concurrently when
+	// adding batches.
+	dasPrefetchBatches int
 
 	batchMetaMutex sync.Mutex
 	batchMeta      *containers.LruCache[uint64, BatchMetadata]
 }
 
-func NewInboxTracker(db ethdb.Database, txStreamer *TransactionStreamer, das arbstate.DataAvailabilityReader) (*InboxTracker, error) {
+func NewInboxTracker(db ethdb.Database, txStreamer *TransactionStreamer, das arbstate.DataAvailabilityReader, dasPrefetchBatches int) (*InboxTracker, error) {
 	// We support a nil txStreamer for the pruning code
 	if txStreamer != nil && txStreamer.chainConfig.ArbitrumChainParams.DataAvailabilityCommittee && das == nil {
 		return nil, errors.New("data availability service required but unconfigured")
@@ -53,6 +56,8 @@ func NewInboxTracker(db ethdb.Database, txStreamer *TransactionStreamer, das arb
 		txStreamer: txStreamer,
 		das:        das,
 		batchMeta:  containers.NewLruCache[uint64, BatchMetadata](1000),
+
+		dasPrefetchBatches: dasPrefetchBatches,
 	}
 	return tracker, nil
 }
@@ -499,15 +504,19 @@ type multiplexerBackend struct {
 	batches               []*SequencerInboxBatch
 	positionWithinMessage uint64
 
-	ctx    context.Context
-	client arbutil.L1Interface
-	inbox  *InboxTracker
+	ctx        context.Context
+	client     arbutil.L1Interface
+	inbox      *InboxTracker
+	prefetcher *dasPrefetcher
 }
 
 func (b *multiplexerBackend) PeekSequencerInbox() ([]byte, error) {
 	if len(b.batches) == 0 {
 		return nil, errors.New("read past end of specified sequencer batches")
 	}
+	if b.prefetcher != nil {
+		b.prefetcher.wait(b.ctx, b.batches[0].SequenceNumber)
+	}
 	return b.batches[0].Serialize(b.ctx, b.client)
 }
 
@@ -603,7 +612,13 @@ func (t *InboxTracker) AddSequencerBatches(ctx context.Context, client arbutil.L
 		ctx:    ctx,
 		client: client,
 	}
-	multiplexer := arbstate.NewInboxMultiplexer(backend, prevbatchmeta.DelayedMessageCount, t.das, arbstate.KeysetValidate)
+	daReader := t.das
+	if t.das != nil && t.dasPrefetchBatches > 0 && len(batches) > 1 {
+		backend.prefetcher = newDASPrefetcher(ctx, t.das, client, batches, t.dasPrefetchBatches)
+		defer backend.prefetcher.stop()
+		daReader = backend.prefetcher
+	}
+	multiplexer := arbstate.NewInboxMultiplexer(backend, prevbatchmeta.DelayedMessageCount, daReader, arbstate.KeysetValidate)
 	batchMessageCounts := make(map[uint64]arbutil.MessageIndex)
 	currentpos := prevbatchmeta.MessageCount + 1
 	for {
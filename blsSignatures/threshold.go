@@ -0,0 +1,178 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package blsSignatures
+
+import (
+	cryptorand "crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+)
+
+// This file implements a joint-Feldman distributed key generation, in which
+// each of n participants deals shares of a random secret to the others, and
+// each participant's key share is the sum of the shares dealt to it. Any
+// threshold of the n key shares determine the group private key, the sum of
+// the dealt secrets, but no participant ever holds it. Participants are
+// numbered from 1, as the secret is the dealt polynomial's value at 0.
+
+// Dealing is a participant's contribution to a key generation: commitments to
+// the coefficients of a random polynomial of degree threshold-1, and the
+// polynomial's value at each participant's index.
+type Dealing struct {
+	Commitments []*bls12381.PointG2
+	Shares      []PrivateKey // Shares[j-1] is dealt to participant j
+}
+
+// Deal generates a participant's dealing for a group of participants, any
+// threshold of whom can sign.
+func Deal(threshold int, participants int) (*Dealing, error) {
+	if threshold < 1 || threshold > participants {
+		return nil, fmt.Errorf("threshold must be between 1 and the number of participants (%d), got %d", participants, threshold)
+	}
+	g2 := bls12381.NewG2()
+	coefficients := make([]*big.Int, threshold)
+	commitments := make([]*bls12381.PointG2, threshold)
+	for k := range coefficients {
+		coefficient, err := cryptorand.Int(cryptorand.Reader, g2.Q())
+		if err != nil {
+			return nil, err
+		}
+		coefficients[k] = coefficient
+		commitments[k] = &bls12381.PointG2{}
+		g2.MulScalar(commitments[k], g2.One(), coefficient)
+	}
+	shares := make([]PrivateKey, participants)
+	for j := range shares {
+		shares[j] = evaluatePolynomial(coefficients, big.NewInt(int64(j+1)), g2.Q())
+	}
+	return &Dealing{Commitments: commitments, Shares: shares}, nil
+}
+
+func evaluatePolynomial(coefficients []*big.Int, x *big.Int, order *big.Int) *big.Int {
+	result := new(big.Int)
+	for k := len(coefficients) - 1; k >= 0; k-- {
+		result.Mul(result, x)
+		result.Add(result, coefficients[k])
+		result.Mod(result, order)
+	}
+	return result
+}
+
+// commitmentAt evaluates the committed polynomial at index in the exponent,
+// giving the public key of the share dealt to index.
+func commitmentAt(commitments []*bls12381.PointG2, index uint64) *bls12381.PointG2 {
+	g2 := bls12381.NewG2()
+	x := new(big.Int).SetUint64(index)
+	result := g2.Zero()
+	for k := len(commitments) - 1; k >= 0; k-- {
+		g2.MulScalar(result, result, x)
+		g2.Add(result, result, commitments[k])
+	}
+	return result
+}
+
+// VerifyShare checks a share dealt to index against the dealer's commitments.
+func VerifyShare(commitments []*bls12381.PointG2, index uint64, share PrivateKey) bool {
+	g2 := bls12381.NewG2()
+	expected := &bls12381.PointG2{}
+	g2.MulScalar(expected, g2.One(), share)
+	return g2.Equal(expected, commitmentAt(commitments, index))
+}
+
+// CombineKeyShares sums the verified shares dealt to a participant by every
+// participant, including itself, into its key share.
+func CombineKeyShares(shares []PrivateKey) PrivateKey {
+	order := bls12381.NewG2().Q()
+	result := new(big.Int)
+	for _, share := range shares {
+		result.Add(result, share)
+	}
+	return result.Mod(result, order)
+}
+
+// GroupPublicKeyPoint is the public key of the group private key, from every
+// participant's commitments.
+func GroupPublicKeyPoint(commitments [][]*bls12381.PointG2) *bls12381.PointG2 {
+	g2 := bls12381.NewG2()
+	result := g2.Zero()
+	for _, c := range commitments {
+		g2.Add(result, result, c[0])
+	}
+	return result
+}
+
+// PublicKeyShare is the public key of participant index's key share, from
+// every participant's commitments, so signature shares can be checked.
+func PublicKeyShare(commitments [][]*bls12381.PointG2, index uint64) PublicKey {
+	g2 := bls12381.NewG2()
+	result := g2.Zero()
+	for _, c := range commitments {
+		g2.Add(result, result, commitmentAt(c, index))
+	}
+	return NewTrustedPublicKey(result)
+}
+
+// KeyValidityProofShare is participant's share of the validity proof of the
+// group public key, which can't be computed directly as no one holds the
+// group private key.
+func KeyValidityProofShare(groupPublicKey *bls12381.PointG2, keyShare PrivateKey) (Signature, error) {
+	return KeyValidityProof(groupPublicKey, keyShare)
+}
+
+// VerifyKeyValidityProofShare checks a participant's share of the group
+// public key's validity proof against the public key of its key share.
+func VerifyKeyValidityProofShare(proofShare Signature, groupPublicKey *bls12381.PointG2, publicKeyShare PublicKey) (bool, error) {
+	g2 := bls12381.NewG2()
+	return verifySignature2(proofShare, g2.ToBytes(groupPublicKey), publicKeyShare, true)
+}
+
+// CombineSignatureShares interpolates the signature of the group private key
+// from at least threshold participants' signatures of the same message with
+// their key shares, keyed by participant index. It also combines shares of
+// the group public key's validity proof.
+func CombineSignatureShares(shares map[uint64]Signature) (Signature, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no signature shares to combine")
+	}
+	g1 := bls12381.NewG1()
+	order := g1.Q()
+	result := g1.Zero()
+	for j, share := range shares {
+		if j == 0 {
+			return nil, errors.New("participant indexes start at 1")
+		}
+		// The Lagrange coefficient of j at 0 is the product of m/(m-j) for
+		// the other indexes m.
+		numerator := big.NewInt(1)
+		denominator := big.NewInt(1)
+		for m := range shares {
+			if m == j {
+				continue
+			}
+			mBig := new(big.Int).SetUint64(m)
+			numerator.Mul(numerator, mBig)
+			numerator.Mod(numerator, order)
+			denominator.Mul(denominator, new(big.Int).Sub(mBig, new(big.Int).SetUint64(j)))
+			denominator.Mod(denominator, order)
+		}
+		coefficient := new(big.Int).ModInverse(denominator, order)
+		coefficient.Mul(coefficient, numerator)
+		coefficient.Mod(coefficient, order)
+		term := &bls12381.PointG1{}
+		g1.MulScalar(term, share, coefficient)
+		g1.Add(result, result, term)
+	}
+	return result, nil
+}
+
+func G2PointToBytes(point *bls12381.PointG2) []byte {
+	return bls12381.NewG2().ToBytes(point)
+}
+
+func G2PointFromBytes(in []byte) (*bls12381.PointG2, error) {
+	return bls12381.NewG2().FromBytes(in)
+}
@@ -0,0 +1,103 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package blsSignatures
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+)
+
+func TestThresholdKeyGeneration(t *testing.T) {
+	const participants = 4
+	const threshold = 3
+
+	var dealings []*Dealing
+	var commitments [][]*bls12381.PointG2
+	for i := 0; i < participants; i++ {
+		dealing, err := Deal(threshold, participants)
+		Require(t, err)
+		dealings = append(dealings, dealing)
+		commitments = append(commitments, dealing.Commitments)
+	}
+
+	keyShares := make(map[uint64]PrivateKey)
+	for j := uint64(1); j <= participants; j++ {
+		var shares []PrivateKey
+		for _, dealing := range dealings {
+			share := dealing.Shares[j-1]
+			if !VerifyShare(dealing.Commitments, j, share) {
+				Fail(t, "valid share failed verification", j)
+			}
+			shares = append(shares, share)
+		}
+		keyShares[j] = CombineKeyShares(shares)
+	}
+	if VerifyShare(dealings[0].Commitments, 1, dealings[0].Shares[1]) {
+		Fail(t, "share dealt to another participant passed verification")
+	}
+
+	groupKey := GroupPublicKeyPoint(commitments)
+	proofShares := make(map[uint64]Signature)
+	for _, j := range []uint64{1, 3, 4} {
+		proofShare, err := KeyValidityProofShare(groupKey, keyShares[j])
+		Require(t, err)
+		valid, err := VerifyKeyValidityProofShare(proofShare, groupKey, PublicKeyShare(commitments, j))
+		Require(t, err)
+		if !valid {
+			Fail(t, "valid proof share failed verification", j)
+		}
+		proofShares[j] = proofShare
+	}
+	proof, err := CombineSignatureShares(proofShares)
+	Require(t, err)
+	pubKey, err := NewPublicKey(groupKey, proof)
+	Require(t, err)
+
+	message := []byte("signed by a threshold of the group")
+	for _, signers := range [][]uint64{{1, 2, 3}, {2, 3, 4}, {1, 2, 3, 4}} {
+		sigShares := make(map[uint64]Signature)
+		for _, j := range signers {
+			sigShare, err := SignMessage(keyShares[j], message)
+			Require(t, err)
+			sigShares[j] = sigShare
+		}
+		sig, err := CombineSignatureShares(sigShares)
+		Require(t, err)
+		valid, err := VerifySignature(sig, message, pubKey)
+		Require(t, err)
+		if !valid {
+			Fail(t, "combined signature failed verification", signers)
+		}
+	}
+
+	sigShares := make(map[uint64]Signature)
+	for _, j := range []uint64{1, 2} {
+		sigShare, err := SignMessage(keyShares[j], message)
+		Require(t, err)
+		sigShares[j] = sigShare
+	}
+	sig, err := CombineSignatureShares(sigShares)
+	Require(t, err)
+	valid, err := VerifySignature(sig, message, pubKey)
+	Require(t, err)
+	if valid {
+		Fail(t, "signature combined from fewer than threshold shares passed verification")
+	}
+}
+
+func TestDealRejectsInvalidThreshold(t *testing.T) {
+	if _, err := Deal(0, 3); err == nil {
+		Fail(t, "zero threshold should be rejected")
+	}
+	if _, err := Deal(4, 3); err == nil {
+		Fail(t, "threshold above the number of participants should be rejected")
+	}
+	// The polynomial is evaluated mod the group order.
+	order := bls12381.NewG2().Q()
+	if evaluatePolynomial([]*big.Int{big.NewInt(1), new(big.Int).Sub(order, big.NewInt(1))}, big.NewInt(1), order).Sign() != 0 {
+		Fail(t, "polynomial evaluation should be reduced mod the group order")
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+	"github.com/offchainlabs/nitro/das"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
+	"github.com/offchainlabs/nitro/util/signature"
+)
+
+type CheckCommitteeConfig struct {
+	Committee             das.AggregatorConfig   `koanf:"committee"`
+	RequestTimeout        time.Duration          `koanf:"request-timeout"`
+	MessageSize           int                    `koanf:"message-size"`
+	RetentionPeriod       time.Duration          `koanf:"retention-period"`
+	RESTURLs              []string               `koanf:"rest-urls"`
+	ExpectedKeysetHash    string                 `koanf:"expected-keyset-hash"`
+	ParentChainNodeURL    string                 `koanf:"parent-chain-node-url"`
+	SequencerInboxAddress string                 `koanf:"sequencer-inbox-address"`
+	SigningKey            string                 `koanf:"signing-key"`
+	SigningWallet         string                 `koanf:"signing-wallet"`
+	SigningWalletPassword string                 `koanf:"signing-wallet-password"`
+	Conf                  genericconf.ConfConfig `koanf:"conf"`
+}
+
+func parseCheckCommittee(args []string) (*CheckCommitteeConfig, error) {
+	f := flag.NewFlagSet("daserver check-committee", flag.ContinueOnError)
+	das.AggregatorConfigAddOptions("committee", f)
+	f.Duration("request-timeout", 5*time.Second, "timeout of the Store to each backend")
+	f.Int("message-size", 32, "size in bytes of the random message stored to each backend")
+	f.Duration("retention-period", time.Hour, "period the backends are asked to retain the message for")
+	f.StringSlice("rest-urls", []string{}, "REST URLs of the committee members to retrieve the stored message from")
+	f.String("expected-keyset-hash", "", "hex-encoded keyset hash the committee is expected to have")
+	f.String("parent-chain-node-url", "", "URL of a parent chain node, to check the keyset is valid in the SequencerInbox")
+	f.String("sequencer-inbox-address", "", "parent chain address of the SequencerInbox contract, to check the keyset is valid in it")
+	f.String("signing-key", "", "ecdsa private key to sign the Store with, treated as a hex string if prefixed with 0x otherwise treated as a file; backends that check signatures need the batch poster's key")
+	f.String("signing-wallet", "", "wallet containing ecdsa key to sign the Store with")
+	f.String("signing-wallet-password", genericconf.PASSWORD_NOT_SET, "password to unlock the wallet, if not specified the user is prompted for the password")
+	genericconf.ConfConfigAddOptions("conf", f)
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var config CheckCommitteeConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	if config.Committee.AssumedHonest == 0 {
+		return nil, errors.New("--committee.assumed-honest must be set")
+	}
+	if config.Committee.Backends == "" && config.Committee.BackendsFile == "" {
+		return nil, errors.New("--committee.backends or --committee.backends-file must be set")
+	}
+	if config.MessageSize <= 0 {
+		return nil, errors.New("--message-size must be positive")
+	}
+	return &config, nil
+}
+
+func (c *CheckCommitteeConfig) signer() (signature.DataSignerFunc, error) {
+	if c.SigningKey != "" {
+		var privateKey *ecdsa.PrivateKey
+		var err error
+		if len(c.SigningKey) > 2 && c.SigningKey[:2] == "0x" {
+			privateKey, err = crypto.HexToECDSA(c.SigningKey[2:])
+		} else {
+			privateKey, err = crypto.LoadECDSA(c.SigningKey)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return signature.DataSignerFromPrivateKey(privateKey), nil
+	}
+	if c.SigningWallet != "" {
+		walletConf := &genericconf.WalletConfig{
+			Pathname: c.SigningWallet,
+			Password: c.SigningWalletPassword,
+		}
+		_, signer, err := util.OpenWallet("daserver", walletConf, nil)
+		return signer, err
+	}
+	return nil, nil
+}
+
+// checkCommittee stores a small random message to each committee member
+// without aggregating the results, retrieves it from the REST endpoints, and
+// checks the committee's keyset, printing a report so a committee can be
+// checked before the batch poster starts using it.
+func checkCommittee(args []string) error {
+	config, err := parseCheckCommittee(args)
+	if err != nil {
+		return err
+	}
+	signer, err := config.signer()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	aggregator, err := das.NewRPCAggregator(ctx, das.DataAvailabilityConfig{
+		RPCAggregator:      config.Committee,
+		RequestTimeout:     config.RequestTimeout,
+		ParentChainNodeURL: "none",
+	})
+	if err != nil {
+		return err
+	}
+
+	message := make([]byte, config.MessageSize)
+	if _, err := rand.Read(message); err != nil {
+		return err
+	}
+	timeout := uint64(time.Now().Add(config.RetentionPeriod).Unix())
+	checks, err := aggregator.CheckBackends(ctx, message, timeout, signer)
+	if err != nil {
+		return err
+	}
+
+	failures := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "BACKEND\tSIGNERS MASK\tHEALTH\tSTORE\tLATENCY")
+	for _, check := range checks {
+		if check.HealthErr != nil || check.StoreErr != nil {
+			failures++
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%v\n", check.Backend, check.SignersMask, checkResult(check.HealthErr), checkResult(check.StoreErr), check.StoreLatency.Round(time.Millisecond))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(config.RESTURLs) > 0 {
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "REST URL\tRETRIEVE\tLATENCY")
+		for _, url := range config.RESTURLs {
+			client, err := das.NewRestfulDasClientFromURL(url)
+			var latency time.Duration
+			if err == nil {
+				start := time.Now()
+				_, err = client.GetByHash(ctx, dastree.Hash(message))
+				latency = time.Since(start)
+			}
+			if err != nil {
+				failures++
+			}
+			fmt.Fprintf(w, "%s\t%s\t%v\n", url, checkResult(err), latency.Round(time.Millisecond))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	keysetHash := aggregator.KeysetHash()
+	fmt.Printf("\nKeysetHash: %s\n", keysetHash.Hex())
+	if config.ExpectedKeysetHash != "" {
+		if common.HexToHash(config.ExpectedKeysetHash) != keysetHash {
+			failures++
+			fmt.Printf("Expected keyset hash: FAILED (expected %s)\n", config.ExpectedKeysetHash)
+		} else {
+			fmt.Println("Expected keyset hash: OK")
+		}
+	}
+	if config.ParentChainNodeURL != "" && config.SequencerInboxAddress != "" {
+		valid, err := keysetValidOnChain(ctx, config, keysetHash)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			failures++
+			fmt.Println("Keyset valid in SequencerInbox: FAILED")
+		} else {
+			fmt.Println("Keyset valid in SequencerInbox: OK")
+		}
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("committee check found %d failures", failures)
+	}
+	fmt.Println("Committee check passed")
+	return nil
+}
+
+func keysetValidOnChain(ctx context.Context, config *CheckCommitteeConfig, keysetHash common.Hash) (bool, error) {
+	seqInboxAddress, err := das.OptionalAddressFromString(config.SequencerInboxAddress)
+	if err != nil {
+		return false, err
+	}
+	if seqInboxAddress == nil {
+		return false, errors.New("--sequencer-inbox-address must be a contract address to check the keyset on chain")
+	}
+	l1Client, err := das.GetL1Client(ctx, 1, config.ParentChainNodeURL)
+	if err != nil {
+		return false, err
+	}
+	seqInbox, err := bridgegen.NewSequencerInboxCaller(*seqInboxAddress, l1Client)
+	if err != nil {
+		return false, err
+	}
+	return seqInbox.IsValidKeysetHash(&bind.CallOpts{Context: ctx}, keysetHash)
+}
+
+func checkResult(err error) string {
+	if err != nil {
+		return "FAILED: " + err.Error()
+	}
+	return "OK"
+}
@@ -10,11 +10,13 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	koanfjson "github.com/knadh/koanf/parsers/json"
 	flag "github.com/spf13/pflag"
+	"google.golang.org/grpc"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -33,12 +35,30 @@ type DAServerConfig struct {
 	EnableRPC         bool                                `koanf:"enable-rpc"`
 	RPCAddr           string                              `koanf:"rpc-addr"`
 	RPCPort           uint64                              `koanf:"rpc-port"`
+	RPCListeners      string                              `koanf:"rpc-listeners"`
+	RPCUnixSocket     string                              `koanf:"rpc-unix-socket"`
 	RPCServerTimeouts genericconf.HTTPServerTimeoutConfig `koanf:"rpc-server-timeouts"`
+	RPCClientCAFile   string                              `koanf:"rpc-client-ca-file"`
+	RPCTokenAuth      das.TokenAuthConfig                 `koanf:"rpc-token-auth"`
+	RPCAsyncStore     das.AsyncStoreConfig                `koanf:"rpc-async-store"`
 
 	EnableREST         bool                                `koanf:"enable-rest"`
 	RESTAddr           string                              `koanf:"rest-addr"`
 	RESTPort           uint64                              `koanf:"rest-port"`
+	RESTListeners      string                              `koanf:"rest-listeners"`
+	RESTUnixSocket     string                              `koanf:"rest-unix-socket"`
+	RESTHTTP3          bool                                `koanf:"rest-http3"`
 	RESTServerTimeouts genericconf.HTTPServerTimeoutConfig `koanf:"rest-server-timeouts"`
+	RESTCORS           das.RestfulCORSConfig               `koanf:"rest-cors"`
+
+	EnableGRPC bool   `koanf:"enable-grpc"`
+	GRPCAddr   string `koanf:"grpc-addr"`
+	GRPCPort   uint64 `koanf:"grpc-port"`
+
+	TLS       genericconf.TLSConfig `koanf:"tls"`
+	RateLimit das.RateLimitConfig   `koanf:"rate-limit"`
+
+	ShutdownDrainTimeout time.Duration `koanf:"shutdown-drain-timeout"`
 
 	DataAvailability das.DataAvailabilityConfig `koanf:"data-availability"`
 
@@ -50,25 +70,36 @@ type DAServerConfig struct {
 	MetricsServer genericconf.MetricsServerConfig `koanf:"metrics-server"`
 	PProf         bool                            `koanf:"pprof"`
 	PprofCfg      genericconf.PProf               `koanf:"pprof-cfg"`
+	Admin         das.AdminServerConfig           `koanf:"admin"`
 }
 
 var DefaultDAServerConfig = DAServerConfig{
-	EnableRPC:          false,
-	RPCAddr:            "localhost",
-	RPCPort:            9876,
-	RPCServerTimeouts:  genericconf.HTTPServerTimeoutConfigDefault,
-	EnableREST:         false,
-	RESTAddr:           "localhost",
-	RESTPort:           9877,
-	RESTServerTimeouts: genericconf.HTTPServerTimeoutConfigDefault,
-	DataAvailability:   das.DefaultDataAvailabilityConfig,
-	Conf:               genericconf.ConfConfigDefault,
-	LogLevel:           int(log.LvlInfo),
-	LogType:            "plaintext",
-	Metrics:            false,
-	MetricsServer:      genericconf.MetricsServerConfigDefault,
-	PProf:              false,
-	PprofCfg:           genericconf.PProfDefault,
+	EnableRPC:            false,
+	RPCAddr:              "localhost",
+	RPCPort:              9876,
+	RPCServerTimeouts:    genericconf.HTTPServerTimeoutConfigDefault,
+	RPCTokenAuth:         das.DefaultTokenAuthConfig,
+	RPCAsyncStore:        das.DefaultAsyncStoreConfig,
+	EnableREST:           false,
+	RESTAddr:             "localhost",
+	RESTPort:             9877,
+	RESTServerTimeouts:   genericconf.HTTPServerTimeoutConfigDefault,
+	RESTCORS:             das.DefaultRestfulCORSConfig,
+	EnableGRPC:           false,
+	GRPCAddr:             "localhost",
+	GRPCPort:             9878,
+	TLS:                  genericconf.TLSConfigDefault,
+	RateLimit:            das.DefaultRateLimitConfig,
+	ShutdownDrainTimeout: 30 * time.Second,
+	DataAvailability:     das.DefaultDataAvailabilityConfig,
+	Conf:                 genericconf.ConfConfigDefault,
+	LogLevel:             int(log.LvlInfo),
+	LogType:              "plaintext",
+	Metrics:              false,
+	MetricsServer:        genericconf.MetricsServerConfigDefault,
+	PProf:                false,
+	PprofCfg:             genericconf.PProfDefault,
+	Admin:                das.DefaultAdminServerConfig,
 }
 
 func main() {
@@ -80,6 +111,8 @@ func main() {
 func printSampleUsage(progname string) {
 	fmt.Printf("\n")
 	fmt.Printf("Sample usage:                  %s --help \n", progname)
+	fmt.Printf("Check a committee's backends:  %s check-committee --committee.assumed-honest <H> --committee.backends <backends JSON> \n", progname)
+	fmt.Printf("Serve a BLS key for signing:   %s remote-signer --key.key-dir <dir> --token-auth.tokens-file <file> --tls.enable ... \n", progname)
 }
 
 func parseDAServer(args []string) (*DAServerConfig, error) {
@@ -87,18 +120,36 @@ func parseDAServer(args []string) (*DAServerConfig, error) {
 	f.Bool("enable-rpc", DefaultDAServerConfig.EnableRPC, "enable the HTTP-RPC server listening on rpc-addr and rpc-port")
 	f.String("rpc-addr", DefaultDAServerConfig.RPCAddr, "HTTP-RPC server listening interface")
 	f.Uint64("rpc-port", DefaultDAServerConfig.RPCPort, "HTTP-RPC server listening port")
+	f.String("rpc-listeners", DefaultDAServerConfig.RPCListeners, "JSON list of more addresses for the HTTP-RPC server to listen on, eg for IPv6 or an internal interface, each with addr and port and optionally enable and store, which are true by default, eg [{\"addr\":\"::\",\"port\":9876,\"store\":false}]")
+	f.String("rpc-unix-socket", DefaultDAServerConfig.RPCUnixSocket, "path of a unix domain socket for the HTTP-RPC server to listen on, in addition to rpc-addr and rpc-port if enable-rpc is set, which local clients in the socket's group may dial as unix://<path>")
 	genericconf.HTTPServerTimeoutConfigAddOptions("rpc-server-timeouts", f)
+	f.String("rpc-client-ca-file", DefaultDAServerConfig.RPCClientCAFile, "PEM encoded CA certificates; if set, only HTTP-RPC and gRPC clients presenting a certificate signed by one of them may store data (requires tls.enable)")
+	das.TokenAuthConfigAddOptions("rpc-token-auth", f)
+	das.AsyncStoreConfigAddOptions("rpc-async-store", f)
 
 	f.Bool("enable-rest", DefaultDAServerConfig.EnableREST, "enable the REST server listening on rest-addr and rest-port")
 	f.String("rest-addr", DefaultDAServerConfig.RESTAddr, "REST server listening interface")
 	f.Uint64("rest-port", DefaultDAServerConfig.RESTPort, "REST server listening port")
+	f.String("rest-listeners", DefaultDAServerConfig.RESTListeners, "JSON list of more addresses for the REST server to listen on, each with addr and port and optionally enable, which is true by default, eg [{\"addr\":\"::\",\"port\":9877}]")
+	f.Bool("rest-http3", DefaultDAServerConfig.RESTHTTP3, "also serve REST over HTTP/3 on the UDP ports of rest-port and rest-listeners (requires tls.enable)")
+	f.String("rest-unix-socket", DefaultDAServerConfig.RESTUnixSocket, "path of a unix domain socket for the REST server to listen on, in addition to rest-addr and rest-port if enable-rest is set")
 	genericconf.HTTPServerTimeoutConfigAddOptions("rest-server-timeouts", f)
+	das.RestfulCORSConfigAddOptions("rest-cors", f)
+
+	f.Bool("enable-grpc", DefaultDAServerConfig.EnableGRPC, "enable the gRPC server listening on grpc-addr and grpc-port, which streams large payloads (see das/das.proto)")
+	f.String("grpc-addr", DefaultDAServerConfig.GRPCAddr, "gRPC server listening interface")
+	f.Uint64("grpc-port", DefaultDAServerConfig.GRPCPort, "gRPC server listening port")
+
+	genericconf.TLSConfigAddOptions("tls", f)
+	das.RateLimitConfigAddOptions("rate-limit", f)
+	f.Duration("shutdown-drain-timeout", DefaultDAServerConfig.ShutdownDrainTimeout, "on SIGTERM or SIGINT, how long to wait for in-flight requests, queued stores and syncing to finish before closing storage and exiting (0 to wait for them however long they take)")
 
 	f.Bool("metrics", DefaultDAServerConfig.Metrics, "enable metrics")
 	genericconf.MetricsServerAddOptions("metrics-server", f)
 
 	f.Bool("pprof", DefaultDAServerConfig.PProf, "enable pprof")
 	genericconf.PProfAddOptions("pprof-cfg", f)
+	das.AdminServerConfigAddOptions("admin", f)
 
 	f.Int("log-level", int(log.LvlInfo), "log level; 1: ERROR, 2: WARN, 3: INFO, 4: DEBUG, 5: TRACE")
 	f.String("log-type", DefaultDAServerConfig.LogType, "log type (plaintext or json)")
@@ -171,6 +222,13 @@ func startMetrics(cfg *DAServerConfig) error {
 }
 
 func startup() error {
+	if len(os.Args) > 1 && os.Args[1] == "check-committee" {
+		return checkCommittee(os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "remote-signer" {
+		return runRemoteSigner(os.Args[2:])
+	}
+
 	// Some different defaults to DAS config in a node.
 	das.DefaultDataAvailabilityConfig.Enable = true
 
@@ -178,8 +236,28 @@ func startup() error {
 	if err != nil {
 		confighelpers.PrintErrorAndExit(err, printSampleUsage)
 	}
-	if !(serverConfig.EnableRPC || serverConfig.EnableREST) {
-		confighelpers.PrintErrorAndExit(errors.New("please specify at least one of --enable-rest or --enable-rpc"), printSampleUsage)
+	rpcListeners, err := das.ParseListenerConfigs(serverConfig.RPCListeners)
+	if err != nil {
+		confighelpers.PrintErrorAndExit(fmt.Errorf("--rpc-listeners: %w", err), printSampleUsage)
+	}
+	restListeners, err := das.ParseListenerConfigs(serverConfig.RESTListeners)
+	if err != nil {
+		confighelpers.PrintErrorAndExit(fmt.Errorf("--rest-listeners: %w", err), printSampleUsage)
+	}
+	if serverConfig.EnableRPC {
+		rpcListeners = append([]das.ListenerConfig{{Addr: serverConfig.RPCAddr, Port: serverConfig.RPCPort, Enable: true, Store: true}}, rpcListeners...)
+	}
+	if serverConfig.EnableREST {
+		restListeners = append([]das.ListenerConfig{{Addr: serverConfig.RESTAddr, Port: serverConfig.RESTPort, Enable: true}}, restListeners...)
+	}
+	if err := serverConfig.RPCAsyncStore.Validate(); err != nil {
+		confighelpers.PrintErrorAndExit(fmt.Errorf("--rpc-async-store: %w", err), printSampleUsage)
+	}
+	if len(rpcListeners) == 0 && len(restListeners) == 0 && !serverConfig.EnableGRPC && serverConfig.RPCUnixSocket == "" && serverConfig.RESTUnixSocket == "" {
+		confighelpers.PrintErrorAndExit(errors.New("please specify at least one of --enable-rest, --enable-rpc, --enable-grpc, --rest-listeners, --rpc-listeners, --rest-unix-socket or --rpc-unix-socket"), printSampleUsage)
+	}
+	if err := serverConfig.Admin.Validate(); err != nil {
+		confighelpers.PrintErrorAndExit(err, printSampleUsage)
 	}
 
 	logFormat, err := genericconf.ParseLogType(serverConfig.LogType)
@@ -229,51 +307,285 @@ func startup() error {
 		return errors.New("sequencer-inbox-address must be set to a valid L1 URL and contract address, or 'none'")
 	}
 
-	daReader, daWriter, daHealthChecker, dasLifecycleManager, err := das.CreateDAComponentsForDaserver(ctx, &serverConfig.DataAvailability, l1Reader, seqInboxAddress)
+	var dasServices das.DaserverServices
+	daReader, daWriter, daHealthChecker, dasLifecycleManager, err := das.CreateDAComponentsForDaserver(ctx, &serverConfig.DataAvailability, l1Reader, seqInboxAddress, &dasServices)
 	if err != nil {
 		return err
 	}
 
+	// The servers only start once the keys are loaded, so readiness doesn't
+	// need to check them.
+	var readinessChecks []das.ReadinessCheck
+	if l1Reader != nil {
+		readinessChecks = append(readinessChecks, das.ReadinessCheck{
+			Name: "parent-chain",
+			Check: func(context.Context) error {
+				header, err := l1Reader.LastHeaderWithError()
+				if err != nil {
+					return err
+				}
+				if header == nil {
+					return errors.New("no parent chain header read yet")
+				}
+				return nil
+			},
+		})
+	}
+	daHealthChecker = das.NewReadinessChecker(daHealthChecker, readinessChecks...)
+
+	var signerLimiter *das.SignerRateLimiter
+	if daWriter != nil {
+		// Inside the authenticators, so unauthenticated stores don't use up
+		// a signer's limit.
+		daWriter = das.NewSignerRateLimiter(daWriter, serverConfig.RateLimit)
+		signerLimiter, _ = daWriter.(*das.SignerRateLimiter)
+	}
+
+	var storeAuthorizers []das.StoreAuthorizer
+	if serverConfig.RPCClientCAFile != "" {
+		if !serverConfig.TLS.Enable {
+			return errors.New("--rpc-client-ca-file requires --tls.enable")
+		}
+		if serverConfig.RPCUnixSocket != "" {
+			// Clients on the socket, which isn't served over TLS, can't
+			// present a certificate.
+			return errors.New("--rpc-client-ca-file can't be used with --rpc-unix-socket")
+		}
+		if daWriter != nil {
+			// Inside the store notifier, which the RPC server expects to be
+			// the outermost writer.
+			clientCertAuthenticator := das.NewClientCertStoreAuthenticator(daWriter)
+			storeAuthorizers = append(storeAuthorizers, clientCertAuthenticator)
+			daWriter = clientCertAuthenticator
+		}
+	}
+
+	if daWriter != nil && serverConfig.RPCTokenAuth.TokensFile != "" {
+		tokenAuthenticator, err := das.NewTokenStoreAuthenticator(daWriter, serverConfig.RPCTokenAuth)
+		if err != nil {
+			return err
+		}
+		tokenAuthenticator.Start(ctx)
+		dasLifecycleManager.Register(tokenAuthenticator)
+		storeAuthorizers = append(storeAuthorizers, tokenAuthenticator)
+		daWriter = tokenAuthenticator
+	}
+
+	if daWriter != nil {
+		// Lets RPC clients subscribe to notifications of the data stored.
+		daWriter = das.NewStoreNotifier(daWriter)
+	}
+
+	if daWriter != nil && serverConfig.RPCAsyncStore.Enable {
+		// Outside the authenticators, which authorize queued stores when
+		// they're queued since the client's credentials aren't kept.
+		asyncStorer, err := das.NewAsyncStorer(daWriter, serverConfig.RPCAsyncStore, storeAuthorizers...)
+		if err != nil {
+			return err
+		}
+		if err := asyncStorer.Start(ctx); err != nil {
+			return err
+		}
+		dasLifecycleManager.Register(asyncStorer)
+		daWriter = asyncStorer
+	}
+
 	if l1Reader != nil {
 		l1Reader.Start(ctx)
 		dasLifecycleManager.Register(&L1ReaderCloser{l1Reader})
 	}
 
+	if serverConfig.RESTHTTP3 && !serverConfig.TLS.Enable {
+		return errors.New("--rest-http3 requires --tls.enable")
+	}
+
+	// The HTTP-RPC, REST, gRPC and admin servers share the TLS config, so
+	// that with ACME they share its certificates.
+	tlsConfig, err := serverConfig.TLS.ServerTLSConfig()
+	if err != nil {
+		return err
+	}
+	rpcTLSConfig := tlsConfig
+	if serverConfig.RPCClientCAFile != "" {
+		rpcTLSConfig, err = das.WithClientCAs(tlsConfig, serverConfig.RPCClientCAFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Shared by the HTTP-RPC, REST and gRPC servers, so a client's limits
+	// cover all of them.
+	ipLimiter := das.NewIPRateLimiter(serverConfig.RateLimit)
+
 	vcsRevision, _, vcsTime := confighelpers.GetVersion()
-	var rpcServer *http.Server
-	if serverConfig.EnableRPC {
-		log.Info("Starting HTTP-RPC server", "addr", serverConfig.RPCAddr, "port", serverConfig.RPCPort, "revision", vcsRevision, "vcs.time", vcsTime)
+	var rpcServers []*http.Server
+	for _, listenerConfig := range rpcListeners {
+		log.Info("Starting HTTP-RPC server", "addr", listenerConfig.Addr, "port", listenerConfig.Port, "store", listenerConfig.Store, "tls", tlsConfig != nil, "revision", vcsRevision, "vcs.time", vcsTime)
 
-		rpcServer, err = das.StartDASRPCServer(ctx, serverConfig.RPCAddr, serverConfig.RPCPort, serverConfig.RPCServerTimeouts, daReader, daWriter, daHealthChecker)
+		listener, err := genericconf.TLSListen(listenerConfig.Address(), rpcTLSConfig)
+		if err != nil {
+			return err
+		}
+		listenerWriter := daWriter
+		if !listenerConfig.Store {
+			listenerWriter = nil
+		}
+		rpcServer, err := das.StartDASRPCServerOnListener(ctx, listener, serverConfig.RPCServerTimeouts, ipLimiter, serverConfig.DataAvailability.StoreBounds, daReader, listenerWriter, daHealthChecker)
 		if err != nil {
 			return err
 		}
+		rpcServers = append(rpcServers, rpcServer)
 	}
+	// Local clients on the unix sockets aren't limited per IP, as they'd all
+	// share the socket's address.
+	if serverConfig.RPCUnixSocket != "" {
+		log.Info("Starting HTTP-RPC server", "unix-socket", serverConfig.RPCUnixSocket, "revision", vcsRevision, "vcs.time", vcsTime)
 
-	var restServer *das.RestfulDasServer
-	if serverConfig.EnableREST {
-		log.Info("Starting REST server", "addr", serverConfig.RESTAddr, "port", serverConfig.RESTPort, "revision", vcsRevision, "vcs.time", vcsTime)
+		listener, err := genericconf.UnixSocketListen(serverConfig.RPCUnixSocket)
+		if err != nil {
+			return err
+		}
+		rpcServer, err := das.StartDASRPCServerOnListener(ctx, listener, serverConfig.RPCServerTimeouts, nil, serverConfig.DataAvailability.StoreBounds, daReader, daWriter, daHealthChecker)
+		if err != nil {
+			return err
+		}
+		rpcServers = append(rpcServers, rpcServer)
+	}
 
-		restServer, err = das.NewRestfulDasServer(serverConfig.RESTAddr, serverConfig.RESTPort, serverConfig.RESTServerTimeouts, daReader, daHealthChecker)
+	var restServers []*das.RestfulDasServer
+	for _, listenerConfig := range restListeners {
+		log.Info("Starting REST server", "addr", listenerConfig.Addr, "port", listenerConfig.Port, "tls", tlsConfig != nil, "revision", vcsRevision, "vcs.time", vcsTime)
+
+		listener, err := genericconf.TLSListen(listenerConfig.Address(), tlsConfig)
 		if err != nil {
 			return err
 		}
+		restServer, err := das.NewRestfulDasServerOnListener(listener, serverConfig.RESTServerTimeouts, serverConfig.RESTCORS, ipLimiter, daReader, daHealthChecker, &dasServices)
+		if err != nil {
+			return err
+		}
+		restServers = append(restServers, restServer)
+		if serverConfig.RESTHTTP3 {
+			if err := restServer.StartHTTP3(listenerConfig.Address(), tlsConfig); err != nil {
+				return err
+			}
+		}
 	}
+	if serverConfig.RESTUnixSocket != "" {
+		log.Info("Starting REST server", "unix-socket", serverConfig.RESTUnixSocket, "revision", vcsRevision, "vcs.time", vcsTime)
 
-	<-sigint
-	dasLifecycleManager.StopAndWaitUntil(2 * time.Second)
+		listener, err := genericconf.UnixSocketListen(serverConfig.RESTUnixSocket)
+		if err != nil {
+			return err
+		}
+		restServer, err := das.NewRestfulDasServerOnListener(listener, serverConfig.RESTServerTimeouts, serverConfig.RESTCORS, nil, daReader, daHealthChecker, &dasServices)
+		if err != nil {
+			return err
+		}
+		restServers = append(restServers, restServer)
+	}
 
-	var err1, err2 error
-	if rpcServer != nil {
-		err1 = rpcServer.Shutdown(ctx)
+	var grpcServer *grpc.Server
+	if serverConfig.EnableGRPC {
+		log.Info("Starting gRPC server", "addr", serverConfig.GRPCAddr, "port", serverConfig.GRPCPort, "tls", tlsConfig != nil, "revision", vcsRevision, "vcs.time", vcsTime)
+
+		grpcServer, err = das.StartDASGRPCServer(ctx, serverConfig.GRPCAddr, serverConfig.GRPCPort, rpcTLSConfig, ipLimiter, serverConfig.DataAvailability.StoreBounds, daReader, daWriter)
+		if err != nil {
+			return err
+		}
+	}
+
+	var adminServer *das.AdminServer
+	if serverConfig.Admin.Enable {
+		log.Info("Starting admin server", "addr", serverConfig.Admin.Addr, "port", serverConfig.Admin.Port, "tls", tlsConfig != nil)
+
+		listener, err := genericconf.TLSListen(serverConfig.Admin.Address(), tlsConfig)
+		if err != nil {
+			return err
+		}
+		adminServer, err = das.NewAdminServerOnListener(ctx, listener, serverConfig.Admin.TokenAuth, das.AdminSources{
+			Backends:          dasServices.Backends,
+			HealthChecker:     daHealthChecker,
+			LogHandler:        glogger,
+			LogLevel:          serverConfig.LogLevel,
+			IPRateLimiter:     ipLimiter,
+			SignerRateLimiter: signerLimiter,
+		})
+		if err != nil {
+			return err
+		}
 	}
 
-	if restServer != nil {
-		err2 = restServer.Shutdown()
+	<-sigint
+	log.Info("Shutting down, draining in-flight requests", "timeout", serverConfig.ShutdownDrainTimeout)
+	drainCtx, cancelDrain := ctx, context.CancelFunc(func() {})
+	if serverConfig.ShutdownDrainTimeout > 0 {
+		drainCtx, cancelDrain = context.WithTimeout(ctx, serverConfig.ShutdownDrainTimeout)
 	}
+	defer cancelDrain()
+	shutdownErr := drainServers(drainCtx, rpcServers, restServers, grpcServer, adminServer)
+
+	// Queued stores and syncing get whatever is left of the timeout, and
+	// storage is flushed and closed once they're done.
+	var remaining time.Duration
+	if drainDeadline, ok := drainCtx.Deadline(); ok {
+		remaining = time.Until(drainDeadline)
+		if remaining < 2*time.Second {
+			remaining = 2 * time.Second
+		}
+	}
+	dasLifecycleManager.StopAndWaitUntil(remaining)
+	log.Info("Shut down")
 
-	if err1 != nil {
-		return err1
+	return shutdownErr
+}
+
+// drainServers stops the servers accepting requests, and waits for the
+// requests in flight to finish until drainCtx is done.
+func drainServers(drainCtx context.Context, rpcServers []*http.Server, restServers []*das.RestfulDasServer, grpcServer *grpc.Server, adminServer *das.AdminServer) error {
+	// The servers stop accepting requests at once, and the stores in flight
+	// finish before the storage they write to is closed.
+	var wg sync.WaitGroup
+	var errMutex sync.Mutex
+	var shutdownErr error
+	shutdown := func(name string, stop func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := stop(); err != nil {
+				log.Warn("Failed to shut down server gracefully", "server", name, "err", err)
+				errMutex.Lock()
+				shutdownErr = err
+				errMutex.Unlock()
+			}
+		}()
+	}
+	for _, rpcServer := range rpcServers {
+		rpcServer := rpcServer
+		shutdown("HTTP-RPC", func() error { return rpcServer.Shutdown(drainCtx) })
+	}
+	for _, restServer := range restServers {
+		shutdown("REST", restServer.Shutdown)
+	}
+	if grpcServer != nil {
+		shutdown("gRPC", func() error {
+			stopped := make(chan struct{})
+			go func() {
+				grpcServer.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+				return nil
+			case <-drainCtx.Done():
+				grpcServer.Stop()
+				return drainCtx.Err()
+			}
+		})
+	}
+	if adminServer != nil {
+		shutdown("admin", func() error { return adminServer.Shutdown(drainCtx) })
 	}
-	return err2
+	wg.Wait()
+	return shutdownErr
 }
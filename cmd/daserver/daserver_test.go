@@ -0,0 +1,77 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/das"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+// blockingDASWriter holds each store until it is released.
+type blockingDASWriter struct {
+	das.DataAvailabilityServiceWriter
+	started chan struct{}
+	release chan struct{}
+}
+
+func (w *blockingDASWriter) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	close(w.started)
+	<-w.release
+	return w.DataAvailabilityServiceWriter.Store(ctx, message, timeout, sig)
+}
+
+func TestShutdownDrainsInFlightStores(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	storageService := das.NewMemoryBackedStorageService(ctx)
+	_, privKey, err := blsSignatures.GenerateKeys()
+	testhelpers.RequireImpl(t, err)
+	localDas, err := das.NewSignAfterStoreDASWriterWithSeqInboxCaller(privKey, nil, storageService, "")
+	testhelpers.RequireImpl(t, err)
+	daWriter := &blockingDASWriter{
+		DataAvailabilityServiceWriter: localDas,
+		started:                       make(chan struct{}),
+		release:                       make(chan struct{}),
+	}
+	lis, err := net.Listen("tcp", "localhost:0")
+	testhelpers.RequireImpl(t, err)
+	rpcServer, err := das.StartDASRPCServerOnListener(ctx, lis, genericconf.HTTPServerTimeoutConfigDefault, nil, das.DefaultStoreBoundsConfig, storageService, daWriter, storageService)
+	testhelpers.RequireImpl(t, err)
+	client, err := das.NewDASRPCClient("http://" + lis.Addr().String())
+	testhelpers.RequireImpl(t, err)
+
+	storeErr := make(chan error, 1)
+	go func() {
+		_, err := client.Store(ctx, []byte("in flight"), uint64(time.Now().Add(time.Hour).Unix()), nil)
+		storeErr <- err
+	}()
+	<-daWriter.started
+
+	drainCtx, cancelDrain := context.WithTimeout(ctx, DefaultDAServerConfig.ShutdownDrainTimeout)
+	defer cancelDrain()
+	drained := make(chan error, 1)
+	go func() {
+		drained <- drainServers(drainCtx, []*http.Server{rpcServer}, nil, nil, nil)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case err := <-drained:
+		testhelpers.FailImpl(t, "shutdown didn't wait for the store in flight, err:", err)
+	default:
+	}
+	close(daWriter.release)
+
+	testhelpers.RequireImpl(t, <-storeErr)
+	testhelpers.RequireImpl(t, <-drained)
+}
@@ -0,0 +1,108 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+	"github.com/offchainlabs/nitro/das"
+)
+
+type RemoteSignerServerConfig struct {
+	Addr           string                              `koanf:"addr"`
+	Port           uint64                              `koanf:"port"`
+	Key            das.KeyConfig                       `koanf:"key"`
+	TokenAuth      das.TokenAuthConfig                 `koanf:"token-auth"`
+	TLS            genericconf.TLSConfig               `koanf:"tls"`
+	ServerTimeouts genericconf.HTTPServerTimeoutConfig `koanf:"server-timeouts"`
+	LogLevel       int                                 `koanf:"log-level"`
+	Conf           genericconf.ConfConfig              `koanf:"conf"`
+}
+
+func parseRemoteSigner(args []string) (*RemoteSignerServerConfig, error) {
+	f := flag.NewFlagSet("daserver remote-signer", flag.ContinueOnError)
+	f.String("addr", "localhost", "address to listen on for signing requests")
+	f.Uint64("port", 9878, "port to listen on for signing requests")
+	f.String("key.key-dir", "", fmt.Sprintf("the directory to read the bls keypair ('%s' and '%s') from", das.DefaultPubKeyFilename, das.DefaultPrivKeyFilename))
+	f.String("key.priv-key", "", "the base64 BLS private key to sign with")
+	f.String("key.passphrase-file", "", "file with the passphrase of the BLS key, if it's an encrypted keystore; if not specified the user is prompted for it")
+	das.VaultConfigAddOptions("key.vault", f)
+	das.PKCS11ConfigAddOptions("key.pkcs11", f)
+	das.TokenAuthConfigAddOptions("token-auth", f)
+	genericconf.TLSConfigAddOptions("tls", f)
+	genericconf.HTTPServerTimeoutConfigAddOptions("server-timeouts", f)
+	f.Int("log-level", int(log.LvlInfo), "log level; 1: ERROR, 2: WARN, 3: INFO, 4: DEBUG, 5: TRACE")
+	genericconf.ConfConfigAddOptions("conf", f)
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var config RemoteSignerServerConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	if config.Key.KeyDir == "" && config.Key.PrivKey == "" && config.Key.Vault.Address == "" && config.Key.PKCS11.Module == "" {
+		return nil, errors.New("--key.key-dir, --key.priv-key, --key.vault.address or --key.pkcs11.module must be set")
+	}
+	if config.TokenAuth.TokensFile == "" {
+		return nil, errors.New("--token-auth.tokens-file must be set, since anyone able to reach the signer could otherwise sign certificates with its key")
+	}
+	return &config, nil
+}
+
+// runRemoteSigner serves a BLS key for daservers configured with
+// --data-availability.key.remote-signer.url to sign certificates with, so the
+// key needn't be kept on the hosts serving the data.
+func runRemoteSigner(args []string) error {
+	config, err := parseRemoteSigner(args)
+	if err != nil {
+		return err
+	}
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(config.LogLevel))
+	log.Root().SetHandler(glogger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signer, err := config.Key.BLSSigner(ctx)
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := config.TLS.ServerTLSConfig()
+	if err != nil {
+		return err
+	}
+	if tlsConfig == nil {
+		log.Warn("Remote signer is serving without TLS, so bearer tokens are sent in the clear")
+	}
+
+	addr := fmt.Sprintf("%s:%d", config.Addr, config.Port)
+	listener, err := genericconf.TLSListen(addr, tlsConfig)
+	if err != nil {
+		return err
+	}
+	log.Info("Starting remote signer", "addr", addr, "tls", tlsConfig != nil)
+	if _, err := das.StartBLSSignerServerOnListener(ctx, listener, config.ServerTimeouts, signer, config.TokenAuth); err != nil {
+		return err
+	}
+
+	sigint := make(chan os.Signal, 1)
+	signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
+	<-sigint
+	log.Info("Shutting down remote signer")
+	return nil
+}
@@ -18,24 +18,29 @@ import (
 
 	koanfjson "github.com/knadh/koanf/parsers/json"
 	flag "github.com/spf13/pflag"
+	"golang.org/x/term"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/blsSignatures"
 	"github.com/offchainlabs/nitro/cmd/genericconf"
 	"github.com/offchainlabs/nitro/cmd/util"
 
 	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
 	"github.com/offchainlabs/nitro/das"
 	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
+	"github.com/offchainlabs/nitro/solgen/go/upgrade_executorgen"
 	"github.com/offchainlabs/nitro/util/signature"
 )
 
 func main() {
 	args := os.Args
 	if len(args) < 2 {
-		panic("Usage: datool [client|keygen|generatehash|dumpkeyset] ...")
+		panic("Usage: datool [client|keygen|generatehash|dumpkeyset|keyset|dkg|sync] ...")
 	}
 
 	var err error
@@ -48,8 +53,14 @@ func main() {
 		err = generateHash(args[2])
 	case "dumpkeyset":
 		err = dumpKeyset(args[2:])
+	case "keyset":
+		err = startKeyset(args[2:])
+	case "dkg":
+		err = startDKG(args[2:])
+	case "sync":
+		err = startSync(args[2:])
 	default:
-		panic(fmt.Sprintf("Unknown tool '%s' specified, valid tools are 'client', 'keygen', 'generatehash'", args[1]))
+		panic(fmt.Sprintf("Unknown tool '%s' specified, valid tools are 'client', 'keygen', 'generatehash', 'dumpkeyset', 'keyset', 'dkg', 'sync'", args[1]))
 	}
 	if err != nil {
 		panic(err)
@@ -257,6 +268,10 @@ type KeyGenConfig struct {
 	ECDSA bool `koanf:"ecdsa"`
 	// Wallet mode.
 	Wallet bool `koanf:"wallet"`
+	// Encrypted BLS keystore mode.
+	Encrypt        bool   `koanf:"encrypt"`
+	PassphraseFile string `koanf:"passphrase-file"`
+	KDF            string `koanf:"kdf"`
 }
 
 func parseKeyGenConfig(args []string) (*KeyGenConfig, error) {
@@ -264,6 +279,9 @@ func parseKeyGenConfig(args []string) (*KeyGenConfig, error) {
 	f.String("dir", "", "the directory to generate the keys in")
 	f.Bool("ecdsa", false, "generate an ECDSA keypair instead of BLS")
 	f.Bool("wallet", false, "generate the ECDSA keypair in a wallet file")
+	f.Bool("encrypt", false, "write the BLS private key to a keystore encrypted with a passphrase, which the daserver reads with --data-availability.key.passphrase-file or prompts for")
+	f.String("passphrase-file", "", "file with the passphrase to encrypt the BLS private key with; if not specified the user is prompted for it")
+	f.String("kdf", das.BLSKeystoreKDFScrypt, fmt.Sprintf("function deriving the encryption key from the passphrase, %s or %s", das.BLSKeystoreKDFScrypt, das.BLSKeystoreKDFArgon2id))
 
 	k, err := confighelpers.BeginCommonParse(f, args)
 	if err != nil {
@@ -283,7 +301,17 @@ func startKeyGen(args []string) error {
 		return err
 	}
 
-	if !config.ECDSA {
+	if !config.ECDSA && config.Encrypt {
+		passphrase, err := newKeystorePassphrase(config.PassphraseFile)
+		if err != nil {
+			return err
+		}
+		pubKey, privKey, err := blsSignatures.GenerateKeys()
+		if err != nil {
+			return err
+		}
+		return das.StoreEncryptedKeys(config.Dir, pubKey, privKey, passphrase, config.KDF)
+	} else if !config.ECDSA {
 		_, _, err = das.GenerateAndStoreKeys(config.Dir)
 		if err != nil {
 			return err
@@ -307,6 +335,37 @@ func startKeyGen(args []string) error {
 	}
 }
 
+// newKeystorePassphrase reads the passphrase to encrypt a new keystore with
+// from passphraseFile, or prompts for it twice.
+func newKeystorePassphrase(passphraseFile string) (string, error) {
+	if passphraseFile != "" {
+		passphrase, err := os.ReadFile(passphraseFile)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(passphrase), "\r\n"), nil
+	}
+	fmt.Print("Passphrase to encrypt the BLS key with: ")
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+	fmt.Print("Repeat passphrase: ")
+	repeated, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+	if !bytes.Equal(passphrase, repeated) {
+		return "", errors.New("passphrases don't match")
+	}
+	if len(passphrase) == 0 {
+		return "", errors.New("passphrase must not be empty")
+	}
+	return string(passphrase), nil
+}
+
 func generateHash(message string) error {
 	fmt.Printf("Hex Encoded Data Hash: %s\n", hexutil.Encode(dastree.HashBytes([]byte(message))))
 	return nil
@@ -341,8 +400,8 @@ func parseDumpKeyset(args []string) (*DumpKeysetConfig, error) {
 	if config.Keyset.AssumedHonest == 0 {
 		return nil, errors.New("--keyset.assumed-honest must be set")
 	}
-	if config.Keyset.Backends == "" {
-		return nil, errors.New("--keyset.backends must be set")
+	if config.Keyset.Backends == "" && config.Keyset.BackendsFile == "" {
+		return nil, errors.New("--keyset.backends or --keyset.backends-file must be set")
 	}
 
 	return &config, nil
@@ -365,6 +424,13 @@ func dumpKeyset(args []string) error {
 	if err != nil {
 		return err
 	}
+	services, err = das.OrderServices(services, config.Keyset.MemberOrder)
+	if err != nil {
+		return err
+	}
+	if config.Keyset.CommitteeSize != 0 && len(services) != config.Keyset.CommitteeSize {
+		return fmt.Errorf("--keyset.committee-size is %d but %d backends are configured", config.Keyset.CommitteeSize, len(services))
+	}
 
 	keysetHash, keysetBytes, err := das.KeysetHashFromServices(services, uint64(config.Keyset.AssumedHonest))
 	if err != nil {
@@ -376,3 +442,515 @@ func dumpKeyset(args []string) error {
 
 	return err
 }
+
+// datool keyset ...
+
+func startKeyset(args []string) error {
+	if len(args) == 0 {
+		return errors.New("datool keyset requires an argument, valid arguments are 'create', 'dump' and 'diff'")
+	}
+	switch strings.ToLower(args[0]) {
+	case "create":
+		return createKeyset(args[1:])
+	case "dump":
+		return startKeysetDump(args[1:])
+	case "diff":
+		return startKeysetDiff(args[1:])
+	}
+	return fmt.Errorf("datool keyset '%s' not supported, valid arguments are 'create', 'dump' and 'diff'", args[0])
+}
+
+// datool keyset create
+
+type KeysetCreateConfig struct {
+	PubKeys               []string               `koanf:"pubkeys"`
+	PubKeyFiles           []string               `koanf:"pubkey-files"`
+	AssumedHonest         uint64                 `koanf:"assumed-honest"`
+	Calldata              bool                   `koanf:"calldata"`
+	UpgradeExecutor       bool                   `koanf:"upgrade-executor"`
+	SequencerInboxAddress string                 `koanf:"sequencer-inbox-address"`
+	Conf                  genericconf.ConfConfig `koanf:"conf"`
+}
+
+func parseKeysetCreateConfig(args []string) (*KeysetCreateConfig, error) {
+	f := flag.NewFlagSet("datool keyset create", flag.ContinueOnError)
+	f.StringSlice("pubkeys", []string{}, "base64-encoded BLS public keys of the committee members, in keyset order")
+	f.StringSlice("pubkey-files", []string{}, "files containing base64-encoded BLS public keys of the committee members, such as das_bls.pub generated by keygen, in keyset order after any pubkeys")
+	f.Uint64("assumed-honest", 0, "number of committee members assumed to be honest (H)")
+	f.Bool("calldata", false, "also output the calldata of the SequencerInbox setValidKeyset call that registers the keyset")
+	f.Bool("upgrade-executor", false, "also output the calldata of the UpgradeExecutor executeCall that makes the setValidKeyset call, for rollups owned by an UpgradeExecutor; requires sequencer-inbox-address")
+	f.String("sequencer-inbox-address", "", "parent chain address of the SequencerInbox contract, the target of the UpgradeExecutor call")
+	genericconf.ConfConfigAddOptions("conf", f)
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var config KeysetCreateConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	numKeys := uint64(len(config.PubKeys) + len(config.PubKeyFiles))
+	if numKeys == 0 {
+		return nil, errors.New("--pubkeys or --pubkey-files must be set")
+	}
+	if config.AssumedHonest == 0 || config.AssumedHonest > numKeys {
+		return nil, fmt.Errorf("--assumed-honest must be between 1 and the number of public keys (%d)", numKeys)
+	}
+	if config.UpgradeExecutor && !common.IsHexAddress(config.SequencerInboxAddress) {
+		return nil, errors.New("--sequencer-inbox-address must be set to an address along with --upgrade-executor")
+	}
+	return &config, nil
+}
+
+func createKeyset(args []string) error {
+	config, err := parseKeysetCreateConfig(args)
+	if err != nil {
+		return err
+	}
+
+	var pubKeys []blsSignatures.PublicKey
+	for i, encoded := range config.PubKeys {
+		pubKey, err := das.DecodeBase64BLSPublicKey([]byte(encoded))
+		if err != nil {
+			return fmt.Errorf("invalid public key %d: %w", i, err)
+		}
+		pubKeys = append(pubKeys, *pubKey)
+	}
+	for _, file := range config.PubKeyFiles {
+		pubKey, err := das.ReadPubKeyFromFile(file)
+		if err != nil {
+			return fmt.Errorf("invalid public key file %s: %w", file, err)
+		}
+		pubKeys = append(pubKeys, *pubKey)
+	}
+	seen := make(map[string]bool)
+	for i, pubKey := range pubKeys {
+		key := string(blsSignatures.PublicKeyToBytes(pubKey))
+		if seen[key] {
+			return fmt.Errorf("public key %d is a duplicate", i)
+		}
+		seen[key] = true
+	}
+
+	keyset := &arbstate.DataAvailabilityKeyset{
+		AssumedHonest: config.AssumedHonest,
+		PubKeys:       pubKeys,
+	}
+	keysetBuf := bytes.NewBuffer([]byte{})
+	if err := keyset.Serialize(keysetBuf); err != nil {
+		return err
+	}
+	keysetHash, err := keyset.Hash()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Keyset: %s\n", hexutil.Encode(keysetBuf.Bytes()))
+	fmt.Printf("KeysetHash: %s\n", hexutil.Encode(keysetHash[:]))
+
+	if config.Calldata || config.UpgradeExecutor {
+		seqInboxABI, err := bridgegen.SequencerInboxMetaData.GetAbi()
+		if err != nil {
+			return err
+		}
+		calldata, err := seqInboxABI.Pack("setValidKeyset", keysetBuf.Bytes())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("SetValidKeysetCalldata: %s\n", hexutil.Encode(calldata))
+
+		if config.UpgradeExecutor {
+			upgradeExecutorABI, err := upgrade_executorgen.UpgradeExecutorMetaData.GetAbi()
+			if err != nil {
+				return err
+			}
+			executeCalldata, err := upgradeExecutorABI.Pack("executeCall", common.HexToAddress(config.SequencerInboxAddress), calldata)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("UpgradeExecutorCalldata: %s\n", hexutil.Encode(executeCalldata))
+		}
+	}
+	return nil
+}
+
+// KeysetSourceConfig is where a keyset to inspect is read from: its
+// hex-encoded bytes, a file containing them, or the parent chain, by hash.
+type KeysetSourceConfig struct {
+	Keyset     string `koanf:"keyset"`
+	KeysetFile string `koanf:"keyset-file"`
+	KeysetHash string `koanf:"keyset-hash"`
+}
+
+func KeysetSourceConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+"keyset", "", "hex-encoded serialized keyset")
+	f.String(prefix+"keyset-file", "", "file containing the hex-encoded serialized keyset")
+	f.String(prefix+"keyset-hash", "", "hash of a keyset to fetch from the SetValidKeyset event on the parent chain; requires parent-chain-node-url and sequencer-inbox-address")
+}
+
+func addKeysetChainOptions(f *flag.FlagSet) {
+	f.String("parent-chain-node-url", "", "URL of a parent chain node, to fetch keysets by hash and check whether they are valid")
+	f.String("sequencer-inbox-address", "", "parent chain address of the SequencerInbox contract")
+}
+
+// keysetFromChain fetches keysets from the parent chain, if configured.
+type keysetFromChain struct {
+	reader   das.DataAvailabilityServiceReader
+	seqInbox *bridgegen.SequencerInboxCaller
+}
+
+func newKeysetFromChain(ctx context.Context, parentChainNodeURL string, sequencerInboxAddress string) (*keysetFromChain, error) {
+	if parentChainNodeURL == "" || sequencerInboxAddress == "" {
+		return nil, nil
+	}
+	if !common.IsHexAddress(sequencerInboxAddress) {
+		return nil, fmt.Errorf("invalid --sequencer-inbox-address %s", sequencerInboxAddress)
+	}
+	seqInboxAddress := common.HexToAddress(sequencerInboxAddress)
+	l1Client, err := das.GetL1Client(ctx, 1, parentChainNodeURL)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := das.NewChainFetchReader(das.NewMemoryBackedStorageService(ctx), l1Client, seqInboxAddress)
+	if err != nil {
+		return nil, err
+	}
+	seqInbox, err := bridgegen.NewSequencerInboxCaller(seqInboxAddress, l1Client)
+	if err != nil {
+		return nil, err
+	}
+	return &keysetFromChain{reader: reader, seqInbox: seqInbox}, nil
+}
+
+func readKeyset(ctx context.Context, source KeysetSourceConfig, chain *keysetFromChain, name string) (*arbstate.DataAvailabilityKeyset, error) {
+	var keysetHex string
+	switch {
+	case source.Keyset != "":
+		keysetHex = source.Keyset
+	case source.KeysetFile != "":
+		contents, err := os.ReadFile(source.KeysetFile)
+		if err != nil {
+			return nil, err
+		}
+		keysetHex = strings.TrimSpace(string(contents))
+	case source.KeysetHash != "":
+		if chain == nil {
+			return nil, fmt.Errorf("--parent-chain-node-url and --sequencer-inbox-address must be set to fetch %s by hash", name)
+		}
+		keysetBytes, err := chain.reader.GetByHash(ctx, common.HexToHash(source.KeysetHash))
+		if err != nil {
+			return nil, fmt.Errorf("couldn't fetch %s %s from the parent chain: %w", name, source.KeysetHash, err)
+		}
+		return arbstate.DeserializeKeyset(bytes.NewReader(keysetBytes), false)
+	default:
+		return nil, fmt.Errorf("one of the %s keyset, keyset-file or keyset-hash options must be set", name)
+	}
+	keysetBytes, err := hexutil.Decode(keysetHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return arbstate.DeserializeKeyset(bytes.NewReader(keysetBytes), false)
+}
+
+func printKeysetValidity(ctx context.Context, chain *keysetFromChain, keysetHash common.Hash) error {
+	if chain == nil {
+		return nil
+	}
+	valid, err := chain.seqInbox.IsValidKeysetHash(&bind.CallOpts{Context: ctx}, keysetHash)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("ValidInSequencerInbox: %v\n", valid)
+	return nil
+}
+
+func encodePubKey(pubKey blsSignatures.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(blsSignatures.PublicKeyToBytes(pubKey))
+}
+
+// datool keyset dump
+
+type KeysetDumpConfig struct {
+	Keyset                string                 `koanf:"keyset"`
+	KeysetFile            string                 `koanf:"keyset-file"`
+	KeysetHash            string                 `koanf:"keyset-hash"`
+	ParentChainNodeURL    string                 `koanf:"parent-chain-node-url"`
+	SequencerInboxAddress string                 `koanf:"sequencer-inbox-address"`
+	Conf                  genericconf.ConfConfig `koanf:"conf"`
+}
+
+func startKeysetDump(args []string) error {
+	f := flag.NewFlagSet("datool keyset dump", flag.ContinueOnError)
+	KeysetSourceConfigAddOptions("", f)
+	addKeysetChainOptions(f)
+	genericconf.ConfConfigAddOptions("conf", f)
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return err
+	}
+	var config KeysetDumpConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	chain, err := newKeysetFromChain(ctx, config.ParentChainNodeURL, config.SequencerInboxAddress)
+	if err != nil {
+		return err
+	}
+	source := KeysetSourceConfig{
+		Keyset:     config.Keyset,
+		KeysetFile: config.KeysetFile,
+		KeysetHash: config.KeysetHash,
+	}
+	keyset, err := readKeyset(ctx, source, chain, "keyset")
+	if err != nil {
+		return err
+	}
+	keysetHash, err := keyset.Hash()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("KeysetHash: %s\n", keysetHash.Hex())
+	fmt.Printf("AssumedHonest: %d\n", keyset.AssumedHonest)
+	fmt.Printf("Members: %d\n", len(keyset.PubKeys))
+	for i, pubKey := range keyset.PubKeys {
+		fmt.Printf("  %d: %s\n", i, encodePubKey(pubKey))
+	}
+	return printKeysetValidity(ctx, chain, keysetHash)
+}
+
+// datool keyset diff
+
+type KeysetDiffConfig struct {
+	Old                   KeysetSourceConfig     `koanf:"old"`
+	New                   KeysetSourceConfig     `koanf:"new"`
+	ParentChainNodeURL    string                 `koanf:"parent-chain-node-url"`
+	SequencerInboxAddress string                 `koanf:"sequencer-inbox-address"`
+	Conf                  genericconf.ConfConfig `koanf:"conf"`
+}
+
+func startKeysetDiff(args []string) error {
+	f := flag.NewFlagSet("datool keyset diff", flag.ContinueOnError)
+	KeysetSourceConfigAddOptions("old.", f)
+	KeysetSourceConfigAddOptions("new.", f)
+	addKeysetChainOptions(f)
+	genericconf.ConfConfigAddOptions("conf", f)
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return err
+	}
+	var config KeysetDiffConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	chain, err := newKeysetFromChain(ctx, config.ParentChainNodeURL, config.SequencerInboxAddress)
+	if err != nil {
+		return err
+	}
+	oldKeyset, err := readKeyset(ctx, config.Old, chain, "old keyset")
+	if err != nil {
+		return err
+	}
+	newKeyset, err := readKeyset(ctx, config.New, chain, "new keyset")
+	if err != nil {
+		return err
+	}
+	oldHash, err := oldKeyset.Hash()
+	if err != nil {
+		return err
+	}
+	newHash, err := newKeyset.Hash()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Old KeysetHash: %s\n", oldHash.Hex())
+	fmt.Printf("New KeysetHash: %s\n", newHash.Hex())
+	if oldHash == newHash {
+		fmt.Println("Keysets are identical")
+		return nil
+	}
+	if oldKeyset.AssumedHonest != newKeyset.AssumedHonest {
+		fmt.Printf("AssumedHonest: %d -> %d\n", oldKeyset.AssumedHonest, newKeyset.AssumedHonest)
+	}
+	if len(oldKeyset.PubKeys) != len(newKeyset.PubKeys) {
+		fmt.Printf("Members: %d -> %d\n", len(oldKeyset.PubKeys), len(newKeyset.PubKeys))
+	}
+
+	// Members are matched by public key, so a member whose index changed
+	// signs with a different signersMask bit.
+	oldIndexes := make(map[string]int)
+	for i, pubKey := range oldKeyset.PubKeys {
+		oldIndexes[encodePubKey(pubKey)] = i
+	}
+	newIndexes := make(map[string]int)
+	for i, pubKey := range newKeyset.PubKeys {
+		newIndexes[encodePubKey(pubKey)] = i
+	}
+	for i, pubKey := range oldKeyset.PubKeys {
+		encoded := encodePubKey(pubKey)
+		if _, ok := newIndexes[encoded]; !ok {
+			fmt.Printf("- %d: %s\n", i, encoded)
+		}
+	}
+	for i, pubKey := range newKeyset.PubKeys {
+		encoded := encodePubKey(pubKey)
+		oldIndex, ok := oldIndexes[encoded]
+		if !ok {
+			fmt.Printf("+ %d: %s\n", i, encoded)
+		} else if oldIndex != i {
+			fmt.Printf("~ %d -> %d: %s\n", oldIndex, i, encoded)
+		}
+	}
+
+	if chain != nil {
+		fmt.Print("Old keyset ")
+		if err := printKeysetValidity(ctx, chain, oldHash); err != nil {
+			return err
+		}
+		fmt.Print("New keyset ")
+		return printKeysetValidity(ctx, chain, newHash)
+	}
+	return nil
+}
+
+// datool sync
+
+type SyncConfig struct {
+	URLs             []string                   `koanf:"urls"`
+	FromBlock        uint64                     `koanf:"from-block"`
+	ToBlock          uint64                     `koanf:"to-block"`
+	BlocksPerRead    uint64                     `koanf:"parent-chain-blocks-per-read"`
+	DataAvailability das.DataAvailabilityConfig `koanf:"data-availability"`
+	Conf             genericconf.ConfConfig     `koanf:"conf"`
+}
+
+func parseSyncConfig(args []string) (*SyncConfig, error) {
+	f := flag.NewFlagSet("datool sync", flag.ContinueOnError)
+	f.StringSlice("urls", []string{}, "list of REST URLs of existing committee members to backfill from, including 'http://' or 'https://' prefixes and port numbers")
+	f.Uint64("from-block", 0, "if data-availability.parent-chain-node-url is set, first L1 block to backfill the batches posted in")
+	f.Uint64("to-block", 0, "if data-availability.parent-chain-node-url is set, last L1 block to backfill the batches posted in (0 for the latest block)")
+	f.Uint64("parent-chain-blocks-per-read", 1000, "max L1 blocks to read logs for per request")
+	das.DataAvailabilityConfigAddDaserverOptions("data-availability", f)
+	genericconf.ConfConfigAddOptions("conf", f)
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var config SyncConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+
+	if config.Conf.Dump {
+		c, err := k.Marshal(koanfjson.Parser())
+		if err != nil {
+			return nil, fmt.Errorf("unable to marshal config file to JSON: %w", err)
+		}
+
+		fmt.Println(string(c))
+		os.Exit(0)
+	}
+
+	if len(config.URLs) == 0 {
+		return nil, errors.New("--urls must be set")
+	}
+	return &config, nil
+}
+
+// startSync backfills a new committee member's storage from existing members.
+// With an L1 node, the data for the batches posted in the given block range
+// is recovered; otherwise every key in the existing members' inventories is
+// copied, which requires them to have anti-entropy enabled. Data is checked
+// against its hash before it is stored either way.
+func startSync(args []string) error {
+	config, err := parseSyncConfig(args)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var syncFromStorageServices []*das.IterableStorageService
+	var syncToStorageServices []das.StorageService
+	storageService, lifecycleManager, err := das.CreatePersistentStorageService(ctx, &config.DataAvailability, &syncFromStorageServices, &syncToStorageServices)
+	if err != nil {
+		return err
+	}
+	if storageService == nil {
+		return errors.New("a --data-availability storage backend must be enabled to backfill")
+	}
+	defer lifecycleManager.StopAndWaitUntil(2 * time.Second)
+
+	var fetched, failed int
+	l1URL := config.DataAvailability.ParentChainNodeURL
+	if l1URL != "" && l1URL != "none" {
+		seqInboxAddress, err := das.OptionalAddressFromString(config.DataAvailability.SequencerInboxAddress)
+		if err != nil {
+			return err
+		}
+		if seqInboxAddress == nil {
+			return errors.New("--data-availability.sequencer-inbox-address must be set along with --data-availability.parent-chain-node-url")
+		}
+		l1Client, err := das.GetL1Client(ctx, config.DataAvailability.ParentChainConnectionAttempts, l1URL)
+		if err != nil {
+			return err
+		}
+		toBlock := config.ToBlock
+		if toBlock == 0 {
+			toBlock, err = l1Client.BlockNumber(ctx)
+			if err != nil {
+				return err
+			}
+		}
+		restAggConfig := das.DefaultRestfulClientAggregatorConfig
+		restAggConfig.Enable = true
+		restAggConfig.Urls = config.URLs
+		restAgg, err := das.NewRestfulClientAggregator(ctx, &restAggConfig)
+		if err != nil {
+			return err
+		}
+		restAgg.Start(ctx)
+		defer restAgg.StopAndWait()
+
+		fmt.Printf("Backfilling batches posted in L1 blocks %d to %d\n", config.FromBlock, toBlock)
+		fetched, failed, err = das.BackfillFromL1(ctx, storageService, restAgg, l1Client, *seqInboxAddress, config.FromBlock, toBlock, config.BlocksPerRead)
+		if err != nil {
+			return err
+		}
+	} else {
+		var peers []*das.RestfulDasClient
+		for _, url := range config.URLs {
+			peer, err := das.NewRestfulDasClientFromURL(url)
+			if err != nil {
+				return err
+			}
+			peers = append(peers, peer)
+		}
+		fmt.Printf("Backfilling the inventories of %d committee members\n", len(peers))
+		fetched, failed, err = das.BackfillFromInventories(ctx, storageService, peers)
+		if err != nil {
+			return err
+		}
+	}
+	if err := storageService.Sync(ctx); err != nil {
+		return err
+	}
+
+	fmt.Printf("Fetched: %d\n", fetched)
+	fmt.Printf("Failed: %d\n", failed)
+	if failed > 0 {
+		return fmt.Errorf("failed to backfill %d entries", failed)
+	}
+	return nil
+}
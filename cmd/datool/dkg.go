@@ -0,0 +1,323 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+
+	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+	"github.com/offchainlabs/nitro/das"
+)
+
+// The key generation ceremony has three steps, run by each participant with
+// a shared directory of the ceremony's public files:
+//
+//  1. deal: write commitments to a random polynomial to the shared
+//     directory, and a secret share for each participant, which must be
+//     sent to that participant privately.
+//  2. combine: once every participant's commitments are in the shared
+//     directory and the shares dealt to this participant have been
+//     received, verify the shares and combine them into this participant's
+//     key share, and write its share of the group public key's validity
+//     proof to the shared directory.
+//  3. finalize: once a threshold of proof shares are in the shared
+//     directory, combine them into the group public key, which can be used
+//     in a keyset like any other member's. Anyone can run this step.
+
+func startDKG(args []string) error {
+	if len(args) == 0 {
+		return errors.New("datool dkg requires an argument, valid arguments are 'deal', 'combine' and 'finalize'")
+	}
+	switch strings.ToLower(args[0]) {
+	case "deal":
+		return dkgDeal(args[1:])
+	case "combine":
+		return dkgCombine(args[1:])
+	case "finalize":
+		return dkgFinalize(args[1:])
+	}
+	return fmt.Errorf("datool dkg '%s' not supported, valid arguments are 'deal', 'combine' and 'finalize'", args[0])
+}
+
+type dkgCommitmentsFile struct {
+	Index        uint64   `json:"index"`
+	Threshold    int      `json:"threshold"`
+	Participants int      `json:"participants"`
+	Commitments  []string `json:"commitments"`
+}
+
+type dkgShareFile struct {
+	From  uint64 `json:"from"`
+	To    uint64 `json:"to"`
+	Share string `json:"share"`
+}
+
+type dkgProofShareFile struct {
+	Index      uint64 `json:"index"`
+	ProofShare string `json:"proofShare"`
+}
+
+func dkgCommitmentsPath(dir string, index uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("dkg_commitments_%d.json", index))
+}
+
+func dkgSharePath(dir string, from, to uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("dkg_share_%d_for_%d.json", from, to))
+}
+
+func dkgProofSharePath(dir string, index uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("dkg_proof_share_%d.json", index))
+}
+
+func writeJSONFile(path string, v interface{}, perm os.FileMode) error {
+	encoded, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, encoded, perm)
+}
+
+func readJSONFile(path string, v interface{}) error {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(contents, v)
+}
+
+// readDKGCommitments reads every participant's commitments from dir, checking
+// they agree on the threshold and number of participants.
+func readDKGCommitments(dir string, threshold int, participants int) ([][]*bls12381.PointG2, error) {
+	var commitments [][]*bls12381.PointG2
+	for i := uint64(1); i <= uint64(participants); i++ {
+		var file dkgCommitmentsFile
+		if err := readJSONFile(dkgCommitmentsPath(dir, i), &file); err != nil {
+			return nil, fmt.Errorf("couldn't read participant %d's commitments: %w", i, err)
+		}
+		if file.Index != i || file.Threshold != threshold || file.Participants != participants || len(file.Commitments) != threshold {
+			return nil, fmt.Errorf("participant %d's commitments are for a different ceremony", i)
+		}
+		var points []*bls12381.PointG2
+		for _, encoded := range file.Commitments {
+			pointBytes, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("invalid commitment from participant %d: %w", i, err)
+			}
+			point, err := blsSignatures.G2PointFromBytes(pointBytes)
+			if err != nil {
+				return nil, fmt.Errorf("invalid commitment from participant %d: %w", i, err)
+			}
+			points = append(points, point)
+		}
+		commitments = append(commitments, points)
+	}
+	return commitments, nil
+}
+
+type DKGConfig struct {
+	Dir          string `koanf:"dir"`
+	KeyDir       string `koanf:"key-dir"`
+	Index        uint64 `koanf:"index"`
+	Threshold    int    `koanf:"threshold"`
+	Participants int    `koanf:"participants"`
+}
+
+func parseDKGConfig(name string, args []string) (*DKGConfig, error) {
+	f := flag.NewFlagSet("datool dkg "+name, flag.ContinueOnError)
+	f.String("dir", "", "directory of the ceremony's public files, shared between the participants")
+	f.String("key-dir", "", "directory to write this participant's secret shares (deal) or key share (combine) to, and read the shares dealt to it from (combine)")
+	f.Uint64("index", 0, "this participant's index, from 1 to the number of participants")
+	f.Int("threshold", 0, "number of participants whose signatures are needed to sign for the group")
+	f.Int("participants", 0, "number of participants")
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+	var config DKGConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	if config.Dir == "" {
+		return nil, errors.New("--dir must be set")
+	}
+	if config.Threshold < 1 || config.Threshold > config.Participants {
+		return nil, errors.New("--threshold must be between 1 and --participants")
+	}
+	if name != "finalize" {
+		if config.KeyDir == "" {
+			return nil, errors.New("--key-dir must be set")
+		}
+		if config.Index < 1 || config.Index > uint64(config.Participants) {
+			return nil, errors.New("--index must be between 1 and --participants")
+		}
+	}
+	return &config, nil
+}
+
+// datool dkg deal
+
+func dkgDeal(args []string) error {
+	config, err := parseDKGConfig("deal", args)
+	if err != nil {
+		return err
+	}
+	dealing, err := blsSignatures.Deal(config.Threshold, config.Participants)
+	if err != nil {
+		return err
+	}
+
+	commitments := dkgCommitmentsFile{
+		Index:        config.Index,
+		Threshold:    config.Threshold,
+		Participants: config.Participants,
+	}
+	for _, point := range dealing.Commitments {
+		commitments.Commitments = append(commitments.Commitments, base64.StdEncoding.EncodeToString(blsSignatures.G2PointToBytes(point)))
+	}
+	if err := writeJSONFile(dkgCommitmentsPath(config.Dir, config.Index), commitments, 0o644); err != nil {
+		return err
+	}
+	for j, share := range dealing.Shares {
+		to := uint64(j + 1)
+		shareFile := dkgShareFile{
+			From:  config.Index,
+			To:    to,
+			Share: base64.StdEncoding.EncodeToString(blsSignatures.PrivateKeyToBytes(share)),
+		}
+		if err := writeJSONFile(dkgSharePath(config.KeyDir, config.Index, to), shareFile, 0o600); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Wrote commitments to %s\n", dkgCommitmentsPath(config.Dir, config.Index))
+	fmt.Printf("Wrote shares to %s; send each participant the share for it privately, and keep the one for this participant\n", config.KeyDir)
+	return nil
+}
+
+// datool dkg combine
+
+func dkgCombine(args []string) error {
+	config, err := parseDKGConfig("combine", args)
+	if err != nil {
+		return err
+	}
+	commitments, err := readDKGCommitments(config.Dir, config.Threshold, config.Participants)
+	if err != nil {
+		return err
+	}
+
+	var shares []blsSignatures.PrivateKey
+	for i := uint64(1); i <= uint64(config.Participants); i++ {
+		var file dkgShareFile
+		if err := readJSONFile(dkgSharePath(config.KeyDir, i, config.Index), &file); err != nil {
+			return fmt.Errorf("couldn't read the share dealt by participant %d: %w", i, err)
+		}
+		shareBytes, err := base64.StdEncoding.DecodeString(file.Share)
+		if err != nil || file.From != i || file.To != config.Index {
+			return fmt.Errorf("invalid share dealt by participant %d", i)
+		}
+		share := blsSignatures.PrivateKey(new(big.Int).SetBytes(shareBytes))
+		if !blsSignatures.VerifyShare(commitments[i-1], config.Index, share) {
+			return fmt.Errorf("the share dealt by participant %d doesn't match its commitments; participant %d must deal again", i, i)
+		}
+		shares = append(shares, share)
+	}
+	keyShare := blsSignatures.CombineKeyShares(shares)
+	pubKeyShare, err := blsSignatures.PublicKeyFromPrivateKey(keyShare)
+	if err != nil {
+		return err
+	}
+	if err := das.StoreKeys(config.KeyDir, pubKeyShare, keyShare); err != nil {
+		return err
+	}
+
+	proofShare, err := blsSignatures.KeyValidityProofShare(blsSignatures.GroupPublicKeyPoint(commitments), keyShare)
+	if err != nil {
+		return err
+	}
+	proofShareFile := dkgProofShareFile{
+		Index:      config.Index,
+		ProofShare: base64.StdEncoding.EncodeToString(blsSignatures.SignatureToBytes(proofShare)),
+	}
+	if err := writeJSONFile(dkgProofSharePath(config.Dir, config.Index), proofShareFile, 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote key share to %s\n", filepath.Join(config.KeyDir, das.DefaultPrivKeyFilename))
+	fmt.Printf("Wrote proof share to %s\n", dkgProofSharePath(config.Dir, config.Index))
+	return nil
+}
+
+// datool dkg finalize
+
+func dkgFinalize(args []string) error {
+	config, err := parseDKGConfig("finalize", args)
+	if err != nil {
+		return err
+	}
+	commitments, err := readDKGCommitments(config.Dir, config.Threshold, config.Participants)
+	if err != nil {
+		return err
+	}
+	groupKey := blsSignatures.GroupPublicKeyPoint(commitments)
+
+	proofShares := make(map[uint64]blsSignatures.Signature)
+	for j := uint64(1); j <= uint64(config.Participants) && len(proofShares) < config.Threshold; j++ {
+		var file dkgProofShareFile
+		if err := readJSONFile(dkgProofSharePath(config.Dir, j), &file); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return err
+		}
+		proofShareBytes, err := base64.StdEncoding.DecodeString(file.ProofShare)
+		if err != nil || file.Index != j {
+			return fmt.Errorf("invalid proof share from participant %d", j)
+		}
+		proofShare, err := blsSignatures.SignatureFromBytes(proofShareBytes)
+		if err != nil {
+			return fmt.Errorf("invalid proof share from participant %d: %w", j, err)
+		}
+		valid, err := blsSignatures.VerifyKeyValidityProofShare(proofShare, groupKey, blsSignatures.PublicKeyShare(commitments, j))
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return fmt.Errorf("proof share from participant %d doesn't match its key share", j)
+		}
+		proofShares[j] = proofShare
+	}
+	if len(proofShares) < config.Threshold {
+		return fmt.Errorf("only %d of the %d proof shares needed are in %s", len(proofShares), config.Threshold, config.Dir)
+	}
+	proof, err := blsSignatures.CombineSignatureShares(proofShares)
+	if err != nil {
+		return err
+	}
+	groupPubKey, err := blsSignatures.NewPublicKey(groupKey, proof)
+	if err != nil {
+		return err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(blsSignatures.PublicKeyToBytes(groupPubKey))
+	groupPubKeyPath := filepath.Join(config.Dir, "dkg_group_"+das.DefaultPubKeyFilename)
+	if err := os.WriteFile(groupPubKeyPath, []byte(encoded), 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Group public key: %s\n", encoded)
+	fmt.Printf("Wrote group public key to %s\n", groupPubKeyPath)
+	fmt.Println("A single member can sign with the key shares by running 'daserver remote-signer' with each and setting --data-availability.key.threshold")
+	return nil
+}
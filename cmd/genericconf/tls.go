@@ -0,0 +1,142 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package genericconf
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+type TLSConfig struct {
+	Enable   bool       `koanf:"enable"`
+	CertFile string     `koanf:"cert-file"`
+	KeyFile  string     `koanf:"key-file"`
+	ACME     ACMEConfig `koanf:"acme"`
+}
+
+type ACMEConfig struct {
+	Enable            bool     `koanf:"enable"`
+	Domains           []string `koanf:"domains"`
+	Email             string   `koanf:"email"`
+	CacheDir          string   `koanf:"cache-dir"`
+	DirectoryURL      string   `koanf:"directory-url"`
+	HTTPChallengeAddr string   `koanf:"http-challenge-addr"`
+}
+
+var TLSConfigDefault = TLSConfig{
+	ACME: ACMEConfigDefault,
+}
+
+var ACMEConfigDefault = ACMEConfig{
+	Domains:      []string{},
+	CacheDir:     "acme-cache",
+	DirectoryURL: autocert.DefaultACMEDirectory,
+}
+
+func TLSConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", TLSConfigDefault.Enable, "serve HTTP-RPC, REST, gRPC and the admin listener over TLS, with the certificate in cert-file and key-file or one issued by ACME")
+	f.String(prefix+".cert-file", TLSConfigDefault.CertFile, "PEM encoded TLS certificate chain")
+	f.String(prefix+".key-file", TLSConfigDefault.KeyFile, "PEM encoded TLS private key")
+	ACMEConfigAddOptions(prefix+".acme", f)
+}
+
+func ACMEConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", ACMEConfigDefault.Enable, "obtain and renew the TLS certificate automatically from an ACME CA such as Let's Encrypt, accepting its terms of service")
+	f.StringSlice(prefix+".domains", ACMEConfigDefault.Domains, "comma separated list of domains to obtain the certificate for")
+	f.String(prefix+".email", ACMEConfigDefault.Email, "contact email for the ACME account, for notices about certificate problems")
+	f.String(prefix+".cache-dir", ACMEConfigDefault.CacheDir, "directory the ACME account key and certificates are kept in across restarts")
+	f.String(prefix+".directory-url", ACMEConfigDefault.DirectoryURL, "ACME directory URL, eg Let's Encrypt's staging directory for testing")
+	f.String(prefix+".http-challenge-addr", ACMEConfigDefault.HTTPChallengeAddr, "address to answer HTTP-01 challenges on, eg :80; if empty, only TLS-ALPN-01 challenges are answered, which the CA makes on port 443")
+}
+
+func (c *TLSConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.ACME.Enable {
+		if c.CertFile != "" || c.KeyFile != "" {
+			return errors.New("TLS cert-file and key-file can't be used with ACME")
+		}
+		if len(c.ACME.Domains) == 0 {
+			return errors.New("ACME requires at least one domain")
+		}
+		return nil
+	}
+	if c.CertFile == "" || c.KeyFile == "" {
+		return errors.New("TLS requires cert-file and key-file, or ACME")
+	}
+	return nil
+}
+
+// ServerTLSConfig returns the tls.Config to serve with, or nil if TLS isn't
+// enabled. With ACME, certificates are issued when first requested and
+// renewed before they expire, and the same tls.Config should be shared by
+// every server for the domains.
+func (c *TLSConfig) ServerTLSConfig() (*tls.Config, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	if !c.Enable {
+		return nil, nil
+	}
+	if !c.ACME.Enable {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}, nil
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(c.ACME.Domains...),
+		Cache:      autocert.DirCache(c.ACME.CacheDir),
+		Email:      c.ACME.Email,
+		Client:     &acme.Client{DirectoryURL: c.ACME.DirectoryURL},
+	}
+	if c.ACME.HTTPChallengeAddr != "" {
+		challengeServer := &http.Server{
+			Addr:              c.ACME.HTTPChallengeAddr,
+			Handler:           manager.HTTPHandler(nil),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		listener, err := net.Listen("tcp", c.ACME.HTTPChallengeAddr)
+		if err != nil {
+			return nil, err
+		}
+		go func() {
+			if err := challengeServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Error("ACME HTTP challenge server stopped", "err", err)
+			}
+		}()
+	}
+	tlsConfig := manager.TLSConfig()
+	tlsConfig.MinVersion = tls.VersionTLS12
+	return tlsConfig, nil
+}
+
+// TLSListen listens on addr, over TLS if tlsConfig isn't nil.
+func TLSListen(addr string, tlsConfig *tls.Config) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		return listener, nil
+	}
+	return tls.NewListener(listener, tlsConfig), nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package genericconf
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func writeSelfSignedCert(t *testing.T, dir string) (string, string, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	testhelpers.RequireImpl(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	testhelpers.RequireImpl(t, err)
+	cert, err := x509.ParseCertificate(der)
+	testhelpers.RequireImpl(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	testhelpers.RequireImpl(t, err)
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	testhelpers.RequireImpl(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	testhelpers.RequireImpl(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return certFile, keyFile, cert
+}
+
+func TestTLSConfigValidate(t *testing.T) {
+	config := TLSConfigDefault
+	testhelpers.RequireImpl(t, config.Validate())
+	config.Enable = true
+	if config.Validate() == nil {
+		testhelpers.FailImpl(t, "expected TLS without a certificate to be rejected")
+	}
+	config.ACME.Enable = true
+	if config.Validate() == nil {
+		testhelpers.FailImpl(t, "expected ACME without domains to be rejected")
+	}
+	config.ACME.Domains = []string{"das.example"}
+	testhelpers.RequireImpl(t, config.Validate())
+	config.CertFile = "cert.pem"
+	if config.Validate() == nil {
+		testhelpers.FailImpl(t, "expected ACME with a certificate file to be rejected")
+	}
+}
+
+func TestTLSListen(t *testing.T) {
+	certFile, keyFile, cert := writeSelfSignedCert(t, t.TempDir())
+	config := TLSConfigDefault
+	config.Enable = true
+	config.CertFile = certFile
+	config.KeyFile = keyFile
+	tlsConfig, err := config.ServerTLSConfig()
+	testhelpers.RequireImpl(t, err)
+
+	listener, err := TLSListen("localhost:0", tlsConfig)
+	testhelpers.RequireImpl(t, err)
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("ok"))
+		}),
+		ReadHeaderTimeout: time.Second,
+	}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}}}
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	testhelpers.RequireImpl(t, err)
+	res, err := client.Get("https://localhost:" + port)
+	testhelpers.RequireImpl(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	testhelpers.RequireImpl(t, err)
+	if string(body) != "ok" {
+		testhelpers.FailImpl(t, "unexpected response", string(body))
+	}
+}
@@ -0,0 +1,360 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	runtimepprof "runtime/pprof"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	flag "github.com/spf13/pflag"
+)
+
+var ErrAdminTokensFileRequired = errors.New("the admin listener requires admin.token-auth.tokens-file")
+
+type AdminServerConfig struct {
+	Enable    bool            `koanf:"enable"`
+	Addr      string          `koanf:"addr"`
+	Port      uint64          `koanf:"port"`
+	TokenAuth TokenAuthConfig `koanf:"token-auth"`
+}
+
+var DefaultAdminServerConfig = AdminServerConfig{
+	Enable:    false,
+	Addr:      "localhost",
+	Port:      9880,
+	TokenAuth: DefaultTokenAuthConfig,
+}
+
+func AdminServerConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultAdminServerConfig.Enable, "enable the admin listener, serving storage, cache and health stats and the runtime-tunable log level and rate limits under /admin/, and pprof profiles, goroutine dumps and GC stats under /debug/, to clients sending one of the bearer tokens in token-auth.tokens-file")
+	f.String(prefix+".addr", DefaultAdminServerConfig.Addr, "admin listener interface")
+	f.Uint64(prefix+".port", DefaultAdminServerConfig.Port, "admin listener port")
+	f.String(prefix+".token-auth.tokens-file", DefaultAdminServerConfig.TokenAuth.TokensFile, "file with one bearer token per line, one of which admin clients must send in the Authorization header (required if the admin listener is enabled)")
+	f.Duration(prefix+".token-auth.reload-interval", DefaultAdminServerConfig.TokenAuth.ReloadInterval, "how often to reload token-auth.tokens-file (0 to disable reloading)")
+}
+
+func (c *AdminServerConfig) Validate() error {
+	if c.Enable && c.TokenAuth.TokensFile == "" {
+		return ErrAdminTokensFileRequired
+	}
+	return nil
+}
+
+func (c *AdminServerConfig) Address() string {
+	return net.JoinHostPort(c.Addr, strconv.FormatUint(c.Port, 10))
+}
+
+// AdminSources are what the admin server reports on and tunes. Any may be
+// nil, leaving it out of the stats and the runtime config.
+type AdminSources struct {
+	Backends      []StorageBackend
+	HealthChecker DataAvailabilityServiceHealthChecker
+	LogHandler    *log.GlogHandler
+	// LogLevel is the level LogHandler was started with, which it doesn't
+	// report itself.
+	LogLevel          int
+	IPRateLimiter     *IPRateLimiter
+	SignerRateLimiter *SignerRateLimiter
+}
+
+// AdminServer serves stats on the daserver, changes to its runtime config and
+// runtime diagnostics, which can reveal the process's memory, so unlike the
+// public ports and the pprof-cfg server every request must carry a bearer
+// token.
+type AdminServer struct {
+	server    *http.Server
+	tokens    *bearerTokens
+	sources   AdminSources
+	startTime time.Time
+
+	configMutex sync.Mutex
+	logLevel    int
+}
+
+func NewAdminServerOnListener(ctx context.Context, listener net.Listener, config TokenAuthConfig, sources AdminSources) (*AdminServer, error) {
+	tokens, err := newBearerTokens(config)
+	if err != nil {
+		return nil, err
+	}
+	tokens.Start(ctx)
+
+	a := &AdminServer{
+		tokens:    tokens,
+		sources:   sources,
+		startTime: time.Now(),
+		logLevel:  sources.LogLevel,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/stats", a.serveStats)
+	mux.HandleFunc("/admin/config", a.serveConfig)
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/goroutines", serveGoroutineDump)
+	mux.HandleFunc("/debug/gcstats", serveGCStats)
+
+	a.server = &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = withBearerToken(r)
+			if !tokens.valid(r.Context()) {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, "a valid bearer token is required", http.StatusUnauthorized)
+				return
+			}
+			mux.ServeHTTP(w, r)
+		}),
+		// CPU profiles and traces run for as long as their seconds
+		// parameter asks, so there's no write timeout.
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+
+	go func() {
+		err := a.server.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("das: admin server exited", "err", err)
+		}
+	}()
+	return a, nil
+}
+
+func (a *AdminServer) Shutdown(ctx context.Context) error {
+	a.tokens.StopOnly()
+	return a.server.Shutdown(ctx)
+}
+
+// adminStatsTimeout bounds the health checks and storage scans of a stats
+// request.
+const adminStatsTimeout = 30 * time.Second
+
+type AdminStats struct {
+	StartTime   time.Time             `json:"startTime"`
+	Healthy     bool                  `json:"healthy"`
+	HealthError string                `json:"healthError,omitempty"`
+	Backends    []StorageBackendStats `json:"backends,omitempty"`
+	// Metrics are the arb/das/ metrics, eg the cache hits, misses and
+	// evictions, which are only recorded with metrics enabled.
+	Metrics map[string]interface{} `json:"metrics"`
+}
+
+func (a *AdminServer) serveStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), adminStatsTimeout)
+	defer cancel()
+
+	stats := AdminStats{StartTime: a.startTime, Healthy: true, Metrics: dasMetrics()}
+	if a.sources.HealthChecker != nil {
+		if err := a.sources.HealthChecker.HealthCheck(ctx); err != nil {
+			stats.Healthy = false
+			stats.HealthError = err.Error()
+		}
+	}
+	stats.Backends = StorageBackendsStats(ctx, a.sources.Backends)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		log.Warn("das: failed to write admin stats", "err", err)
+	}
+}
+
+func dasMetrics() map[string]interface{} {
+	snapshot := make(map[string]interface{})
+	metrics.DefaultRegistry.Each(func(name string, metric interface{}) {
+		if !strings.HasPrefix(name, "arb/das/") {
+			return
+		}
+		switch m := metric.(type) {
+		case metrics.Counter:
+			snapshot[name] = m.Count()
+		case metrics.Gauge:
+			snapshot[name] = m.Value()
+		case metrics.GaugeFloat64:
+			snapshot[name] = m.Value()
+		case metrics.Meter:
+			snapshot[name] = m.Count()
+		case metrics.Timer:
+			t := m.Snapshot()
+			snapshot[name] = map[string]interface{}{"count": t.Count(), "mean": t.Mean(), "p50": t.Percentile(0.5), "p99": t.Percentile(0.99)}
+		case metrics.Histogram:
+			h := m.Snapshot()
+			snapshot[name] = map[string]interface{}{"count": h.Count(), "mean": h.Mean(), "p50": h.Percentile(0.5), "p99": h.Percentile(0.99)}
+		}
+	})
+	return snapshot
+}
+
+// AdminRuntimeConfig is the config the admin server can change without a
+// restart. Settings left out of an update are unchanged. Rate limits that
+// weren't enabled at startup are left out, and can't be set.
+type AdminRuntimeConfig struct {
+	LogLevel           *int                   `json:"logLevel,omitempty"`
+	PerIPRateLimit     *ClientRateLimitConfig `json:"perIPRateLimit,omitempty"`
+	PerSignerRateLimit *ClientRateLimitConfig `json:"perSignerRateLimit,omitempty"`
+}
+
+func (a *AdminServer) runtimeConfig() AdminRuntimeConfig {
+	var config AdminRuntimeConfig
+	if a.sources.LogHandler != nil {
+		logLevel := a.logLevel
+		config.LogLevel = &logLevel
+	}
+	if a.sources.IPRateLimiter != nil {
+		perIP := a.sources.IPRateLimiter.Config()
+		config.PerIPRateLimit = &perIP
+	}
+	if a.sources.SignerRateLimiter != nil {
+		perSigner := a.sources.SignerRateLimiter.Config()
+		config.PerSignerRateLimit = &perSigner
+	}
+	return config
+}
+
+// updateRuntimeConfig checks the whole update before applying any of it.
+func (a *AdminServer) updateRuntimeConfig(update AdminRuntimeConfig) error {
+	if update.LogLevel != nil {
+		if a.sources.LogHandler == nil {
+			return errors.New("the log level can't be changed")
+		}
+		if *update.LogLevel < int(log.LvlCrit) || *update.LogLevel > int(log.LvlTrace) {
+			return fmt.Errorf("log level must be between %d and %d", log.LvlCrit, log.LvlTrace)
+		}
+	}
+	if update.PerIPRateLimit != nil {
+		if a.sources.IPRateLimiter == nil {
+			return errors.New("per IP rate limits weren't enabled at startup")
+		}
+		if err := update.PerIPRateLimit.Validate(); err != nil {
+			return fmt.Errorf("per IP rate limit: %w", err)
+		}
+	}
+	if update.PerSignerRateLimit != nil {
+		if a.sources.SignerRateLimiter == nil {
+			return errors.New("per signer rate limits weren't enabled at startup")
+		}
+		if err := update.PerSignerRateLimit.Validate(); err != nil {
+			return fmt.Errorf("per signer rate limit: %w", err)
+		}
+	}
+
+	if update.LogLevel != nil {
+		a.sources.LogHandler.Verbosity(log.Lvl(*update.LogLevel))
+		a.logLevel = *update.LogLevel
+		log.Info("das: admin changed the log level", "level", a.logLevel)
+	}
+	if update.PerIPRateLimit != nil {
+		if err := a.sources.IPRateLimiter.SetConfig(*update.PerIPRateLimit); err != nil {
+			return err
+		}
+		log.Info("das: admin changed the per IP rate limit", "limit", *update.PerIPRateLimit)
+	}
+	if update.PerSignerRateLimit != nil {
+		if err := a.sources.SignerRateLimiter.SetConfig(*update.PerSignerRateLimit); err != nil {
+			return err
+		}
+		log.Info("das: admin changed the per signer rate limit", "limit", *update.PerSignerRateLimit)
+	}
+	return nil
+}
+
+// serveConfig returns the runtime config on GET, and applies the update in
+// the body on PUT, returning the resulting config.
+func (a *AdminServer) serveConfig(w http.ResponseWriter, r *http.Request) {
+	a.configMutex.Lock()
+	defer a.configMutex.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var update AdminRuntimeConfig
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&update); err != nil {
+			http.Error(w, fmt.Sprintf("invalid runtime config: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err := a.updateRuntimeConfig(update); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(a.runtimeConfig()); err != nil {
+		log.Warn("das: failed to write admin runtime config", "err", err)
+	}
+}
+
+// serveGoroutineDump writes the stacks of all goroutines, in the same format
+// as an unrecovered panic.
+func serveGoroutineDump(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err := runtimepprof.Lookup("goroutine").WriteTo(w, 2); err != nil {
+		log.Warn("das: failed to write goroutine dump", "err", err)
+	}
+}
+
+type GCStats struct {
+	NumGC          int64           `json:"numGC"`
+	LastGC         time.Time       `json:"lastGC"`
+	PauseTotal     time.Duration   `json:"pauseTotalNs"`
+	RecentPauses   []time.Duration `json:"recentPausesNs"`
+	PauseQuantiles []time.Duration `json:"pauseQuantilesNs"`
+	HeapAlloc      uint64          `json:"heapAlloc"`
+	HeapInuse      uint64          `json:"heapInuse"`
+	HeapObjects    uint64          `json:"heapObjects"`
+	NextGC         uint64          `json:"nextGC"`
+	Sys            uint64          `json:"sys"`
+	GCCPUFraction  float64         `json:"gcCPUFraction"`
+	NumGoroutine   int             `json:"numGoroutine"`
+}
+
+func serveGCStats(w http.ResponseWriter, r *http.Request) {
+	gcStats := debug.GCStats{PauseQuantiles: make([]time.Duration, 5)}
+	debug.ReadGCStats(&gcStats)
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	recentPauses := gcStats.Pause
+	if len(recentPauses) > 16 {
+		recentPauses = recentPauses[:16]
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(GCStats{
+		NumGC:          gcStats.NumGC,
+		LastGC:         gcStats.LastGC,
+		PauseTotal:     gcStats.PauseTotal,
+		RecentPauses:   recentPauses,
+		PauseQuantiles: gcStats.PauseQuantiles,
+		HeapAlloc:      memStats.HeapAlloc,
+		HeapInuse:      memStats.HeapInuse,
+		HeapObjects:    memStats.HeapObjects,
+		NextGC:         memStats.NextGC,
+		Sys:            memStats.Sys,
+		GCCPUFraction:  memStats.GCCPUFraction,
+		NumGoroutine:   runtime.NumGoroutine(),
+	})
+	if err != nil {
+		log.Warn("das: failed to write GC stats", "err", err)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestAdminServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tokensFile := filepath.Join(t.TempDir(), "tokens")
+	testhelpers.RequireImpl(t, os.WriteFile(tokensFile, []byte("admin-token\n"), 0600))
+	lis, err := net.Listen("tcp", "localhost:0")
+	testhelpers.RequireImpl(t, err)
+	storageService := NewMemoryBackedStorageService(ctx)
+	testhelpers.RequireImpl(t, storageService.Put(ctx, make([]byte, 100), 0))
+	ipLimiter := NewIPRateLimiter(RateLimitConfig{PerIP: ClientRateLimitConfig{RequestsPerSecond: 10, RequestBurst: 10}})
+	adminServer, err := NewAdminServerOnListener(ctx, lis, TokenAuthConfig{TokensFile: tokensFile}, AdminSources{
+		Backends:      []StorageBackend{{Name: "memory-storage", Service: storageService}},
+		HealthChecker: storageService,
+		LogHandler:    log.NewGlogHandler(log.StreamHandler(io.Discard, log.TerminalFormat(false))),
+		LogLevel:      int(log.LvlInfo),
+		IPRateLimiter: ipLimiter,
+	})
+	testhelpers.RequireImpl(t, err)
+	defer func() {
+		testhelpers.RequireImpl(t, adminServer.Shutdown(ctx))
+	}()
+
+	do := func(method, path, token, reqBody string) (int, []byte) {
+		t.Helper()
+		req, err := http.NewRequestWithContext(ctx, method, "http://"+lis.Addr().String()+path, strings.NewReader(reqBody))
+		testhelpers.RequireImpl(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		testhelpers.RequireImpl(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		testhelpers.RequireImpl(t, err)
+		return resp.StatusCode, body
+	}
+	get := func(path, token string) (int, []byte) {
+		t.Helper()
+		return do(http.MethodGet, path, token, "")
+	}
+
+	for _, path := range []string{"/admin/stats", "/admin/config", "/debug/pprof/", "/debug/pprof/heap", "/debug/goroutines", "/debug/gcstats"} {
+		for _, token := range []string{"", "wrong-token"} {
+			if status, _ := get(path, token); status != http.StatusUnauthorized {
+				testhelpers.FailImpl(t, "expected", path, "to be unauthorized with token", token, "got status", status)
+			}
+		}
+		if status, _ := get(path, "admin-token"); status != http.StatusOK {
+			testhelpers.FailImpl(t, "expected", path, "to be served, got status", status)
+		}
+	}
+
+	_, dump := get("/debug/goroutines", "admin-token")
+	if !strings.Contains(string(dump), "goroutine ") {
+		testhelpers.FailImpl(t, "goroutine dump has no goroutines:", string(dump))
+	}
+	_, body := get("/debug/gcstats", "admin-token")
+	var gcStats GCStats
+	testhelpers.RequireImpl(t, json.Unmarshal(body, &gcStats))
+	if gcStats.NumGoroutine == 0 || gcStats.HeapAlloc == 0 {
+		testhelpers.FailImpl(t, "unexpected GC stats", string(body))
+	}
+
+	_, body = get("/admin/stats", "admin-token")
+	var adminStats AdminStats
+	testhelpers.RequireImpl(t, json.Unmarshal(body, &adminStats))
+	if !adminStats.Healthy || len(adminStats.Backends) != 1 || adminStats.Backends[0].Name != "memory-storage" || !adminStats.Backends[0].Healthy {
+		testhelpers.FailImpl(t, "unexpected admin stats", string(body))
+	}
+	if usage := adminStats.Backends[0].Usage; usage == nil || usage.Entries != 1 || usage.Bytes != 100 {
+		testhelpers.FailImpl(t, "unexpected storage usage", string(body))
+	}
+
+	status, body := do(http.MethodPut, "/admin/config", "admin-token", `{"logLevel": 5, "perIPRateLimit": {"requestsPerSecond": 1, "requestBurst": 2}}`)
+	if status != http.StatusOK {
+		testhelpers.FailImpl(t, "expected runtime config update to succeed, got status", status, string(body))
+	}
+	var config AdminRuntimeConfig
+	testhelpers.RequireImpl(t, json.Unmarshal(body, &config))
+	if config.LogLevel == nil || *config.LogLevel != 5 || config.PerIPRateLimit == nil || config.PerIPRateLimit.RequestsPerSecond != 1 || config.PerSignerRateLimit != nil {
+		testhelpers.FailImpl(t, "unexpected runtime config", string(body))
+	}
+	if ipLimiter.Config().RequestBurst != 2 {
+		testhelpers.FailImpl(t, "per IP rate limit wasn't changed", ipLimiter.Config())
+	}
+
+	// Invalid updates change nothing.
+	for _, update := range []string{
+		`{"logLevel": 9}`,
+		`{"logLevel": 3, "perSignerRateLimit": {"requestsPerSecond": 1}}`,
+		`{"logLevel": 3, "perIPRateLimit": {"requestsPerSecond": -1}}`,
+		`{"logLevel": 3, "rateLimit": {}}`,
+	} {
+		if status, _ := do(http.MethodPut, "/admin/config", "admin-token", update); status != http.StatusBadRequest {
+			testhelpers.FailImpl(t, "expected update", update, "to be rejected, got status", status)
+		}
+	}
+	_, body = get("/admin/config", "admin-token")
+	testhelpers.RequireImpl(t, json.Unmarshal(body, &config))
+	if *config.LogLevel != 5 {
+		testhelpers.FailImpl(t, "rejected update changed the log level", string(body))
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"errors"
 	"fmt"
 	"math/bits"
+	"sort"
+	"sync/atomic"
 	"time"
 
 	flag "github.com/spf13/pflag"
@@ -24,38 +26,161 @@ import (
 	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
 	"github.com/offchainlabs/nitro/util/contracts"
 	"github.com/offchainlabs/nitro/util/pretty"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
 )
 
 type AggregatorConfig struct {
-	Enable        bool   `koanf:"enable"`
-	AssumedHonest int    `koanf:"assumed-honest"`
-	Backends      string `koanf:"backends"`
+	Enable                 bool                        `koanf:"enable"`
+	AssumedHonest          int                         `koanf:"assumed-honest"`
+	CommitteeSize          int                         `koanf:"committee-size"`
+	MemberOrder            string                      `koanf:"member-order"`
+	Backends               string                      `koanf:"backends"`
+	BackendsFile           string                      `koanf:"backends-file"`
+	BackendsReloadInterval time.Duration               `koanf:"backends-reload-interval"`
+	HealthCheck            AggregatorHealthCheckConfig `koanf:"health-check"`
+	CircuitBreaker         CircuitBreakerConfig        `koanf:"circuit-breaker"`
+	Hedging                HedgingConfig               `koanf:"hedging"`
+	StoreDeadline          StoreDeadlineConfig         `koanf:"store-deadline"`
 }
 
 var DefaultAggregatorConfig = AggregatorConfig{
-	AssumedHonest: 0,
-	Backends:      "",
+	AssumedHonest:          0,
+	CommitteeSize:          0,
+	MemberOrder:            MemberOrderConfig,
+	Backends:               "",
+	BackendsFile:           "",
+	BackendsReloadInterval: time.Minute,
+	HealthCheck:            DefaultAggregatorHealthCheckConfig,
+	CircuitBreaker:         DefaultCircuitBreakerConfig,
+	Hedging:                DefaultHedgingConfig,
+	StoreDeadline:          DefaultStoreDeadlineConfig,
 }
 
 var BatchToDasFailed = errors.New("unable to batch to DAS")
 
+const (
+	MemberOrderConfig      = "config"
+	MemberOrderSignersMask = "signers-mask"
+)
+
+// OrderServices orders the committee members as their public keys appear in
+// the keyset.
+func OrderServices(services []ServiceDetails, memberOrder string) ([]ServiceDetails, error) {
+	switch memberOrder {
+	case MemberOrderConfig, "":
+		return services, nil
+	case MemberOrderSignersMask:
+		ordered := append([]ServiceDetails{}, services...)
+		sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].signersMask < ordered[j].signersMask })
+		return ordered, nil
+	default:
+		return nil, fmt.Errorf("unknown member-order '%s', use --help to see available options", memberOrder)
+	}
+}
+
 func AggregatorConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Bool(prefix+".enable", DefaultAggregatorConfig.Enable, "enable storage/retrieval of sequencer batch data from a list of RPC endpoints; this should only be used by the batch poster and not in combination with other DAS storage types")
-	f.Int(prefix+".assumed-honest", DefaultAggregatorConfig.AssumedHonest, "Number of assumed honest backends (H). If there are N backends, K=N+1-H valid responses are required to consider an Store request to be successful.")
-	f.String(prefix+".backends", DefaultAggregatorConfig.Backends, "JSON RPC backend configuration")
+	f.Int(prefix+".assumed-honest", DefaultAggregatorConfig.AssumedHonest, "Number of assumed honest backends (H). If there are N backends, K=N+1-H valid responses are required to consider an Store request to be successful. Every backend counts as one member; per-member weights aren't supported, because the keyset that certificates are verified against only records the number of assumed-honest members.")
+	f.Int(prefix+".committee-size", DefaultAggregatorConfig.CommitteeSize, "expected number of backends (N) in the committee; if set, backend configurations with a different number of backends are rejected (0 to accept any number)")
+	f.String(prefix+".member-order", DefaultAggregatorConfig.MemberOrder, fmt.Sprintf("order of the committee members' public keys in the keyset; valid options are '%s' (the order of the backend configuration) and '%s' (ascending signersMask)", MemberOrderConfig, MemberOrderSignersMask))
+	f.String(prefix+".backends", DefaultAggregatorConfig.Backends, "JSON RPC backend configuration; a backend's url may be prefixed with 'srv+', eg 'srv+https://_das._tcp.example.com', to use the most preferred target of that DNS SRV record; each backend may also set a Store \"timeout\", \"retries\", and exponential \"backoff\"/\"maxbackoff\", and credentials \"bearertoken\" or \"basicauth\" (user:password) and \"clientcert\"/\"clientkey\"/\"rootca\" files")
+	f.String(prefix+".backends-file", DefaultAggregatorConfig.BackendsFile, "file containing the JSON RPC backend configuration, used instead of backends; the file is reloaded when it changes")
+	f.Duration(prefix+".backends-reload-interval", DefaultAggregatorConfig.BackendsReloadInterval, "how often to check backends-file for changes and re-resolve backends' DNS SRV URLs (0 to disable reloading)")
+	AggregatorHealthCheckConfigAddOptions(prefix+".health-check", f)
+	CircuitBreakerConfigAddOptions(prefix+".circuit-breaker", f)
+	HedgingConfigAddOptions(prefix+".hedging", f)
+	StoreDeadlineConfigAddOptions(prefix+".store-deadline", f)
 }
 
 type Aggregator struct {
+	stopwaiter.StopWaiter
+
 	config         AggregatorConfig
-	services       []ServiceDetails
 	requestTimeout time.Duration
+	addrVerifier   *contracts.AddressVerifier
+
+	// committee is replaced as a whole when the backends are reloaded, so each
+	// Store sees a consistent set of backends and keyset.
+	committee    atomic.Pointer[aggregatorCommittee]
+	lastBackends string
+
+	registry                *CommitteeRegistry
+	registryRefreshInterval time.Duration
+}
+
+type aggregatorCommittee struct {
+	services []ServiceDetails
+	health   []*backendHealth
 
 	// calculated fields
 	requiredServicesForStore       int
 	maxAllowedServiceStoreFailures int
 	keysetHash                     [32]byte
 	keysetBytes                    []byte
-	addrVerifier                   *contracts.AddressVerifier
+
+	// The keyset certificates are issued for once the store deadline budget
+	// is exhausted, if the policy is to accept a reduced quorum.
+	reducedAssumedHonest  int
+	reducedRequiredStores int
+	reducedKeysetHash     [32]byte
+}
+
+// newAggregatorCommittee calculates the keyset and quorum of the services. The
+// health of backends that were also in the previous committee is carried over.
+func newAggregatorCommittee(config AggregatorConfig, services []ServiceDetails, previous *aggregatorCommittee) (*aggregatorCommittee, error) {
+	if config.CommitteeSize != 0 && len(services) != config.CommitteeSize {
+		return nil, fmt.Errorf("committee-size is %d but %d backends are configured", config.CommitteeSize, len(services))
+	}
+	if config.AssumedHonest < 1 || config.AssumedHonest > len(services) {
+		return nil, fmt.Errorf("assumed-honest must be between 1 and the number of backends (%d), got %d", len(services), config.AssumedHonest)
+	}
+	services, err := OrderServices(services, config.MemberOrder)
+	if err != nil {
+		return nil, err
+	}
+	keysetHash, keysetBytes, err := KeysetHashFromServices(services, uint64(config.AssumedHonest))
+	if err != nil {
+		return nil, err
+	}
+	if err := config.StoreDeadline.validate(config.AssumedHonest, len(services)); err != nil {
+		return nil, err
+	}
+	var reducedKeysetHash [32]byte
+	reducedAssumedHonest := 0
+	if config.StoreDeadline.Budget != 0 && config.StoreDeadline.OnExhausted == OnBudgetExhaustedReducedQuorum {
+		reducedAssumedHonest = config.StoreDeadline.ReducedAssumedHonest
+		reducedKeysetHash, _, err = KeysetHashFromServices(services, uint64(reducedAssumedHonest))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	previousHealth := make(map[string]*backendHealth)
+	if previous != nil {
+		for i, d := range previous.services {
+			previousHealth[d.String()] = previous.health[i]
+		}
+	}
+	health := make([]*backendHealth, 0, len(services))
+	for _, d := range services {
+		h, ok := previousHealth[d.String()]
+		if !ok {
+			h = newBackendHealth(config.CircuitBreaker, d.metricName)
+		}
+		health = append(health, h)
+	}
+
+	return &aggregatorCommittee{
+		services:                       services,
+		health:                         health,
+		requiredServicesForStore:       len(services) + 1 - config.AssumedHonest,
+		maxAllowedServiceStoreFailures: config.AssumedHonest - 1,
+		keysetHash:                     keysetHash,
+		keysetBytes:                    keysetBytes,
+		reducedAssumedHonest:           reducedAssumedHonest,
+		reducedRequiredStores:          len(services) + 1 - reducedAssumedHonest,
+		reducedKeysetHash:              reducedKeysetHash,
+	}, nil
 }
 
 type ServiceDetails struct {
@@ -63,6 +188,21 @@ type ServiceDetails struct {
 	pubKey      blsSignatures.PublicKey
 	signersMask uint64
 	metricName  string
+	policy      StorePolicy
+}
+
+// StorePolicy controls how the Aggregator calls Store on a single backend, so
+// one slow or flaky committee member can be given its own limits.
+type StorePolicy struct {
+	// Timeout of each Store attempt; if zero, the aggregator's request
+	// timeout is used.
+	Timeout time.Duration
+	// Retries is the number of times a failed Store is retried.
+	Retries int
+	// Backoff is the wait before the first retry, doubling on each further
+	// retry up to MaxBackoff (if non-zero).
+	Backoff    time.Duration
+	MaxBackoff time.Duration
 }
 
 func (s *ServiceDetails) String() string {
@@ -118,7 +258,7 @@ func NewAggregatorWithSeqInboxCaller(
 	seqInboxCaller *bridgegen.SequencerInboxCaller,
 ) (*Aggregator, error) {
 
-	keysetHash, keysetBytes, err := KeysetHashFromServices(services, uint64(config.RPCAggregator.AssumedHonest))
+	committee, err := newAggregatorCommittee(config.RPCAggregator, services, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -128,16 +268,58 @@ func NewAggregatorWithSeqInboxCaller(
 		addrVerifier = contracts.NewAddressVerifier(seqInboxCaller)
 	}
 
-	return &Aggregator{
-		config:                         config.RPCAggregator,
-		services:                       services,
-		requestTimeout:                 config.RequestTimeout,
-		requiredServicesForStore:       len(services) + 1 - config.RPCAggregator.AssumedHonest,
-		maxAllowedServiceStoreFailures: config.RPCAggregator.AssumedHonest - 1,
-		keysetHash:                     keysetHash,
-		keysetBytes:                    keysetBytes,
-		addrVerifier:                   addrVerifier,
-	}, nil
+	a := &Aggregator{
+		config:         config.RPCAggregator,
+		requestTimeout: config.RequestTimeout,
+		addrVerifier:   addrVerifier,
+	}
+	a.committee.Store(committee)
+	return a, nil
+}
+
+// ReloadBackends replaces the committee members that subsequent Stores are
+// sent to. Stores already in progress finish with the previous members.
+func (a *Aggregator) ReloadBackends(services []ServiceDetails) error {
+	previous := a.committee.Load()
+	committee, err := newAggregatorCommittee(a.config, services, previous)
+	if err != nil {
+		return err
+	}
+	a.committee.Store(committee)
+	if committee.keysetHash != previous.keysetHash {
+		log.Info("das.Aggregator: reloaded backends with new keyset", "backends", len(services), "keysetHash", common.Hash(committee.keysetHash))
+	} else {
+		log.Info("das.Aggregator: reloaded backends", "backends", len(services))
+	}
+	return nil
+}
+
+// Start periodically health checks the backends and reloads the backends
+// from the committee registry, backends file or DNS SRV records, if enabled.
+func (a *Aggregator) Start(ctx context.Context) {
+	a.StopWaiter.Start(ctx, a)
+	if a.config.HealthCheck.Interval > 0 {
+		a.CallIteratively(a.checkBackendHealth)
+	}
+	if a.registry != nil {
+		a.CallIteratively(a.reloadFromRegistry)
+	} else if (a.config.BackendsFile != "" || backendsHaveDNSSRVURL(a.config.Backends)) && a.config.BackendsReloadInterval > 0 {
+		a.CallIteratively(a.reloadBackends)
+	}
+}
+
+func (a *Aggregator) Close(ctx context.Context) error {
+	a.StopWaiter.StopOnly()
+	waitChan, err := a.StopWaiter.GetWaitChannel()
+	if err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-waitChan:
+		return nil
+	}
 }
 
 type storeResponse struct {
@@ -158,7 +340,17 @@ type storeResponse struct {
 // and returns an error.
 //
 // If Store gets not enough successful responses by the time its context is canceled
-// (eg via TimeoutWrapper) then it also returns an error.
+// (eg via TimeoutWrapper) then it returns an error as soon as the context is done,
+// without waiting for the outstanding backends.
+//
+// If hedging is enabled, Store is first sent to only K backends (plus any
+// extra initial stores), then to more backends as earlier ones fail or each
+// time the hedging delay passes, and outstanding requests are canceled as soon
+// as there are K responses.
+//
+// Backends that are failing health checks or have tripped their circuit breaker
+// are skipped and count as failures, unless skipping them would leave too few
+// backends to reach K, in which case every backend is tried.
 //
 // If Sequencer Inbox contract details are provided when a das.Aggregator is
 // constructed, calls to Store(...) will try to verify the passed-in data's signature
@@ -166,110 +358,166 @@ type storeResponse struct {
 // signature is not checked, which is useful for testing.
 func (a *Aggregator) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
 	log.Trace("das.Aggregator.Store", "message", pretty.FirstFewBytes(message), "timeout", time.Unix(int64(timeout), 0), "sig", pretty.FirstFewBytes(sig))
-	if a.addrVerifier != nil {
-		actualSigner, err := DasRecoverSigner(message, timeout, sig)
-		if err != nil {
-			return nil, err
+	if err := a.checkStoreSigner(ctx, message, timeout, sig); err != nil {
+		return nil, err
+	}
+
+	c := a.committee.Load()
+	responses := make(chan storeResponse, len(c.services))
+
+	now := time.Now()
+	available := make([]bool, len(c.services))
+	numAvailable := 0
+	for i, h := range c.health {
+		available[i] = h.available(now)
+		if available[i] {
+			numAvailable++
 		}
-		isBatchPosterOrSequencer, err := a.addrVerifier.IsBatchPosterOrSequencer(ctx, actualSigner)
-		if err != nil {
-			return nil, err
+	}
+	if numAvailable < c.requiredServicesForStore {
+		if numAvailable < len(c.services) {
+			log.Warn("das.Aggregator: too few backends available to reach quorum, trying all of them", "available", numAvailable, "required", c.requiredServicesForStore)
 		}
-		if !isBatchPosterOrSequencer {
-			return nil, errors.New("store request not properly signed")
+		for i := range available {
+			available[i] = true
 		}
 	}
 
-	responses := make(chan storeResponse, len(a.services))
-
 	expectedHash := dastree.Hash(message)
-	for _, d := range a.services {
-		go func(ctx context.Context, d ServiceDetails) {
-			storeCtx, cancel := context.WithTimeout(ctx, a.requestTimeout)
-			const metricBase string = "arb/das/rpc/aggregator/store"
-			var metricWithServiceName = metricBase + "/" + d.metricName
-			defer cancel()
-			incFailureMetric := func() {
-				metrics.GetOrRegisterCounter(metricWithServiceName+"/error/total", nil).Inc(1)
-				metrics.GetOrRegisterCounter(metricBase+"/error/all/total", nil).Inc(1)
-			}
-
-			cert, err := d.service.Store(storeCtx, message, timeout, sig)
-			if err != nil {
-				incFailureMetric()
-				if errors.Is(err, context.DeadlineExceeded) {
-					metrics.GetOrRegisterCounter(metricWithServiceName+"/error/timeout/total", nil).Inc(1)
-				} else {
-					metrics.GetOrRegisterCounter(metricWithServiceName+"/error/client/total", nil).Inc(1)
-				}
-				responses <- storeResponse{d, nil, err}
-				return
-			}
-
-			verified, err := blsSignatures.VerifySignature(
-				cert.Sig, cert.SerializeSignableFields(), d.pubKey,
-			)
-			if err != nil {
-				incFailureMetric()
-				metrics.GetOrRegisterCounter(metricWithServiceName+"/error/bad_response/total", nil).Inc(1)
-				responses <- storeResponse{d, nil, err}
-				return
-			}
-			if !verified {
-				incFailureMetric()
-				metrics.GetOrRegisterCounter(metricWithServiceName+"/error/bad_response/total", nil).Inc(1)
-				responses <- storeResponse{d, nil, errors.New("signature verification failed")}
-				return
+	expectedSignableFields := (&arbstate.DataAvailabilityCertificate{
+		DataHash: expectedHash,
+		Timeout:  timeout,
+		Version:  1,
+	}).SerializeSignableFields()
+	// storeCtx is canceled once enough signatures are collected if hedging,
+	// and otherwise once every backend has responded.
+	storeCtx, cancelStores := context.WithCancel(ctx)
+	hedging := a.config.Hedging.Delay > 0
+	launch := func(i int) {
+		go func(d ServiceDetails, h *backendHealth) {
+			response := a.storeToBackendAndVerify(storeCtx, d, expectedHash, expectedSignableFields, message, timeout, sig)
+			if response.err == nil {
+				h.recordSuccess()
+			} else if storeCtx.Err() == nil {
+				// Failures after the request was canceled aren't the backend's fault.
+				h.recordFailure(response.err)
 			}
-
-			// SignersMask from backend DAS is ignored.
-
-			if cert.DataHash != expectedHash {
-				incFailureMetric()
-				metrics.GetOrRegisterCounter(metricWithServiceName+"/error/bad_response/total", nil).Inc(1)
-				responses <- storeResponse{d, nil, errors.New("hash verification failed")}
-				return
-			}
-			if cert.Timeout != timeout {
-				incFailureMetric()
-				metrics.GetOrRegisterCounter(metricWithServiceName+"/error/bad_response/total", nil).Inc(1)
-				responses <- storeResponse{d, nil, fmt.Errorf("timeout was %d, expected %d", cert.Timeout, timeout)}
-				return
-			}
-
-			metrics.GetOrRegisterCounter(metricWithServiceName+"/success/total", nil).Inc(1)
-			metrics.GetOrRegisterCounter(metricBase+"/success/all/total", nil).Inc(1)
-			responses <- storeResponse{d, cert.Sig, nil}
-		}(ctx, d)
+			responses <- response
+		}(c.services[i], c.health[i])
+	}
+	var toLaunch []int
+	for i, d := range c.services {
+		if !available[i] {
+			metrics.GetOrRegisterCounter("arb/das/rpc/aggregator/store/"+d.metricName+"/skipped/total", nil).Inc(1)
+			responses <- storeResponse{d, nil, errBackendUnavailable}
+			continue
+		}
+		toLaunch = append(toLaunch, i)
+	}
+	var failed chan struct{}
+	if !hedging {
+		for _, i := range toLaunch {
+			launch(i)
+		}
+	} else {
+		initial := c.requiredServicesForStore + a.config.Hedging.ExtraInitialStores
+		if initial > len(toLaunch) {
+			initial = len(toLaunch)
+		}
+		for _, i := range toLaunch[:initial] {
+			launch(i)
+		}
+		failed = make(chan struct{}, len(c.services))
+		notSent := func(i int) {
+			responses <- storeResponse{c.services[i], nil, errHedgeNotSent}
+		}
+		go a.hedgeStores(storeCtx, toLaunch[initial:], launch, notSent, failed)
 	}
 
 	var aggCert arbstate.DataAvailabilityCertificate
 
 	type certDetails struct {
+		signers        []ServiceDetails
 		pubKeys        []blsSignatures.PublicKey
 		sigs           []blsSignatures.Signature
 		aggSignersMask uint64
+		reduced        bool
 		err            error
 	}
 
-	// Collect responses from backends.
-	certDetailsChan := make(chan certDetails)
+	// Collect responses from backends. The channel is buffered so the collector
+	// never blocks on it, whether or not Store is still waiting for a result.
+	certDetailsChan := make(chan certDetails, 1)
+	var budgetExhausted <-chan time.Time
+	if a.config.StoreDeadline.Budget > 0 {
+		budgetTimer := time.NewTimer(a.config.StoreDeadline.Budget)
+		defer budgetTimer.Stop()
+		budgetExhausted = budgetTimer.C
+	}
+	reducedQuorum := a.config.StoreDeadline.OnExhausted == OnBudgetExhaustedReducedQuorum && budgetExhausted != nil
+	maxAllowedStoreFailures := c.maxAllowedServiceStoreFailures
+	if reducedQuorum {
+		// A reduced quorum may still be reached once the budget is exhausted.
+		maxAllowedStoreFailures = c.reducedAssumedHonest - 1
+	}
+
 	go func() {
+		defer cancelStores()
+		var signers []ServiceDetails
 		var pubKeys []blsSignatures.PublicKey
 		var sigs []blsSignatures.Signature
 		var aggSignersMask uint64
 		var storeFailures, successfullyStoredCount int
-		var returned bool
-		for i := 0; i < len(a.services); i++ {
+		var returned, exhausted bool
+		// With a reduced quorum policy every backend may have responded without
+		// enough signatures for the full quorum, in which case the reduced
+		// certificate is only issued once the budget is exhausted.
+		for received := 0; received < len(c.services) || (!returned && budgetExhausted != nil); {
 
 			select {
 			case <-ctx.Done():
-				break
+				if !returned {
+					cd := certDetails{}
+					cd.err = fmt.Errorf("aggregator only stored message to %d out of %d DASes before %v, needed %d. %w", successfullyStoredCount, len(c.services), ctx.Err(), c.requiredServicesForStore, BatchToDasFailed)
+					certDetailsChan <- cd
+				}
+				if pending := len(c.services) - storeFailures - successfullyStoredCount; pending > 0 {
+					log.Warn("das.Aggregator: context done before all backends responded", "pending", pending, "err", ctx.Err())
+				}
+				return
+			case <-budgetExhausted:
+				budgetExhausted = nil
+				if returned {
+					continue
+				}
+				exhausted = true
+				aggregatorBudgetExhaustedCounter.Inc(1)
+				if !reducedQuorum {
+					cd := certDetails{}
+					cd.err = a.config.StoreDeadline.budgetExhaustedError(successfullyStoredCount, len(c.services), c.requiredServicesForStore)
+					certDetailsChan <- cd
+					returned = true
+					cancelStores()
+				}
 			case r := <-responses:
+				received++
 				if r.err != nil {
 					storeFailures++
-					log.Warn("das.Aggregator: Error from backend", "backend", r.details.service, "signerMask", r.details.signersMask, "err", r.err)
+					if returned && hedging {
+						// Expected once outstanding requests are canceled.
+						log.Debug("das.Aggregator: Error from backend after enough signatures were collected", "backend", r.details.service, "signerMask", r.details.signersMask, "err", r.err)
+					} else {
+						log.Warn("das.Aggregator: Error from backend", "backend", r.details.service, "signerMask", r.details.signersMask, "err", r.err)
+					}
+					if failed != nil && !returned && !errors.Is(r.err, errBackendUnavailable) {
+						// Replace the failed backend straight away.
+						select {
+						case failed <- struct{}{}:
+						default:
+						}
+					}
 				} else {
+					signers = append(signers, r.details)
 					pubKeys = append(pubKeys, r.details.pubKey)
 					sigs = append(sigs, r.sig)
 					aggSignersMask |= r.details.signersMask
@@ -283,20 +531,36 @@ func (a *Aggregator) Store(ctx context.Context, message []byte, timeout uint64,
 			// running until all responses are received (or the context is canceled)
 			// in order to produce accurate logs/metrics.
 			if !returned {
-				if successfullyStoredCount >= a.requiredServicesForStore {
+				if exhausted && successfullyStoredCount < c.requiredServicesForStore && successfullyStoredCount >= c.reducedRequiredStores {
+					log.Error("das.Aggregator: store deadline budget exhausted, issuing a certificate for the reduced safety keyset", "signers", successfullyStoredCount, "required", c.requiredServicesForStore, "reducedAssumedHonest", c.reducedAssumedHonest, "keysetHash", common.Hash(c.reducedKeysetHash))
+					aggregatorReducedQuorumCounter.Inc(1)
+					cd := certDetails{}
+					cd.signers = append(cd.signers, signers...)
+					cd.pubKeys = append(cd.pubKeys, pubKeys...)
+					cd.sigs = append(cd.sigs, sigs...)
+					cd.aggSignersMask = aggSignersMask
+					cd.reduced = true
+					certDetailsChan <- cd
+					returned = true
+					cancelStores()
+				} else if successfullyStoredCount >= c.requiredServicesForStore {
 					cd := certDetails{}
+					cd.signers = append(cd.signers, signers...)
 					cd.pubKeys = append(cd.pubKeys, pubKeys...)
 					cd.sigs = append(cd.sigs, sigs...)
 					cd.aggSignersMask = aggSignersMask
 					certDetailsChan <- cd
 					returned = true
-					if a.maxAllowedServiceStoreFailures > 0 && // Ignore the case where AssumedHonest = 1, probably a testnet
-						storeFailures+1 > a.maxAllowedServiceStoreFailures {
+					if hedging {
+						cancelStores()
+					}
+					if c.maxAllowedServiceStoreFailures > 0 && // Ignore the case where AssumedHonest = 1, probably a testnet
+						storeFailures+1 > c.maxAllowedServiceStoreFailures {
 						log.Error("das.Aggregator: storing the batch data succeeded to enough DAS commitee members to generate the Data Availability Cert, but if one more had failed then the cert would not have been able to be generated. Look for preceding logs with \"Error from backend\"")
 					}
-				} else if storeFailures > a.maxAllowedServiceStoreFailures {
+				} else if storeFailures > maxAllowedStoreFailures {
 					cd := certDetails{}
-					cd.err = fmt.Errorf("aggregator failed to store message to at least %d out of %d DASes (assuming %d are honest). %w", a.requiredServicesForStore, len(a.services), a.config.AssumedHonest, BatchToDasFailed)
+					cd.err = fmt.Errorf("aggregator failed to store message to at least %d out of %d DASes (assuming %d are honest). %w", c.requiredServicesForStore, len(c.services), a.config.AssumedHonest, BatchToDasFailed)
 					certDetailsChan <- cd
 					returned = true
 				}
@@ -317,7 +581,12 @@ func (a *Aggregator) Store(ctx context.Context, message []byte, timeout uint64,
 
 	aggCert.DataHash = expectedHash
 	aggCert.Timeout = timeout
-	aggCert.KeysetHash = a.keysetHash
+	aggCert.KeysetHash = c.keysetHash
+	assumedHonest := a.config.AssumedHonest
+	if cd.reduced {
+		aggCert.KeysetHash = c.reducedKeysetHash
+		assumedHonest = c.reducedAssumedHonest
+	}
 	aggCert.Version = 1
 
 	verified, err := blsSignatures.VerifySignature(aggCert.Sig, aggCert.SerializeSignableFields(), aggPubKey)
@@ -328,14 +597,165 @@ func (a *Aggregator) Store(ctx context.Context, message []byte, timeout uint64,
 	if !verified {
 		return nil, fmt.Errorf("failed aggregate signature check. %w", BatchToDasFailed)
 	}
+	if err := c.validateCert(&aggCert, cd.signers, assumedHonest); err != nil {
+		//nolint:errorlint
+		return nil, fmt.Errorf("%s. %w", err.Error(), BatchToDasFailed)
+	}
 	return &aggCert, nil
 }
 
+// checkStoreSigner checks the store request was signed by the batch poster or
+// sequencer, if the aggregator was given the Sequencer Inbox to check against.
+func (a *Aggregator) checkStoreSigner(ctx context.Context, message []byte, timeout uint64, sig []byte) error {
+	if a.addrVerifier == nil {
+		return nil
+	}
+	actualSigner, err := DasRecoverSigner(message, timeout, sig)
+	if err != nil {
+		return err
+	}
+	isBatchPosterOrSequencer, err := a.addrVerifier.IsBatchPosterOrSequencer(ctx, actualSigner)
+	if err != nil {
+		return err
+	}
+	if !isBatchPosterOrSequencer {
+		return errors.New("store request not properly signed")
+	}
+	return nil
+}
+
+// validateCert checks the certificate the way it will be checked against the
+// keyset on chain: each signer's bit in the SignersMask must be its position
+// in the keyset, so that the keyset's public keys at the set bits are exactly
+// the signers'.
+func (c *aggregatorCommittee) validateCert(cert *arbstate.DataAvailabilityCertificate, signers []ServiceDetails, assumedHonest int) error {
+	keyset := &arbstate.DataAvailabilityKeyset{
+		AssumedHonest: uint64(assumedHonest),
+	}
+	for _, d := range c.services {
+		keyset.PubKeys = append(keyset.PubKeys, d.pubKey)
+	}
+	for _, d := range signers {
+		i := bits.TrailingZeros64(d.signersMask)
+		if i >= len(keyset.PubKeys) {
+			return fmt.Errorf("signer %v has signersMask %X beyond the %d members of the keyset", d.service, d.signersMask, len(keyset.PubKeys))
+		}
+		if !bytes.Equal(blsSignatures.PublicKeyToBytes(keyset.PubKeys[i]), blsSignatures.PublicKeyToBytes(d.pubKey)) {
+			return fmt.Errorf("signer %v has signersMask %X but its public key isn't at position %d of the keyset", d.service, d.signersMask, i)
+		}
+	}
+	if bits.OnesCount64(cert.SignersMask) != len(signers) {
+		return fmt.Errorf("signersMask %X doesn't have a bit for each of the %d signers", cert.SignersMask, len(signers))
+	}
+	return keyset.VerifySignature(cert.SignersMask, cert.SerializeSignableFields(), cert.Sig)
+}
+
+// storeToBackendAndVerify stores the message to a single backend and checks its
+// certificate, recording metrics for the outcome. Stores canceled because
+// enough signatures were collected from other backends aren't counted as
+// failures.
+func (a *Aggregator) storeToBackendAndVerify(ctx context.Context, d ServiceDetails, expectedHash common.Hash, expectedSignableFields []byte, message []byte, timeout uint64, sig []byte) storeResponse {
+	const metricBase string = "arb/das/rpc/aggregator/store"
+	var metricWithServiceName = metricBase + "/" + d.metricName
+	incFailureMetric := func() {
+		metrics.GetOrRegisterCounter(metricWithServiceName+"/error/total", nil).Inc(1)
+		metrics.GetOrRegisterCounter(metricBase+"/error/all/total", nil).Inc(1)
+	}
+	incSignatureFailureMetric := func() {
+		incFailureMetric()
+		metrics.GetOrRegisterCounter(metricWithServiceName+"/error/bad_response/total", nil).Inc(1)
+		metrics.GetOrRegisterCounter(metricWithServiceName+"/error/signature/total", nil).Inc(1)
+	}
+
+	metrics.GetOrRegisterCounter(metricWithServiceName+"/attempt/total", nil).Inc(1)
+	start := time.Now()
+	cert, err := a.storeToBackend(ctx, d, metricWithServiceName, message, timeout, sig)
+	duration := time.Since(start)
+	metrics.GetOrRegisterHistogram(metricWithServiceName+"/duration", nil, metrics.NewBoundedHistogramSample()).Update(duration.Nanoseconds())
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			metrics.GetOrRegisterCounter(metricWithServiceName+"/canceled/total", nil).Inc(1)
+			return storeResponse{d, nil, err}
+		}
+		incFailureMetric()
+		if errors.Is(err, context.DeadlineExceeded) {
+			metrics.GetOrRegisterCounter(metricWithServiceName+"/error/timeout/total", nil).Inc(1)
+		} else {
+			metrics.GetOrRegisterCounter(metricWithServiceName+"/error/client/total", nil).Inc(1)
+		}
+		return storeResponse{d, nil, err}
+	}
+
+	// SignersMask from backend DAS is ignored.
+
+	if cert.DataHash != expectedHash {
+		incFailureMetric()
+		metrics.GetOrRegisterCounter(metricWithServiceName+"/error/bad_response/total", nil).Inc(1)
+		return storeResponse{d, nil, errors.New("hash verification failed")}
+	}
+	if cert.Timeout != timeout {
+		incFailureMetric()
+		metrics.GetOrRegisterCounter(metricWithServiceName+"/error/bad_response/total", nil).Inc(1)
+		return storeResponse{d, nil, fmt.Errorf("timeout was %d, expected %d", cert.Timeout, timeout)}
+	}
+
+	// Verify the signature over the fields of the certificate that will be
+	// aggregated, rather than the fields the backend returned, so a signature
+	// over anything else (eg another certificate version) can't make the
+	// aggregate signature invalid.
+	verified, err := blsSignatures.VerifySignature(
+		cert.Sig, expectedSignableFields, d.pubKey,
+	)
+	if err != nil {
+		incSignatureFailureMetric()
+		return storeResponse{d, nil, err}
+	}
+	if !verified {
+		incSignatureFailureMetric()
+		return storeResponse{d, nil, fmt.Errorf("signature verification failed against public key of backend with signersMask %d", d.signersMask)}
+	}
+
+	metrics.GetOrRegisterCounter(metricWithServiceName+"/success/total", nil).Inc(1)
+	metrics.GetOrRegisterCounter(metricBase+"/success/all/total", nil).Inc(1)
+	metrics.GetOrRegisterHistogram(metricWithServiceName+"/success/duration", nil, metrics.NewBoundedHistogramSample()).Update(duration.Nanoseconds())
+	return storeResponse{d, cert.Sig, nil}
+}
+
+// storeToBackend calls Store on a single backend, retrying failures according
+// to the backend's StorePolicy until it succeeds, runs out of retries, or ctx
+// is done.
+func (a *Aggregator) storeToBackend(ctx context.Context, d ServiceDetails, metricName string, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	requestTimeout := a.requestTimeout
+	if d.policy.Timeout > 0 {
+		requestTimeout = d.policy.Timeout
+	}
+	backoff := d.policy.Backoff
+	for attempt := 0; ; attempt++ {
+		storeCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+		cert, err := d.service.Store(storeCtx, message, timeout, sig)
+		cancel()
+		if err == nil || attempt >= d.policy.Retries || ctx.Err() != nil {
+			return cert, err
+		}
+		log.Debug("das.Aggregator: retrying Store to backend", "backend", d.service, "attempt", attempt+1, "backoff", backoff, "err", err)
+		metrics.GetOrRegisterCounter(metricName+"/retry/total", nil).Inc(1)
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if d.policy.MaxBackoff > 0 && backoff > d.policy.MaxBackoff {
+			backoff = d.policy.MaxBackoff
+		}
+	}
+}
+
 func (a *Aggregator) String() string {
 	var b bytes.Buffer
 	b.WriteString("das.Aggregator{")
 	first := true
-	for _, d := range a.services {
+	for _, d := range a.committee.Load().services {
 		if !first {
 			b.WriteString(",")
 		}
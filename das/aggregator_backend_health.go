@@ -0,0 +1,196 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+type AggregatorHealthCheckConfig struct {
+	Interval time.Duration `koanf:"interval"`
+	Timeout  time.Duration `koanf:"timeout"`
+}
+
+var DefaultAggregatorHealthCheckConfig = AggregatorHealthCheckConfig{
+	Interval: 30 * time.Second,
+	Timeout:  5 * time.Second,
+}
+
+func AggregatorHealthCheckConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Duration(prefix+".interval", DefaultAggregatorHealthCheckConfig.Interval, "how often to health check each backend; backends failing their health check are skipped by Store until they pass again (0 to disable)")
+	f.Duration(prefix+".timeout", DefaultAggregatorHealthCheckConfig.Timeout, "timeout of each backend health check")
+}
+
+type CircuitBreakerConfig struct {
+	FailureThreshold int           `koanf:"failure-threshold"`
+	OpenDuration     time.Duration `koanf:"open-duration"`
+}
+
+var DefaultCircuitBreakerConfig = CircuitBreakerConfig{
+	FailureThreshold: 5,
+	OpenDuration:     time.Minute,
+}
+
+func CircuitBreakerConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Int(prefix+".failure-threshold", DefaultCircuitBreakerConfig.FailureThreshold, "number of consecutive failed Stores after which a backend is skipped (0 to disable the circuit breaker)")
+	f.Duration(prefix+".open-duration", DefaultCircuitBreakerConfig.OpenDuration, "how long a backend is skipped after tripping the circuit breaker before it is tried again")
+}
+
+var errBackendUnavailable = errors.New("backend skipped: circuit breaker open or failing health checks")
+
+// BackendStatus is a snapshot of the aggregator's view of one backend.
+type BackendStatus struct {
+	Backend             string
+	SignersMask         uint64
+	Healthy             bool
+	CircuitOpen         bool
+	ConsecutiveFailures int
+	LastError           string
+}
+
+// backendHealth tracks whether a backend is passing its health checks and the
+// state of its circuit breaker. Once the breaker has been open for
+// OpenDuration, the next Store is let through; if it fails the breaker opens
+// again, otherwise it closes.
+type backendHealth struct {
+	config CircuitBreakerConfig
+
+	mutex               sync.Mutex
+	healthy             bool
+	consecutiveFailures int
+	openUntil           time.Time
+	lastErr             error
+
+	healthyGauge     metrics.Gauge
+	circuitOpenGauge metrics.Gauge
+}
+
+func newBackendHealth(config CircuitBreakerConfig, metricName string) *backendHealth {
+	const metricBase string = "arb/das/rpc/aggregator/backend/"
+	h := &backendHealth{
+		config:           config,
+		healthy:          true,
+		healthyGauge:     metrics.GetOrRegisterGauge(metricBase+metricName+"/healthy", nil),
+		circuitOpenGauge: metrics.GetOrRegisterGauge(metricBase+metricName+"/circuit_open", nil),
+	}
+	h.updateGauges(time.Now())
+	return h
+}
+
+// updateGauges must be called with the mutex held, or before h is shared.
+func (h *backendHealth) updateGauges(now time.Time) {
+	var healthy, open int64
+	if h.healthy {
+		healthy = 1
+	}
+	if now.Before(h.openUntil) {
+		open = 1
+	}
+	h.healthyGauge.Update(healthy)
+	h.circuitOpenGauge.Update(open)
+}
+
+func (h *backendHealth) available(now time.Time) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.healthy && !now.Before(h.openUntil)
+}
+
+func (h *backendHealth) recordSuccess() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.consecutiveFailures = 0
+	h.openUntil = time.Time{}
+	h.updateGauges(time.Now())
+}
+
+func (h *backendHealth) recordFailure(err error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.consecutiveFailures++
+	h.lastErr = err
+	now := time.Now()
+	if h.config.FailureThreshold > 0 && h.consecutiveFailures >= h.config.FailureThreshold {
+		h.openUntil = now.Add(h.config.OpenDuration)
+	}
+	// An overloaded backend is given the time it asked for to catch up.
+	var overloaded *OverloadedError
+	if errors.As(err, &overloaded) && now.Add(overloaded.RetryAfter).After(h.openUntil) {
+		h.openUntil = now.Add(overloaded.RetryAfter)
+	}
+	h.updateGauges(now)
+}
+
+func (h *backendHealth) recordHealthCheck(err error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.healthy = err == nil
+	if err != nil {
+		h.lastErr = err
+	}
+	h.updateGauges(time.Now())
+}
+
+func (h *backendHealth) status(d *ServiceDetails) BackendStatus {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	status := BackendStatus{
+		Backend:             d.service.String(),
+		SignersMask:         d.signersMask,
+		Healthy:             h.healthy,
+		CircuitOpen:         time.Now().Before(h.openUntil),
+		ConsecutiveFailures: h.consecutiveFailures,
+	}
+	if h.lastErr != nil {
+		status.LastError = h.lastErr.Error()
+	}
+	return status
+}
+
+// checkBackendHealth health checks each backend that supports it, returning
+// the time until the next round of checks.
+func (a *Aggregator) checkBackendHealth(ctx context.Context) time.Duration {
+	c := a.committee.Load()
+	var wg sync.WaitGroup
+	for i := range c.services {
+		checker, ok := c.services[i].service.(DataAvailabilityServiceHealthChecker)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(d *ServiceDetails, h *backendHealth) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, a.config.HealthCheck.Timeout)
+			defer cancel()
+			err := checker.HealthCheck(checkCtx)
+			if err != nil && ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				log.Warn("das.Aggregator: backend failed health check", "backend", d.service, "err", err)
+			}
+			h.recordHealthCheck(err)
+		}(&c.services[i], c.health[i])
+	}
+	wg.Wait()
+	return a.config.HealthCheck.Interval
+}
+
+// BackendStatus returns the health and circuit breaker state of each backend.
+func (a *Aggregator) BackendStatus() []BackendStatus {
+	c := a.committee.Load()
+	statuses := make([]BackendStatus, 0, len(c.services))
+	for i := range c.services {
+		statuses = append(statuses, c.health[i].status(&c.services[i]))
+	}
+	return statuses
+}
@@ -0,0 +1,192 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+// DataAvailabilityServiceBatchWriter is implemented by writers that can store
+// several independent messages in one call, returning a certificate for each
+// in the same order.
+type DataAvailabilityServiceBatchWriter interface {
+	StoreBatch(ctx context.Context, items []StoreBatchItem) ([]*arbstate.DataAvailabilityCertificate, error)
+}
+
+var aggregatorStoreBatchCounter = metrics.NewRegisteredCounter("arb/das/rpc/aggregator/storebatch/total", nil)
+
+type batchStoreResponse struct {
+	index int
+	// sigs has the backend's signature for each message it stored, and nil
+	// for the rest.
+	sigs []blsSignatures.Signature
+	err  error
+}
+
+// StoreBatch stores several messages with one request to each backend that
+// supports das_storeBatch, and one request per message to the others. Each
+// message gets its own certificate once K backends have signed it, and the
+// batch fails if any message can't get one.
+//
+// Unlike Store, StoreBatch sends every message to every backend, so hedging,
+// health and the store deadline budget don't apply to it.
+func (a *Aggregator) StoreBatch(ctx context.Context, items []StoreBatchItem) ([]*arbstate.DataAvailabilityCertificate, error) {
+	if len(items) > MaxRPCBatchItems {
+		return nil, fmt.Errorf("batch of %d messages is larger than the maximum of %d", len(items), MaxRPCBatchItems)
+	}
+	for _, item := range items {
+		if err := a.checkStoreSigner(ctx, item.Message, uint64(item.Timeout), item.Sig); err != nil {
+			return nil, err
+		}
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	aggregatorStoreBatchCounter.Inc(1)
+
+	c := a.committee.Load()
+	expectedHashes := make([]common.Hash, len(items))
+	expectedSignableFields := make([][]byte, len(items))
+	for i, item := range items {
+		expectedHashes[i] = dastree.Hash(item.Message)
+		expectedSignableFields[i] = (&arbstate.DataAvailabilityCertificate{
+			DataHash: expectedHashes[i],
+			Timeout:  uint64(item.Timeout),
+			Version:  1,
+		}).SerializeSignableFields()
+	}
+
+	storeCtx, cancelStores := context.WithCancel(ctx)
+	defer cancelStores()
+	responses := make(chan batchStoreResponse, len(c.services))
+	for i := range c.services {
+		go func(i int) {
+			sigs, err := a.storeBatchToBackend(storeCtx, c.services[i], items, expectedHashes, expectedSignableFields)
+			if err == nil {
+				c.health[i].recordSuccess()
+			} else if storeCtx.Err() == nil {
+				c.health[i].recordFailure(err)
+			}
+			responses <- batchStoreResponse{i, sigs, err}
+		}(i)
+	}
+
+	signers := make([][]ServiceDetails, len(items))
+	sigs := make([][]blsSignatures.Signature, len(items))
+	failures := make([]int, len(items))
+	certified := 0
+	for received := 0; received < len(c.services) && certified < len(items); received++ {
+		var r batchStoreResponse
+		select {
+		case <-ctx.Done():
+			//nolint:errorlint
+			return nil, fmt.Errorf("%s. %w", ctx.Err().Error(), BatchToDasFailed)
+		case r = <-responses:
+		}
+		d := c.services[r.index]
+		if r.err != nil {
+			log.Warn("das.Aggregator: Error from backend storing batch", "backend", d.metricName, "err", r.err)
+		}
+		for i := range items {
+			if r.err != nil || r.sigs[i] == nil {
+				failures[i]++
+				if failures[i] > c.maxAllowedServiceStoreFailures {
+					return nil, fmt.Errorf("aggregator failed to store message %d of the batch to at least %d out of %d DASes (assuming %d are honest). %w", i, c.requiredServicesForStore, len(c.services), a.config.AssumedHonest, BatchToDasFailed)
+				}
+				continue
+			}
+			if len(signers[i]) >= c.requiredServicesForStore {
+				continue
+			}
+			signers[i] = append(signers[i], d)
+			sigs[i] = append(sigs[i], r.sigs[i])
+			if len(signers[i]) == c.requiredServicesForStore {
+				certified++
+			}
+		}
+	}
+	if certified < len(items) {
+		return nil, fmt.Errorf("aggregator failed to store every message of the batch to enough DASes. %w", BatchToDasFailed)
+	}
+
+	certs := make([]*arbstate.DataAvailabilityCertificate, len(items))
+	for i, item := range items {
+		cert := &arbstate.DataAvailabilityCertificate{
+			DataHash:   expectedHashes[i],
+			Timeout:    uint64(item.Timeout),
+			KeysetHash: c.keysetHash,
+			Sig:        blsSignatures.AggregateSignatures(sigs[i]),
+			Version:    1,
+		}
+		for _, d := range signers[i] {
+			cert.SignersMask |= d.signersMask
+		}
+		if err := c.validateCert(cert, signers[i], a.config.AssumedHonest); err != nil {
+			//nolint:errorlint
+			return nil, fmt.Errorf("%s. %w", err.Error(), BatchToDasFailed)
+		}
+		certs[i] = cert
+	}
+	return certs, nil
+}
+
+// storeBatchToBackend returns the backend's verified signature for each of
+// the messages, or nil for the messages it didn't sign properly.
+func (a *Aggregator) storeBatchToBackend(ctx context.Context, d ServiceDetails, items []StoreBatchItem, expectedHashes []common.Hash, expectedSignableFields [][]byte) ([]blsSignatures.Signature, error) {
+	requestTimeout := a.requestTimeout
+	if d.policy.Timeout > 0 {
+		requestTimeout = d.policy.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	certs := make([]*arbstate.DataAvailabilityCertificate, len(items))
+	if batchWriter, ok := d.service.(DataAvailabilityServiceBatchWriter); ok {
+		var err error
+		certs, err = batchWriter.StoreBatch(ctx, items)
+		if err != nil {
+			return nil, err
+		}
+		if len(certs) != len(items) {
+			return nil, fmt.Errorf("backend returned %d certificates for %d messages", len(certs), len(items))
+		}
+	} else {
+		for i, item := range items {
+			cert, err := d.service.Store(ctx, item.Message, uint64(item.Timeout), item.Sig)
+			if err != nil {
+				return nil, err
+			}
+			certs[i] = cert
+		}
+	}
+
+	sigs := make([]blsSignatures.Signature, len(items))
+	var errs []error
+	for i, cert := range certs {
+		if cert.DataHash != expectedHashes[i] || cert.Timeout != uint64(items[i].Timeout) {
+			errs = append(errs, fmt.Errorf("message %d: certificate for the wrong data or timeout", i))
+			continue
+		}
+		verified, err := blsSignatures.VerifySignature(cert.Sig, expectedSignableFields[i], d.pubKey)
+		if err != nil || !verified {
+			errs = append(errs, fmt.Errorf("message %d: signature verification failed against public key of backend with signersMask %d", i, d.signersMask))
+			continue
+		}
+		sigs[i] = cert.Sig
+	}
+	if len(errs) > 0 {
+		log.Warn("das.Aggregator: Bad certificates from backend storing batch", "backend", d.metricName, "err", errors.Join(errs...))
+	}
+	return sigs, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/signature"
+)
+
+// BackendCheck is the result of checking that a committee member is
+// reachable and signs Stores with the public key in the keyset.
+type BackendCheck struct {
+	Backend      string
+	SignersMask  uint64
+	HealthErr    error
+	StoreErr     error
+	StoreLatency time.Duration
+}
+
+// CheckBackends sends a health check and a Store of message to each backend
+// in the committee, and checks the certificate each returns as Store does,
+// without aggregating them. If signer is set, the Store is signed with it as
+// the batch poster would sign it.
+func (a *Aggregator) CheckBackends(ctx context.Context, message []byte, timeout uint64, signer signature.DataSignerFunc) ([]BackendCheck, error) {
+	var sig []byte
+	if signer != nil {
+		var err error
+		sig, err = applyDasSigner(signer, message, timeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+	c := a.committee.Load()
+	expectedHash := dastree.Hash(message)
+	expectedSignableFields := (&arbstate.DataAvailabilityCertificate{
+		DataHash: expectedHash,
+		Timeout:  timeout,
+		Version:  1,
+	}).SerializeSignableFields()
+
+	checks := make([]BackendCheck, len(c.services))
+	var wg sync.WaitGroup
+	for i := range c.services {
+		wg.Add(1)
+		go func(d ServiceDetails, check *BackendCheck) {
+			defer wg.Done()
+			check.Backend = d.service.String()
+			check.SignersMask = d.signersMask
+			if checker, ok := d.service.(DataAvailabilityServiceHealthChecker); ok {
+				checkCtx, cancel := context.WithTimeout(ctx, a.config.HealthCheck.Timeout)
+				check.HealthErr = checker.HealthCheck(checkCtx)
+				cancel()
+			}
+			start := time.Now()
+			response := a.storeToBackendAndVerify(ctx, d, expectedHash, expectedSignableFields, message, timeout, sig)
+			check.StoreLatency = time.Since(start)
+			check.StoreErr = response.err
+		}(c.services[i], &checks[i])
+	}
+	wg.Wait()
+	return checks, nil
+}
+
+// KeysetHash returns the hash of the current committee's keyset.
+func (a *Aggregator) KeysetHash() common.Hash {
+	return a.committee.Load().keysetHash
+}
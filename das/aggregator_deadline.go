@@ -0,0 +1,73 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	OnBudgetExhaustedFail          = "fail"
+	OnBudgetExhaustedFallback      = "fallback"
+	OnBudgetExhaustedReducedQuorum = "reduced-quorum"
+)
+
+type StoreDeadlineConfig struct {
+	Budget               time.Duration `koanf:"budget"`
+	OnExhausted          string        `koanf:"on-exhausted"`
+	ReducedAssumedHonest int           `koanf:"reduced-assumed-honest"`
+}
+
+var DefaultStoreDeadlineConfig = StoreDeadlineConfig{
+	Budget:               0,
+	OnExhausted:          OnBudgetExhaustedFallback,
+	ReducedAssumedHonest: 0,
+}
+
+func StoreDeadlineConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Duration(prefix+".budget", DefaultStoreDeadlineConfig.Budget, "total time Store may take to collect enough signatures for a certificate, across all backends and retries, before on-exhausted applies (0 for no budget)")
+	f.String(prefix+".on-exhausted", DefaultStoreDeadlineConfig.OnExhausted, fmt.Sprintf("what to do when the store deadline budget is exhausted; valid options are '%s' (return an error so the batch poster retries), '%s' (return an error so the batch poster falls back to posting the data on chain, unless that is disabled) and '%s' (return a certificate for the reduced-assumed-honest keyset as soon as it has enough signatures)", OnBudgetExhaustedFail, OnBudgetExhaustedFallback, OnBudgetExhaustedReducedQuorum))
+	f.Int(prefix+".reduced-assumed-honest", DefaultStoreDeadlineConfig.ReducedAssumedHonest, "assumed-honest of the keyset certificates are issued for once the budget is exhausted with on-exhausted 'reduced-quorum'; it must be larger than assumed-honest, so fewer signatures are needed, and the keyset must also be valid on chain")
+}
+
+var ErrStoreBudgetExhausted = errors.New("store deadline budget exhausted")
+
+var (
+	aggregatorBudgetExhaustedCounter = metrics.NewRegisteredCounter("arb/das/rpc/aggregator/store/budget_exhausted/total", nil)
+	aggregatorReducedQuorumCounter   = metrics.NewRegisteredCounter("arb/das/rpc/aggregator/store/reduced_quorum/total", nil)
+)
+
+func (c StoreDeadlineConfig) validate(assumedHonest int, committeeSize int) error {
+	if c.Budget == 0 {
+		return nil
+	}
+	switch c.OnExhausted {
+	case OnBudgetExhaustedFail, OnBudgetExhaustedFallback:
+		return nil
+	case OnBudgetExhaustedReducedQuorum:
+		if c.ReducedAssumedHonest <= assumedHonest || c.ReducedAssumedHonest > committeeSize {
+			return fmt.Errorf("store-deadline.reduced-assumed-honest must be larger than assumed-honest (%d) and at most the number of backends (%d), got %d", assumedHonest, committeeSize, c.ReducedAssumedHonest)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown store-deadline.on-exhausted '%s', use --help to see available options", c.OnExhausted)
+	}
+}
+
+// budgetExhaustedError is the error Store returns when the budget runs out
+// before a certificate can be issued, which the batch poster only falls back
+// to posting the data on chain for if the policy says to.
+func (c StoreDeadlineConfig) budgetExhaustedError(stored int, total int, required int) error {
+	err := fmt.Errorf("aggregator only stored message to %d out of %d DASes within the store deadline budget of %v, needed %d", stored, total, c.Budget, required)
+	if c.OnExhausted == OnBudgetExhaustedFail {
+		return fmt.Errorf("%s. %w", err.Error(), ErrStoreBudgetExhausted)
+	}
+	return fmt.Errorf("%s. %w", err.Error(), BatchToDasFailed)
+}
@@ -0,0 +1,69 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+type HedgingConfig struct {
+	Delay              time.Duration `koanf:"delay"`
+	ExtraInitialStores int           `koanf:"extra-initial-stores"`
+	StoresPerDelay     int           `koanf:"stores-per-delay"`
+}
+
+var DefaultHedgingConfig = HedgingConfig{
+	Delay:              0,
+	ExtraInitialStores: 0,
+	StoresPerDelay:     1,
+}
+
+func HedgingConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Duration(prefix+".delay", DefaultHedgingConfig.Delay, "if non-zero, Store is first sent to only as many backends as are needed for a certificate, then to more backends each time this delay passes without enough signatures, and outstanding requests are canceled once there are enough (0 sends Store to every backend at once)")
+	f.Int(prefix+".extra-initial-stores", DefaultHedgingConfig.ExtraInitialStores, "number of backends beyond the number needed for a certificate to send Store to straight away when hedging")
+	f.Int(prefix+".stores-per-delay", DefaultHedgingConfig.StoresPerDelay, "number of additional backends to send Store to each time the hedging delay passes")
+}
+
+var errHedgeNotSent = errors.New("store not sent to backend: enough signatures were collected or the request was canceled first")
+
+// hedgeStores sends Store to the pending backends, StoresPerDelay at a time
+// each time the hedging delay passes, or one at a time straight away when a
+// backend fails. Once ctx is done, the backends it hasn't sent to are passed
+// to notSent instead.
+func (a *Aggregator) hedgeStores(ctx context.Context, pending []int, launch func(int), notSent func(int), failed <-chan struct{}) {
+	timer := time.NewTimer(a.config.Hedging.Delay)
+	defer timer.Stop()
+	for len(pending) > 0 {
+		var n int
+		select {
+		case <-ctx.Done():
+			for _, i := range pending {
+				notSent(i)
+			}
+			return
+		case <-failed:
+			n = 1
+		case <-timer.C:
+			n = a.config.Hedging.StoresPerDelay
+			if n < 1 {
+				n = 1
+			}
+			timer.Reset(a.config.Hedging.Delay)
+		}
+		if n > len(pending) {
+			n = len(pending)
+		}
+		for _, i := range pending[:n] {
+			launch(i)
+		}
+		metrics.GetOrRegisterCounter("arb/das/rpc/aggregator/store/hedged/total", nil).Inc(int64(n))
+		pending = pending[n:]
+	}
+}
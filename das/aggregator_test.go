@@ -6,12 +6,15 @@ package das
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math/bits"
 	"math/rand"
 	"os"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -19,6 +22,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
 )
 
 func TestDAS_BasicAggregationLocal(t *testing.T) {
@@ -288,3 +292,605 @@ func TestDAS_AtLeastHStorageFailures(t *testing.T) {
 		})
 	}
 }
+
+type hangingStore struct {
+	DataAvailabilityServiceWriter
+}
+
+func (h *hangingStore) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func newAggregatorWithHangingBackends(t *testing.T, ctx context.Context, numBackendDAS, numHanging int, aggConfig AggregatorConfig) *Aggregator {
+	var backends []ServiceDetails
+	for i := 0; i < numBackendDAS; i++ {
+		privKey, err := blsSignatures.GeneratePrivKeyString()
+		Require(t, err)
+		config := DataAvailabilityConfig{
+			Enable: true,
+			Key: KeyConfig{
+				PrivKey: privKey,
+			},
+			ParentChainNodeURL: "none",
+		}
+		das, err := NewSignAfterStoreDASWriter(ctx, config, NewMemoryBackedStorageService(ctx))
+		Require(t, err)
+		var writer DataAvailabilityServiceWriter = das
+		if i < numHanging {
+			writer = &hangingStore{das}
+		}
+		details, err := NewServiceDetails(writer, *das.pubKey, uint64(1<<i), "service"+strconv.Itoa(i))
+		Require(t, err)
+		backends = append(backends, *details)
+	}
+	aggregator, err := NewAggregator(
+		ctx,
+		DataAvailabilityConfig{
+			RPCAggregator:      aggConfig,
+			ParentChainNodeURL: "none",
+			RequestTimeout:     time.Minute,
+		}, backends)
+	Require(t, err)
+	return aggregator
+}
+
+func TestDAS_AggregatorReturnsAtQuorum(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// K=N+1-H=3, so the two hanging backends aren't needed.
+	aggregator := newAggregatorWithHangingBackends(t, ctx, 5, 2, AggregatorConfig{AssumedHonest: 3})
+	start := time.Now()
+	cert, err := aggregator.Store(ctx, []byte("It's time for you to see the fnords."), 0, []byte{})
+	Require(t, err, "Error storing message")
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		Fail(t, "Store waited for the hanging backends", elapsed)
+	}
+	if bits.OnesCount64(cert.SignersMask) != 3 {
+		Fail(t, "unexpected signers mask", cert.SignersMask)
+	}
+}
+
+func TestDAS_AggregatorContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	aggregator := newAggregatorWithHangingBackends(t, ctx, 3, 3, AggregatorConfig{AssumedHonest: 1})
+	storeCtx, storeCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer storeCancel()
+	_, err := aggregator.Store(storeCtx, []byte("It's time for you to see the fnords."), 0, []byte{})
+	if !errors.Is(err, BatchToDasFailed) {
+		Fail(t, "expected Store to fail when its context was done", err)
+	}
+}
+
+func TestDAS_AggregatorStoreDeadline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rawMsg := []byte("It's time for you to see the fnords.")
+
+	// K=N+1-H=4 with H=2, but only three backends respond.
+	aggConfig := AggregatorConfig{AssumedHonest: 2}
+	aggConfig.StoreDeadline = StoreDeadlineConfig{Budget: 100 * time.Millisecond, OnExhausted: OnBudgetExhaustedFail}
+	aggregator := newAggregatorWithHangingBackends(t, ctx, 5, 2, aggConfig)
+	_, err := aggregator.Store(ctx, rawMsg, 0, []byte{})
+	if !errors.Is(err, ErrStoreBudgetExhausted) || errors.Is(err, BatchToDasFailed) {
+		Fail(t, "expected Store to fail without falling back", err)
+	}
+
+	aggConfig.StoreDeadline.OnExhausted = OnBudgetExhaustedFallback
+	aggregator = newAggregatorWithHangingBackends(t, ctx, 5, 2, aggConfig)
+	_, err = aggregator.Store(ctx, rawMsg, 0, []byte{})
+	if !errors.Is(err, BatchToDasFailed) {
+		Fail(t, "expected Store to fall back", err)
+	}
+
+	// With H=3 the reduced quorum is K=3, which the responding backends meet.
+	aggConfig.StoreDeadline.OnExhausted = OnBudgetExhaustedReducedQuorum
+	aggConfig.StoreDeadline.ReducedAssumedHonest = 3
+	aggregator = newAggregatorWithHangingBackends(t, ctx, 5, 2, aggConfig)
+	start := time.Now()
+	cert, err := aggregator.Store(ctx, rawMsg, 0, []byte{})
+	Require(t, err, "Error storing message with a reduced quorum")
+	if elapsed := time.Since(start); elapsed < aggConfig.StoreDeadline.Budget {
+		Fail(t, "reduced quorum certificate issued before the budget was exhausted", elapsed)
+	}
+	if cert.KeysetHash != aggregator.committee.Load().reducedKeysetHash {
+		Fail(t, "certificate isn't for the reduced keyset")
+	}
+	if bits.OnesCount64(cert.SignersMask) != 3 {
+		Fail(t, "unexpected signers mask", cert.SignersMask)
+	}
+
+	// Without a budget the full quorum's keyset is still used.
+	aggConfig.StoreDeadline.Budget = 0
+	aggregator = newAggregatorWithHangingBackends(t, ctx, 5, 1, aggConfig)
+	cert, err = aggregator.Store(ctx, rawMsg, 0, []byte{})
+	Require(t, err, "Error storing message")
+	if cert.KeysetHash != aggregator.committee.Load().keysetHash {
+		Fail(t, "certificate isn't for the full keyset")
+	}
+
+	for _, reduced := range []int{2, 6} {
+		_, err = NewAggregator(ctx, DataAvailabilityConfig{
+			RPCAggregator: AggregatorConfig{
+				AssumedHonest: 2,
+				StoreDeadline: StoreDeadlineConfig{Budget: time.Second, OnExhausted: OnBudgetExhaustedReducedQuorum, ReducedAssumedHonest: reduced},
+			},
+			ParentChainNodeURL: "none",
+		}, aggregator.committee.Load().services)
+		if err == nil {
+			Fail(t, "expected invalid reduced-assumed-honest to be rejected", reduced)
+		}
+	}
+}
+
+func TestDAS_AggregatorStoreBatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var items []StoreBatchItem
+	for i := 0; i < 3; i++ {
+		items = append(items, StoreBatchItem{Message: []byte("It's time for you to see the fnords " + strconv.Itoa(i))})
+	}
+
+	// K=N+1-H=4, so the hanging backend isn't needed.
+	aggregator := newAggregatorWithHangingBackends(t, ctx, 5, 1, AggregatorConfig{AssumedHonest: 2})
+	certs, err := aggregator.StoreBatch(ctx, items)
+	Require(t, err, "Error storing batch")
+	if len(certs) != len(items) {
+		Fail(t, "unexpected number of certificates", len(certs))
+	}
+	for i, cert := range certs {
+		if cert.DataHash != dastree.Hash(items[i].Message) {
+			Fail(t, "certificate out of order", i)
+		}
+		if bits.OnesCount64(cert.SignersMask) != 4 {
+			Fail(t, "unexpected signers mask", cert.SignersMask)
+		}
+	}
+
+	aggregator = newAggregatorWithHangingBackends(t, ctx, 5, 2, AggregatorConfig{AssumedHonest: 2})
+	storeCtx, storeCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer storeCancel()
+	if _, err := aggregator.StoreBatch(storeCtx, items); !errors.Is(err, BatchToDasFailed) {
+		Fail(t, "expected StoreBatch to fail without enough backends", err)
+	}
+}
+
+type flakyStore struct {
+	DataAvailabilityServiceWriter
+	failures int32
+}
+
+func (f *flakyStore) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	if atomic.AddInt32(&f.failures, -1) >= 0 {
+		return nil, errors.New("expected Store failure")
+	}
+	return f.DataAvailabilityServiceWriter.Store(ctx, message, timeout, sig)
+}
+
+func TestDAS_AggregatorStorePolicyRetries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	privKey, err := blsSignatures.GeneratePrivKeyString()
+	Require(t, err)
+	config := DataAvailabilityConfig{
+		Enable: true,
+		Key: KeyConfig{
+			PrivKey: privKey,
+		},
+		ParentChainNodeURL: "none",
+	}
+	das, err := NewSignAfterStoreDASWriter(ctx, config, NewMemoryBackedStorageService(ctx))
+	Require(t, err)
+	flaky := &flakyStore{DataAvailabilityServiceWriter: das, failures: 2}
+	details, err := NewServiceDetails(flaky, *das.pubKey, 1, "service0")
+	Require(t, err)
+
+	aggConfig := DataAvailabilityConfig{
+		RPCAggregator:      AggregatorConfig{AssumedHonest: 1},
+		ParentChainNodeURL: "none",
+		RequestTimeout:     time.Second,
+	}
+	rawMsg := []byte("It's time for you to see the fnords.")
+
+	details.policy = StorePolicy{Retries: 1, Backoff: time.Millisecond}
+	aggregator, err := NewAggregator(ctx, aggConfig, []ServiceDetails{*details})
+	Require(t, err)
+	if _, err := aggregator.Store(ctx, rawMsg, 0, []byte{}); err == nil {
+		Fail(t, "expected Store to fail with too few retries")
+	}
+
+	flaky.failures = 2
+	details.policy = StorePolicy{Retries: 2, Backoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+	aggregator, err = NewAggregator(ctx, aggConfig, []ServiceDetails{*details})
+	Require(t, err)
+	_, err = aggregator.Store(ctx, rawMsg, 0, []byte{})
+	Require(t, err, "Error storing message with retries")
+}
+
+func TestDAS_BackendConfigStorePolicy(t *testing.T) {
+	b := BackendConfig{URL: "http://localhost:9876", Timeout: "3s", Retries: 2, Backoff: "100ms", MaxBackoff: "1s"}
+	policy, err := b.storePolicy()
+	Require(t, err)
+	expected := StorePolicy{Timeout: 3 * time.Second, Retries: 2, Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	if policy != expected {
+		Fail(t, "unexpected store policy", policy)
+	}
+	b.Backoff = "soon"
+	if _, err := b.storePolicy(); err == nil {
+		Fail(t, "expected invalid backoff to be rejected")
+	}
+}
+
+type failingStore struct {
+	DataAvailabilityServiceWriter
+	calls int32
+}
+
+func (f *failingStore) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	atomic.AddInt32(&f.calls, 1)
+	return nil, errors.New("expected Store failure")
+}
+
+func TestDAS_AggregatorCircuitBreaker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var backends []ServiceDetails
+	var failing *failingStore
+	for i := 0; i < 3; i++ {
+		privKey, err := blsSignatures.GeneratePrivKeyString()
+		Require(t, err)
+		config := DataAvailabilityConfig{
+			Enable: true,
+			Key: KeyConfig{
+				PrivKey: privKey,
+			},
+			ParentChainNodeURL: "none",
+		}
+		das, err := NewSignAfterStoreDASWriter(ctx, config, NewMemoryBackedStorageService(ctx))
+		Require(t, err)
+		var writer DataAvailabilityServiceWriter = das
+		if i == 0 {
+			failing = &failingStore{DataAvailabilityServiceWriter: das}
+			writer = failing
+		}
+		details, err := NewServiceDetails(writer, *das.pubKey, uint64(1<<i), "breaker"+strconv.Itoa(i))
+		Require(t, err)
+		backends = append(backends, *details)
+	}
+	aggConfig := DefaultAggregatorConfig
+	aggConfig.AssumedHonest = 2
+	aggConfig.CircuitBreaker = CircuitBreakerConfig{FailureThreshold: 2, OpenDuration: time.Hour}
+	aggregator, err := NewAggregator(ctx, DataAvailabilityConfig{
+		RPCAggregator:      aggConfig,
+		ParentChainNodeURL: "none",
+		RequestTimeout:     time.Second,
+	}, backends)
+	Require(t, err)
+
+	rawMsg := []byte("It's time for you to see the fnords.")
+	for i := 0; i < 2; i++ {
+		_, err := aggregator.Store(ctx, rawMsg, 0, []byte{})
+		Require(t, err)
+	}
+	// Store returns at quorum, so wait for the failing backend's response.
+	for i := 0; i < 100 && !aggregator.BackendStatus()[0].CircuitOpen; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	status := aggregator.BackendStatus()
+	if !status[0].CircuitOpen || status[0].ConsecutiveFailures != 2 {
+		Fail(t, "expected circuit breaker to be open", status[0])
+	}
+	if status[1].CircuitOpen || status[2].CircuitOpen {
+		Fail(t, "unexpected open circuit breaker", status)
+	}
+
+	_, err = aggregator.Store(ctx, rawMsg, 0, []byte{})
+	Require(t, err)
+	if calls := atomic.LoadInt32(&failing.calls); calls != 2 {
+		Fail(t, "expected backend with open circuit breaker to be skipped, got calls", calls)
+	}
+}
+
+func TestDAS_AggregatorReloadBackends(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newBackends := func(n int) []ServiceDetails {
+		var backends []ServiceDetails
+		for i := 0; i < n; i++ {
+			privKey, err := blsSignatures.GeneratePrivKeyString()
+			Require(t, err)
+			config := DataAvailabilityConfig{
+				Enable: true,
+				Key: KeyConfig{
+					PrivKey: privKey,
+				},
+				ParentChainNodeURL: "none",
+			}
+			das, err := NewSignAfterStoreDASWriter(ctx, config, NewMemoryBackedStorageService(ctx))
+			Require(t, err)
+			details, err := NewServiceDetails(das, *das.pubKey, uint64(1<<i), "reload"+strconv.Itoa(i))
+			Require(t, err)
+			backends = append(backends, *details)
+		}
+		return backends
+	}
+
+	aggregator, err := NewAggregator(ctx, DataAvailabilityConfig{
+		RPCAggregator:      AggregatorConfig{AssumedHonest: 1},
+		ParentChainNodeURL: "none",
+		RequestTimeout:     time.Second,
+	}, newBackends(2))
+	Require(t, err)
+
+	rawMsg := []byte("It's time for you to see the fnords.")
+	cert, err := aggregator.Store(ctx, rawMsg, 0, []byte{})
+	Require(t, err)
+	if cert.SignersMask != 0b11 {
+		Fail(t, "unexpected signers mask", cert.SignersMask)
+	}
+
+	Require(t, aggregator.ReloadBackends(newBackends(3)))
+	reloadedCert, err := aggregator.Store(ctx, rawMsg, 0, []byte{})
+	Require(t, err)
+	if reloadedCert.SignersMask != 0b111 {
+		Fail(t, "unexpected signers mask after reload", reloadedCert.SignersMask)
+	}
+	if reloadedCert.KeysetHash == cert.KeysetHash {
+		Fail(t, "expected keyset to change after reload")
+	}
+
+	// An invalid committee is rejected and the current one kept.
+	invalid := newBackends(2)
+	invalid[1].signersMask = invalid[0].signersMask
+	if aggregator.ReloadBackends(invalid) == nil {
+		Fail(t, "expected backends sharing a signers mask to be rejected")
+	}
+	if len(aggregator.BackendStatus()) != 3 {
+		Fail(t, "expected the reloaded backends to be kept", aggregator.BackendStatus())
+	}
+
+	// A rejected backend configuration is tried again on the next reload.
+	backendsJSON := func(masks ...uint64) string {
+		var cs []BackendConfig
+		for i, mask := range masks {
+			pubKey, _, err := blsSignatures.GenerateKeys()
+			Require(t, err)
+			cs = append(cs, BackendConfig{
+				URL:                 "http://localhost:" + strconv.Itoa(9000+i),
+				PubKeyBase64Encoded: blsPubToBase64(&pubKey),
+				SignerMask:          mask,
+			})
+		}
+		backends, err := json.Marshal(cs)
+		Require(t, err)
+		return string(backends)
+	}
+	invalidBackends := backendsJSON(1, 1)
+	if aggregator.reloadServices(invalidBackends) == nil {
+		Fail(t, "expected backends sharing a signers mask to be rejected")
+	}
+	if aggregator.lastBackends == invalidBackends {
+		Fail(t, "rejected backends were recorded as loaded")
+	}
+	validBackends := backendsJSON(1, 2)
+	Require(t, aggregator.reloadServices(validBackends))
+	if aggregator.lastBackends != validBackends || len(aggregator.BackendStatus()) != 2 {
+		Fail(t, "expected the backends to be reloaded", aggregator.BackendStatus())
+	}
+}
+
+func TestDAS_AggregatorCommitteeConfig(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var backends []ServiceDetails
+	for i := 0; i < 3; i++ {
+		privKey, err := blsSignatures.GeneratePrivKeyString()
+		Require(t, err)
+		config := DataAvailabilityConfig{
+			Enable: true,
+			Key: KeyConfig{
+				PrivKey: privKey,
+			},
+			ParentChainNodeURL: "none",
+		}
+		das, err := NewSignAfterStoreDASWriter(ctx, config, NewMemoryBackedStorageService(ctx))
+		Require(t, err)
+		// List the backends in descending signersMask order.
+		details, err := NewServiceDetails(das, *das.pubKey, uint64(1<<(2-i)), "committee"+strconv.Itoa(i))
+		Require(t, err)
+		backends = append(backends, *details)
+	}
+
+	newAggregator := func(aggConfig AggregatorConfig) (*Aggregator, error) {
+		return NewAggregator(ctx, DataAvailabilityConfig{
+			RPCAggregator:      aggConfig,
+			ParentChainNodeURL: "none",
+			RequestTimeout:     time.Second,
+		}, backends)
+	}
+
+	if _, err := newAggregator(AggregatorConfig{AssumedHonest: 1, CommitteeSize: 4}); err == nil {
+		Fail(t, "expected a committee of the wrong size to be rejected")
+	}
+	if _, err := newAggregator(AggregatorConfig{AssumedHonest: 4}); err == nil {
+		Fail(t, "expected assumed-honest larger than the committee to be rejected")
+	}
+	if _, err := newAggregator(AggregatorConfig{AssumedHonest: 1, MemberOrder: "random"}); err == nil {
+		Fail(t, "expected an unknown member order to be rejected")
+	}
+
+	ordered, err := newAggregator(AggregatorConfig{AssumedHonest: 2, CommitteeSize: 3, MemberOrder: MemberOrderSignersMask})
+	Require(t, err)
+	for i, d := range ordered.committee.Load().services {
+		if d.signersMask != uint64(1<<i) {
+			Fail(t, "expected backends in signersMask order, got", d.signersMask, "at", i)
+		}
+	}
+	unordered, err := newAggregator(AggregatorConfig{AssumedHonest: 2, MemberOrder: MemberOrderConfig})
+	Require(t, err)
+	if ordered.committee.Load().keysetHash == unordered.committee.Load().keysetHash {
+		Fail(t, "expected member order to change the keyset")
+	}
+	// The keyset lists the backends in descending signersMask order, so
+	// the signers' masks don't match their positions in it.
+	if _, err := unordered.Store(ctx, []byte("It's time for you to see the fnords."), 0, []byte{}); !errors.Is(err, BatchToDasFailed) {
+		Fail(t, "expected signersMasks not matching the keyset to be rejected", err)
+	}
+
+	cert, err := ordered.Store(ctx, []byte("It's time for you to see the fnords."), 0, []byte{})
+	Require(t, err)
+	if bits.OnesCount64(cert.SignersMask) < 2 {
+		Fail(t, "unexpected signers mask", cert.SignersMask)
+	}
+}
+
+// versionZeroStore signs certificates as version 0, which doesn't match the
+// version the aggregator produces.
+type versionZeroStore struct {
+	*SignAfterStoreDASWriter
+}
+
+func (v *versionZeroStore) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	cert, err := v.SignAfterStoreDASWriter.Store(ctx, message, timeout, sig)
+	if err != nil {
+		return nil, err
+	}
+	cert.Version = 0
+	cert.Sig, err = blsSignatures.SignMessage(v.privKey, cert.SerializeSignableFields())
+	return cert, err
+}
+
+func TestDAS_AggregatorRejectsBadMemberSignature(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var backends []ServiceDetails
+	for i := 0; i < 4; i++ {
+		privKey, err := blsSignatures.GeneratePrivKeyString()
+		Require(t, err)
+		config := DataAvailabilityConfig{
+			Enable: true,
+			Key: KeyConfig{
+				PrivKey: privKey,
+			},
+			ParentChainNodeURL: "none",
+		}
+		das, err := NewSignAfterStoreDASWriter(ctx, config, NewMemoryBackedStorageService(ctx))
+		Require(t, err)
+		var writer DataAvailabilityServiceWriter = das
+		pubKey := *das.pubKey
+		switch i {
+		case 0:
+			// Register a different key than the member signs with.
+			pubKey, _, err = blsSignatures.GenerateKeys()
+			Require(t, err)
+		case 1:
+			writer = &versionZeroStore{das}
+		}
+		details, err := NewServiceDetails(writer, pubKey, uint64(1<<i), "badsig"+strconv.Itoa(i))
+		Require(t, err)
+		backends = append(backends, *details)
+	}
+
+	aggregator, err := NewAggregator(ctx, DataAvailabilityConfig{
+		RPCAggregator:      AggregatorConfig{AssumedHonest: 3},
+		ParentChainNodeURL: "none",
+		RequestTimeout:     time.Second,
+	}, backends)
+	Require(t, err)
+	cert, err := aggregator.Store(ctx, []byte("It's time for you to see the fnords."), 0, []byte{})
+	Require(t, err)
+	if cert.SignersMask != 0b1100 {
+		Fail(t, "expected the members with bad signatures to be left out of the certificate", cert.SignersMask)
+	}
+
+	// Without enough members with valid signatures, no certificate is produced.
+	aggregator, err = NewAggregator(ctx, DataAvailabilityConfig{
+		RPCAggregator:      AggregatorConfig{AssumedHonest: 2},
+		ParentChainNodeURL: "none",
+		RequestTimeout:     time.Second,
+	}, backends)
+	Require(t, err)
+	if _, err := aggregator.Store(ctx, []byte("It's time for you to see the fnords."), 0, []byte{}); !errors.Is(err, BatchToDasFailed) {
+		Fail(t, "expected Store to fail when a required member's signature is bad", err)
+	}
+}
+
+type countingStore struct {
+	DataAvailabilityServiceWriter
+	calls    *int32
+	canceled *int32
+	hang     bool
+}
+
+func (c *countingStore) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	atomic.AddInt32(c.calls, 1)
+	if c.hang {
+		<-ctx.Done()
+		atomic.AddInt32(c.canceled, 1)
+		return nil, ctx.Err()
+	}
+	return c.DataAvailabilityServiceWriter.Store(ctx, message, timeout, sig)
+}
+
+func TestDAS_AggregatorHedging(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls, canceled int32
+	var backends []ServiceDetails
+	for i := 0; i < 5; i++ {
+		privKey, err := blsSignatures.GeneratePrivKeyString()
+		Require(t, err)
+		config := DataAvailabilityConfig{
+			Enable: true,
+			Key: KeyConfig{
+				PrivKey: privKey,
+			},
+			ParentChainNodeURL: "none",
+		}
+		das, err := NewSignAfterStoreDASWriter(ctx, config, NewMemoryBackedStorageService(ctx))
+		Require(t, err)
+		// The first backend hangs, so a hedged Store is needed to reach quorum.
+		writer := &countingStore{das, &calls, &canceled, i == 0}
+		details, err := NewServiceDetails(writer, *das.pubKey, uint64(1<<i), "hedge"+strconv.Itoa(i))
+		Require(t, err)
+		backends = append(backends, *details)
+	}
+
+	aggConfig := DefaultAggregatorConfig
+	aggConfig.AssumedHonest = 4 // K=2
+	aggConfig.CircuitBreaker.FailureThreshold = 0
+	aggConfig.Hedging = HedgingConfig{Delay: 50 * time.Millisecond, StoresPerDelay: 1}
+	aggregator, err := NewAggregator(ctx, DataAvailabilityConfig{
+		RPCAggregator:      aggConfig,
+		ParentChainNodeURL: "none",
+		RequestTimeout:     time.Minute,
+	}, backends)
+	Require(t, err)
+
+	cert, err := aggregator.Store(ctx, []byte("It's time for you to see the fnords."), 0, []byte{})
+	Require(t, err)
+	if bits.OnesCount64(cert.SignersMask) != 2 || cert.SignersMask&1 != 0 {
+		Fail(t, "unexpected signers mask", cert.SignersMask)
+	}
+	// Only the two initial backends and one hedged backend were sent the Store.
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		Fail(t, "expected Store to be sent to 3 backends, got", n)
+	}
+	// The hanging backend's request is canceled once there are enough signatures.
+	for i := 0; i < 100 && atomic.LoadInt32(&canceled) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if atomic.LoadInt32(&canceled) != 1 {
+		Fail(t, "expected the outstanding Store to be canceled")
+	}
+}
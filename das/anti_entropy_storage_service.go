@@ -0,0 +1,347 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+type AntiEntropyConfig struct {
+	Enable   bool          `koanf:"enable"`
+	Peers    []string      `koanf:"peers"`
+	Interval time.Duration `koanf:"interval"`
+}
+
+var DefaultAntiEntropyConfig = AntiEntropyConfig{
+	Enable:   false,
+	Peers:    []string{},
+	Interval: time.Hour,
+}
+
+func AntiEntropyConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultAntiEntropyConfig.Enable, "enable periodically comparing digests of this DAS's stored keys with other committee members' over REST, and fetching the data for any keys this DAS is missing; requires a storage backend with sync-from-storage-service enabled to build the initial key inventory from")
+	f.StringSlice(prefix+".peers", DefaultAntiEntropyConfig.Peers, "list of REST URLs of other committee members to repair from, including 'http://' or 'https://' prefixes and port numbers (defaults to rest-aggregator.urls)")
+	f.Duration(prefix+".interval", DefaultAntiEntropyConfig.Interval, "interval between repairs")
+}
+
+var (
+	antiEntropyRepairedCounter = metrics.NewRegisteredCounter("arb/das/antientropy/repaired", nil)
+	antiEntropyFailedCounter   = metrics.NewRegisteredCounter("arb/das/antientropy/failed", nil)
+	antiEntropyDivergentGauge  = metrics.NewRegisteredGauge("arb/das/antientropy/divergent_ranges", nil)
+)
+
+// Keys are split into inventoryRanges ranges by their first byte.
+const inventoryRanges = 256
+
+// InventoryDigest summarizes a DAS's unexpired keys. Ranges[i] is the XOR of
+// the keys whose first byte is i, and Root is the hash of the ranges and
+// their key counts, so two DASes with equal Roots hold the same keys, and if
+// the Roots differ only the ranges whose digests differ need to be listed.
+type InventoryDigest struct {
+	Root   common.Hash   `json:"root"`
+	Ranges []common.Hash `json:"ranges"`
+	Counts []uint64      `json:"counts"`
+}
+
+// InventoryKey is a stored key and the time until which it is stored.
+type InventoryKey struct {
+	Hash           common.Hash `json:"hash"`
+	ExpirationTime uint64      `json:"expirationTime"`
+}
+
+// InventoryReader is implemented by readers that keep an inventory of their
+// stored keys.
+type InventoryReader interface {
+	InventoryDigest() InventoryDigest
+	InventoryRange(keyRange uint8) []InventoryKey
+}
+
+// AntiEntropyStorageService keeps an in-memory inventory of the keys in the
+// storage it wraps, and periodically compares its inventory digest with each
+// peer's, fetching from the peer the data for any keys only the peer holds.
+// Peers repair themselves from this DAS in the same way.
+type AntiEntropyStorageService struct {
+	StorageService
+	stopwaiter.StopWaiter
+
+	config AntiEntropyConfig
+	peers  []*RestfulDasClient
+
+	mutex  sync.Mutex
+	keys   map[common.Hash]uint64
+	ranges [inventoryRanges]common.Hash
+	counts [inventoryRanges]uint64
+}
+
+func NewAntiEntropyStorageService(ctx context.Context, config AntiEntropyConfig, storageService StorageService, peerURLs []string, iterables []*IterableStorageService) (*AntiEntropyStorageService, error) {
+	if len(peerURLs) == 0 {
+		return nil, errors.New("at least one peer must be configured for anti-entropy, with anti-entropy.peers or rest-aggregator.urls")
+	}
+	if len(iterables) == 0 {
+		return nil, errors.New("no storage backend has sync-from-storage-service enabled to build the anti-entropy key inventory from")
+	}
+	a := &AntiEntropyStorageService{
+		StorageService: storageService,
+		config:         config,
+		keys:           make(map[common.Hash]uint64),
+	}
+	for _, url := range peerURLs {
+		client, err := NewRestfulDasClientFromURL(url)
+		if err != nil {
+			return nil, err
+		}
+		a.peers = append(a.peers, client)
+	}
+	for _, iterable := range iterables {
+		if err := a.buildFrom(ctx, iterable); err != nil {
+			return nil, err
+		}
+	}
+	log.Info("das.AntiEntropyStorageService built key inventory", "keys", len(a.keys))
+	return a, nil
+}
+
+func (a *AntiEntropyStorageService) buildFrom(ctx context.Context, iterable *IterableStorageService) error {
+	end := iterable.End(ctx)
+	if (end == common.Hash{}) {
+		return nil
+	}
+	hash := iterable.DefaultBegin()
+	for hash != end {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		hash = iterable.Next(ctx, hash)
+		if (hash == common.Hash{}) {
+			return fmt.Errorf("key iteration of %v ended before its last key", iterable)
+		}
+		expirationTime, err := iterable.GetExpirationTime(ctx, hash)
+		if err != nil {
+			return err
+		}
+		a.add(hash, expirationTime)
+	}
+	return nil
+}
+
+func (a *AntiEntropyStorageService) add(key common.Hash, expirationTime uint64) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if current, found := a.keys[key]; found {
+		if expirationTime > current {
+			a.keys[key] = expirationTime
+		}
+		return
+	}
+	a.keys[key] = expirationTime
+	a.toggle(key)
+	a.counts[key[0]]++
+}
+
+// toggle must be called with the mutex held.
+func (a *AntiEntropyStorageService) toggle(key common.Hash) {
+	r := &a.ranges[key[0]]
+	for i := range r {
+		r[i] ^= key[i]
+	}
+}
+
+// pruneExpired removes expired keys from the inventory, since they can't be
+// repaired anyway and peers may have already deleted them.
+func (a *AntiEntropyStorageService) pruneExpired() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	now := uint64(time.Now().Unix())
+	for key, expirationTime := range a.keys {
+		if expirationTime < now {
+			delete(a.keys, key)
+			a.toggle(key)
+			a.counts[key[0]]--
+		}
+	}
+}
+
+func (a *AntiEntropyStorageService) Start(ctx context.Context) {
+	a.StopWaiter.Start(ctx, a)
+	a.CallIteratively(a.repair)
+}
+
+func (a *AntiEntropyStorageService) Put(ctx context.Context, data []byte, expirationTime uint64) error {
+	if err := a.StorageService.Put(ctx, data, expirationTime); err != nil {
+		return err
+	}
+	a.add(dastree.Hash(data), expirationTime)
+	return nil
+}
+
+func (a *AntiEntropyStorageService) InventoryDigest() InventoryDigest {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	digest := InventoryDigest{
+		Ranges: append([]common.Hash(nil), a.ranges[:]...),
+		Counts: append([]uint64(nil), a.counts[:]...),
+	}
+	digest.Root = inventoryRoot(digest.Ranges, digest.Counts)
+	return digest
+}
+
+func inventoryRoot(ranges []common.Hash, counts []uint64) common.Hash {
+	preimage := make([]byte, 0, len(ranges)*(32+8))
+	for i := range ranges {
+		preimage = append(preimage, ranges[i].Bytes()...)
+		preimage = binary.BigEndian.AppendUint64(preimage, counts[i])
+	}
+	return crypto.Keccak256Hash(preimage)
+}
+
+func (a *AntiEntropyStorageService) InventoryRange(keyRange uint8) []InventoryKey {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	var keys []InventoryKey
+	for key, expirationTime := range a.keys {
+		if key[0] == keyRange {
+			keys = append(keys, InventoryKey{Hash: key, ExpirationTime: expirationTime})
+		}
+	}
+	return keys
+}
+
+func (a *AntiEntropyStorageService) repair(ctx context.Context) time.Duration {
+	a.pruneExpired()
+	var divergent int
+	for _, peer := range a.peers {
+		n, err := a.repairFrom(ctx, peer)
+		divergent += n
+		if err != nil && ctx.Err() == nil {
+			log.Warn("das.AntiEntropyStorageService: failed to repair from peer", "peer", peer.url, "err", err)
+		}
+	}
+	antiEntropyDivergentGauge.Update(int64(divergent))
+	return a.config.Interval
+}
+
+// repairFrom returns the number of key ranges found to differ from the peer's.
+func (a *AntiEntropyStorageService) repairFrom(ctx context.Context, peer *RestfulDasClient) (int, error) {
+	peerDigest, err := peer.InventoryDigest(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(peerDigest.Ranges) != inventoryRanges || len(peerDigest.Counts) != inventoryRanges {
+		return 0, fmt.Errorf("peer returned an inventory digest with %d ranges, expected %d", len(peerDigest.Ranges), inventoryRanges)
+	}
+	digest := a.InventoryDigest()
+	if peerDigest.Root == digest.Root {
+		return 0, nil
+	}
+	var divergent, repaired, failed int
+	now := uint64(time.Now().Unix())
+	for i := 0; i < inventoryRanges; i++ {
+		if peerDigest.Ranges[i] == digest.Ranges[i] && peerDigest.Counts[i] == digest.Counts[i] {
+			continue
+		}
+		divergent++
+		peerKeys, err := peer.InventoryRange(ctx, uint8(i))
+		if err != nil {
+			return divergent, err
+		}
+		for _, peerKey := range peerKeys {
+			if ctx.Err() != nil {
+				return divergent, ctx.Err()
+			}
+			if peerKey.ExpirationTime < now || a.has(peerKey.Hash) {
+				continue
+			}
+			data, err := peer.GetByHash(ctx, peerKey.Hash)
+			if err == nil {
+				err = a.Put(ctx, data, peerKey.ExpirationTime)
+			}
+			if err != nil {
+				failed++
+				antiEntropyFailedCounter.Inc(1)
+				log.Warn("das.AntiEntropyStorageService: failed to repair key from peer", "peer", peer.url, "hash", peerKey.Hash, "err", err)
+				continue
+			}
+			repaired++
+			antiEntropyRepairedCounter.Inc(1)
+		}
+	}
+	log.Info("das.AntiEntropyStorageService: repaired from peer", "peer", peer.url, "divergentRanges", divergent, "repaired", repaired, "failed", failed)
+	return divergent, nil
+}
+
+func (a *AntiEntropyStorageService) has(key common.Hash) bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	_, found := a.keys[key]
+	return found
+}
+
+func (a *AntiEntropyStorageService) Close(ctx context.Context) error {
+	a.StopOnly()
+	return a.StorageService.Close(ctx)
+}
+
+func (a *AntiEntropyStorageService) String() string {
+	return "AntiEntropyStorageService(" + a.StorageService.String() + ")"
+}
+
+// BackfillFromInventories stores the data for each unexpired key in the
+// peers' inventories that isn't already in storage, eg to bootstrap a new
+// committee member. The peers must have anti-entropy enabled to serve their
+// inventories. It returns the number of keys whose data was fetched and the
+// number whose data couldn't be.
+func BackfillFromInventories(ctx context.Context, storage StorageService, peers []*RestfulDasClient) (int, int, error) {
+	var fetched, failed int
+	for _, peer := range peers {
+		for i := 0; i < inventoryRanges; i++ {
+			peerKeys, err := peer.InventoryRange(ctx, uint8(i))
+			if err != nil {
+				return fetched, failed, fmt.Errorf("error listing inventory range %d of %s: %w", i, peer.url, err)
+			}
+			now := uint64(time.Now().Unix())
+			for _, peerKey := range peerKeys {
+				if ctx.Err() != nil {
+					return fetched, failed, ctx.Err()
+				}
+				if peerKey.ExpirationTime < now {
+					continue
+				}
+				_, err := storage.GetByHash(ctx, peerKey.Hash)
+				if err == nil {
+					continue
+				}
+				if !errors.Is(err, ErrNotFound) {
+					return fetched, failed, err
+				}
+				// GetByHash checks the data against its hash.
+				data, err := peer.GetByHash(ctx, peerKey.Hash)
+				if err == nil {
+					err = storage.Put(ctx, data, peerKey.ExpirationTime)
+				}
+				if err != nil {
+					failed++
+					log.Warn("das: failed to backfill key from peer", "peer", peer.url, "hash", peerKey.Hash, "err", err)
+					continue
+				}
+				fetched++
+			}
+		}
+	}
+	return fetched, failed, nil
+}
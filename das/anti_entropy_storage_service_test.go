@@ -0,0 +1,72 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+func TestAntiEntropyRepair(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	expirationTime := uint64(time.Now().Add(time.Hour).Unix())
+	shared := []byte("data both members hold")
+	missing := []byte("data only the source holds")
+
+	sourceIterable := NewIterableStorageService(ConvertStorageServiceToIterationCompatibleStorageService(NewMemoryBackedStorageService(ctx)))
+	Require(t, sourceIterable.Put(ctx, shared, expirationTime))
+	Require(t, sourceIterable.Put(ctx, missing, expirationTime))
+	source, err := NewAntiEntropyStorageService(ctx, DefaultAntiEntropyConfig, sourceIterable, []string{"http://localhost:1"}, []*IterableStorageService{sourceIterable})
+	Require(t, err)
+	server, port, err := NewRestfulDasServerOnRandomPort(LocalServerAddressForTest, source)
+	Require(t, err)
+	defer func() {
+		Require(t, server.Shutdown())
+	}()
+
+	targetIterable := NewIterableStorageService(ConvertStorageServiceToIterationCompatibleStorageService(NewMemoryBackedStorageService(ctx)))
+	Require(t, targetIterable.Put(ctx, shared, expirationTime))
+	target, err := NewAntiEntropyStorageService(ctx, DefaultAntiEntropyConfig, targetIterable, []string{fmt.Sprintf("http://%s:%d", LocalServerAddressForTest, port)}, []*IterableStorageService{targetIterable})
+	Require(t, err)
+
+	if source.InventoryDigest().Root == target.InventoryDigest().Root {
+		Fail(t, "expected inventory digests to differ before repair")
+	}
+	target.repair(ctx)
+
+	returnedData, err := target.GetByHash(ctx, dastree.Hash(missing))
+	Require(t, err)
+	if !bytes.Equal(missing, returnedData) {
+		Fail(t, "repaired data doesn't match", returnedData, missing)
+	}
+	if source.InventoryDigest().Root != target.InventoryDigest().Root {
+		Fail(t, "expected inventory digests to match after repair")
+	}
+}
+
+func TestAntiEntropyInventoryDigest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	iterable := NewIterableStorageService(ConvertStorageServiceToIterationCompatibleStorageService(NewMemoryBackedStorageService(ctx)))
+	a, err := NewAntiEntropyStorageService(ctx, DefaultAntiEntropyConfig, iterable, []string{"http://localhost:1"}, []*IterableStorageService{iterable})
+	Require(t, err)
+	empty := a.InventoryDigest().Root
+
+	Require(t, a.Put(ctx, []byte("expiring data"), uint64(time.Now().Add(-time.Minute).Unix())))
+	if a.InventoryDigest().Root == empty {
+		Fail(t, "expected the digest to change after a Put")
+	}
+	a.pruneExpired()
+	if a.InventoryDigest().Root != empty {
+		Fail(t, "expected the digest to return to empty after pruning expired keys")
+	}
+}
@@ -0,0 +1,396 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+
+	flag "github.com/spf13/pflag"
+)
+
+var (
+	asyncStoreQueuedCounter     = metrics.NewRegisteredCounter("arb/das/rpc/storeasync/queued", nil)
+	asyncStoreStoredCounter     = metrics.NewRegisteredCounter("arb/das/rpc/storeasync/stored", nil)
+	asyncStoreFailedCounter     = metrics.NewRegisteredCounter("arb/das/rpc/storeasync/failed", nil)
+	asyncStorePendingGauge      = metrics.NewRegisteredGauge("arb/das/rpc/storeasync/pending", nil)
+	asyncStoreDurationHistogram = metrics.NewRegisteredHistogram("arb/das/rpc/storeasync/duration", nil, metrics.NewBoundedHistogramSample())
+)
+
+var (
+	ErrAsyncStoreQueueFull       = errors.New("too many asynchronous stores are pending, retry later")
+	ErrAsyncStoreReceiptNotFound = errors.New("unknown or expired receipt")
+	ErrAsyncStorePending         = errors.New("the message hasn't been stored yet")
+)
+
+const (
+	asyncStoreWALSuffix    = ".wal"
+	asyncStoreResultSuffix = ".result"
+	asyncStoreReceiptBytes = 16
+
+	asyncStoreQueueFullRetryAfter = 5 * time.Second
+)
+
+type AsyncStoreConfig struct {
+	Enable          bool          `koanf:"enable"`
+	WALDir          string        `koanf:"wal-dir"`
+	Workers         int           `koanf:"workers"`
+	MaxPending      int           `koanf:"max-pending"`
+	StoreTimeout    time.Duration `koanf:"store-timeout"`
+	ResultRetention time.Duration `koanf:"result-retention"`
+}
+
+var DefaultAsyncStoreConfig = AsyncStoreConfig{
+	Enable:          false,
+	Workers:         4,
+	MaxPending:      1024,
+	StoreTimeout:    time.Minute,
+	ResultRetention: time.Hour,
+}
+
+func AsyncStoreConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultAsyncStoreConfig.Enable, "enable das_storeAsync, which returns a receipt as soon as the message is written to wal-dir, and das_storeAsyncResult, which returns the certificate for a receipt once the message is stored")
+	f.String(prefix+".wal-dir", DefaultAsyncStoreConfig.WALDir, "directory where queued messages are written until they're stored, and then their results until result-retention passes (required if enabled)")
+	f.Int(prefix+".workers", DefaultAsyncStoreConfig.Workers, "how many queued messages to store concurrently")
+	f.Int(prefix+".max-pending", DefaultAsyncStoreConfig.MaxPending, "most messages that may be queued but not yet stored")
+	f.Duration(prefix+".store-timeout", DefaultAsyncStoreConfig.StoreTimeout, "how long storing a queued message may take")
+	f.Duration(prefix+".result-retention", DefaultAsyncStoreConfig.ResultRetention, "how long the result for a receipt is kept after the message is stored")
+}
+
+func (c *AsyncStoreConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.WALDir == "" {
+		return errors.New("asynchronous stores require a wal-dir")
+	}
+	if c.Workers <= 0 || c.MaxPending <= 0 {
+		return errors.New("asynchronous stores require positive workers and max-pending")
+	}
+	return nil
+}
+
+// StoreAuthorizer is implemented by the writers that only let some clients
+// store, so that a queued store can be authorized when the client queues it.
+type StoreAuthorizer interface {
+	AuthorizeStore(ctx context.Context) error
+}
+
+// authorizeStore authorizes the store with writer if it's a StoreAuthorizer.
+// The writers wrapping an authenticator forward to it, so servers can reject
+// a client before it sends a large message.
+func authorizeStore(ctx context.Context, writer DataAvailabilityServiceWriter) error {
+	if authorizer, ok := writer.(StoreAuthorizer); ok {
+		return authorizer.AuthorizeStore(ctx)
+	}
+	return nil
+}
+
+type queuedStoreKey struct{}
+
+// isQueuedStore reports whether the store is one an AsyncStorer queued, which
+// its StoreAuthorizers already authorized.
+func isQueuedStore(ctx context.Context) bool {
+	queued, _ := ctx.Value(queuedStoreKey{}).(bool)
+	return queued
+}
+
+const (
+	AsyncStorePending = "pending"
+	AsyncStoreStored  = "stored"
+	AsyncStoreFailed  = "failed"
+)
+
+type AsyncStoreResult struct {
+	Status string       `json:"status"`
+	Cert   *StoreResult `json:"cert,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// AsyncStorer lets the HTTP-RPC server's clients queue messages instead of
+// waiting for them to be stored. Each queued message is written to a file in
+// the WAL directory before its receipt is returned, so that it's still stored
+// if the server restarts, and the file is replaced with the result once the
+// writer it wraps has stored it.
+type AsyncStorer struct {
+	DataAvailabilityServiceWriter
+	stopwaiter.StopWaiter
+	config      AsyncStoreConfig
+	authorizers []StoreAuthorizer
+	queue       chan string
+
+	// Stores in progress aren't cancelled on Close until its context is done.
+	storeCtx     context.Context
+	cancelStores context.CancelFunc
+}
+
+func NewAsyncStorer(writer DataAvailabilityServiceWriter, config AsyncStoreConfig, authorizers ...StoreAuthorizer) (*AsyncStorer, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(config.WALDir, 0700); err != nil {
+		return nil, err
+	}
+	return &AsyncStorer{
+		DataAvailabilityServiceWriter: writer,
+		config:                        config,
+		authorizers:                   authorizers,
+		queue:                         make(chan string, config.MaxPending),
+	}, nil
+}
+
+func (s *AsyncStorer) Start(ctx context.Context) error {
+	s.StopWaiter.Start(ctx, s)
+	s.storeCtx, s.cancelStores = context.WithCancel(ctx)
+	// Messages queued before a restart are stored first.
+	entries, err := os.ReadDir(s.config.WALDir)
+	if err != nil {
+		return err
+	}
+	var replayed []string
+	for _, entry := range entries {
+		if receipt, ok := strings.CutSuffix(entry.Name(), asyncStoreWALSuffix); ok {
+			replayed = append(replayed, receipt)
+		}
+	}
+	if len(replayed) > 0 {
+		log.Info("das: storing messages queued before restart", "count", len(replayed))
+	}
+	s.LaunchThread(func(ctx context.Context) {
+		for _, receipt := range replayed {
+			asyncStorePendingGauge.Inc(1)
+			select {
+			case s.queue <- receipt:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	for i := 0; i < s.config.Workers; i++ {
+		s.LaunchThread(s.storeQueued)
+	}
+	s.CallIteratively(s.pruneResults)
+	return nil
+}
+
+// Close stops taking messages from the queue, waiting until ctx is done for the
+// ones being stored. Those that aren't stored by then stay in the WAL.
+func (s *AsyncStorer) Close(ctx context.Context) error {
+	s.StopOnly()
+	waitChan, err := s.GetWaitChannel()
+	if err != nil {
+		return err
+	}
+	select {
+	case <-waitChan:
+		s.cancelStores()
+		return nil
+	case <-ctx.Done():
+		s.cancelStores()
+		<-waitChan
+		return ctx.Err()
+	}
+}
+
+func (s *AsyncStorer) AuthorizeStore(ctx context.Context) error {
+	return authorizeStore(ctx, s.DataAvailabilityServiceWriter)
+}
+
+// StoreAsync authorizes the store and queues the message, returning the
+// receipt to poll Result with.
+func (s *AsyncStorer) StoreAsync(ctx context.Context, message []byte, timeout uint64, sig []byte) (string, error) {
+	for _, authorizer := range s.authorizers {
+		if err := authorizer.AuthorizeStore(ctx); err != nil {
+			return "", err
+		}
+	}
+	if len(s.queue) >= cap(s.queue) {
+		return "", s.queueFull()
+	}
+	receiptBytes := make([]byte, asyncStoreReceiptBytes)
+	if _, err := rand.Read(receiptBytes); err != nil {
+		return "", err
+	}
+	receipt := hex.EncodeToString(receiptBytes)
+
+	entry := make([]byte, 12, 12+len(sig)+len(message))
+	binary.BigEndian.PutUint64(entry, timeout)
+	binary.BigEndian.PutUint32(entry[8:], uint32(len(sig)))
+	entry = append(append(entry, sig...), message...)
+	if err := writeFileDurably(s.path(receipt, asyncStoreWALSuffix), entry); err != nil {
+		return "", err
+	}
+
+	select {
+	case s.queue <- receipt:
+	default:
+		_ = os.Remove(s.path(receipt, asyncStoreWALSuffix))
+		return "", s.queueFull()
+	}
+	asyncStoreQueuedCounter.Inc(1)
+	asyncStorePendingGauge.Inc(1)
+	return receipt, nil
+}
+
+// queueFull tells the client to retry once the workers have had time to store
+// a message each.
+func (s *AsyncStorer) queueFull() error {
+	return &OverloadedError{Reason: ErrAsyncStoreQueueFull, RetryAfter: asyncStoreQueueFullRetryAfter, Queued: len(s.queue)}
+}
+
+// Result returns the result for the receipt, which is pending until the
+// message is stored.
+func (s *AsyncStorer) Result(receipt string) (*AsyncStoreResult, error) {
+	if decoded, err := hex.DecodeString(receipt); err != nil || len(decoded) != asyncStoreReceiptBytes {
+		return nil, ErrAsyncStoreReceiptNotFound
+	}
+	contents, err := os.ReadFile(s.path(receipt, asyncStoreResultSuffix))
+	if errors.Is(err, os.ErrNotExist) {
+		if _, err := os.Stat(s.path(receipt, asyncStoreWALSuffix)); err == nil {
+			return &AsyncStoreResult{Status: AsyncStorePending}, nil
+		}
+		return nil, ErrAsyncStoreReceiptNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var result AsyncStoreResult
+	if err := json.Unmarshal(contents, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (s *AsyncStorer) path(receipt string, suffix string) string {
+	return filepath.Join(s.config.WALDir, receipt+suffix)
+}
+
+func (s *AsyncStorer) storeQueued(ctx context.Context) {
+	for {
+		select {
+		case receipt := <-s.queue:
+			s.store(s.storeCtx, receipt)
+			asyncStorePendingGauge.Dec(1)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *AsyncStorer) store(ctx context.Context, receipt string) {
+	walPath := s.path(receipt, asyncStoreWALSuffix)
+	entry, err := os.ReadFile(walPath)
+	if err != nil {
+		log.Error("das: failed to read queued message", "receipt", receipt, "err", err)
+		return
+	}
+	result := &AsyncStoreResult{Status: AsyncStoreFailed}
+	if len(entry) < 12 || uint64(len(entry)-12) < uint64(binary.BigEndian.Uint32(entry[8:])) {
+		result.Error = "corrupt queued message"
+	} else {
+		timeout := binary.BigEndian.Uint64(entry)
+		sigLen := binary.BigEndian.Uint32(entry[8:])
+		sig, message := entry[12:12+sigLen], entry[12+sigLen:]
+
+		start := time.Now()
+		storeCtx, cancel := context.WithTimeout(context.WithValue(ctx, queuedStoreKey{}, true), s.config.StoreTimeout)
+		cert, err := s.DataAvailabilityServiceWriter.Store(storeCtx, message, timeout, sig)
+		cancel()
+		if ctx.Err() != nil {
+			// Closed before it was stored, so leave the message to be stored
+			// after the restart.
+			return
+		}
+		asyncStoreDurationHistogram.Update(time.Since(start).Nanoseconds())
+		if err != nil {
+			log.Warn("das: failed to store queued message", "receipt", receipt, "err", err)
+			result.Error = err.Error()
+		} else {
+			result = &AsyncStoreResult{Status: AsyncStoreStored, Cert: certToStoreResult(cert)}
+		}
+	}
+	if result.Status == AsyncStoreStored {
+		asyncStoreStoredCounter.Inc(1)
+	} else {
+		asyncStoreFailedCounter.Inc(1)
+	}
+
+	contents, err := json.Marshal(result)
+	if err != nil {
+		log.Error("das: failed to encode asynchronous store result", "receipt", receipt, "err", err)
+		return
+	}
+	if err := writeFileDurably(s.path(receipt, asyncStoreResultSuffix), contents); err != nil {
+		log.Error("das: failed to write asynchronous store result", "receipt", receipt, "err", err)
+		return
+	}
+	if err := os.Remove(walPath); err != nil {
+		log.Warn("das: failed to remove stored message from WAL", "receipt", receipt, "err", err)
+	}
+}
+
+func (s *AsyncStorer) pruneResults(ctx context.Context) time.Duration {
+	entries, err := os.ReadDir(s.config.WALDir)
+	if err != nil {
+		log.Warn("das: failed to list asynchronous store results", "err", err)
+		return time.Minute
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), asyncStoreResultSuffix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < s.config.ResultRetention {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.config.WALDir, entry.Name())); err != nil {
+			log.Warn("das: failed to remove expired asynchronous store result", "file", entry.Name(), "err", err)
+		}
+	}
+	return time.Minute
+}
+
+func (s *AsyncStorer) String() string {
+	return fmt.Sprintf("AsyncStorer{%v}", s.DataAvailabilityServiceWriter)
+}
+
+// writeFileDurably writes the file so that it's either complete or absent
+// after a crash, and present once it returns.
+func writeFileDurably(path string, contents []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(contents); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	// The rename is only durable once the directory entry is.
+	return syncDir(filepath.Dir(path))
+}
@@ -0,0 +1,205 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func waitForAsyncStore(t *testing.T, ctx context.Context, client *DASRPCClient, receipt string) *arbstate.DataAvailabilityCertificate {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		cert, err := client.StoreAsyncResult(ctx, receipt)
+		if !errors.Is(err, ErrAsyncStorePending) {
+			testhelpers.RequireImpl(t, err)
+			return cert
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	testhelpers.FailImpl(t, "message wasn't stored asynchronously")
+	return nil
+}
+
+func TestRPCStoreAsync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	storageService := NewMemoryBackedStorageService(ctx)
+	keyDir := t.TempDir()
+	_, _, err := GenerateAndStoreKeys(keyDir)
+	testhelpers.RequireImpl(t, err)
+	privKey, err := (&KeyConfig{KeyDir: keyDir}).BLSPrivKey()
+	testhelpers.RequireImpl(t, err)
+	localDas, err := NewSignAfterStoreDASWriterWithSeqInboxCaller(privKey, nil, storageService, "")
+	testhelpers.RequireImpl(t, err)
+
+	tokensFile := filepath.Join(t.TempDir(), "tokens")
+	testhelpers.RequireImpl(t, os.WriteFile(tokensFile, []byte("token\n"), 0600))
+	authenticator, err := NewTokenStoreAuthenticator(localDas, TokenAuthConfig{TokensFile: tokensFile})
+	testhelpers.RequireImpl(t, err)
+
+	config := DefaultAsyncStoreConfig
+	config.Enable = true
+	config.WALDir = t.TempDir()
+
+	// A message queued before a restart is stored once the storer starts.
+	stopped, err := NewAsyncStorer(authenticator, config, authenticator)
+	testhelpers.RequireImpl(t, err)
+	queuedBeforeRestart := testhelpers.RandomizeSlice(make([]byte, 100))
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	replayedReceipt, err := stopped.StoreAsync(context.WithValue(ctx, bearerTokenKey{}, "token"), queuedBeforeRestart, timeout, nil)
+	testhelpers.RequireImpl(t, err)
+
+	asyncStorer, err := NewAsyncStorer(authenticator, config, authenticator)
+	testhelpers.RequireImpl(t, err)
+	testhelpers.RequireImpl(t, asyncStorer.Start(ctx))
+	defer asyncStorer.StopAndWait()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	testhelpers.RequireImpl(t, err)
+	dasServer, err := StartDASRPCServerOnListener(ctx, lis, genericconf.HTTPServerTimeoutConfigDefault, nil, DefaultStoreBoundsConfig, storageService, asyncStorer, storageService)
+	testhelpers.RequireImpl(t, err)
+	defer func() {
+		testhelpers.RequireImpl(t, dasServer.Shutdown(ctx))
+	}()
+
+	newClient := func(token string) *DASRPCClient {
+		backend := BackendConfig{URL: "http://" + lis.Addr().String(), BearerToken: token}
+		options, err := backend.dialOptions()
+		testhelpers.RequireImpl(t, err)
+		client, err := NewDASRPCClientWithOptions(ctx, backend.URL, options...)
+		testhelpers.RequireImpl(t, err)
+		return client
+	}
+	client := newClient("token")
+
+	cert := waitForAsyncStore(t, ctx, client, replayedReceipt)
+	if cert.DataHash != dastree.Hash(queuedBeforeRestart) {
+		testhelpers.FailImpl(t, "replayed message stored with the wrong hash")
+	}
+
+	message := testhelpers.RandomizeSlice(make([]byte, 1000))
+	receipt, err := client.StoreAsync(ctx, message, timeout, nil)
+	testhelpers.RequireImpl(t, err)
+	cert = waitForAsyncStore(t, ctx, client, receipt)
+	if cert.DataHash != dastree.Hash(message) || cert.Timeout != timeout {
+		testhelpers.FailImpl(t, "message stored with the wrong certificate", cert)
+	}
+	stored, err := storageService.GetByHash(ctx, cert.DataHash)
+	testhelpers.RequireImpl(t, err)
+	if !bytes.Equal(stored, message) {
+		testhelpers.FailImpl(t, "stored message doesn't match")
+	}
+
+	// Queuing is authorized up front, as the token isn't kept with the
+	// message.
+	if _, err := newClient("wrong-token").StoreAsync(ctx, message, timeout, nil); err == nil || err.Error() != ErrInvalidBearerToken.Error() {
+		testhelpers.FailImpl(t, "expected queuing with the wrong token to be rejected, got", err)
+	}
+	if _, err := client.StoreAsyncResult(ctx, "../"+receipt); err == nil || err.Error() != ErrAsyncStoreReceiptNotFound.Error() {
+		testhelpers.FailImpl(t, "expected an invalid receipt to be rejected, got", err)
+	}
+}
+
+type blockingWriter struct {
+	DataAvailabilityServiceWriter
+	started chan struct{}
+	release chan struct{}
+}
+
+func (w *blockingWriter) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	w.started <- struct{}{}
+	select {
+	case <-w.release:
+		return w.DataAvailabilityServiceWriter.Store(ctx, message, timeout, sig)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestAsyncStorerCloseDrains(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	storageService := NewMemoryBackedStorageService(ctx)
+	keyDir := t.TempDir()
+	_, _, err := GenerateAndStoreKeys(keyDir)
+	testhelpers.RequireImpl(t, err)
+	privKey, err := (&KeyConfig{KeyDir: keyDir}).BLSPrivKey()
+	testhelpers.RequireImpl(t, err)
+	localDas, err := NewSignAfterStoreDASWriterWithSeqInboxCaller(privKey, nil, storageService, "")
+	testhelpers.RequireImpl(t, err)
+
+	config := DefaultAsyncStoreConfig
+	config.Enable = true
+	config.WALDir = t.TempDir()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+
+	closeWhileStoring := func(closeTimeout time.Duration, release bool) (string, error) {
+		writer := &blockingWriter{localDas, make(chan struct{}, 1), make(chan struct{})}
+		asyncStorer, err := NewAsyncStorer(writer, config)
+		testhelpers.RequireImpl(t, err)
+		testhelpers.RequireImpl(t, asyncStorer.Start(ctx))
+		receipt, err := asyncStorer.StoreAsync(ctx, testhelpers.RandomizeSlice(make([]byte, 100)), timeout, nil)
+		testhelpers.RequireImpl(t, err)
+		<-writer.started
+		if release {
+			time.AfterFunc(50*time.Millisecond, func() { close(writer.release) })
+		}
+		closeCtx, cancelClose := context.WithTimeout(ctx, closeTimeout)
+		defer cancelClose()
+		return receipt, asyncStorer.Close(closeCtx)
+	}
+
+	// A store in progress finishes within the timeout.
+	receipt, err := closeWhileStoring(5*time.Second, true)
+	testhelpers.RequireImpl(t, err)
+	reader, err := NewAsyncStorer(localDas, config)
+	testhelpers.RequireImpl(t, err)
+	result, err := reader.Result(receipt)
+	testhelpers.RequireImpl(t, err)
+	if result.Status != AsyncStoreStored {
+		testhelpers.FailImpl(t, "expected the store in progress to finish, got", result)
+	}
+
+	// One that doesn't is left in the WAL to be stored after a restart.
+	receipt, err = closeWhileStoring(50*time.Millisecond, false)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		testhelpers.FailImpl(t, "expected Close to time out, got", err)
+	}
+	result, err = reader.Result(receipt)
+	testhelpers.RequireImpl(t, err)
+	if result.Status != AsyncStorePending {
+		testhelpers.FailImpl(t, "expected the unfinished store to stay queued, got", result)
+	}
+}
+
+func TestWriteFileDurably(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "entry")
+	testhelpers.RequireImpl(t, writeFileDurably(path, []byte("first")))
+	testhelpers.RequireImpl(t, writeFileDurably(path, []byte("second")))
+	contents, err := os.ReadFile(path)
+	testhelpers.RequireImpl(t, err)
+	if !bytes.Equal(contents, []byte("second")) {
+		testhelpers.FailImpl(t, "expected the file to be replaced, got", string(contents))
+	}
+	entries, err := os.ReadDir(dir)
+	testhelpers.RequireImpl(t, err)
+	if len(entries) != 1 {
+		testhelpers.FailImpl(t, "expected no temporary files to be left, got", len(entries), "files")
+	}
+}
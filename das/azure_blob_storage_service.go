@@ -0,0 +1,174 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/pretty"
+
+	flag "github.com/spf13/pflag"
+)
+
+// AzureBlobOperator is the subset of Azure Blob Storage operations used by
+// AzureBlobStorageService, so the client can be replaced in tests.
+type AzureBlobOperator interface {
+	Upload(ctx context.Context, container, blobName string, value []byte) error
+	Download(ctx context.Context, container, blobName string) ([]byte, error)
+	ContainerExists(ctx context.Context, container string) error
+}
+
+type AzureBlobStorageServiceConfig struct {
+	Enable                  bool   `koanf:"enable"`
+	ConnectionString        string `koanf:"connection-string"`
+	AccountURL              string `koanf:"account-url"`
+	UseManagedIdentity      bool   `koanf:"use-managed-identity"`
+	ManagedIdentityClientID string `koanf:"managed-identity-client-id"`
+	Container               string `koanf:"container"`
+	ObjectPrefix            string `koanf:"object-prefix"`
+	SyncFromStorageService  bool   `koanf:"sync-from-storage-service"`
+	SyncToStorageService    bool   `koanf:"sync-to-storage-service"`
+}
+
+var DefaultAzureBlobStorageServiceConfig = AzureBlobStorageServiceConfig{}
+
+func AzureBlobStorageConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultAzureBlobStorageServiceConfig.Enable, "enable storage/retrieval of sequencer batch data from an Azure Blob Storage container")
+	f.String(prefix+".connection-string", DefaultAzureBlobStorageServiceConfig.ConnectionString, "Azure Storage account connection string; exactly one of connection-string or use-managed-identity must be set")
+	f.String(prefix+".account-url", DefaultAzureBlobStorageServiceConfig.AccountURL, "Azure Storage account blob service URL (eg https://myaccount.blob.core.windows.net/), required when using managed identity")
+	f.Bool(prefix+".use-managed-identity", DefaultAzureBlobStorageServiceConfig.UseManagedIdentity, "authenticate to Azure Storage using the managed identity of the host")
+	f.String(prefix+".managed-identity-client-id", DefaultAzureBlobStorageServiceConfig.ManagedIdentityClientID, "client ID of a user-assigned managed identity; if not set the system-assigned identity is used")
+	f.String(prefix+".container", DefaultAzureBlobStorageServiceConfig.Container, "Azure Blob Storage container")
+	f.String(prefix+".object-prefix", DefaultAzureBlobStorageServiceConfig.ObjectPrefix, "prefix to add to Azure blob names")
+	f.Bool(prefix+".sync-from-storage-service", DefaultAzureBlobStorageServiceConfig.SyncFromStorageService, "enable Azure Blob Storage to be used as a source for regular sync storage")
+	f.Bool(prefix+".sync-to-storage-service", DefaultAzureBlobStorageServiceConfig.SyncToStorageService, "enable Azure Blob Storage to be used as a sink for regular sync storage")
+}
+
+type AzureBlobStorageService struct {
+	operator     AzureBlobOperator
+	container    string
+	objectPrefix string
+}
+
+func NewAzureBlobStorageService(config AzureBlobStorageServiceConfig) (StorageService, error) {
+	client, err := buildAzureBlobClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return &AzureBlobStorageService{
+		operator:     &azureBlobClient{client: client},
+		container:    config.Container,
+		objectPrefix: config.ObjectPrefix,
+	}, nil
+}
+
+func buildAzureBlobClient(config AzureBlobStorageServiceConfig) (*azblob.Client, error) {
+	if config.ConnectionString != "" && config.UseManagedIdentity {
+		return nil, errors.New("only one of azure-blob-storage.connection-string and azure-blob-storage.use-managed-identity may be set")
+	}
+	if config.ConnectionString != "" {
+		return azblob.NewClientFromConnectionString(config.ConnectionString, nil)
+	}
+	if !config.UseManagedIdentity {
+		return nil, errors.New("one of azure-blob-storage.connection-string or azure-blob-storage.use-managed-identity must be set")
+	}
+	if config.AccountURL == "" {
+		return nil, errors.New("azure-blob-storage.account-url must be set when using managed identity")
+	}
+	var options azidentity.ManagedIdentityCredentialOptions
+	if config.ManagedIdentityClientID != "" {
+		options.ID = azidentity.ClientID(config.ManagedIdentityClientID)
+	}
+	cred, err := azidentity.NewManagedIdentityCredential(&options)
+	if err != nil {
+		return nil, err
+	}
+	return azblob.NewClient(config.AccountURL, cred, nil)
+}
+
+type azureBlobClient struct {
+	client *azblob.Client
+}
+
+func (c *azureBlobClient) Upload(ctx context.Context, container, blobName string, value []byte) error {
+	_, err := c.client.UploadBuffer(ctx, container, blobName, value, nil)
+	return err
+}
+
+func (c *azureBlobClient) Download(ctx context.Context, container, blobName string) ([]byte, error) {
+	resp, err := c.client.DownloadStream(ctx, container, blobName, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func (c *azureBlobClient) ContainerExists(ctx context.Context, container string) error {
+	_, err := c.client.ServiceClient().NewContainerClient(container).GetProperties(ctx, nil)
+	return err
+}
+
+func (abs *AzureBlobStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.AzureBlobStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", abs)
+
+	value, err := abs.operator.Download(ctx, abs.container, abs.objectPrefix+EncodeStorageServiceKey(key))
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		log.Error("das.AzureBlobStorageService.GetByHash", "err", err)
+		return nil, err
+	}
+	return value, nil
+}
+
+func (abs *AzureBlobStorageService) Put(ctx context.Context, value []byte, timeout uint64) error {
+	logPut("das.AzureBlobStorageService.Store", value, timeout, abs)
+	err := abs.operator.Upload(ctx, abs.container, abs.objectPrefix+EncodeStorageServiceKey(dastree.Hash(value)), value)
+	if err != nil {
+		log.Error("das.AzureBlobStorageService.Store", "err", err)
+	}
+	return err
+}
+
+func (abs *AzureBlobStorageService) putKeyValue(ctx context.Context, key common.Hash, value []byte) error {
+	err := abs.operator.Upload(ctx, abs.container, abs.objectPrefix+EncodeStorageServiceKey(key), value)
+	if err != nil {
+		log.Error("das.AzureBlobStorageService.putKeyValue", "err", err)
+	}
+	return err
+}
+
+func (abs *AzureBlobStorageService) Sync(ctx context.Context) error {
+	return nil
+}
+
+func (abs *AzureBlobStorageService) Close(ctx context.Context) error {
+	return nil
+}
+
+func (abs *AzureBlobStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	return arbstate.KeepForever, nil
+}
+
+func (abs *AzureBlobStorageService) String() string {
+	return fmt.Sprintf("AzureBlobStorageService(:%s)", abs.container)
+}
+
+func (abs *AzureBlobStorageService) HealthCheck(ctx context.Context) error {
+	return abs.operator.ContainerExists(ctx, abs.container)
+}
@@ -0,0 +1,77 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+type mockAzureBlobOperator struct {
+	mutex sync.Mutex
+	blobs map[string][]byte
+}
+
+func (m *mockAzureBlobOperator) Upload(ctx context.Context, container, blobName string, value []byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.blobs[container+"/"+blobName] = append([]byte{}, value...)
+	return nil
+}
+
+func (m *mockAzureBlobOperator) Download(ctx context.Context, container, blobName string) ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	value, ok := m.blobs[container+"/"+blobName]
+	if !ok {
+		return nil, &azcore.ResponseError{StatusCode: http.StatusNotFound, ErrorCode: string(bloberror.BlobNotFound)}
+	}
+	return value, nil
+}
+
+func (m *mockAzureBlobOperator) ContainerExists(ctx context.Context, container string) error {
+	return nil
+}
+
+func TestAzureBlobStorageService(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	azureService := &AzureBlobStorageService{
+		operator:     &mockAzureBlobOperator{blobs: make(map[string][]byte)},
+		container:    "container",
+		objectPrefix: "prefix/",
+	}
+
+	val1 := []byte("The first value")
+	val1CorrectKey := dastree.Hash(val1)
+	val2IncorrectKey := dastree.Hash(append(val1, 0))
+
+	_, err := azureService.GetByHash(ctx, val1CorrectKey)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	err = azureService.Put(ctx, val1, timeout)
+	Require(t, err)
+
+	_, err = azureService.GetByHash(ctx, val2IncorrectKey)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	val, err := azureService.GetByHash(ctx, val1CorrectKey)
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		t.Fatal(val, val1)
+	}
+}
@@ -5,7 +5,9 @@ package das
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/allegro/bigcache"
@@ -19,31 +21,48 @@ import (
 )
 
 type BigCacheConfig struct {
-	// TODO add other config information like HardMaxCacheSize
 	Enable             bool          `koanf:"enable"`
 	Expiration         time.Duration `koanf:"expiration"`
+	HardMaxCacheSize   int           `koanf:"hard-max-cache-size"`
+	Shards             int           `koanf:"shards"`
 	MaxEntriesInWindow int
 }
 
 var DefaultBigCacheConfig = BigCacheConfig{
 	Expiration: time.Hour,
+	Shards:     1024,
 }
 
 var TestBigCacheConfig = BigCacheConfig{
 	Enable:             true,
 	Expiration:         time.Hour,
+	Shards:             1024,
 	MaxEntriesInWindow: 1000,
 }
 
 func BigCacheConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Bool(prefix+".enable", DefaultBigCacheConfig.Enable, "Enable local in-memory caching of sequencer batch data")
-	f.Duration(prefix+".expiration", DefaultBigCacheConfig.Expiration, "Expiration time for in-memory cached sequencer batches")
+	f.Duration(prefix+".expiration", DefaultBigCacheConfig.Expiration, "Expiration time for in-memory cached sequencer batches; batches are also evicted when their data timeout passes")
+	f.Int(prefix+".hard-max-cache-size", DefaultBigCacheConfig.HardMaxCacheSize, "Maximum size of the in-memory cache in MB (0 for no limit)")
+	f.Int(prefix+".shards", DefaultBigCacheConfig.Shards, "Number of shards in the in-memory cache, which must be a power of two")
+}
+
+// Cache entries are the big-endian data timeout followed by the data, so
+// entries can be expired with their data even though bigcache only supports a
+// single lifetime for all entries.
+const bigCacheTimeoutLength = 8
+
+func bigCacheEntry(value []byte, timeout uint64) []byte {
+	entry := make([]byte, bigCacheTimeoutLength, bigCacheTimeoutLength+len(value))
+	binary.BigEndian.PutUint64(entry, timeout)
+	return append(entry, value...)
 }
 
 type BigCacheStorageService struct {
 	baseStorageService StorageService
 	bigCacheConfig     BigCacheConfig
 	bigCache           *bigcache.BigCache
+	metrics            *cacheMetrics
 }
 
 func NewBigCacheStorageService(bigCacheConfig BigCacheConfig, baseStorageService StorageService) (StorageService, error) {
@@ -51,6 +70,16 @@ func NewBigCacheStorageService(bigCacheConfig BigCacheConfig, baseStorageService
 	if bigCacheConfig.MaxEntriesInWindow > 0 {
 		conf.MaxEntriesInWindow = bigCacheConfig.MaxEntriesInWindow
 	}
+	if bigCacheConfig.Shards > 0 {
+		conf.Shards = bigCacheConfig.Shards
+	}
+	conf.HardMaxCacheSize = bigCacheConfig.HardMaxCacheSize
+	cacheMetrics := newCacheMetrics("local")
+	conf.OnRemoveWithReason = func(key string, entry []byte, reason bigcache.RemoveReason) {
+		if reason != bigcache.Deleted {
+			cacheMetrics.evicted()
+		}
+	}
 	bigCache, err := bigcache.NewBigCache(conf)
 	if err != nil {
 		return nil, err
@@ -59,27 +88,34 @@ func NewBigCacheStorageService(bigCacheConfig BigCacheConfig, baseStorageService
 		baseStorageService: baseStorageService,
 		bigCacheConfig:     bigCacheConfig,
 		bigCache:           bigCache,
+		metrics:            cacheMetrics,
 	}, nil
 }
 
 func (bcs *BigCacheStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
 	log.Trace("das.BigCacheStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", bcs)
 
-	ret, err := bcs.bigCache.Get(string(key.Bytes()))
-	if err != nil {
-		ret, err = bcs.baseStorageService.GetByHash(ctx, key)
-		if err != nil {
-			return nil, err
-		}
-
-		err = bcs.bigCache.Set(string(key.Bytes()), ret)
-		if err != nil {
-			return nil, err
+	entry, err := bcs.bigCache.Get(string(key.Bytes()))
+	if err == nil && len(entry) >= bigCacheTimeoutLength {
+		if binary.BigEndian.Uint64(entry) > uint64(time.Now().Unix()) {
+			bcs.metrics.hit()
+			return entry[bigCacheTimeoutLength:], nil
 		}
-		return ret, err
+		_ = bcs.bigCache.Delete(string(key.Bytes()))
+		bcs.metrics.evicted()
 	}
+	bcs.metrics.miss()
 
-	return ret, err
+	ret, err := bcs.baseStorageService.GetByHash(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	// The data's timeout isn't known, so it's kept for the cache's expiration.
+	err = bcs.bigCache.Set(string(key.Bytes()), bigCacheEntry(ret, math.MaxUint64))
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
 }
 
 func (bcs *BigCacheStorageService) Put(ctx context.Context, value []byte, timeout uint64) error {
@@ -88,7 +124,10 @@ func (bcs *BigCacheStorageService) Put(ctx context.Context, value []byte, timeou
 	if err != nil {
 		return err
 	}
-	return bcs.bigCache.Set(string(dastree.HashBytes(value)), value)
+	if timeout <= uint64(time.Now().Unix()) {
+		return nil
+	}
+	return bcs.bigCache.Set(string(dastree.HashBytes(value)), bigCacheEntry(value, timeout))
 }
 
 func (bcs *BigCacheStorageService) Sync(ctx context.Context) error {
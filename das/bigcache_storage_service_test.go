@@ -88,3 +88,20 @@ func TestBigCacheStorageService(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestBigCacheStorageServiceDataTimeout(t *testing.T) {
+	ctx := context.Background()
+	baseStorageService := NewMemoryBackedStorageService(ctx)
+	bigCacheService, err := NewBigCacheStorageService(TestBigCacheConfig, baseStorageService)
+	Require(t, err)
+
+	// Data past its timeout isn't served from the cache, only from the base.
+	val1 := []byte("The first value")
+	key1 := dastree.Hash(val1)
+	Require(t, bigCacheService.Put(ctx, val1, uint64(time.Now().Add(-time.Minute).Unix())))
+	Require(t, baseStorageService.Close(ctx))
+	_, err = bigCacheService.GetByHash(ctx, key1)
+	if !errors.Is(err, ErrClosed) {
+		t.Fatal("expired data was served from the cache", err)
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"cloud.google.com/go/bigtable"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/pretty"
+
+	flag "github.com/spf13/pflag"
+)
+
+// Cells are written with a timestamp of their expiry timeout minus this
+// period, and the column family's GC policy discards cells older than it, so
+// Bigtable discards each cell at its timeout.
+const bigtableGCMaxAge = 24 * time.Hour
+
+// Latest timeout that can be converted to a Bigtable timestamp, which goes
+// through time.Time.UnixNano, without overflowing.
+const bigtableMaxTimeout = math.MaxInt64/int64(time.Second) - int64(bigtableGCMaxAge/time.Second)
+
+const bigtableColumn = "data"
+
+// BigtableOperator is the subset of Bigtable operations used by
+// BigtableStorageService, so the client can be replaced in tests.
+type BigtableOperator interface {
+	// Set writes value to the row with the given cell timestamp.
+	Set(ctx context.Context, rowKey string, value []byte, timestamp bigtable.Timestamp) error
+	// Get returns the latest cell of the row with a timestamp at or after
+	// minTimestamp, or ErrNotFound if there is none.
+	Get(ctx context.Context, rowKey string, minTimestamp bigtable.Timestamp) ([]byte, error)
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+type BigtableStorageServiceConfig struct {
+	Enable                 bool   `koanf:"enable"`
+	Project                string `koanf:"project"`
+	Instance               string `koanf:"instance"`
+	Table                  string `koanf:"table"`
+	ColumnFamily           string `koanf:"column-family"`
+	CredentialsFile        string `koanf:"credentials-file"`
+	CreateTable            bool   `koanf:"create-table"`
+	DiscardAfterTimeout    bool   `koanf:"discard-after-timeout"`
+	SyncFromStorageService bool   `koanf:"sync-from-storage-service"`
+	SyncToStorageService   bool   `koanf:"sync-to-storage-service"`
+}
+
+var DefaultBigtableStorageServiceConfig = BigtableStorageServiceConfig{
+	Table:        "das-data",
+	ColumnFamily: "d",
+	CreateTable:  true,
+}
+
+func BigtableStorageConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultBigtableStorageServiceConfig.Enable, "enable storage/retrieval of sequencer batch data from a Google Cloud Bigtable table")
+	f.String(prefix+".project", DefaultBigtableStorageServiceConfig.Project, "Google Cloud project of the Bigtable instance")
+	f.String(prefix+".instance", DefaultBigtableStorageServiceConfig.Instance, "Bigtable instance ID")
+	f.String(prefix+".table", DefaultBigtableStorageServiceConfig.Table, "Bigtable table in which to store the data")
+	f.String(prefix+".column-family", DefaultBigtableStorageServiceConfig.ColumnFamily, "Bigtable column family in which to store the data")
+	f.String(prefix+".credentials-file", DefaultBigtableStorageServiceConfig.CredentialsFile, "path to a service account JSON key file; if not set, Application Default Credentials are used")
+	f.Bool(prefix+".create-table", DefaultBigtableStorageServiceConfig.CreateTable, "create the table and column family, and set its GC policy, on startup")
+	f.Bool(prefix+".discard-after-timeout", DefaultBigtableStorageServiceConfig.DiscardAfterTimeout, "timestamp cells so the column family's GC policy discards them after their expiry timeout")
+	f.Bool(prefix+".sync-from-storage-service", DefaultBigtableStorageServiceConfig.SyncFromStorageService, "enable Bigtable to be used as a source for regular sync storage")
+	f.Bool(prefix+".sync-to-storage-service", DefaultBigtableStorageServiceConfig.SyncToStorageService, "enable Bigtable to be used as a sink for regular sync storage")
+}
+
+type BigtableStorageService struct {
+	operator            BigtableOperator
+	instance            string
+	table               string
+	discardAfterTimeout bool
+}
+
+func NewBigtableStorageService(ctx context.Context, config BigtableStorageServiceConfig) (StorageService, error) {
+	if config.Project == "" || config.Instance == "" {
+		return nil, errors.New("bigtable-storage.project and bigtable-storage.instance must be set")
+	}
+	var opts []option.ClientOption
+	if config.CredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(config.CredentialsFile))
+	}
+	if config.CreateTable {
+		if err := createBigtableTable(ctx, config, opts); err != nil {
+			return nil, err
+		}
+	}
+	client, err := bigtable.NewClient(ctx, config.Project, config.Instance, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating Bigtable client: %w", err)
+	}
+	return &BigtableStorageService{
+		operator: &bigtableClient{
+			client:       client,
+			table:        client.Open(config.Table),
+			columnFamily: config.ColumnFamily,
+		},
+		instance:            config.Instance,
+		table:               config.Table,
+		discardAfterTimeout: config.DiscardAfterTimeout,
+	}, nil
+}
+
+func createBigtableTable(ctx context.Context, config BigtableStorageServiceConfig, opts []option.ClientOption) error {
+	admin, err := bigtable.NewAdminClient(ctx, config.Project, config.Instance, opts...)
+	if err != nil {
+		return fmt.Errorf("error creating Bigtable admin client: %w", err)
+	}
+	defer admin.Close()
+	err = admin.CreateTable(ctx, config.Table)
+	if err != nil && status.Code(err) != codes.AlreadyExists {
+		return fmt.Errorf("error creating Bigtable table: %w", err)
+	}
+	err = admin.CreateColumnFamily(ctx, config.Table, config.ColumnFamily)
+	if err != nil && status.Code(err) != codes.AlreadyExists {
+		return fmt.Errorf("error creating Bigtable column family: %w", err)
+	}
+	policy := bigtable.MaxVersionsPolicy(1)
+	if config.DiscardAfterTimeout {
+		policy = bigtable.UnionPolicy(policy, bigtable.MaxAgePolicy(bigtableGCMaxAge))
+	}
+	if err := admin.SetGCPolicy(ctx, config.Table, config.ColumnFamily, policy); err != nil {
+		return fmt.Errorf("error setting Bigtable GC policy: %w", err)
+	}
+	return nil
+}
+
+type bigtableClient struct {
+	client       *bigtable.Client
+	table        *bigtable.Table
+	columnFamily string
+}
+
+func (c *bigtableClient) Set(ctx context.Context, rowKey string, value []byte, timestamp bigtable.Timestamp) error {
+	mut := bigtable.NewMutation()
+	mut.Set(c.columnFamily, bigtableColumn, timestamp, value)
+	return c.table.Apply(ctx, rowKey, mut)
+}
+
+func (c *bigtableClient) Get(ctx context.Context, rowKey string, minTimestamp bigtable.Timestamp) ([]byte, error) {
+	filter := bigtable.ChainFilters(
+		bigtable.FamilyFilter(c.columnFamily),
+		bigtable.TimestampRangeFilterMicros(minTimestamp, 0),
+		bigtable.LatestNFilter(1),
+	)
+	row, err := c.table.ReadRow(ctx, rowKey, bigtable.RowFilter(filter))
+	if err != nil {
+		return nil, err
+	}
+	items := row[c.columnFamily]
+	if len(items) == 0 {
+		return nil, ErrNotFound
+	}
+	return items[0].Value, nil
+}
+
+func (c *bigtableClient) Ping(ctx context.Context) error {
+	_, err := c.table.ReadRow(ctx, "das-health-check", bigtable.RowFilter(bigtable.LatestNFilter(1)))
+	return err
+}
+
+func (c *bigtableClient) Close() error {
+	return c.client.Close()
+}
+
+// cellTimestamp returns the timestamp to write a cell with. If data is
+// discarded after its timeout, cells are backdated so the GC policy's max age
+// elapses at the timeout.
+func (bs *BigtableStorageService) cellTimestamp(timeout uint64) bigtable.Timestamp {
+	if !bs.discardAfterTimeout {
+		return bigtable.Now()
+	}
+	if timeout > uint64(bigtableMaxTimeout) {
+		timeout = uint64(bigtableMaxTimeout)
+	}
+	return bigtable.Time(time.Unix(int64(timeout), 0).Add(-bigtableGCMaxAge))
+}
+
+// minTimestamp returns the oldest cell timestamp that hasn't expired yet. GC
+// happens lazily, so expired cells have to be filtered out of reads.
+func (bs *BigtableStorageService) minTimestamp() bigtable.Timestamp {
+	if !bs.discardAfterTimeout {
+		return 0
+	}
+	return bigtable.Time(time.Now().Add(-bigtableGCMaxAge))
+}
+
+func (bs *BigtableStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.BigtableStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", bs)
+
+	value, err := bs.operator.Get(ctx, EncodeStorageServiceKey(key), bs.minTimestamp())
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		log.Error("das.BigtableStorageService.GetByHash", "err", err)
+	}
+	return value, err
+}
+
+func (bs *BigtableStorageService) Put(ctx context.Context, value []byte, timeout uint64) error {
+	logPut("das.BigtableStorageService.Store", value, timeout, bs)
+	err := bs.operator.Set(ctx, EncodeStorageServiceKey(dastree.Hash(value)), value, bs.cellTimestamp(timeout))
+	if err != nil {
+		log.Error("das.BigtableStorageService.Store", "err", err)
+	}
+	return err
+}
+
+func (bs *BigtableStorageService) putKeyValue(ctx context.Context, key common.Hash, value []byte) error {
+	err := bs.operator.Set(ctx, EncodeStorageServiceKey(key), value, bs.cellTimestamp(math.MaxUint64))
+	if err != nil {
+		log.Error("das.BigtableStorageService.putKeyValue", "err", err)
+	}
+	return err
+}
+
+func (bs *BigtableStorageService) Sync(ctx context.Context) error {
+	return nil
+}
+
+func (bs *BigtableStorageService) Close(ctx context.Context) error {
+	return bs.operator.Close()
+}
+
+func (bs *BigtableStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	if bs.discardAfterTimeout {
+		return arbstate.DiscardAfterDataTimeout, nil
+	}
+	return arbstate.KeepForever, nil
+}
+
+func (bs *BigtableStorageService) String() string {
+	return fmt.Sprintf("BigtableStorageService(%s/%s)", bs.instance, bs.table)
+}
+
+func (bs *BigtableStorageService) HealthCheck(ctx context.Context) error {
+	return bs.operator.Ping(ctx)
+}
@@ -0,0 +1,86 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigtable"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+type mockBigtableCell struct {
+	value     []byte
+	timestamp bigtable.Timestamp
+}
+
+type mockBigtableOperator struct {
+	mutex sync.Mutex
+	rows  map[string]mockBigtableCell
+}
+
+func (m *mockBigtableOperator) Set(ctx context.Context, rowKey string, value []byte, timestamp bigtable.Timestamp) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.rows[rowKey] = mockBigtableCell{append([]byte{}, value...), timestamp}
+	return nil
+}
+
+func (m *mockBigtableOperator) Get(ctx context.Context, rowKey string, minTimestamp bigtable.Timestamp) ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	cell, ok := m.rows[rowKey]
+	if !ok || cell.timestamp < minTimestamp {
+		return nil, ErrNotFound
+	}
+	return cell.value, nil
+}
+
+func (m *mockBigtableOperator) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (m *mockBigtableOperator) Close() error {
+	return nil
+}
+
+func TestBigtableStorageService(t *testing.T) {
+	ctx := context.Background()
+	bigtableService := &BigtableStorageService{
+		operator:            &mockBigtableOperator{rows: make(map[string]mockBigtableCell)},
+		discardAfterTimeout: true,
+	}
+
+	val1 := []byte("The first value")
+	key1 := dastree.Hash(val1)
+	_, err := bigtableService.GetByHash(ctx, key1)
+	if !errors.Is(err, ErrNotFound) {
+		Fail(t, "expected ErrNotFound", err)
+	}
+	Require(t, bigtableService.Put(ctx, val1, uint64(time.Now().Add(time.Hour).Unix())))
+	val, err := bigtableService.GetByHash(ctx, key1)
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		Fail(t, val, val1)
+	}
+
+	expired := []byte("An expired value")
+	Require(t, bigtableService.Put(ctx, expired, uint64(time.Now().Add(-time.Minute).Unix())))
+	_, err = bigtableService.GetByHash(ctx, dastree.Hash(expired))
+	if !errors.Is(err, ErrNotFound) {
+		Fail(t, "expired data was returned", err)
+	}
+
+	forever := []byte("A value kept forever")
+	Require(t, bigtableService.Put(ctx, forever, math.MaxUint64))
+	_, err = bigtableService.GetByHash(ctx, dastree.Hash(forever))
+	Require(t, err)
+}
@@ -0,0 +1,180 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/holiman/bloomfilter/v2"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/pretty"
+
+	flag "github.com/spf13/pflag"
+)
+
+type BloomFilterConfig struct {
+	Enable            bool    `koanf:"enable"`
+	File              string  `koanf:"file"`
+	ExpectedEntries   uint64  `koanf:"expected-entries"`
+	FalsePositiveRate float64 `koanf:"false-positive-rate"`
+}
+
+var DefaultBloomFilterConfig = BloomFilterConfig{
+	ExpectedEntries:   10000000,
+	FalsePositiveRate: 0.001,
+}
+
+func BloomFilterConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultBloomFilterConfig.Enable, "answer lookups of hashes that were never stored from a bloom filter, without reading storage; only safe if all data reaches storage through this daserver")
+	f.String(prefix+".file", DefaultBloomFilterConfig.File, "file in which the bloom filter is saved on shutdown; if it is missing on startup the filter is rebuilt from the storage backends with sync-from-storage-service enabled")
+	f.Uint64(prefix+".expected-entries", DefaultBloomFilterConfig.ExpectedEntries, "number of stored hashes the bloom filter is sized for")
+	f.Float64(prefix+".false-positive-rate", DefaultBloomFilterConfig.FalsePositiveRate, "false positive rate of the bloom filter at its expected number of entries")
+}
+
+// bloomFilterHash adapts a hash for the bloom filter, which only needs 64 bits
+// of it.
+type bloomFilterHash common.Hash
+
+func (h bloomFilterHash) Write(p []byte) (n int, err error) { panic("not implemented") }
+func (h bloomFilterHash) Sum(b []byte) []byte               { panic("not implemented") }
+func (h bloomFilterHash) Reset()                            { panic("not implemented") }
+func (h bloomFilterHash) BlockSize() int                    { panic("not implemented") }
+func (h bloomFilterHash) Size() int                         { return 8 }
+func (h bloomFilterHash) Sum64() uint64                     { return binary.BigEndian.Uint64(h[:8]) }
+
+// BloomFilterStorageService keeps a bloom filter of the hashes stored in the
+// wrapped StorageService, so lookups of data it doesn't have are answered
+// without reading it.
+//
+// The filter is written to a file on Close and the file is removed when it is
+// loaded, so a daserver that didn't shut down cleanly rebuilds the filter by
+// iterating over the keys of its sync-from storage backends.
+type BloomFilterStorageService struct {
+	baseStorageService StorageService
+	config             BloomFilterConfig
+
+	mutex  sync.RWMutex
+	filter *bloomfilter.Filter
+}
+
+func NewBloomFilterStorageService(ctx context.Context, config BloomFilterConfig, baseStorageService StorageService, iterables []*IterableStorageService) (*BloomFilterStorageService, error) {
+	b := &BloomFilterStorageService{
+		baseStorageService: baseStorageService,
+		config:             config,
+	}
+	if config.File != "" {
+		filter, _, err := bloomfilter.ReadFile(config.File)
+		if err == nil {
+			if err := os.Remove(config.File); err != nil {
+				return nil, err
+			}
+			b.filter = filter
+			log.Info("das.BloomFilterStorageService loaded bloom filter", "file", config.File, "entries", filter.N())
+			return b, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("error reading bloom filter: %w", err)
+		}
+	}
+
+	if len(iterables) == 0 {
+		return nil, errors.New("bloom-filter.file doesn't exist, and no storage backend has sync-from-storage-service enabled to rebuild the bloom filter from")
+	}
+	filter, err := bloomfilter.NewOptimal(config.ExpectedEntries, config.FalsePositiveRate)
+	if err != nil {
+		return nil, err
+	}
+	b.filter = filter
+	for _, iterable := range iterables {
+		if err := b.rebuildFrom(ctx, iterable); err != nil {
+			return nil, err
+		}
+	}
+	log.Info("das.BloomFilterStorageService rebuilt bloom filter", "entries", filter.N())
+	return b, nil
+}
+
+func (b *BloomFilterStorageService) rebuildFrom(ctx context.Context, iterable *IterableStorageService) error {
+	end := iterable.End(ctx)
+	if (end == common.Hash{}) {
+		return nil
+	}
+	hash := iterable.DefaultBegin()
+	for hash != end {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		hash = iterable.Next(ctx, hash)
+		if (hash == common.Hash{}) {
+			return fmt.Errorf("key iteration of %v ended before its last key", iterable)
+		}
+		b.add(hash)
+	}
+	return nil
+}
+
+func (b *BloomFilterStorageService) add(key common.Hash) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.filter.Add(bloomFilterHash(key))
+}
+
+func (b *BloomFilterStorageService) mayContain(key common.Hash) bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.filter.Contains(bloomFilterHash(key))
+}
+
+func (b *BloomFilterStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.BloomFilterStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", b)
+	if !b.mayContain(key) {
+		return nil, ErrNotFound
+	}
+	return b.baseStorageService.GetByHash(ctx, key)
+}
+
+func (b *BloomFilterStorageService) Put(ctx context.Context, value []byte, timeout uint64) error {
+	logPut("das.BloomFilterStorageService.Put", value, timeout, b)
+	// Add before storing, so the data can't be stored but not found.
+	b.add(dastree.Hash(value))
+	return b.baseStorageService.Put(ctx, value, timeout)
+}
+
+func (b *BloomFilterStorageService) Sync(ctx context.Context) error {
+	return b.baseStorageService.Sync(ctx)
+}
+
+func (b *BloomFilterStorageService) Close(ctx context.Context) error {
+	if b.config.File != "" {
+		b.mutex.RLock()
+		_, err := b.filter.WriteFile(b.config.File)
+		b.mutex.RUnlock()
+		if err != nil {
+			log.Error("das.BloomFilterStorageService failed to save bloom filter", "file", b.config.File, "err", err)
+		}
+	}
+	return b.baseStorageService.Close(ctx)
+}
+
+func (b *BloomFilterStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	return b.baseStorageService.ExpirationPolicy(ctx)
+}
+
+func (b *BloomFilterStorageService) String() string {
+	return fmt.Sprintf("BloomFilterStorageService(%v)", b.baseStorageService)
+}
+
+func (b *BloomFilterStorageService) HealthCheck(ctx context.Context) error {
+	return b.baseStorageService.HealthCheck(ctx)
+}
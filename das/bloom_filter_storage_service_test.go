@@ -0,0 +1,67 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+func TestBloomFilterStorageService(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	config := BloomFilterConfig{
+		Enable:            true,
+		File:              filepath.Join(t.TempDir(), "bloom"),
+		ExpectedEntries:   1000,
+		FalsePositiveRate: 0.001,
+	}
+
+	base := NewMemoryBackedStorageService(ctx)
+	_, err := NewBloomFilterStorageService(ctx, config, base, nil)
+	if err == nil {
+		t.Fatal("expected error with neither a saved bloom filter nor storage to rebuild it from")
+	}
+
+	// Data stored before the filter existed is found by rebuilding it from the key iterator.
+	iterable := NewIterableStorageService(ConvertStorageServiceToIterationCompatibleStorageService(base))
+	val1 := []byte("The first value")
+	Require(t, iterable.Put(ctx, val1, timeout))
+	bloom, err := NewBloomFilterStorageService(ctx, config, iterable, []*IterableStorageService{iterable})
+	Require(t, err)
+	val, err := bloom.GetByHash(ctx, dastree.Hash(val1))
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		t.Fatal(val, val1)
+	}
+
+	// Data missing from the filter isn't read from storage.
+	val2 := []byte("The second value")
+	Require(t, base.Put(ctx, val2, timeout))
+	_, err = bloom.GetByHash(ctx, dastree.Hash(val2))
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	val3 := []byte("The third value")
+	Require(t, bloom.Put(ctx, val3, timeout))
+	Require(t, bloom.Close(ctx))
+
+	// The saved filter is loaded without rebuilding it.
+	base = NewMemoryBackedStorageService(ctx)
+	Require(t, base.Put(ctx, val3, timeout))
+	bloom, err = NewBloomFilterStorageService(ctx, config, base, nil)
+	Require(t, err)
+	val, err = bloom.GetByHash(ctx, dastree.Hash(val3))
+	Require(t, err)
+	if !bytes.Equal(val, val3) {
+		t.Fatal(val, val3)
+	}
+}
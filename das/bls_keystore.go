@@ -0,0 +1,231 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+
+	"github.com/offchainlabs/nitro/blsSignatures"
+)
+
+const (
+	BLSKeystoreKDFScrypt   = "scrypt"
+	BLSKeystoreKDFArgon2id = "argon2id"
+
+	blsKeystoreVersion = 1
+	blsKeystoreCipher  = "aes-256-gcm"
+	blsKeystoreKeyLen  = 32
+)
+
+var ErrBLSKeystorePassphrase = errors.New("wrong passphrase for BLS keystore, or it's corrupted")
+
+// blsKeystoreKDFParams are stored with each keystore, so keystores written
+// with different parameters can still be decrypted.
+type blsKeystoreKDFParams struct {
+	Salt string `json:"salt"`
+	// scrypt
+	N int `json:"n,omitempty"`
+	R int `json:"r,omitempty"`
+	P int `json:"p,omitempty"`
+	// argon2id
+	Time    uint32 `json:"time,omitempty"`
+	Memory  uint32 `json:"memory,omitempty"`
+	Threads uint8  `json:"threads,omitempty"`
+}
+
+// blsKeystore is the JSON format of a passphrase-encrypted BLS private key,
+// which can be used wherever the base64 private key file can.
+type blsKeystore struct {
+	Version    int                  `json:"version"`
+	KDF        string               `json:"kdf"`
+	KDFParams  blsKeystoreKDFParams `json:"kdfparams"`
+	Cipher     string               `json:"cipher"`
+	Nonce      string               `json:"nonce"`
+	Ciphertext string               `json:"ciphertext"`
+	PubKey     string               `json:"pubkey,omitempty"`
+}
+
+func (p *blsKeystoreKDFParams) deriveKey(kdf string, passphrase string) ([]byte, error) {
+	salt, err := hex.DecodeString(p.Salt)
+	if err != nil {
+		return nil, err
+	}
+	switch kdf {
+	case BLSKeystoreKDFScrypt:
+		return scrypt.Key([]byte(passphrase), salt, p.N, p.R, p.P, blsKeystoreKeyLen)
+	case BLSKeystoreKDFArgon2id:
+		if p.Time == 0 || p.Memory == 0 || p.Threads == 0 {
+			return nil, errors.New("invalid argon2id parameters in BLS keystore")
+		}
+		return argon2.IDKey([]byte(passphrase), salt, p.Time, p.Memory, p.Threads, blsKeystoreKeyLen), nil
+	default:
+		return nil, fmt.Errorf("unsupported BLS keystore kdf %q", kdf)
+	}
+}
+
+func newBLSKeystoreGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptBLSPrivateKey returns privKey in a keystore encrypted with a key
+// derived from passphrase by kdf, BLSKeystoreKDFScrypt or
+// BLSKeystoreKDFArgon2id.
+func EncryptBLSPrivateKey(privKey blsSignatures.PrivateKey, passphrase string, kdf string) ([]byte, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	params := blsKeystoreKDFParams{Salt: hex.EncodeToString(salt)}
+	switch kdf {
+	case BLSKeystoreKDFScrypt:
+		params.N, params.R, params.P = 1<<18, 8, 1
+	case BLSKeystoreKDFArgon2id:
+		params.Time, params.Memory, params.Threads = 3, 64*1024, 4
+	default:
+		return nil, fmt.Errorf("unsupported BLS keystore kdf %q, must be %s or %s", kdf, BLSKeystoreKDFScrypt, BLSKeystoreKDFArgon2id)
+	}
+	key, err := params.deriveKey(kdf, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newBLSKeystoreGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	pubKey, err := blsSignatures.PublicKeyFromPrivateKey(privKey)
+	if err != nil {
+		return nil, err
+	}
+	keystore := blsKeystore{
+		Version:    blsKeystoreVersion,
+		KDF:        kdf,
+		KDFParams:  params,
+		Cipher:     blsKeystoreCipher,
+		Nonce:      hex.EncodeToString(nonce),
+		Ciphertext: hex.EncodeToString(gcm.Seal(nil, nonce, blsSignatures.PrivateKeyToBytes(privKey), nil)),
+		PubKey:     base64.StdEncoding.EncodeToString(blsSignatures.PublicKeyToBytes(pubKey)),
+	}
+	return json.MarshalIndent(keystore, "", "  ")
+}
+
+// DecryptBLSPrivateKey returns the private key in a keystore written by
+// EncryptBLSPrivateKey.
+func DecryptBLSPrivateKey(keystoreBytes []byte, passphrase string) (blsSignatures.PrivateKey, error) {
+	var keystore blsKeystore
+	if err := json.Unmarshal(keystoreBytes, &keystore); err != nil {
+		return nil, fmt.Errorf("invalid BLS keystore: %w", err)
+	}
+	if keystore.Version != blsKeystoreVersion {
+		return nil, fmt.Errorf("unsupported BLS keystore version %d", keystore.Version)
+	}
+	if keystore.Cipher != blsKeystoreCipher {
+		return nil, fmt.Errorf("unsupported BLS keystore cipher %q", keystore.Cipher)
+	}
+	key, err := keystore.KDFParams.deriveKey(keystore.KDF, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newBLSKeystoreGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hex.DecodeString(keystore.Nonce)
+	if err != nil || len(nonce) != gcm.NonceSize() {
+		return nil, errors.New("invalid nonce in BLS keystore")
+	}
+	ciphertext, err := hex.DecodeString(keystore.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	privKeyBytes, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrBLSKeystorePassphrase
+	}
+	return blsSignatures.PrivateKeyFromBytes(privKeyBytes)
+}
+
+// isBLSKeystore tells an encrypted keystore from a base64 private key.
+func isBLSKeystore(privKeyBytes []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(privKeyBytes), []byte("{"))
+}
+
+// StoreEncryptedKeys is like StoreKeys, but writes the private key in a
+// keystore encrypted with passphrase. The private key never reaches the disk
+// unencrypted, and the keystore is renamed into place so a crash can't leave
+// a partial one, or replace an existing key with one.
+func StoreEncryptedKeys(keyDir string, pubKey blsSignatures.PublicKey, privKey blsSignatures.PrivateKey, passphrase string, kdf string) error {
+	keystore, err := EncryptBLSPrivateKey(privKey, passphrase, kdf)
+	if err != nil {
+		return err
+	}
+	if err := storePubKey(keyDir, pubKey); err != nil {
+		return err
+	}
+	// CreateTemp creates the file with mode 0600.
+	tmpFile, err := os.CreateTemp(keyDir, "."+DefaultPrivKeyFilename+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(keystore); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), filepath.Join(keyDir, DefaultPrivKeyFilename))
+}
+
+// passphrase returns the passphrase to decrypt the key with, prompting for it
+// on the terminal if it's not configured.
+func (c *KeyConfig) passphrase(keyDescription string) (string, error) {
+	if c.Passphrase != "" {
+		return c.Passphrase, nil
+	}
+	if c.PassphraseFile != "" {
+		passphrase, err := os.ReadFile(c.PassphraseFile)
+		if err != nil {
+			return "", fmt.Errorf("error reading BLS key passphrase: %w", err)
+		}
+		return strings.TrimRight(string(passphrase), "\r\n"), nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("BLS key %s is encrypted, but neither passphrase nor passphrase-file is set and there's no terminal to prompt on", keyDescription)
+	}
+	fmt.Fprintf(os.Stderr, "Passphrase for BLS key %s: ", keyDescription)
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(passphrase), nil
+}
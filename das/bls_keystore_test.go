@@ -0,0 +1,75 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestBLSKeystore(t *testing.T) {
+	pubKey, privKey, err := blsSignatures.GenerateKeys()
+	testhelpers.RequireImpl(t, err)
+
+	for _, kdf := range []string{BLSKeystoreKDFScrypt, BLSKeystoreKDFArgon2id} {
+		keystore, err := EncryptBLSPrivateKey(privKey, "passphrase", kdf)
+		testhelpers.RequireImpl(t, err)
+		if bytes.Contains(keystore, blsSignatures.PrivateKeyToBytes(privKey)) {
+			testhelpers.FailImpl(t, kdf, "keystore contains the private key in the clear")
+		}
+		decrypted, err := DecryptBLSPrivateKey(keystore, "passphrase")
+		testhelpers.RequireImpl(t, err)
+		if !bytes.Equal(blsSignatures.PrivateKeyToBytes(decrypted), blsSignatures.PrivateKeyToBytes(privKey)) {
+			testhelpers.FailImpl(t, kdf, "keystore decrypted to the wrong key")
+		}
+		if _, err := DecryptBLSPrivateKey(keystore, "wrong"); !errors.Is(err, ErrBLSKeystorePassphrase) {
+			testhelpers.FailImpl(t, kdf, "expected the wrong passphrase to be rejected, got", err)
+		}
+	}
+
+	// An encrypted key in a key directory is read with the passphrase.
+	keyDir := t.TempDir()
+	testhelpers.RequireImpl(t, StoreEncryptedKeys(keyDir, pubKey, privKey, "passphrase", BLSKeystoreKDFArgon2id))
+	entries, err := os.ReadDir(keyDir)
+	testhelpers.RequireImpl(t, err)
+	if len(entries) != 2 {
+		testhelpers.FailImpl(t, "expected only the public key and the keystore in the key directory, got", len(entries), "files")
+	}
+	privKeyPath := filepath.Join(keyDir, DefaultPrivKeyFilename)
+	info, err := os.Stat(privKeyPath)
+	testhelpers.RequireImpl(t, err)
+	if info.Mode().Perm() != 0o600 {
+		testhelpers.FailImpl(t, "keystore has mode", info.Mode().Perm())
+	}
+	stored, err := os.ReadFile(privKeyPath)
+	testhelpers.RequireImpl(t, err)
+	encodedPrivKey := base64.StdEncoding.EncodeToString(blsSignatures.PrivateKeyToBytes(privKey))
+	if bytes.Contains(stored, []byte(encodedPrivKey)) || bytes.Contains(stored, blsSignatures.PrivateKeyToBytes(privKey)) {
+		testhelpers.FailImpl(t, "key directory contains the private key in the clear")
+	}
+	passphraseFile := filepath.Join(t.TempDir(), "passphrase")
+	testhelpers.RequireImpl(t, os.WriteFile(passphraseFile, []byte("passphrase\n"), 0600))
+	read, err := (&KeyConfig{KeyDir: keyDir, PassphraseFile: passphraseFile}).BLSPrivKey()
+	testhelpers.RequireImpl(t, err)
+	if !bytes.Equal(blsSignatures.PrivateKeyToBytes(read), blsSignatures.PrivateKeyToBytes(privKey)) {
+		testhelpers.FailImpl(t, "read the wrong key from the encrypted key directory")
+	}
+	if _, err := (&KeyConfig{KeyDir: keyDir, Passphrase: "wrong"}).BLSPrivKey(); !errors.Is(err, ErrBLSKeystorePassphrase) {
+		testhelpers.FailImpl(t, "expected the wrong passphrase to be rejected, got", err)
+	}
+	// Tests have no terminal to prompt on.
+	if _, err := (&KeyConfig{KeyDir: keyDir}).BLSPrivKey(); err == nil {
+		testhelpers.FailImpl(t, "expected reading an encrypted key without a passphrase to fail")
+	}
+	if _, err := ReadPubKeyFromFile(keyDir + "/" + DefaultPubKeyFilename); err != nil {
+		testhelpers.FailImpl(t, "public key should stay readable:", err)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+
+	"github.com/offchainlabs/nitro/blsSignatures"
+)
+
+// BLSSigner signs with a committee member's BLS key, which it may hold in the
+// process or leave with an external service.
+type BLSSigner interface {
+	PublicKey() blsSignatures.PublicKey
+	Sign(ctx context.Context, message []byte) (blsSignatures.Signature, error)
+}
+
+type localBLSSigner struct {
+	privKey blsSignatures.PrivateKey
+	pubKey  blsSignatures.PublicKey
+}
+
+// NewLocalBLSSigner returns a signer holding privKey in the process.
+func NewLocalBLSSigner(privKey blsSignatures.PrivateKey) (BLSSigner, error) {
+	pubKey, err := blsSignatures.PublicKeyFromPrivateKey(privKey)
+	if err != nil {
+		return nil, err
+	}
+	return &localBLSSigner{privKey: privKey, pubKey: pubKey}, nil
+}
+
+func (s *localBLSSigner) PublicKey() blsSignatures.PublicKey {
+	return s.pubKey
+}
+
+func (s *localBLSSigner) Sign(ctx context.Context, message []byte) (blsSignatures.Signature, error) {
+	return blsSignatures.SignMessage(s.privKey, message)
+}
+
+// hasKey reports whether a key, or a signer holding one, is configured.
+func (c *KeyConfig) hasKey() bool {
+	return c.KeyDir != "" || c.PrivKey != "" || c.RemoteSigner.URL != "" || c.Vault.Address != "" || c.PKCS11.Module != "" || c.Threshold.Shares != ""
+}
+
+// BLSSigner returns a signer for the configured key.
+func (c *KeyConfig) BLSSigner(ctx context.Context) (BLSSigner, error) {
+	sources := 0
+	for _, set := range []bool{c.KeyDir != "" || c.PrivKey != "", c.RemoteSigner.URL != "", c.Vault.Address != "", c.PKCS11.Module != "", c.Threshold.Shares != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return nil, errors.New("only one of key-dir or priv-key, remote-signer, vault, pkcs11 and threshold may be set")
+	}
+	if c.RemoteSigner.URL != "" {
+		return NewRemoteBLSSigner(ctx, c.RemoteSigner)
+	}
+	if c.Vault.Address != "" {
+		return NewVaultBLSSigner(ctx, c.Vault)
+	}
+	if c.PKCS11.Module != "" {
+		return NewPKCS11BLSSigner(c.PKCS11)
+	}
+	if c.Threshold.Shares != "" {
+		return NewThresholdBLSSigner(ctx, c.Threshold, c)
+	}
+	privKey, err := c.BLSPrivKey()
+	if err != nil {
+		return nil, err
+	}
+	return NewLocalBLSSigner(privKey)
+}
@@ -0,0 +1,196 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/pretty"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+const boltDBFileName = "das.bolt"
+
+var (
+	boltDataBucket   = []byte("data")
+	boltExpiryBucket = []byte("expiry")
+)
+
+// BoltDBStorageService keeps all data in a single bbolt file, which is simple
+// to back up while the daserver is stopped.
+type BoltDBStorageService struct {
+	db                  *bolt.DB
+	discardAfterTimeout bool
+	path                string
+	stopWaiter          stopwaiter.StopWaiterSafe
+}
+
+func NewBoltDBStorageService(ctx context.Context, dirPath string, discardAfterTimeout bool) (StorageService, error) {
+	if err := os.MkdirAll(dirPath, 0o700); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dirPath, boltDBFileName)
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(boltDataBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(boltExpiryBucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	ret := &BoltDBStorageService{
+		db:                  db,
+		discardAfterTimeout: discardAfterTimeout,
+		path:                path,
+	}
+	if err := ret.stopWaiter.Start(ctx, ret); err != nil {
+		return nil, err
+	}
+	err = ret.stopWaiter.LaunchThreadSafe(func(myCtx context.Context) {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+		defer func() {
+			if err := ret.db.Close(); err != nil {
+				log.Error("Failed to close DB", "err", err)
+			}
+		}()
+		for {
+			select {
+			case <-ticker.C:
+				if err := ret.discardExpired(uint64(time.Now().Unix())); err != nil {
+					log.Error("das.BoltDBStorageService failed to discard expired data", "err", err)
+				}
+			case <-myCtx.Done():
+				return
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// discardExpired deletes all data whose timeout is at or before now. Expiry
+// keys are the big-endian timeout followed by the data hash, so the cursor
+// visits them in order of timeout.
+func (bs *BoltDBStorageService) discardExpired(now uint64) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		data := tx.Bucket(boltDataBucket)
+		expiry := tx.Bucket(boltExpiryBucket)
+		// Deleting through the cursor while iterating can skip keys, so
+		// collect the expired keys first.
+		var expired [][]byte
+		c := expiry.Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			if len(k) != 8+32 || binary.BigEndian.Uint64(k) > now {
+				break
+			}
+			expired = append(expired, append([]byte{}, k...))
+		}
+		for _, k := range expired {
+			if err := data.Delete(k[8:]); err != nil {
+				return err
+			}
+			if err := expiry.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (bs *BoltDBStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.BoltDBStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", bs)
+
+	var ret []byte
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(boltDataBucket).Get(key.Bytes())
+		if value == nil {
+			return ErrNotFound
+		}
+		// Values are only valid for the life of the transaction.
+		ret = append([]byte{}, value...)
+		return nil
+	})
+	return ret, err
+}
+
+func (bs *BoltDBStorageService) Put(ctx context.Context, data []byte, timeout uint64) error {
+	logPut("das.BoltDBStorageService.Put", data, timeout, bs)
+
+	key := dastree.HashBytes(data)
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(boltDataBucket).Put(key, data); err != nil {
+			return err
+		}
+		if !bs.discardAfterTimeout {
+			return nil
+		}
+		expiryKey := binary.BigEndian.AppendUint64(make([]byte, 0, 8+len(key)), timeout)
+		return tx.Bucket(boltExpiryBucket).Put(append(expiryKey, key...), []byte{})
+	})
+}
+
+func (bs *BoltDBStorageService) putKeyValue(ctx context.Context, key common.Hash, value []byte) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltDataBucket).Put(key.Bytes(), value)
+	})
+}
+
+func (bs *BoltDBStorageService) Sync(ctx context.Context) error {
+	return bs.db.Sync()
+}
+
+func (bs *BoltDBStorageService) Close(ctx context.Context) error {
+	return bs.stopWaiter.StopAndWait()
+}
+
+func (bs *BoltDBStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	if bs.discardAfterTimeout {
+		return arbstate.DiscardAfterDataTimeout, nil
+	}
+	return arbstate.KeepForever, nil
+}
+
+func (bs *BoltDBStorageService) String() string {
+	return "BoltDB(" + bs.path + ")"
+}
+
+func (bs *BoltDBStorageService) HealthCheck(ctx context.Context) error {
+	testData := []byte("Test-Data")
+	err := bs.Put(ctx, testData, uint64(time.Now().Add(time.Minute).Unix()))
+	if err != nil {
+		return err
+	}
+	res, err := bs.GetByHash(ctx, dastree.Hash(testData))
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(res, testData) {
+		return errors.New("invalid GetByHash result")
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+func TestBoltDBStorageService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	storageService, err := NewDBStorageServiceForEngine(ctx, LocalDBStorageConfig{
+		DataDir:             t.TempDir(),
+		DBEngine:            "bolt",
+		DiscardAfterTimeout: true,
+	})
+	Require(t, err)
+	defer storageService.Close(ctx)
+	bs := storageService.(*BoltDBStorageService)
+
+	now := uint64(time.Now().Unix())
+	val1 := []byte("The first value")
+	val2 := []byte("The second value")
+	Require(t, bs.Put(ctx, val1, now+10))
+	Require(t, bs.Put(ctx, val2, now+1000))
+
+	_, err = bs.GetByHash(ctx, dastree.Hash(append(val1, 0)))
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	val, err := bs.GetByHash(ctx, dastree.Hash(val1))
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		t.Fatal(val, val1)
+	}
+
+	Require(t, bs.discardExpired(now+100))
+	_, err = bs.GetByHash(ctx, dastree.Hash(val1))
+	if !errors.Is(err, ErrNotFound) {
+		Fail(t, "expired data wasn't discarded", err)
+	}
+	val, err = bs.GetByHash(ctx, dastree.Hash(val2))
+	Require(t, err)
+	if !bytes.Equal(val, val2) {
+		t.Fatal(val, val2)
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// cacheMetrics counts the hits, misses and evictions of a cache in front of
+// the storage backends, as arb/das/cache/<name>/{hit,miss,eviction}.
+type cacheMetrics struct {
+	hits      metrics.Counter
+	misses    metrics.Counter
+	evictions metrics.Counter
+}
+
+func newCacheMetrics(name string) *cacheMetrics {
+	base := "arb/das/cache/" + name
+	return &cacheMetrics{
+		hits:      metrics.GetOrRegisterCounter(base+"/hit", nil),
+		misses:    metrics.GetOrRegisterCounter(base+"/miss", nil),
+		evictions: metrics.GetOrRegisterCounter(base+"/eviction", nil),
+	}
+}
+
+func (m *cacheMetrics) hit() {
+	m.hits.Inc(1)
+}
+
+func (m *cacheMetrics) miss() {
+	m.misses.Inc(1)
+}
+
+func (m *cacheMetrics) evicted() {
+	m.evictions.Inc(1)
+}
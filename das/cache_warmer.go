@@ -0,0 +1,142 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
+	"github.com/offchainlabs/nitro/util/headerreader"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+
+	flag "github.com/spf13/pflag"
+)
+
+type CacheWarmupConfig struct {
+	Enable                   bool   `koanf:"enable"`
+	Batches                  uint64 `koanf:"batches"`
+	ParentChainBlocksPerRead uint64 `koanf:"parent-chain-blocks-per-read"`
+}
+
+var DefaultCacheWarmupConfig = CacheWarmupConfig{
+	Batches:                  100,
+	ParentChainBlocksPerRead: 1000,
+}
+
+func CacheWarmupConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultCacheWarmupConfig.Enable, "on startup, read the data of the most recent batches posted to the sequencer inbox, so it is cached before it is requested")
+	f.Uint64(prefix+".batches", DefaultCacheWarmupConfig.Batches, "number of recent batches to read")
+	f.Uint64(prefix+".parent-chain-blocks-per-read", DefaultCacheWarmupConfig.ParentChainBlocksPerRead, "max parent chain blocks to search for batches per log query")
+}
+
+// CacheWarmer reads the DAS data of the most recent batches in the sequencer
+// inbox through the daserver's storage, so that the caches in front of the
+// storage backends hold it.
+type CacheWarmer struct {
+	stopwaiter.StopWaiter
+
+	config        CacheWarmupConfig
+	dataSource    arbstate.DataAvailabilityReader
+	l1Reader      *headerreader.HeaderReader
+	inboxContract *bridgegen.SequencerInbox
+	inboxAddr     common.Address
+}
+
+func NewCacheWarmer(config CacheWarmupConfig, dataSource arbstate.DataAvailabilityReader, l1Reader *headerreader.HeaderReader, inboxAddr common.Address) (*CacheWarmer, error) {
+	l1Client := l1Reader.Client()
+	inboxContract, err := bridgegen.NewSequencerInbox(inboxAddr, l1Client)
+	if err != nil {
+		return nil, err
+	}
+	dataSource, err = NewChainFetchReader(dataSource, l1Client, inboxAddr)
+	if err != nil {
+		return nil, err
+	}
+	return &CacheWarmer{
+		config:        config,
+		dataSource:    dataSource,
+		l1Reader:      l1Reader,
+		inboxContract: inboxContract,
+		inboxAddr:     inboxAddr,
+	}, nil
+}
+
+func (w *CacheWarmer) Start(ctxIn context.Context) {
+	w.StopWaiter.Start(ctxIn, w)
+	w.LaunchThread(func(ctx context.Context) {
+		warmed, err := w.warm(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Warn("error warming DAS caches from recent batches", "batches", warmed, "err", err)
+			return
+		}
+		log.Info("warmed DAS caches from recent batches", "batches", warmed)
+	})
+}
+
+func (w *CacheWarmer) Close(ctx context.Context) error {
+	w.StopOnly()
+	return nil
+}
+
+// warm reads batches from the newest backwards, until enough have been read
+// or the start of the parent chain is reached.
+func (w *CacheWarmer) warm(ctx context.Context) (uint64, error) {
+	header, err := w.l1Reader.LastHeader(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var warmed uint64
+	high := header.Number.Uint64()
+	for warmed < w.config.Batches {
+		low := uint64(0)
+		if high >= w.config.ParentChainBlocksPerRead {
+			low = high - w.config.ParentChainBlocksPerRead + 1
+		}
+		query := ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(low),
+			ToBlock:   new(big.Int).SetUint64(high),
+			Addresses: []common.Address{w.inboxAddr},
+			Topics:    [][]common.Hash{{BatchDeliveredID}},
+		}
+		logs, err := w.l1Reader.Client().FilterLogs(ctx, query)
+		if err != nil {
+			return warmed, err
+		}
+		for i := len(logs) - 1; i >= 0 && warmed < w.config.Batches; i-- {
+			if err := w.warmBatch(ctx, logs[i]); err != nil {
+				if ctx.Err() != nil {
+					return warmed, err
+				}
+				log.Warn("error warming DAS caches from batch", "txhash", logs[i].TxHash, "err", err)
+			}
+			warmed++
+		}
+		if low == 0 {
+			break
+		}
+		high = low - 1
+	}
+	return warmed, nil
+}
+
+func (w *CacheWarmer) warmBatch(ctx context.Context, batchDeliveredLog types.Log) error {
+	deliveredEvent, err := w.inboxContract.ParseSequencerBatchDelivered(batchDeliveredLog)
+	if err != nil {
+		return err
+	}
+	data, err := FindDASDataFromLog(ctx, w.inboxContract, deliveredEvent, w.inboxAddr, w.l1Reader.Client(), batchDeliveredLog)
+	if err != nil || data == nil {
+		return err
+	}
+	data = sequencerMessageFromDASData(deliveredEvent, data)
+	_, err = arbstate.RecoverPayloadFromDasBatch(ctx, deliveredEvent.BatchSequenceNumber.Uint64(), data, w.dataSource, nil, arbstate.KeysetValidate)
+	return err
+}
@@ -0,0 +1,178 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/containers"
+	"github.com/offchainlabs/nitro/util/pretty"
+
+	flag "github.com/spf13/pflag"
+)
+
+type LruCacheConfig struct {
+	Enable        bool   `koanf:"enable"`
+	Capacity      int    `koanf:"capacity"`
+	MaxSize       uint64 `koanf:"max-size"`
+	MaxObjectSize uint64 `koanf:"max-object-size"`
+	Policy        string `koanf:"policy"`
+}
+
+var DefaultLruCacheConfig = LruCacheConfig{
+	Capacity: 1000,
+	MaxSize:  256 * 1024 * 1024,
+	Policy:   "lru",
+}
+
+func LruCacheConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultLruCacheConfig.Enable, "enable an in-memory cache of the most recently read and written sequencer batches")
+	f.Int(prefix+".capacity", DefaultLruCacheConfig.Capacity, "maximum number of batches in the LRU cache")
+	f.Uint64(prefix+".max-size", DefaultLruCacheConfig.MaxSize, "maximum total size in bytes of the batches in the LRU cache (0 for no limit)")
+	f.Uint64(prefix+".max-object-size", DefaultLruCacheConfig.MaxObjectSize, "maximum size in bytes of a batch to keep in the LRU cache (0 for no limit other than max-size)")
+	f.String(prefix+".policy", DefaultLruCacheConfig.Policy, "admission policy of the LRU cache: lru admits every batch, tinylfu only admits a batch when the cache is full if it has been read more often recently than the batch it would evict")
+}
+
+// CachedStorageService keeps the most recently used data in a bounded LRU
+// cache in front of the wrapped StorageService. With the tinylfu policy, the
+// recent read frequency of keys is tracked so that when the cache is full,
+// data read once doesn't push out data that is read often.
+type CachedStorageService struct {
+	baseStorageService StorageService
+	config             LruCacheConfig
+	metrics            *cacheMetrics
+
+	mutex     sync.Mutex
+	cache     *containers.LruCache[common.Hash, []byte]
+	size      uint64
+	frequency *containers.FrequencySketch
+}
+
+func NewCachedStorageService(config LruCacheConfig, baseStorageService StorageService) (*CachedStorageService, error) {
+	c := &CachedStorageService{
+		baseStorageService: baseStorageService,
+		config:             config,
+		metrics:            newCacheMetrics("lru"),
+	}
+	switch config.Policy {
+	case "", "lru":
+	case "tinylfu":
+		c.frequency = containers.NewFrequencySketch(config.Capacity)
+	default:
+		return nil, fmt.Errorf("invalid lru-cache.policy %s, must be lru or tinylfu", config.Policy)
+	}
+	c.cache = containers.NewLruCacheWithOnEvict(config.Capacity, func(key common.Hash, value []byte) {
+		c.size -= uint64(len(value))
+	})
+	return c, nil
+}
+
+func frequencySketchKey(key common.Hash) uint64 {
+	return binary.BigEndian.Uint64(key[:8])
+}
+
+// full returns whether adding a value of the given size would evict others.
+// It must be called with the mutex held.
+func (c *CachedStorageService) full(size uint64) bool {
+	return c.cache.Len() >= c.config.Capacity || (c.config.MaxSize > 0 && c.size+size > c.config.MaxSize)
+}
+
+// admit returns whether the tinylfu policy admits key into the full cache.
+// It must be called with the mutex held.
+func (c *CachedStorageService) admit(key common.Hash) bool {
+	victim, _, ok := c.cache.GetOldest()
+	if !ok {
+		return true
+	}
+	return c.frequency.Estimate(frequencySketchKey(key)) > c.frequency.Estimate(frequencySketchKey(victim))
+}
+
+func (c *CachedStorageService) add(key common.Hash, value []byte) {
+	size := uint64(len(value))
+	if c.config.Capacity <= 0 || (c.config.MaxSize > 0 && size > c.config.MaxSize) ||
+		(c.config.MaxObjectSize > 0 && size > c.config.MaxObjectSize) {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.cache.Contains(key) {
+		c.cache.Get(key)
+		return
+	}
+	if c.frequency != nil && c.full(size) && !c.admit(key) {
+		return
+	}
+	if c.cache.Add(key, value) {
+		c.metrics.evicted()
+	}
+	c.size += size
+	for c.config.MaxSize > 0 && c.size > c.config.MaxSize {
+		c.cache.RemoveOldest()
+		c.metrics.evicted()
+	}
+}
+
+func (c *CachedStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.CachedStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", c)
+
+	c.mutex.Lock()
+	if c.frequency != nil {
+		c.frequency.Increment(frequencySketchKey(key))
+	}
+	value, ok := c.cache.Get(key)
+	c.mutex.Unlock()
+	if ok {
+		c.metrics.hit()
+		return value, nil
+	}
+	c.metrics.miss()
+	value, err := c.baseStorageService.GetByHash(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if dastree.ValidHash(key, value) {
+		c.add(key, value)
+	}
+	return value, nil
+}
+
+func (c *CachedStorageService) Put(ctx context.Context, value []byte, timeout uint64) error {
+	logPut("das.CachedStorageService.Put", value, timeout, c)
+	if err := c.baseStorageService.Put(ctx, value, timeout); err != nil {
+		return err
+	}
+	c.add(dastree.Hash(value), value)
+	return nil
+}
+
+func (c *CachedStorageService) Sync(ctx context.Context) error {
+	return c.baseStorageService.Sync(ctx)
+}
+
+func (c *CachedStorageService) Close(ctx context.Context) error {
+	c.mutex.Lock()
+	c.cache.Clear()
+	c.mutex.Unlock()
+	return c.baseStorageService.Close(ctx)
+}
+
+func (c *CachedStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	return c.baseStorageService.ExpirationPolicy(ctx)
+}
+
+func (c *CachedStorageService) String() string {
+	return fmt.Sprintf("CachedStorageService(%+v, %v)", c.config, c.baseStorageService)
+}
+
+func (c *CachedStorageService) HealthCheck(ctx context.Context) error {
+	return c.baseStorageService.HealthCheck(ctx)
+}
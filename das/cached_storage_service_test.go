@@ -0,0 +1,133 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+func TestCachedStorageService(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	base := NewMemoryBackedStorageService(ctx)
+	cached, err := NewCachedStorageService(LruCacheConfig{Enable: true, Capacity: 10, MaxSize: 20}, base)
+	Require(t, err)
+
+	val1 := []byte("0123456789")
+	val2 := []byte("abcdefghij")
+	val3 := []byte("ABCDEFGHIJ")
+	Require(t, base.Put(ctx, val1, timeout))
+	val, err := cached.GetByHash(ctx, dastree.Hash(val1))
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		Fail(t, val, val1)
+	}
+	Require(t, cached.Put(ctx, val2, timeout))
+
+	// Reading val1 makes val2 the least recently used, so it's evicted to keep
+	// the cache within its max size.
+	_, err = cached.GetByHash(ctx, dastree.Hash(val1))
+	Require(t, err)
+	Require(t, cached.Put(ctx, val3, timeout))
+	if cached.size != 20 {
+		Fail(t, "unexpected cache size", cached.size)
+	}
+	if cached.cache.Contains(dastree.Hash(val2)) {
+		Fail(t, "least recently used data wasn't evicted")
+	}
+
+	// Cached data is served without the base service.
+	Require(t, base.Close(ctx))
+	val, err = cached.GetByHash(ctx, dastree.Hash(val1))
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		Fail(t, val, val1)
+	}
+	_, err = cached.GetByHash(ctx, dastree.Hash(val2))
+	if !errors.Is(err, ErrClosed) {
+		Fail(t, "expected ErrClosed from base service", err)
+	}
+}
+
+func TestCachedStorageServiceAdmission(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	base := NewMemoryBackedStorageService(ctx)
+	_, err := NewCachedStorageService(LruCacheConfig{Enable: true, Capacity: 2, Policy: "lfu"}, base)
+	if err == nil {
+		Fail(t, "expected error for unknown policy")
+	}
+	cached, err := NewCachedStorageService(LruCacheConfig{Enable: true, Capacity: 2, MaxObjectSize: 15, Policy: "tinylfu"}, base)
+	Require(t, err)
+
+	popular1 := []byte("first popular")
+	popular2 := []byte("second popular")
+	unpopular := []byte("unpopular")
+	tooBig := []byte("bigger than the max object size")
+	for _, val := range [][]byte{popular1, popular2, unpopular, tooBig} {
+		Require(t, base.Put(ctx, val, timeout))
+	}
+	for i := 0; i < 3; i++ {
+		for _, val := range [][]byte{popular1, popular2} {
+			_, err = cached.GetByHash(ctx, dastree.Hash(val))
+			Require(t, err)
+		}
+	}
+
+	// A batch read once doesn't displace batches read more often.
+	_, err = cached.GetByHash(ctx, dastree.Hash(unpopular))
+	Require(t, err)
+	if cached.cache.Contains(dastree.Hash(unpopular)) {
+		Fail(t, "infrequently read data was admitted to the full cache")
+	}
+	if !cached.cache.Contains(dastree.Hash(popular1)) || !cached.cache.Contains(dastree.Hash(popular2)) {
+		Fail(t, "frequently read data was evicted")
+	}
+
+	_, err = cached.GetByHash(ctx, dastree.Hash(tooBig))
+	Require(t, err)
+	if cached.cache.Contains(dastree.Hash(tooBig)) {
+		Fail(t, "data bigger than max-object-size was cached")
+	}
+}
+
+type countingReader struct {
+	StorageService
+	reads int
+}
+
+func (r *countingReader) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	r.reads++
+	return r.StorageService.GetByHash(ctx, key)
+}
+
+func TestCachedStorageServiceForReader(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	base := NewMemoryBackedStorageService(ctx)
+	val := []byte("The first value")
+	Require(t, base.Put(ctx, val, timeout))
+
+	reader := &countingReader{StorageService: base}
+	cached, err := NewCachedStorageService(DefaultLruCacheConfig, NewReadLimitedStorageService(reader))
+	Require(t, err)
+	for i := 0; i < 3; i++ {
+		res, err := cached.GetByHash(ctx, dastree.Hash(val))
+		Require(t, err)
+		if !bytes.Equal(res, val) {
+			Fail(t, res, val)
+		}
+	}
+	if reader.reads != 1 {
+		Fail(t, "retrieved data was fetched again", reader.reads)
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/pretty"
+
+	flag "github.com/spf13/pflag"
+)
+
+// Cassandra rejects TTLs above 20 years.
+const cassandraMaxTTLSeconds = 20 * 365 * 24 * 60 * 60
+
+var cassandraIdentifierRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
+
+// CassandraOperator is the subset of Cassandra operations used by
+// CassandraStorageService, so the session can be replaced in tests.
+type CassandraOperator interface {
+	// Insert stores the value under key. A zero ttl means the row never expires.
+	Insert(ctx context.Context, key []byte, value []byte, ttlSeconds int) error
+	// Select returns gocql.ErrNotFound if there is no row for key.
+	Select(ctx context.Context, key []byte) ([]byte, error)
+	Ping(ctx context.Context) error
+	Close()
+}
+
+type CassandraStorageServiceConfig struct {
+	Enable                 bool          `koanf:"enable"`
+	Hosts                  []string      `koanf:"hosts"`
+	Keyspace               string        `koanf:"keyspace"`
+	Table                  string        `koanf:"table"`
+	Username               string        `koanf:"username"`
+	Password               string        `koanf:"password"`
+	WriteConsistency       string        `koanf:"write-consistency"`
+	ReadConsistency        string        `koanf:"read-consistency"`
+	Timeout                time.Duration `koanf:"timeout"`
+	NumConns               int           `koanf:"num-conns"`
+	CreateTable            bool          `koanf:"create-table"`
+	DiscardAfterTimeout    bool          `koanf:"discard-after-timeout"`
+	SyncFromStorageService bool          `koanf:"sync-from-storage-service"`
+	SyncToStorageService   bool          `koanf:"sync-to-storage-service"`
+}
+
+var DefaultCassandraStorageServiceConfig = CassandraStorageServiceConfig{
+	Table:            "das_data",
+	WriteConsistency: "QUORUM",
+	ReadConsistency:  "ONE",
+	Timeout:          5 * time.Second,
+	NumConns:         2,
+	CreateTable:      true,
+}
+
+func CassandraStorageConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultCassandraStorageServiceConfig.Enable, "enable storage/retrieval of sequencer batch data from a Cassandra or ScyllaDB cluster")
+	f.StringSlice(prefix+".hosts", DefaultCassandraStorageServiceConfig.Hosts, "Cassandra contact points, as host or host:port")
+	f.String(prefix+".keyspace", DefaultCassandraStorageServiceConfig.Keyspace, "Cassandra keyspace, which must already exist")
+	f.String(prefix+".table", DefaultCassandraStorageServiceConfig.Table, "Cassandra table in which to store the data")
+	f.String(prefix+".username", DefaultCassandraStorageServiceConfig.Username, "Cassandra username for password authentication")
+	f.String(prefix+".password", DefaultCassandraStorageServiceConfig.Password, "Cassandra password for password authentication")
+	f.String(prefix+".write-consistency", DefaultCassandraStorageServiceConfig.WriteConsistency, "consistency level for writes (ANY, ONE, TWO, THREE, QUORUM, ALL, LOCAL_QUORUM, EACH_QUORUM, LOCAL_ONE)")
+	f.String(prefix+".read-consistency", DefaultCassandraStorageServiceConfig.ReadConsistency, "consistency level for reads (ONE, TWO, THREE, QUORUM, ALL, LOCAL_QUORUM, EACH_QUORUM, LOCAL_ONE)")
+	f.Duration(prefix+".timeout", DefaultCassandraStorageServiceConfig.Timeout, "timeout for each Cassandra query")
+	f.Int(prefix+".num-conns", DefaultCassandraStorageServiceConfig.NumConns, "number of connections to open to each Cassandra host")
+	f.Bool(prefix+".create-table", DefaultCassandraStorageServiceConfig.CreateTable, "create the table on startup if it doesn't exist")
+	f.Bool(prefix+".discard-after-timeout", DefaultCassandraStorageServiceConfig.DiscardAfterTimeout, "set a TTL on each row so Cassandra discards it after its expiry timeout")
+	f.Bool(prefix+".sync-from-storage-service", DefaultCassandraStorageServiceConfig.SyncFromStorageService, "enable Cassandra to be used as a source for regular sync storage")
+	f.Bool(prefix+".sync-to-storage-service", DefaultCassandraStorageServiceConfig.SyncToStorageService, "enable Cassandra to be used as a sink for regular sync storage")
+}
+
+type CassandraStorageService struct {
+	operator            CassandraOperator
+	keyspace            string
+	table               string
+	discardAfterTimeout bool
+}
+
+func NewCassandraStorageService(config CassandraStorageServiceConfig) (StorageService, error) {
+	if len(config.Hosts) == 0 {
+		return nil, errors.New("cassandra-storage.hosts must be set")
+	}
+	if !cassandraIdentifierRegex.MatchString(config.Keyspace) {
+		return nil, fmt.Errorf("invalid cassandra-storage.keyspace '%s'", config.Keyspace)
+	}
+	if !cassandraIdentifierRegex.MatchString(config.Table) {
+		return nil, fmt.Errorf("invalid cassandra-storage.table '%s'", config.Table)
+	}
+	writeConsistency, err := gocql.ParseConsistencyWrapper(strings.ToUpper(config.WriteConsistency))
+	if err != nil {
+		return nil, fmt.Errorf("invalid cassandra-storage.write-consistency: %w", err)
+	}
+	readConsistency, err := gocql.ParseConsistencyWrapper(strings.ToUpper(config.ReadConsistency))
+	if err != nil {
+		return nil, fmt.Errorf("invalid cassandra-storage.read-consistency: %w", err)
+	}
+
+	cluster := gocql.NewCluster(config.Hosts...)
+	cluster.Keyspace = config.Keyspace
+	cluster.Timeout = config.Timeout
+	cluster.NumConns = config.NumConns
+	if config.Username != "" {
+		cluster.Authenticator = gocql.PasswordAuthenticator{
+			Username: config.Username,
+			Password: config.Password,
+		}
+	}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to Cassandra: %w", err)
+	}
+	if config.CreateTable {
+		err = session.Query(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s.%s (key blob PRIMARY KEY, data blob)", config.Keyspace, config.Table)).Exec()
+		if err != nil {
+			session.Close()
+			return nil, fmt.Errorf("error creating Cassandra table: %w", err)
+		}
+	}
+	return &CassandraStorageService{
+		operator: &cassandraSession{
+			session:          session,
+			insertQuery:      fmt.Sprintf("INSERT INTO %s.%s (key, data) VALUES (?, ?) USING TTL ?", config.Keyspace, config.Table),
+			selectQuery:      fmt.Sprintf("SELECT data FROM %s.%s WHERE key = ?", config.Keyspace, config.Table),
+			writeConsistency: writeConsistency,
+			readConsistency:  readConsistency,
+		},
+		keyspace:            config.Keyspace,
+		table:               config.Table,
+		discardAfterTimeout: config.DiscardAfterTimeout,
+	}, nil
+}
+
+type cassandraSession struct {
+	session          *gocql.Session
+	insertQuery      string
+	selectQuery      string
+	writeConsistency gocql.Consistency
+	readConsistency  gocql.Consistency
+}
+
+func (c *cassandraSession) Insert(ctx context.Context, key []byte, value []byte, ttlSeconds int) error {
+	return c.session.Query(c.insertQuery, key, value, ttlSeconds).WithContext(ctx).Consistency(c.writeConsistency).Exec()
+}
+
+func (c *cassandraSession) Select(ctx context.Context, key []byte) ([]byte, error) {
+	var value []byte
+	err := c.session.Query(c.selectQuery, key).WithContext(ctx).Consistency(c.readConsistency).Scan(&value)
+	return value, err
+}
+
+func (c *cassandraSession) Ping(ctx context.Context) error {
+	return c.session.Query("SELECT now() FROM system.local").WithContext(ctx).Consistency(gocql.One).Exec()
+}
+
+func (c *cassandraSession) Close() {
+	c.session.Close()
+}
+
+// ttlSeconds returns the Cassandra TTL for data with the given expiry timeout,
+// or zero if the data should be kept forever.
+func (cs *CassandraStorageService) ttlSeconds(timeout uint64) int {
+	if !cs.discardAfterTimeout || timeout > math.MaxInt64 {
+		return 0
+	}
+	ttl := int64(timeout) - time.Now().Unix()
+	if ttl < 1 {
+		// A zero TTL would keep the row forever, so expire it as soon as possible instead.
+		return 1
+	}
+	if ttl > cassandraMaxTTLSeconds {
+		return cassandraMaxTTLSeconds
+	}
+	return int(ttl)
+}
+
+func (cs *CassandraStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.CassandraStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", cs)
+
+	value, err := cs.operator.Select(ctx, key.Bytes())
+	if errors.Is(err, gocql.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		log.Error("das.CassandraStorageService.GetByHash", "err", err)
+		return nil, err
+	}
+	return value, nil
+}
+
+func (cs *CassandraStorageService) Put(ctx context.Context, value []byte, timeout uint64) error {
+	logPut("das.CassandraStorageService.Store", value, timeout, cs)
+	err := cs.operator.Insert(ctx, dastree.HashBytes(value), value, cs.ttlSeconds(timeout))
+	if err != nil {
+		log.Error("das.CassandraStorageService.Store", "err", err)
+	}
+	return err
+}
+
+func (cs *CassandraStorageService) putKeyValue(ctx context.Context, key common.Hash, value []byte) error {
+	err := cs.operator.Insert(ctx, key.Bytes(), value, 0)
+	if err != nil {
+		log.Error("das.CassandraStorageService.putKeyValue", "err", err)
+	}
+	return err
+}
+
+func (cs *CassandraStorageService) Sync(ctx context.Context) error {
+	return nil
+}
+
+func (cs *CassandraStorageService) Close(ctx context.Context) error {
+	cs.operator.Close()
+	return nil
+}
+
+func (cs *CassandraStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	if cs.discardAfterTimeout {
+		return arbstate.DiscardAfterDataTimeout, nil
+	}
+	return arbstate.KeepForever, nil
+}
+
+func (cs *CassandraStorageService) String() string {
+	return fmt.Sprintf("CassandraStorageService(:%s.%s)", cs.keyspace, cs.table)
+}
+
+func (cs *CassandraStorageService) HealthCheck(ctx context.Context) error {
+	return cs.operator.Ping(ctx)
+}
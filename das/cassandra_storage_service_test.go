@@ -0,0 +1,89 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+type mockCassandraOperator struct {
+	mutex sync.Mutex
+	rows  map[string][]byte
+	ttls  map[string]int
+}
+
+func (m *mockCassandraOperator) Insert(ctx context.Context, key []byte, value []byte, ttlSeconds int) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.rows[string(key)] = append([]byte{}, value...)
+	m.ttls[string(key)] = ttlSeconds
+	return nil
+}
+
+func (m *mockCassandraOperator) Select(ctx context.Context, key []byte) ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	value, ok := m.rows[string(key)]
+	if !ok {
+		return nil, gocql.ErrNotFound
+	}
+	return value, nil
+}
+
+func (m *mockCassandraOperator) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (m *mockCassandraOperator) Close() {}
+
+func TestCassandraStorageService(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	operator := &mockCassandraOperator{rows: make(map[string][]byte), ttls: make(map[string]int)}
+	cassandraService := &CassandraStorageService{
+		operator:            operator,
+		keyspace:            "das",
+		table:               "das_data",
+		discardAfterTimeout: true,
+	}
+
+	val1 := []byte("The first value")
+	val1CorrectKey := dastree.Hash(val1)
+	val2IncorrectKey := dastree.Hash(append(val1, 0))
+
+	_, err := cassandraService.GetByHash(ctx, val1CorrectKey)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	err = cassandraService.Put(ctx, val1, timeout)
+	Require(t, err)
+
+	_, err = cassandraService.GetByHash(ctx, val2IncorrectKey)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	val, err := cassandraService.GetByHash(ctx, val1CorrectKey)
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		t.Fatal(val, val1)
+	}
+
+	ttl := operator.ttls[string(val1CorrectKey.Bytes())]
+	if ttl <= 0 || ttl > 3600 {
+		Fail(t, "unexpected TTL for stored data", ttl)
+	}
+	if cassandraService.ttlSeconds(uint64(time.Now().Add(-time.Hour).Unix())) != 1 {
+		Fail(t, "data past its timeout should expire immediately")
+	}
+}
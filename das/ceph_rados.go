@@ -0,0 +1,97 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+//go:build ceph
+
+package das
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ceph/go-ceph/rados"
+)
+
+type radosOperator struct {
+	conn      *rados.Conn
+	ioctx     *rados.IOContext
+	closeOnce sync.Once
+}
+
+func newRadosOperator(config CephStorageServiceConfig) (CephOperator, error) {
+	conn, err := rados.NewConnWithClusterAndUser(config.ClusterName, "client."+config.User)
+	if err != nil {
+		return nil, err
+	}
+	if config.ConfigFile != "" {
+		err = conn.ReadConfigFile(config.ConfigFile)
+	} else {
+		err = conn.ReadDefaultConfigFile()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading Ceph config: %w", err)
+	}
+	if config.Keyring != "" {
+		if err := conn.SetConfigOption("keyring", config.Keyring); err != nil {
+			return nil, err
+		}
+	}
+	if config.MonHost != "" {
+		if err := conn.SetConfigOption("mon_host", config.MonHost); err != nil {
+			return nil, err
+		}
+	}
+	if err := conn.Connect(); err != nil {
+		return nil, fmt.Errorf("error connecting to Ceph cluster %s: %w", config.ClusterName, err)
+	}
+	ioctx, err := conn.OpenIOContext(config.Pool)
+	if err != nil {
+		conn.Shutdown()
+		return nil, fmt.Errorf("error opening Ceph pool %s: %w", config.Pool, err)
+	}
+	ioctx.SetNamespace(config.Namespace)
+	return &radosOperator{conn: conn, ioctx: ioctx}, nil
+}
+
+func (r *radosOperator) Write(key string, value []byte) error {
+	return r.ioctx.WriteFull(key, value)
+}
+
+func (r *radosOperator) Read(key string) ([]byte, error) {
+	stat, err := r.ioctx.Stat(key)
+	if errors.Is(err, rados.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, stat.Size)
+	var offset uint64
+	for offset < stat.Size {
+		n, err := r.ioctx.Read(key, data[offset:], offset)
+		if errors.Is(err, rados.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return nil, fmt.Errorf("object %s is shorter than its reported size", key)
+		}
+		offset += uint64(n)
+	}
+	return data, nil
+}
+
+func (r *radosOperator) Ping() error {
+	_, err := r.ioctx.GetPoolStats()
+	return err
+}
+
+func (r *radosOperator) Close() {
+	r.closeOnce.Do(func() {
+		r.ioctx.Destroy()
+		r.conn.Shutdown()
+	})
+}
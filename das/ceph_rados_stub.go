@@ -0,0 +1,12 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+//go:build !ceph
+
+package das
+
+import "errors"
+
+func newRadosOperator(config CephStorageServiceConfig) (CephOperator, error) {
+	return nil, errors.New("ceph-storage requires a daserver built with the ceph build tag, which links against librados")
+}
@@ -0,0 +1,135 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/pretty"
+
+	flag "github.com/spf13/pflag"
+)
+
+// CephOperator is the subset of RADOS operations used by CephStorageService, so
+// librados can be replaced in tests. Only the build with the ceph tag links
+// against librados.
+type CephOperator interface {
+	// Write replaces the whole object.
+	Write(key string, value []byte) error
+	// Read returns ErrNotFound if the object doesn't exist.
+	Read(key string) ([]byte, error)
+	Ping() error
+	Close()
+}
+
+type CephStorageServiceConfig struct {
+	Enable                 bool   `koanf:"enable"`
+	ClusterName            string `koanf:"cluster-name"`
+	User                   string `koanf:"user"`
+	ConfigFile             string `koanf:"config-file"`
+	Keyring                string `koanf:"keyring"`
+	MonHost                string `koanf:"mon-host"`
+	Pool                   string `koanf:"pool"`
+	Namespace              string `koanf:"namespace"`
+	ObjectPrefix           string `koanf:"object-prefix"`
+	SyncFromStorageService bool   `koanf:"sync-from-storage-service"`
+	SyncToStorageService   bool   `koanf:"sync-to-storage-service"`
+}
+
+var DefaultCephStorageServiceConfig = CephStorageServiceConfig{
+	ClusterName: "ceph",
+	User:        "admin",
+}
+
+func CephStorageConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultCephStorageServiceConfig.Enable, "enable storage/retrieval of sequencer batch data from a Ceph RADOS pool (requires a build with the ceph tag)")
+	f.String(prefix+".cluster-name", DefaultCephStorageServiceConfig.ClusterName, "Ceph cluster name")
+	f.String(prefix+".user", DefaultCephStorageServiceConfig.User, "Ceph client user, without the client. prefix")
+	f.String(prefix+".config-file", DefaultCephStorageServiceConfig.ConfigFile, "ceph.conf file to read (if not set, the default locations are searched)")
+	f.String(prefix+".keyring", DefaultCephStorageServiceConfig.Keyring, "keyring file containing the client user's key")
+	f.String(prefix+".mon-host", DefaultCephStorageServiceConfig.MonHost, "comma separated Ceph monitor addresses, overriding the config file")
+	f.String(prefix+".pool", DefaultCephStorageServiceConfig.Pool, "RADOS pool in which to store data")
+	f.String(prefix+".namespace", DefaultCephStorageServiceConfig.Namespace, "RADOS namespace within the pool")
+	f.String(prefix+".object-prefix", DefaultCephStorageServiceConfig.ObjectPrefix, "prefix to add to RADOS object names")
+	f.Bool(prefix+".sync-from-storage-service", DefaultCephStorageServiceConfig.SyncFromStorageService, "enable Ceph to be used as a source for regular sync storage")
+	f.Bool(prefix+".sync-to-storage-service", DefaultCephStorageServiceConfig.SyncToStorageService, "enable Ceph to be used as a sink for regular sync storage")
+}
+
+type CephStorageService struct {
+	operator     CephOperator
+	pool         string
+	namespace    string
+	objectPrefix string
+}
+
+func NewCephStorageService(config CephStorageServiceConfig) (StorageService, error) {
+	if config.Pool == "" {
+		return nil, errors.New("ceph-storage.pool must be set")
+	}
+	operator, err := newRadosOperator(config)
+	if err != nil {
+		return nil, err
+	}
+	return &CephStorageService{
+		operator:     operator,
+		pool:         config.Pool,
+		namespace:    config.Namespace,
+		objectPrefix: config.ObjectPrefix,
+	}, nil
+}
+
+func (cs *CephStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.CephStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", cs)
+
+	value, err := cs.operator.Read(cs.objectPrefix + EncodeStorageServiceKey(key))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		log.Error("das.CephStorageService.GetByHash", "err", err)
+	}
+	return value, err
+}
+
+func (cs *CephStorageService) Put(ctx context.Context, value []byte, timeout uint64) error {
+	logPut("das.CephStorageService.Store", value, timeout, cs)
+	err := cs.operator.Write(cs.objectPrefix+EncodeStorageServiceKey(dastree.Hash(value)), value)
+	if err != nil {
+		log.Error("das.CephStorageService.Store", "err", err)
+	}
+	return err
+}
+
+func (cs *CephStorageService) putKeyValue(ctx context.Context, key common.Hash, value []byte) error {
+	err := cs.operator.Write(cs.objectPrefix+EncodeStorageServiceKey(key), value)
+	if err != nil {
+		log.Error("das.CephStorageService.putKeyValue", "err", err)
+	}
+	return err
+}
+
+func (cs *CephStorageService) Sync(ctx context.Context) error {
+	return nil
+}
+
+func (cs *CephStorageService) Close(ctx context.Context) error {
+	cs.operator.Close()
+	return nil
+}
+
+func (cs *CephStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	return arbstate.KeepForever, nil
+}
+
+func (cs *CephStorageService) String() string {
+	return fmt.Sprintf("CephStorageService(%s/%s)", cs.pool, cs.namespace)
+}
+
+func (cs *CephStorageService) HealthCheck(ctx context.Context) error {
+	return cs.operator.Ping()
+}
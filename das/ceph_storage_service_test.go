@@ -0,0 +1,72 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+type mockCephOperator struct {
+	mutex   sync.Mutex
+	objects map[string][]byte
+}
+
+func (m *mockCephOperator) Write(key string, value []byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.objects[key] = append([]byte{}, value...)
+	return nil
+}
+
+func (m *mockCephOperator) Read(key string) ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	value, ok := m.objects[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return value, nil
+}
+
+func (m *mockCephOperator) Ping() error {
+	return nil
+}
+
+func (m *mockCephOperator) Close() {}
+
+func TestCephStorageService(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	operator := &mockCephOperator{objects: make(map[string][]byte)}
+	cephService := &CephStorageService{
+		operator:     operator,
+		pool:         "das",
+		objectPrefix: "batches/",
+	}
+
+	val1 := []byte("The first value")
+	key1 := dastree.Hash(val1)
+	_, err := cephService.GetByHash(ctx, key1)
+	if !errors.Is(err, ErrNotFound) {
+		Fail(t, "expected ErrNotFound", err)
+	}
+
+	Require(t, cephService.Put(ctx, val1, timeout))
+	val, err := cephService.GetByHash(ctx, key1)
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		Fail(t, val, val1)
+	}
+	if _, ok := operator.objects["batches/"+EncodeStorageServiceKey(key1)]; !ok {
+		Fail(t, "object wasn't stored under the object prefix")
+	}
+	Require(t, cephService.HealthCheck(ctx))
+}
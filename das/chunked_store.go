@@ -0,0 +1,115 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// maxChunkedStoreSize bounds how much of a message stored in chunks is
+	// buffered, over JSON-RPC or gRPC, unless store-bounds.max-payload-size
+	// sets the bound.
+	maxChunkedStoreSize = 64 << 20
+	// maxChunkedStores bounds the uploads in progress at once.
+	maxChunkedStores = 16
+	// chunkedStoreExpiry is how long an upload is kept without a new chunk.
+	chunkedStoreExpiry = time.Minute
+)
+
+var errChunkedStoreNotFound = errors.New("unknown or expired chunked store")
+
+type chunkedStore struct {
+	message     []byte
+	timeout     uint64
+	sig         []byte
+	lastUpdated time.Time
+}
+
+// chunkedStores holds the messages being uploaded in chunks until they're
+// committed, for payloads larger than a single RPC request may be.
+type chunkedStores struct {
+	mutex   sync.Mutex
+	stores  map[uint64]*chunkedStore
+	maxSize int
+}
+
+func newChunkedStores(storeBounds StoreBoundsConfig) *chunkedStores {
+	return &chunkedStores{stores: make(map[uint64]*chunkedStore), maxSize: chunkedStoreMaxSize(storeBounds)}
+}
+
+// chunkedStoreMaxSize is the most of a message stored in chunks that's
+// buffered, since a larger one would be rejected by the store bounds anyway.
+func chunkedStoreMaxSize(storeBounds StoreBoundsConfig) int {
+	if storeBounds.MaxPayloadSize > 0 {
+		return storeBounds.MaxPayloadSize
+	}
+	return maxChunkedStoreSize
+}
+
+func (s *chunkedStores) pruneLocked(now time.Time) {
+	for id, store := range s.stores {
+		if now.Sub(store.lastUpdated) > chunkedStoreExpiry {
+			delete(s.stores, id)
+		}
+	}
+}
+
+// start begins an upload and returns its id, which is random so that other
+// clients can't append to it.
+func (s *chunkedStores) start(timeout uint64, sig []byte) (uint64, error) {
+	var idBytes [8]byte
+	if _, err := rand.Read(idBytes[:]); err != nil {
+		return 0, err
+	}
+	id := binary.BigEndian.Uint64(idBytes[:])
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	s.pruneLocked(now)
+	if len(s.stores) >= maxChunkedStores {
+		return 0, fmt.Errorf("too many chunked stores in progress, the maximum is %d", maxChunkedStores)
+	}
+	s.stores[id] = &chunkedStore{timeout: timeout, sig: sig, lastUpdated: now}
+	return id, nil
+}
+
+// appendChunk adds the chunk at offset, which must be where the previous
+// chunk ended so that a chunk sent twice isn't stored twice.
+func (s *chunkedStores) appendChunk(id uint64, offset uint64, chunk []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	store, ok := s.stores[id]
+	if !ok {
+		return errChunkedStoreNotFound
+	}
+	if offset != uint64(len(store.message)) {
+		return fmt.Errorf("chunk at offset %d doesn't follow the %d bytes received", offset, len(store.message))
+	}
+	if len(store.message)+len(chunk) > s.maxSize {
+		delete(s.stores, id)
+		return &PayloadTooLargeError{Size: len(store.message) + len(chunk), MaxSize: s.maxSize}
+	}
+	store.message = append(store.message, chunk...)
+	store.lastUpdated = time.Now()
+	return nil
+}
+
+// finish ends the upload, returning what was received.
+func (s *chunkedStores) finish(id uint64) (*chunkedStore, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	store, ok := s.stores[id]
+	if !ok {
+		return nil, errChunkedStoreNotFound
+	}
+	delete(s.stores, id)
+	return store, nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbstate"
+)
+
+var clientCertStoreRejectedCounter = metrics.NewRegisteredCounter("arb/das/rpc/store/clientcert/rejected", nil)
+
+var ErrClientCertRequired = errors.New("storing requires a client certificate signed by a trusted CA")
+
+type verifiedClientCertKey struct{}
+
+// withVerifiedClientCert records in the request's context whether the client
+// presented a certificate that the TLS handshake verified.
+func withVerifiedClientCert(r *http.Request) *http.Request {
+	return r.WithContext(contextWithVerifiedClientCert(r.Context(), r.TLS))
+}
+
+func contextWithVerifiedClientCert(ctx context.Context, state *tls.ConnectionState) context.Context {
+	if state == nil || len(state.VerifiedChains) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, verifiedClientCertKey{}, true)
+}
+
+func hasVerifiedClientCert(ctx context.Context) bool {
+	verified, _ := ctx.Value(verifiedClientCertKey{}).(bool)
+	return verified
+}
+
+// ClientCertStoreAuthenticator only lets clients with a verified TLS client
+// certificate store data through the writer it wraps. Retrieval doesn't need
+// a certificate, so the server asks for one without requiring it.
+type ClientCertStoreAuthenticator struct {
+	DataAvailabilityServiceWriter
+}
+
+func NewClientCertStoreAuthenticator(writer DataAvailabilityServiceWriter) *ClientCertStoreAuthenticator {
+	return &ClientCertStoreAuthenticator{DataAvailabilityServiceWriter: writer}
+}
+
+func (a *ClientCertStoreAuthenticator) AuthorizeStore(ctx context.Context) error {
+	if !hasVerifiedClientCert(ctx) && !isQueuedStore(ctx) {
+		clientCertStoreRejectedCounter.Inc(1)
+		return ErrClientCertRequired
+	}
+	return authorizeStore(ctx, a.DataAvailabilityServiceWriter)
+}
+
+func (a *ClientCertStoreAuthenticator) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	if err := a.AuthorizeStore(ctx); err != nil {
+		return nil, err
+	}
+	return a.DataAvailabilityServiceWriter.Store(ctx, message, timeout, sig)
+}
+
+func (a *ClientCertStoreAuthenticator) String() string {
+	return fmt.Sprintf("ClientCertStoreAuthenticator{%v}", a.DataAvailabilityServiceWriter)
+}
+
+// WithClientCAs returns a copy of tlsConfig that verifies the certificates
+// clients present against the CAs in caFile, without requiring one.
+func WithClientCAs(tlsConfig *tls.Config, caFile string) (*tls.Config, error) {
+	caCerts, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCerts) {
+		return nil, fmt.Errorf("no PEM encoded certificates found in %s", caFile)
+	}
+	tlsConfig = tlsConfig.Clone()
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	return tlsConfig, nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+// issueTestCert issues a certificate signed by issuer, or a self-signed CA
+// certificate if issuer is nil.
+func issueTestCert(t *testing.T, dir string, name string, issuer *testCert, extKeyUsage x509.ExtKeyUsage) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	testhelpers.RequireImpl(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	testhelpers.RequireImpl(t, err)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{extKeyUsage},
+	}
+	parent, signer := template, key
+	if issuer == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		template.ExtKeyUsage = nil
+	} else {
+		template.DNSNames = []string{"localhost"}
+		template.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1)}
+		parent, signer = issuer.cert, issuer.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	testhelpers.RequireImpl(t, err)
+	cert, err := x509.ParseCertificate(der)
+	testhelpers.RequireImpl(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	testhelpers.RequireImpl(t, err)
+
+	c := &testCert{
+		cert:     cert,
+		key:      key,
+		certFile: filepath.Join(dir, name+".crt"),
+		keyFile:  filepath.Join(dir, name+".key"),
+	}
+	testhelpers.RequireImpl(t, os.WriteFile(c.certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	testhelpers.RequireImpl(t, os.WriteFile(c.keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return c
+}
+
+func TestRPCStoreClientCert(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	certDir := t.TempDir()
+	ca := issueTestCert(t, certDir, "ca", nil, 0)
+	serverCert := issueTestCert(t, certDir, "server", ca, x509.ExtKeyUsageServerAuth)
+	clientCert := issueTestCert(t, certDir, "client", ca, x509.ExtKeyUsageClientAuth)
+	otherCA := issueTestCert(t, certDir, "other-ca", nil, 0)
+	otherClientCert := issueTestCert(t, certDir, "other-client", otherCA, x509.ExtKeyUsageClientAuth)
+
+	keyPair, err := tls.LoadX509KeyPair(serverCert.certFile, serverCert.keyFile)
+	testhelpers.RequireImpl(t, err)
+	tlsConfig, err := WithClientCAs(&tls.Config{Certificates: []tls.Certificate{keyPair}, MinVersion: tls.VersionTLS12}, ca.certFile)
+	testhelpers.RequireImpl(t, err)
+	lis, err := genericconf.TLSListen("localhost:0", tlsConfig)
+	testhelpers.RequireImpl(t, err)
+
+	storageService := NewMemoryBackedStorageService(ctx)
+	keyDir := t.TempDir()
+	_, _, err = GenerateAndStoreKeys(keyDir)
+	testhelpers.RequireImpl(t, err)
+	privKey, err := (&KeyConfig{KeyDir: keyDir}).BLSPrivKey()
+	testhelpers.RequireImpl(t, err)
+	localDas, err := NewSignAfterStoreDASWriterWithSeqInboxCaller(privKey, nil, storageService, "")
+	testhelpers.RequireImpl(t, err)
+	dasServer, err := StartDASRPCServerOnListener(ctx, lis, genericconf.HTTPServerTimeoutConfigDefault, nil, DefaultStoreBoundsConfig, storageService, NewClientCertStoreAuthenticator(localDas), storageService)
+	testhelpers.RequireImpl(t, err)
+	defer func() {
+		testhelpers.RequireImpl(t, dasServer.Shutdown(ctx))
+	}()
+
+	dial := func(cert *testCert) *DASRPCClient {
+		t.Helper()
+		backend := BackendConfig{URL: "https://" + lis.Addr().String(), RootCA: ca.certFile}
+		if cert != nil {
+			backend.ClientCert = cert.certFile
+			backend.ClientKey = cert.keyFile
+		}
+		options, err := backend.dialOptions()
+		testhelpers.RequireImpl(t, err)
+		client, err := NewDASRPCClientWithOptions(ctx, backend.URL, options...)
+		testhelpers.RequireImpl(t, err)
+		return client
+	}
+
+	msg := testhelpers.RandomizeSlice(make([]byte, 100))
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	if _, err := dial(nil).Store(ctx, msg, timeout, nil); err == nil || !strings.Contains(err.Error(), ErrClientCertRequired.Error()) {
+		testhelpers.FailImpl(t, "expected store without a client certificate to be rejected", err)
+	}
+	if _, err := dial(otherClientCert).Store(ctx, msg, timeout, nil); err == nil {
+		testhelpers.FailImpl(t, "expected store with a certificate from another CA to be rejected")
+	}
+	cert, err := dial(clientCert).Store(ctx, msg, timeout, nil)
+	testhelpers.RequireImpl(t, err)
+	if cert.DataHash != dastree.Hash(msg) {
+		testhelpers.FailImpl(t, "unexpected certificate", cert)
+	}
+
+	// Retrieval doesn't need a client certificate.
+	retrieved, err := dial(nil).RetrieveBatch(ctx, []common.Hash{cert.DataHash})
+	testhelpers.RequireImpl(t, err)
+	if len(retrieved) != 1 || retrieved[0] == nil {
+		testhelpers.FailImpl(t, "failed to retrieve stored message")
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/util/headerreader"
+)
+
+type CommitteeRegistryConfig struct {
+	Enable                 bool          `koanf:"enable"`
+	Address                string        `koanf:"address"`
+	RefreshInterval        time.Duration `koanf:"refresh-interval"`
+	RequireSignedEndpoints bool          `koanf:"require-signed-endpoints"`
+}
+
+var DefaultCommitteeRegistryConfig = CommitteeRegistryConfig{
+	Enable:                 false,
+	Address:                "",
+	RefreshInterval:        10 * time.Minute,
+	RequireSignedEndpoints: false,
+}
+
+func CommitteeRegistryConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultCommitteeRegistryConfig.Enable, "enable discovering the committee members' RPC and REST URLs from a registry contract on the parent chain, replacing the rpc-aggregator backends and adding to the rest-aggregator URLs")
+	f.String(prefix+".address", DefaultCommitteeRegistryConfig.Address, "parent chain address of the committee registry contract")
+	f.Duration(prefix+".refresh-interval", DefaultCommitteeRegistryConfig.RefreshInterval, "interval between reads of the committee registry contract")
+	f.Bool(prefix+".require-signed-endpoints", DefaultCommitteeRegistryConfig.RequireSignedEndpoints, "only use a member's registered URLs if the member announces them, signed with its BLS key, at its REST URL")
+}
+
+// committeeRegistryABI is the interface of the registry contract. getMembers
+// lists the members' BLS public keys, serialized as by
+// blsSignatures.PublicKeyToBytes, in keyset order, and getEndpoints returns
+// the URLs a member has registered for its public key.
+const committeeRegistryABI = `[
+	{"type":"function","name":"getMembers","stateMutability":"view","inputs":[],"outputs":[{"name":"pubKeys","type":"bytes[]"}]},
+	{"type":"function","name":"getEndpoints","stateMutability":"view","inputs":[{"name":"pubKey","type":"bytes"}],"outputs":[{"name":"rpcUrl","type":"string"},{"name":"restUrl","type":"string"}]}
+]`
+
+// CommitteeMember is a committee member as listed in the registry.
+type CommitteeMember struct {
+	PubKey  []byte
+	RPCURL  string
+	RESTURL string
+}
+
+// CommitteeRegistry reads the committee members from a registry contract.
+type CommitteeRegistry struct {
+	address  common.Address
+	contract *bind.BoundContract
+
+	// If set, Members drops the URLs of members that don't announce them.
+	requireSignedEndpoints bool
+}
+
+func NewCommitteeRegistry(address common.Address, caller bind.ContractCaller) (*CommitteeRegistry, error) {
+	parsed, err := abi.JSON(strings.NewReader(committeeRegistryABI))
+	if err != nil {
+		return nil, err
+	}
+	return &CommitteeRegistry{
+		address:  address,
+		contract: bind.NewBoundContract(address, parsed, caller, nil, nil),
+	}, nil
+}
+
+// NewCommitteeRegistryFromConfig returns nil if the registry isn't enabled.
+func NewCommitteeRegistryFromConfig(config CommitteeRegistryConfig, caller bind.ContractCaller) (*CommitteeRegistry, error) {
+	if !config.Enable {
+		return nil, nil
+	}
+	if caller == nil {
+		return nil, errors.New("a parent chain connection must be configured along with committee-registry")
+	}
+	if !common.IsHexAddress(config.Address) {
+		return nil, fmt.Errorf("invalid committee-registry.address %q", config.Address)
+	}
+	registry, err := NewCommitteeRegistry(common.HexToAddress(config.Address), caller)
+	if err != nil {
+		return nil, err
+	}
+	registry.requireSignedEndpoints = config.RequireSignedEndpoints
+	return registry, nil
+}
+
+// newRestfulClientAggregatorFromConfig creates the REST aggregator, reading
+// URLs from the committee registry too if it is enabled.
+func newRestfulClientAggregatorFromConfig(ctx context.Context, config *DataAvailabilityConfig, l1Client bind.ContractCaller) (*SimpleDASReaderAggregator, error) {
+	registry, err := NewCommitteeRegistryFromConfig(config.CommitteeRegistry, l1Client)
+	if err != nil {
+		return nil, err
+	}
+	return NewRestfulClientAggregatorWithRegistry(ctx, &config.RestAggregator, registry, config.CommitteeRegistry.RefreshInterval)
+}
+
+func headerReaderClient(l1Reader *headerreader.HeaderReader) bind.ContractCaller {
+	if l1Reader == nil {
+		return nil
+	}
+	return l1Reader.Client()
+}
+
+// Members reads the committee members in keyset order, with their URLs.
+func (r *CommitteeRegistry) Members(ctx context.Context) ([]CommitteeMember, error) {
+	opts := &bind.CallOpts{Context: ctx}
+	var out []interface{}
+	if err := r.contract.Call(opts, &out, "getMembers"); err != nil {
+		return nil, fmt.Errorf("error reading members from committee registry %v: %w", r.address, err)
+	}
+	pubKeys := *abi.ConvertType(out[0], new([][]byte)).(*[][]byte)
+	members := make([]CommitteeMember, 0, len(pubKeys))
+	for _, pubKey := range pubKeys {
+		out = nil
+		if err := r.contract.Call(opts, &out, "getEndpoints", pubKey); err != nil {
+			return nil, fmt.Errorf("error reading endpoints from committee registry %v: %w", r.address, err)
+		}
+		member := CommitteeMember{
+			PubKey:  pubKey,
+			RPCURL:  *abi.ConvertType(out[0], new(string)).(*string),
+			RESTURL: *abi.ConvertType(out[1], new(string)).(*string),
+		}
+		if r.requireSignedEndpoints {
+			if err := verifyMemberEndpoints(ctx, member); err != nil {
+				log.Warn("Ignoring committee member's registered URLs, as it doesn't announce them", "rpcUrl", member.RPCURL, "restUrl", member.RESTURL, "err", err)
+				member.RPCURL = ""
+				member.RESTURL = ""
+			}
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// RESTURLs reads the REST URLs the committee members have registered.
+func (r *CommitteeRegistry) RESTURLs(ctx context.Context) ([]string, error) {
+	members, err := r.Members(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var urls []string
+	for _, member := range members {
+		if member.RESTURL != "" {
+			urls = append(urls, member.RESTURL)
+		}
+	}
+	return urls, nil
+}
+
+// registryBackendsJSON builds the RPC backend configuration for the members,
+// with signersMasks assigned in registry order. Store policies and
+// credentials are taken from the configured backend with the same public
+// key, if there is one.
+func registryBackendsJSON(members []CommitteeMember, configured string) (string, error) {
+	configuredByPubKey := make(map[string]BackendConfig)
+	if configured != "" {
+		var cs []BackendConfig
+		if err := json.Unmarshal([]byte(configured), &cs); err != nil {
+			return "", err
+		}
+		for _, b := range cs {
+			configuredByPubKey[b.PubKeyBase64Encoded] = b
+		}
+	}
+	if len(members) > 64 {
+		return "", fmt.Errorf("committee registry lists %d members, more than the max of 64", len(members))
+	}
+	backends := make([]BackendConfig, 0, len(members))
+	for i, member := range members {
+		if member.RPCURL == "" {
+			return "", fmt.Errorf("committee member %d hasn't registered an RPC URL", i)
+		}
+		if _, err := blsSignatures.PublicKeyFromBytes(member.PubKey, false); err != nil {
+			return "", fmt.Errorf("committee member %d has an invalid public key: %w", i, err)
+		}
+		pubKey := base64.StdEncoding.EncodeToString(member.PubKey)
+		b := configuredByPubKey[pubKey]
+		b.URL = member.RPCURL
+		b.PubKeyBase64Encoded = pubKey
+		b.SignerMask = 1 << i
+		backends = append(backends, b)
+	}
+	encoded, err := json.Marshal(backends)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// StartCommitteeRegistryURLFetchDaemon sends the REST URLs read from the
+// registry immediately and then periodically, like
+// StartRestfulServerListFetchDaemon does for the online URL list.
+func StartCommitteeRegistryURLFetchDaemon(ctx context.Context, registry *CommitteeRegistry, updatePeriod time.Duration) <-chan []string {
+	updateChan := make(chan []string)
+	if registry == nil {
+		return updateChan
+	}
+	go func() {
+		defer close(updateChan)
+		ticker := time.NewTicker(updatePeriod)
+		defer ticker.Stop()
+		for {
+			urls, err := registry.RESTURLs(ctx)
+			if err != nil {
+				log.Warn(fmt.Sprintf("Couldn't read REST URLs from the committee registry, will retry in %s", updatePeriod), "err", err)
+			} else {
+				select {
+				case updateChan <- urls:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return updateChan
+}
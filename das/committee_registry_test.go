@@ -0,0 +1,55 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/offchainlabs/nitro/blsSignatures"
+)
+
+func TestRegistryBackendsJSON(t *testing.T) {
+	var members []CommitteeMember
+	for i := 0; i < 3; i++ {
+		pubKey, _, err := blsSignatures.GenerateKeys()
+		Require(t, err)
+		members = append(members, CommitteeMember{
+			PubKey:  blsSignatures.PublicKeyToBytes(pubKey),
+			RPCURL:  fmt.Sprintf("http://member%d:9876", i),
+			RESTURL: fmt.Sprintf("http://member%d:9877", i),
+		})
+	}
+	configured, err := json.Marshal([]BackendConfig{{
+		URL:                 "http://old-url:9876",
+		PubKeyBase64Encoded: base64.StdEncoding.EncodeToString(members[1].PubKey),
+		SignerMask:          1,
+		Timeout:             "3s",
+		Retries:             2,
+	}})
+	Require(t, err)
+
+	backendsJSON, err := registryBackendsJSON(members, string(configured))
+	Require(t, err)
+	var backends []BackendConfig
+	Require(t, json.Unmarshal([]byte(backendsJSON), &backends))
+	if len(backends) != len(members) {
+		Fail(t, "expected a backend per member, got", backends)
+	}
+	for i, b := range backends {
+		if b.URL != members[i].RPCURL || b.SignerMask != 1<<i || b.PubKeyBase64Encoded != base64.StdEncoding.EncodeToString(members[i].PubKey) {
+			Fail(t, "backend doesn't match registry member", i, b)
+		}
+	}
+	if backends[1].Timeout != "3s" || backends[1].Retries != 2 || backends[0].Timeout != "" {
+		Fail(t, "policy should only be taken from the configured backend with the same public key", backends)
+	}
+
+	members[2].RPCURL = ""
+	if _, err := registryBackendsJSON(members, ""); err == nil {
+		Fail(t, "member without an RPC URL should be rejected")
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/pretty"
+
+	flag "github.com/spf13/pflag"
+)
+
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+type CompressionConfig struct {
+	Enable bool   `koanf:"enable"`
+	Level  string `koanf:"level"`
+}
+
+var DefaultCompressionConfig = CompressionConfig{
+	Level: "default",
+}
+
+func CompressionConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultCompressionConfig.Enable, "zstd compress data before storing it in storage backends that support it; compressed data is stored without an expiry timeout")
+	f.String(prefix+".level", DefaultCompressionConfig.Level, "zstd compression level (fastest, default, better or best)")
+}
+
+// CompressedStorageService zstd compresses data before storing it in the
+// wrapped StorageService, under the hash of the uncompressed data. Data that
+// doesn't get smaller is stored as is, and so is anything stored before
+// compression was enabled, so reads accept both.
+type CompressedStorageService struct {
+	IterationCompatibleStorageService
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+func NewCompressedStorageService(storageService StorageService, config CompressionConfig) (*CompressedStorageService, error) {
+	inner, ok := storageService.(IterationCompatibleStorageService)
+	if !ok {
+		return nil, fmt.Errorf("%v doesn't support storing data under an arbitrary key, so can't be compressed", storageService)
+	}
+	if _, ok := storageService.(*IterableStorageService); ok {
+		return nil, fmt.Errorf("%v is a source for regular sync storage, so can't be compressed", storageService)
+	}
+	ok, level := zstd.EncoderLevelFromString(config.Level)
+	if !ok {
+		return nil, fmt.Errorf("invalid compression.level %s", config.Level)
+	}
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(level))
+	if err != nil {
+		return nil, err
+	}
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderMaxMemory(1<<30))
+	if err != nil {
+		return nil, err
+	}
+	return &CompressedStorageService{
+		IterationCompatibleStorageService: inner,
+		encoder:                           encoder,
+		decoder:                           decoder,
+	}, nil
+}
+
+func (c *CompressedStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.CompressedStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", c)
+	data, err := c.IterationCompatibleStorageService.GetByHash(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, zstdMagic) {
+		decompressed, err := c.decoder.DecodeAll(data, nil)
+		if err == nil && dastree.ValidHash(key, decompressed) {
+			return decompressed, nil
+		}
+	}
+	if !dastree.ValidHash(key, data) {
+		return nil, fmt.Errorf("data stored under %v doesn't match its hash", key)
+	}
+	return data, nil
+}
+
+func (c *CompressedStorageService) Put(ctx context.Context, data []byte, timeout uint64) error {
+	logPut("das.CompressedStorageService.Store", data, timeout, c)
+	compressed := c.encoder.EncodeAll(data, nil)
+	if len(compressed) >= len(data) {
+		return c.IterationCompatibleStorageService.Put(ctx, data, timeout)
+	}
+	return c.IterationCompatibleStorageService.putKeyValue(ctx, dastree.Hash(data), compressed)
+}
+
+func (c *CompressedStorageService) putKeyValue(ctx context.Context, key common.Hash, value []byte) error {
+	compressed := c.encoder.EncodeAll(value, nil)
+	if len(compressed) >= len(value) {
+		compressed = value
+	}
+	return c.IterationCompatibleStorageService.putKeyValue(ctx, key, compressed)
+}
+
+func (c *CompressedStorageService) Close(ctx context.Context) error {
+	c.decoder.Close()
+	return c.IterationCompatibleStorageService.Close(ctx)
+}
+
+func (c *CompressedStorageService) String() string {
+	return fmt.Sprintf("CompressedStorageService(%v)", c.IterationCompatibleStorageService)
+}
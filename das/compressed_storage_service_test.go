@@ -0,0 +1,48 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+func TestCompressedStorageService(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	inner := NewMemoryBackedStorageService(ctx)
+	compressedService, err := NewCompressedStorageService(inner, DefaultCompressionConfig)
+	Require(t, err)
+
+	compressible := bytes.Repeat([]byte("calldata"), 1000)
+	key := dastree.Hash(compressible)
+	Require(t, compressedService.Put(ctx, compressible, timeout))
+	stored, err := inner.GetByHash(ctx, key)
+	Require(t, err)
+	if len(stored) >= len(compressible) {
+		Fail(t, "data wasn't compressed", len(stored))
+	}
+	val, err := compressedService.GetByHash(ctx, key)
+	Require(t, err)
+	if !bytes.Equal(val, compressible) {
+		Fail(t, "decompressed data doesn't match")
+	}
+
+	// Data stored before compression was enabled can still be read.
+	uncompressed := []byte("stored without compression")
+	Require(t, inner.Put(ctx, uncompressed, timeout))
+	val, err = compressedService.GetByHash(ctx, dastree.Hash(uncompressed))
+	Require(t, err)
+	if !bytes.Equal(val, uncompressed) {
+		Fail(t, val, uncompressed)
+	}
+
+	if _, err := NewCompressedStorageService(inner, CompressionConfig{Level: "maximum"}); err == nil {
+		Fail(t, "expected error for invalid compression level")
+	}
+}
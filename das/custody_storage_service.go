@@ -0,0 +1,273 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+type CustodyChallengeConfig struct {
+	Enable          bool          `koanf:"enable"`
+	Peers           []string      `koanf:"peers"`
+	Interval        time.Duration `koanf:"interval"`
+	ResponseTimeout time.Duration `koanf:"response-timeout"`
+	MaxSliceLength  uint64        `koanf:"max-slice-length"`
+	MaxKeys         int           `koanf:"max-keys"`
+}
+
+var DefaultCustodyChallengeConfig = CustodyChallengeConfig{
+	Enable:          false,
+	Peers:           []string{},
+	Interval:        10 * time.Minute,
+	ResponseTimeout: 2 * time.Second,
+	MaxSliceLength:  4096,
+	MaxKeys:         10000,
+}
+
+func CustodyChallengeConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultCustodyChallengeConfig.Enable, "enable periodically challenging other committee members over REST to prove they hold data this DAS stored, and answering their challenges from local storage only")
+	f.StringSlice(prefix+".peers", DefaultCustodyChallengeConfig.Peers, "list of REST URLs of other committee members to challenge, including 'http://' or 'https://' prefixes and port numbers (defaults to rest-aggregator.urls)")
+	f.Duration(prefix+".interval", DefaultCustodyChallengeConfig.Interval, "interval between challenges to each peer")
+	f.Duration(prefix+".response-timeout", DefaultCustodyChallengeConfig.ResponseTimeout, "time a peer has to answer a challenge before it is counted as failed; a peer fetching the data from elsewhere to answer is unlikely to answer in time")
+	f.Uint64(prefix+".max-slice-length", DefaultCustodyChallengeConfig.MaxSliceLength, "max length in bytes of the slice of data a challenge asks a peer to hash")
+	f.Int(prefix+".max-keys", DefaultCustodyChallengeConfig.MaxKeys, "max number of recently stored keys kept to pick challenges from")
+}
+
+var (
+	custodyPassedCounter    = metrics.NewRegisteredCounter("arb/das/custody/passed", nil)
+	custodyFailedCounter    = metrics.NewRegisteredCounter("arb/das/custody/failed", nil)
+	custodyTimeoutCounter   = metrics.NewRegisteredCounter("arb/das/custody/timeout", nil)
+	custodyMissingCounter   = metrics.NewRegisteredCounter("arb/das/custody/missing", nil)
+	custodyUnreachedCounter = metrics.NewRegisteredCounter("arb/das/custody/unreached", nil)
+	custodyAnsweredCounter  = metrics.NewRegisteredCounter("arb/das/custody/answered", nil)
+	custodyLatencyHistogram = metrics.NewRegisteredHistogram("arb/das/custody/latency", nil, metrics.NewBoundedHistogramSample())
+)
+
+// CustodyChallenge asks a DAS to hash the slice data[Offset:Offset+Length]
+// of the data with the given hash, keyed with Nonce so the answer can't be
+// precomputed.
+type CustodyChallenge struct {
+	Hash   common.Hash
+	Nonce  common.Hash
+	Offset uint64
+	Length uint64
+}
+
+// CustodyProof is the answer to challenge for data. The slice is truncated
+// to the end of the data.
+func CustodyProof(challenge CustodyChallenge, data []byte) common.Hash {
+	start := challenge.Offset
+	if start > uint64(len(data)) {
+		start = uint64(len(data))
+	}
+	end := start + challenge.Length
+	if end > uint64(len(data)) || end < start {
+		end = uint64(len(data))
+	}
+	return crypto.Keccak256Hash(challenge.Nonce[:], data[start:end])
+}
+
+// CustodyProver is implemented by readers that answer custody challenges.
+type CustodyProver interface {
+	ProveCustody(ctx context.Context, challenge CustodyChallenge) (common.Hash, error)
+}
+
+type custodyKey struct {
+	hash           common.Hash
+	expirationTime uint64
+}
+
+type custodyPeer struct {
+	client *RestfulDasClient
+}
+
+// CustodyStorageService records the keys of data Put to it, and periodically
+// challenges each peer to prove it holds the data for one of them. It answers
+// peers' challenges from the local storage only, never from the REST
+// aggregator fallback, so a member that only proxies data it doesn't hold
+// fails them.
+type CustodyStorageService struct {
+	StorageService
+	stopwaiter.StopWaiter
+
+	config       CustodyChallengeConfig
+	localStorage StorageService
+	peers        []*custodyPeer
+
+	keysMutex sync.Mutex
+	keys      []custodyKey
+}
+
+func NewCustodyStorageService(config CustodyChallengeConfig, storageService StorageService, localStorage StorageService, peerURLs []string) (*CustodyStorageService, error) {
+	if len(peerURLs) == 0 {
+		return nil, errors.New("at least one peer must be configured for custody challenges, with custody-challenge.peers or rest-aggregator.urls")
+	}
+	if config.MaxSliceLength == 0 {
+		return nil, errors.New("custody-challenge.max-slice-length must be positive")
+	}
+	var peers []*custodyPeer
+	for _, url := range peerURLs {
+		client, err := NewRestfulDasClientFromURL(url)
+		if err != nil {
+			return nil, err
+		}
+		peers = append(peers, &custodyPeer{client: client})
+	}
+	return &CustodyStorageService{
+		StorageService: storageService,
+		config:         config,
+		localStorage:   localStorage,
+		peers:          peers,
+	}, nil
+}
+
+func (c *CustodyStorageService) Start(ctx context.Context) {
+	c.StopWaiter.Start(ctx, c)
+	c.CallIteratively(c.challengePeers)
+}
+
+func (c *CustodyStorageService) Put(ctx context.Context, data []byte, expirationTime uint64) error {
+	if err := c.StorageService.Put(ctx, data, expirationTime); err != nil {
+		return err
+	}
+	c.keysMutex.Lock()
+	defer c.keysMutex.Unlock()
+	c.keys = append(c.keys, custodyKey{hash: dastree.Hash(data), expirationTime: expirationTime})
+	if len(c.keys) > c.config.MaxKeys {
+		c.keys = append([]custodyKey(nil), c.keys[len(c.keys)-c.config.MaxKeys:]...)
+	}
+	return nil
+}
+
+func (c *CustodyStorageService) ProveCustody(ctx context.Context, challenge CustodyChallenge) (common.Hash, error) {
+	if challenge.Length > c.config.MaxSliceLength {
+		return common.Hash{}, fmt.Errorf("challenge slice length %d is more than the max of %d", challenge.Length, c.config.MaxSliceLength)
+	}
+	data, err := c.localStorage.GetByHash(ctx, challenge.Hash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	custodyAnsweredCounter.Inc(1)
+	return CustodyProof(challenge, data), nil
+}
+
+// randomKey picks one of the recorded keys that hasn't expired, dropping
+// expired ones.
+func (c *CustodyStorageService) randomKey() (common.Hash, bool, error) {
+	c.keysMutex.Lock()
+	defer c.keysMutex.Unlock()
+	now := uint64(time.Now().Unix())
+	unexpired := c.keys[:0]
+	for _, key := range c.keys {
+		if key.expirationTime > now {
+			unexpired = append(unexpired, key)
+		}
+	}
+	c.keys = unexpired
+	if len(c.keys) == 0 {
+		return common.Hash{}, false, nil
+	}
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(len(c.keys))))
+	if err != nil {
+		return common.Hash{}, false, err
+	}
+	return c.keys[i.Int64()].hash, true, nil
+}
+
+func (c *CustodyStorageService) newChallenge(ctx context.Context) (CustodyChallenge, []byte, bool, error) {
+	hash, ok, err := c.randomKey()
+	if err != nil || !ok {
+		return CustodyChallenge{}, nil, false, err
+	}
+	data, err := c.localStorage.GetByHash(ctx, hash)
+	if err != nil {
+		return CustodyChallenge{}, nil, false, err
+	}
+	challenge := CustodyChallenge{Hash: hash}
+	if _, err := rand.Read(challenge.Nonce[:]); err != nil {
+		return CustodyChallenge{}, nil, false, err
+	}
+	challenge.Length = c.config.MaxSliceLength
+	if uint64(len(data)) < challenge.Length {
+		challenge.Length = uint64(len(data))
+	}
+	offset, err := rand.Int(rand.Reader, new(big.Int).SetUint64(uint64(len(data))-challenge.Length+1))
+	if err != nil {
+		return CustodyChallenge{}, nil, false, err
+	}
+	challenge.Offset = offset.Uint64()
+	return challenge, data, true, nil
+}
+
+func (c *CustodyStorageService) challengePeers(ctx context.Context) time.Duration {
+	for _, peer := range c.peers {
+		if err := c.challenge(ctx, peer); err != nil && ctx.Err() == nil {
+			log.Warn("das.CustodyStorageService: peer failed custody challenge", "peer", peer.client.url, "err", err)
+		}
+	}
+	return c.config.Interval
+}
+
+// challenge sends a new challenge for a random recorded key to peer, and
+// checks its answer against the proof computed from the local data.
+func (c *CustodyStorageService) challenge(ctx context.Context, peer *custodyPeer) error {
+	challenge, data, ok, err := c.newChallenge(ctx)
+	if err != nil {
+		return fmt.Errorf("couldn't create challenge: %w", err)
+	}
+	if !ok {
+		return nil
+	}
+	challengeCtx, cancel := context.WithTimeout(ctx, c.config.ResponseTimeout)
+	defer cancel()
+	start := time.Now()
+	proof, err := peer.client.ProveCustody(challengeCtx, challenge)
+	custodyLatencyHistogram.Update(time.Since(start).Nanoseconds())
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(challengeCtx.Err(), context.DeadlineExceeded) {
+			custodyTimeoutCounter.Inc(1)
+			return fmt.Errorf("no answer to challenge for %s within %v", challenge.Hash, c.config.ResponseTimeout)
+		}
+		if errors.Is(err, ErrNotFound) {
+			custodyMissingCounter.Inc(1)
+			return fmt.Errorf("peer doesn't hold %s: %w", challenge.Hash, err)
+		}
+		custodyUnreachedCounter.Inc(1)
+		return err
+	}
+	if proof != CustodyProof(challenge, data) {
+		custodyFailedCounter.Inc(1)
+		return fmt.Errorf("wrong answer to challenge for %s", challenge.Hash)
+	}
+	custodyPassedCounter.Inc(1)
+	return nil
+}
+
+func (c *CustodyStorageService) Close(ctx context.Context) error {
+	c.StopOnly()
+	return c.StorageService.Close(ctx)
+}
+
+func (c *CustodyStorageService) String() string {
+	return "CustodyStorageService(" + c.StorageService.String() + ")"
+}
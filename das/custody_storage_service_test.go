@@ -0,0 +1,75 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCustodyChallenge(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := DefaultCustodyChallengeConfig
+	config.Enable = true
+	config.MaxSliceLength = 8
+
+	data := []byte("data both committee members were asked to store")
+	expirationTime := uint64(time.Now().Add(time.Hour).Unix())
+
+	holderStorage := NewMemoryBackedStorageService(ctx)
+	holder, err := NewCustodyStorageService(config, holderStorage, holderStorage, []string{"http://localhost:1"})
+	Require(t, err)
+	Require(t, holder.Put(ctx, data, expirationTime))
+	holderServer, holderPort, err := NewRestfulDasServerOnRandomPort(LocalServerAddressForTest, holder)
+	Require(t, err)
+	defer func() {
+		Require(t, holderServer.Shutdown())
+	}()
+
+	// The proxy can serve the data through its fallback, but doesn't hold it.
+	proxyStorage := NewMemoryBackedStorageService(ctx)
+	proxy, err := NewCustodyStorageService(config, NewFallbackStorageService(proxyStorage, holder, holder, 3600, true, false), proxyStorage, []string{"http://localhost:1"})
+	Require(t, err)
+	proxyServer, proxyPort, err := NewRestfulDasServerOnRandomPort(LocalServerAddressForTest, proxy)
+	Require(t, err)
+	defer func() {
+		Require(t, proxyServer.Shutdown())
+	}()
+
+	challengerStorage := NewMemoryBackedStorageService(ctx)
+	challenger, err := NewCustodyStorageService(config, challengerStorage, challengerStorage, []string{
+		fmt.Sprintf("http://%s:%d", LocalServerAddressForTest, holderPort),
+		fmt.Sprintf("http://%s:%d", LocalServerAddressForTest, proxyPort),
+	})
+	Require(t, err)
+	Require(t, challenger.Put(ctx, data, expirationTime))
+
+	for i := 0; i < 5; i++ {
+		Require(t, challenger.challenge(ctx, challenger.peers[0]))
+		err = challenger.challenge(ctx, challenger.peers[1])
+		if !errors.Is(err, ErrNotFound) {
+			Fail(t, "proxy without the data should fail the challenge with not found, got", err)
+		}
+	}
+}
+
+func TestCustodyProofSlices(t *testing.T) {
+	data := []byte("0123456789")
+	challenge := CustodyChallenge{Offset: 4, Length: 3}
+	if CustodyProof(challenge, data) != CustodyProof(challenge, []byte("xxxx456xxxx")) {
+		Fail(t, "proof should only depend on the challenged slice")
+	}
+	challenge.Nonce[0] = 1
+	if CustodyProof(challenge, data) == CustodyProof(CustodyChallenge{Offset: 4, Length: 3}, data) {
+		Fail(t, "proof should depend on the nonce")
+	}
+	// Slices past the end of the data are truncated rather than panicking.
+	CustodyProof(CustodyChallenge{Offset: 8, Length: 100}, data)
+	CustodyProof(CustodyChallenge{Offset: 100, Length: ^uint64(0)}, data)
+}
@@ -38,21 +38,55 @@ type DataAvailabilityServiceHealthChecker interface {
 type DataAvailabilityConfig struct {
 	Enable bool `koanf:"enable"`
 
-	RequestTimeout time.Duration `koanf:"request-timeout"`
-
-	LocalCache BigCacheConfig `koanf:"local-cache"`
-	RedisCache RedisConfig    `koanf:"redis-cache"`
-
-	LocalDBStorage     LocalDBStorageConfig     `koanf:"local-db-storage"`
-	LocalFileStorage   LocalFileStorageConfig   `koanf:"local-file-storage"`
-	S3Storage          S3StorageServiceConfig   `koanf:"s3-storage"`
-	IpfsStorage        IpfsStorageServiceConfig `koanf:"ipfs-storage"`
-	RegularSyncStorage RegularSyncStorageConfig `koanf:"regular-sync-storage"`
+	RequestTimeout  time.Duration `koanf:"request-timeout"`
+	PrefetchBatches int           `koanf:"prefetch-batches"`
+
+	LocalCache    BigCacheConfig      `koanf:"local-cache"`
+	RedisCache    RedisConfig         `koanf:"redis-cache"`
+	MemcacheCache MemcacheConfig      `koanf:"memcache-cache"`
+	LruCache      LruCacheConfig      `koanf:"lru-cache"`
+	DiskCache     DiskCacheConfig     `koanf:"disk-cache"`
+	NegativeCache NegativeCacheConfig `koanf:"negative-cache"`
+	BloomFilter   BloomFilterConfig   `koanf:"bloom-filter"`
+	CacheWarmup   CacheWarmupConfig   `koanf:"cache-warmup"`
+
+	LocalDBStorage      LocalDBStorageConfig            `koanf:"local-db-storage"`
+	LocalFileStorage    LocalFileStorageConfig          `koanf:"local-file-storage"`
+	S3Storage           S3StorageServiceConfig          `koanf:"s3-storage"`
+	GoogleCloudStorage  GoogleCloudStorageServiceConfig `koanf:"google-cloud-storage"`
+	AzureBlobStorage    AzureBlobStorageServiceConfig   `koanf:"azure-blob-storage"`
+	CassandraStorage    CassandraStorageServiceConfig   `koanf:"cassandra-storage"`
+	MongoStorage        MongoStorageServiceConfig       `koanf:"mongo-storage"`
+	EtcdStorage         EtcdStorageServiceConfig        `koanf:"etcd-storage"`
+	MemoryStorage       MemoryStorageConfig             `koanf:"memory-storage"`
+	WebDAVStorage       WebDAVStorageServiceConfig      `koanf:"webdav-storage"`
+	SFTPStorage         SFTPStorageServiceConfig        `koanf:"sftp-storage"`
+	PluginStorage       PluginStorageServiceConfig      `koanf:"plugin-storage"`
+	CephStorage         CephStorageServiceConfig        `koanf:"ceph-storage"`
+	BigtableStorage     BigtableStorageServiceConfig    `koanf:"bigtable-storage"`
+	HDFSStorage         HDFSStorageServiceConfig        `koanf:"hdfs-storage"`
+	IpfsStorage         IpfsStorageServiceConfig        `koanf:"ipfs-storage"`
+	FilecoinColdStorage FilecoinColdStorageConfig       `koanf:"filecoin-cold-storage"`
+	TieredStorage       TieredStorageConfig             `koanf:"tiered-storage"`
+	Compression         CompressionConfig               `koanf:"compression"`
+	Encryption          EncryptionConfig                `koanf:"encryption"`
+	RedundantStorage    RedundantStorageConfig          `koanf:"redundant-storage"`
+	RegularSyncStorage  RegularSyncStorageConfig        `koanf:"regular-sync-storage"`
+	Mirror              MirrorConfig                    `koanf:"mirror"`
+	Gossip              GossipConfig                    `koanf:"gossip"`
+	AntiEntropy         AntiEntropyConfig               `koanf:"anti-entropy"`
+	CustodyChallenge    CustodyChallengeConfig          `koanf:"custody-challenge"`
+	RequestPriority     RequestPriorityConfig           `koanf:"request-priority"`
+	Announcement        EndpointAnnouncementConfig      `koanf:"announcement"`
+	StoreBounds         StoreBoundsConfig               `koanf:"store-bounds"`
+	StoreBackpressure   StoreBackpressureConfig         `koanf:"store-backpressure"`
 
 	Key KeyConfig `koanf:"key"`
 
-	RPCAggregator  AggregatorConfig              `koanf:"rpc-aggregator"`
-	RestAggregator RestfulClientAggregatorConfig `koanf:"rest-aggregator"`
+	RPCAggregator       AggregatorConfig              `koanf:"rpc-aggregator"`
+	SecondaryAggregator SecondaryAggregatorConfig     `koanf:"secondary-aggregator"`
+	RestAggregator      RestfulClientAggregatorConfig `koanf:"rest-aggregator"`
+	CommitteeRegistry   CommitteeRegistryConfig       `koanf:"committee-registry"`
 
 	ParentChainNodeURL              string `koanf:"parent-chain-node-url"`
 	ParentChainConnectionAttempts   int    `koanf:"parent-chain-connection-attempts"`
@@ -61,15 +95,48 @@ type DataAvailabilityConfig struct {
 
 	PanicOnError             bool `koanf:"panic-on-error"`
 	DisableSignatureChecking bool `koanf:"disable-signature-checking"`
+	ReadOnly                 bool `koanf:"read-only"`
 }
 
 var DefaultDataAvailabilityConfig = DataAvailabilityConfig{
 	RequestTimeout:                5 * time.Second,
+	PrefetchBatches:               4,
 	Enable:                        false,
+	SecondaryAggregator:           DefaultSecondaryAggregatorConfig,
 	RestAggregator:                DefaultRestfulClientAggregatorConfig,
+	CommitteeRegistry:             DefaultCommitteeRegistryConfig,
+	LruCache:                      DefaultLruCacheConfig,
+	MemcacheCache:                 DefaultMemcacheConfig,
+	DiskCache:                     DefaultDiskCacheConfig,
+	NegativeCache:                 DefaultNegativeCacheConfig,
+	BloomFilter:                   DefaultBloomFilterConfig,
+	CacheWarmup:                   DefaultCacheWarmupConfig,
 	ParentChainConnectionAttempts: 15,
 	PanicOnError:                  false,
 	IpfsStorage:                   DefaultIpfsStorageServiceConfig,
+	FilecoinColdStorage:           DefaultFilecoinColdStorageConfig,
+	GoogleCloudStorage:            DefaultGoogleCloudStorageServiceConfig,
+	CassandraStorage:              DefaultCassandraStorageServiceConfig,
+	MongoStorage:                  DefaultMongoStorageServiceConfig,
+	EtcdStorage:                   DefaultEtcdStorageServiceConfig,
+	WebDAVStorage:                 DefaultWebDAVStorageServiceConfig,
+	SFTPStorage:                   DefaultSFTPStorageServiceConfig,
+	TieredStorage:                 DefaultTieredStorageConfig,
+	Compression:                   DefaultCompressionConfig,
+	Encryption:                    DefaultEncryptionConfig,
+	RedundantStorage:              DefaultRedundantStorageConfig,
+	PluginStorage:                 DefaultPluginStorageServiceConfig,
+	CephStorage:                   DefaultCephStorageServiceConfig,
+	BigtableStorage:               DefaultBigtableStorageServiceConfig,
+	HDFSStorage:                   DefaultHDFSStorageServiceConfig,
+	Mirror:                        DefaultMirrorConfig,
+	Gossip:                        DefaultGossipConfig,
+	AntiEntropy:                   DefaultAntiEntropyConfig,
+	CustodyChallenge:              DefaultCustodyChallengeConfig,
+	RequestPriority:               DefaultRequestPriorityConfig,
+	Announcement:                  DefaultEndpointAnnouncementConfig,
+	StoreBounds:                   DefaultStoreBoundsConfig,
+	StoreBackpressure:             DefaultStoreBackpressureConfig,
 }
 
 func OptionalAddressFromString(s string) (*common.Address, error) {
@@ -107,16 +174,47 @@ func dataAvailabilityConfigAddOptions(prefix string, f *flag.FlagSet, r role) {
 
 	if r == roleDaserver {
 		f.Bool(prefix+".disable-signature-checking", DefaultDataAvailabilityConfig.DisableSignatureChecking, "disables signature checking on Data Availability Store requests (DANGEROUS, FOR TESTING ONLY)")
+		f.Bool(prefix+".read-only", DefaultDataAvailabilityConfig.ReadOnly, "serve data from storage without ever writing to it, eg for a public mirror of a replicated copy of committee data")
 
 		// Cache options
 		BigCacheConfigAddOptions(prefix+".local-cache", f)
 		RedisConfigAddOptions(prefix+".redis-cache", f)
+		MemcacheConfigAddOptions(prefix+".memcache-cache", f)
+		DiskCacheConfigAddOptions(prefix+".disk-cache", f)
+		NegativeCacheConfigAddOptions(prefix+".negative-cache", f)
+		BloomFilterConfigAddOptions(prefix+".bloom-filter", f)
+		CacheWarmupConfigAddOptions(prefix+".cache-warmup", f)
 
 		// Storage options
 		LocalDBStorageConfigAddOptions(prefix+".local-db-storage", f)
 		LocalFileStorageConfigAddOptions(prefix+".local-file-storage", f)
 		S3ConfigAddOptions(prefix+".s3-storage", f)
+		GoogleCloudStorageConfigAddOptions(prefix+".google-cloud-storage", f)
+		AzureBlobStorageConfigAddOptions(prefix+".azure-blob-storage", f)
+		CassandraStorageConfigAddOptions(prefix+".cassandra-storage", f)
+		MongoStorageConfigAddOptions(prefix+".mongo-storage", f)
+		FilecoinColdStorageConfigAddOptions(prefix+".filecoin-cold-storage", f)
+		EtcdStorageConfigAddOptions(prefix+".etcd-storage", f)
+		MemoryStorageConfigAddOptions(prefix+".memory-storage", f)
+		WebDAVStorageConfigAddOptions(prefix+".webdav-storage", f)
+		SFTPStorageConfigAddOptions(prefix+".sftp-storage", f)
+		TieredStorageConfigAddOptions(prefix+".tiered-storage", f)
+		RedundantStorageConfigAddOptions(prefix+".redundant-storage", f)
+		PluginStorageConfigAddOptions(prefix+".plugin-storage", f)
+		CephStorageConfigAddOptions(prefix+".ceph-storage", f)
+		BigtableStorageConfigAddOptions(prefix+".bigtable-storage", f)
+		CompressionConfigAddOptions(prefix+".compression", f)
+		EncryptionConfigAddOptions(prefix+".encryption", f)
+		HDFSStorageConfigAddOptions(prefix+".hdfs-storage", f)
 		RegularSyncStorageConfigAddOptions(prefix+".regular-sync-storage", f)
+		MirrorConfigAddOptions(prefix+".mirror", f)
+		GossipConfigAddOptions(prefix+".gossip", f)
+		AntiEntropyConfigAddOptions(prefix+".anti-entropy", f)
+		CustodyChallengeConfigAddOptions(prefix+".custody-challenge", f)
+		RequestPriorityConfigAddOptions(prefix+".request-priority", f)
+		EndpointAnnouncementConfigAddOptions(prefix+".announcement", f)
+		StoreBoundsConfigAddOptions(prefix+".store-bounds", f)
+		StoreBackpressureConfigAddOptions(prefix+".store-backpressure", f)
 
 		// Key config for storage
 		KeyConfigAddOptions(prefix+".key", f)
@@ -126,12 +224,18 @@ func dataAvailabilityConfigAddOptions(prefix string, f *flag.FlagSet, r role) {
 	if r == roleNode {
 		// These are only for batch poster
 		AggregatorConfigAddOptions(prefix+".rpc-aggregator", f)
+		SecondaryAggregatorConfigAddOptions(prefix+".secondary-aggregator", f)
 		f.Duration(prefix+".request-timeout", DefaultDataAvailabilityConfig.RequestTimeout, "Data Availability Service timeout duration for Store requests")
+		f.Int(prefix+".prefetch-batches", DefaultDataAvailabilityConfig.PrefetchBatches, "number of batches whose data is fetched concurrently from the Data Availability Service while catching up (0 to fetch one batch at a time)")
 	}
 
 	// Both the Nitro node and daserver can use these options.
+	// On a Nitro node, the LRU cache keeps batches retrieved from the rest
+	// aggregator, so they aren't fetched again.
+	LruCacheConfigAddOptions(prefix+".lru-cache", f)
 	IpfsStorageServiceConfigAddOptions(prefix+".ipfs-storage", f)
 	RestfulClientAggregatorConfigAddOptions(prefix+".rest-aggregator", f)
+	CommitteeRegistryConfigAddOptions(prefix+".committee-registry", f)
 
 	f.String(prefix+".parent-chain-node-url", DefaultDataAvailabilityConfig.ParentChainNodeURL, "URL for parent chain node, only used in standalone daserver; when running as part of a node that node's L1 configuration is used")
 	f.Int(prefix+".parent-chain-connection-attempts", DefaultDataAvailabilityConfig.ParentChainConnectionAttempts, "parent chain RPC connection attempts (spaced out at least 1 second per attempt, 0 to retry infinitely), only used in standalone daserver; when running as part of a node that node's parent chain configuration is used")
@@ -5,7 +5,11 @@ package das
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -15,31 +19,76 @@ import (
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/offchainlabs/nitro/arbstate"
 	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/das/dastree"
 	"github.com/offchainlabs/nitro/util/pretty"
 )
 
+// unixSocketURLPrefix prefixes the path of a daserver's HTTP-RPC unix socket
+// to make a target URL, eg unix:///run/daserver/rpc.sock.
+const unixSocketURLPrefix = "unix://"
+
+func unixSocketHTTPClient(socketPath string) *http.Client {
+	var dialer net.Dialer
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", socketPath)
+			},
+		},
+	}
+}
+
 type DASRPCClient struct { // implements DataAvailabilityService
 	clnt *rpc.Client
 	url  string
+	// httpClient is set if the client has its own HTTP client, whose
+	// connections are closed with it.
+	httpClient *http.Client
 }
 
 func NewDASRPCClient(target string) (*DASRPCClient, error) {
-	clnt, err := rpc.Dial(target)
+	return NewDASRPCClientWithOptions(context.Background(), target)
+}
+
+// NewDASRPCClientWithOptions dials target with the given options, eg to
+// authenticate to a proxy in front of the DAS.
+func NewDASRPCClientWithOptions(ctx context.Context, target string, options ...rpc.ClientOption) (*DASRPCClient, error) {
+	dialTarget := target
+	var httpClient *http.Client
+	if socketPath, ok := strings.CutPrefix(target, unixSocketURLPrefix); ok {
+		dialTarget = "http://localhost"
+		httpClient = unixSocketHTTPClient(socketPath)
+		options = append(options, rpc.WithHTTPClient(httpClient))
+	}
+	clnt, err := rpc.DialOptions(ctx, dialTarget, options...)
 	if err != nil {
 		return nil, err
 	}
 	return &DASRPCClient{
-		clnt: clnt,
-		url:  target,
+		clnt:       clnt,
+		url:        target,
+		httpClient: httpClient,
 	}, nil
 }
 
+// Close closes the client's connections.
+func (c *DASRPCClient) Close() {
+	c.clnt.Close()
+	if c.httpClient != nil {
+		c.httpClient.CloseIdleConnections()
+	}
+}
+
 func (c *DASRPCClient) Store(ctx context.Context, message []byte, timeout uint64, reqSig []byte) (*arbstate.DataAvailabilityCertificate, error) {
 	log.Trace("das.DASRPCClient.Store(...)", "message", pretty.FirstFewBytes(message), "timeout", time.Unix(int64(timeout), 0), "sig", pretty.FirstFewBytes(reqSig), "this", *c)
 	var ret StoreResult
 	if err := c.clnt.CallContext(ctx, &ret, "das_store", hexutil.Bytes(message), hexutil.Uint64(timeout), hexutil.Bytes(reqSig)); err != nil {
-		return nil, err
+		return nil, overloadedErrorFromHTTP(err)
 	}
+	return storeResultToCert(&ret)
+}
+
+func storeResultToCert(ret *StoreResult) (*arbstate.DataAvailabilityCertificate, error) {
 	respSig, err := blsSignatures.SignatureFromBytes(ret.Sig)
 	if err != nil {
 		return nil, err
@@ -54,6 +103,109 @@ func (c *DASRPCClient) Store(ctx context.Context, message []byte, timeout uint64
 	}, nil
 }
 
+// StoreChunked stores a message too large for one request, sending it in
+// chunks of chunkSize bytes.
+func (c *DASRPCClient) StoreChunked(ctx context.Context, message []byte, timeout uint64, reqSig []byte, chunkSize int) (*arbstate.DataAvailabilityCertificate, error) {
+	log.Trace("das.DASRPCClient.StoreChunked(...)", "message", pretty.FirstFewBytes(message), "message length", len(message), "timeout", time.Unix(int64(timeout), 0), "this", *c)
+	if chunkSize <= 0 {
+		return nil, errors.New("chunk size must be positive")
+	}
+	var id hexutil.Uint64
+	if err := c.clnt.CallContext(ctx, &id, "das_startChunkedStore", hexutil.Uint64(timeout), hexutil.Bytes(reqSig)); err != nil {
+		return nil, err
+	}
+	for offset := 0; offset < len(message); offset += chunkSize {
+		end := offset + chunkSize
+		if end > len(message) {
+			end = len(message)
+		}
+		if err := c.clnt.CallContext(ctx, nil, "das_sendChunk", id, hexutil.Uint64(offset), hexutil.Bytes(message[offset:end])); err != nil {
+			return nil, err
+		}
+	}
+	var ret StoreResult
+	if err := c.clnt.CallContext(ctx, &ret, "das_commitChunkedStore", id, dastree.Hash(message)); err != nil {
+		return nil, err
+	}
+	return storeResultToCert(&ret)
+}
+
+// StoreAsync queues a message on the server without waiting for it to be
+// stored, returning the receipt to pass to StoreAsyncResult.
+func (c *DASRPCClient) StoreAsync(ctx context.Context, message []byte, timeout uint64, reqSig []byte) (string, error) {
+	log.Trace("das.DASRPCClient.StoreAsync(...)", "message", pretty.FirstFewBytes(message), "timeout", time.Unix(int64(timeout), 0), "this", *c)
+	var receipt string
+	if err := c.clnt.CallContext(ctx, &receipt, "das_storeAsync", hexutil.Bytes(message), hexutil.Uint64(timeout), hexutil.Bytes(reqSig)); err != nil {
+		return "", overloadedErrorFromHTTP(err)
+	}
+	return receipt, nil
+}
+
+// StoreAsyncResult returns the certificate for a message queued with
+// StoreAsync, or ErrAsyncStorePending if it isn't stored yet.
+func (c *DASRPCClient) StoreAsyncResult(ctx context.Context, receipt string) (*arbstate.DataAvailabilityCertificate, error) {
+	var ret AsyncStoreResult
+	if err := c.clnt.CallContext(ctx, &ret, "das_storeAsyncResult", receipt); err != nil {
+		return nil, err
+	}
+	switch ret.Status {
+	case AsyncStorePending:
+		return nil, ErrAsyncStorePending
+	case AsyncStoreStored:
+		if ret.Cert == nil {
+			return nil, errors.New("das_storeAsyncResult returned no certificate")
+		}
+		return storeResultToCert(ret.Cert)
+	default:
+		return nil, fmt.Errorf("asynchronous store failed: %s", ret.Error)
+	}
+}
+
+// StoreBatch stores several messages in one call, returning their
+// certificates in the same order.
+func (c *DASRPCClient) StoreBatch(ctx context.Context, items []StoreBatchItem) ([]*arbstate.DataAvailabilityCertificate, error) {
+	log.Trace("das.DASRPCClient.StoreBatch(...)", "messages", len(items), "this", *c)
+	var ret []StoreResult
+	if err := c.clnt.CallContext(ctx, &ret, "das_storeBatch", items); err != nil {
+		return nil, err
+	}
+	if len(ret) != len(items) {
+		return nil, fmt.Errorf("das_storeBatch returned %d certificates for %d messages", len(ret), len(items))
+	}
+	certs := make([]*arbstate.DataAvailabilityCertificate, len(ret))
+	for i := range ret {
+		cert, err := storeResultToCert(&ret[i])
+		if err != nil {
+			return nil, err
+		}
+		certs[i] = cert
+	}
+	return certs, nil
+}
+
+// RetrieveBatch fetches the data for several hashes in one call, in the same
+// order, with nil for the data the server doesn't have.
+func (c *DASRPCClient) RetrieveBatch(ctx context.Context, hashes []common.Hash) ([][]byte, error) {
+	var ret []*hexutil.Bytes
+	if err := c.clnt.CallContext(ctx, &ret, "das_retrieveBatch", hashes); err != nil {
+		return nil, err
+	}
+	if len(ret) != len(hashes) {
+		return nil, fmt.Errorf("das_retrieveBatch returned %d results for %d hashes", len(ret), len(hashes))
+	}
+	data := make([][]byte, len(ret))
+	for i, r := range ret {
+		if r == nil {
+			continue
+		}
+		if !dastree.ValidHash(hashes[i], *r) {
+			return nil, arbstate.ErrHashMismatch
+		}
+		data[i] = *r
+	}
+	return data, nil
+}
+
 func (c *DASRPCClient) String() string {
 	return fmt.Sprintf("DASRPCClient{url:%s}", c.url)
 }
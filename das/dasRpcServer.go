@@ -5,19 +5,26 @@ package das
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 
 	"github.com/ethereum/go-ethereum/rpc"
 
+	"github.com/offchainlabs/nitro/arbstate"
 	"github.com/offchainlabs/nitro/blsSignatures"
 	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/das/dastree"
 	"github.com/offchainlabs/nitro/util/pretty"
 )
 
@@ -27,35 +34,83 @@ var (
 	rpcStoreFailureGauge      = metrics.NewRegisteredGauge("arb/das/rpc/store/failure", nil)
 	rpcStoreStoredBytesGauge  = metrics.NewRegisteredGauge("arb/das/rpc/store/bytes", nil)
 	rpcStoreDurationHistogram = metrics.NewRegisteredHistogram("arb/das/rpc/store/duration", nil, metrics.NewBoundedHistogramSample())
+
+	rpcStoreBatchRequestGauge    = metrics.NewRegisteredGauge("arb/das/rpc/storebatch/requests", nil)
+	rpcRetrieveBatchRequestGauge = metrics.NewRegisteredGauge("arb/das/rpc/retrievebatch/requests", nil)
+	rpcRetrieveBatchBytesGauge   = metrics.NewRegisteredGauge("arb/das/rpc/retrievebatch/bytes", nil)
 )
 
+// MaxRPCBatchItems is the most messages or hashes das_storeBatch and
+// das_retrieveBatch accept in one call.
+const MaxRPCBatchItems = 64
+
+// ErrStoreDisabled is returned by the store methods of an HTTP-RPC server
+// started without a writer, eg one only serving retrievals.
+var ErrStoreDisabled = errors.New("storing isn't enabled on this server")
+
 type DASRPCServer struct {
 	daReader        DataAvailabilityServiceReader
 	daWriter        DataAvailabilityServiceWriter
 	daHealthChecker DataAvailabilityServiceHealthChecker
+	storeNotifier   *StoreNotifier
+	asyncStorer     *AsyncStorer
+	chunkedStores   *chunkedStores
 }
 
-func StartDASRPCServer(ctx context.Context, addr string, portNum uint64, rpcServerTimeouts genericconf.HTTPServerTimeoutConfig, daReader DataAvailabilityServiceReader, daWriter DataAvailabilityServiceWriter, daHealthChecker DataAvailabilityServiceHealthChecker) (*http.Server, error) {
-	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", addr, portNum))
+func StartDASRPCServer(ctx context.Context, addr string, portNum uint64, rpcServerTimeouts genericconf.HTTPServerTimeoutConfig, ipLimiter *IPRateLimiter, storeBounds StoreBoundsConfig, daReader DataAvailabilityServiceReader, daWriter DataAvailabilityServiceWriter, daHealthChecker DataAvailabilityServiceHealthChecker) (*http.Server, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(addr, strconv.FormatUint(portNum, 10)))
 	if err != nil {
 		return nil, err
 	}
-	return StartDASRPCServerOnListener(ctx, listener, rpcServerTimeouts, daReader, daWriter, daHealthChecker)
+	return StartDASRPCServerOnListener(ctx, listener, rpcServerTimeouts, ipLimiter, storeBounds, daReader, daWriter, daHealthChecker)
 }
 
-func StartDASRPCServerOnListener(ctx context.Context, listener net.Listener, rpcServerTimeouts genericconf.HTTPServerTimeoutConfig, daReader DataAvailabilityServiceReader, daWriter DataAvailabilityServiceWriter, daHealthChecker DataAvailabilityServiceHealthChecker) (*http.Server, error) {
+// StartDASRPCServerOnListener only uses storeBounds to limit the messages
+// buffered from chunked stores; daWriter is expected to enforce them.
+func StartDASRPCServerOnListener(ctx context.Context, listener net.Listener, rpcServerTimeouts genericconf.HTTPServerTimeoutConfig, ipLimiter *IPRateLimiter, storeBounds StoreBoundsConfig, daReader DataAvailabilityServiceReader, daWriter DataAvailabilityServiceWriter, daHealthChecker DataAvailabilityServiceHealthChecker) (*http.Server, error) {
 	rpcServer := rpc.NewServer()
-	err := rpcServer.RegisterName("das", &DASRPCServer{
+	// Subscriptions to das_subscribe("stored") are only served if daWriter
+	// notifies of the data it stores.
+	storeNotifier, _ := daWriter.(*StoreNotifier)
+	// das_storeAsync is only served if daWriter can queue stores, in which
+	// case it wraps the store notifier.
+	asyncStorer, _ := daWriter.(*AsyncStorer)
+	if asyncStorer != nil {
+		storeNotifier, _ = asyncStorer.DataAvailabilityServiceWriter.(*StoreNotifier)
+	}
+	dasRPCServer := &DASRPCServer{
 		daReader:        daReader,
 		daWriter:        daWriter,
 		daHealthChecker: daHealthChecker,
-	})
+		storeNotifier:   storeNotifier,
+		asyncStorer:     asyncStorer,
+		chunkedStores:   newChunkedStores(storeBounds),
+	}
+	err := rpcServer.RegisterName("das", dasRPCServer)
 	if err != nil {
 		return nil, err
 	}
 
+	// WebSocket connections, which subscriptions need, are upgraded on the
+	// same port as HTTP-RPC.
+	wsHandler := rpcServer.WebsocketHandler(nil)
 	srv := &http.Server{
-		Handler:           rpcServer,
+		Handler: probeHandler(daHealthChecker, ipLimiter.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+				wsHandler.ServeHTTP(w, r)
+				return
+			}
+			// Lets a ClientCertStoreAuthenticator or TokenStoreAuthenticator
+			// writer see the client's credentials.
+			r = withBearerToken(withVerifiedClientCert(r))
+			r, note := withOverloadNote(r)
+			w = &overloadResponseWriter{ResponseWriter: w, note: note}
+			if r.URL.Path == protobufStorePath {
+				dasRPCServer.serveProtobufStore(w, r)
+				return
+			}
+			rpcServer.ServeHTTP(w, r)
+		}))),
 		ReadTimeout:       rpcServerTimeouts.ReadTimeout,
 		ReadHeaderTimeout: rpcServerTimeouts.ReadHeaderTimeout,
 		WriteTimeout:      rpcServerTimeouts.WriteTimeout,
@@ -86,6 +141,9 @@ type StoreResult struct {
 
 func (serv *DASRPCServer) Store(ctx context.Context, message hexutil.Bytes, timeout hexutil.Uint64, sig hexutil.Bytes) (*StoreResult, error) {
 	log.Trace("dasRpc.DASRPCServer.Store", "message", pretty.FirstFewBytes(message), "message length", len(message), "timeout", time.Unix(int64(timeout), 0), "sig", pretty.FirstFewBytes(sig), "this", serv)
+	if serv.daWriter == nil {
+		return nil, ErrStoreDisabled
+	}
 	rpcStoreRequestGauge.Inc(1)
 	start := time.Now()
 	success := false
@@ -100,10 +158,15 @@ func (serv *DASRPCServer) Store(ctx context.Context, message hexutil.Bytes, time
 
 	cert, err := serv.daWriter.Store(ctx, message, uint64(timeout), sig)
 	if err != nil {
+		noteOverloaded(ctx, err)
 		return nil, err
 	}
 	rpcStoreStoredBytesGauge.Inc(int64(len(message)))
 	success = true
+	return certToStoreResult(cert), nil
+}
+
+func certToStoreResult(cert *arbstate.DataAvailabilityCertificate) *StoreResult {
 	return &StoreResult{
 		KeysetHash:  cert.KeysetHash[:],
 		DataHash:    cert.DataHash[:],
@@ -111,7 +174,161 @@ func (serv *DASRPCServer) Store(ctx context.Context, message hexutil.Bytes, time
 		SignersMask: hexutil.Uint64(cert.SignersMask),
 		Sig:         blsSignatures.SignatureToBytes(cert.Sig),
 		Version:     hexutil.Uint64(cert.Version),
-	}, nil
+	}
+}
+
+// StartChunkedStore begins storing a message too large for one request. The
+// message is sent with SendChunk, then stored with CommitChunkedStore. Clients
+// that couldn't store it are turned away before it's buffered.
+func (serv *DASRPCServer) StartChunkedStore(ctx context.Context, timeout hexutil.Uint64, sig hexutil.Bytes) (hexutil.Uint64, error) {
+	if serv.daWriter == nil {
+		return 0, ErrStoreDisabled
+	}
+	if err := authorizeStore(ctx, serv.daWriter); err != nil {
+		return 0, err
+	}
+	id, err := serv.chunkedStores.start(uint64(timeout), sig)
+	return hexutil.Uint64(id), err
+}
+
+func (serv *DASRPCServer) SendChunk(ctx context.Context, id hexutil.Uint64, offset hexutil.Uint64, chunk hexutil.Bytes) error {
+	if serv.daWriter == nil {
+		return ErrStoreDisabled
+	}
+	if err := authorizeStore(ctx, serv.daWriter); err != nil {
+		return err
+	}
+	return serv.chunkedStores.appendChunk(uint64(id), uint64(offset), chunk)
+}
+
+// CommitChunkedStore stores the message sent in chunks as Store would, once
+// it's checked to have the dataHash the client expects.
+func (serv *DASRPCServer) CommitChunkedStore(ctx context.Context, id hexutil.Uint64, dataHash common.Hash) (*StoreResult, error) {
+	store, err := serv.chunkedStores.finish(uint64(id))
+	if err != nil {
+		return nil, err
+	}
+	if dastree.Hash(store.message) != dataHash {
+		return nil, fmt.Errorf("chunked message of %d bytes doesn't have the expected hash %v", len(store.message), dataHash)
+	}
+	return serv.Store(ctx, store.message, hexutil.Uint64(store.timeout), store.sig)
+}
+
+// StoreAsync queues the message to be stored, returning a receipt as soon as
+// it's written to disk. Its certificate is returned by StoreAsyncResult once
+// it's stored.
+func (serv *DASRPCServer) StoreAsync(ctx context.Context, message hexutil.Bytes, timeout hexutil.Uint64, sig hexutil.Bytes) (string, error) {
+	if serv.asyncStorer == nil {
+		return "", errors.New("this DAS doesn't store asynchronously")
+	}
+	receipt, err := serv.asyncStorer.StoreAsync(ctx, message, uint64(timeout), sig)
+	noteOverloaded(ctx, err)
+	return receipt, err
+}
+
+func (serv *DASRPCServer) StoreAsyncResult(ctx context.Context, receipt string) (*AsyncStoreResult, error) {
+	if serv.asyncStorer == nil {
+		return nil, errors.New("this DAS doesn't store asynchronously")
+	}
+	return serv.asyncStorer.Result(receipt)
+}
+
+type StoreBatchItem struct {
+	Message hexutil.Bytes  `json:"message"`
+	Timeout hexutil.Uint64 `json:"timeout"`
+	Sig     hexutil.Bytes  `json:"sig,omitempty"`
+}
+
+// StoreBatch stores each of the messages as Store would, concurrently unless
+// the writer can store them in one call itself, and returns their
+// certificates in the same order. It fails if any of them fails; storing the
+// others again on retry is harmless.
+func (serv *DASRPCServer) StoreBatch(ctx context.Context, items []StoreBatchItem) ([]*StoreResult, error) {
+	if len(items) > MaxRPCBatchItems {
+		return nil, fmt.Errorf("batch of %d messages is larger than the maximum of %d", len(items), MaxRPCBatchItems)
+	}
+	rpcStoreBatchRequestGauge.Inc(1)
+	if batchWriter, ok := serv.daWriter.(DataAvailabilityServiceBatchWriter); ok {
+		certs, err := batchWriter.StoreBatch(ctx, items)
+		if err != nil {
+			return nil, err
+		}
+		results := make([]*StoreResult, len(certs))
+		for i, cert := range certs {
+			results[i] = certToStoreResult(cert)
+		}
+		return results, nil
+	}
+	results := make([]*StoreResult, len(items))
+	errs := make([]error, len(items))
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		go func(i int, item StoreBatchItem) {
+			defer wg.Done()
+			results[i], errs[i] = serv.Store(ctx, item.Message, item.Timeout, item.Sig)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("message %d: %w", i, errs[i])
+			}
+		}(i, item)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// RetrieveBatch returns the data stored under each of the hashes, in the same
+// order, with null for the data that isn't found.
+func (serv *DASRPCServer) RetrieveBatch(ctx context.Context, hashes []common.Hash) ([]*hexutil.Bytes, error) {
+	if len(hashes) > MaxRPCBatchItems {
+		return nil, fmt.Errorf("batch of %d hashes is larger than the maximum of %d", len(hashes), MaxRPCBatchItems)
+	}
+	rpcRetrieveBatchRequestGauge.Inc(1)
+	results := make([]*hexutil.Bytes, len(hashes))
+	for i, hash := range hashes {
+		data, err := serv.daReader.GetByHash(ctx, hash)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("hash %v: %w", hash, err)
+		}
+		rpcRetrieveBatchBytesGauge.Inc(int64(len(data)))
+		results[i] = (*hexutil.Bytes)(&data)
+	}
+	return results, nil
+}
+
+// Stored is the "stored" subscription, which notifies the subscriber of the
+// hash, size and timeout of each message stored from then on.
+func (serv *DASRPCServer) Stored(ctx context.Context) (*rpc.Subscription, error) {
+	if serv.storeNotifier == nil {
+		return nil, errors.New("this DAS doesn't notify of stored data")
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	stored := make(chan StoredData, 256)
+	unsubscribe := serv.storeNotifier.SubscribeStored(stored)
+	go func() {
+		defer unsubscribe()
+		for {
+			select {
+			case data := <-stored:
+				if err := notifier.Notify(sub.ID, data); err != nil {
+					log.Debug("Failed to notify subscriber of stored data", "err", err)
+					return
+				}
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return sub, nil
 }
 
 func (serv *DASRPCServer) HealthCheck(ctx context.Context) error {
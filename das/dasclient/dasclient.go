@@ -0,0 +1,238 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package dasclient is a client for storing data to and retrieving data from
+// daservers, for integrators that aren't running a nitro node. It stores over
+// HTTP-RPC and retrieves over REST, falling back through the configured
+// endpoints and retrying with backoff, and checks that the data and
+// certificates it returns match what was asked for.
+package dasclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das"
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+type Config struct {
+	// StoreURLs are the HTTP-RPC endpoints of the daservers or aggregators
+	// to store to, tried in order.
+	StoreURLs []string `koanf:"store-urls"`
+	// RetrieveURLs are the REST endpoints to retrieve data and keysets
+	// from, tried in order.
+	RetrieveURLs []string `koanf:"retrieve-urls"`
+	// HTTP3 retrieves over HTTP/3, for which RetrieveURLs must be https.
+	HTTP3 bool `koanf:"http3"`
+	// Timeout of each request to an endpoint.
+	Timeout time.Duration `koanf:"timeout"`
+	// Retries is the number of times all the endpoints are tried again after
+	// they've all failed, waiting Backoff before the first retry, doubling
+	// on each further retry up to MaxBackoff.
+	Retries    int           `koanf:"retries"`
+	Backoff    time.Duration `koanf:"backoff"`
+	MaxBackoff time.Duration `koanf:"max-backoff"`
+	// VerifySignatures checks each stored certificate's signature against
+	// its keyset, fetched from RetrieveURLs.
+	VerifySignatures bool `koanf:"verify-signatures"`
+}
+
+var DefaultConfig = Config{
+	Timeout:          10 * time.Second,
+	Retries:          2,
+	Backoff:          500 * time.Millisecond,
+	MaxBackoff:       5 * time.Second,
+	VerifySignatures: true,
+}
+
+func ConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.StringSlice(prefix+".store-urls", DefaultConfig.StoreURLs, "HTTP-RPC URLs of the DAS to store to, tried in order")
+	f.StringSlice(prefix+".retrieve-urls", DefaultConfig.RetrieveURLs, "REST URLs of the DAS to retrieve from, tried in order")
+	f.Bool(prefix+".http3", DefaultConfig.HTTP3, "retrieve over HTTP/3, for which the retrieve URLs must be 'https://' URLs serving it")
+	f.Duration(prefix+".timeout", DefaultConfig.Timeout, "timeout of each request to a DAS endpoint")
+	f.Int(prefix+".retries", DefaultConfig.Retries, "number of times to try all the endpoints again once they've all failed")
+	f.Duration(prefix+".backoff", DefaultConfig.Backoff, "wait before the first retry, doubling on each further retry")
+	f.Duration(prefix+".max-backoff", DefaultConfig.MaxBackoff, "longest wait between retries (0 for no limit)")
+	f.Bool(prefix+".verify-signatures", DefaultConfig.VerifySignatures, "verify the signature of each stored certificate against its keyset, fetched from the retrieve URLs")
+}
+
+func (c *Config) Validate() error {
+	if len(c.StoreURLs) == 0 && len(c.RetrieveURLs) == 0 {
+		return errors.New("at least one store or retrieve URL must be set")
+	}
+	if c.VerifySignatures && len(c.StoreURLs) != 0 && len(c.RetrieveURLs) == 0 {
+		return errors.New("verifying signatures needs a retrieve URL to fetch keysets from")
+	}
+	if c.Retries < 0 || c.Timeout < 0 || c.Backoff < 0 || c.MaxBackoff < 0 {
+		return errors.New("retries, timeout and backoffs can't be negative")
+	}
+	return nil
+}
+
+var (
+	ErrNoStoreURLs    = errors.New("no store URLs configured")
+	ErrNoRetrieveURLs = errors.New("no retrieve URLs configured")
+)
+
+// Client stores and retrieves data through the configured endpoints.
+type Client struct {
+	config    Config
+	writers   []*das.DASRPCClient
+	readers   []*das.RestfulDasClient
+	keysetsMu sync.Mutex
+	keysets   map[common.Hash]*arbstate.DataAvailabilityKeyset
+}
+
+func New(ctx context.Context, config Config) (*Client, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	c := &Client{
+		config:  config,
+		keysets: make(map[common.Hash]*arbstate.DataAvailabilityKeyset),
+	}
+	for _, url := range config.StoreURLs {
+		writer, err := das.NewDASRPCClientWithOptions(ctx, url)
+		if err != nil {
+			return nil, fmt.Errorf("store URL %s: %w", url, err)
+		}
+		c.writers = append(c.writers, writer)
+	}
+	newReader := das.NewRestfulDasClientFromURL
+	if config.HTTP3 {
+		newReader = func(url string) (*das.RestfulDasClient, error) {
+			return das.NewHTTP3RestfulDasClientFromURL(url, nil)
+		}
+	}
+	for _, url := range config.RetrieveURLs {
+		reader, err := newReader(url)
+		if err != nil {
+			return nil, fmt.Errorf("retrieve URL %s: %w", url, err)
+		}
+		c.readers = append(c.readers, reader)
+	}
+	return c, nil
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// try calls attempt with each of the endpoints in turn until one succeeds,
+// then retries them all with backoff, returning every endpoint's error from
+// the last round if none did.
+func try[T any, E fmt.Stringer](ctx context.Context, config *Config, endpoints []E, attempt func(context.Context, E) (T, error)) (T, error) {
+	var zero T
+	backoff := config.Backoff
+	for round := 0; ; round++ {
+		var errs []error
+		for _, endpoint := range endpoints {
+			attemptCtx, cancel := withTimeout(ctx, config.Timeout)
+			result, err := attempt(attemptCtx, endpoint)
+			cancel()
+			if err == nil {
+				return result, nil
+			}
+			if ctx.Err() != nil {
+				return zero, ctx.Err()
+			}
+			log.Debug("dasclient: request to DAS endpoint failed", "endpoint", endpoint, "err", err)
+			errs = append(errs, fmt.Errorf("%v: %w", endpoint, err))
+		}
+		if round >= config.Retries {
+			return zero, errors.Join(errs...)
+		}
+		select {
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if config.MaxBackoff > 0 && backoff > config.MaxBackoff {
+			backoff = config.MaxBackoff
+		}
+	}
+}
+
+// Store stores message until timeout, in unix seconds, returning its
+// certificate once it's checked to be for message. sig is the store's
+// signature, if the DAS requires one.
+func (c *Client) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	if len(c.writers) == 0 {
+		return nil, ErrNoStoreURLs
+	}
+	expectedHash := dastree.Hash(message)
+	return try(ctx, &c.config, c.writers, func(ctx context.Context, writer *das.DASRPCClient) (*arbstate.DataAvailabilityCertificate, error) {
+		cert, err := writer.Store(ctx, message, timeout, sig)
+		if err != nil {
+			return nil, err
+		}
+		if cert.DataHash != expectedHash || cert.Timeout != timeout {
+			return nil, fmt.Errorf("certificate for data hash %v and timeout %d doesn't match the store of %v until %d", common.Hash(cert.DataHash), cert.Timeout, expectedHash, timeout)
+		}
+		if c.config.VerifySignatures {
+			keyset, err := c.KeysetFromHash(ctx, cert.KeysetHash)
+			if err != nil {
+				return nil, fmt.Errorf("fetching certificate's keyset: %w", err)
+			}
+			if err := keyset.VerifySignature(cert.SignersMask, cert.SerializeSignableFields(), cert.Sig); err != nil {
+				return nil, fmt.Errorf("invalid certificate signature: %w", err)
+			}
+		}
+		return cert, nil
+	})
+}
+
+// Retrieve returns the data stored under hash, checked to match it.
+func (c *Client) Retrieve(ctx context.Context, hash common.Hash) ([]byte, error) {
+	if len(c.readers) == 0 {
+		return nil, ErrNoRetrieveURLs
+	}
+	return try(ctx, &c.config, c.readers, func(ctx context.Context, reader *das.RestfulDasClient) ([]byte, error) {
+		data, err := reader.GetByHash(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if !dastree.ValidHash(hash, data) {
+			return nil, arbstate.ErrHashMismatch
+		}
+		return data, nil
+	})
+}
+
+// KeysetFromHash returns the keyset with the given hash, as referenced by
+// certificates. Keysets are cached, as their hash identifies them.
+func (c *Client) KeysetFromHash(ctx context.Context, keysetHash common.Hash) (*arbstate.DataAvailabilityKeyset, error) {
+	c.keysetsMu.Lock()
+	keyset, ok := c.keysets[keysetHash]
+	c.keysetsMu.Unlock()
+	if ok {
+		return keyset, nil
+	}
+	keysetBytes, err := c.Retrieve(ctx, keysetHash)
+	if err != nil {
+		return nil, err
+	}
+	keyset, err = arbstate.DeserializeKeyset(bytes.NewReader(keysetBytes), false)
+	if err != nil {
+		return nil, err
+	}
+	c.keysetsMu.Lock()
+	c.keysets[keysetHash] = keyset
+	c.keysetsMu.Unlock()
+	return keyset, nil
+}
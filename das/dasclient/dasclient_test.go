@@ -0,0 +1,110 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package dasclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/das"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
+
+func TestClient(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	storageService := das.NewMemoryBackedStorageService(ctx)
+	keyDir := t.TempDir()
+	pubKey, _, err := das.GenerateAndStoreKeys(keyDir)
+	Require(t, err)
+	privKey, err := (&das.KeyConfig{KeyDir: keyDir}).BLSPrivKey()
+	Require(t, err)
+	writer, err := das.NewSignAfterStoreDASWriterWithSeqInboxCaller(privKey, nil, storageService, "")
+	Require(t, err)
+	// Store the keyset the certificates are signed with, for the client to
+	// verify them against.
+	keyset := &arbstate.DataAvailabilityKeyset{AssumedHonest: 1, PubKeys: []blsSignatures.PublicKey{*pubKey}}
+	var keysetBuf bytes.Buffer
+	Require(t, keyset.Serialize(&keysetBuf))
+	Require(t, storageService.Put(ctx, keysetBuf.Bytes(), uint64(time.Now().Add(24*time.Hour).Unix())))
+
+	rpcListener, err := net.Listen("tcp", "localhost:0")
+	Require(t, err)
+	rpcServer, err := das.StartDASRPCServerOnListener(ctx, rpcListener, genericconf.HTTPServerTimeoutConfigDefault, nil, das.DefaultStoreBoundsConfig, storageService, writer, storageService)
+	Require(t, err)
+	defer func() {
+		Require(t, rpcServer.Shutdown(ctx))
+	}()
+	restListener, err := net.Listen("tcp", "localhost:0")
+	Require(t, err)
+	restServer, err := das.NewRestfulDasServerOnListener(restListener, genericconf.HTTPServerTimeoutConfigDefault, das.DefaultRestfulCORSConfig, nil, storageService, storageService, nil)
+	Require(t, err)
+	defer func() {
+		Require(t, restServer.Shutdown())
+	}()
+
+	// Nothing listens on the first endpoints, so the client falls back.
+	deadListener, err := net.Listen("tcp", "localhost:0")
+	Require(t, err)
+	deadURL := "http://" + deadListener.Addr().String()
+	Require(t, deadListener.Close())
+
+	config := DefaultConfig
+	config.StoreURLs = []string{deadURL, "http://" + rpcListener.Addr().String()}
+	config.RetrieveURLs = []string{deadURL, "http://" + restListener.Addr().String()}
+	config.Retries = 1
+	config.Backoff = 10 * time.Millisecond
+	client, err := New(ctx, config)
+	Require(t, err)
+
+	message := testhelpers.RandomizeSlice(make([]byte, 1000))
+	cert, err := client.Store(ctx, message, uint64(time.Now().Add(time.Hour).Unix()), nil)
+	Require(t, err)
+	retrieved, err := client.Retrieve(ctx, cert.DataHash)
+	Require(t, err)
+	if !bytes.Equal(retrieved, message) {
+		Fail(t, "retrieved data doesn't match the stored message")
+	}
+	retrievedKeyset, err := client.KeysetFromHash(ctx, cert.KeysetHash)
+	Require(t, err)
+	if len(retrievedKeyset.PubKeys) != 1 {
+		Fail(t, "unexpected keyset", retrievedKeyset)
+	}
+
+	if _, err := client.Retrieve(ctx, common.Hash{1}); err == nil {
+		Fail(t, "expected retrieving unknown data to fail")
+	}
+
+	config = DefaultConfig
+	config.StoreURLs = []string{deadURL}
+	if _, err := New(ctx, config); err == nil {
+		Fail(t, "expected verifying signatures without a retrieve URL to be invalid")
+	}
+	config.VerifySignatures = false
+	client, err = New(ctx, config)
+	Require(t, err)
+	if _, err := client.Retrieve(ctx, cert.DataHash); !errors.Is(err, ErrNoRetrieveURLs) {
+		Fail(t, "expected no retrieve URLs error", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	badger "github.com/dgraph-io/badger/v3"
@@ -22,21 +23,42 @@ import (
 type LocalDBStorageConfig struct {
 	Enable                 bool   `koanf:"enable"`
 	DataDir                string `koanf:"data-dir"`
+	DBEngine               string `koanf:"db-engine"`
 	DiscardAfterTimeout    bool   `koanf:"discard-after-timeout"`
 	SyncFromStorageService bool   `koanf:"sync-from-storage-service"`
 	SyncToStorageService   bool   `koanf:"sync-to-storage-service"`
 }
 
-var DefaultLocalDBStorageConfig = LocalDBStorageConfig{}
+var DefaultLocalDBStorageConfig = LocalDBStorageConfig{
+	DBEngine: "badger",
+}
 
 func LocalDBStorageConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Bool(prefix+".enable", DefaultLocalDBStorageConfig.Enable, "enable storage/retrieval of sequencer batch data from a database on the local filesystem")
 	f.String(prefix+".data-dir", DefaultLocalDBStorageConfig.DataDir, "directory in which to store the database")
+	f.String(prefix+".db-engine", DefaultLocalDBStorageConfig.DBEngine, "database engine to use ('badger', 'leveldb', 'pebble' or 'bolt')")
 	f.Bool(prefix+".discard-after-timeout", DefaultLocalDBStorageConfig.DiscardAfterTimeout, "discard data after its expiry timeout")
 	f.Bool(prefix+".sync-from-storage-service", DefaultLocalDBStorageConfig.SyncFromStorageService, "enable db storage to be used as a source for regular sync storage")
 	f.Bool(prefix+".sync-to-storage-service", DefaultLocalDBStorageConfig.SyncToStorageService, "enable db storage to be used as a sink for regular sync storage")
 }
 
+// NewDBStorageServiceForEngine opens the local database using the engine
+// selected in the config.
+func NewDBStorageServiceForEngine(ctx context.Context, config LocalDBStorageConfig) (StorageService, error) {
+	switch config.DBEngine {
+	case "badger", "":
+		return NewDBStorageService(ctx, config.DataDir, config.DiscardAfterTimeout)
+	case "leveldb":
+		return NewLevelDBStorageService(ctx, config.DataDir, config.DiscardAfterTimeout)
+	case "pebble":
+		return NewPebbleStorageService(ctx, config.DataDir, config.DiscardAfterTimeout)
+	case "bolt":
+		return NewBoltDBStorageService(ctx, config.DataDir, config.DiscardAfterTimeout)
+	default:
+		return nil, fmt.Errorf(`invalid local-db-storage.db-engine choice: %q, allowed "badger", "leveldb", "pebble" or "bolt"`, config.DBEngine)
+	}
+}
+
 type DBStorageService struct {
 	db                  *badger.DB
 	discardAfterTimeout bool
@@ -161,3 +183,29 @@ func (dbs *DBStorageService) HealthCheck(ctx context.Context) error {
 	}
 	return nil
 }
+
+// StorageUsage counts the keys in the database, which include the expiration
+// times and iteration order recorded if it's synced from, and its size on
+// disk.
+func (dbs *DBStorageService) StorageUsage(ctx context.Context) (*StorageUsage, error) {
+	usage := &StorageUsage{}
+	err := dbs.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			usage.Entries++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	lsm, vlog := dbs.db.Size()
+	usage.Bytes = uint64(lsm + vlog)
+	return usage, nil
+}
@@ -0,0 +1,312 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/containers"
+	"github.com/offchainlabs/nitro/util/pretty"
+
+	flag "github.com/spf13/pflag"
+)
+
+type DiskCacheConfig struct {
+	Enable   bool   `koanf:"enable"`
+	DataDir  string `koanf:"data-dir"`
+	Capacity int    `koanf:"capacity"`
+	MaxSize  uint64 `koanf:"max-size"`
+}
+
+var DefaultDiskCacheConfig = DiskCacheConfig{
+	Capacity: 100000,
+	MaxSize:  10 * 1024 * 1024 * 1024,
+}
+
+func DiskCacheConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultDiskCacheConfig.Enable, "enable an on-disk LRU cache of sequencer batches that is kept across restarts")
+	f.String(prefix+".data-dir", DefaultDiskCacheConfig.DataDir, "directory in which to keep the disk cache")
+	f.Int(prefix+".capacity", DefaultDiskCacheConfig.Capacity, "maximum number of batches in the disk cache")
+	f.Uint64(prefix+".max-size", DefaultDiskCacheConfig.MaxSize, "maximum total size in bytes of the disk cache (0 for no limit)")
+}
+
+// Each cached batch is a file named by its hash, holding the big-endian data
+// timeout followed by the data. The LRU order is saved to the index file on
+// Sync and Close, and any files missing from it are treated as the oldest
+// entries when the cache is reopened.
+const (
+	diskCacheTimeoutLength = 8
+	diskCacheIndexName     = ".das-cache-index"
+	diskCacheTmpSuffix     = ".tmp"
+)
+
+// DiskCacheStorageService keeps the most recently used data in files in a
+// directory in front of the wrapped StorageService, so a restarted daserver
+// still serves hot data without going back to the wrapped StorageService.
+type DiskCacheStorageService struct {
+	baseStorageService StorageService
+	config             DiskCacheConfig
+	metrics            *cacheMetrics
+
+	mutex   sync.Mutex
+	entries *containers.LruCache[common.Hash, uint64]
+	size    uint64
+}
+
+func NewDiskCacheStorageService(config DiskCacheConfig, baseStorageService StorageService) (*DiskCacheStorageService, error) {
+	if config.DataDir == "" {
+		return nil, errors.New("disk-cache.data-dir must be set")
+	}
+	if err := os.MkdirAll(config.DataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("couldn't create disk cache directory: %w", err)
+	}
+	c := &DiskCacheStorageService{
+		baseStorageService: baseStorageService,
+		config:             config,
+		metrics:            newCacheMetrics("disk"),
+	}
+	c.entries = containers.NewLruCacheWithOnEvict(config.Capacity, func(key common.Hash, size uint64) {
+		c.size -= size
+		if err := os.Remove(c.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Warn("das.DiskCacheStorageService failed to remove evicted batch", "key", pretty.PrettyHash(key), "err", err)
+		}
+	})
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *DiskCacheStorageService) path(key common.Hash) string {
+	return filepath.Join(c.config.DataDir, common.Bytes2Hex(key.Bytes()))
+}
+
+// load reopens the cache from the files in the data directory, in the order
+// saved in the index.
+func (c *DiskCacheStorageService) load() error {
+	dirEntries, err := os.ReadDir(c.config.DataDir)
+	if err != nil {
+		return err
+	}
+	type cachedFile struct {
+		key     common.Hash
+		size    uint64
+		modTime time.Time
+	}
+	files := make(map[common.Hash]cachedFile)
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if strings.HasSuffix(name, diskCacheTmpSuffix) {
+			_ = os.Remove(filepath.Join(c.config.DataDir, name))
+			continue
+		}
+		if dirEntry.IsDir() || len(name) != 2*common.HashLength {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			return err
+		}
+		key := common.HexToHash(name)
+		files[key] = cachedFile{key, uint64(info.Size()), info.ModTime()}
+	}
+
+	var indexed []common.Hash
+	index, err := os.Open(filepath.Join(c.config.DataDir, diskCacheIndexName))
+	if err == nil {
+		scanner := bufio.NewScanner(index)
+		for scanner.Scan() {
+			key := common.HexToHash(scanner.Text())
+			if _, ok := files[key]; ok {
+				indexed = append(indexed, key)
+			}
+		}
+		err = scanner.Err()
+		index.Close()
+		if err != nil {
+			return fmt.Errorf("error reading disk cache index: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var unindexed []cachedFile
+	for _, key := range indexed {
+		delete(files, key)
+	}
+	for _, file := range files {
+		unindexed = append(unindexed, file)
+	}
+	sort.Slice(unindexed, func(i, j int) bool {
+		return unindexed[i].modTime.Before(unindexed[j].modTime)
+	})
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, file := range unindexed {
+		c.addEntry(file.key, file.size)
+	}
+	for _, key := range indexed {
+		info, err := os.Stat(c.path(key))
+		if err != nil {
+			continue
+		}
+		c.addEntry(key, uint64(info.Size()))
+	}
+	log.Info("das.DiskCacheStorageService loaded disk cache", "dir", c.config.DataDir, "entries", c.entries.Len(), "size", c.size)
+	return nil
+}
+
+// addEntry must be called with the mutex held.
+func (c *DiskCacheStorageService) addEntry(key common.Hash, size uint64) {
+	if c.config.Capacity <= 0 || (c.config.MaxSize > 0 && size > c.config.MaxSize) {
+		_ = os.Remove(c.path(key))
+		return
+	}
+	if c.entries.Add(key, size) {
+		c.metrics.evicted()
+	}
+	c.size += size
+	for c.config.MaxSize > 0 && c.size > c.config.MaxSize {
+		c.entries.RemoveOldest()
+		c.metrics.evicted()
+	}
+}
+
+func (c *DiskCacheStorageService) add(key common.Hash, value []byte, timeout uint64) {
+	if timeout <= uint64(time.Now().Unix()) {
+		return
+	}
+	c.mutex.Lock()
+	if c.entries.Contains(key) {
+		c.entries.Get(key)
+		c.mutex.Unlock()
+		return
+	}
+	c.mutex.Unlock()
+
+	contents := make([]byte, diskCacheTimeoutLength, diskCacheTimeoutLength+len(value))
+	binary.BigEndian.PutUint64(contents, timeout)
+	contents = append(contents, value...)
+	if err := writeFileAtomically(c.path(key), contents); err != nil {
+		log.Warn("das.DiskCacheStorageService failed to cache batch", "key", pretty.PrettyHash(key), "err", err)
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.entries.Contains(key) {
+		return
+	}
+	c.addEntry(key, uint64(len(contents)))
+}
+
+func (c *DiskCacheStorageService) remove(key common.Hash) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries.Remove(key)
+}
+
+func writeFileAtomically(path string, contents []byte) error {
+	tmpPath := path + diskCacheTmpSuffix
+	if err := os.WriteFile(tmpPath, contents, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func (c *DiskCacheStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.DiskCacheStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", c)
+
+	c.mutex.Lock()
+	_, ok := c.entries.Get(key)
+	c.mutex.Unlock()
+	if ok {
+		contents, err := os.ReadFile(c.path(key))
+		if err == nil && len(contents) >= diskCacheTimeoutLength {
+			timeout := binary.BigEndian.Uint64(contents)
+			value := contents[diskCacheTimeoutLength:]
+			if timeout > uint64(time.Now().Unix()) && dastree.ValidHash(key, value) {
+				c.metrics.hit()
+				return value, nil
+			}
+		}
+		c.remove(key)
+	}
+	c.metrics.miss()
+
+	value, err := c.baseStorageService.GetByHash(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if dastree.ValidHash(key, value) {
+		// The data's timeout isn't known, so it's kept until it is evicted.
+		c.add(key, value, math.MaxUint64)
+	}
+	return value, nil
+}
+
+func (c *DiskCacheStorageService) Put(ctx context.Context, value []byte, timeout uint64) error {
+	logPut("das.DiskCacheStorageService.Put", value, timeout, c)
+	if err := c.baseStorageService.Put(ctx, value, timeout); err != nil {
+		return err
+	}
+	c.add(dastree.Hash(value), value, timeout)
+	return nil
+}
+
+// saveIndex writes the LRU order of the cache to the index file.
+func (c *DiskCacheStorageService) saveIndex() error {
+	c.mutex.Lock()
+	keys := c.entries.Keys()
+	c.mutex.Unlock()
+	var builder strings.Builder
+	for _, key := range keys {
+		builder.WriteString(common.Bytes2Hex(key.Bytes()))
+		builder.WriteByte('\n')
+	}
+	return writeFileAtomically(filepath.Join(c.config.DataDir, diskCacheIndexName), []byte(builder.String()))
+}
+
+func (c *DiskCacheStorageService) Sync(ctx context.Context) error {
+	if err := c.saveIndex(); err != nil {
+		return err
+	}
+	return c.baseStorageService.Sync(ctx)
+}
+
+func (c *DiskCacheStorageService) Close(ctx context.Context) error {
+	if err := c.saveIndex(); err != nil {
+		log.Error("das.DiskCacheStorageService failed to save disk cache index", "err", err)
+	}
+	return c.baseStorageService.Close(ctx)
+}
+
+func (c *DiskCacheStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	return c.baseStorageService.ExpirationPolicy(ctx)
+}
+
+func (c *DiskCacheStorageService) String() string {
+	return fmt.Sprintf("DiskCacheStorageService(%+v, %v)", c.config, c.baseStorageService)
+}
+
+func (c *DiskCacheStorageService) HealthCheck(ctx context.Context) error {
+	return c.baseStorageService.HealthCheck(ctx)
+}
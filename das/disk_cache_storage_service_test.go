@@ -0,0 +1,63 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+func TestDiskCacheStorageService(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	config := DiskCacheConfig{
+		Enable:   true,
+		DataDir:  t.TempDir(),
+		Capacity: 2,
+	}
+
+	cache, err := NewDiskCacheStorageService(config, NewMemoryBackedStorageService(ctx))
+	Require(t, err)
+	val1 := []byte("The first value")
+	val2 := []byte("The second value")
+	Require(t, cache.Put(ctx, val1, timeout))
+	Require(t, cache.Put(ctx, val2, timeout))
+	Require(t, cache.Close(ctx))
+
+	// A restarted cache serves the data without the base storage.
+	cache, err = NewDiskCacheStorageService(config, NewMemoryBackedStorageService(ctx))
+	Require(t, err)
+	for _, val := range [][]byte{val1, val2} {
+		got, err := cache.GetByHash(ctx, dastree.Hash(val))
+		Require(t, err)
+		if !bytes.Equal(got, val) {
+			t.Fatal(got, val)
+		}
+	}
+
+	// val1 was read before val2, so it is evicted first.
+	val3 := []byte("The third value")
+	Require(t, cache.Put(ctx, val3, timeout))
+	_, err = cache.GetByHash(ctx, dastree.Hash(val1))
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(cache.path(dastree.Hash(val1))); !errors.Is(err, os.ErrNotExist) {
+		Fail(t, "evicted batch wasn't removed from disk", err)
+	}
+
+	// Expired data isn't served from the cache.
+	val4 := []byte("The fourth value")
+	Require(t, cache.Put(ctx, val4, uint64(time.Now().Add(-time.Hour).Unix())))
+	if cache.entries.Contains(dastree.Hash(val4)) {
+		Fail(t, "expired batch was cached")
+	}
+	Require(t, cache.Close(ctx))
+}
@@ -0,0 +1,181 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// URLs with this prefix before their scheme, eg
+// srv+https://_das._tcp.example.com/path, name a DNS SRV record whose targets
+// are the hosts and ports of the endpoints.
+const dnsSRVSchemePrefix = "srv+"
+
+// lookupSRV is replaced in tests.
+var lookupSRV = net.DefaultResolver.LookupSRV
+
+func isDNSSRVURL(rawURL string) bool {
+	return strings.HasPrefix(rawURL, dnsSRVSchemePrefix)
+}
+
+// resolveDNSSRVURL returns a URL for each target of the SRV record named by
+// the host of rawURL, in order of preference: ascending priority, then
+// descending weight. Ties are broken by target and port rather than
+// randomly, so resolving unchanged records gives the same URLs.
+func resolveDNSSRVURL(ctx context.Context, rawURL string) ([]string, error) {
+	u, err := url.Parse(strings.TrimPrefix(rawURL, dnsSRVSchemePrefix))
+	if err != nil {
+		return nil, err
+	}
+	if u.Port() != "" {
+		return nil, fmt.Errorf("DNS SRV URL %s can't have a port, the port is taken from the SRV record", rawURL)
+	}
+	_, records, err := lookupSRV(ctx, "", "", u.Hostname())
+	if err != nil {
+		return nil, fmt.Errorf("error resolving DNS SRV URL %s: %w", rawURL, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("DNS SRV URL %s has no targets", rawURL)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		if a.Weight != b.Weight {
+			return a.Weight > b.Weight
+		}
+		if a.Target != b.Target {
+			return a.Target < b.Target
+		}
+		return a.Port < b.Port
+	})
+	urls := make([]string, 0, len(records))
+	for _, record := range records {
+		target := *u
+		target.Host = net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port)))
+		urls = append(urls, target.String())
+	}
+	return urls, nil
+}
+
+// resolveDNSSRVURLs returns the URLs that aren't DNS SRV URLs unchanged, and
+// the URLs of all the targets of those that are.
+func resolveDNSSRVURLs(ctx context.Context, urls []string) ([]string, error) {
+	var resolved []string
+	for _, rawURL := range urls {
+		if !isDNSSRVURL(rawURL) {
+			resolved = append(resolved, rawURL)
+			continue
+		}
+		targets, err := resolveDNSSRVURL(ctx, rawURL)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, targets...)
+	}
+	return resolved, nil
+}
+
+func hasDNSSRVURL(urls []string) bool {
+	for _, rawURL := range urls {
+		if isDNSSRVURL(rawURL) {
+			return true
+		}
+	}
+	return false
+}
+
+func backendsHaveDNSSRVURL(backends string) bool {
+	var cs []BackendConfig
+	if err := json.Unmarshal([]byte(backends), &cs); err != nil {
+		return false
+	}
+	for _, b := range cs {
+		if isDNSSRVURL(b.URL) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveDNSSRVBackends replaces each backend's DNS SRV URL with the URL of
+// its most preferred target, since each backend is a single committee
+// member. Backends without DNS SRV URLs are returned unchanged.
+func resolveDNSSRVBackends(ctx context.Context, backends string) (string, error) {
+	if !backendsHaveDNSSRVURL(backends) {
+		return backends, nil
+	}
+	var cs []BackendConfig
+	if err := json.Unmarshal([]byte(backends), &cs); err != nil {
+		return "", err
+	}
+	for i := range cs {
+		if !isDNSSRVURL(cs[i].URL) {
+			continue
+		}
+		targets, err := resolveDNSSRVURL(ctx, cs[i].URL)
+		if err != nil {
+			return "", err
+		}
+		cs[i].URL = targets[0]
+	}
+	resolved, err := json.Marshal(cs)
+	if err != nil {
+		return "", err
+	}
+	return string(resolved), nil
+}
+
+// StartDNSSRVResolveDaemon sends the URLs of the targets of the DNS SRV URLs
+// among urls immediately and then periodically, like
+// StartRestfulServerListFetchDaemon does for the online URL list.
+func StartDNSSRVResolveDaemon(ctx context.Context, urls []string, updatePeriod time.Duration) <-chan []string {
+	updateChan := make(chan []string)
+	var srvURLs []string
+	for _, rawURL := range urls {
+		if isDNSSRVURL(rawURL) {
+			srvURLs = append(srvURLs, rawURL)
+		}
+	}
+	if len(srvURLs) == 0 {
+		return updateChan
+	}
+	if updatePeriod == 0 {
+		panic("DNSSRVResolveDaemon started with zero updatePeriod")
+	}
+	go func() {
+		defer close(updateChan)
+		ticker := time.NewTicker(updatePeriod)
+		defer ticker.Stop()
+		for {
+			resolved, err := resolveDNSSRVURLs(ctx, srvURLs)
+			if err != nil {
+				log.Warn(fmt.Sprintf("Couldn't resolve DNS SRV URLs, will retry in %s", updatePeriod), "err", err)
+			} else {
+				select {
+				case updateChan <- resolved:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return updateChan
+}
@@ -0,0 +1,82 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+)
+
+func withLookupSRV(t *testing.T, records map[string][]*net.SRV) {
+	previous := lookupSRV
+	lookupSRV = func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		srvs, ok := records[name]
+		if !ok {
+			return "", nil, fmt.Errorf("no such host %s", name)
+		}
+		// Return copies, as the caller sorts them.
+		return name, append([]*net.SRV(nil), srvs...), nil
+	}
+	t.Cleanup(func() { lookupSRV = previous })
+}
+
+func TestResolveDNSSRVURLs(t *testing.T) {
+	withLookupSRV(t, map[string][]*net.SRV{
+		"_das._tcp.example.com": {
+			{Target: "backup.example.com.", Port: 9877, Priority: 20, Weight: 10},
+			{Target: "b.example.com.", Port: 9877, Priority: 10, Weight: 5},
+			{Target: "a.example.com.", Port: 9878, Priority: 10, Weight: 5},
+			{Target: "c.example.com.", Port: 9877, Priority: 10, Weight: 50},
+		},
+	})
+	ctx := context.Background()
+
+	urls, err := resolveDNSSRVURLs(ctx, []string{"http://static:9877", "srv+https://_das._tcp.example.com/prefix"})
+	Require(t, err)
+	expected := []string{
+		"http://static:9877",
+		"https://c.example.com:9877/prefix",
+		"https://a.example.com:9878/prefix",
+		"https://b.example.com:9877/prefix",
+		"https://backup.example.com:9877/prefix",
+	}
+	if fmt.Sprint(urls) != fmt.Sprint(expected) {
+		Fail(t, "unexpected resolved URLs", urls, expected)
+	}
+
+	if _, err := resolveDNSSRVURLs(ctx, []string{"srv+https://_das._tcp.example.com:443"}); err == nil {
+		Fail(t, "DNS SRV URL with a port should be rejected")
+	}
+	if _, err := resolveDNSSRVURLs(ctx, []string{"srv+https://_das._tcp.missing.com"}); err == nil {
+		Fail(t, "unresolvable DNS SRV URL should fail")
+	}
+}
+
+func TestResolveDNSSRVBackends(t *testing.T) {
+	withLookupSRV(t, map[string][]*net.SRV{
+		"_das._tcp.member.example.com": {
+			{Target: "standby.example.com.", Port: 9876, Priority: 20},
+			{Target: "primary.example.com.", Port: 9876, Priority: 10},
+		},
+	})
+	ctx := context.Background()
+
+	static := `[{"url":"http://static:9876","pubkey":"a","signermask":1}]`
+	resolved, err := resolveDNSSRVBackends(ctx, static)
+	Require(t, err)
+	if resolved != static {
+		Fail(t, "backends without DNS SRV URLs should be unchanged", resolved)
+	}
+
+	resolved, err = resolveDNSSRVBackends(ctx, `[{"url":"http://static:9876","pubkey":"a","signermask":1},{"url":"srv+http://_das._tcp.member.example.com","pubkey":"b","signermask":2,"retries":3}]`)
+	Require(t, err)
+	var backends []BackendConfig
+	Require(t, json.Unmarshal([]byte(resolved), &backends))
+	if backends[0].URL != "http://static:9876" || backends[1].URL != "http://primary.example.com:9876" || backends[1].Retries != 3 {
+		Fail(t, "DNS SRV backend should resolve to its most preferred target", backends)
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsConfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/pretty"
+
+	flag "github.com/spf13/pflag"
+)
+
+// Encrypted data is stored as encryptedDataVersion, then the nonce, then the
+// AES-GCM ciphertext.
+const encryptedDataVersion = 1
+
+type EncryptionConfig struct {
+	Enable          bool   `koanf:"enable"`
+	KeyFile         string `koanf:"key-file"`
+	WrappedKeyFile  string `koanf:"wrapped-key-file"`
+	KMSKeyId        string `koanf:"kms-key-id"`
+	KMSRegion       string `koanf:"kms-region"`
+	VaultAddress    string `koanf:"vault-address"`
+	VaultToken      string `koanf:"vault-token"`
+	VaultTransitKey string `koanf:"vault-transit-key"`
+}
+
+var DefaultEncryptionConfig = EncryptionConfig{}
+
+func EncryptionConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultEncryptionConfig.Enable, "AES-GCM encrypt data before storing it in storage backends that support it; encrypted data is stored without an expiry timeout")
+	f.String(prefix+".key-file", DefaultEncryptionConfig.KeyFile, "file containing the hex encoded 32 byte data key")
+	f.String(prefix+".wrapped-key-file", DefaultEncryptionConfig.WrappedKeyFile, "file containing the data key wrapped by AWS KMS (base64 encoded) or Vault transit (vault:v1:...), used if key-file isn't set")
+	f.String(prefix+".kms-key-id", DefaultEncryptionConfig.KMSKeyId, "AWS KMS key that wraps the data key")
+	f.String(prefix+".kms-region", DefaultEncryptionConfig.KMSRegion, "AWS KMS region")
+	f.String(prefix+".vault-address", DefaultEncryptionConfig.VaultAddress, "address of the Vault server whose transit engine wraps the data key, eg https://vault:8200")
+	f.String(prefix+".vault-token", DefaultEncryptionConfig.VaultToken, "Vault token (defaults to the VAULT_TOKEN environment variable)")
+	f.String(prefix+".vault-transit-key", DefaultEncryptionConfig.VaultTransitKey, "Vault transit key that wraps the data key")
+}
+
+// EncryptedStorageService encrypts data with a data key before storing it in
+// the wrapped StorageService, under the hash of the plaintext. The hash is used
+// as additional authenticated data, so ciphertexts can't be swapped between
+// keys.
+type EncryptedStorageService struct {
+	IterationCompatibleStorageService
+	aead cipher.AEAD
+}
+
+func NewEncryptedStorageService(storageService StorageService, aead cipher.AEAD) (*EncryptedStorageService, error) {
+	inner, ok := storageService.(IterationCompatibleStorageService)
+	if !ok {
+		return nil, fmt.Errorf("%v doesn't support storing data under an arbitrary key, so can't be encrypted", storageService)
+	}
+	if _, ok := storageService.(*IterableStorageService); ok {
+		return nil, fmt.Errorf("%v is a source for regular sync storage, so can't be encrypted", storageService)
+	}
+	return &EncryptedStorageService{
+		IterationCompatibleStorageService: inner,
+		aead:                              aead,
+	}, nil
+}
+
+// NewDataKeyAEAD loads the data key as configured, unwrapping it if necessary.
+func NewDataKeyAEAD(ctx context.Context, config EncryptionConfig) (cipher.AEAD, error) {
+	key, err := loadDataKey(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("data key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func loadDataKey(ctx context.Context, config EncryptionConfig) ([]byte, error) {
+	if config.KeyFile != "" {
+		contents, err := os.ReadFile(config.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return common.FromHex(strings.TrimSpace(string(contents))), nil
+	}
+	if config.WrappedKeyFile == "" {
+		return nil, errors.New("encryption.key-file or encryption.wrapped-key-file must be set")
+	}
+	contents, err := os.ReadFile(config.WrappedKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	wrapped := strings.TrimSpace(string(contents))
+	if config.VaultAddress != "" {
+		return unwrapDataKeyWithVault(ctx, config, wrapped)
+	}
+	if config.KMSKeyId != "" {
+		return unwrapDataKeyWithKMS(ctx, config, wrapped)
+	}
+	return nil, errors.New("encryption.kms-key-id or encryption.vault-address must be set to unwrap the data key")
+}
+
+func unwrapDataKeyWithKMS(ctx context.Context, config EncryptionConfig, wrapped string) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding wrapped data key: %w", err)
+	}
+	cfg, err := awsConfig.LoadDefaultConfig(ctx, awsConfig.WithRegion(config.KMSRegion))
+	if err != nil {
+		return nil, err
+	}
+	res, err := kms.NewFromConfig(cfg).Decrypt(ctx, &kms.DecryptInput{
+		CiphertextBlob: ciphertext,
+		KeyId:          aws.String(config.KMSKeyId),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error unwrapping data key with KMS: %w", err)
+	}
+	return res.Plaintext, nil
+}
+
+func unwrapDataKeyWithVault(ctx context.Context, config EncryptionConfig, wrapped string) ([]byte, error) {
+	if config.VaultTransitKey == "" {
+		return nil, errors.New("encryption.vault-transit-key must be set")
+	}
+	token := config.VaultToken
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	body, err := json.Marshal(map[string]string{"ciphertext": wrapped})
+	if err != nil {
+		return nil, err
+	}
+	url := strings.TrimSuffix(config.VaultAddress, "/") + "/v1/transit/decrypt/" + config.VaultTransitKey
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	req.Header.Set("Content-Type", "application/json")
+	client := &http.Client{Timeout: 30 * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error with status %d returned by Vault unwrapping data key: %s", res.StatusCode, http.StatusText(res.StatusCode))
+	}
+	var decrypted struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&decrypted); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(decrypted.Data.Plaintext)
+}
+
+func (e *EncryptedStorageService) encrypt(key common.Hash, plaintext []byte) ([]byte, error) {
+	nonceSize := e.aead.NonceSize()
+	out := make([]byte, 1+nonceSize, 1+nonceSize+len(plaintext)+e.aead.Overhead())
+	out[0] = encryptedDataVersion
+	nonce := out[1 : 1+nonceSize]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return e.aead.Seal(out, nonce, plaintext, key.Bytes()), nil
+}
+
+func (e *EncryptedStorageService) decrypt(key common.Hash, data []byte) ([]byte, error) {
+	nonceSize := e.aead.NonceSize()
+	if len(data) < 1+nonceSize || data[0] != encryptedDataVersion {
+		return nil, errors.New("data isn't encrypted")
+	}
+	return e.aead.Open(nil, data[1:1+nonceSize], data[1+nonceSize:], key.Bytes())
+}
+
+func (e *EncryptedStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.EncryptedStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", e)
+	data, err := e.IterationCompatibleStorageService.GetByHash(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := e.decrypt(key, data)
+	if err != nil {
+		// Data stored before encryption was enabled is still served.
+		if dastree.ValidHash(key, data) {
+			return data, nil
+		}
+		return nil, fmt.Errorf("error decrypting data stored under %v: %w", key, err)
+	}
+	return plaintext, nil
+}
+
+func (e *EncryptedStorageService) Put(ctx context.Context, data []byte, timeout uint64) error {
+	logPut("das.EncryptedStorageService.Store", data, timeout, e)
+	return e.putKeyValue(ctx, dastree.Hash(data), data)
+}
+
+func (e *EncryptedStorageService) putKeyValue(ctx context.Context, key common.Hash, value []byte) error {
+	encrypted, err := e.encrypt(key, value)
+	if err != nil {
+		return err
+	}
+	return e.IterationCompatibleStorageService.putKeyValue(ctx, key, encrypted)
+}
+
+func (e *EncryptedStorageService) String() string {
+	return fmt.Sprintf("EncryptedStorageService(%v)", e.IterationCompatibleStorageService)
+}
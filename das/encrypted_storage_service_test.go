@@ -0,0 +1,63 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+func TestEncryptedStorageService(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	keyFile := filepath.Join(t.TempDir(), "data-key")
+	Require(t, os.WriteFile(keyFile, []byte(hexutil.Encode(bytes.Repeat([]byte{0x42}, 32))), 0o600))
+	aead, err := NewDataKeyAEAD(ctx, EncryptionConfig{KeyFile: keyFile})
+	Require(t, err)
+
+	inner := NewMemoryBackedStorageService(ctx)
+	encryptedService, err := NewEncryptedStorageService(inner, aead)
+	Require(t, err)
+
+	val1 := []byte("The first value")
+	key1 := dastree.Hash(val1)
+	Require(t, encryptedService.Put(ctx, val1, timeout))
+	stored, err := inner.GetByHash(ctx, key1)
+	Require(t, err)
+	if bytes.Contains(stored, val1) {
+		Fail(t, "data was stored in plaintext")
+	}
+	val, err := encryptedService.GetByHash(ctx, key1)
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		Fail(t, val, val1)
+	}
+
+	// Ciphertext stored under another key fails authentication.
+	val2 := []byte("The second value")
+	key2 := dastree.Hash(val2)
+	Require(t, ConvertStorageServiceToIterationCompatibleStorageService(inner).putKeyValue(ctx, key2, stored))
+	if _, err := encryptedService.GetByHash(ctx, key2); err == nil {
+		Fail(t, "ciphertext moved to another key was decrypted")
+	}
+
+	// Compression can be layered on top of encryption.
+	compressedService, err := NewCompressedStorageService(encryptedService, DefaultCompressionConfig)
+	Require(t, err)
+	compressible := bytes.Repeat([]byte("calldata"), 1000)
+	Require(t, compressedService.Put(ctx, compressible, timeout))
+	val, err = compressedService.GetByHash(ctx, dastree.Hash(compressible))
+	Require(t, err)
+	if !bytes.Equal(val, compressible) {
+		Fail(t, "data doesn't match after compression and encryption")
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/offchainlabs/nitro/blsSignatures"
+)
+
+type EndpointAnnouncementConfig struct {
+	Enable  bool   `koanf:"enable"`
+	RPCURL  string `koanf:"rpc-url"`
+	RESTURL string `koanf:"rest-url"`
+}
+
+var DefaultEndpointAnnouncementConfig = EndpointAnnouncementConfig{
+	Enable:  false,
+	RPCURL:  "",
+	RESTURL: "",
+}
+
+func EndpointAnnouncementConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultEndpointAnnouncementConfig.Enable, "enable serving this committee member's public URLs signed with its BLS key at the REST server's /announcement path, so clients discovering them can check they weren't spoofed")
+	f.String(prefix+".rpc-url", DefaultEndpointAnnouncementConfig.RPCURL, "public URL of this committee member's RPC server, as clients discover it")
+	f.String(prefix+".rest-url", DefaultEndpointAnnouncementConfig.RESTURL, "public URL of this committee member's REST server, as clients discover it")
+}
+
+// EndpointAnnouncement is a committee member's statement, signed with its BLS
+// key, of the URLs it serves at. Discovery sources only pass on URLs, so
+// clients can check the URLs they discovered for a member against its
+// announcement to reject impostor endpoints.
+type EndpointAnnouncement struct {
+	PubKey    string `json:"pubKey"` // base64 encoded
+	RPCURL    string `json:"rpcUrl"`
+	RESTURL   string `json:"restUrl"`
+	Timestamp uint64 `json:"timestamp"`
+	Signature string `json:"signature"` // base64 encoded
+}
+
+var endpointAnnouncementDomain = []byte("Nitro DAS endpoint announcement")
+
+func (a *EndpointAnnouncement) signedMessage() []byte {
+	var buf bytes.Buffer
+	buf.Write(endpointAnnouncementDomain)
+	for _, url := range []string{a.RPCURL, a.RESTURL} {
+		_ = binary.Write(&buf, binary.BigEndian, uint64(len(url)))
+		buf.WriteString(url)
+	}
+	_ = binary.Write(&buf, binary.BigEndian, a.Timestamp)
+	return crypto.Keccak256(buf.Bytes())
+}
+
+func NewEndpointAnnouncement(rpcURL, restURL string, pubKey blsSignatures.PublicKey, privKey blsSignatures.PrivateKey) (*EndpointAnnouncement, error) {
+	signer := &localBLSSigner{privKey: privKey, pubKey: pubKey}
+	return NewEndpointAnnouncementWithSigner(context.Background(), rpcURL, restURL, signer)
+}
+
+func NewEndpointAnnouncementWithSigner(ctx context.Context, rpcURL, restURL string, signer BLSSigner) (*EndpointAnnouncement, error) {
+	a := &EndpointAnnouncement{
+		PubKey:    base64.StdEncoding.EncodeToString(blsSignatures.PublicKeyToBytes(signer.PublicKey())),
+		RPCURL:    rpcURL,
+		RESTURL:   restURL,
+		Timestamp: uint64(time.Now().Unix()),
+	}
+	sig, err := signer.Sign(ctx, a.signedMessage())
+	if err != nil {
+		return nil, err
+	}
+	a.Signature = base64.StdEncoding.EncodeToString(blsSignatures.SignatureToBytes(sig))
+	return a, nil
+}
+
+// Verify checks the announcement was signed by the member with pubKey, a
+// serialized BLS public key.
+func (a *EndpointAnnouncement) Verify(pubKey []byte) error {
+	announcedPubKey, err := base64.StdEncoding.DecodeString(a.PubKey)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(announcedPubKey, pubKey) {
+		return errors.New("announcement is for a different public key")
+	}
+	blsPubKey, err := blsSignatures.PublicKeyFromBytes(pubKey, false)
+	if err != nil {
+		return err
+	}
+	sigBytes, err := base64.StdEncoding.DecodeString(a.Signature)
+	if err != nil {
+		return err
+	}
+	sig, err := blsSignatures.SignatureFromBytes(sigBytes)
+	if err != nil {
+		return err
+	}
+	verified, err := blsSignatures.VerifySignature(sig, a.signedMessage(), blsPubKey)
+	if err != nil {
+		return err
+	}
+	if !verified {
+		return errors.New("invalid announcement signature")
+	}
+	return nil
+}
+
+// verifyMemberEndpoints fetches the announcement the member serves at its
+// REST URL and checks it is signed by the member and announces both of the
+// member's URLs.
+func verifyMemberEndpoints(ctx context.Context, member CommitteeMember) error {
+	if member.RESTURL == "" {
+		return errors.New("member has no REST URL to fetch its endpoint announcement from")
+	}
+	client, err := NewRestfulDasClientFromURL(member.RESTURL)
+	if err != nil {
+		return err
+	}
+	announcement, err := client.EndpointAnnouncement(ctx)
+	if err != nil {
+		return err
+	}
+	if err := announcement.Verify(member.PubKey); err != nil {
+		return err
+	}
+	if announcement.RPCURL != member.RPCURL || announcement.RESTURL != member.RESTURL {
+		return fmt.Errorf("member announced rpc url %q and rest url %q", announcement.RPCURL, announcement.RESTURL)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+)
+
+func TestEndpointAnnouncement(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pubKey, privKey, err := blsSignatures.GenerateKeys()
+	Require(t, err)
+	otherPubKey, _, err := blsSignatures.GenerateKeys()
+	Require(t, err)
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:0", LocalServerAddressForTest))
+	Require(t, err)
+	restURL := "http://" + listener.Addr().String()
+	rpcURL := "http://member.example.com:9876"
+
+	announcement, err := NewEndpointAnnouncement(rpcURL, restURL, pubKey, privKey)
+	Require(t, err)
+	Require(t, announcement.Verify(blsSignatures.PublicKeyToBytes(pubKey)))
+	if announcement.Verify(blsSignatures.PublicKeyToBytes(otherPubKey)) == nil {
+		Fail(t, "announcement should only verify against the member's public key")
+	}
+	tampered := *announcement
+	tampered.RPCURL = "http://impostor.example.com:9876"
+	if tampered.Verify(blsSignatures.PublicKeyToBytes(pubKey)) == nil {
+		Fail(t, "announcement with changed URLs should fail verification")
+	}
+
+	storage := NewMemoryBackedStorageService(ctx)
+	server, err := NewRestfulDasServerOnListener(listener, genericconf.HTTPServerTimeoutConfigDefault, DefaultRestfulCORSConfig, nil, storage, storage, &DaserverServices{Announcement: announcement})
+	Require(t, err)
+	defer func() {
+		Require(t, server.Shutdown())
+	}()
+
+	member := CommitteeMember{
+		PubKey:  blsSignatures.PublicKeyToBytes(pubKey),
+		RPCURL:  rpcURL,
+		RESTURL: restURL,
+	}
+	Require(t, verifyMemberEndpoints(ctx, member))
+
+	spoofed := member
+	spoofed.RPCURL = "http://impostor.example.com:9876"
+	if verifyMemberEndpoints(ctx, spoofed) == nil {
+		Fail(t, "URLs the member doesn't announce should fail verification")
+	}
+	impersonated := member
+	impersonated.PubKey = blsSignatures.PublicKeyToBytes(otherPubKey)
+	if verifyMemberEndpoints(ctx, impersonated) == nil {
+		Fail(t, "endpoint announcing another member's key should fail verification")
+	}
+}
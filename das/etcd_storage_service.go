@@ -0,0 +1,220 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"go.etcd.io/etcd/client/pkg/v3/transport"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/pretty"
+
+	flag "github.com/spf13/pflag"
+)
+
+// EtcdOperator is the subset of etcd operations used by EtcdStorageService, so
+// the client can be replaced in tests.
+type EtcdOperator interface {
+	// Put stores value under key. A zero ttl means the key never expires.
+	Put(ctx context.Context, key string, value []byte, ttlSeconds int64) error
+	// Get returns ErrNotFound if the key doesn't exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+	Status(ctx context.Context) error
+	Close() error
+}
+
+type EtcdStorageServiceConfig struct {
+	Enable                 bool          `koanf:"enable"`
+	Endpoints              []string      `koanf:"endpoints"`
+	Username               string        `koanf:"username"`
+	Password               string        `koanf:"password"`
+	KeyPrefix              string        `koanf:"key-prefix"`
+	DialTimeout            time.Duration `koanf:"dial-timeout"`
+	TLSCertFile            string        `koanf:"tls-cert-file"`
+	TLSKeyFile             string        `koanf:"tls-key-file"`
+	TLSCAFile              string        `koanf:"tls-ca-file"`
+	DiscardAfterTimeout    bool          `koanf:"discard-after-timeout"`
+	SyncFromStorageService bool          `koanf:"sync-from-storage-service"`
+	SyncToStorageService   bool          `koanf:"sync-to-storage-service"`
+}
+
+var DefaultEtcdStorageServiceConfig = EtcdStorageServiceConfig{
+	KeyPrefix:   "das/",
+	DialTimeout: 5 * time.Second,
+}
+
+func EtcdStorageConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultEtcdStorageServiceConfig.Enable, "enable storage/retrieval of sequencer batch data from an etcd cluster; batches must be smaller than the cluster's max request size")
+	f.StringSlice(prefix+".endpoints", DefaultEtcdStorageServiceConfig.Endpoints, "etcd endpoints, eg https://etcd-0:2379")
+	f.String(prefix+".username", DefaultEtcdStorageServiceConfig.Username, "etcd username")
+	f.String(prefix+".password", DefaultEtcdStorageServiceConfig.Password, "etcd password")
+	f.String(prefix+".key-prefix", DefaultEtcdStorageServiceConfig.KeyPrefix, "prefix to add to etcd keys")
+	f.Duration(prefix+".dial-timeout", DefaultEtcdStorageServiceConfig.DialTimeout, "timeout for connecting to etcd")
+	f.String(prefix+".tls-cert-file", DefaultEtcdStorageServiceConfig.TLSCertFile, "client TLS certificate file for etcd")
+	f.String(prefix+".tls-key-file", DefaultEtcdStorageServiceConfig.TLSKeyFile, "client TLS key file for etcd")
+	f.String(prefix+".tls-ca-file", DefaultEtcdStorageServiceConfig.TLSCAFile, "CA file used to verify the etcd server certificates")
+	f.Bool(prefix+".discard-after-timeout", DefaultEtcdStorageServiceConfig.DiscardAfterTimeout, "attach a lease to each key so etcd discards it after its expiry timeout")
+	f.Bool(prefix+".sync-from-storage-service", DefaultEtcdStorageServiceConfig.SyncFromStorageService, "enable etcd to be used as a source for regular sync storage")
+	f.Bool(prefix+".sync-to-storage-service", DefaultEtcdStorageServiceConfig.SyncToStorageService, "enable etcd to be used as a sink for regular sync storage")
+}
+
+type EtcdStorageService struct {
+	operator            EtcdOperator
+	keyPrefix           string
+	endpoints           []string
+	discardAfterTimeout bool
+}
+
+func NewEtcdStorageService(config EtcdStorageServiceConfig) (StorageService, error) {
+	if len(config.Endpoints) == 0 {
+		return nil, errors.New("etcd-storage.endpoints must be set")
+	}
+	var tlsConfig *tls.Config
+	if config.TLSCertFile != "" || config.TLSCAFile != "" {
+		tlsInfo := transport.TLSInfo{
+			CertFile:      config.TLSCertFile,
+			KeyFile:       config.TLSKeyFile,
+			TrustedCAFile: config.TLSCAFile,
+		}
+		var err error
+		tlsConfig, err = tlsInfo.ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("error loading etcd TLS config: %w", err)
+		}
+	}
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   config.Endpoints,
+		Username:    config.Username,
+		Password:    config.Password,
+		DialTimeout: config.DialTimeout,
+		TLS:         tlsConfig,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to etcd: %w", err)
+	}
+	return &EtcdStorageService{
+		operator:            &etcdClient{client: client},
+		keyPrefix:           config.KeyPrefix,
+		endpoints:           config.Endpoints,
+		discardAfterTimeout: config.DiscardAfterTimeout,
+	}, nil
+}
+
+type etcdClient struct {
+	client *clientv3.Client
+}
+
+func (c *etcdClient) Put(ctx context.Context, key string, value []byte, ttlSeconds int64) error {
+	var opts []clientv3.OpOption
+	if ttlSeconds > 0 {
+		lease, err := c.client.Grant(ctx, ttlSeconds)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, clientv3.WithLease(lease.ID))
+	}
+	_, err := c.client.Put(ctx, key, string(value), opts...)
+	return err
+}
+
+func (c *etcdClient) Get(ctx context.Context, key string) ([]byte, error) {
+	res, err := c.client.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Kvs) == 0 {
+		return nil, ErrNotFound
+	}
+	return res.Kvs[0].Value, nil
+}
+
+func (c *etcdClient) Status(ctx context.Context) error {
+	var errs []string
+	for _, endpoint := range c.client.Endpoints() {
+		_, err := c.client.Status(ctx, endpoint)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err.Error())
+	}
+	return fmt.Errorf("no etcd endpoint is healthy: %s", strings.Join(errs, "; "))
+}
+
+func (c *etcdClient) Close() error {
+	return c.client.Close()
+}
+
+func (es *EtcdStorageService) ttlSeconds(timeout uint64) int64 {
+	if !es.discardAfterTimeout || timeout > math.MaxInt64 {
+		return 0
+	}
+	ttl := int64(timeout) - time.Now().Unix()
+	if ttl < 1 {
+		// A zero TTL would keep the key forever, so expire it as soon as possible instead.
+		return 1
+	}
+	return ttl
+}
+
+func (es *EtcdStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.EtcdStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", es)
+
+	value, err := es.operator.Get(ctx, es.keyPrefix+EncodeStorageServiceKey(key))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		log.Error("das.EtcdStorageService.GetByHash", "err", err)
+	}
+	return value, err
+}
+
+func (es *EtcdStorageService) Put(ctx context.Context, value []byte, timeout uint64) error {
+	logPut("das.EtcdStorageService.Store", value, timeout, es)
+	err := es.operator.Put(ctx, es.keyPrefix+EncodeStorageServiceKey(dastree.Hash(value)), value, es.ttlSeconds(timeout))
+	if err != nil {
+		log.Error("das.EtcdStorageService.Store", "err", err)
+	}
+	return err
+}
+
+func (es *EtcdStorageService) putKeyValue(ctx context.Context, key common.Hash, value []byte) error {
+	err := es.operator.Put(ctx, es.keyPrefix+EncodeStorageServiceKey(key), value, 0)
+	if err != nil {
+		log.Error("das.EtcdStorageService.putKeyValue", "err", err)
+	}
+	return err
+}
+
+func (es *EtcdStorageService) Sync(ctx context.Context) error {
+	return nil
+}
+
+func (es *EtcdStorageService) Close(ctx context.Context) error {
+	return es.operator.Close()
+}
+
+func (es *EtcdStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	if es.discardAfterTimeout {
+		return arbstate.DiscardAfterDataTimeout, nil
+	}
+	return arbstate.KeepForever, nil
+}
+
+func (es *EtcdStorageService) String() string {
+	return fmt.Sprintf("EtcdStorageService(%s)", strings.Join(es.endpoints, ","))
+}
+
+func (es *EtcdStorageService) HealthCheck(ctx context.Context) error {
+	return es.operator.Status(ctx)
+}
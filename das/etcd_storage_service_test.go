@@ -0,0 +1,85 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+type mockEtcdOperator struct {
+	mutex sync.Mutex
+	kvs   map[string][]byte
+	ttls  map[string]int64
+}
+
+func (m *mockEtcdOperator) Put(ctx context.Context, key string, value []byte, ttlSeconds int64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.kvs[key] = append([]byte{}, value...)
+	m.ttls[key] = ttlSeconds
+	return nil
+}
+
+func (m *mockEtcdOperator) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	value, ok := m.kvs[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return value, nil
+}
+
+func (m *mockEtcdOperator) Status(ctx context.Context) error {
+	return nil
+}
+
+func (m *mockEtcdOperator) Close() error {
+	return nil
+}
+
+func TestEtcdStorageService(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	operator := &mockEtcdOperator{kvs: make(map[string][]byte), ttls: make(map[string]int64)}
+	etcdService := &EtcdStorageService{
+		operator:            operator,
+		keyPrefix:           "das/",
+		discardAfterTimeout: true,
+	}
+
+	val1 := []byte("The first value")
+	val1CorrectKey := dastree.Hash(val1)
+	val2IncorrectKey := dastree.Hash(append(val1, 0))
+
+	_, err := etcdService.GetByHash(ctx, val1CorrectKey)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	err = etcdService.Put(ctx, val1, timeout)
+	Require(t, err)
+
+	_, err = etcdService.GetByHash(ctx, val2IncorrectKey)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	val, err := etcdService.GetByHash(ctx, val1CorrectKey)
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		t.Fatal(val, val1)
+	}
+
+	ttl := operator.ttls["das/"+EncodeStorageServiceKey(val1CorrectKey)]
+	if ttl <= 0 || ttl > 3600 {
+		Fail(t, "unexpected lease TTL for stored data", ttl)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ExpirationInfo describes when stored data will be removed and where it is.
+type ExpirationInfo struct {
+	Hash common.Hash `json:"hash"`
+	// ExpirationTime is the timeout recorded when the data was stored, in unix
+	// seconds, if any storage service records timeouts (ie is synced from).
+	ExpirationTime uint64 `json:"expirationTime,omitempty"`
+	// Tier is the first storage tier that has the data, if tiered storage is
+	// enabled.
+	Tier             string `json:"tier,omitempty"`
+	ExpirationPolicy string `json:"expirationPolicy,omitempty"`
+}
+
+// ExpirationInfoReader looks up the ExpirationInfo of stored data, returning
+// ErrNotFound if it isn't stored.
+type ExpirationInfoReader interface {
+	ExpirationInfo(ctx context.Context, hash common.Hash) (*ExpirationInfo, error)
+}
+
+type storageExpirationInfo struct {
+	storage   StorageService
+	iterables []*IterableStorageService
+}
+
+// newStorageExpirationInfo looks up expiration info in the persistent storage
+// service and the iterable storage services, which record the timeouts.
+func newStorageExpirationInfo(storage StorageService, iterables []*IterableStorageService) *storageExpirationInfo {
+	return &storageExpirationInfo{storage, iterables}
+}
+
+func (s *storageExpirationInfo) ExpirationInfo(ctx context.Context, hash common.Hash) (*ExpirationInfo, error) {
+	info := &ExpirationInfo{Hash: hash}
+	if tiered, ok := s.storage.(*TieredStorageService); ok {
+		tier, err := tiered.tierWith(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		info.Tier = tier.String()
+	} else if _, err := s.storage.GetByHash(ctx, hash); err != nil {
+		return nil, err
+	}
+	for _, iterable := range s.iterables {
+		// Data stored before the storage service was synced from has no
+		// recorded timeout.
+		if expirationTime, err := iterable.GetExpirationTime(ctx, hash); err == nil {
+			info.ExpirationTime = expirationTime
+			break
+		}
+	}
+	policy, err := s.storage.ExpirationPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	info.ExpirationPolicy, err = policy.String()
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+func TestExpirationInfo(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fast := NewMemoryBackedStorageService(ctx)
+	localFile, err := NewLocalFileStorageService(t.TempDir(), false)
+	Require(t, err)
+	durable := NewIterableStorageService(ConvertStorageServiceToIterationCompatibleStorageService(localFile))
+	tiered, err := NewTieredStorageService([]StorageService{fast, durable}, []StorageService{durable}, false, 0)
+	Require(t, err)
+
+	data := []byte("Testing expiration info.")
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	Require(t, tiered.Put(ctx, data, timeout))
+
+	services := &DaserverServices{Expiration: newStorageExpirationInfo(tiered, []*IterableStorageService{durable})}
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:0", LocalServerAddressForTest))
+	Require(t, err)
+	server, err := NewRestfulDasServerOnListener(listener, genericconf.HTTPServerTimeoutConfigDefault, DefaultRestfulCORSConfig, nil, tiered, tiered, services)
+	Require(t, err)
+	defer func() {
+		Require(t, server.Shutdown())
+	}()
+	client, err := NewRestfulDasClientFromURL("http://" + listener.Addr().String())
+	Require(t, err)
+
+	info, err := client.ExpirationInfo(ctx, dastree.Hash(data))
+	Require(t, err)
+	if info.ExpirationTime != timeout || info.Tier != localFile.String() || info.ExpirationPolicy == "" {
+		Fail(t, "unexpected expiration info", info)
+	}
+
+	_, err = client.ExpirationInfo(ctx, dastree.Hash([]byte("absent data")))
+	if !errors.Is(err, ErrNotFound) {
+		Fail(t, "expected not found error", err)
+	}
+}
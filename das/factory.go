@@ -18,17 +18,31 @@ import (
 )
 
 // CreatePersistentStorageService creates any storage services that persist to files, database, cloud storage,
-// and group them together into a RedundantStorage instance if there is more than one.
+// and group them together into a RedundantStorage instance if there is more than one, or into a
+// TieredStorageService if tiered storage is enabled.
 func CreatePersistentStorageService(
 	ctx context.Context,
 	config *DataAvailabilityConfig,
 	syncFromStorageServices *[]*IterableStorageService,
 	syncToStorageServices *[]StorageService,
+) (StorageService, *LifecycleManager, error) {
+	return createPersistentStorageService(ctx, config, syncFromStorageServices, syncToStorageServices, nil)
+}
+
+// createPersistentStorageService also appends the backends to backends, if
+// not nil, so the admin API can report on each.
+func createPersistentStorageService(
+	ctx context.Context,
+	config *DataAvailabilityConfig,
+	syncFromStorageServices *[]*IterableStorageService,
+	syncToStorageServices *[]StorageService,
+	backends *[]StorageBackend,
 ) (StorageService, *LifecycleManager, error) {
 	storageServices := make([]StorageService, 0, 10)
+	storageServiceNames := make([]string, 0, 10)
 	var lifecycleManager LifecycleManager
 	if config.LocalDBStorage.Enable {
-		s, err := NewDBStorageService(ctx, config.LocalDBStorage.DataDir, config.LocalDBStorage.DiscardAfterTimeout)
+		s, err := NewDBStorageServiceForEngine(ctx, config.LocalDBStorage)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -42,10 +56,11 @@ func CreatePersistentStorageService(
 		}
 		lifecycleManager.Register(s)
 		storageServices = append(storageServices, s)
+		storageServiceNames = append(storageServiceNames, "local-db-storage")
 	}
 
 	if config.LocalFileStorage.Enable {
-		s, err := NewLocalFileStorageService(config.LocalFileStorage.DataDir)
+		s, err := NewLocalFileStorageService(config.LocalFileStorage.DataDir, config.LocalFileStorage.SharedFilesystem)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -59,6 +74,7 @@ func CreatePersistentStorageService(
 		}
 		lifecycleManager.Register(s)
 		storageServices = append(storageServices, s)
+		storageServiceNames = append(storageServiceNames, "local-file-storage")
 	}
 
 	if config.S3Storage.Enable {
@@ -76,6 +92,220 @@ func CreatePersistentStorageService(
 			*syncToStorageServices = append(*syncToStorageServices, s)
 		}
 		storageServices = append(storageServices, s)
+		storageServiceNames = append(storageServiceNames, "s3-storage")
+	}
+
+	if config.GoogleCloudStorage.Enable {
+		s, err := NewGoogleCloudStorageService(ctx, config.GoogleCloudStorage)
+		if err != nil {
+			return nil, nil, err
+		}
+		lifecycleManager.Register(s)
+		if config.GoogleCloudStorage.SyncFromStorageService {
+			iterableStorageService := NewIterableStorageService(ConvertStorageServiceToIterationCompatibleStorageService(s))
+			*syncFromStorageServices = append(*syncFromStorageServices, iterableStorageService)
+			s = iterableStorageService
+		}
+		if config.GoogleCloudStorage.SyncToStorageService {
+			*syncToStorageServices = append(*syncToStorageServices, s)
+		}
+		storageServices = append(storageServices, s)
+		storageServiceNames = append(storageServiceNames, "google-cloud-storage")
+	}
+
+	if config.AzureBlobStorage.Enable {
+		s, err := NewAzureBlobStorageService(config.AzureBlobStorage)
+		if err != nil {
+			return nil, nil, err
+		}
+		lifecycleManager.Register(s)
+		if config.AzureBlobStorage.SyncFromStorageService {
+			iterableStorageService := NewIterableStorageService(ConvertStorageServiceToIterationCompatibleStorageService(s))
+			*syncFromStorageServices = append(*syncFromStorageServices, iterableStorageService)
+			s = iterableStorageService
+		}
+		if config.AzureBlobStorage.SyncToStorageService {
+			*syncToStorageServices = append(*syncToStorageServices, s)
+		}
+		storageServices = append(storageServices, s)
+		storageServiceNames = append(storageServiceNames, "azure-blob-storage")
+	}
+
+	if config.CassandraStorage.Enable {
+		s, err := NewCassandraStorageService(config.CassandraStorage)
+		if err != nil {
+			return nil, nil, err
+		}
+		lifecycleManager.Register(s)
+		if config.CassandraStorage.SyncFromStorageService {
+			iterableStorageService := NewIterableStorageService(ConvertStorageServiceToIterationCompatibleStorageService(s))
+			*syncFromStorageServices = append(*syncFromStorageServices, iterableStorageService)
+			s = iterableStorageService
+		}
+		if config.CassandraStorage.SyncToStorageService {
+			*syncToStorageServices = append(*syncToStorageServices, s)
+		}
+		storageServices = append(storageServices, s)
+		storageServiceNames = append(storageServiceNames, "cassandra-storage")
+	}
+
+	if config.MongoStorage.Enable {
+		s, err := NewMongoStorageService(ctx, config.MongoStorage)
+		if err != nil {
+			return nil, nil, err
+		}
+		lifecycleManager.Register(s)
+		if config.MongoStorage.SyncFromStorageService {
+			iterableStorageService := NewIterableStorageService(ConvertStorageServiceToIterationCompatibleStorageService(s))
+			*syncFromStorageServices = append(*syncFromStorageServices, iterableStorageService)
+			s = iterableStorageService
+		}
+		if config.MongoStorage.SyncToStorageService {
+			*syncToStorageServices = append(*syncToStorageServices, s)
+		}
+		storageServices = append(storageServices, s)
+		storageServiceNames = append(storageServiceNames, "mongo-storage")
+	}
+
+	if config.EtcdStorage.Enable {
+		s, err := NewEtcdStorageService(config.EtcdStorage)
+		if err != nil {
+			return nil, nil, err
+		}
+		lifecycleManager.Register(s)
+		if config.EtcdStorage.SyncFromStorageService {
+			iterableStorageService := NewIterableStorageService(ConvertStorageServiceToIterationCompatibleStorageService(s))
+			*syncFromStorageServices = append(*syncFromStorageServices, iterableStorageService)
+			s = iterableStorageService
+		}
+		if config.EtcdStorage.SyncToStorageService {
+			*syncToStorageServices = append(*syncToStorageServices, s)
+		}
+		storageServices = append(storageServices, s)
+		storageServiceNames = append(storageServiceNames, "etcd-storage")
+	}
+
+	if config.MemoryStorage.Enable {
+		s := NewMemoryBackedStorageServiceWithMaxSize(ctx, config.MemoryStorage.MaxSize)
+		lifecycleManager.Register(s)
+		if config.MemoryStorage.SyncFromStorageService {
+			iterableStorageService := NewIterableStorageService(ConvertStorageServiceToIterationCompatibleStorageService(s))
+			*syncFromStorageServices = append(*syncFromStorageServices, iterableStorageService)
+			s = iterableStorageService
+		}
+		if config.MemoryStorage.SyncToStorageService {
+			*syncToStorageServices = append(*syncToStorageServices, s)
+		}
+		storageServices = append(storageServices, s)
+		storageServiceNames = append(storageServiceNames, "memory-storage")
+	}
+
+	if config.WebDAVStorage.Enable {
+		s, err := NewWebDAVStorageService(ctx, config.WebDAVStorage)
+		if err != nil {
+			return nil, nil, err
+		}
+		lifecycleManager.Register(s)
+		if config.WebDAVStorage.SyncFromStorageService {
+			iterableStorageService := NewIterableStorageService(ConvertStorageServiceToIterationCompatibleStorageService(s))
+			*syncFromStorageServices = append(*syncFromStorageServices, iterableStorageService)
+			s = iterableStorageService
+		}
+		if config.WebDAVStorage.SyncToStorageService {
+			*syncToStorageServices = append(*syncToStorageServices, s)
+		}
+		storageServices = append(storageServices, s)
+		storageServiceNames = append(storageServiceNames, "webdav-storage")
+	}
+
+	if config.SFTPStorage.Enable {
+		s, err := NewSFTPStorageService(config.SFTPStorage)
+		if err != nil {
+			return nil, nil, err
+		}
+		lifecycleManager.Register(s)
+		if config.SFTPStorage.SyncFromStorageService {
+			iterableStorageService := NewIterableStorageService(ConvertStorageServiceToIterationCompatibleStorageService(s))
+			*syncFromStorageServices = append(*syncFromStorageServices, iterableStorageService)
+			s = iterableStorageService
+		}
+		if config.SFTPStorage.SyncToStorageService {
+			*syncToStorageServices = append(*syncToStorageServices, s)
+		}
+		storageServices = append(storageServices, s)
+		storageServiceNames = append(storageServiceNames, "sftp-storage")
+	}
+
+	if config.PluginStorage.Enable {
+		s, err := NewPluginStorageService(ctx, config.PluginStorage)
+		if err != nil {
+			return nil, nil, err
+		}
+		lifecycleManager.Register(s)
+		if config.PluginStorage.SyncFromStorageService {
+			iterableStorageService := NewIterableStorageService(ConvertStorageServiceToIterationCompatibleStorageService(s))
+			*syncFromStorageServices = append(*syncFromStorageServices, iterableStorageService)
+			s = iterableStorageService
+		}
+		if config.PluginStorage.SyncToStorageService {
+			*syncToStorageServices = append(*syncToStorageServices, s)
+		}
+		storageServices = append(storageServices, s)
+		storageServiceNames = append(storageServiceNames, "plugin-storage")
+	}
+
+	if config.CephStorage.Enable {
+		s, err := NewCephStorageService(config.CephStorage)
+		if err != nil {
+			return nil, nil, err
+		}
+		lifecycleManager.Register(s)
+		if config.CephStorage.SyncFromStorageService {
+			iterableStorageService := NewIterableStorageService(ConvertStorageServiceToIterationCompatibleStorageService(s))
+			*syncFromStorageServices = append(*syncFromStorageServices, iterableStorageService)
+			s = iterableStorageService
+		}
+		if config.CephStorage.SyncToStorageService {
+			*syncToStorageServices = append(*syncToStorageServices, s)
+		}
+		storageServices = append(storageServices, s)
+		storageServiceNames = append(storageServiceNames, "ceph-storage")
+	}
+
+	if config.BigtableStorage.Enable {
+		s, err := NewBigtableStorageService(ctx, config.BigtableStorage)
+		if err != nil {
+			return nil, nil, err
+		}
+		lifecycleManager.Register(s)
+		if config.BigtableStorage.SyncFromStorageService {
+			iterableStorageService := NewIterableStorageService(ConvertStorageServiceToIterationCompatibleStorageService(s))
+			*syncFromStorageServices = append(*syncFromStorageServices, iterableStorageService)
+			s = iterableStorageService
+		}
+		if config.BigtableStorage.SyncToStorageService {
+			*syncToStorageServices = append(*syncToStorageServices, s)
+		}
+		storageServices = append(storageServices, s)
+		storageServiceNames = append(storageServiceNames, "bigtable-storage")
+	}
+
+	if config.HDFSStorage.Enable {
+		s, err := NewHDFSStorageService(config.HDFSStorage)
+		if err != nil {
+			return nil, nil, err
+		}
+		lifecycleManager.Register(s)
+		if config.HDFSStorage.SyncFromStorageService {
+			iterableStorageService := NewIterableStorageService(ConvertStorageServiceToIterationCompatibleStorageService(s))
+			*syncFromStorageServices = append(*syncFromStorageServices, iterableStorageService)
+			s = iterableStorageService
+		}
+		if config.HDFSStorage.SyncToStorageService {
+			*syncToStorageServices = append(*syncToStorageServices, s)
+		}
+		storageServices = append(storageServices, s)
+		storageServiceNames = append(storageServiceNames, "hdfs-storage")
 	}
 
 	if config.IpfsStorage.Enable {
@@ -85,10 +315,57 @@ func CreatePersistentStorageService(
 		}
 		lifecycleManager.Register(s)
 		storageServices = append(storageServices, s)
+		storageServiceNames = append(storageServiceNames, "ipfs-storage")
+	}
+
+	if backends != nil {
+		for i, s := range storageServices {
+			*backends = append(*backends, StorageBackend{Name: storageServiceNames[i], Service: s})
+		}
+	}
+
+	if config.Encryption.Enable {
+		aead, err := NewDataKeyAEAD(ctx, config.Encryption)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, s := range storageServices {
+			if storageServiceNames[i] == "ipfs-storage" {
+				continue
+			}
+			encrypted, err := NewEncryptedStorageService(s, aead)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error enabling encryption for %s: %w", storageServiceNames[i], err)
+			}
+			storageServices[i] = encrypted
+		}
+	}
+
+	// Data is compressed before it's encrypted.
+	if config.Compression.Enable {
+		for i, s := range storageServices {
+			if storageServiceNames[i] == "ipfs-storage" {
+				continue
+			}
+			compressed, err := NewCompressedStorageService(s, config.Compression)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error enabling compression for %s: %w", storageServiceNames[i], err)
+			}
+			storageServices[i] = compressed
+		}
+	}
+
+	if config.TieredStorage.Enable {
+		s, err := NewTieredStorageServiceFromConfig(config.TieredStorage, storageServices, storageServiceNames)
+		if err != nil {
+			return nil, nil, err
+		}
+		lifecycleManager.Register(s)
+		return s, &lifecycleManager, nil
 	}
 
 	if len(storageServices) > 1 {
-		s, err := NewRedundantStorageService(ctx, storageServices)
+		s, err := NewRedundantStorageServiceWithWriteQuorum(ctx, storageServices, config.RedundantStorage.WriteQuorum)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -112,8 +389,17 @@ func WrapStorageWithCache(
 		return nil, nil
 	}
 
-	// Enable caches, Redis and (local) BigCache. Local is the outermost, so it will be tried first.
+	// Enable the bloom filter, which is innermost, directly in front of the storage backends, then caches, Redis, memcached,
+	// the disk cache, (local) BigCache, the LRU cache and the negative cache. The negative cache is the outermost, so lookups
+	// of recently missing hashes don't reach the other caches.
 	var err error
+	if config.BloomFilter.Enable {
+		storageService, err = NewBloomFilterStorageService(ctx, config.BloomFilter, storageService, *syncFromStorageServices)
+		if err != nil {
+			return nil, err
+		}
+		lifecycleManager.Register(storageService)
+	}
 	if config.RedisCache.Enable {
 		storageService, err = NewRedisStorageService(config.RedisCache, storageService)
 		lifecycleManager.Register(storageService)
@@ -129,6 +415,20 @@ func WrapStorageWithCache(
 			*syncToStorageServices = append(*syncToStorageServices, storageService)
 		}
 	}
+	if config.MemcacheCache.Enable {
+		storageService, err = NewMemcacheStorageService(config.MemcacheCache, storageService)
+		if err != nil {
+			return nil, err
+		}
+		lifecycleManager.Register(storageService)
+	}
+	if config.DiskCache.Enable {
+		storageService, err = NewDiskCacheStorageService(config.DiskCache, storageService)
+		if err != nil {
+			return nil, err
+		}
+		lifecycleManager.Register(storageService)
+	}
 	if config.LocalCache.Enable {
 		storageService, err = NewBigCacheStorageService(config.LocalCache, storageService)
 		lifecycleManager.Register(storageService)
@@ -136,6 +436,17 @@ func WrapStorageWithCache(
 			return nil, err
 		}
 	}
+	if config.LruCache.Enable {
+		storageService, err = NewCachedStorageService(config.LruCache, storageService)
+		if err != nil {
+			return nil, err
+		}
+		lifecycleManager.Register(storageService)
+	}
+	if config.NegativeCache.Enable {
+		storageService = NewNegativeCachingStorageService(config.NegativeCache, storageService)
+		lifecycleManager.Register(storageService)
+	}
 	return storageService, nil
 }
 
@@ -160,11 +471,30 @@ func CreateBatchPosterDAS(
 	}
 	// Done checking config requirements
 
-	var daWriter DataAvailabilityServiceWriter
-	daWriter, err := NewRPCAggregator(ctx, *config)
+	registry, err := NewCommitteeRegistryFromConfig(config.CommitteeRegistry, l1Reader)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var aggregator *Aggregator
+	if registry != nil {
+		aggregator, err = NewRPCAggregatorWithRegistry(ctx, *config, registry)
+	} else {
+		aggregator, err = NewRPCAggregator(ctx, *config)
+	}
 	if err != nil {
 		return nil, nil, nil, err
 	}
+	aggregator.Start(ctx)
+	var lifecycleManager LifecycleManager
+	lifecycleManager.Register(aggregator)
+	var daWriter DataAvailabilityServiceWriter = aggregator
+	if config.SecondaryAggregator.Enable {
+		secondary, err := newSecondaryAggregator(ctx, config, &lifecycleManager)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		daWriter = NewFailoverDASWriter(daWriter, secondary)
+	}
 	if dataSigner != nil {
 		// In some tests the batch poster does not sign Store requests
 		daWriter, err = NewStoreSigningDAS(daWriter, dataSigner)
@@ -173,12 +503,11 @@ func CreateBatchPosterDAS(
 		}
 	}
 
-	restAgg, err := NewRestfulClientAggregator(ctx, &config.RestAggregator)
+	restAgg, err := NewRestfulClientAggregatorWithRegistry(ctx, &config.RestAggregator, registry, config.CommitteeRegistry.RefreshInterval)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 	restAgg.Start(ctx)
-	var lifecycleManager LifecycleManager
 	lifecycleManager.Register(restAgg)
 	var daReader DataAvailabilityServiceReader = restAgg
 	daReader, err = NewChainFetchReader(daReader, l1Reader, sequencerInboxAddr)
@@ -189,11 +518,29 @@ func CreateBatchPosterDAS(
 	return daWriter, daReader, &lifecycleManager, nil
 }
 
+// DaserverServices are the parts of a daserver that its REST and admin
+// servers serve, besides the reader, writer and health checker. Any may be
+// nil.
+type DaserverServices struct {
+	// Backends are the persistent storage backends, before any encryption
+	// or compression.
+	Backends     []StorageBackend
+	RecentHashes RecentHashesReader
+	Inventory    InventoryReader
+	Custody      CustodyProver
+	Announcement *EndpointAnnouncement
+	Expiration   ExpirationInfoReader
+	Keysets      KeysetLister
+}
+
+// CreateDAComponentsForDaserver fills in services, if not nil, for the REST
+// and admin servers.
 func CreateDAComponentsForDaserver(
 	ctx context.Context,
 	config *DataAvailabilityConfig,
 	l1Reader *headerreader.HeaderReader,
 	seqInboxAddress *common.Address,
+	services *DaserverServices,
 ) (DataAvailabilityServiceReader, DataAvailabilityServiceWriter, DataAvailabilityServiceHealthChecker, *LifecycleManager, error) {
 	if !config.Enable {
 		return nil, nil, nil, nil, nil
@@ -203,33 +550,91 @@ func CreateDAComponentsForDaserver(
 	if !config.LocalDBStorage.Enable &&
 		!config.LocalFileStorage.Enable &&
 		!config.S3Storage.Enable &&
+		!config.GoogleCloudStorage.Enable &&
+		!config.AzureBlobStorage.Enable &&
+		!config.CassandraStorage.Enable &&
+		!config.MongoStorage.Enable &&
+		!config.EtcdStorage.Enable &&
+		!config.MemoryStorage.Enable &&
+		!config.WebDAVStorage.Enable &&
+		!config.SFTPStorage.Enable &&
+		!config.PluginStorage.Enable &&
+		!config.CephStorage.Enable &&
+		!config.BigtableStorage.Enable &&
+		!config.HDFSStorage.Enable &&
 		!config.IpfsStorage.Enable {
-		return nil, nil, nil, nil, errors.New("At least one of --data-availability.(local-db-storage|local-file-storage|s3-storage|ipfs-storage) must be enabled.")
+		return nil, nil, nil, nil, errors.New("At least one of --data-availability.(local-db-storage|local-file-storage|s3-storage|google-cloud-storage|azure-blob-storage|cassandra-storage|mongo-storage|etcd-storage|memory-storage|webdav-storage|sftp-storage|plugin-storage|ceph-storage|bigtable-storage|hdfs-storage|ipfs-storage) must be enabled.")
+	}
+
+	if config.ReadOnly {
+		if config.Key.hasKey() {
+			return nil, nil, nil, nil, errors.New("--data-availability.key can't be set with --data-availability.read-only, since a read-only daserver can't accept Store requests")
+		}
+		if config.RestAggregator.SyncToStorage.Eager || config.RegularSyncStorage.Enable || config.Mirror.Enable || config.Gossip.Enable || config.AntiEntropy.Enable || config.CustodyChallenge.Enable {
+			return nil, nil, nil, nil, errors.New("--data-availability.rest-aggregator.sync-to-storage.eager, --data-availability.regular-sync-storage, --data-availability.mirror, --data-availability.gossip, --data-availability.anti-entropy and --data-availability.custody-challenge can't be used with --data-availability.read-only")
+		}
+	}
+	if config.Mirror.Enable {
+		if !config.RestAggregator.Enable {
+			return nil, nil, nil, nil, errors.New("--data-availability.rest-aggregator must be enabled along with --data-availability.mirror, to fetch missing data from")
+		}
+		if l1Reader == nil || seqInboxAddress == nil {
+			return nil, nil, nil, nil, errors.New("l1-node-url and sequencer-inbox-address must be specified along with mirror")
+		}
 	}
 	// Done checking config requirements
 
 	var syncFromStorageServices []*IterableStorageService
 	var syncToStorageServices []StorageService
-	storageService, dasLifecycleManager, err := CreatePersistentStorageService(ctx, config, &syncFromStorageServices, &syncToStorageServices)
+	var backends *[]StorageBackend
+	if services != nil {
+		backends = &services.Backends
+	}
+	storageService, dasLifecycleManager, err := createPersistentStorageService(ctx, config, &syncFromStorageServices, &syncToStorageServices, backends)
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
+	expiration := newStorageExpirationInfo(storageService, syncFromStorageServices)
+
+	if config.FilecoinColdStorage.Enable {
+		storageService, err = NewFilecoinColdStorageService(ctx, config.FilecoinColdStorage, storageService)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		dasLifecycleManager.Register(storageService)
+	}
+
+	if config.ReadOnly {
+		storageService = NewReadOnlyStorageService(storageService)
+	}
 
 	storageService, err = WrapStorageWithCache(ctx, config, storageService, &syncFromStorageServices, &syncToStorageServices, dasLifecycleManager)
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
 
+	// Custody challenges must be answered without the REST aggregator fallback.
+	localStorageService := storageService
+
 	// The REST aggregator is used as the fallback if requested data is not present
 	// in the storage service.
 	if config.RestAggregator.Enable {
-		restAgg, err := NewRestfulClientAggregator(ctx, &config.RestAggregator)
+		restAgg, err := newRestfulClientAggregatorFromConfig(ctx, config, headerReaderClient(l1Reader))
 		if err != nil {
 			return nil, nil, nil, nil, err
 		}
 		restAgg.Start(ctx)
 		dasLifecycleManager.Register(restAgg)
 
+		if config.Mirror.Enable {
+			mirror, err := NewMirrorStorage(config.Mirror, storageService, restAgg, l1Reader, *seqInboxAddress)
+			if err != nil {
+				return nil, nil, nil, nil, err
+			}
+			mirror.Start(ctx)
+			dasLifecycleManager.Register(mirror)
+		}
+
 		syncConf := &config.RestAggregator.SyncToStorage
 		var retentionPeriodSeconds uint64
 		if uint64(syncConf.RetentionPeriod) == math.MaxUint64 {
@@ -255,18 +660,90 @@ func CreateDAComponentsForDaserver(
 				return nil, nil, nil, nil, err
 			}
 		} else {
+			// A read-only daserver can't keep data fetched from the REST
+			// aggregator, which isn't an error.
 			storageService = NewFallbackStorageService(storageService, restAgg, restAgg,
-				retentionPeriodSeconds, syncConf.IgnoreWriteErrors, true)
+				retentionPeriodSeconds, syncConf.IgnoreWriteErrors || config.ReadOnly, true)
 			dasLifecycleManager.Register(storageService)
 		}
 
 	}
 
+	if config.CacheWarmup.Enable {
+		if l1Reader == nil || seqInboxAddress == nil {
+			return nil, nil, nil, nil, errors.New("l1-node-url and sequencer-inbox-address must be specified along with cache-warmup.enable")
+		}
+		cacheWarmer, err := NewCacheWarmer(config.CacheWarmup, storageService, l1Reader, *seqInboxAddress)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		cacheWarmer.Start(ctx)
+		dasLifecycleManager.Register(cacheWarmer)
+	}
+
+	var antiEntropy *AntiEntropyStorageService
+	if config.AntiEntropy.Enable {
+		peers := config.AntiEntropy.Peers
+		if len(peers) == 0 {
+			peers = config.RestAggregator.Urls
+		}
+		peers, err = resolveDNSSRVURLs(ctx, peers)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		antiEntropy, err = NewAntiEntropyStorageService(ctx, config.AntiEntropy, storageService, peers, syncFromStorageServices)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		antiEntropy.Start(ctx)
+		dasLifecycleManager.Register(antiEntropy)
+		storageService = antiEntropy
+	}
+
+	var gossip *GossipStorageService
+	if config.Gossip.Enable {
+		peers := config.Gossip.Peers
+		if len(peers) == 0 {
+			peers = config.RestAggregator.Urls
+		}
+		peers, err = resolveDNSSRVURLs(ctx, peers)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		gossip, err = NewGossipStorageService(config.Gossip, storageService, peers)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		gossip.Start(ctx)
+		dasLifecycleManager.Register(gossip)
+		storageService = gossip
+	}
+
+	var custody *CustodyStorageService
+	if config.CustodyChallenge.Enable {
+		peers := config.CustodyChallenge.Peers
+		if len(peers) == 0 {
+			peers = config.RestAggregator.Urls
+		}
+		peers, err = resolveDNSSRVURLs(ctx, peers)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		custody, err = NewCustodyStorageService(config.CustodyChallenge, storageService, localStorageService, peers)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		custody.Start(ctx)
+		dasLifecycleManager.Register(custody)
+		storageService = custody
+	}
+
 	var daWriter DataAvailabilityServiceWriter
 	var daReader DataAvailabilityServiceReader = storageService
 	var daHealthChecker DataAvailabilityServiceHealthChecker = storageService
 
-	if config.Key.KeyDir != "" || config.Key.PrivKey != "" {
+	var signer BLSSigner
+	if config.Key.hasKey() {
 		var seqInboxCaller *bridgegen.SequencerInboxCaller
 		if seqInboxAddress != nil {
 			seqInbox, err := bridgegen.NewSequencerInbox(*seqInboxAddress, (*l1Reader).Client())
@@ -280,13 +757,13 @@ func CreateDAComponentsForDaserver(
 			seqInboxCaller = nil
 		}
 
-		privKey, err := config.Key.BLSPrivKey()
+		signer, err = config.Key.BLSSigner(ctx)
 		if err != nil {
 			return nil, nil, nil, nil, err
 		}
 
-		daWriter, err = NewSignAfterStoreDASWriterWithSeqInboxCaller(
-			privKey,
+		signAfterStoreDASWriter, err := NewSignAfterStoreDASWriterWithSigner(
+			signer,
 			seqInboxCaller,
 			storageService,
 			config.ExtraSignatureCheckingPublicKey,
@@ -294,6 +771,40 @@ func CreateDAComponentsForDaserver(
 		if err != nil {
 			return nil, nil, nil, nil, err
 		}
+
+		rotations, err := config.Key.BLSKeyRotations()
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		if len(rotations) > 0 {
+			if l1Reader == nil {
+				return nil, nil, nil, nil, errors.New("l1-node-url must be specified along with key.rotations")
+			}
+			parentChainHeight := func(ctx context.Context) (uint64, error) {
+				header, err := l1Reader.LastHeader(ctx)
+				if err != nil {
+					return 0, err
+				}
+				return header.Number.Uint64(), nil
+			}
+			if err := signAfterStoreDASWriter.AddKeyRotations(rotations, parentChainHeight); err != nil {
+				return nil, nil, nil, nil, err
+			}
+		}
+		daWriter = signAfterStoreDASWriter
+	}
+
+	if daWriter != nil {
+		daWriter, err = NewStoreBackpressure(daWriter, config.StoreBackpressure)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		// Outside the backpressure, so stores out of bounds don't wait for
+		// a slot.
+		daWriter, err = NewStoreBoundsChecker(daWriter, config.StoreBounds)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
 	}
 
 	if config.RegularSyncStorage.Enable && len(syncFromStorageServices) != 0 && len(syncToStorageServices) != 0 {
@@ -301,11 +812,53 @@ func CreateDAComponentsForDaserver(
 		regularlySyncStorage.Start(ctx)
 	}
 
+	var keysets KeysetLister
 	if seqInboxAddress != nil {
-		daReader, err = NewChainFetchReader(daReader, (*l1Reader).Client(), *seqInboxAddress)
+		chainFetchReader, err := NewChainFetchReader(daReader, (*l1Reader).Client(), *seqInboxAddress)
 		if err != nil {
 			return nil, nil, nil, nil, err
 		}
+		daReader = chainFetchReader
+		keysets = chainFetchReader
+	}
+
+	if config.RequestPriority.Enable {
+		scheduler, err := NewRequestScheduler(config.RequestPriority)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		daReader = NewDeprioritizedReader(daReader, scheduler)
+		if daWriter != nil {
+			daWriter = NewPrioritizedWriter(daWriter, scheduler)
+		}
+	}
+
+	var announcement *EndpointAnnouncement
+	if config.Announcement.Enable {
+		if signer == nil {
+			return nil, nil, nil, nil, errors.New("--data-availability.announcement requires the committee member's key to be configured with --data-availability.key")
+		}
+		var err error
+		announcement, err = NewEndpointAnnouncementWithSigner(ctx, config.Announcement.RPCURL, config.Announcement.RESTURL, signer)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+	}
+
+	if services != nil {
+		services.Announcement = announcement
+		services.Expiration = expiration
+		services.Keysets = keysets
+		// Only set if enabled, so the interfaces aren't typed nils.
+		if gossip != nil {
+			services.RecentHashes = gossip
+		}
+		if antiEntropy != nil {
+			services.Inventory = antiEntropy
+		}
+		if custody != nil {
+			services.Custody = custody
+		}
 	}
 
 	return daReader, daWriter, daHealthChecker, dasLifecycleManager, nil
@@ -343,7 +896,7 @@ func CreateDAReaderForNode(
 	var daReader DataAvailabilityServiceReader
 	if config.RestAggregator.Enable {
 		var restAgg *SimpleDASReaderAggregator
-		restAgg, err = NewRestfulClientAggregator(ctx, &config.RestAggregator)
+		restAgg, err = newRestfulClientAggregatorFromConfig(ctx, config, headerReaderClient(l1Reader))
 		if err != nil {
 			return nil, nil, err
 		}
@@ -360,8 +913,10 @@ func CreateDAReaderForNode(
 			}
 
 			// This falls back to REST and updates the local IPFS repo if the data is found.
+			// A read-only daserver can't keep data fetched from the REST
+			// aggregator, which isn't an error.
 			storageService = NewFallbackStorageService(storageService, restAgg, restAgg,
-				retentionPeriodSeconds, syncConf.IgnoreWriteErrors, true)
+				retentionPeriodSeconds, syncConf.IgnoreWriteErrors || config.ReadOnly, true)
 			dasLifecycleManager.Register(storageService)
 
 			daReader = storageService
@@ -370,6 +925,15 @@ func CreateDAReaderForNode(
 		}
 	}
 
+	// Retrieved data is only cached once it has been checked against its hash.
+	if config.LruCache.Enable && daReader != nil {
+		cachedReader, err := NewCachedStorageService(config.LruCache, NewReadLimitedStorageService(daReader))
+		if err != nil {
+			return nil, nil, err
+		}
+		daReader = cachedReader
+	}
+
 	if seqInboxAddress != nil {
 		seqInbox, err := bridgegen.NewSequencerInbox(*seqInboxAddress, (*l1Reader).Client())
 		if err != nil {
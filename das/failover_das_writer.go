@@ -0,0 +1,105 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbstate"
+)
+
+type SecondaryAggregatorConfig struct {
+	Enable        bool             `koanf:"enable"`
+	URL           string           `koanf:"url"`
+	RPCAggregator AggregatorConfig `koanf:"rpc-aggregator"`
+}
+
+var DefaultSecondaryAggregatorConfig = SecondaryAggregatorConfig{
+	RPCAggregator: DefaultAggregatorConfig,
+}
+
+func SecondaryAggregatorConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultSecondaryAggregatorConfig.Enable, "enable failing over to a secondary aggregator when Store to the primary rpc-aggregator fails")
+	f.String(prefix+".url", DefaultSecondaryAggregatorConfig.URL, "URL of a remote aggregator's RPC endpoint to fail over to; if not set, an aggregator is constructed locally from secondary-aggregator.rpc-aggregator")
+	AggregatorConfigAddOptions(prefix+".rpc-aggregator", f)
+}
+
+var (
+	failoverPrimaryFailureCounter   = metrics.NewRegisteredCounter("arb/das/failover/primary/failure", nil)
+	failoverSecondarySuccessCounter = metrics.NewRegisteredCounter("arb/das/failover/secondary/success", nil)
+	failoverSecondaryFailureCounter = metrics.NewRegisteredCounter("arb/das/failover/secondary/failure", nil)
+)
+
+// FailoverDASWriter sends Store to the primary writer, and if that fails, to
+// the secondary writer, so a single aggregator outage doesn't stop batches
+// being stored to the DAS. If both fail, the error wraps BatchToDasFailed so
+// the batch poster can fall back to storing the data on chain.
+type FailoverDASWriter struct {
+	primary   DataAvailabilityServiceWriter
+	secondary DataAvailabilityServiceWriter
+}
+
+func NewFailoverDASWriter(primary, secondary DataAvailabilityServiceWriter) *FailoverDASWriter {
+	return &FailoverDASWriter{
+		primary:   primary,
+		secondary: secondary,
+	}
+}
+
+func (f *FailoverDASWriter) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	cert, err := f.primary.Store(ctx, message, timeout, sig)
+	if err == nil || ctx.Err() != nil {
+		return cert, err
+	}
+	failoverPrimaryFailureCounter.Inc(1)
+	log.Warn("das.FailoverDASWriter: Store to primary aggregator failed, failing over to secondary", "primary", f.primary, "secondary", f.secondary, "err", err)
+
+	cert, secondaryErr := f.secondary.Store(ctx, message, timeout, sig)
+	if secondaryErr != nil {
+		failoverSecondaryFailureCounter.Inc(1)
+		if ctx.Err() != nil {
+			return nil, secondaryErr
+		}
+		//nolint:errorlint
+		return nil, fmt.Errorf("primary aggregator: %v, secondary aggregator: %v. %w", err, secondaryErr, BatchToDasFailed)
+	}
+	failoverSecondarySuccessCounter.Inc(1)
+	return cert, nil
+}
+
+func (f *FailoverDASWriter) String() string {
+	return fmt.Sprintf("FailoverDASWriter{primary:%v, secondary:%v}", f.primary, f.secondary)
+}
+
+// newSecondaryAggregator creates the writer configured by
+// secondary-aggregator, registering it with lifecycleManager if it needs to
+// be stopped.
+func newSecondaryAggregator(ctx context.Context, config *DataAvailabilityConfig, lifecycleManager *LifecycleManager) (DataAvailabilityServiceWriter, error) {
+	secondaryConfig := config.SecondaryAggregator
+	if secondaryConfig.URL != "" {
+		if secondaryConfig.RPCAggregator.Enable {
+			return nil, errors.New("only one of secondary-aggregator.url and secondary-aggregator.rpc-aggregator.enable may be set")
+		}
+		return NewDASRPCClient(secondaryConfig.URL)
+	}
+	if !secondaryConfig.RPCAggregator.Enable {
+		return nil, errors.New("secondary-aggregator.url or secondary-aggregator.rpc-aggregator.enable must be set along with secondary-aggregator.enable")
+	}
+	aggregatorConfig := *config
+	aggregatorConfig.RPCAggregator = secondaryConfig.RPCAggregator
+	aggregator, err := NewRPCAggregator(ctx, aggregatorConfig)
+	if err != nil {
+		return nil, err
+	}
+	aggregator.Start(ctx)
+	lifecycleManager.Register(aggregator)
+	return aggregator, nil
+}
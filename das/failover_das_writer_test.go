@@ -0,0 +1,54 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/offchainlabs/nitro/arbstate"
+)
+
+type certStore struct {
+	DataAvailabilityServiceWriter
+	calls int
+}
+
+func (c *certStore) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	c.calls++
+	return &arbstate.DataAvailabilityCertificate{Timeout: timeout}, nil
+}
+
+func TestFailoverDASWriter(t *testing.T) {
+	ctx := context.Background()
+
+	primary := &certStore{}
+	secondary := &certStore{}
+	_, err := NewFailoverDASWriter(primary, secondary).Store(ctx, []byte("batch"), 1, nil)
+	Require(t, err)
+	if primary.calls != 1 || secondary.calls != 0 {
+		Fail(t, "expected only the primary to be called", primary.calls, secondary.calls)
+	}
+
+	failingPrimary := &failingStore{}
+	cert, err := NewFailoverDASWriter(failingPrimary, secondary).Store(ctx, []byte("batch"), 2, nil)
+	Require(t, err)
+	if cert.Timeout != 2 || failingPrimary.calls != 1 || secondary.calls != 1 {
+		Fail(t, "expected failover to the secondary", failingPrimary.calls, secondary.calls)
+	}
+
+	_, err = NewFailoverDASWriter(&failingStore{}, &failingStore{}).Store(ctx, []byte("batch"), 3, nil)
+	if !errors.Is(err, BatchToDasFailed) {
+		Fail(t, "expected BatchToDasFailed when both aggregators fail, got", err)
+	}
+
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	secondary.calls = 0
+	_, err = NewFailoverDASWriter(&failingStore{}, secondary).Store(canceledCtx, []byte("batch"), 4, nil)
+	if err == nil || secondary.calls != 0 {
+		Fail(t, "expected no failover once the context is done", err, secondary.calls)
+	}
+}
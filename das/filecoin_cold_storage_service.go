@@ -0,0 +1,586 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/pretty"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+
+	flag "github.com/spf13/pflag"
+)
+
+type FilecoinColdStorageConfig struct {
+	Enable              bool          `koanf:"enable"`
+	LotusApiUrl         string        `koanf:"lotus-api-url"`
+	LotusAuthToken      string        `koanf:"lotus-auth-token"`
+	Wallet              string        `koanf:"wallet"`
+	Miners              []string      `koanf:"miners"`
+	EpochPrice          string        `koanf:"epoch-price"`
+	DealDurationEpochs  uint64        `koanf:"deal-duration-epochs"`
+	VerifiedDeal        bool          `koanf:"verified-deal"`
+	ImportDir           string        `koanf:"import-dir"`
+	RetrievalGatewayUrl string        `koanf:"retrieval-gateway-url"`
+	ArchiveAfter        time.Duration `koanf:"archive-after"`
+	CheckInterval       time.Duration `koanf:"check-interval"`
+	MaxDealAttempts     int           `koanf:"max-deal-attempts"`
+}
+
+var DefaultFilecoinColdStorageConfig = FilecoinColdStorageConfig{
+	EpochPrice:         "0",
+	DealDurationEpochs: 518400, // 180 days, the minimum deal duration
+	ArchiveAfter:       24 * time.Hour,
+	CheckInterval:      10 * time.Minute,
+	MaxDealAttempts:    3,
+}
+
+func FilecoinColdStorageConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultFilecoinColdStorageConfig.Enable, "make Filecoin storage deals for aging sequencer batch data, and retrieve it from Filecoin once it is no longer in the other storage backends")
+	f.String(prefix+".lotus-api-url", DefaultFilecoinColdStorageConfig.LotusApiUrl, "URL of the Lotus JSON-RPC API used to make deals, eg http://127.0.0.1:1234/rpc/v0")
+	f.String(prefix+".lotus-auth-token", DefaultFilecoinColdStorageConfig.LotusAuthToken, "Lotus API token with write permission")
+	f.String(prefix+".wallet", DefaultFilecoinColdStorageConfig.Wallet, "Filecoin wallet address paying for deals")
+	f.StringSlice(prefix+".miners", DefaultFilecoinColdStorageConfig.Miners, "storage provider addresses to make deals with; failed deals are retried with the next provider")
+	f.String(prefix+".epoch-price", DefaultFilecoinColdStorageConfig.EpochPrice, "price per epoch offered for each deal, in attoFIL")
+	f.Uint64(prefix+".deal-duration-epochs", DefaultFilecoinColdStorageConfig.DealDurationEpochs, "duration of each deal in epochs")
+	f.Bool(prefix+".verified-deal", DefaultFilecoinColdStorageConfig.VerifiedDeal, "make verified deals using the wallet's DataCap")
+	f.String(prefix+".import-dir", DefaultFilecoinColdStorageConfig.ImportDir, "directory, readable by the Lotus node, where data is staged for import; deal state is also kept here")
+	f.String(prefix+".retrieval-gateway-url", DefaultFilecoinColdStorageConfig.RetrievalGatewayUrl, "HTTP gateway used to retrieve archived data by CID, eg https://ipfs.io")
+	f.Duration(prefix+".archive-after", DefaultFilecoinColdStorageConfig.ArchiveAfter, "how long after it is stored data is archived to Filecoin; data is archived earlier if its expiry timeout is sooner")
+	f.Duration(prefix+".check-interval", DefaultFilecoinColdStorageConfig.CheckInterval, "interval at which to make new deals and check the status of existing ones")
+	f.Int(prefix+".max-deal-attempts", DefaultFilecoinColdStorageConfig.MaxDealAttempts, "number of times to attempt a deal for the same data before giving up")
+}
+
+// FilecoinDealMaker is the subset of Filecoin client operations used by
+// FilecoinColdStorageService, so the Lotus client can be replaced in tests.
+type FilecoinDealMaker interface {
+	// Import stages data for deal making and returns its root CID.
+	Import(ctx context.Context, key common.Hash, data []byte) (string, error)
+	// RemoveImport deletes the data staged by Import, once its deal no longer
+	// needs it.
+	RemoveImport(ctx context.Context, key common.Hash) error
+	// StartDeal proposes a deal for the given root CID and returns the proposal CID.
+	StartDeal(ctx context.Context, rootCid string, miner string) (string, error)
+	DealState(ctx context.Context, proposalCid string) (filecoinStorageDealState, string, error)
+	Retrieve(ctx context.Context, rootCid string) ([]byte, error)
+}
+
+// filecoinStorageDealState mirrors storagemarket.StorageDealStatus.
+type filecoinStorageDealState uint64
+
+const (
+	filecoinStorageDealProposalNotFound filecoinStorageDealState = 1
+	filecoinStorageDealProposalRejected filecoinStorageDealState = 2
+	filecoinStorageDealActive           filecoinStorageDealState = 7
+	filecoinStorageDealExpired          filecoinStorageDealState = 8
+	filecoinStorageDealSlashed          filecoinStorageDealState = 9
+	filecoinStorageDealFailing          filecoinStorageDealState = 11
+	filecoinStorageDealError            filecoinStorageDealState = 26
+)
+
+func (s filecoinStorageDealState) failed() bool {
+	switch s {
+	case filecoinStorageDealProposalNotFound, filecoinStorageDealProposalRejected, filecoinStorageDealExpired,
+		filecoinStorageDealSlashed, filecoinStorageDealFailing, filecoinStorageDealError:
+		return true
+	}
+	return false
+}
+
+type lotusDealMaker struct {
+	client     *rpc.Client
+	config     FilecoinColdStorageConfig
+	httpClient *http.Client
+}
+
+func newLotusDealMaker(ctx context.Context, config FilecoinColdStorageConfig) (*lotusDealMaker, error) {
+	var opts []rpc.ClientOption
+	if config.LotusAuthToken != "" {
+		opts = append(opts, rpc.WithHeader("Authorization", "Bearer "+config.LotusAuthToken))
+	}
+	client, err := rpc.DialOptions(ctx, config.LotusApiUrl, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to Lotus API: %w", err)
+	}
+	return &lotusDealMaker{
+		client:     client,
+		config:     config,
+		httpClient: &http.Client{},
+	}, nil
+}
+
+type lotusCid struct {
+	Cid string `json:"/"`
+}
+
+type lotusFileRef struct {
+	Path  string
+	IsCAR bool
+}
+
+type lotusImportRes struct {
+	Root     lotusCid
+	ImportID uint64
+}
+
+type lotusDataRef struct {
+	TransferType string
+	Root         lotusCid
+}
+
+type lotusStartDealParams struct {
+	Data              *lotusDataRef
+	Wallet            string
+	Miner             string
+	EpochPrice        string
+	MinBlocksDuration uint64
+	FastRetrieval     bool
+	VerifiedDeal      bool
+}
+
+type lotusDealInfo struct {
+	State   filecoinStorageDealState
+	Message string
+	DealID  uint64
+}
+
+func (l *lotusDealMaker) importPath(key common.Hash) string {
+	return filepath.Join(l.config.ImportDir, EncodeStorageServiceKey(key))
+}
+
+func (l *lotusDealMaker) Import(ctx context.Context, key common.Hash, data []byte) (string, error) {
+	path := l.importPath(key)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	var res lotusImportRes
+	if err := l.client.CallContext(ctx, &res, "Filecoin.ClientImport", lotusFileRef{Path: path}); err != nil {
+		return "", err
+	}
+	return res.Root.Cid, nil
+}
+
+func (l *lotusDealMaker) RemoveImport(ctx context.Context, key common.Hash) error {
+	err := os.Remove(l.importPath(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (l *lotusDealMaker) StartDeal(ctx context.Context, rootCid string, miner string) (string, error) {
+	params := lotusStartDealParams{
+		Data: &lotusDataRef{
+			TransferType: "graphsync",
+			Root:         lotusCid{rootCid},
+		},
+		Wallet:            l.config.Wallet,
+		Miner:             miner,
+		EpochPrice:        l.config.EpochPrice,
+		MinBlocksDuration: l.config.DealDurationEpochs,
+		FastRetrieval:     true,
+		VerifiedDeal:      l.config.VerifiedDeal,
+	}
+	var proposal lotusCid
+	if err := l.client.CallContext(ctx, &proposal, "Filecoin.ClientStartDeal", params); err != nil {
+		return "", err
+	}
+	return proposal.Cid, nil
+}
+
+func (l *lotusDealMaker) DealState(ctx context.Context, proposalCid string) (filecoinStorageDealState, string, error) {
+	var info lotusDealInfo
+	if err := l.client.CallContext(ctx, &info, "Filecoin.ClientGetDealInfo", lotusCid{proposalCid}); err != nil {
+		return 0, "", err
+	}
+	return info.State, info.Message, nil
+}
+
+func (l *lotusDealMaker) Retrieve(ctx context.Context, rootCid string) ([]byte, error) {
+	url := strings.TrimSuffix(l.config.RetrievalGatewayUrl, "/") + "/ipfs/" + rootCid
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := l.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error with status %d returned by retrieval gateway: %s", res.StatusCode, http.StatusText(res.StatusCode))
+	}
+	return io.ReadAll(res.Body)
+}
+
+const (
+	filecoinDealPending  = "pending"
+	filecoinDealProposed = "proposed"
+	filecoinDealActive   = "active"
+	filecoinDealFailed   = "failed"
+)
+
+type filecoinDealRecord struct {
+	StoredAt    uint64 `json:"storedAt"`
+	Timeout     uint64 `json:"timeout"`
+	State       string `json:"state"`
+	RootCid     string `json:"rootCid,omitempty"`
+	ProposalCid string `json:"proposalCid,omitempty"`
+	Miner       string `json:"miner,omitempty"`
+	Attempts    int    `json:"attempts"`
+	// DealEnd is an upper bound on when an active deal ends, after which the
+	// data can no longer be retrieved from Filecoin.
+	DealEnd uint64 `json:"dealEnd,omitempty"`
+}
+
+// filecoinEpochDuration is the length of a Filecoin epoch.
+const filecoinEpochDuration = 30 * time.Second
+
+// filecoinDealTracker keeps the archival state of each stored batch, persisted
+// as JSON so that deals in progress survive restarts. Failed deals are removed
+// at once and active ones when they end, so the state stays bounded by the
+// deals still running.
+type filecoinDealTracker struct {
+	mutex sync.Mutex
+	path  string
+	deals map[common.Hash]*filecoinDealRecord
+	dirty bool
+}
+
+func newFilecoinDealTracker(path string) (*filecoinDealTracker, error) {
+	t := &filecoinDealTracker{
+		path:  path,
+		deals: make(map[common.Hash]*filecoinDealRecord),
+	}
+	if path == "" {
+		return t, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &t.deals); err != nil {
+		return nil, fmt.Errorf("invalid Filecoin deal state file %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// add starts tracking a newly stored batch, unless it is already tracked.
+func (t *filecoinDealTracker) add(key common.Hash, record *filecoinDealRecord) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if existing, ok := t.deals[key]; ok {
+		if existing.State == filecoinDealPending && existing.Timeout < record.Timeout {
+			existing.Timeout = record.Timeout
+			t.dirty = true
+		}
+		return
+	}
+	t.deals[key] = record
+	t.dirty = true
+}
+
+func (t *filecoinDealTracker) get(key common.Hash) (filecoinDealRecord, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	record, ok := t.deals[key]
+	if !ok {
+		return filecoinDealRecord{}, false
+	}
+	return *record, true
+}
+
+func (t *filecoinDealTracker) update(key common.Hash, record filecoinDealRecord) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.deals[key] = &record
+	t.dirty = true
+}
+
+func (t *filecoinDealTracker) remove(key common.Hash) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if _, ok := t.deals[key]; ok {
+		delete(t.deals, key)
+		t.dirty = true
+	}
+}
+
+func (t *filecoinDealTracker) snapshot() map[common.Hash]filecoinDealRecord {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	res := make(map[common.Hash]filecoinDealRecord, len(t.deals))
+	for key, record := range t.deals {
+		res[key] = *record
+	}
+	return res
+}
+
+func (t *filecoinDealTracker) flush() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.path == "" || !t.dirty {
+		return nil
+	}
+	data, err := json.Marshal(t.deals)
+	if err != nil {
+		return err
+	}
+	tmpPath := t.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, t.path); err != nil {
+		return err
+	}
+	t.dirty = false
+	return nil
+}
+
+// FilecoinColdStorageService archives batches from its base storage to
+// Filecoin once they reach a configured age, and serves them from Filecoin
+// when the base storage no longer has them.
+type FilecoinColdStorageService struct {
+	baseStorageService StorageService
+	dealMaker          FilecoinDealMaker
+	tracker            *filecoinDealTracker
+	config             FilecoinColdStorageConfig
+	stopWaiter         stopwaiter.StopWaiterSafe
+}
+
+func NewFilecoinColdStorageService(ctx context.Context, config FilecoinColdStorageConfig, baseStorageService StorageService) (*FilecoinColdStorageService, error) {
+	if len(config.Miners) == 0 {
+		return nil, errors.New("filecoin-cold-storage.miners must be set")
+	}
+	if config.ImportDir == "" {
+		return nil, errors.New("filecoin-cold-storage.import-dir must be set")
+	}
+	if config.RetrievalGatewayUrl == "" {
+		return nil, errors.New("filecoin-cold-storage.retrieval-gateway-url must be set")
+	}
+	if err := os.MkdirAll(config.ImportDir, 0o755); err != nil {
+		return nil, err
+	}
+	dealMaker, err := newLotusDealMaker(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	tracker, err := newFilecoinDealTracker(filepath.Join(config.ImportDir, "das-filecoin-deals.json"))
+	if err != nil {
+		return nil, err
+	}
+	fcs := &FilecoinColdStorageService{
+		baseStorageService: baseStorageService,
+		dealMaker:          dealMaker,
+		tracker:            tracker,
+		config:             config,
+	}
+	if err := fcs.stopWaiter.Start(ctx, fcs); err != nil {
+		return nil, err
+	}
+	err = fcs.stopWaiter.CallIterativelySafe(func(ctx context.Context) time.Duration {
+		fcs.processDeals(ctx)
+		return config.CheckInterval
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fcs, nil
+}
+
+// dueForArchival returns whether a pending batch should be archived now. It is
+// archived before its timeout even if younger than archive-after, since base
+// storage may discard it after the timeout.
+func (fcs *FilecoinColdStorageService) dueForArchival(record filecoinDealRecord, now uint64) bool {
+	if now >= record.StoredAt+uint64(fcs.config.ArchiveAfter.Seconds()) {
+		return true
+	}
+	return now+2*uint64(fcs.config.CheckInterval.Seconds()) >= record.Timeout
+}
+
+func (fcs *FilecoinColdStorageService) processDeals(ctx context.Context) {
+	now := uint64(time.Now().Unix())
+	for key, record := range fcs.tracker.snapshot() {
+		if ctx.Err() != nil {
+			break
+		}
+		switch record.State {
+		case filecoinDealPending:
+			if !fcs.dueForArchival(record, now) {
+				continue
+			}
+			if err := fcs.startDeal(ctx, key, &record); err != nil {
+				log.Warn("das.FilecoinColdStorageService failed to start deal", "key", pretty.PrettyHash(key), "miner", record.Miner, "err", err)
+			}
+			if record.State == filecoinDealFailed {
+				fcs.dealFinished(ctx, key, record)
+				continue
+			}
+			// Saved even on failure, so that data which was already imported isn't imported again.
+			fcs.tracker.update(key, record)
+		case filecoinDealProposed:
+			state, message, err := fcs.dealMaker.DealState(ctx, record.ProposalCid)
+			if err != nil {
+				log.Warn("das.FilecoinColdStorageService failed to get deal state", "proposal", record.ProposalCid, "err", err)
+				continue
+			}
+			if state == filecoinStorageDealActive {
+				log.Info("das.FilecoinColdStorageService deal active", "key", pretty.PrettyHash(key), "proposal", record.ProposalCid, "miner", record.Miner)
+				record.State = filecoinDealActive
+				// The deal started at the latest now, so it ends within its duration from now.
+				record.DealEnd = now + fcs.config.DealDurationEpochs*uint64(filecoinEpochDuration.Seconds())
+				fcs.dealFinished(ctx, key, record)
+			} else if state.failed() {
+				log.Warn("das.FilecoinColdStorageService deal failed", "key", pretty.PrettyHash(key), "proposal", record.ProposalCid, "miner", record.Miner, "message", message)
+				fcs.dealFailed(ctx, key, record)
+			}
+		case filecoinDealActive:
+			// The data stays retrievable from Filecoin, long after base storage
+			// discards it, until the deal ends.
+			if record.DealEnd != 0 && now >= record.DealEnd {
+				log.Info("das.FilecoinColdStorageService deal ended", "key", pretty.PrettyHash(key), "proposal", record.ProposalCid, "miner", record.Miner)
+				fcs.tracker.remove(key)
+			}
+		}
+	}
+	if err := fcs.tracker.flush(); err != nil {
+		log.Error("das.FilecoinColdStorageService failed to save deal state", "err", err)
+	}
+}
+
+func (fcs *FilecoinColdStorageService) startDeal(ctx context.Context, key common.Hash, record *filecoinDealRecord) error {
+	if record.RootCid == "" {
+		data, err := fcs.baseStorageService.GetByHash(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			log.Error("das.FilecoinColdStorageService data was discarded before it could be archived", "key", pretty.PrettyHash(key))
+			record.State = filecoinDealFailed
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		rootCid, err := fcs.dealMaker.Import(ctx, key, data)
+		if err != nil {
+			return err
+		}
+		record.RootCid = rootCid
+	}
+	record.Miner = fcs.config.Miners[record.Attempts%len(fcs.config.Miners)]
+	proposalCid, err := fcs.dealMaker.StartDeal(ctx, record.RootCid, record.Miner)
+	if err != nil {
+		return err
+	}
+	record.ProposalCid = proposalCid
+	record.State = filecoinDealProposed
+	return nil
+}
+
+func (fcs *FilecoinColdStorageService) dealFailed(ctx context.Context, key common.Hash, record filecoinDealRecord) {
+	record.Attempts++
+	record.ProposalCid = ""
+	if record.Attempts >= fcs.config.MaxDealAttempts {
+		log.Error("das.FilecoinColdStorageService giving up archiving data", "key", pretty.PrettyHash(key), "attempts", record.Attempts)
+		record.State = filecoinDealFailed
+		fcs.dealFinished(ctx, key, record)
+		return
+	}
+	record.State = filecoinDealPending
+	fcs.tracker.update(key, record)
+}
+
+// dealFinished deletes the staged data of a deal that became active or was
+// given up on. Active deals stay tracked so the data can be retrieved from
+// Filecoin, failed ones are dropped.
+func (fcs *FilecoinColdStorageService) dealFinished(ctx context.Context, key common.Hash, record filecoinDealRecord) {
+	if record.RootCid != "" {
+		if err := fcs.dealMaker.RemoveImport(ctx, key); err != nil {
+			log.Warn("das.FilecoinColdStorageService failed to remove imported data", "key", pretty.PrettyHash(key), "err", err)
+		}
+	}
+	if record.State == filecoinDealFailed {
+		fcs.tracker.remove(key)
+	} else {
+		fcs.tracker.update(key, record)
+	}
+}
+
+func (fcs *FilecoinColdStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.FilecoinColdStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", fcs)
+	data, err := fcs.baseStorageService.GetByHash(ctx, key)
+	if !errors.Is(err, ErrNotFound) {
+		return data, err
+	}
+	record, ok := fcs.tracker.get(key)
+	if !ok || record.State != filecoinDealActive {
+		return nil, ErrNotFound
+	}
+	data, err = fcs.dealMaker.Retrieve(ctx, record.RootCid)
+	if err != nil {
+		log.Error("das.FilecoinColdStorageService.GetByHash", "cid", record.RootCid, "err", err)
+		return nil, err
+	}
+	if !dastree.ValidHash(key, data) {
+		return nil, fmt.Errorf("data retrieved from Filecoin for CID %s doesn't match hash %s", record.RootCid, key)
+	}
+	return data, nil
+}
+
+func (fcs *FilecoinColdStorageService) Put(ctx context.Context, data []byte, timeout uint64) error {
+	logPut("das.FilecoinColdStorageService.Store", data, timeout, fcs)
+	if err := fcs.baseStorageService.Put(ctx, data, timeout); err != nil {
+		return err
+	}
+	fcs.tracker.add(dastree.Hash(data), &filecoinDealRecord{
+		StoredAt: uint64(time.Now().Unix()),
+		Timeout:  timeout,
+		State:    filecoinDealPending,
+	})
+	return nil
+}
+
+func (fcs *FilecoinColdStorageService) Sync(ctx context.Context) error {
+	if err := fcs.tracker.flush(); err != nil {
+		return err
+	}
+	return fcs.baseStorageService.Sync(ctx)
+}
+
+func (fcs *FilecoinColdStorageService) Close(ctx context.Context) error {
+	fcs.stopWaiter.StopAndWait()
+	if err := fcs.tracker.flush(); err != nil {
+		log.Error("das.FilecoinColdStorageService failed to save deal state", "err", err)
+	}
+	return fcs.baseStorageService.Close(ctx)
+}
+
+func (fcs *FilecoinColdStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	return fcs.baseStorageService.ExpirationPolicy(ctx)
+}
+
+func (fcs *FilecoinColdStorageService) String() string {
+	return fmt.Sprintf("FilecoinColdStorageService(%v)", fcs.baseStorageService)
+}
+
+func (fcs *FilecoinColdStorageService) HealthCheck(ctx context.Context) error {
+	return fcs.baseStorageService.HealthCheck(ctx)
+}
@@ -0,0 +1,195 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+type mockFilecoinDealMaker struct {
+	mutex     sync.Mutex
+	imported  map[string][]byte
+	staged    map[common.Hash]bool
+	proposals map[string]filecoinStorageDealState
+	miners    []string
+}
+
+func newMockFilecoinDealMaker() *mockFilecoinDealMaker {
+	return &mockFilecoinDealMaker{
+		imported:  make(map[string][]byte),
+		staged:    make(map[common.Hash]bool),
+		proposals: make(map[string]filecoinStorageDealState),
+	}
+}
+
+func (m *mockFilecoinDealMaker) Import(ctx context.Context, key common.Hash, data []byte) (string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	rootCid := "cid-" + key.Hex()
+	m.imported[rootCid] = append([]byte{}, data...)
+	m.staged[key] = true
+	return rootCid, nil
+}
+
+func (m *mockFilecoinDealMaker) RemoveImport(ctx context.Context, key common.Hash) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.staged, key)
+	return nil
+}
+
+func (m *mockFilecoinDealMaker) stagedCount() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.staged)
+}
+
+func (m *mockFilecoinDealMaker) StartDeal(ctx context.Context, rootCid string, miner string) (string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	proposalCid := fmt.Sprintf("proposal-%d", len(m.proposals))
+	m.proposals[proposalCid] = 0
+	m.miners = append(m.miners, miner)
+	return proposalCid, nil
+}
+
+func (m *mockFilecoinDealMaker) DealState(ctx context.Context, proposalCid string) (filecoinStorageDealState, string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.proposals[proposalCid], "", nil
+}
+
+func (m *mockFilecoinDealMaker) Retrieve(ctx context.Context, rootCid string) ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	data, ok := m.imported[rootCid]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return data, nil
+}
+
+func (m *mockFilecoinDealMaker) setAllDealStates(state filecoinStorageDealState) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for proposalCid := range m.proposals {
+		m.proposals[proposalCid] = state
+	}
+}
+
+func TestFilecoinColdStorageService(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	trackerPath := filepath.Join(t.TempDir(), "deals.json")
+	tracker, err := newFilecoinDealTracker(trackerPath)
+	Require(t, err)
+	dealMaker := newMockFilecoinDealMaker()
+	fcs := &FilecoinColdStorageService{
+		baseStorageService: NewMemoryBackedStorageService(ctx),
+		dealMaker:          dealMaker,
+		tracker:            tracker,
+		config: FilecoinColdStorageConfig{
+			Miners:             []string{"f01000", "f02000"},
+			DealDurationEpochs: DefaultFilecoinColdStorageConfig.DealDurationEpochs,
+			ArchiveAfter:       0,
+			CheckInterval:      time.Minute,
+			MaxDealAttempts:    3,
+		},
+	}
+
+	val1 := []byte("The first value")
+	key1 := dastree.Hash(val1)
+	Require(t, fcs.Put(ctx, val1, timeout))
+
+	// The first deal fails and is retried with the next miner.
+	fcs.processDeals(ctx)
+	dealMaker.setAllDealStates(filecoinStorageDealProposalRejected)
+	fcs.processDeals(ctx)
+	fcs.processDeals(ctx)
+	if len(dealMaker.miners) != 2 || dealMaker.miners[0] != "f01000" || dealMaker.miners[1] != "f02000" {
+		Fail(t, "unexpected deal attempts", dealMaker.miners)
+	}
+	dealMaker.setAllDealStates(filecoinStorageDealActive)
+	fcs.processDeals(ctx)
+	record, _ := fcs.tracker.get(key1)
+	if record.State != filecoinDealActive {
+		Fail(t, "expected active deal, got", record.State)
+	}
+	if record.DealEnd <= timeout {
+		Fail(t, "deal should last beyond the data's timeout, ends at", record.DealEnd)
+	}
+	if dealMaker.stagedCount() != 0 {
+		Fail(t, "imported data wasn't removed once the deal was active")
+	}
+
+	// Once the base storage no longer has the data, it is retrieved from Filecoin.
+	// The deal state must have been persisted for a restarted service to do so.
+	tracker, err = newFilecoinDealTracker(trackerPath)
+	Require(t, err)
+	fcs.tracker = tracker
+	fcs.baseStorageService = NewMemoryBackedStorageService(ctx)
+	val, err := fcs.GetByHash(ctx, key1)
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		t.Fatal(val, val1)
+	}
+	_, err = fcs.GetByHash(ctx, dastree.Hash(append(val1, 0)))
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	// Data that can't be archived is dropped, along with its imported data,
+	// once it has used up its attempts.
+	val2 := []byte("The second value")
+	key2 := dastree.Hash(val2)
+	Require(t, fcs.Put(ctx, val2, timeout))
+	for i := 0; i < fcs.config.MaxDealAttempts; i++ {
+		fcs.processDeals(ctx)
+		dealMaker.setAllDealStates(filecoinStorageDealProposalRejected)
+		fcs.processDeals(ctx)
+	}
+	if _, ok := fcs.tracker.get(key2); ok {
+		Fail(t, "failed deal is still tracked")
+	}
+	if dealMaker.stagedCount() != 0 {
+		Fail(t, "imported data wasn't removed once the deal failed")
+	}
+
+	// Data past its timeout, which base storage discards, is still retrieved
+	// from Filecoin.
+	record, _ = fcs.tracker.get(key1)
+	record.Timeout = uint64(time.Now().Unix()) - 1
+	fcs.tracker.update(key1, record)
+	fcs.processDeals(ctx)
+	fcs.baseStorageService = NewMemoryBackedStorageService(ctx)
+	val, err = fcs.GetByHash(ctx, key1)
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		t.Fatal(val, val1)
+	}
+
+	// Active deals are dropped once they end.
+	record.DealEnd = uint64(time.Now().Unix()) - 1
+	fcs.tracker.update(key1, record)
+	fcs.processDeals(ctx)
+	if _, ok := fcs.tracker.get(key1); ok {
+		Fail(t, "ended deal is still tracked")
+	}
+	tracker, err = newFilecoinDealTracker(trackerPath)
+	Require(t, err)
+	if len(tracker.snapshot()) != 0 {
+		Fail(t, "finished deals were persisted", tracker.snapshot())
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/googleapis/gax-go/v2"
+	"google.golang.org/api/option"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/pretty"
+
+	flag "github.com/spf13/pflag"
+)
+
+// GoogleCloudStorageOperator is the subset of Google Cloud Storage operations
+// used by GoogleCloudStorageService, so the client can be replaced in tests.
+type GoogleCloudStorageOperator interface {
+	Upload(ctx context.Context, bucket, objectName string, value []byte, customTime time.Time) error
+	Download(ctx context.Context, bucket, objectName string) ([]byte, error)
+	BucketExists(ctx context.Context, bucket string) error
+	Close() error
+}
+
+type GoogleCloudStorageServiceConfig struct {
+	Enable                 bool          `koanf:"enable"`
+	Bucket                 string        `koanf:"bucket"`
+	ObjectPrefix           string        `koanf:"object-prefix"`
+	CredentialsFile        string        `koanf:"credentials-file"`
+	DiscardAfterTimeout    bool          `koanf:"discard-after-timeout"`
+	MaxRetryAttempts       int           `koanf:"max-retry-attempts"`
+	RetryInitialBackoff    time.Duration `koanf:"retry-initial-backoff"`
+	RetryMaxBackoff        time.Duration `koanf:"retry-max-backoff"`
+	SyncFromStorageService bool          `koanf:"sync-from-storage-service"`
+	SyncToStorageService   bool          `koanf:"sync-to-storage-service"`
+}
+
+var DefaultGoogleCloudStorageServiceConfig = GoogleCloudStorageServiceConfig{
+	MaxRetryAttempts:    5,
+	RetryInitialBackoff: 250 * time.Millisecond,
+	RetryMaxBackoff:     10 * time.Second,
+}
+
+func GoogleCloudStorageConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultGoogleCloudStorageServiceConfig.Enable, "enable storage/retrieval of sequencer batch data from a Google Cloud Storage bucket")
+	f.String(prefix+".bucket", DefaultGoogleCloudStorageServiceConfig.Bucket, "Google Cloud Storage bucket")
+	f.String(prefix+".object-prefix", DefaultGoogleCloudStorageServiceConfig.ObjectPrefix, "prefix to add to Google Cloud Storage objects")
+	f.String(prefix+".credentials-file", DefaultGoogleCloudStorageServiceConfig.CredentialsFile, "path to a service account JSON key file; if not set, Application Default Credentials are used")
+	f.Bool(prefix+".discard-after-timeout", DefaultGoogleCloudStorageServiceConfig.DiscardAfterTimeout, "set the object Custom-Time to the expiry timeout so a bucket lifecycle rule (daysSinceCustomTime) can discard it")
+	f.Int(prefix+".max-retry-attempts", DefaultGoogleCloudStorageServiceConfig.MaxRetryAttempts, "maximum number of attempts for a Google Cloud Storage request that failed with a transient error")
+	f.Duration(prefix+".retry-initial-backoff", DefaultGoogleCloudStorageServiceConfig.RetryInitialBackoff, "initial backoff between retries of failed Google Cloud Storage requests")
+	f.Duration(prefix+".retry-max-backoff", DefaultGoogleCloudStorageServiceConfig.RetryMaxBackoff, "maximum backoff between retries of failed Google Cloud Storage requests")
+	f.Bool(prefix+".sync-from-storage-service", DefaultGoogleCloudStorageServiceConfig.SyncFromStorageService, "enable Google Cloud Storage to be used as a source for regular sync storage")
+	f.Bool(prefix+".sync-to-storage-service", DefaultGoogleCloudStorageServiceConfig.SyncToStorageService, "enable Google Cloud Storage to be used as a sink for regular sync storage")
+}
+
+type GoogleCloudStorageService struct {
+	operator            GoogleCloudStorageOperator
+	bucket              string
+	objectPrefix        string
+	discardAfterTimeout bool
+}
+
+func NewGoogleCloudStorageService(ctx context.Context, config GoogleCloudStorageServiceConfig) (StorageService, error) {
+	var opts []option.ClientOption
+	if config.CredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(config.CredentialsFile))
+	}
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating Google Cloud Storage client: %w", err)
+	}
+	// All objects are content addressed, so retrying uploads is always safe.
+	retryOptions := []storage.RetryOption{
+		storage.WithPolicy(storage.RetryAlways),
+		storage.WithBackoff(gax.Backoff{
+			Initial:    config.RetryInitialBackoff,
+			Max:        config.RetryMaxBackoff,
+			Multiplier: 2,
+		}),
+	}
+	if config.MaxRetryAttempts > 0 {
+		retryOptions = append(retryOptions, storage.WithMaxAttempts(config.MaxRetryAttempts))
+	}
+	return &GoogleCloudStorageService{
+		operator:            &googleCloudStorageClient{client: client, retryOptions: retryOptions},
+		bucket:              config.Bucket,
+		objectPrefix:        config.ObjectPrefix,
+		discardAfterTimeout: config.DiscardAfterTimeout,
+	}, nil
+}
+
+type googleCloudStorageClient struct {
+	client       *storage.Client
+	retryOptions []storage.RetryOption
+}
+
+func (c *googleCloudStorageClient) Upload(ctx context.Context, bucket, objectName string, value []byte, customTime time.Time) error {
+	w := c.client.Bucket(bucket).Object(objectName).Retryer(c.retryOptions...).NewWriter(ctx)
+	if !customTime.IsZero() {
+		w.CustomTime = customTime
+	}
+	if _, err := w.Write(value); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (c *googleCloudStorageClient) Download(ctx context.Context, bucket, objectName string) ([]byte, error) {
+	reader, err := c.client.Bucket(bucket).Object(objectName).Retryer(c.retryOptions...).NewReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+func (c *googleCloudStorageClient) BucketExists(ctx context.Context, bucket string) error {
+	_, err := c.client.Bucket(bucket).Retryer(c.retryOptions...).Attrs(ctx)
+	return err
+}
+
+func (c *googleCloudStorageClient) Close() error {
+	return c.client.Close()
+}
+
+func (gcs *GoogleCloudStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.GoogleCloudStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", gcs)
+
+	value, err := gcs.operator.Download(ctx, gcs.bucket, gcs.objectPrefix+EncodeStorageServiceKey(key))
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		log.Error("das.GoogleCloudStorageService.GetByHash", "err", err)
+		return nil, err
+	}
+	return value, nil
+}
+
+func (gcs *GoogleCloudStorageService) Put(ctx context.Context, value []byte, timeout uint64) error {
+	logPut("das.GoogleCloudStorageService.Store", value, timeout, gcs)
+	var customTime time.Time
+	if gcs.discardAfterTimeout {
+		customTime = time.Unix(int64(timeout), 0)
+	}
+	err := gcs.operator.Upload(ctx, gcs.bucket, gcs.objectPrefix+EncodeStorageServiceKey(dastree.Hash(value)), value, customTime)
+	if err != nil {
+		log.Error("das.GoogleCloudStorageService.Store", "err", err)
+	}
+	return err
+}
+
+func (gcs *GoogleCloudStorageService) putKeyValue(ctx context.Context, key common.Hash, value []byte) error {
+	err := gcs.operator.Upload(ctx, gcs.bucket, gcs.objectPrefix+EncodeStorageServiceKey(key), value, time.Time{})
+	if err != nil {
+		log.Error("das.GoogleCloudStorageService.putKeyValue", "err", err)
+	}
+	return err
+}
+
+func (gcs *GoogleCloudStorageService) Sync(ctx context.Context) error {
+	return nil
+}
+
+func (gcs *GoogleCloudStorageService) Close(ctx context.Context) error {
+	return gcs.operator.Close()
+}
+
+func (gcs *GoogleCloudStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	if gcs.discardAfterTimeout {
+		return arbstate.DiscardAfterDataTimeout, nil
+	}
+	return arbstate.KeepForever, nil
+}
+
+func (gcs *GoogleCloudStorageService) String() string {
+	return fmt.Sprintf("GoogleCloudStorageService(:%s)", gcs.bucket)
+}
+
+func (gcs *GoogleCloudStorageService) HealthCheck(ctx context.Context) error {
+	return gcs.operator.BucketExists(ctx, gcs.bucket)
+}
@@ -0,0 +1,86 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+type mockGoogleCloudStorageOperator struct {
+	mutex   sync.Mutex
+	objects map[string][]byte
+}
+
+func (m *mockGoogleCloudStorageOperator) Upload(ctx context.Context, bucket, objectName string, value []byte, customTime time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.objects[bucket+"/"+objectName] = append([]byte{}, value...)
+	return nil
+}
+
+func (m *mockGoogleCloudStorageOperator) Download(ctx context.Context, bucket, objectName string) ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	value, ok := m.objects[bucket+"/"+objectName]
+	if !ok {
+		return nil, storage.ErrObjectNotExist
+	}
+	return value, nil
+}
+
+func (m *mockGoogleCloudStorageOperator) BucketExists(ctx context.Context, bucket string) error {
+	return nil
+}
+
+func (m *mockGoogleCloudStorageOperator) Close() error {
+	return nil
+}
+
+func NewTestGoogleCloudStorageService(config GoogleCloudStorageServiceConfig) StorageService {
+	return &GoogleCloudStorageService{
+		operator:     &mockGoogleCloudStorageOperator{objects: make(map[string][]byte)},
+		bucket:       config.Bucket,
+		objectPrefix: config.ObjectPrefix,
+	}
+}
+
+func TestGoogleCloudStorageService(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	gcsService := NewTestGoogleCloudStorageService(GoogleCloudStorageServiceConfig{
+		Bucket:       "bucket",
+		ObjectPrefix: "prefix/",
+	})
+
+	val1 := []byte("The first value")
+	val1CorrectKey := dastree.Hash(val1)
+	val2IncorrectKey := dastree.Hash(append(val1, 0))
+
+	_, err := gcsService.GetByHash(ctx, val1CorrectKey)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	err = gcsService.Put(ctx, val1, timeout)
+	Require(t, err)
+
+	_, err = gcsService.GetByHash(ctx, val2IncorrectKey)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	val, err := gcsService.GetByHash(ctx, val1CorrectKey)
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		t.Fatal(val, val1)
+	}
+}
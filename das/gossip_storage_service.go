@@ -0,0 +1,205 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+type GossipConfig struct {
+	Enable    bool          `koanf:"enable"`
+	Peers     []string      `koanf:"peers"`
+	Interval  time.Duration `koanf:"interval"`
+	Window    time.Duration `koanf:"window"`
+	MaxHashes int           `koanf:"max-hashes"`
+}
+
+var DefaultGossipConfig = GossipConfig{
+	Enable:    false,
+	Peers:     []string{},
+	Interval:  30 * time.Second,
+	Window:    time.Hour,
+	MaxHashes: 100000,
+}
+
+func GossipConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultGossipConfig.Enable, "enable exchanging lists of recently stored hashes with other committee members over REST, and fetching any data this DAS is missing from them")
+	f.StringSlice(prefix+".peers", DefaultGossipConfig.Peers, "list of REST URLs of other committee members to gossip with, including 'http://' or 'https://' prefixes and port numbers (defaults to rest-aggregator.urls)")
+	f.Duration(prefix+".interval", DefaultGossipConfig.Interval, "interval between requests to each peer for its recently stored hashes")
+	f.Duration(prefix+".window", DefaultGossipConfig.Window, "how long a stored hash is kept in the list of recently stored hashes served to peers")
+	f.Int(prefix+".max-hashes", DefaultGossipConfig.MaxHashes, "max number of hashes kept in the list of recently stored hashes served to peers")
+}
+
+var (
+	gossipFetchedCounter = metrics.NewRegisteredCounter("arb/das/gossip/fetched", nil)
+	gossipFailedCounter  = metrics.NewRegisteredCounter("arb/das/gossip/failed", nil)
+)
+
+// RecentHash is an entry in the list of recently stored hashes a DAS serves
+// to its peers.
+type RecentHash struct {
+	Hash           common.Hash `json:"hash"`
+	ExpirationTime uint64      `json:"expirationTime"`
+	StoredAt       int64       `json:"storedAt"`
+}
+
+// RecentHashesReader is implemented by readers that can list the hashes
+// stored since a given time, in the order they were stored.
+type RecentHashesReader interface {
+	RecentHashes(since time.Time) []RecentHash
+}
+
+type gossipPeer struct {
+	client *RestfulDasClient
+	since  time.Time
+}
+
+// GossipStorageService records the hashes of data Put to it, and periodically
+// asks each peer for the hashes it has recently stored, fetching any data
+// that isn't in the underlying storage from that peer. Data fetched from
+// peers is recorded too, so it spreads to members that aren't peered with
+// the member that first stored it.
+type GossipStorageService struct {
+	StorageService
+	stopwaiter.StopWaiter
+
+	config GossipConfig
+	peers  []*gossipPeer
+
+	recentMutex sync.Mutex
+	recent      []RecentHash
+}
+
+func NewGossipStorageService(config GossipConfig, storageService StorageService, peerURLs []string) (*GossipStorageService, error) {
+	if len(peerURLs) == 0 {
+		return nil, errors.New("at least one peer must be configured for gossip, with gossip.peers or rest-aggregator.urls")
+	}
+	var peers []*gossipPeer
+	for _, url := range peerURLs {
+		client, err := NewRestfulDasClientFromURL(url)
+		if err != nil {
+			return nil, err
+		}
+		peers = append(peers, &gossipPeer{client: client})
+	}
+	return &GossipStorageService{
+		StorageService: storageService,
+		config:         config,
+		peers:          peers,
+	}, nil
+}
+
+func (g *GossipStorageService) Start(ctx context.Context) {
+	g.StopWaiter.Start(ctx, g)
+	g.CallIteratively(g.gossip)
+}
+
+func (g *GossipStorageService) Put(ctx context.Context, data []byte, expirationTime uint64) error {
+	if err := g.StorageService.Put(ctx, data, expirationTime); err != nil {
+		return err
+	}
+	g.record(dastree.Hash(data), expirationTime)
+	return nil
+}
+
+func (g *GossipStorageService) record(hash common.Hash, expirationTime uint64) {
+	g.recentMutex.Lock()
+	defer g.recentMutex.Unlock()
+	now := time.Now()
+	g.recent = append(g.recent, RecentHash{
+		Hash:           hash,
+		ExpirationTime: expirationTime,
+		StoredAt:       now.Unix(),
+	})
+	cutoff := now.Add(-g.config.Window).Unix()
+	drop := 0
+	for drop < len(g.recent) && (g.recent[drop].StoredAt < cutoff || len(g.recent)-drop > g.config.MaxHashes) {
+		drop++
+	}
+	if drop > 0 {
+		g.recent = append([]RecentHash(nil), g.recent[drop:]...)
+	}
+}
+
+func (g *GossipStorageService) RecentHashes(since time.Time) []RecentHash {
+	g.recentMutex.Lock()
+	defer g.recentMutex.Unlock()
+	cutoff := time.Now().Add(-g.config.Window).Unix()
+	var hashes []RecentHash
+	for _, recent := range g.recent {
+		if recent.StoredAt >= since.Unix() && recent.StoredAt >= cutoff {
+			hashes = append(hashes, recent)
+		}
+	}
+	return hashes
+}
+
+func (g *GossipStorageService) gossip(ctx context.Context) time.Duration {
+	for _, peer := range g.peers {
+		if err := g.gossipWith(ctx, peer); err != nil && ctx.Err() == nil {
+			log.Warn("das.GossipStorageService: failed to gossip with peer", "peer", peer.client.url, "err", err)
+		}
+	}
+	return g.config.Interval
+}
+
+func (g *GossipStorageService) gossipWith(ctx context.Context, peer *gossipPeer) error {
+	hashes, err := peer.client.RecentHashes(ctx, peer.since)
+	if err != nil {
+		return err
+	}
+	now := uint64(time.Now().Unix())
+	for _, recent := range hashes {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if recent.ExpirationTime < now {
+			continue
+		}
+		_, err := g.StorageService.GetByHash(ctx, recent.Hash)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		data, err := peer.client.GetByHash(ctx, recent.Hash)
+		if err == nil {
+			err = g.Put(ctx, data, recent.ExpirationTime)
+		}
+		if err != nil {
+			gossipFailedCounter.Inc(1)
+			log.Warn("das.GossipStorageService: failed to fetch data from peer", "peer", peer.client.url, "hash", recent.Hash, "err", err)
+			continue
+		}
+		gossipFetchedCounter.Inc(1)
+	}
+	// Hashes stored in the same second as the last one seen are asked for
+	// again, as more may have been stored in that second since.
+	if len(hashes) > 0 {
+		peer.since = time.Unix(hashes[len(hashes)-1].StoredAt, 0)
+	}
+	return nil
+}
+
+func (g *GossipStorageService) Close(ctx context.Context) error {
+	g.StopOnly()
+	return g.StorageService.Close(ctx)
+}
+
+func (g *GossipStorageService) String() string {
+	return "GossipStorageService(" + g.StorageService.String() + ")"
+}
@@ -0,0 +1,73 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+func TestGossipStorageService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := DefaultGossipConfig
+	config.Enable = true
+
+	source, err := NewGossipStorageService(config, NewMemoryBackedStorageService(ctx), []string{"http://localhost:1"})
+	Require(t, err)
+	server, port, err := NewRestfulDasServerOnRandomPort(LocalServerAddressForTest, source)
+	Require(t, err)
+	defer func() {
+		Require(t, server.Shutdown())
+	}()
+
+	target, err := NewGossipStorageService(config, NewMemoryBackedStorageService(ctx), []string{fmt.Sprintf("http://%s:%d", LocalServerAddressForTest, port)})
+	Require(t, err)
+
+	data := []byte("gossiped batch data")
+	expirationTime := uint64(time.Now().Add(time.Hour).Unix())
+	Require(t, source.Put(ctx, data, expirationTime))
+	Require(t, source.Put(ctx, []byte("expired batch data"), uint64(time.Now().Add(-time.Hour).Unix())))
+
+	target.gossip(ctx)
+
+	returnedData, err := target.GetByHash(ctx, dastree.Hash(data))
+	Require(t, err)
+	if !bytes.Equal(data, returnedData) {
+		Fail(t, "gossiped data doesn't match", returnedData, data)
+	}
+	if _, err := target.GetByHash(ctx, dastree.Hash([]byte("expired batch data"))); err == nil {
+		Fail(t, "expired data shouldn't be gossiped")
+	}
+	recent := target.RecentHashes(time.Time{})
+	if len(recent) != 1 || recent[0].Hash != dastree.Hash(data) || recent[0].ExpirationTime != expirationTime {
+		Fail(t, "fetched data should be recorded as recently stored", recent)
+	}
+}
+
+func TestGossipRecentHashesBounded(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := DefaultGossipConfig
+	config.MaxHashes = 3
+	gossip, err := NewGossipStorageService(config, NewMemoryBackedStorageService(ctx), []string{"http://localhost:1"})
+	Require(t, err)
+	for i := 0; i < 5; i++ {
+		Require(t, gossip.Put(ctx, []byte{byte(i)}, uint64(time.Now().Add(time.Hour).Unix())))
+	}
+	recent := gossip.RecentHashes(time.Time{})
+	if len(recent) != 3 || recent[0].Hash != dastree.Hash([]byte{2}) {
+		Fail(t, "expected only the most recent hashes to be kept", recent)
+	}
+	if len(gossip.RecentHashes(time.Now().Add(time.Minute))) != 0 {
+		Fail(t, "expected no hashes stored after the since time")
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/pretty"
+)
+
+type DASGRPCClient struct { // implements DataAvailabilityServiceWriter
+	conn   *grpc.ClientConn
+	target string
+}
+
+func NewDASGRPCClient(target string, options ...grpc.DialOption) (*DASGRPCClient, error) {
+	options = append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(grpcCodec{})),
+	}, options...)
+	conn, err := grpc.Dial(target, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &DASGRPCClient{
+		conn:   conn,
+		target: target,
+	}, nil
+}
+
+func grpcMethod(name string) string {
+	return "/" + dasGRPCServiceDesc.ServiceName + "/" + name
+}
+
+func (c *DASGRPCClient) Store(ctx context.Context, message []byte, timeout uint64, reqSig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	log.Trace("das.DASGRPCClient.Store(...)", "message", pretty.FirstFewBytes(message), "timeout", time.Unix(int64(timeout), 0), "sig", pretty.FirstFewBytes(reqSig), "this", *c)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.conn.NewStream(ctx, &dasGRPCServiceDesc.Streams[0], grpcMethod("Store"))
+	if err != nil {
+		return nil, err
+	}
+	req := &grpcStoreRequest{Timeout: timeout, Sig: reqSig}
+	for first := true; first || len(message) > 0; first = false {
+		req.Chunk = message
+		if len(req.Chunk) > grpcChunkSize {
+			req.Chunk = req.Chunk[:grpcChunkSize]
+		}
+		if err := stream.SendMsg(req); err != nil {
+			if errors.Is(err, io.EOF) {
+				// The server ended the stream early, eg rejecting the
+				// store, and RecvMsg returns why.
+				break
+			}
+			return nil, err
+		}
+		message = message[len(req.Chunk):]
+		req = &grpcStoreRequest{}
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	var ret grpcStoreResponse
+	if err := stream.RecvMsg(&ret); err != nil {
+		return nil, err
+	}
+	respSig, err := blsSignatures.SignatureFromBytes(ret.Sig)
+	if err != nil {
+		return nil, err
+	}
+	return &arbstate.DataAvailabilityCertificate{
+		DataHash:    common.BytesToHash(ret.DataHash),
+		Timeout:     ret.Timeout,
+		SignersMask: ret.SignersMask,
+		Sig:         respSig,
+		KeysetHash:  common.BytesToHash(ret.KeysetHash),
+		Version:     byte(ret.Version),
+	}, nil
+}
+
+func (c *DASGRPCClient) GetByHash(ctx context.Context, hash common.Hash) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.conn.NewStream(ctx, &dasGRPCServiceDesc.Streams[1], grpcMethod("Retrieve"))
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(&grpcRetrieveRequest{Hash: hash[:]}); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	var data []byte
+	for {
+		var res grpcRetrieveResponse
+		err := stream.RecvMsg(&res)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if status.Code(err) == codes.NotFound {
+			return nil, ErrNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, res.Chunk...)
+	}
+	if !dastree.ValidHash(hash, data) {
+		return nil, arbstate.ErrHashMismatch
+	}
+	return data, nil
+}
+
+func (c *DASGRPCClient) KeysetFromHash(ctx context.Context, keysetHash common.Hash) ([]byte, error) {
+	var res grpcKeysetFromHashResponse
+	err := c.conn.Invoke(ctx, grpcMethod("KeysetFromHash"), &grpcKeysetFromHashRequest{KeysetHash: keysetHash[:]}, &res)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !dastree.ValidHash(keysetHash, res.Keyset) {
+		return nil, arbstate.ErrHashMismatch
+	}
+	return res.Keyset, nil
+}
+
+func (c *DASGRPCClient) Close() error {
+	return c.conn.Close()
+}
+
+func (c *DASGRPCClient) String() string {
+	return fmt.Sprintf("DASGRPCClient{target:%s}", c.target)
+}
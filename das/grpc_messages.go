@@ -0,0 +1,171 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The messages of das.proto. They're few and flat enough that encoding them
+// with protowire is simpler than generating code, and the wire format stays
+// compatible with clients generated from das.proto in other languages.
+
+type grpcField struct {
+	num    protowire.Number
+	bytes  *[]byte
+	varint *uint64
+}
+
+type grpcMessage interface {
+	fields() []grpcField
+}
+
+// grpcMessageSize is the size of m's bytes fields, which are most of its
+// encoding, for the rate limits.
+func grpcMessageSize(m interface{}) int {
+	message, ok := m.(grpcMessage)
+	if !ok {
+		return 0
+	}
+	size := 0
+	for _, field := range message.fields() {
+		if field.bytes != nil {
+			size += len(*field.bytes)
+		}
+	}
+	return size
+}
+
+type grpcStoreRequest struct {
+	Chunk   []byte
+	Timeout uint64
+	Sig     []byte
+}
+
+func (m *grpcStoreRequest) fields() []grpcField {
+	return []grpcField{{num: 1, bytes: &m.Chunk}, {num: 2, varint: &m.Timeout}, {num: 3, bytes: &m.Sig}}
+}
+
+type grpcStoreResponse struct {
+	DataHash    []byte
+	Timeout     uint64
+	SignersMask uint64
+	KeysetHash  []byte
+	Sig         []byte
+	Version     uint64
+}
+
+func (m *grpcStoreResponse) fields() []grpcField {
+	return []grpcField{
+		{num: 1, bytes: &m.DataHash},
+		{num: 2, varint: &m.Timeout},
+		{num: 3, varint: &m.SignersMask},
+		{num: 4, bytes: &m.KeysetHash},
+		{num: 5, bytes: &m.Sig},
+		{num: 6, varint: &m.Version},
+	}
+}
+
+type grpcRetrieveRequest struct {
+	Hash []byte
+}
+
+func (m *grpcRetrieveRequest) fields() []grpcField {
+	return []grpcField{{num: 1, bytes: &m.Hash}}
+}
+
+type grpcRetrieveResponse struct {
+	Chunk []byte
+}
+
+func (m *grpcRetrieveResponse) fields() []grpcField {
+	return []grpcField{{num: 1, bytes: &m.Chunk}}
+}
+
+type grpcKeysetFromHashRequest struct {
+	KeysetHash []byte
+}
+
+func (m *grpcKeysetFromHashRequest) fields() []grpcField {
+	return []grpcField{{num: 1, bytes: &m.KeysetHash}}
+}
+
+type grpcKeysetFromHashResponse struct {
+	Keyset []byte
+}
+
+func (m *grpcKeysetFromHashResponse) fields() []grpcField {
+	return []grpcField{{num: 1, bytes: &m.Keyset}}
+}
+
+// grpcCodec encodes grpcMessages as protobuf. It's forced on the DAS gRPC
+// server and clients rather than registered, which would replace the proto
+// codec for every other gRPC user in the process.
+type grpcCodec struct{}
+
+func (grpcCodec) Name() string {
+	return "proto"
+}
+
+func (grpcCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(grpcMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC message type %T", v)
+	}
+	var b []byte
+	// proto3 omits fields with default values.
+	for _, f := range m.fields() {
+		if f.bytes != nil && len(*f.bytes) > 0 {
+			b = protowire.AppendTag(b, f.num, protowire.BytesType)
+			b = protowire.AppendBytes(b, *f.bytes)
+		}
+		if f.varint != nil && *f.varint != 0 {
+			b = protowire.AppendTag(b, f.num, protowire.VarintType)
+			b = protowire.AppendVarint(b, *f.varint)
+		}
+	}
+	return b, nil
+}
+
+func (grpcCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(grpcMessage)
+	if !ok {
+		return fmt.Errorf("unexpected gRPC message type %T", v)
+	}
+	fields := m.fields()
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		var field *grpcField
+		for i := range fields {
+			if fields[i].num == num {
+				field = &fields[i]
+			}
+		}
+		switch {
+		case field != nil && field.bytes != nil && typ == protowire.BytesType:
+			var value []byte
+			value, n = protowire.ConsumeBytes(data)
+			if n >= 0 {
+				// The buffer may be reused by gRPC once Unmarshal returns.
+				*field.bytes = append([]byte{}, value...)
+			}
+		case field != nil && field.varint != nil && typ == protowire.VarintType:
+			*field.varint, n = protowire.ConsumeVarint(data)
+		default:
+			// Skip unknown fields, so newer clients and servers stay compatible.
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+	}
+	return nil
+}
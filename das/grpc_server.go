@@ -0,0 +1,371 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/util/pretty"
+)
+
+var (
+	grpcStoreRequestGauge      = metrics.NewRegisteredGauge("arb/das/grpc/store/requests", nil)
+	grpcStoreSuccessGauge      = metrics.NewRegisteredGauge("arb/das/grpc/store/success", nil)
+	grpcStoreFailureGauge      = metrics.NewRegisteredGauge("arb/das/grpc/store/failure", nil)
+	grpcStoreStoredBytesGauge  = metrics.NewRegisteredGauge("arb/das/grpc/store/bytes", nil)
+	grpcStoreDurationHistogram = metrics.NewRegisteredHistogram("arb/das/grpc/store/duration", nil, metrics.NewBoundedHistogramSample())
+
+	grpcRetrieveRequestGauge  = metrics.NewRegisteredGauge("arb/das/grpc/retrieve/requests", nil)
+	grpcRetrieveSuccessGauge  = metrics.NewRegisteredGauge("arb/das/grpc/retrieve/success", nil)
+	grpcRetrieveFailureGauge  = metrics.NewRegisteredGauge("arb/das/grpc/retrieve/failure", nil)
+	grpcRetrieveReturnedBytes = metrics.NewRegisteredGauge("arb/das/grpc/retrieve/bytes", nil)
+)
+
+// grpcChunkSize keeps each streamed message well under gRPC's default 4MB
+// message size limit.
+const grpcChunkSize = 1 << 20
+
+type dasGRPCService interface {
+	store(stream grpc.ServerStream) error
+	retrieve(stream grpc.ServerStream) error
+	keysetFromHash(ctx context.Context, req *grpcKeysetFromHashRequest) (*grpcKeysetFromHashResponse, error)
+}
+
+var dasGRPCServiceDesc = grpc.ServiceDesc{
+	ServiceName: "das.v1.DataAvailabilityService",
+	HandlerType: (*dasGRPCService)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "KeysetFromHash",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				req := &grpcKeysetFromHashRequest{}
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				return srv.(dasGRPCService).keysetFromHash(ctx, req)
+			},
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName: "Store",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				return srv.(dasGRPCService).store(stream)
+			},
+			ClientStreams: true,
+		},
+		{
+			StreamName: "Retrieve",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				return srv.(dasGRPCService).retrieve(stream)
+			},
+			ServerStreams: true,
+		},
+	},
+	Metadata: "das/das.proto",
+}
+
+type DASGRPCServer struct {
+	daReader       DataAvailabilityServiceReader
+	daWriter       DataAvailabilityServiceWriter
+	maxMessageSize int
+}
+
+func StartDASGRPCServer(ctx context.Context, addr string, portNum uint64, tlsConfig *tls.Config, ipLimiter *IPRateLimiter, storeBounds StoreBoundsConfig, daReader DataAvailabilityServiceReader, daWriter DataAvailabilityServiceWriter) (*grpc.Server, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(addr, strconv.FormatUint(portNum, 10)))
+	if err != nil {
+		return nil, err
+	}
+	return StartDASGRPCServerOnListener(ctx, listener, tlsConfig, ipLimiter, storeBounds, daReader, daWriter)
+}
+
+// StartDASGRPCServerOnListener serves over TLS if tlsConfig isn't nil. Unlike
+// the HTTP servers, gRPC does the TLS handshake itself, so listener shouldn't
+// be a TLS listener. As with the HTTP-RPC server, storeBounds only limits the
+// messages buffered, and daWriter may be nil to disable storing.
+func StartDASGRPCServerOnListener(ctx context.Context, listener net.Listener, tlsConfig *tls.Config, ipLimiter *IPRateLimiter, storeBounds StoreBoundsConfig, daReader DataAvailabilityServiceReader, daWriter DataAvailabilityServiceWriter) (*grpc.Server, error) {
+	options := []grpc.ServerOption{
+		grpc.ForceServerCodec(grpcCodec{}),
+		grpc.UnaryInterceptor(grpcUnaryInterceptor(ipLimiter)),
+		grpc.StreamInterceptor(grpcStreamInterceptor(ipLimiter)),
+	}
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	srv := grpc.NewServer(options...)
+	srv.RegisterService(&dasGRPCServiceDesc, &DASGRPCServer{
+		daReader:       daReader,
+		daWriter:       daWriter,
+		maxMessageSize: chunkedStoreMaxSize(storeBounds),
+	})
+
+	go func() {
+		err := srv.Serve(listener)
+		if err != nil {
+			return
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		srv.GracefulStop()
+	}()
+	return srv, nil
+}
+
+// grpcCredentials records the client's bearer token, sent as authorization
+// metadata, and verified TLS client certificate in ctx, as the HTTP-RPC server
+// does, for the store authenticators.
+func grpcCredentials(ctx context.Context) context.Context {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if authorization := md.Get("authorization"); len(authorization) > 0 {
+			ctx = contextWithBearerToken(ctx, authorization[0])
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			ctx = contextWithVerifiedClientCert(ctx, &tlsInfo.State)
+		}
+	}
+	return ctx
+}
+
+// grpcAllow takes a request from the client IP's limits, returning the IP to
+// charge the bytes transferred to.
+func grpcAllow(ctx context.Context, ipLimiter *IPRateLimiter) (string, error) {
+	if ipLimiter == nil {
+		return "", nil
+	}
+	var forwarded []string
+	if md, ok := metadata.FromIncomingContext(ctx); ok && ipLimiter.clientIPHeader != "" {
+		forwarded = md.Get(ipLimiter.clientIPHeader)
+	}
+	var remoteAddr string
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+	ip := clientIPFrom(forwarded, remoteAddr)
+	if allowed, wait := ipLimiter.limiter.allow(ip, 0); !allowed {
+		ipRateLimitedCounter.Inc(1)
+		return "", status.Errorf(codes.ResourceExhausted, "%v, retry in %v", ErrRateLimited, wait.Round(time.Millisecond))
+	}
+	return ip, nil
+}
+
+func grpcCharge(ipLimiter *IPRateLimiter, ip string, size int) {
+	if ipLimiter != nil {
+		ipLimiter.limiter.charge(ip, size)
+	}
+}
+
+func grpcUnaryInterceptor(ipLimiter *IPRateLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ip, err := grpcAllow(ctx, ipLimiter)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := handler(grpcCredentials(ctx), req)
+		size := grpcMessageSize(req)
+		if err == nil {
+			size += grpcMessageSize(resp)
+		}
+		grpcCharge(ipLimiter, ip, size)
+		return resp, err
+	}
+}
+
+// grpcServerStream gives the handlers the context with the client's
+// credentials, and counts the bytes streamed for the rate limits.
+type grpcServerStream struct {
+	grpc.ServerStream
+	ctx   context.Context
+	count int
+}
+
+func (s *grpcServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *grpcServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	s.count += grpcMessageSize(m)
+	return nil
+}
+
+func (s *grpcServerStream) SendMsg(m interface{}) error {
+	s.count += grpcMessageSize(m)
+	return s.ServerStream.SendMsg(m)
+}
+
+func grpcStreamInterceptor(ipLimiter *IPRateLimiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ip, err := grpcAllow(stream.Context(), ipLimiter)
+		if err != nil {
+			return err
+		}
+		wrapped := &grpcServerStream{ServerStream: stream, ctx: grpcCredentials(stream.Context())}
+		err = handler(srv, wrapped)
+		grpcCharge(ipLimiter, ip, wrapped.count)
+		return err
+	}
+}
+
+func grpcError(err error) error {
+	var payloadTooLarge *PayloadTooLargeError
+	var timeoutOutOfBounds *TimeoutOutOfBoundsError
+	var overloaded *OverloadedError
+	switch {
+	case errors.As(err, &payloadTooLarge), errors.As(err, &timeoutOutOfBounds):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrStoreDisabled):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, ErrInvalidBearerToken), errors.Is(err, ErrClientCertRequired):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, ErrRequestQueueFull):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, ErrRateLimited), errors.As(err, &overloaded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Unknown, err.Error())
+	}
+}
+
+func (serv *DASGRPCServer) store(stream grpc.ServerStream) error {
+	rpcStart := time.Now()
+	grpcStoreRequestGauge.Inc(1)
+	success := false
+	defer func() {
+		if success {
+			grpcStoreSuccessGauge.Inc(1)
+		} else {
+			grpcStoreFailureGauge.Inc(1)
+		}
+		grpcStoreDurationHistogram.Update(time.Since(rpcStart).Nanoseconds())
+	}()
+
+	if serv.daWriter == nil {
+		return grpcError(ErrStoreDisabled)
+	}
+	// Clients that couldn't store the message are turned away before it's
+	// received.
+	if err := authorizeStore(stream.Context(), serv.daWriter); err != nil {
+		return grpcError(err)
+	}
+	var message []byte
+	var timeout uint64
+	var sig []byte
+	for first := true; ; first = false {
+		req := &grpcStoreRequest{}
+		err := stream.RecvMsg(req)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if first {
+			timeout = req.Timeout
+			sig = req.Sig
+		}
+		if len(message)+len(req.Chunk) > serv.maxMessageSize {
+			return grpcError(&PayloadTooLargeError{Size: len(message) + len(req.Chunk), MaxSize: serv.maxMessageSize})
+		}
+		message = append(message, req.Chunk...)
+	}
+	log.Trace("das.DASGRPCServer.store", "message", pretty.FirstFewBytes(message), "message length", len(message), "timeout", time.Unix(int64(timeout), 0), "sig", pretty.FirstFewBytes(sig))
+
+	cert, err := serv.daWriter.Store(stream.Context(), message, timeout, sig)
+	if err != nil {
+		return grpcError(err)
+	}
+	grpcStoreStoredBytesGauge.Inc(int64(len(message)))
+	success = true
+	return stream.SendMsg(&grpcStoreResponse{
+		DataHash:    cert.DataHash[:],
+		Timeout:     cert.Timeout,
+		SignersMask: cert.SignersMask,
+		KeysetHash:  cert.KeysetHash[:],
+		Sig:         blsSignatures.SignatureToBytes(cert.Sig),
+		Version:     uint64(cert.Version),
+	})
+}
+
+func (serv *DASGRPCServer) retrieve(stream grpc.ServerStream) error {
+	grpcRetrieveRequestGauge.Inc(1)
+	success := false
+	defer func() {
+		if success {
+			grpcRetrieveSuccessGauge.Inc(1)
+		} else {
+			grpcRetrieveFailureGauge.Inc(1)
+		}
+	}()
+
+	req := &grpcRetrieveRequest{}
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	if len(req.Hash) != common.HashLength {
+		return status.Errorf(codes.InvalidArgument, "hash must be %d bytes", common.HashLength)
+	}
+	data, err := serv.daReader.GetByHash(stream.Context(), common.BytesToHash(req.Hash))
+	if err != nil {
+		return grpcError(err)
+	}
+	for len(data) > 0 {
+		chunk := data
+		if len(chunk) > grpcChunkSize {
+			chunk = chunk[:grpcChunkSize]
+		}
+		if err := stream.SendMsg(&grpcRetrieveResponse{Chunk: chunk}); err != nil {
+			return err
+		}
+		grpcRetrieveReturnedBytes.Inc(int64(len(chunk)))
+		data = data[len(chunk):]
+	}
+	success = true
+	return nil
+}
+
+func (serv *DASGRPCServer) keysetFromHash(ctx context.Context, req *grpcKeysetFromHashRequest) (*grpcKeysetFromHashResponse, error) {
+	if len(req.KeysetHash) != common.HashLength {
+		return nil, status.Errorf(codes.InvalidArgument, "keyset hash must be %d bytes", common.HashLength)
+	}
+	// Keysets are stored like any other data, under their hash.
+	keyset, err := serv.daReader.GetByHash(ctx, common.BytesToHash(req.KeysetHash))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if _, err := arbstate.DeserializeKeyset(bytes.NewReader(keyset), true); err != nil {
+		return nil, status.Errorf(codes.NotFound, "data with hash %v isn't a keyset: %v", common.BytesToHash(req.KeysetHash), err)
+	}
+	return &grpcKeysetFromHashResponse{Keyset: keyset}, nil
+}
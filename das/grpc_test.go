@@ -0,0 +1,209 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestGRPC(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	privKey, err := blsSignatures.GeneratePrivKeyString()
+	Require(t, err)
+	config := DataAvailabilityConfig{
+		Enable: true,
+		Key: KeyConfig{
+			PrivKey: privKey,
+		},
+		ParentChainNodeURL: "none",
+	}
+	storage := NewMemoryBackedStorageService(ctx)
+	localDas, err := NewSignAfterStoreDASWriter(ctx, config, storage)
+	Require(t, err)
+	Require(t, storage.Put(ctx, localDas.keysetBytes, uint64(time.Now().Add(time.Hour).Unix())))
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	Require(t, err)
+	_, err = StartDASGRPCServerOnListener(ctx, lis, nil, nil, DefaultStoreBoundsConfig, storage, localDas)
+	Require(t, err)
+	client, err := NewDASGRPCClient(lis.Addr().String())
+	Require(t, err)
+	defer client.Close()
+
+	// Larger than a chunk, so both Store and Retrieve stream it.
+	msg := testhelpers.RandomizeSlice(make([]byte, grpcChunkSize*2+100))
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	cert, err := client.Store(ctx, msg, timeout, nil)
+	Require(t, err)
+	if cert.DataHash != dastree.Hash(msg) || cert.Timeout != timeout || cert.KeysetHash != localDas.keysetHash {
+		Fail(t, "unexpected certificate", cert)
+	}
+	verified, err := blsSignatures.VerifySignature(cert.Sig, cert.SerializeSignableFields(), *localDas.pubKey)
+	Require(t, err)
+	if !verified {
+		Fail(t, "certificate signature doesn't verify")
+	}
+
+	retrieved, err := client.GetByHash(ctx, cert.DataHash)
+	Require(t, err)
+	if !bytes.Equal(retrieved, msg) {
+		Fail(t, "failed to retrieve correct message")
+	}
+
+	keyset, err := client.KeysetFromHash(ctx, cert.KeysetHash)
+	Require(t, err)
+	if !bytes.Equal(keyset, localDas.keysetBytes) {
+		Fail(t, "failed to retrieve correct keyset")
+	}
+	if _, err := client.KeysetFromHash(ctx, cert.DataHash); err == nil {
+		Fail(t, "data that isn't a keyset returned as one")
+	}
+
+	if _, err := client.GetByHash(ctx, dastree.Hash([]byte("absent data"))); !errors.Is(err, ErrNotFound) {
+		Fail(t, "expected not found error", err)
+	}
+}
+
+func TestGRPCTLS(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	certDir := t.TempDir()
+	ca := issueTestCert(t, certDir, "ca", nil, 0)
+	serverCert := issueTestCert(t, certDir, "server", ca, x509.ExtKeyUsageServerAuth)
+	keyPair, err := tls.LoadX509KeyPair(serverCert.certFile, serverCert.keyFile)
+	Require(t, err)
+	caPEM, err := os.ReadFile(ca.certFile)
+	Require(t, err)
+	rootCAs := x509.NewCertPool()
+	rootCAs.AppendCertsFromPEM(caPEM)
+
+	storage := NewMemoryBackedStorageService(ctx)
+	data := []byte("Testing gRPC over TLS.")
+	Require(t, storage.Put(ctx, data, uint64(time.Now().Add(time.Hour).Unix())))
+	lis, err := net.Listen("tcp", "localhost:0")
+	Require(t, err)
+	_, err = StartDASGRPCServerOnListener(ctx, lis, &tls.Config{Certificates: []tls.Certificate{keyPair}, MinVersion: tls.VersionTLS12}, nil, DefaultStoreBoundsConfig, storage, nil)
+	Require(t, err)
+
+	client, err := NewDASGRPCClient(lis.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12})))
+	Require(t, err)
+	defer client.Close()
+	retrieved, err := client.GetByHash(ctx, dastree.Hash(data))
+	Require(t, err)
+	if !bytes.Equal(retrieved, data) {
+		Fail(t, "failed to retrieve correct message over TLS")
+	}
+
+	plaintextClient, err := NewDASGRPCClient(lis.Addr().String())
+	Require(t, err)
+	defer plaintextClient.Close()
+	if _, err := plaintextClient.GetByHash(ctx, dastree.Hash(data)); err == nil {
+		Fail(t, "expected a plaintext client to be refused")
+	}
+}
+
+// grpcBearerToken sends a bearer token with each gRPC call.
+type grpcBearerToken string
+
+func (t grpcBearerToken) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+func (t grpcBearerToken) RequireTransportSecurity() bool {
+	return false
+}
+
+func TestGRPCStoreAuthAndLimits(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	privKey, err := blsSignatures.GeneratePrivKeyString()
+	Require(t, err)
+	config := DataAvailabilityConfig{
+		Enable: true,
+		Key: KeyConfig{
+			PrivKey: privKey,
+		},
+		ParentChainNodeURL: "none",
+	}
+	storage := NewMemoryBackedStorageService(ctx)
+	localDas, err := NewSignAfterStoreDASWriter(ctx, config, storage)
+	Require(t, err)
+	tokensFile := filepath.Join(t.TempDir(), "tokens")
+	Require(t, os.WriteFile(tokensFile, []byte("token\n"), 0600))
+	authenticator, err := NewTokenStoreAuthenticator(localDas, TokenAuthConfig{TokensFile: tokensFile})
+	Require(t, err)
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+
+	start := func(ipLimiter *IPRateLimiter, writer DataAvailabilityServiceWriter) string {
+		t.Helper()
+		lis, err := net.Listen("tcp", "localhost:0")
+		Require(t, err)
+		_, err = StartDASGRPCServerOnListener(ctx, lis, nil, ipLimiter, StoreBoundsConfig{MaxPayloadSize: 500}, storage, writer)
+		Require(t, err)
+		return lis.Addr().String()
+	}
+	dial := func(addr string, token string) *DASGRPCClient {
+		t.Helper()
+		var options []grpc.DialOption
+		if token != "" {
+			options = append(options, grpc.WithPerRPCCredentials(grpcBearerToken(token)))
+		}
+		client, err := NewDASGRPCClient(addr, options...)
+		Require(t, err)
+		t.Cleanup(func() { client.Close() })
+		return client
+	}
+
+	readOnly := start(nil, nil)
+	if _, err := dial(readOnly, "").Store(ctx, []byte("stored"), timeout, nil); err == nil || !strings.Contains(err.Error(), ErrStoreDisabled.Error()) {
+		Fail(t, "expected store to a server without a writer to be rejected", err)
+	}
+
+	authenticated := start(nil, NewStoreNotifier(authenticator))
+	if _, err := dial(authenticated, "").Store(ctx, []byte("stored"), timeout, nil); err == nil || !strings.Contains(err.Error(), ErrInvalidBearerToken.Error()) {
+		Fail(t, "expected store without a token to be rejected", err)
+	}
+	if _, err := dial(authenticated, "wrong-token").Store(ctx, []byte("stored"), timeout, nil); err == nil || !strings.Contains(err.Error(), ErrInvalidBearerToken.Error()) {
+		Fail(t, "expected store with the wrong token to be rejected", err)
+	}
+	client := dial(authenticated, "token")
+	cert, err := client.Store(ctx, []byte("stored"), timeout, nil)
+	Require(t, err)
+	if cert.DataHash != dastree.Hash([]byte("stored")) {
+		Fail(t, "unexpected certificate", cert)
+	}
+	if _, err := client.Store(ctx, make([]byte, 501), timeout, nil); err == nil || !strings.Contains(err.Error(), "larger than the maximum of 500 bytes") {
+		Fail(t, "expected store larger than max-payload-size to be rejected", err)
+	}
+
+	limited := start(NewIPRateLimiter(RateLimitConfig{PerIP: ClientRateLimitConfig{RequestsPerSecond: 0.001, RequestBurst: 1}}), nil)
+	limitedClient := dial(limited, "")
+	if _, err := limitedClient.GetByHash(ctx, cert.DataHash); err != nil {
+		Fail(t, "expected the first request to be allowed", err)
+	}
+	if _, err := limitedClient.GetByHash(ctx, cert.DataHash); err == nil || !strings.Contains(err.Error(), ErrRateLimited.Error()) {
+		Fail(t, "expected the second request to be rate limited", err)
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/colinmarc/hdfs/v2"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/pretty"
+
+	flag "github.com/spf13/pflag"
+)
+
+// HDFSOperator is the subset of HDFS operations used by HDFSStorageService, so
+// the client can be replaced in tests.
+type HDFSOperator interface {
+	// WriteFile atomically replaces the file at name.
+	WriteFile(name string, data []byte) error
+	// ReadFile returns an error matching os.ErrNotExist if the file doesn't exist.
+	ReadFile(name string) ([]byte, error)
+	MkdirAll(dir string) error
+	Stat(name string) error
+	Close() error
+}
+
+type HDFSStorageServiceConfig struct {
+	Enable                 bool     `koanf:"enable"`
+	NamenodeAddresses      []string `koanf:"namenode-addresses"`
+	User                   string   `koanf:"user"`
+	DataDir                string   `koanf:"data-dir"`
+	BlockSize              int64    `koanf:"block-size"`
+	Replication            int      `koanf:"replication"`
+	SyncFromStorageService bool     `koanf:"sync-from-storage-service"`
+	SyncToStorageService   bool     `koanf:"sync-to-storage-service"`
+}
+
+var DefaultHDFSStorageServiceConfig = HDFSStorageServiceConfig{
+	DataDir: "/das",
+}
+
+func HDFSStorageConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultHDFSStorageServiceConfig.Enable, "enable storage/retrieval of sequencer batch data from an HDFS cluster")
+	f.StringSlice(prefix+".namenode-addresses", DefaultHDFSStorageServiceConfig.NamenodeAddresses, "HDFS namenode addresses as host:port; more than one can be given for high availability")
+	f.String(prefix+".user", DefaultHDFSStorageServiceConfig.User, "HDFS user to connect as")
+	f.String(prefix+".data-dir", DefaultHDFSStorageServiceConfig.DataDir, "HDFS directory in which to store data")
+	f.Int64(prefix+".block-size", DefaultHDFSStorageServiceConfig.BlockSize, "HDFS block size for new files in bytes (0 uses the cluster default)")
+	f.Int(prefix+".replication", DefaultHDFSStorageServiceConfig.Replication, "HDFS replication factor for new files (0 uses the cluster default)")
+	f.Bool(prefix+".sync-from-storage-service", DefaultHDFSStorageServiceConfig.SyncFromStorageService, "enable HDFS to be used as a source for regular sync storage")
+	f.Bool(prefix+".sync-to-storage-service", DefaultHDFSStorageServiceConfig.SyncToStorageService, "enable HDFS to be used as a sink for regular sync storage")
+}
+
+type HDFSStorageService struct {
+	operator  HDFSOperator
+	dataDir   string
+	namenodes []string
+}
+
+func NewHDFSStorageService(config HDFSStorageServiceConfig) (StorageService, error) {
+	if len(config.NamenodeAddresses) == 0 {
+		return nil, errors.New("hdfs-storage.namenode-addresses must be set")
+	}
+	client, err := hdfs.NewClient(hdfs.ClientOptions{
+		Addresses: config.NamenodeAddresses,
+		User:      config.User,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to HDFS: %w", err)
+	}
+	hs := &HDFSStorageService{
+		operator: &hdfsClient{
+			client:      client,
+			blockSize:   config.BlockSize,
+			replication: config.Replication,
+		},
+		dataDir:   config.DataDir,
+		namenodes: config.NamenodeAddresses,
+	}
+	if err := hs.operator.MkdirAll(config.DataDir); err != nil {
+		_ = hs.operator.Close()
+		return nil, fmt.Errorf("error creating HDFS directory %s: %w", config.DataDir, err)
+	}
+	return hs, nil
+}
+
+type hdfsClient struct {
+	client      *hdfs.Client
+	blockSize   int64
+	replication int
+}
+
+func (c *hdfsClient) WriteFile(name string, data []byte) error {
+	tmpName := fmt.Sprintf("%s.tmp-%d", name, time.Now().UnixNano())
+	err := func() error {
+		var writer *hdfs.FileWriter
+		var err error
+		if c.blockSize > 0 || c.replication > 0 {
+			defaults, err := c.client.ServerDefaults()
+			if err != nil {
+				return err
+			}
+			blockSize, replication := defaults.BlockSize, defaults.Replication
+			if c.blockSize > 0 {
+				blockSize = c.blockSize
+			}
+			if c.replication > 0 {
+				replication = c.replication
+			}
+			writer, err = c.client.CreateFile(tmpName, replication, blockSize, 0o644)
+			if err != nil {
+				return err
+			}
+		} else {
+			writer, err = c.client.Create(tmpName)
+			if err != nil {
+				return err
+			}
+		}
+		if _, err := writer.Write(data); err != nil {
+			_ = writer.Close()
+			return err
+		}
+		if err := writer.Close(); err != nil {
+			return err
+		}
+		// HDFS renames replace existing files.
+		return c.client.Rename(tmpName, name)
+	}()
+	if err != nil {
+		_ = c.client.Remove(tmpName)
+	}
+	return err
+}
+
+func (c *hdfsClient) ReadFile(name string) ([]byte, error) {
+	return c.client.ReadFile(name)
+}
+
+func (c *hdfsClient) MkdirAll(dir string) error {
+	return c.client.MkdirAll(dir, 0o755)
+}
+
+func (c *hdfsClient) Stat(name string) error {
+	_, err := c.client.Stat(name)
+	return err
+}
+
+func (c *hdfsClient) Close() error {
+	return c.client.Close()
+}
+
+func (hs *HDFSStorageService) filePath(key common.Hash) string {
+	return path.Join(hs.dataDir, EncodeStorageServiceKey(key))
+}
+
+func (hs *HDFSStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.HDFSStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", hs)
+
+	data, err := hs.operator.ReadFile(hs.filePath(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		log.Error("das.HDFSStorageService.GetByHash", "err", err)
+		return nil, err
+	}
+	return data, nil
+}
+
+func (hs *HDFSStorageService) Put(ctx context.Context, value []byte, timeout uint64) error {
+	logPut("das.HDFSStorageService.Store", value, timeout, hs)
+	err := hs.operator.WriteFile(hs.filePath(dastree.Hash(value)), value)
+	if err != nil {
+		log.Error("das.HDFSStorageService.Store", "err", err)
+	}
+	return err
+}
+
+func (hs *HDFSStorageService) putKeyValue(ctx context.Context, key common.Hash, value []byte) error {
+	err := hs.operator.WriteFile(hs.filePath(key), value)
+	if err != nil {
+		log.Error("das.HDFSStorageService.putKeyValue", "err", err)
+	}
+	return err
+}
+
+func (hs *HDFSStorageService) Sync(ctx context.Context) error {
+	return nil
+}
+
+func (hs *HDFSStorageService) Close(ctx context.Context) error {
+	return hs.operator.Close()
+}
+
+func (hs *HDFSStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	return arbstate.KeepForever, nil
+}
+
+func (hs *HDFSStorageService) String() string {
+	return fmt.Sprintf("HDFSStorageService(%s:%s)", strings.Join(hs.namenodes, ","), hs.dataDir)
+}
+
+func (hs *HDFSStorageService) HealthCheck(ctx context.Context) error {
+	return hs.operator.Stat(hs.dataDir)
+}
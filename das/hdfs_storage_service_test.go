@@ -0,0 +1,67 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+// mockHDFSOperator stores files on the local filesystem under root.
+type mockHDFSOperator struct {
+	root string
+}
+
+func (m *mockHDFSOperator) WriteFile(name string, data []byte) error {
+	return os.WriteFile(filepath.Join(m.root, name), data, 0o600)
+}
+
+func (m *mockHDFSOperator) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(m.root, name))
+}
+
+func (m *mockHDFSOperator) MkdirAll(dir string) error {
+	return os.MkdirAll(filepath.Join(m.root, dir), 0o700)
+}
+
+func (m *mockHDFSOperator) Stat(name string) error {
+	_, err := os.Stat(filepath.Join(m.root, name))
+	return err
+}
+
+func (m *mockHDFSOperator) Close() error {
+	return nil
+}
+
+func TestHDFSStorageService(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	operator := &mockHDFSOperator{root: t.TempDir()}
+	Require(t, operator.MkdirAll("/das"))
+	hdfsService := &HDFSStorageService{
+		operator: operator,
+		dataDir:  "/das",
+	}
+
+	val1 := []byte("The first value")
+	key1 := dastree.Hash(val1)
+	_, err := hdfsService.GetByHash(ctx, key1)
+	if !errors.Is(err, ErrNotFound) {
+		Fail(t, "expected ErrNotFound", err)
+	}
+	Require(t, hdfsService.Put(ctx, val1, timeout))
+	val, err := hdfsService.GetByHash(ctx, key1)
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		Fail(t, val, val1)
+	}
+	Require(t, hdfsService.HealthCheck(ctx))
+}
@@ -0,0 +1,203 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	flag "github.com/spf13/pflag"
+)
+
+type IpfsRemotePinningConfig struct {
+	Enable      bool   `koanf:"enable"`
+	Endpoint    string `koanf:"endpoint"`
+	AccessToken string `koanf:"access-token"`
+}
+
+var DefaultIpfsRemotePinningConfig = IpfsRemotePinningConfig{}
+
+func IpfsRemotePinningConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultIpfsRemotePinningConfig.Enable, "additionally pin stored sequencer batch data with a remote IPFS pinning service")
+	f.String(prefix+".endpoint", DefaultIpfsRemotePinningConfig.Endpoint, "base URL of a remote pinning service implementing the IPFS Pinning Service API, eg https://api.pinata.cloud/psa")
+	f.String(prefix+".access-token", DefaultIpfsRemotePinningConfig.AccessToken, "bearer token for the remote pinning service")
+}
+
+// ipfsRemotePinningClient is a minimal client for the IPFS Pinning Service API,
+// see https://ipfs.github.io/pinning-services-api-spec/
+type ipfsRemotePinningClient struct {
+	endpoint    string
+	accessToken string
+	client      *http.Client
+}
+
+func newIpfsRemotePinningClient(config IpfsRemotePinningConfig) (*ipfsRemotePinningClient, error) {
+	if !(strings.HasPrefix(config.Endpoint, "http://") || strings.HasPrefix(config.Endpoint, "https://")) {
+		return nil, fmt.Errorf("protocol prefix 'http://' or 'https://' must be specified for the remote pinning service endpoint; got '%s'", config.Endpoint)
+	}
+	return &ipfsRemotePinningClient{
+		endpoint:    strings.TrimSuffix(config.Endpoint, "/"),
+		accessToken: config.AccessToken,
+		client:      &http.Client{},
+	}, nil
+}
+
+type ipfsPinRequest struct {
+	Cid  string `json:"cid"`
+	Name string `json:"name,omitempty"`
+}
+
+type ipfsPinStatus struct {
+	RequestId string `json:"requestid"`
+	Status    string `json:"status"`
+}
+
+func (c *ipfsRemotePinningClient) do(ctx context.Context, method, path string, body []byte, expectedStatus int) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != expectedStatus {
+		res.Body.Close()
+		return nil, fmt.Errorf("HTTP error with status %d returned by remote pinning service: %s", res.StatusCode, http.StatusText(res.StatusCode))
+	}
+	return res, nil
+}
+
+// Pin asks the remote pinning service to pin the given CID, returning the
+// request id that can later be used to remove the pin.
+func (c *ipfsRemotePinningClient) Pin(ctx context.Context, cid string, name string) (string, error) {
+	body, err := json.Marshal(ipfsPinRequest{Cid: cid, Name: name})
+	if err != nil {
+		return "", err
+	}
+	res, err := c.do(ctx, http.MethodPost, "/pins", body, http.StatusAccepted)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	var status ipfsPinStatus
+	if err := json.NewDecoder(res.Body).Decode(&status); err != nil {
+		return "", err
+	}
+	if status.RequestId == "" {
+		return "", errors.New("remote pinning service returned empty request id")
+	}
+	return status.RequestId, nil
+}
+
+func (c *ipfsRemotePinningClient) Unpin(ctx context.Context, requestId string) error {
+	res, err := c.do(ctx, http.MethodDelete, "/pins/"+requestId, nil, http.StatusAccepted)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+type ipfsPinRecord struct {
+	Expiration       uint64   `json:"expiration"`
+	Cids             []string `json:"cids"`
+	RemoteRequestIds []string `json:"remoteRequestIds,omitempty"`
+}
+
+// ipfsPinTracker records when the pins for each stored batch expire, so they
+// can be removed after the DAS timeout. It is persisted as JSON so that
+// expirations survive restarts.
+type ipfsPinTracker struct {
+	mutex sync.Mutex
+	path  string
+	pins  map[string]*ipfsPinRecord
+	dirty bool
+}
+
+func newIpfsPinTracker(path string) (*ipfsPinTracker, error) {
+	t := &ipfsPinTracker{
+		path: path,
+		pins: make(map[string]*ipfsPinRecord),
+	}
+	if path == "" {
+		return t, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &t.pins); err != nil {
+		return nil, fmt.Errorf("invalid IPFS pin expiration file %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// add records the pins for a batch. If the batch was already being tracked the
+// later of the two expirations is kept.
+func (t *ipfsPinTracker) add(key string, record *ipfsPinRecord) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if existing, ok := t.pins[key]; ok {
+		if existing.Expiration > record.Expiration {
+			record.Expiration = existing.Expiration
+		}
+		record.RemoteRequestIds = append(record.RemoteRequestIds, existing.RemoteRequestIds...)
+	}
+	t.pins[key] = record
+	t.dirty = true
+}
+
+func (t *ipfsPinTracker) expired(now uint64) map[string]*ipfsPinRecord {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	res := make(map[string]*ipfsPinRecord)
+	for key, record := range t.pins {
+		if record.Expiration <= now {
+			res[key] = record
+		}
+	}
+	return res
+}
+
+func (t *ipfsPinTracker) remove(key string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.pins, key)
+	t.dirty = true
+}
+
+func (t *ipfsPinTracker) flush() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.path == "" || !t.dirty {
+		return nil
+	}
+	data, err := json.Marshal(t.pins)
+	if err != nil {
+		return err
+	}
+	tmpPath := t.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, t.path); err != nil {
+		return err
+	}
+	t.dirty = false
+	return nil
+}
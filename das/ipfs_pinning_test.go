@@ -0,0 +1,92 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestIpfsRemotePinningClient(t *testing.T) {
+	var mutex sync.Mutex
+	pins := make(map[string]string)
+	numPins := func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(pins)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/pins":
+			var req ipfsPinRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			requestId := "req-" + req.Cid
+			pins[requestId] = req.Cid
+			w.WriteHeader(http.StatusAccepted)
+			_ = json.NewEncoder(w).Encode(ipfsPinStatus{RequestId: requestId, Status: "queued"})
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/pins/"):
+			delete(pins, strings.TrimPrefix(r.URL.Path, "/pins/"))
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client, err := newIpfsRemotePinningClient(IpfsRemotePinningConfig{Enable: true, Endpoint: server.URL, AccessToken: "secret"})
+	Require(t, err)
+	requestId, err := client.Pin(ctx, "bafkreia", "name")
+	Require(t, err)
+	if numPins() != 1 {
+		Fail(t, "expected one pin, got", numPins())
+	}
+	Require(t, client.Unpin(ctx, requestId))
+	if numPins() != 0 {
+		Fail(t, "expected no pins, got", numPins())
+	}
+
+	badClient, err := newIpfsRemotePinningClient(IpfsRemotePinningConfig{Enable: true, Endpoint: server.URL, AccessToken: "wrong"})
+	Require(t, err)
+	if _, err := badClient.Pin(ctx, "bafkreia", "name"); err == nil {
+		Fail(t, "expected pin with bad token to fail")
+	}
+}
+
+func TestIpfsPinTrackerPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ipfsPinExpirationsFilename)
+	tracker, err := newIpfsPinTracker(path)
+	Require(t, err)
+	tracker.add("a", &ipfsPinRecord{Expiration: 10, Cids: []string{"cid1"}})
+	tracker.add("b", &ipfsPinRecord{Expiration: 100, Cids: []string{"cid2"}})
+	// Re-adding with an earlier expiration keeps the later one.
+	tracker.add("b", &ipfsPinRecord{Expiration: 5, Cids: []string{"cid2"}})
+	Require(t, tracker.flush())
+
+	reloaded, err := newIpfsPinTracker(path)
+	Require(t, err)
+	expired := reloaded.expired(50)
+	if len(expired) != 1 || expired["a"] == nil {
+		Fail(t, "unexpected expired pins", expired)
+	}
+	reloaded.remove("a")
+	if len(reloaded.expired(1000)) != 1 {
+		Fail(t, "expected only one remaining pin")
+	}
+}
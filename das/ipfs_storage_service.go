@@ -11,8 +11,11 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"math/rand"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -26,6 +29,7 @@ import (
 	"github.com/offchainlabs/nitro/cmd/ipfshelper"
 	"github.com/offchainlabs/nitro/das/dastree"
 	"github.com/offchainlabs/nitro/util/pretty"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
 	flag "github.com/spf13/pflag"
 )
 
@@ -37,8 +41,11 @@ type IpfsStorageServiceConfig struct {
 	Peers       []string      `koanf:"peers"`
 
 	// Pinning options
-	PinAfterGet   bool    `koanf:"pin-after-get"`
-	PinPercentage float64 `koanf:"pin-percentage"`
+	PinAfterGet        bool                    `koanf:"pin-after-get"`
+	PinPercentage      float64                 `koanf:"pin-percentage"`
+	UnpinAfterTimeout  bool                    `koanf:"unpin-after-timeout"`
+	UnpinCheckInterval time.Duration           `koanf:"unpin-check-interval"`
+	RemotePinning      IpfsRemotePinningConfig `koanf:"remote-pinning"`
 }
 
 var DefaultIpfsStorageServiceConfig = IpfsStorageServiceConfig{
@@ -48,8 +55,11 @@ var DefaultIpfsStorageServiceConfig = IpfsStorageServiceConfig{
 	Profiles:    "",
 	Peers:       []string{},
 
-	PinAfterGet:   true,
-	PinPercentage: 100.0,
+	PinAfterGet:        true,
+	PinPercentage:      100.0,
+	UnpinAfterTimeout:  false,
+	UnpinCheckInterval: 10 * time.Minute,
+	RemotePinning:      DefaultIpfsRemotePinningConfig,
 }
 
 func IpfsStorageServiceConfigAddOptions(prefix string, f *flag.FlagSet) {
@@ -60,12 +70,20 @@ func IpfsStorageServiceConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.StringSlice(prefix+".peers", DefaultIpfsStorageServiceConfig.Peers, "list of IPFS peers to connect to, eg /ip4/1.2.3.4/tcp/12345/p2p/abc...xyz")
 	f.Bool(prefix+".pin-after-get", DefaultIpfsStorageServiceConfig.PinAfterGet, "pin sequencer batch data in IPFS")
 	f.Float64(prefix+".pin-percentage", DefaultIpfsStorageServiceConfig.PinPercentage, "percent of sequencer batch data to pin, as a floating point number in the range 0.0 to 100.0")
+	f.Bool(prefix+".unpin-after-timeout", DefaultIpfsStorageServiceConfig.UnpinAfterTimeout, "remove the pins of stored sequencer batch data once its expiry timeout has passed, allowing IPFS to garbage collect it")
+	f.Duration(prefix+".unpin-check-interval", DefaultIpfsStorageServiceConfig.UnpinCheckInterval, "how often to check for pins whose expiry timeout has passed")
+	IpfsRemotePinningConfigAddOptions(prefix+".remote-pinning", f)
 }
 
+const ipfsPinExpirationsFilename = "das-pin-expirations.json"
+
 type IpfsStorageService struct {
-	config     IpfsStorageServiceConfig
-	ipfsHelper *ipfshelper.IpfsHelper
-	ipfsApi    coreiface.CoreAPI
+	config        IpfsStorageServiceConfig
+	ipfsHelper    *ipfshelper.IpfsHelper
+	ipfsApi       coreiface.CoreAPI
+	remotePinning *ipfsRemotePinningClient
+	pinTracker    *ipfsPinTracker
+	stopWaiter    stopwaiter.StopWaiterSafe
 }
 
 func NewIpfsStorageService(ctx context.Context, config IpfsStorageServiceConfig) (*IpfsStorageService, error) {
@@ -79,11 +97,38 @@ func NewIpfsStorageService(ctx context.Context, config IpfsStorageServiceConfig)
 	}
 	log.Info("IPFS node started up", "hostAddresses", addrs)
 
-	return &IpfsStorageService{
+	ret := &IpfsStorageService{
 		config:     config,
 		ipfsHelper: ipfsHelper,
 		ipfsApi:    ipfsHelper.GetAPI(),
-	}, nil
+	}
+	if config.RemotePinning.Enable {
+		ret.remotePinning, err = newIpfsRemotePinningClient(config.RemotePinning)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.UnpinAfterTimeout {
+		var trackerPath string
+		if config.RepoDir != "" {
+			trackerPath = filepath.Join(config.RepoDir, ipfsPinExpirationsFilename)
+		}
+		ret.pinTracker, err = newIpfsPinTracker(trackerPath)
+		if err != nil {
+			return nil, err
+		}
+		if err := ret.stopWaiter.Start(ctx, ret); err != nil {
+			return nil, err
+		}
+		err = ret.stopWaiter.CallIterativelySafe(func(ctx context.Context) time.Duration {
+			ret.unpinExpired(ctx)
+			return config.UnpinCheckInterval
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
 }
 
 func hashToCid(hash common.Hash) (cid.Cid, error) {
@@ -168,16 +213,15 @@ func (s *IpfsStorageService) GetByHash(ctx context.Context, hash common.Hash) ([
 	return dastree.Content(hash, oracle)
 }
 
-// Put stores all the preimages required to reconstruct the dastree for single batch,
+// putBlocks stores all the preimages required to reconstruct the dastree for single batch,
 // ie the hashed data chunks and dastree nodes.
 // This takes advantage of IPFS supporting keccak256 on raw data blocks for calculating
 // its CIDs, and the fact that the dastree structure uses keccak256 for addressing its
 // nodes, to directly store the dastree structure in IPFS.
 // IPFS default block size is 256KB and dastree max block size is 64KB so each dastree
 // node and data chunk easily fits within an IPFS block.
-func (s *IpfsStorageService) Put(ctx context.Context, data []byte, timeout uint64) error {
-	logPut("das.IpfsStorageService.Put", data, timeout, s)
-
+// It returns the CIDs of the stored blocks.
+func (s *IpfsStorageService) putBlocks(ctx context.Context, data []byte) ([]string, error) {
 	var chunks [][]byte
 
 	record := func(_ common.Hash, value []byte) {
@@ -186,8 +230,13 @@ func (s *IpfsStorageService) Put(ctx context.Context, data []byte, timeout uint6
 
 	_ = dastree.RecordHash(record, data)
 
+	type putResult struct {
+		cid string
+		err error
+	}
+
 	numChunks := len(chunks)
-	resultChan := make(chan error, numChunks)
+	resultChan := make(chan putResult, numChunks)
 	for _, chunk := range chunks {
 		_chunk := chunk
 		go func() {
@@ -199,27 +248,96 @@ func (s *IpfsStorageService) Put(ctx context.Context, data []byte, timeout uint6
 				options.Block.Hash(multihash.KECCAK_256, -1), // Use keccak256 to calculate the hash to put in the block's
 				// CID, since it is the same algo used by dastree.
 				options.Block.Pin(true)) // Keep the data in the local IPFS repo, don't GC it.
-			if err == nil {
-				log.Trace("Wrote IPFS path", "path", blockStat.Path().String())
+			if err != nil {
+				resultChan <- putResult{err: err}
+				return
 			}
-			resultChan <- err
+			log.Trace("Wrote IPFS path", "path", blockStat.Path().String())
+			resultChan <- putResult{cid: blockStat.Path().Cid().String()}
 		}()
 	}
 
-	successfullyWrittenChunks := 0
-	for err := range resultChan {
-		if err != nil {
-			return err
+	cids := make([]string, 0, numChunks)
+	for len(cids) < numChunks {
+		result := <-resultChan
+		if result.err != nil {
+			return nil, result.err
+		}
+		cids = append(cids, result.cid)
+	}
+	return cids, nil
+}
+
+// Put stores the batch in the local IPFS repo and, if configured, pins it with the remote
+// pinning service and records its pins so they can be removed after the timeout.
+func (s *IpfsStorageService) Put(ctx context.Context, data []byte, timeout uint64) error {
+	logPut("das.IpfsStorageService.Put", data, timeout, s)
+
+	cids, err := s.putBlocks(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	hash := dastree.Hash(data)
+	var remoteRequestIds []string
+	if s.remotePinning != nil {
+		for _, c := range cids {
+			requestId, err := s.remotePinning.Pin(ctx, c, EncodeStorageServiceKey(hash))
+			if err != nil {
+				return fmt.Errorf("failed to pin %s with remote pinning service: %w", c, err)
+			}
+			remoteRequestIds = append(remoteRequestIds, requestId)
+		}
+	}
+	if s.pinTracker != nil {
+		s.pinTracker.add(EncodeStorageServiceKey(hash), &ipfsPinRecord{
+			Expiration:       timeout,
+			Cids:             cids,
+			RemoteRequestIds: remoteRequestIds,
+		})
+	}
+	return nil
+}
+
+// unpinExpired removes local and remote pins of batches whose timeout has passed.
+// Pins in IPFS are not reference counted, so a dastree node shared by two batches
+// with different timeouts is unpinned with whichever batch expires first.
+func (s *IpfsStorageService) unpinExpired(ctx context.Context) {
+	for key, record := range s.pinTracker.expired(uint64(time.Now().Unix())) {
+		failed := false
+		for _, c := range record.Cids {
+			parsed, err := cid.Decode(c)
+			if err != nil {
+				log.Warn("Invalid CID in IPFS pin expiration record", "cid", c, "err", err)
+				continue
+			}
+			err = s.ipfsApi.Pin().Rm(ctx, path.IpfsPath(parsed))
+			if err != nil && !strings.Contains(err.Error(), "not pinned") {
+				log.Warn("Failed to unpin expired data in IPFS", "key", key, "cid", c, "err", err)
+				failed = true
+			}
+		}
+		if s.remotePinning != nil {
+			for _, requestId := range record.RemoteRequestIds {
+				if err := s.remotePinning.Unpin(ctx, requestId); err != nil {
+					log.Warn("Failed to unpin expired data with remote pinning service", "key", key, "requestId", requestId, "err", err)
+					failed = true
+				}
+			}
 		}
-		successfullyWrittenChunks++
-		if successfullyWrittenChunks == numChunks {
-			return nil
+		if !failed {
+			s.pinTracker.remove(key)
 		}
 	}
-	panic("unreachable")
+	if err := s.pinTracker.flush(); err != nil {
+		log.Error("Failed to write IPFS pin expirations", "err", err)
+	}
 }
 
 func (s *IpfsStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	if s.config.UnpinAfterTimeout {
+		return arbstate.DiscardAfterDataTimeout, nil
+	}
 	return arbstate.KeepForever, nil
 }
 
@@ -228,6 +346,14 @@ func (s *IpfsStorageService) Sync(ctx context.Context) error {
 }
 
 func (s *IpfsStorageService) Close(ctx context.Context) error {
+	if s.pinTracker != nil {
+		if err := s.stopWaiter.StopAndWait(); err != nil {
+			return err
+		}
+		if err := s.pinTracker.flush(); err != nil {
+			log.Error("Failed to write IPFS pin expirations", "err", err)
+		}
+	}
 	return s.ipfsHelper.Close()
 }
 
@@ -237,7 +363,7 @@ func (s *IpfsStorageService) String() string {
 
 func (s *IpfsStorageService) HealthCheck(ctx context.Context) error {
 	testData := []byte("Test-Data")
-	err := s.Put(ctx, testData, 0)
+	_, err := s.putBlocks(ctx, testData)
 	if err != nil {
 		return err
 	}
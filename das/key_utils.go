@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/hex"
+	"fmt"
 	"io"
 	"os"
 
@@ -52,24 +53,31 @@ func GenerateAndStoreKeys(keyDir string) (*blsSignatures.PublicKey, *blsSignatur
 	if err != nil {
 		return nil, nil, err
 	}
-	pubKeyPath := keyDir + "/" + DefaultPubKeyFilename
-	pubKeyBytes := blsSignatures.PublicKeyToBytes(pubKey)
-	encodedPubKey := make([]byte, base64.StdEncoding.EncodedLen(len(pubKeyBytes)))
-	base64.StdEncoding.Encode(encodedPubKey, pubKeyBytes)
-	err = os.WriteFile(pubKeyPath, encodedPubKey, 0o600)
-	if err != nil {
+	if err := StoreKeys(keyDir, pubKey, privKey); err != nil {
 		return nil, nil, err
 	}
+	return &pubKey, &privKey, nil
+}
+
+// StoreKeys writes the keys to keyDir in the format ReadKeysFromFile reads.
+func StoreKeys(keyDir string, pubKey blsSignatures.PublicKey, privKey blsSignatures.PrivateKey) error {
+	if err := storePubKey(keyDir, pubKey); err != nil {
+		return err
+	}
 
 	privKeyPath := keyDir + "/" + DefaultPrivKeyFilename
 	privKeyBytes := blsSignatures.PrivateKeyToBytes(privKey)
 	encodedPrivKey := make([]byte, base64.StdEncoding.EncodedLen(len(privKeyBytes)))
 	base64.StdEncoding.Encode(encodedPrivKey, privKeyBytes)
-	err = os.WriteFile(privKeyPath, encodedPrivKey, 0o600)
-	if err != nil {
-		return nil, nil, err
-	}
-	return &pubKey, &privKey, nil
+	return os.WriteFile(privKeyPath, encodedPrivKey, 0o600)
+}
+
+func storePubKey(keyDir string, pubKey blsSignatures.PublicKey) error {
+	pubKeyPath := keyDir + "/" + DefaultPubKeyFilename
+	pubKeyBytes := blsSignatures.PublicKeyToBytes(pubKey)
+	encodedPubKey := make([]byte, base64.StdEncoding.EncodedLen(len(pubKeyBytes)))
+	base64.StdEncoding.Encode(encodedPubKey, pubKeyBytes)
+	return os.WriteFile(pubKeyPath, encodedPubKey, 0o600)
 }
 
 func ReadKeysFromFile(keyDir string) (*blsSignatures.PublicKey, blsSignatures.PrivateKey, error) {
@@ -102,6 +110,9 @@ func ReadPrivKeyFromFile(privKeyPath string) (blsSignatures.PrivateKey, error) {
 	if err != nil {
 		return nil, err
 	}
+	if isBLSKeystore(privKeyEncodedBytes) {
+		return nil, fmt.Errorf("%s is an encrypted keystore, which needs a passphrase to read", privKeyPath)
+	}
 	privKey, err := DecodeBase64BLSPrivateKey(privKeyEncodedBytes)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,214 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/syndtr/goleveldb/leveldb"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	ethleveldb "github.com/ethereum/go-ethereum/ethdb/leveldb"
+	ethpebble "github.com/ethereum/go-ethereum/ethdb/pebble"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/pretty"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+const (
+	keyValueDBCacheMB = 16
+	keyValueDBHandles = 16
+)
+
+// Expiry index entries are keyed by this prefix, the big-endian timeout and the
+// data hash, so iterating over the prefix visits them in order of timeout. Data
+// itself is keyed by its 32 byte hash, which can't collide with index keys.
+var keyValueDBExpiryPrefix = []byte("das-expiry-")
+
+// KeyValueDBStorageService stores data in one of the embedded key-value
+// databases supported by geth's ethdb, LevelDB or Pebble. Unlike badger these
+// have no native TTLs, so when discarding after timeout an expiry index is kept
+// alongside the data and swept periodically.
+type KeyValueDBStorageService struct {
+	db                  ethdb.KeyValueStore
+	engine              string
+	discardAfterTimeout bool
+	dirPath             string
+	stopWaiter          stopwaiter.StopWaiterSafe
+}
+
+func NewLevelDBStorageService(ctx context.Context, dirPath string, discardAfterTimeout bool) (StorageService, error) {
+	db, err := ethleveldb.New(dirPath, keyValueDBCacheMB, keyValueDBHandles, "das/leveldb/", false)
+	if err != nil {
+		return nil, err
+	}
+	return newKeyValueDBStorageService(ctx, db, "leveldb", dirPath, discardAfterTimeout)
+}
+
+// NewPebbleStorageService opens a Pebble database. Pebble's lower write
+// amplification suits large nodes storing many hash-keyed batches.
+func NewPebbleStorageService(ctx context.Context, dirPath string, discardAfterTimeout bool) (StorageService, error) {
+	db, err := ethpebble.New(dirPath, keyValueDBCacheMB, keyValueDBHandles, "das/pebble/", false)
+	if err != nil {
+		return nil, err
+	}
+	return newKeyValueDBStorageService(ctx, db, "pebble", dirPath, discardAfterTimeout)
+}
+
+func newKeyValueDBStorageService(ctx context.Context, db ethdb.KeyValueStore, engine string, dirPath string, discardAfterTimeout bool) (*KeyValueDBStorageService, error) {
+	ret := &KeyValueDBStorageService{
+		db:                  db,
+		engine:              engine,
+		discardAfterTimeout: discardAfterTimeout,
+		dirPath:             dirPath,
+	}
+	if err := ret.stopWaiter.Start(ctx, ret); err != nil {
+		return nil, err
+	}
+	err := ret.stopWaiter.LaunchThreadSafe(func(myCtx context.Context) {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+		defer func() {
+			if err := ret.db.Close(); err != nil {
+				log.Error("Failed to close DB", "err", err)
+			}
+		}()
+		for {
+			select {
+			case <-ticker.C:
+				if err := ret.discardExpired(myCtx, uint64(time.Now().Unix())); err != nil {
+					log.Error("das.KeyValueDBStorageService failed to discard expired data", "engine", ret.engine, "err", err)
+				}
+			case <-myCtx.Done():
+				return
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func keyValueDBExpiryKey(timeout uint64, key []byte) []byte {
+	expiryKey := make([]byte, 0, len(keyValueDBExpiryPrefix)+8+len(key))
+	expiryKey = append(expiryKey, keyValueDBExpiryPrefix...)
+	expiryKey = binary.BigEndian.AppendUint64(expiryKey, timeout)
+	return append(expiryKey, key...)
+}
+
+// discardExpired deletes all data whose timeout is at or before now.
+func (kvs *KeyValueDBStorageService) discardExpired(ctx context.Context, now uint64) error {
+	it := kvs.db.NewIterator(keyValueDBExpiryPrefix, nil)
+	defer it.Release()
+	batch := kvs.db.NewBatch()
+	for it.Next() {
+		if ctx.Err() != nil {
+			break
+		}
+		expiryKey := it.Key()
+		if len(expiryKey) != len(keyValueDBExpiryPrefix)+8+32 {
+			continue
+		}
+		timeout := binary.BigEndian.Uint64(expiryKey[len(keyValueDBExpiryPrefix):])
+		if timeout > now {
+			break
+		}
+		if err := batch.Delete(expiryKey[len(keyValueDBExpiryPrefix)+8:]); err != nil {
+			return err
+		}
+		if err := batch.Delete(expiryKey); err != nil {
+			return err
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+func isKeyValueDBNotFound(err error) bool {
+	return errors.Is(err, leveldb.ErrNotFound) || errors.Is(err, pebble.ErrNotFound)
+}
+
+func (kvs *KeyValueDBStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.KeyValueDBStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", kvs)
+
+	ret, err := kvs.db.Get(key.Bytes())
+	if isKeyValueDBNotFound(err) {
+		return nil, ErrNotFound
+	}
+	return ret, err
+}
+
+func (kvs *KeyValueDBStorageService) Put(ctx context.Context, data []byte, timeout uint64) error {
+	logPut("das.KeyValueDBStorageService.Put", data, timeout, kvs)
+
+	key := dastree.HashBytes(data)
+	batch := kvs.db.NewBatch()
+	if err := batch.Put(key, data); err != nil {
+		return err
+	}
+	if kvs.discardAfterTimeout {
+		if err := batch.Put(keyValueDBExpiryKey(timeout, key), nil); err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
+
+func (kvs *KeyValueDBStorageService) putKeyValue(ctx context.Context, key common.Hash, value []byte) error {
+	return kvs.db.Put(key.Bytes(), value)
+}
+
+func (kvs *KeyValueDBStorageService) Sync(ctx context.Context) error {
+	return nil
+}
+
+func (kvs *KeyValueDBStorageService) Close(ctx context.Context) error {
+	return kvs.stopWaiter.StopAndWait()
+}
+
+func (kvs *KeyValueDBStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	if kvs.discardAfterTimeout {
+		return arbstate.DiscardAfterDataTimeout, nil
+	}
+	return arbstate.KeepForever, nil
+}
+
+func (kvs *KeyValueDBStorageService) String() string {
+	return fmt.Sprintf("KeyValueDB(%s:%s)", kvs.engine, kvs.dirPath)
+}
+
+func (kvs *KeyValueDBStorageService) HealthCheck(ctx context.Context) error {
+	testData := []byte("Test-Data")
+	err := kvs.Put(ctx, testData, uint64(time.Now().Add(time.Minute).Unix()))
+	if err != nil {
+		return err
+	}
+	res, err := kvs.GetByHash(ctx, dastree.Hash(testData))
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(res, testData) {
+		return errors.New("invalid GetByHash result")
+	}
+	return nil
+}
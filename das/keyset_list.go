@@ -0,0 +1,104 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+// KeysetInfo describes a keyset the DAS can serve, decoded so committee
+// rotations can be audited without fetching and parsing the keyset itself.
+type KeysetInfo struct {
+	KeysetHash    common.Hash `json:"keysetHash"`
+	AssumedHonest uint64      `json:"assumedHonest"`
+	PubKeys       []string    `json:"pubKeys"` // base64 encoded
+	// CreationBlock is the parent chain block the keyset was set valid in.
+	CreationBlock uint64 `json:"creationBlock"`
+	// Valid is false once the sequencer inbox has invalidated the keyset.
+	Valid bool `json:"valid"`
+}
+
+// KeysetLister lists the current and historical keysets, oldest first.
+type KeysetLister interface {
+	Keysets(ctx context.Context) ([]KeysetInfo, error)
+}
+
+// keysetListCacheTime bounds how often the whole history of SetValidKeyset
+// events is fetched from the parent chain.
+const keysetListCacheTime = time.Minute
+
+type keysetListCache struct {
+	mutex     sync.Mutex
+	keysets   []KeysetInfo
+	fetchedAt time.Time
+}
+
+func keysetInfoFromBytes(keysetHash common.Hash, keysetBytes []byte, creationBlock uint64, valid bool) (KeysetInfo, error) {
+	if !dastree.ValidHash(keysetHash, keysetBytes) {
+		return KeysetInfo{}, fmt.Errorf("keyset doesn't match its hash %v", keysetHash)
+	}
+	keyset, err := arbstate.DeserializeKeyset(bytes.NewReader(keysetBytes), true)
+	if err != nil {
+		return KeysetInfo{}, fmt.Errorf("failed to decode keyset %v: %w", keysetHash, err)
+	}
+	pubKeys := make([]string, len(keyset.PubKeys))
+	for i, pubKey := range keyset.PubKeys {
+		pubKeys[i] = base64.StdEncoding.EncodeToString(blsSignatures.PublicKeyToBytes(pubKey))
+	}
+	return KeysetInfo{
+		KeysetHash:    keysetHash,
+		AssumedHonest: keyset.AssumedHonest,
+		PubKeys:       pubKeys,
+		CreationBlock: creationBlock,
+		Valid:         valid,
+	}, nil
+}
+
+// Keysets lists every keyset the sequencer inbox has set valid, noting which
+// have since been invalidated.
+func (c *ChainFetchReader) Keysets(ctx context.Context) ([]KeysetInfo, error) {
+	c.keysetList.mutex.Lock()
+	defer c.keysetList.mutex.Unlock()
+	if c.keysetList.keysets != nil && time.Since(c.keysetList.fetchedAt) < keysetListCacheTime {
+		return c.keysetList.keysets, nil
+	}
+
+	iter, err := c.seqInboxFilterer.FilterSetValidKeyset(&bind.FilterOpts{Context: ctx}, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	keysets := []KeysetInfo{}
+	for iter.Next() {
+		keysetHash := common.Hash(iter.Event.KeysetHash)
+		valid, err := c.seqInboxCaller.IsValidKeysetHash(&bind.CallOpts{Context: ctx}, keysetHash)
+		if err != nil {
+			return nil, err
+		}
+		info, err := keysetInfoFromBytes(keysetHash, iter.Event.KeysetBytes, iter.Event.Raw.BlockNumber, valid)
+		if err != nil {
+			return nil, err
+		}
+		c.keysetCache.put(keysetHash, iter.Event.KeysetBytes)
+		keysets = append(keysets, info)
+	}
+	if iter.Error() != nil {
+		return nil, iter.Error()
+	}
+	c.keysetList.keysets = keysets
+	c.keysetList.fetchedAt = time.Now()
+	return keysets, nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+)
+
+type staticKeysetLister []KeysetInfo
+
+func (l staticKeysetLister) Keysets(ctx context.Context) ([]KeysetInfo, error) {
+	return l, nil
+}
+
+func TestKeysetsEndpoint(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pubKey, _, err := blsSignatures.GenerateKeys()
+	Require(t, err)
+	keyset := &arbstate.DataAvailabilityKeyset{AssumedHonest: 1, PubKeys: []blsSignatures.PublicKey{pubKey}}
+	buf := new(bytes.Buffer)
+	Require(t, keyset.Serialize(buf))
+	keysetHash, err := keyset.Hash()
+	Require(t, err)
+
+	info, err := keysetInfoFromBytes(keysetHash, buf.Bytes(), 10, true)
+	Require(t, err)
+	if info.AssumedHonest != 1 || len(info.PubKeys) != 1 || info.PubKeys[0] != base64.StdEncoding.EncodeToString(blsSignatures.PublicKeyToBytes(pubKey)) {
+		Fail(t, "unexpected keyset info", info)
+	}
+	if _, err := keysetInfoFromBytes(keysetHash, append(buf.Bytes(), 0), 10, true); err == nil {
+		Fail(t, "expected keyset not matching its hash to be rejected")
+	}
+
+	storage := NewMemoryBackedStorageService(ctx)
+	services := &DaserverServices{Keysets: staticKeysetLister{info}}
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:0", LocalServerAddressForTest))
+	Require(t, err)
+	server, err := NewRestfulDasServerOnListener(listener, genericconf.HTTPServerTimeoutConfigDefault, DefaultRestfulCORSConfig, nil, storage, storage, services)
+	Require(t, err)
+	defer func() {
+		Require(t, server.Shutdown())
+	}()
+	client, err := NewRestfulDasClientFromURL("http://" + listener.Addr().String())
+	Require(t, err)
+
+	keysets, err := client.Keysets(ctx)
+	Require(t, err)
+	if len(keysets) != 1 || keysets[0].KeysetHash != keysetHash || keysets[0].CreationBlock != 10 || !keysets[0].Valid || keysets[0].PubKeys[0] != info.PubKeys[0] {
+		Fail(t, "unexpected keysets", keysets)
+	}
+}
@@ -24,9 +24,14 @@ func (m *LifecycleManager) Register(c Closer) {
 	m.toClose = append(m.toClose, c)
 }
 
+// StopAndWaitUntil closes the registered components, giving them up to t to
+// finish, or as long as they take if t is 0.
 func (m *LifecycleManager) StopAndWaitUntil(t time.Duration) {
 	if m != nil && m.toClose != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), t)
+		ctx, cancel := context.Background(), context.CancelFunc(func() {})
+		if t > 0 {
+			ctx, cancel = context.WithTimeout(ctx, t)
+		}
 		defer cancel()
 		for _, c := range m.toClose {
 			err := c.Close(ctx)
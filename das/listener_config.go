@@ -0,0 +1,60 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// ListenerConfig is an address for the HTTP-RPC or REST server to listen on,
+// in addition to its main one, eg an IPv6 or internal interface.
+type ListenerConfig struct {
+	Addr   string `json:"addr"`
+	Port   uint64 `json:"port"`
+	Enable bool   `json:"enable"`
+	// Store is whether the HTTP-RPC server accepts stores on the listener,
+	// so stores can be kept to an internal interface while retrievals are
+	// served externally. The REST server only serves retrievals.
+	Store bool `json:"store"`
+}
+
+var DefaultListenerConfig = ListenerConfig{
+	Enable: true,
+	Store:  true,
+}
+
+func (c *ListenerConfig) Address() string {
+	return net.JoinHostPort(c.Addr, strconv.FormatUint(c.Port, 10))
+}
+
+// ParseListenerConfigs parses a JSON list of listeners, eg
+// [{"addr":"::1","port":9876},{"addr":"10.0.0.1","port":9876,"store":false}],
+// whose fields default to DefaultListenerConfig's. Disabled listeners are left
+// out.
+func ParseListenerConfigs(listeners string) ([]ListenerConfig, error) {
+	if listeners == "" {
+		return nil, nil
+	}
+	var raws []json.RawMessage
+	if err := json.Unmarshal([]byte(listeners), &raws); err != nil {
+		return nil, fmt.Errorf("invalid listeners %s: %w", listeners, err)
+	}
+	var configs []ListenerConfig
+	for _, raw := range raws {
+		config := DefaultListenerConfig
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&config); err != nil {
+			return nil, fmt.Errorf("invalid listener %s: %w", raw, err)
+		}
+		if config.Enable {
+			configs = append(configs, config)
+		}
+	}
+	return configs, nil
+}
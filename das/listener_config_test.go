@@ -0,0 +1,66 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestParseListenerConfigs(t *testing.T) {
+	listeners, err := ParseListenerConfigs(`[
+		{"addr": "::", "port": 9876},
+		{"addr": "10.0.0.1", "port": 9876, "store": false},
+		{"addr": "192.0.2.1", "port": 9876, "enable": false}
+	]`)
+	Require(t, err)
+	if len(listeners) != 2 {
+		Fail(t, "expected the disabled listener to be left out", listeners)
+	}
+	if !listeners[0].Store || listeners[1].Store {
+		Fail(t, "unexpected store flags", listeners)
+	}
+	if address := listeners[0].Address(); address != "[::]:9876" {
+		Fail(t, "unexpected IPv6 address", address)
+	}
+	if address := listeners[1].Address(); address != "10.0.0.1:9876" {
+		Fail(t, "unexpected IPv4 address", address)
+	}
+
+	if _, err := ParseListenerConfigs(`[{"address": "::", "port": 9876}]`); err == nil {
+		Fail(t, "expected unknown field to be an error")
+	}
+	listeners, err = ParseListenerConfigs("")
+	Require(t, err)
+	if len(listeners) != 0 {
+		Fail(t, "expected no listeners by default", listeners)
+	}
+}
+
+func TestRPCStoreDisabled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	testhelpers.RequireImpl(t, err)
+	storageService := NewMemoryBackedStorageService(ctx)
+	dasServer, err := StartDASRPCServerOnListener(ctx, lis, genericconf.HTTPServerTimeoutConfigDefault, nil, DefaultStoreBoundsConfig, storageService, nil, storageService)
+	testhelpers.RequireImpl(t, err)
+	defer func() {
+		testhelpers.RequireImpl(t, dasServer.Shutdown(ctx))
+	}()
+
+	client, err := NewDASRPCClient("http://" + lis.Addr().String())
+	testhelpers.RequireImpl(t, err)
+	if _, err := client.Store(ctx, []byte("batch"), uint64(time.Now().Add(time.Hour).Unix()), nil); err == nil || !strings.Contains(err.Error(), ErrStoreDisabled.Error()) {
+		testhelpers.FailImpl(t, "expected store to be rejected", err)
+	}
+	testhelpers.RequireImpl(t, client.HealthCheck(ctx))
+}
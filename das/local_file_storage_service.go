@@ -7,9 +7,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/base32"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -24,6 +27,7 @@ import (
 type LocalFileStorageConfig struct {
 	Enable                 bool   `koanf:"enable"`
 	DataDir                string `koanf:"data-dir"`
+	SharedFilesystem       bool   `koanf:"shared-filesystem"`
 	SyncFromStorageService bool   `koanf:"sync-from-storage-service"`
 	SyncToStorageService   bool   `koanf:"sync-to-storage-service"`
 }
@@ -35,91 +39,151 @@ var DefaultLocalFileStorageConfig = LocalFileStorageConfig{
 func LocalFileStorageConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Bool(prefix+".enable", DefaultLocalFileStorageConfig.Enable, "enable storage/retrieval of sequencer batch data from a directory of files, one per batch")
 	f.String(prefix+".data-dir", DefaultLocalFileStorageConfig.DataDir, "local data directory")
+	f.Bool(prefix+".shared-filesystem", DefaultLocalFileStorageConfig.SharedFilesystem, "make writes safe when the data directory is shared with other daservers, eg over NFS, by locking, fsyncing files and the directory, and renaming into place")
 	f.Bool(prefix+".sync-from-storage-service", DefaultLocalFileStorageConfig.SyncFromStorageService, "enable local storage to be used as a source for regular sync storage")
 	f.Bool(prefix+".sync-to-storage-service", DefaultLocalFileStorageConfig.SyncToStorageService, "enable local storage to be used as a sink for regular sync storage")
 }
 
+// Name of the file used for advisory locking when the data directory is
+// shared. It can't collide with data files, which are named by hash.
+const localFileStorageLockName = ".das-lock"
+
 type LocalFileStorageService struct {
 	dataDir string
+
+	// Only used with a shared filesystem. fcntl locks are held per process,
+	// so the mutex serializes writers within this process.
+	sharedFilesystem bool
+	lockMutex        sync.Mutex
+	lockFile         *os.File
 }
 
-func NewLocalFileStorageService(dataDir string) (StorageService, error) {
+func NewLocalFileStorageService(dataDir string, sharedFilesystem bool) (StorageService, error) {
 	if unix.Access(dataDir, unix.W_OK|unix.R_OK) != nil {
 		return nil, fmt.Errorf("couldn't start LocalFileStorageService, directory '%s' must be readable and writeable", dataDir)
 	}
-	return &LocalFileStorageService{dataDir: dataDir}, nil
-}
-
-func (s *LocalFileStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
-	log.Trace("das.LocalFileStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", s)
-	pathname := s.dataDir + "/" + EncodeStorageServiceKey(key)
-	data, err := os.ReadFile(pathname)
-	if err != nil {
-		// Just for backward compatability.
-		pathname = s.dataDir + "/" + base32.StdEncoding.EncodeToString(key.Bytes())
-		data, err = os.ReadFile(pathname)
+	s := &LocalFileStorageService{dataDir: dataDir, sharedFilesystem: sharedFilesystem}
+	if sharedFilesystem {
+		lockFile, err := os.OpenFile(dataDir+"/"+localFileStorageLockName, os.O_RDWR|os.O_CREATE, 0o600)
 		if err != nil {
-			if errors.Is(err, os.ErrNotExist) {
-				return nil, ErrNotFound
-			}
-			return nil, err
+			return nil, fmt.Errorf("couldn't open lock file for LocalFileStorageService: %w", err)
 		}
-		return data, nil
+		s.lockFile = lockFile
 	}
-	return data, nil
+	return s, nil
 }
 
-func (s *LocalFileStorageService) Put(ctx context.Context, data []byte, timeout uint64) error {
-	logPut("das.LocalFileStorageService.Store", data, timeout, s)
-	fileName := EncodeStorageServiceKey(dastree.Hash(data))
-	finalPath := s.dataDir + "/" + fileName
+// lock takes an exclusive advisory lock on the data directory. POSIX record
+// locks are used rather than flock since they are supported over NFS.
+func (s *LocalFileStorageService) lock() (func(), error) {
+	s.lockMutex.Lock()
+	lk := unix.Flock_t{Type: unix.F_WRLCK, Whence: io.SeekStart}
+	if err := unix.FcntlFlock(s.lockFile.Fd(), unix.F_SETLKW, &lk); err != nil {
+		s.lockMutex.Unlock()
+		return nil, fmt.Errorf("couldn't lock LocalFileStorageService data directory: %w", err)
+	}
+	return func() {
+		lk.Type = unix.F_UNLCK
+		if err := unix.FcntlFlock(s.lockFile.Fd(), unix.F_SETLK, &lk); err != nil {
+			log.Error("das.LocalFileStorageService failed to unlock data directory", "err", err)
+		}
+		s.lockMutex.Unlock()
+	}, nil
+}
+
+// writeFile writes data to a temp file and renames it into place, so readers
+// never see partially written files. With a shared filesystem the write is
+// also made durable before returning.
+func (s *LocalFileStorageService) writeFile(fileName string, data []byte) error {
+	if s.sharedFilesystem {
+		unlock, err := s.lock()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
 
-	// Use a temp file and rename to achieve atomic writes.
 	f, err := os.CreateTemp(s.dataDir, fileName)
 	if err != nil {
 		return err
 	}
-	err = f.Chmod(0o600)
+	err = s.writeTempFile(f, data)
 	if err != nil {
+		_ = os.Remove(f.Name())
 		return err
 	}
-	_, err = f.Write(data)
+	err = os.Rename(f.Name(), s.dataDir+"/"+fileName)
 	if err != nil {
+		_ = os.Remove(f.Name())
 		return err
 	}
-	err = f.Close()
-	if err != nil {
-		return err
+	if s.sharedFilesystem {
+		return syncDir(s.dataDir)
 	}
-
-	return os.Rename(f.Name(), finalPath)
-
+	return nil
 }
 
-func (s *LocalFileStorageService) putKeyValue(ctx context.Context, key common.Hash, value []byte) error {
-	fileName := EncodeStorageServiceKey(key)
-	finalPath := s.dataDir + "/" + fileName
-
-	// Use a temp file and rename to achieve atomic writes.
-	f, err := os.CreateTemp(s.dataDir, fileName)
+func (s *LocalFileStorageService) writeTempFile(f *os.File, data []byte) error {
+	err := f.Chmod(0o600)
 	if err != nil {
+		_ = f.Close()
 		return err
 	}
-	err = f.Chmod(0o600)
+	_, err = f.Write(data)
 	if err != nil {
+		_ = f.Close()
 		return err
 	}
-	_, err = f.Write(value)
+	if s.sharedFilesystem {
+		err = f.Sync()
+		if err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// syncDir fsyncs a directory so that renames within it are durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
 	if err != nil {
 		return err
 	}
-	err = f.Close()
+	err = d.Sync()
 	if err != nil {
+		_ = d.Close()
 		return err
 	}
+	return d.Close()
+}
 
-	return os.Rename(f.Name(), finalPath)
+func (s *LocalFileStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.LocalFileStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", s)
+	pathname := s.dataDir + "/" + EncodeStorageServiceKey(key)
+	data, err := os.ReadFile(pathname)
+	if err != nil {
+		// Just for backward compatability.
+		pathname = s.dataDir + "/" + base32.StdEncoding.EncodeToString(key.Bytes())
+		data, err = os.ReadFile(pathname)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, ErrNotFound
+			}
+			return nil, err
+		}
+		return data, nil
+	}
+	return data, nil
+}
+
+func (s *LocalFileStorageService) Put(ctx context.Context, data []byte, timeout uint64) error {
+	logPut("das.LocalFileStorageService.Store", data, timeout, s)
+	return s.writeFile(EncodeStorageServiceKey(dastree.Hash(data)), data)
+}
 
+func (s *LocalFileStorageService) putKeyValue(ctx context.Context, key common.Hash, value []byte) error {
+	return s.writeFile(EncodeStorageServiceKey(key), value)
 }
 
 func (s *LocalFileStorageService) Sync(ctx context.Context) error {
@@ -127,6 +191,9 @@ func (s *LocalFileStorageService) Sync(ctx context.Context) error {
 }
 
 func (s *LocalFileStorageService) Close(ctx context.Context) error {
+	if s.lockFile != nil {
+		return s.lockFile.Close()
+	}
 	return nil
 }
 
@@ -153,3 +220,47 @@ func (s *LocalFileStorageService) HealthCheck(ctx context.Context) error {
 	}
 	return nil
 }
+
+func (s *LocalFileStorageService) StorageUsage(ctx context.Context) (*StorageUsage, error) {
+	dir, err := os.Open(s.dataDir)
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Close()
+	usage := &StorageUsage{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		entries, err := dir.ReadDir(1024)
+		for _, entry := range entries {
+			if !entry.Type().IsRegular() || !isLocalFileStorageKey(entry.Name()) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				// Removed since the directory was read.
+				continue
+			}
+			usage.Entries++
+			usage.Bytes += uint64(info.Size())
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return usage, nil
+}
+
+// isLocalFileStorageKey tells data files from the lock file and the temp
+// files of writes in progress. Files named in base32 are from older versions.
+func isLocalFileStorageKey(name string) bool {
+	if decoded, err := hex.DecodeString(name); err == nil && len(decoded) == 32 {
+		return true
+	}
+	decoded, err := base32.StdEncoding.DecodeString(name)
+	return err == nil && len(decoded) == 32
+}
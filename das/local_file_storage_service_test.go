@@ -0,0 +1,91 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"encoding/base32"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+func TestLocalFileStorageServiceSharedFilesystem(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	dataDir := t.TempDir()
+
+	// Two services sharing a directory, as two daservers on one NFS export would.
+	var services []StorageService
+	for i := 0; i < 2; i++ {
+		s, err := NewLocalFileStorageService(dataDir, true)
+		Require(t, err)
+		defer s.Close(ctx)
+		services = append(services, s)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 10; i++ {
+		for _, s := range services {
+			wg.Add(1)
+			go func(s StorageService, i int) {
+				defer wg.Done()
+				errs <- s.Put(ctx, []byte(fmt.Sprintf("value %d", i)), timeout)
+			}(s, i)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		Require(t, err)
+	}
+
+	for i := 0; i < 10; i++ {
+		expected := []byte(fmt.Sprintf("value %d", i))
+		for _, s := range services {
+			val, err := s.GetByHash(ctx, dastree.Hash(expected))
+			Require(t, err)
+			if !bytes.Equal(val, expected) {
+				t.Fatal(val, expected)
+			}
+		}
+	}
+
+	// Only the data files and the lock file should remain, without leftover temp files.
+	entries, err := os.ReadDir(dataDir)
+	Require(t, err)
+	if len(entries) != 11 {
+		Fail(t, "unexpected number of files in data directory", len(entries))
+	}
+}
+
+func TestLocalFileStorageServiceUsage(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	dataDir := t.TempDir()
+	s, err := NewLocalFileStorageService(dataDir, true)
+	Require(t, err)
+	defer s.Close(ctx)
+	Require(t, s.Put(ctx, make([]byte, 100), timeout))
+	Require(t, s.Put(ctx, make([]byte, 50), timeout))
+
+	// Files named in base32 by older versions count, the lock file and temp
+	// files don't.
+	legacy := base32.StdEncoding.EncodeToString(dastree.Hash([]byte("legacy")).Bytes())
+	Require(t, os.WriteFile(filepath.Join(dataDir, legacy), []byte("legacy"), 0o600))
+	Require(t, os.WriteFile(filepath.Join(dataDir, EncodeStorageServiceKey(dastree.Hash(nil))+"123456"), []byte("temp"), 0o600))
+
+	usage, err := s.(StorageUsageReporter).StorageUsage(ctx)
+	Require(t, err)
+	if usage.Entries != 3 || usage.Bytes != 156 {
+		Fail(t, "unexpected storage usage", usage.Entries, usage.Bytes)
+	}
+}
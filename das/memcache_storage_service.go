@@ -0,0 +1,233 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/pretty"
+
+	flag "github.com/spf13/pflag"
+)
+
+type MemcacheConfig struct {
+	Enable       bool          `koanf:"enable"`
+	Servers      []string      `koanf:"servers"`
+	Expiration   time.Duration `koanf:"expiration"`
+	KeyPrefix    string        `koanf:"key-prefix"`
+	Timeout      time.Duration `koanf:"timeout"`
+	MaxIdleConns int           `koanf:"max-idle-conns"`
+	VirtualNodes int           `koanf:"virtual-nodes"`
+}
+
+var DefaultMemcacheConfig = MemcacheConfig{
+	Expiration:   time.Hour,
+	Timeout:      500 * time.Millisecond,
+	MaxIdleConns: 16,
+	VirtualNodes: 160,
+}
+
+func MemcacheConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultMemcacheConfig.Enable, "enable memcached caching of sequencer batch data")
+	f.StringSlice(prefix+".servers", DefaultMemcacheConfig.Servers, "memcached servers (host:port) to spread the cache over by consistent hashing")
+	f.Duration(prefix+".expiration", DefaultMemcacheConfig.Expiration, "memcached expiration; batches are also expired at their data timeout if it is sooner")
+	f.String(prefix+".key-prefix", DefaultMemcacheConfig.KeyPrefix, "prefix for memcached keys, so deployments sharing memcached servers don't see each other's data")
+	f.Duration(prefix+".timeout", DefaultMemcacheConfig.Timeout, "timeout of memcached requests")
+	f.Int(prefix+".max-idle-conns", DefaultMemcacheConfig.MaxIdleConns, "maximum number of idle connections to keep open to each memcached server")
+	f.Int(prefix+".virtual-nodes", DefaultMemcacheConfig.VirtualNodes, "number of points each memcached server has on the consistent hash ring")
+}
+
+// MemcacheStorageService caches data in a fleet of memcached servers in front
+// of the wrapped StorageService. Keys are spread over the servers by
+// consistent hashing, so adding or removing a server only moves the keys on
+// its share of the ring. Memcached isn't authenticated, so cached data is only
+// used if it matches its hash.
+type MemcacheStorageService struct {
+	baseStorageService StorageService
+	config             MemcacheConfig
+	client             *memcache.Client
+	metrics            *cacheMetrics
+}
+
+func NewMemcacheStorageService(config MemcacheConfig, baseStorageService StorageService) (StorageService, error) {
+	if len(config.Servers) == 0 {
+		return nil, errors.New("memcache-cache.servers must be set")
+	}
+	ring, err := newMemcacheRing(config.Servers, config.VirtualNodes)
+	if err != nil {
+		return nil, err
+	}
+	client := memcache.NewFromSelector(ring)
+	client.Timeout = config.Timeout
+	client.MaxIdleConns = config.MaxIdleConns
+	return &MemcacheStorageService{
+		baseStorageService: baseStorageService,
+		config:             config,
+		client:             client,
+		metrics:            newCacheMetrics("memcache"),
+	}, nil
+}
+
+// memcacheRing is a consistent hash ring of memcached servers.
+type memcacheRing struct {
+	points  []uint32
+	servers map[uint32]net.Addr
+	addrs   []net.Addr
+}
+
+func newMemcacheRing(servers []string, virtualNodes int) (*memcacheRing, error) {
+	if virtualNodes <= 0 {
+		virtualNodes = 1
+	}
+	r := &memcacheRing{servers: make(map[uint32]net.Addr)}
+	for _, server := range servers {
+		addr, err := net.ResolveTCPAddr("tcp", server)
+		if err != nil {
+			return nil, fmt.Errorf("invalid memcached server %s: %w", server, err)
+		}
+		r.addrs = append(r.addrs, addr)
+		for i := 0; i < virtualNodes; i++ {
+			point := crc32.ChecksumIEEE([]byte(server + "-" + strconv.Itoa(i)))
+			if _, ok := r.servers[point]; ok {
+				continue
+			}
+			r.servers[point] = addr
+			r.points = append(r.points, point)
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+	return r, nil
+}
+
+func (r *memcacheRing) PickServer(key string) (net.Addr, error) {
+	if len(r.points) == 0 {
+		return nil, memcache.ErrNoServers
+	}
+	hash := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= hash })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.servers[r.points[i]], nil
+}
+
+func (r *memcacheRing) Each(f func(net.Addr) error) error {
+	for _, addr := range r.addrs {
+		if err := f(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *MemcacheStorageService) memcacheKey(key common.Hash) string {
+	return ms.config.KeyPrefix + strings.TrimPrefix(key.Hex(), "0x")
+}
+
+// expiration returns the memcached expiration for data with the given
+// timeout, or false if it has already expired.
+func (ms *MemcacheStorageService) expiration(timeout uint64) (int32, bool) {
+	expiration := ms.config.Expiration
+	if timeout <= math.MaxInt64 {
+		untilTimeout := time.Until(time.Unix(int64(timeout), 0))
+		if untilTimeout < expiration {
+			expiration = untilTimeout
+		}
+	}
+	seconds := int64(expiration / time.Second)
+	if seconds <= 0 {
+		return 0, false
+	}
+	// Memcached treats expirations over 30 days as unix timestamps.
+	if seconds > 30*24*60*60 {
+		return int32(time.Now().Unix() + seconds), true
+	}
+	return int32(seconds), true
+}
+
+func (ms *MemcacheStorageService) set(key common.Hash, value []byte, timeout uint64) {
+	expiration, ok := ms.expiration(timeout)
+	if !ok {
+		return
+	}
+	err := ms.client.Set(&memcache.Item{
+		Key:        ms.memcacheKey(key),
+		Value:      value,
+		Expiration: expiration,
+	})
+	if err != nil {
+		log.Warn("das.MemcacheStorageService failed to cache data", "key", pretty.PrettyHash(key), "err", err)
+	}
+}
+
+func (ms *MemcacheStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.MemcacheStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", ms)
+	item, err := ms.client.Get(ms.memcacheKey(key))
+	if err == nil && dastree.ValidHash(key, item.Value) {
+		ms.metrics.hit()
+		return item.Value, nil
+	}
+	if err != nil && !errors.Is(err, memcache.ErrCacheMiss) {
+		log.Warn("das.MemcacheStorageService.GetByHash", "err", err)
+	}
+	ms.metrics.miss()
+
+	value, err := ms.baseStorageService.GetByHash(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if dastree.ValidHash(key, value) {
+		// The data's timeout isn't known, so it's kept for the cache's expiration.
+		ms.set(key, value, math.MaxUint64)
+	}
+	return value, nil
+}
+
+func (ms *MemcacheStorageService) Put(ctx context.Context, value []byte, timeout uint64) error {
+	logPut("das.MemcacheStorageService.Store", value, timeout, ms)
+	if err := ms.baseStorageService.Put(ctx, value, timeout); err != nil {
+		return err
+	}
+	ms.set(dastree.Hash(value), value, timeout)
+	return nil
+}
+
+func (ms *MemcacheStorageService) Sync(ctx context.Context) error {
+	return ms.baseStorageService.Sync(ctx)
+}
+
+func (ms *MemcacheStorageService) Close(ctx context.Context) error {
+	return ms.baseStorageService.Close(ctx)
+}
+
+func (ms *MemcacheStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	return ms.baseStorageService.ExpirationPolicy(ctx)
+}
+
+func (ms *MemcacheStorageService) String() string {
+	return fmt.Sprintf("MemcacheStorageService(%v)", ms.config.Servers)
+}
+
+func (ms *MemcacheStorageService) HealthCheck(ctx context.Context) error {
+	if err := ms.client.Ping(); err != nil {
+		return err
+	}
+	return ms.baseStorageService.HealthCheck(ctx)
+}
@@ -0,0 +1,74 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+func TestMemcacheRing(t *testing.T) {
+	servers := []string{"127.0.0.1:11211", "127.0.0.1:11212", "127.0.0.1:11213"}
+	ring, err := newMemcacheRing(servers, 160)
+	Require(t, err)
+	grownRing, err := newMemcacheRing(append(servers, "127.0.0.1:11214"), 160)
+	Require(t, err)
+
+	const keys = 10000
+	moved := 0
+	used := make(map[string]int)
+	for i := 0; i < keys; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		addr, err := ring.PickServer(key)
+		Require(t, err)
+		used[addr.String()]++
+		grownAddr, err := grownRing.PickServer(key)
+		Require(t, err)
+		if addr.String() != grownAddr.String() {
+			moved++
+		}
+	}
+	if len(used) != len(servers) {
+		Fail(t, "keys weren't spread over every server", used)
+	}
+	// Adding a fourth server should move about a quarter of the keys.
+	if moved > keys/2 {
+		Fail(t, "too many keys moved when a server was added", moved)
+	}
+}
+
+func TestMemcacheStorageServiceUnavailable(t *testing.T) {
+	ctx := context.Background()
+	// Reserve a port with nothing listening on it.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Require(t, err)
+	addr := listener.Addr().String()
+	Require(t, listener.Close())
+
+	base := NewMemoryBackedStorageService(ctx)
+	config := DefaultMemcacheConfig
+	config.Enable = true
+	config.Servers = []string{addr}
+	config.Timeout = 100 * time.Millisecond
+	memcacheService, err := NewMemcacheStorageService(config, base)
+	Require(t, err)
+
+	// Data is still stored and read when memcached is down.
+	val := []byte("The first value")
+	Require(t, memcacheService.Put(ctx, val, uint64(time.Now().Add(time.Hour).Unix())))
+	res, err := memcacheService.GetByHash(ctx, dastree.Hash(val))
+	Require(t, err)
+	if !bytes.Equal(res, val) {
+		Fail(t, res, val)
+	}
+	if memcacheService.HealthCheck(ctx) == nil {
+		Fail(t, "expected health check to fail while memcached is down")
+	}
+}
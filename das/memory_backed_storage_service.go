@@ -6,25 +6,75 @@ package das
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/offchainlabs/nitro/arbstate"
 	"github.com/offchainlabs/nitro/das/dastree"
+	flag "github.com/spf13/pflag"
 )
 
+type MemoryStorageConfig struct {
+	Enable                 bool   `koanf:"enable"`
+	MaxSize                uint64 `koanf:"max-size"`
+	SyncFromStorageService bool   `koanf:"sync-from-storage-service"`
+	SyncToStorageService   bool   `koanf:"sync-to-storage-service"`
+}
+
+var DefaultMemoryStorageConfig = MemoryStorageConfig{}
+
+func MemoryStorageConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultMemoryStorageConfig.Enable, "enable storage/retrieval of sequencer batch data in memory, which is lost on restart; intended for devnets and testing")
+	f.Uint64(prefix+".max-size", DefaultMemoryStorageConfig.MaxSize, "maximum total size in bytes of data kept in memory, evicting the oldest data first (0 = unlimited)")
+	f.Bool(prefix+".sync-from-storage-service", DefaultMemoryStorageConfig.SyncFromStorageService, "enable memory storage to be used as a source for regular sync storage")
+	f.Bool(prefix+".sync-to-storage-service", DefaultMemoryStorageConfig.SyncToStorageService, "enable memory storage to be used as a sink for regular sync storage")
+}
+
 type MemoryBackedStorageService struct { // intended for testing and debugging
 	contents map[[32]byte][]byte
 	rwmutex  sync.RWMutex
 	closed   bool
+
+	// If maxSize is non-zero, keys are evicted in insertion order once the
+	// total size of the contents exceeds it.
+	maxSize        uint64
+	size           uint64
+	insertionOrder [][32]byte
 }
 
 var ErrClosed = errors.New("cannot access a StorageService that has been Closed")
 
 func NewMemoryBackedStorageService(ctx context.Context) StorageService {
+	return NewMemoryBackedStorageServiceWithMaxSize(ctx, 0)
+}
+
+func NewMemoryBackedStorageServiceWithMaxSize(ctx context.Context, maxSize uint64) StorageService {
 	return &MemoryBackedStorageService{
 		contents: make(map[[32]byte][]byte),
+		maxSize:  maxSize,
+	}
+}
+
+// store must be called with the write lock held.
+func (m *MemoryBackedStorageService) store(key [32]byte, value []byte) {
+	if old, found := m.contents[key]; found {
+		m.size -= uint64(len(old))
+	} else {
+		m.insertionOrder = append(m.insertionOrder, key)
+	}
+	m.contents[key] = append([]byte{}, value...)
+	m.size += uint64(len(value))
+	if m.maxSize == 0 {
+		return
+	}
+	// Always keep the newest entry, even if it alone exceeds the max size.
+	for m.size > m.maxSize && len(m.insertionOrder) > 1 {
+		oldest := m.insertionOrder[0]
+		m.insertionOrder = m.insertionOrder[1:]
+		m.size -= uint64(len(m.contents[oldest]))
+		delete(m.contents, oldest)
 	}
 }
 
@@ -49,7 +99,7 @@ func (m *MemoryBackedStorageService) Put(ctx context.Context, data []byte, expir
 	if m.closed {
 		return ErrClosed
 	}
-	m.contents[dastree.Hash(data)] = append([]byte{}, data...)
+	m.store(dastree.Hash(data), data)
 	return nil
 }
 
@@ -59,7 +109,7 @@ func (m *MemoryBackedStorageService) putKeyValue(ctx context.Context, key common
 	if m.closed {
 		return ErrClosed
 	}
-	m.contents[key] = append([]byte{}, value...)
+	m.store(key, value)
 	return nil
 }
 
@@ -84,9 +134,25 @@ func (m *MemoryBackedStorageService) ExpirationPolicy(ctx context.Context) (arbs
 }
 
 func (m *MemoryBackedStorageService) String() string {
+	if m.maxSize != 0 {
+		return fmt.Sprintf("MemoryBackedStorageService(max-size:%d)", m.maxSize)
+	}
 	return "MemoryBackedStorageService"
 }
 
 func (m *MemoryBackedStorageService) HealthCheck(ctx context.Context) error {
 	return nil
 }
+
+func (m *MemoryBackedStorageService) StorageUsage(ctx context.Context) (*StorageUsage, error) {
+	m.rwmutex.RLock()
+	defer m.rwmutex.RUnlock()
+	if m.closed {
+		return nil, ErrClosed
+	}
+	var size uint64
+	for _, value := range m.contents {
+		size += uint64(len(value))
+	}
+	return &StorageUsage{Entries: uint64(len(m.contents)), Bytes: size}, nil
+}
@@ -0,0 +1,39 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+func TestMemoryBackedStorageServiceEviction(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	storageService := NewMemoryBackedStorageServiceWithMaxSize(ctx, 20)
+
+	val1 := []byte("0123456789")
+	val2 := []byte("abcdefghij")
+	val3 := []byte("ABCDEFGHIJ")
+	Require(t, storageService.Put(ctx, val1, timeout))
+	Require(t, storageService.Put(ctx, val2, timeout))
+	// Storing the same data again doesn't count towards the size twice.
+	Require(t, storageService.Put(ctx, val2, timeout))
+	_, err := storageService.GetByHash(ctx, dastree.Hash(val1))
+	Require(t, err)
+
+	Require(t, storageService.Put(ctx, val3, timeout))
+	_, err = storageService.GetByHash(ctx, dastree.Hash(val1))
+	if !errors.Is(err, ErrNotFound) {
+		Fail(t, "oldest data wasn't evicted", err)
+	}
+	for _, val := range [][]byte{val2, val3} {
+		_, err = storageService.GetByHash(ctx, dastree.Hash(val))
+		Require(t, err)
+	}
+}
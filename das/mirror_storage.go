@@ -0,0 +1,261 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
+	"github.com/offchainlabs/nitro/util/arbmath"
+	"github.com/offchainlabs/nitro/util/headerreader"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+type MirrorConfig struct {
+	Enable                   bool          `koanf:"enable"`
+	Interval                 time.Duration `koanf:"interval"`
+	LookbackBlocks           uint64        `koanf:"lookback-blocks"`
+	ParentChainBlocksPerRead uint64        `koanf:"parent-chain-blocks-per-read"`
+}
+
+var DefaultMirrorConfig = MirrorConfig{
+	Enable:                   false,
+	Interval:                 10 * time.Minute,
+	LookbackBlocks:           50400,
+	ParentChainBlocksPerRead: 1000,
+}
+
+func MirrorConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultMirrorConfig.Enable, "periodically scan L1 for DAS certificates whose data isn't in this DAS's storage and fetch it from the rest-aggregator endpoints, so this committee member converges to a full copy even if it missed Store requests")
+	f.Duration(prefix+".interval", DefaultMirrorConfig.Interval, "interval between scans of L1 for missing data")
+	f.Uint64(prefix+".lookback-blocks", DefaultMirrorConfig.LookbackBlocks, "number of L1 blocks back from the latest block to scan for certificates on each pass")
+	f.Uint64(prefix+".parent-chain-blocks-per-read", DefaultMirrorConfig.ParentChainBlocksPerRead, "max L1 blocks to read logs for per request when scanning")
+}
+
+var (
+	mirrorFetchedCounter = metrics.NewRegisteredCounter("arb/das/mirror/fetched", nil)
+	mirrorFailedCounter  = metrics.NewRegisteredCounter("arb/das/mirror/failed", nil)
+)
+
+// MirrorStorage periodically scans the last LookbackBlocks L1 blocks for
+// batches posted with a DAS certificate, and for each unexpired certificate
+// whose data isn't in local storage, recovers the data from peers and stores
+// it locally. Unlike rest-aggregator.sync-to-storage.eager, which follows L1
+// forwards once, every pass rechecks the whole window, so data that was
+// never stored or was since lost is repaired.
+type MirrorStorage struct {
+	stopwaiter.StopWaiter
+
+	config     MirrorConfig
+	l1Reader   *headerreader.HeaderReader
+	backfiller *l1Backfiller
+}
+
+func NewMirrorStorage(config MirrorConfig, local StorageService, peers arbstate.DataAvailabilityReader, l1Reader *headerreader.HeaderReader, inboxAddr common.Address) (*MirrorStorage, error) {
+	if config.ParentChainBlocksPerRead == 0 {
+		return nil, errors.New("mirror.parent-chain-blocks-per-read must be greater than 0")
+	}
+	backfiller, err := newL1Backfiller(local, peers, l1Reader.Client(), inboxAddr)
+	if err != nil {
+		return nil, err
+	}
+	return &MirrorStorage{
+		config:     config,
+		l1Reader:   l1Reader,
+		backfiller: backfiller,
+	}, nil
+}
+
+func (m *MirrorStorage) Start(ctx context.Context) {
+	m.StopWaiter.Start(ctx, m)
+	m.CallIteratively(m.mirror)
+}
+
+func (m *MirrorStorage) Close(ctx context.Context) error {
+	m.StopOnly()
+	return nil
+}
+
+func (m *MirrorStorage) String() string {
+	return "MirrorStorage(" + m.backfiller.local.String() + ")"
+}
+
+func (m *MirrorStorage) mirror(ctx context.Context) time.Duration {
+	header, err := m.l1Reader.LastHeader(ctx)
+	if err != nil {
+		log.Warn("das.MirrorStorage: failed to get latest L1 header", "err", err)
+		return m.config.Interval
+	}
+	highBlockNr := header.Number.Uint64()
+	var lowBlockNr uint64
+	if highBlockNr > m.config.LookbackBlocks {
+		lowBlockNr = highBlockNr - m.config.LookbackBlocks
+	}
+	fetched, failed, err := m.backfiller.backfill(ctx, lowBlockNr, highBlockNr, m.config.ParentChainBlocksPerRead)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Warn("das.MirrorStorage: failed to scan L1 blocks", "fromBlock", lowBlockNr, "toBlock", highBlockNr, "err", err)
+		}
+		return m.config.Interval
+	}
+	if fetched > 0 || failed > 0 {
+		log.Info("das.MirrorStorage: finished scanning L1 for missing data", "fromBlock", lowBlockNr, "toBlock", highBlockNr, "fetched", fetched, "failed", failed)
+	}
+	return m.config.Interval
+}
+
+// BackfillFromL1 stores the data for each unexpired DAS certificate posted to
+// the sequencer inbox from fromBlock to toBlock inclusive that isn't already
+// in storage, recovering it from peers. It returns the number of batches
+// whose data was fetched and the number whose data couldn't be.
+func BackfillFromL1(ctx context.Context, storage StorageService, peers arbstate.DataAvailabilityReader, l1Client arbutil.L1Interface, inboxAddr common.Address, fromBlock, toBlock, blocksPerRead uint64) (int, int, error) {
+	if blocksPerRead == 0 {
+		return 0, 0, errors.New("blocks per read must be greater than 0")
+	}
+	backfiller, err := newL1Backfiller(storage, peers, l1Client, inboxAddr)
+	if err != nil {
+		return 0, 0, err
+	}
+	return backfiller.backfill(ctx, fromBlock, toBlock, blocksPerRead)
+}
+
+type l1Backfiller struct {
+	local         StorageService
+	peers         arbstate.DataAvailabilityReader
+	l1Client      arbutil.L1Interface
+	inboxContract *bridgegen.SequencerInbox
+	inboxAddr     common.Address
+}
+
+func newL1Backfiller(local StorageService, peers arbstate.DataAvailabilityReader, l1Client arbutil.L1Interface, inboxAddr common.Address) (*l1Backfiller, error) {
+	inboxContract, err := bridgegen.NewSequencerInbox(inboxAddr, l1Client)
+	if err != nil {
+		return nil, err
+	}
+	// Keysets missing from the peers are fetched from the L1 chain.
+	peers, err = NewChainFetchReader(peers, l1Client, inboxAddr)
+	if err != nil {
+		return nil, err
+	}
+	return &l1Backfiller{
+		local:         local,
+		peers:         peers,
+		l1Client:      l1Client,
+		inboxContract: inboxContract,
+		inboxAddr:     inboxAddr,
+	}, nil
+}
+
+func (b *l1Backfiller) backfill(ctx context.Context, lowBlockNr, highBlockNr, blocksPerRead uint64) (int, int, error) {
+	var fetched, failed int
+	for from := lowBlockNr; from <= highBlockNr; from += blocksPerRead {
+		to := arbmath.MinInt(from+blocksPerRead-1, highBlockNr)
+		f, e, err := b.backfillBlockRange(ctx, from, to)
+		fetched += f
+		failed += e
+		if err != nil {
+			return fetched, failed, fmt.Errorf("error scanning L1 blocks %d to %d: %w", from, to, err)
+		}
+	}
+	return fetched, failed, nil
+}
+
+// backfillBlockRange returns the number of batches whose data was fetched and
+// the number that couldn't be. Failing to fetch one batch's data doesn't stop
+// the others being fetched; only errors reading L1 are returned.
+func (b *l1Backfiller) backfillBlockRange(ctx context.Context, lowerBound, higherBound uint64) (int, int, error) {
+	query := ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(lowerBound),
+		ToBlock:   new(big.Int).SetUint64(higherBound),
+		Addresses: []common.Address{b.inboxAddr},
+		Topics:    [][]common.Hash{{BatchDeliveredID}},
+	}
+	logs, err := b.l1Client.FilterLogs(ctx, query)
+	if err != nil {
+		return 0, 0, err
+	}
+	var fetched, failed int
+	for _, deliveredLog := range logs {
+		if ctx.Err() != nil {
+			return fetched, failed, ctx.Err()
+		}
+		wasFetched, err := b.backfillBatch(ctx, deliveredLog)
+		if err != nil {
+			failed++
+			mirrorFailedCounter.Inc(1)
+			log.Warn("das: failed to fetch missing batch data", "txhash", deliveredLog.TxHash, "err", err)
+			continue
+		}
+		if wasFetched {
+			fetched++
+			mirrorFetchedCounter.Inc(1)
+		}
+	}
+	return fetched, failed, nil
+}
+
+func (b *l1Backfiller) backfillBatch(ctx context.Context, deliveredLog types.Log) (bool, error) {
+	deliveredEvent, err := b.inboxContract.ParseSequencerBatchDelivered(deliveredLog)
+	if err != nil {
+		return false, err
+	}
+	data, err := FindDASDataFromLog(ctx, b.inboxContract, deliveredEvent, b.inboxAddr, b.l1Client, deliveredLog)
+	if err != nil || data == nil {
+		return false, err
+	}
+	cert, err := arbstate.DeserializeDASCertFrom(bytes.NewReader(data))
+	if err != nil {
+		return false, err
+	}
+	if cert.Timeout < uint64(time.Now().Unix()) {
+		return false, nil
+	}
+	dataHash := common.Hash(cert.DataHash)
+	if cert.Version == 0 {
+		dataHash = dastree.FlatHashToTreeHash(dataHash)
+	}
+	_, err = b.local.GetByHash(ctx, dataHash)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return false, err
+	}
+
+	// RecoverPayloadFromDasBatch checks each preimage against its hash, so
+	// only verified data is stored.
+	preimages := make(map[arbutil.PreimageType]map[common.Hash][]byte)
+	message := sequencerMessageFromDASData(deliveredEvent, data)
+	payload, err := arbstate.RecoverPayloadFromDasBatch(ctx, deliveredEvent.BatchSequenceNumber.Uint64(), message, b.peers, preimages, arbstate.KeysetValidate)
+	if err != nil {
+		return false, err
+	}
+	if payload == nil {
+		return false, errors.New("batch has an invalid DAS certificate")
+	}
+	for _, preimages := range preimages {
+		for _, contents := range preimages {
+			if err := b.local.Put(ctx, contents, cert.Timeout); err != nil {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
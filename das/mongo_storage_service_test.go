@@ -0,0 +1,79 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+type mockMongoGridFSOperator struct {
+	mutex sync.Mutex
+	files map[string][]byte
+}
+
+func (m *mockMongoGridFSOperator) Upload(ctx context.Context, filename string, value []byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.files[filename] = append([]byte{}, value...)
+	return nil
+}
+
+func (m *mockMongoGridFSOperator) Download(ctx context.Context, filename string) ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	value, ok := m.files[filename]
+	if !ok {
+		return nil, gridfs.ErrFileNotFound
+	}
+	return value, nil
+}
+
+func (m *mockMongoGridFSOperator) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (m *mockMongoGridFSOperator) Disconnect(ctx context.Context) error {
+	return nil
+}
+
+func TestMongoStorageService(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	mongoService := &MongoStorageService{
+		operator: &mockMongoGridFSOperator{files: make(map[string][]byte)},
+		database: "das",
+		bucket:   "das",
+	}
+
+	val1 := []byte("The first value")
+	val1CorrectKey := dastree.Hash(val1)
+	val2IncorrectKey := dastree.Hash(append(val1, 0))
+
+	_, err := mongoService.GetByHash(ctx, val1CorrectKey)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	err = mongoService.Put(ctx, val1, timeout)
+	Require(t, err)
+
+	_, err = mongoService.GetByHash(ctx, val2IncorrectKey)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	val, err := mongoService.GetByHash(ctx, val1CorrectKey)
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		t.Fatal(val, val1)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/containers"
+	"github.com/offchainlabs/nitro/util/pretty"
+
+	flag "github.com/spf13/pflag"
+)
+
+type NegativeCacheConfig struct {
+	Enable   bool          `koanf:"enable"`
+	TTL      time.Duration `koanf:"ttl"`
+	Capacity int           `koanf:"capacity"`
+}
+
+var DefaultNegativeCacheConfig = NegativeCacheConfig{
+	TTL:      5 * time.Second,
+	Capacity: 100000,
+}
+
+func NegativeCacheConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultNegativeCacheConfig.Enable, "enable caching of lookups for hashes that aren't in storage, so repeated requests for them don't reach the storage backends")
+	f.Duration(prefix+".ttl", DefaultNegativeCacheConfig.TTL, "how long a hash that wasn't found is remembered as missing")
+	f.Int(prefix+".capacity", DefaultNegativeCacheConfig.Capacity, "maximum number of missing hashes to remember")
+}
+
+// NegativeCachingStorageService remembers hashes the wrapped StorageService
+// didn't have for a short time, and answers repeated lookups for them with
+// ErrNotFound. Storing data forgets that its hash was missing.
+type NegativeCachingStorageService struct {
+	baseStorageService StorageService
+	config             NegativeCacheConfig
+	metrics            *cacheMetrics
+
+	mutex   sync.Mutex
+	missing *containers.LruCache[common.Hash, time.Time]
+}
+
+func NewNegativeCachingStorageService(config NegativeCacheConfig, baseStorageService StorageService) *NegativeCachingStorageService {
+	return &NegativeCachingStorageService{
+		baseStorageService: baseStorageService,
+		config:             config,
+		metrics:            newCacheMetrics("negative"),
+		missing:            containers.NewLruCache[common.Hash, time.Time](config.Capacity),
+	}
+}
+
+func (n *NegativeCachingStorageService) knownMissing(key common.Hash) bool {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	expiry, ok := n.missing.Get(key)
+	if !ok {
+		return false
+	}
+	if time.Now().After(expiry) {
+		n.missing.Remove(key)
+		return false
+	}
+	return true
+}
+
+func (n *NegativeCachingStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.NegativeCachingStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", n)
+	if n.knownMissing(key) {
+		n.metrics.hit()
+		return nil, ErrNotFound
+	}
+	n.metrics.miss()
+	value, err := n.baseStorageService.GetByHash(ctx, key)
+	if errors.Is(err, ErrNotFound) {
+		n.mutex.Lock()
+		n.missing.Add(key, time.Now().Add(n.config.TTL))
+		n.mutex.Unlock()
+	}
+	return value, err
+}
+
+func (n *NegativeCachingStorageService) Put(ctx context.Context, value []byte, timeout uint64) error {
+	logPut("das.NegativeCachingStorageService.Put", value, timeout, n)
+	err := n.baseStorageService.Put(ctx, value, timeout)
+	n.mutex.Lock()
+	n.missing.Remove(dastree.Hash(value))
+	n.mutex.Unlock()
+	return err
+}
+
+func (n *NegativeCachingStorageService) Sync(ctx context.Context) error {
+	return n.baseStorageService.Sync(ctx)
+}
+
+func (n *NegativeCachingStorageService) Close(ctx context.Context) error {
+	n.mutex.Lock()
+	n.missing.Clear()
+	n.mutex.Unlock()
+	return n.baseStorageService.Close(ctx)
+}
+
+func (n *NegativeCachingStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	return n.baseStorageService.ExpirationPolicy(ctx)
+}
+
+func (n *NegativeCachingStorageService) String() string {
+	return fmt.Sprintf("NegativeCachingStorageService(%+v, %v)", n.config, n.baseStorageService)
+}
+
+func (n *NegativeCachingStorageService) HealthCheck(ctx context.Context) error {
+	return n.baseStorageService.HealthCheck(ctx)
+}
@@ -0,0 +1,62 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+func TestNegativeCachingStorageService(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	base := NewMemoryBackedStorageService(ctx)
+	cache := NewNegativeCachingStorageService(NegativeCacheConfig{
+		Enable:   true,
+		TTL:      time.Hour,
+		Capacity: 10,
+	}, base)
+
+	val1 := []byte("The first value")
+	key1 := dastree.Hash(val1)
+	_, err := cache.GetByHash(ctx, key1)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	// Data that appears in the base storage behind the cache's back stays
+	// missing until the TTL passes.
+	Require(t, base.Put(ctx, val1, timeout))
+	_, err = cache.GetByHash(ctx, key1)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	cache.mutex.Lock()
+	cache.missing.Add(key1, time.Now().Add(-time.Second))
+	cache.mutex.Unlock()
+	val, err := cache.GetByHash(ctx, key1)
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		t.Fatal(val, val1)
+	}
+
+	// Storing data through the cache forgets that it was missing.
+	val2 := []byte("The second value")
+	key2 := dastree.Hash(val2)
+	_, err = cache.GetByHash(ctx, key2)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	Require(t, cache.Put(ctx, val2, timeout))
+	val, err = cache.GetByHash(ctx, key2)
+	Require(t, err)
+	if !bytes.Equal(val, val2) {
+		t.Fatal(val, val2)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/offchainlabs/nitro/blsSignatures"
+
+	flag "github.com/spf13/pflag"
+)
+
+// PKCS11Token is the subset of PKCS#11 operations used by PKCS11BLSSigner,
+// so the HSM can be replaced in tests. Only the build with the pkcs11 tag
+// loads PKCS#11 modules.
+type PKCS11Token interface {
+	// Sign signs message with the private key; it's safe to call
+	// concurrently.
+	Sign(message []byte) ([]byte, error)
+	// PublicKey returns the value of the public key object matching the
+	// private key, or nil if the token has none.
+	PublicKey() ([]byte, error)
+	Close()
+}
+
+type PKCS11Config struct {
+	Module     string `koanf:"module"`
+	TokenLabel string `koanf:"token-label"`
+	PINFile    string `koanf:"pin-file"`
+	KeyLabel   string `koanf:"key-label"`
+	KeyID      string `koanf:"key-id"`
+	Mechanism  string `koanf:"mechanism"`
+	PublicKey  string `koanf:"public-key"`
+	Sessions   int    `koanf:"sessions"`
+}
+
+var DefaultPKCS11Config = PKCS11Config{
+	Sessions: 4,
+}
+
+func PKCS11ConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".module", DefaultPKCS11Config.Module, "path of a PKCS#11 module, eg the YubiHSM's yubihsm_pkcs11.so, whose HSM holds the BLS key and signs with it, instead of key-dir or priv-key (requires a build with the pkcs11 tag)")
+	f.String(prefix+".token-label", DefaultPKCS11Config.TokenLabel, "label of the PKCS#11 token holding the key")
+	f.String(prefix+".pin-file", DefaultPKCS11Config.PINFile, "file with the PIN to log in to the token with")
+	f.String(prefix+".key-label", DefaultPKCS11Config.KeyLabel, "label of the BLS private key object")
+	f.String(prefix+".key-id", DefaultPKCS11Config.KeyID, "hex ID of the BLS private key object, instead of key-label")
+	f.String(prefix+".mechanism", DefaultPKCS11Config.Mechanism, "vendor defined PKCS#11 mechanism signing with BLS12-381 keys as the DAS does, as a number, eg 0x80000123; it's checked against the public key on startup")
+	f.String(prefix+".public-key", DefaultPKCS11Config.PublicKey, "base64 BLS public key of the key, or a file containing it, if the token has no public key object for it")
+	f.Int(prefix+".sessions", DefaultPKCS11Config.Sessions, "number of PKCS#11 sessions, and so concurrent signatures")
+}
+
+func (c *PKCS11Config) Validate() error {
+	if c.Module == "" {
+		return nil
+	}
+	if c.TokenLabel == "" || c.PINFile == "" {
+		return errors.New("pkcs11.token-label and pkcs11.pin-file must be set")
+	}
+	if (c.KeyLabel == "") == (c.KeyID == "") {
+		return errors.New("exactly one of pkcs11.key-label and pkcs11.key-id must be set")
+	}
+	if _, err := c.mechanism(); err != nil {
+		return err
+	}
+	if c.Sessions < 1 {
+		return errors.New("pkcs11.sessions must be positive")
+	}
+	return nil
+}
+
+func (c *PKCS11Config) mechanism() (uint, error) {
+	if c.Mechanism == "" {
+		return 0, errors.New("pkcs11.mechanism must be set, since PKCS#11 has no standard BLS mechanism")
+	}
+	mechanism, err := strconv.ParseUint(c.Mechanism, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid pkcs11.mechanism: %w", err)
+	}
+	return uint(mechanism), nil
+}
+
+// PKCS11BLSSigner has an HSM sign with a key it never exports.
+type PKCS11BLSSigner struct {
+	token  PKCS11Token
+	pubKey blsSignatures.PublicKey
+	desc   string
+}
+
+func NewPKCS11BLSSigner(config PKCS11Config) (*PKCS11BLSSigner, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	token, err := newPKCS11Token(config)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := newPKCS11BLSSignerWithToken(token, config)
+	if err != nil {
+		token.Close()
+		return nil, err
+	}
+	return signer, nil
+}
+
+func newPKCS11BLSSignerWithToken(token PKCS11Token, config PKCS11Config) (*PKCS11BLSSigner, error) {
+	desc := config.TokenLabel + "/" + config.KeyLabel
+	if config.KeyID != "" {
+		desc = config.TokenLabel + "/id:" + config.KeyID
+	}
+	var pubKey *blsSignatures.PublicKey
+	var err error
+	if config.PublicKey != "" {
+		pubKey, err = DecodeBase64BLSPublicKey([]byte(config.PublicKey))
+		if err != nil {
+			pubKey, err = ReadPubKeyFromFile(config.PublicKey)
+			if err != nil {
+				return nil, fmt.Errorf("pkcs11.public-key is neither a base64 public key nor a readable file: %w", err)
+			}
+		}
+	} else {
+		pubKeyBytes, err := token.PublicKey()
+		if err != nil {
+			return nil, err
+		}
+		if pubKeyBytes == nil {
+			return nil, fmt.Errorf("PKCS#11 token has no public key object for %s, so pkcs11.public-key must be set", desc)
+		}
+		key, err := blsSignatures.PublicKeyFromBytes(pubKeyBytes, false)
+		if err != nil {
+			return nil, fmt.Errorf("PKCS#11 public key object for %s isn't a BLS key: %w", desc, err)
+		}
+		pubKey = &key
+	}
+	s := &PKCS11BLSSigner{token: token, pubKey: *pubKey, desc: desc}
+
+	// A mechanism signing differently than the DAS, eg hashing to the curve
+	// with another domain, is only caught by checking a signature.
+	probe := crypto.Keccak256([]byte("das pkcs11 signer probe"))
+	if _, err := s.Sign(context.Background(), probe); err != nil {
+		return nil, fmt.Errorf("PKCS#11 mechanism %s didn't sign as the DAS does: %w", config.Mechanism, err)
+	}
+	return s, nil
+}
+
+func (s *PKCS11BLSSigner) PublicKey() blsSignatures.PublicKey {
+	return s.pubKey
+}
+
+func (s *PKCS11BLSSigner) Sign(ctx context.Context, message []byte) (blsSignatures.Signature, error) {
+	sigBytes, err := s.token.Sign(message)
+	if err != nil {
+		return nil, fmt.Errorf("PKCS#11 signing with %s failed: %w", s.desc, err)
+	}
+	sig, err := blsSignatures.SignatureFromBytes(sigBytes)
+	if err != nil {
+		return nil, fmt.Errorf("PKCS#11 token returned an invalid signature: %w", err)
+	}
+	verified, err := blsSignatures.VerifySignature(sig, message, s.pubKey)
+	if err != nil {
+		return nil, err
+	}
+	if !verified {
+		return nil, fmt.Errorf("PKCS#11 signature with %s doesn't verify against its public key", s.desc)
+	}
+	return sig, nil
+}
+
+func (s *PKCS11BLSSigner) Close() {
+	s.token.Close()
+}
+
+func (s *PKCS11BLSSigner) String() string {
+	return fmt.Sprintf("PKCS11BLSSigner{%s}", s.desc)
+}
+
+func readPKCS11PIN(pinFile string) (string, error) {
+	pin, err := os.ReadFile(pinFile)
+	if err != nil {
+		return "", fmt.Errorf("error reading PKCS#11 PIN: %w", err)
+	}
+	return string(bytes.TrimRight(pin, "\r\n")), nil
+}
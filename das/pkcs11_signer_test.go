@@ -0,0 +1,77 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+type mockPKCS11Token struct {
+	privKey     blsSignatures.PrivateKey
+	pubKeyValue []byte
+	closed      bool
+}
+
+func (m *mockPKCS11Token) Sign(message []byte) ([]byte, error) {
+	sig, err := blsSignatures.SignMessage(m.privKey, message)
+	if err != nil {
+		return nil, err
+	}
+	return blsSignatures.SignatureToBytes(sig), nil
+}
+
+func (m *mockPKCS11Token) PublicKey() ([]byte, error) {
+	return m.pubKeyValue, nil
+}
+
+func (m *mockPKCS11Token) Close() {
+	m.closed = true
+}
+
+func TestPKCS11BLSSigner(t *testing.T) {
+	pubKey, privKey, err := blsSignatures.GenerateKeys()
+	testhelpers.RequireImpl(t, err)
+	config := PKCS11Config{TokenLabel: "das", KeyLabel: "bls"}
+
+	// The public key can come from the token.
+	token := &mockPKCS11Token{privKey: privKey, pubKeyValue: blsSignatures.PublicKeyToBytes(pubKey)}
+	signer, err := newPKCS11BLSSignerWithToken(token, config)
+	testhelpers.RequireImpl(t, err)
+	message := testhelpers.RandomizeSlice(make([]byte, 32))
+	sig, err := signer.Sign(context.Background(), message)
+	testhelpers.RequireImpl(t, err)
+	verified, err := blsSignatures.VerifySignature(sig, message, pubKey)
+	testhelpers.RequireImpl(t, err)
+	if !verified {
+		testhelpers.FailImpl(t, "PKCS#11 signature doesn't verify")
+	}
+
+	// Or from the config, if the token has no public key object.
+	withoutPubKey := &mockPKCS11Token{privKey: privKey}
+	if _, err := newPKCS11BLSSignerWithToken(withoutPubKey, config); err == nil {
+		testhelpers.FailImpl(t, "expected a token without a public key to need one configured")
+	}
+	configured := config
+	configured.PublicKey = base64.StdEncoding.EncodeToString(blsSignatures.PublicKeyToBytes(pubKey))
+	_, err = newPKCS11BLSSignerWithToken(withoutPubKey, configured)
+	testhelpers.RequireImpl(t, err)
+
+	// A mechanism signing with another key, or differently, is caught on
+	// startup.
+	_, otherPrivKey, err := blsSignatures.GenerateKeys()
+	testhelpers.RequireImpl(t, err)
+	if _, err := newPKCS11BLSSignerWithToken(&mockPKCS11Token{privKey: otherPrivKey}, configured); err == nil {
+		testhelpers.FailImpl(t, "expected a token signing with a different key to be rejected")
+	}
+
+	if err := (&PKCS11Config{Module: "module.so", TokenLabel: "das", PINFile: "pin", KeyLabel: "bls"}).Validate(); err == nil {
+		testhelpers.FailImpl(t, "expected a config without a mechanism to be invalid")
+	}
+	testhelpers.RequireImpl(t, (&PKCS11Config{Module: "module.so", TokenLabel: "das", PINFile: "pin", KeyLabel: "bls", Mechanism: "0x80000123", Sessions: 1}).Validate())
+}
@@ -0,0 +1,157 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+//go:build pkcs11
+
+package das
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+)
+
+type hsmToken struct {
+	ctx       *pkcs11.Ctx
+	mechanism uint
+	keyAttrs  []*pkcs11.Attribute
+	privKey   pkcs11.ObjectHandle
+	// PKCS#11 sessions can't be used concurrently, so each signature takes
+	// one from the pool.
+	sessions  chan pkcs11.SessionHandle
+	all       []pkcs11.SessionHandle
+	closeOnce sync.Once
+}
+
+func newPKCS11Token(config PKCS11Config) (PKCS11Token, error) {
+	mechanism, err := config.mechanism()
+	if err != nil {
+		return nil, err
+	}
+	pin, err := readPKCS11PIN(config.PINFile)
+	if err != nil {
+		return nil, err
+	}
+	p := pkcs11.New(config.Module)
+	if p == nil {
+		return nil, fmt.Errorf("couldn't load PKCS#11 module %s", config.Module)
+	}
+	if err := p.Initialize(); err != nil {
+		p.Destroy()
+		return nil, fmt.Errorf("couldn't initialize PKCS#11 module %s: %w", config.Module, err)
+	}
+	t := &hsmToken{ctx: p, mechanism: mechanism, sessions: make(chan pkcs11.SessionHandle, config.Sessions)}
+	if err := t.open(config, pin); err != nil {
+		t.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *hsmToken) open(config PKCS11Config, pin string) error {
+	slots, err := t.ctx.GetSlotList(true)
+	if err != nil {
+		return err
+	}
+	var slot uint
+	found := false
+	for _, s := range slots {
+		info, err := t.ctx.GetTokenInfo(s)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(info.Label) == config.TokenLabel {
+			slot, found = s, true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("no PKCS#11 token labeled %s", config.TokenLabel)
+	}
+
+	for i := 0; i < config.Sessions; i++ {
+		session, err := t.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+		if err != nil {
+			return err
+		}
+		t.all = append(t.all, session)
+		t.sessions <- session
+	}
+	// Logging in applies to all of the application's sessions.
+	if err := t.ctx.Login(t.all[0], pkcs11.CKU_USER, pin); err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+		return fmt.Errorf("couldn't log in to PKCS#11 token %s: %w", config.TokenLabel, err)
+	}
+
+	if config.KeyID != "" {
+		id, err := hex.DecodeString(strings.TrimPrefix(config.KeyID, "0x"))
+		if err != nil {
+			return fmt.Errorf("invalid pkcs11.key-id: %w", err)
+		}
+		t.keyAttrs = []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_ID, id)}
+	} else {
+		t.keyAttrs = []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_LABEL, config.KeyLabel)}
+	}
+	keys, err := t.findObjects(pkcs11.CKO_PRIVATE_KEY)
+	if err != nil {
+		return err
+	}
+	if len(keys) != 1 {
+		return fmt.Errorf("found %d private keys matching the configured key on PKCS#11 token %s, expected 1", len(keys), config.TokenLabel)
+	}
+	t.privKey = keys[0]
+	return nil
+}
+
+func (t *hsmToken) findObjects(class uint) ([]pkcs11.ObjectHandle, error) {
+	session := <-t.sessions
+	defer func() { t.sessions <- session }()
+	template := append([]*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_CLASS, class)}, t.keyAttrs...)
+	if err := t.ctx.FindObjectsInit(session, template); err != nil {
+		return nil, err
+	}
+	objects, _, err := t.ctx.FindObjects(session, 2)
+	if finalErr := t.ctx.FindObjectsFinal(session); err == nil {
+		err = finalErr
+	}
+	return objects, err
+}
+
+func (t *hsmToken) Sign(message []byte) ([]byte, error) {
+	session := <-t.sessions
+	defer func() { t.sessions <- session }()
+	if err := t.ctx.SignInit(session, []*pkcs11.Mechanism{pkcs11.NewMechanism(t.mechanism, nil)}, t.privKey); err != nil {
+		return nil, err
+	}
+	return t.ctx.Sign(session, message)
+}
+
+func (t *hsmToken) PublicKey() ([]byte, error) {
+	keys, err := t.findObjects(pkcs11.CKO_PUBLIC_KEY)
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	session := <-t.sessions
+	defer func() { t.sessions <- session }()
+	attrs, err := t.ctx.GetAttributeValue(session, keys[0], []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_VALUE, nil)})
+	if err != nil {
+		return nil, err
+	}
+	return attrs[0].Value, nil
+}
+
+func (t *hsmToken) Close() {
+	t.closeOnce.Do(func() {
+		if len(t.all) > 0 {
+			_ = t.ctx.Logout(t.all[0])
+		}
+		for _, session := range t.all {
+			_ = t.ctx.CloseSession(session)
+		}
+		_ = t.ctx.Finalize()
+		t.ctx.Destroy()
+	})
+}
@@ -0,0 +1,12 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+//go:build !pkcs11
+
+package das
+
+import "errors"
+
+func newPKCS11Token(config PKCS11Config) (PKCS11Token, error) {
+	return nil, errors.New("key.pkcs11 requires a daserver built with the pkcs11 build tag, which loads PKCS#11 modules")
+}
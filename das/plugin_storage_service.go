@@ -0,0 +1,294 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/util/pretty"
+
+	flag "github.com/spf13/pflag"
+)
+
+// Storage plugins are separate processes that implement a StorageService, so
+// backends can be written in any language without changes to nitro.
+//
+// The daserver starts the plugin command and speaks JSON-RPC 2.0 to it, one
+// message per line, over the plugin's stdin and stdout. Anything the plugin
+// writes to stderr is logged. Binary data is hex encoded with a 0x prefix.
+// The plugin must implement these methods:
+//
+//	storage_getByHash(hash) -> data
+//	storage_put(data, timeout) -> null
+//	storage_putKeyValue(hash, data) -> null
+//	storage_sync() -> null
+//	storage_expirationPolicy() -> "KeepForever" | "DiscardAfterArchiveTimeout" | "DiscardAfterDataTimeout" | ...
+//	storage_healthCheck() -> null
+//	storage_close() -> null
+//
+// storage_getByHash must return an error with code PluginErrorCodeNotFound if
+// it doesn't have the data. After storage_close the daserver closes the
+// plugin's stdin, and the plugin should exit. Go plugins can use
+// ServeStoragePlugin.
+
+const PluginErrorCodeNotFound = 404
+
+type PluginStorageServiceConfig struct {
+	Enable                 bool          `koanf:"enable"`
+	Command                string        `koanf:"command"`
+	Args                   []string      `koanf:"args"`
+	ShutdownTimeout        time.Duration `koanf:"shutdown-timeout"`
+	SyncFromStorageService bool          `koanf:"sync-from-storage-service"`
+	SyncToStorageService   bool          `koanf:"sync-to-storage-service"`
+}
+
+var DefaultPluginStorageServiceConfig = PluginStorageServiceConfig{
+	ShutdownTimeout: 10 * time.Second,
+}
+
+func PluginStorageConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultPluginStorageServiceConfig.Enable, "enable storage/retrieval of sequencer batch data through an out-of-process storage plugin")
+	f.String(prefix+".command", DefaultPluginStorageServiceConfig.Command, "storage plugin executable, which speaks JSON-RPC over its stdin and stdout")
+	f.StringSlice(prefix+".args", DefaultPluginStorageServiceConfig.Args, "arguments to pass to the storage plugin")
+	f.Duration(prefix+".shutdown-timeout", DefaultPluginStorageServiceConfig.ShutdownTimeout, "how long to wait for the storage plugin to exit before killing it")
+	f.Bool(prefix+".sync-from-storage-service", DefaultPluginStorageServiceConfig.SyncFromStorageService, "enable the storage plugin to be used as a source for regular sync storage")
+	f.Bool(prefix+".sync-to-storage-service", DefaultPluginStorageServiceConfig.SyncToStorageService, "enable the storage plugin to be used as a sink for regular sync storage")
+}
+
+type PluginStorageService struct {
+	client *rpc.Client
+	name   string
+	// stop is called after the plugin has been asked to close.
+	stop func() error
+}
+
+func NewPluginStorageService(ctx context.Context, config PluginStorageServiceConfig) (StorageService, error) {
+	if config.Command == "" {
+		return nil, errors.New("plugin-storage.command must be set")
+	}
+	// #nosec G204
+	cmd := exec.Command(config.Command, config.Args...)
+	cmd.Env = os.Environ()
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting storage plugin %s: %w", config.Command, err)
+	}
+	name := strings.Join(append([]string{config.Command}, config.Args...), " ")
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			log.Info("storage plugin", "plugin", config.Command, "msg", scanner.Text())
+		}
+	}()
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+	var stopOnce sync.Once
+	var stopErr error
+	stop := func() error {
+		stopOnce.Do(func() {
+			_ = stdin.Close()
+			select {
+			case stopErr = <-exited:
+			case <-time.After(config.ShutdownTimeout):
+				log.Warn("storage plugin didn't exit, killing it", "plugin", config.Command)
+				stopErr = cmd.Process.Kill()
+			}
+		})
+		return stopErr
+	}
+
+	ps, err := newPluginStorageService(ctx, stdout, stdin, name, stop)
+	if err != nil {
+		_ = stop()
+		return nil, err
+	}
+	if err := ps.HealthCheck(ctx); err != nil {
+		_ = ps.Close(ctx)
+		return nil, fmt.Errorf("storage plugin %s failed its health check: %w", config.Command, err)
+	}
+	return ps, nil
+}
+
+func newPluginStorageService(ctx context.Context, in io.Reader, out io.Writer, name string, stop func() error) (*PluginStorageService, error) {
+	client, err := rpc.DialIO(ctx, in, out)
+	if err != nil {
+		return nil, err
+	}
+	return &PluginStorageService{
+		client: client,
+		name:   name,
+		stop:   stop,
+	}, nil
+}
+
+func (ps *PluginStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.PluginStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", ps)
+	var data hexutil.Bytes
+	err := ps.client.CallContext(ctx, &data, "storage_getByHash", key)
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == PluginErrorCodeNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		log.Error("das.PluginStorageService.GetByHash", "err", err)
+		return nil, err
+	}
+	return data, nil
+}
+
+func (ps *PluginStorageService) Put(ctx context.Context, value []byte, timeout uint64) error {
+	logPut("das.PluginStorageService.Store", value, timeout, ps)
+	err := ps.client.CallContext(ctx, nil, "storage_put", hexutil.Bytes(value), hexutil.Uint64(timeout))
+	if err != nil {
+		log.Error("das.PluginStorageService.Store", "err", err)
+	}
+	return err
+}
+
+func (ps *PluginStorageService) putKeyValue(ctx context.Context, key common.Hash, value []byte) error {
+	err := ps.client.CallContext(ctx, nil, "storage_putKeyValue", key, hexutil.Bytes(value))
+	if err != nil {
+		log.Error("das.PluginStorageService.putKeyValue", "err", err)
+	}
+	return err
+}
+
+func (ps *PluginStorageService) Sync(ctx context.Context) error {
+	return ps.client.CallContext(ctx, nil, "storage_sync")
+}
+
+func (ps *PluginStorageService) Close(ctx context.Context) error {
+	if err := ps.client.CallContext(ctx, nil, "storage_close"); err != nil {
+		log.Warn("storage plugin failed to close", "plugin", ps.name, "err", err)
+	}
+	ps.client.Close()
+	if ps.stop != nil {
+		return ps.stop()
+	}
+	return nil
+}
+
+func (ps *PluginStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	var res string
+	if err := ps.client.CallContext(ctx, &res, "storage_expirationPolicy"); err != nil {
+		return -1, err
+	}
+	return arbstate.StringToExpirationPolicy(res)
+}
+
+func (ps *PluginStorageService) String() string {
+	return fmt.Sprintf("PluginStorageService(%s)", ps.name)
+}
+
+func (ps *PluginStorageService) HealthCheck(ctx context.Context) error {
+	return ps.client.CallContext(ctx, nil, "storage_healthCheck")
+}
+
+// ServeStoragePlugin serves storageService over the storage plugin protocol,
+// reading requests from in and writing responses to out, until in is closed.
+func ServeStoragePlugin(storageService StorageService, in io.Reader, out io.Writer) error {
+	server := rpc.NewServer()
+	if err := server.RegisterName("storage", &pluginStorageServer{storageService}); err != nil {
+		return err
+	}
+	server.ServeCodec(rpc.NewCodec(&pluginConn{in, out}), 0)
+	return nil
+}
+
+type pluginConn struct {
+	io.Reader
+	io.Writer
+}
+
+func (c *pluginConn) Close() error {
+	if closer, ok := c.Writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (c *pluginConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+type pluginError struct {
+	code int
+	msg  string
+}
+
+func (e *pluginError) Error() string {
+	return e.msg
+}
+
+func (e *pluginError) ErrorCode() int {
+	return e.code
+}
+
+type pluginStorageServer struct {
+	storageService StorageService
+}
+
+func (s *pluginStorageServer) GetByHash(ctx context.Context, key common.Hash) (hexutil.Bytes, error) {
+	data, err := s.storageService.GetByHash(ctx, key)
+	if errors.Is(err, ErrNotFound) {
+		return nil, &pluginError{PluginErrorCodeNotFound, err.Error()}
+	}
+	return data, err
+}
+
+func (s *pluginStorageServer) Put(ctx context.Context, data hexutil.Bytes, timeout hexutil.Uint64) error {
+	return s.storageService.Put(ctx, data, uint64(timeout))
+}
+
+func (s *pluginStorageServer) PutKeyValue(ctx context.Context, key common.Hash, data hexutil.Bytes) error {
+	return ConvertStorageServiceToIterationCompatibleStorageService(s.storageService).putKeyValue(ctx, key, data)
+}
+
+func (s *pluginStorageServer) Sync(ctx context.Context) error {
+	return s.storageService.Sync(ctx)
+}
+
+func (s *pluginStorageServer) ExpirationPolicy(ctx context.Context) (string, error) {
+	expirationPolicy, err := s.storageService.ExpirationPolicy(ctx)
+	if err != nil {
+		return "", err
+	}
+	return expirationPolicy.String()
+}
+
+func (s *pluginStorageServer) HealthCheck(ctx context.Context) error {
+	return s.storageService.HealthCheck(ctx)
+}
+
+func (s *pluginStorageServer) Close(ctx context.Context) error {
+	return s.storageService.Close(ctx)
+}
@@ -0,0 +1,66 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+func TestPluginStorageService(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	inner := NewMemoryBackedStorageService(ctx)
+
+	requestsReader, requestsWriter := io.Pipe()
+	responsesReader, responsesWriter := io.Pipe()
+	go func() {
+		_ = ServeStoragePlugin(inner, requestsReader, responsesWriter)
+	}()
+	stop := func() error {
+		return requestsWriter.Close()
+	}
+	ps, err := newPluginStorageService(ctx, responsesReader, requestsWriter, "test", stop)
+	Require(t, err)
+	Require(t, ps.HealthCheck(ctx))
+
+	val1 := []byte("The first value")
+	key1 := dastree.Hash(val1)
+	_, err = ps.GetByHash(ctx, key1)
+	if !errors.Is(err, ErrNotFound) {
+		Fail(t, "expected ErrNotFound", err)
+	}
+
+	Require(t, ps.Put(ctx, val1, timeout))
+	val, err := ps.GetByHash(ctx, key1)
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		Fail(t, val, val1)
+	}
+	val, err = inner.GetByHash(ctx, key1)
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		Fail(t, val, val1)
+	}
+
+	Require(t, ps.Sync(ctx))
+	expirationPolicy, err := ps.ExpirationPolicy(ctx)
+	Require(t, err)
+	if expirationPolicy != arbstate.KeepForever {
+		Fail(t, "unexpected expiration policy", expirationPolicy)
+	}
+
+	Require(t, ps.Close(ctx))
+	_, err = inner.GetByHash(ctx, key1)
+	if !errors.Is(err, ErrClosed) {
+		Fail(t, "plugin wasn't closed", err)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// The probe endpoints of the REST and HTTP-RPC servers, for Kubernetes and
+// load balancers. /livez and /healthz only report that the process is up, so
+// a slow storage backend doesn't get it restarted; /readyz reports whether
+// it should be sent traffic.
+const (
+	livezRequestPath   = "/livez"
+	healthzRequestPath = "/healthz"
+	readyzRequestPath  = "/readyz"
+)
+
+const readinessCheckTimeout = 5 * time.Second
+
+// ReadinessCheck is a condition for being ready to serve traffic, reported
+// under Name on /readyz.
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// ReadinessChecker adds readiness checks to the health check it wraps, which
+// /readyz runs as the "storage" check.
+type ReadinessChecker struct {
+	DataAvailabilityServiceHealthChecker
+	checks []ReadinessCheck
+}
+
+func NewReadinessChecker(healthChecker DataAvailabilityServiceHealthChecker, checks ...ReadinessCheck) *ReadinessChecker {
+	return &ReadinessChecker{
+		DataAvailabilityServiceHealthChecker: healthChecker,
+		checks:                               checks,
+	}
+}
+
+func readinessChecks(healthChecker DataAvailabilityServiceHealthChecker) []ReadinessCheck {
+	checks := []ReadinessCheck{{Name: "storage", Check: healthChecker.HealthCheck}}
+	if readinessChecker, ok := healthChecker.(*ReadinessChecker); ok {
+		checks = append(checks, readinessChecker.checks...)
+	}
+	return checks
+}
+
+// serveReadyz runs the readiness checks, answering with a line per check in
+// the style of the Kubernetes API server's own /readyz.
+func serveReadyz(w http.ResponseWriter, r *http.Request, healthChecker DataAvailabilityServiceHealthChecker) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessCheckTimeout)
+	defer cancel()
+	var report strings.Builder
+	ready := true
+	for _, check := range readinessChecks(healthChecker) {
+		if err := check.Check(ctx); err != nil {
+			ready = false
+			fmt.Fprintf(&report, "[-]%s failed: %v\n", check.Name, err)
+		} else {
+			fmt.Fprintf(&report, "[+]%s ok\n", check.Name)
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if ready {
+		w.WriteHeader(http.StatusOK)
+		report.WriteString("readyz check passed\n")
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		report.WriteString("readyz check failed\n")
+	}
+	_, _ = w.Write([]byte(report.String()))
+}
+
+// probeHandler serves the probe endpoints ahead of handler, so probes aren't
+// rate limited.
+func probeHandler(healthChecker DataAvailabilityServiceHealthChecker, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case livezRequestPath, healthzRequestPath:
+			w.Header().Set("Cache-Control", "no-store")
+			_, _ = w.Write([]byte("ok\n"))
+		case readyzRequestPath:
+			serveReadyz(w, r, healthChecker)
+		default:
+			handler.ServeHTTP(w, r)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testHealthChecker struct {
+	err error
+}
+
+func (c *testHealthChecker) HealthCheck(ctx context.Context) error {
+	return c.err
+}
+
+func TestProbes(t *testing.T) {
+	storage := &testHealthChecker{}
+	parentChain := &testHealthChecker{}
+	checker := NewReadinessChecker(storage, ReadinessCheck{Name: "parent-chain", Check: parentChain.HealthCheck})
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler := probeHandler(checker, next)
+	probe := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+
+	if res := probe(readyzRequestPath); res.Code != http.StatusOK || !strings.Contains(res.Body.String(), "[+]parent-chain ok") {
+		Fail(t, "expected ready", res.Code, res.Body.String())
+	}
+	parentChain.err = errors.New("latest header is old")
+	res := probe(readyzRequestPath)
+	if res.Code != http.StatusServiceUnavailable || !strings.Contains(res.Body.String(), "[-]parent-chain failed: latest header is old") || !strings.Contains(res.Body.String(), "[+]storage ok") {
+		Fail(t, "expected not ready because of the parent chain", res.Code, res.Body.String())
+	}
+	// Liveness doesn't depend on the checks.
+	storage.err = errors.New("unreachable")
+	for _, path := range []string{livezRequestPath, healthzRequestPath} {
+		if res := probe(path); res.Code != http.StatusOK {
+			Fail(t, "expected live", path, res.Code)
+		}
+	}
+	if res := probe("/health"); res.Code != http.StatusTeapot {
+		Fail(t, "expected other paths to be passed on", res.Code)
+	}
+
+	// Without a ReadinessChecker, only storage is checked.
+	recorder := httptest.NewRecorder()
+	probeHandler(storage, next).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, readyzRequestPath, nil))
+	if recorder.Code != http.StatusServiceUnavailable || !strings.Contains(recorder.Body.String(), "[-]storage failed: unreachable") {
+		Fail(t, "expected not ready because of storage", recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// The HTTP endpoints taking and returning das.proto messages, described there.
+
+const protobufContentType = "application/x-protobuf"
+
+const protobufStorePath = "/store"
+
+// maxProtobufStoreSize bounds the body of a protobuf store, which is read
+// whole before it's decoded. Larger messages go through the chunked store.
+const maxProtobufStoreSize = 64 << 20
+
+// wantsProtobufResponse reports whether the client asked for a protobuf
+// response, with "?encoding=protobuf" or by accepting only
+// application/x-protobuf.
+func wantsProtobufResponse(r *http.Request) bool {
+	if r.URL.Query().Get("encoding") == "protobuf" {
+		return true
+	}
+	return strings.TrimSpace(strings.Split(r.Header.Get("Accept"), ";")[0]) == protobufContentType
+}
+
+func writeProtobuf(w http.ResponseWriter, m grpcMessage) error {
+	b, err := grpcCodec{}.Marshal(m)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", protobufContentType)
+	_, err = w.Write(b)
+	return err
+}
+
+func protobufStoreErrorStatus(err error) int {
+	var payloadTooLarge *PayloadTooLargeError
+	var timeoutOutOfBounds *TimeoutOutOfBoundsError
+	var overloaded *OverloadedError
+	switch {
+	case errors.As(err, &payloadTooLarge), errors.As(err, &timeoutOutOfBounds):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrRateLimited), errors.As(err, &overloaded):
+		return http.StatusTooManyRequests
+	case errors.Is(err, ErrInvalidBearerToken), errors.Is(err, ErrClientCertRequired):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrStoreDisabled):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// serveProtobufStore stores the message of a protobuf StoreRequest as Store
+// would, answering with its certificate as a StoreResponse.
+func (serv *DASRPCServer) serveProtobufStore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if strings.TrimSpace(strings.Split(r.Header.Get("Content-Type"), ";")[0]) != protobufContentType {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxProtobufStoreSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	var req grpcStoreRequest
+	if err := (grpcCodec{}).Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result, err := serv.Store(r.Context(), req.Chunk, hexutil.Uint64(req.Timeout), req.Sig)
+	if err != nil {
+		http.Error(w, err.Error(), protobufStoreErrorStatus(err))
+		return
+	}
+	err = writeProtobuf(w, &grpcStoreResponse{
+		DataHash:    result.DataHash,
+		Timeout:     uint64(result.Timeout),
+		SignersMask: uint64(result.SignersMask),
+		KeysetHash:  result.KeysetHash,
+		Sig:         result.Sig,
+		Version:     uint64(result.Version),
+	})
+	if err != nil {
+		log.Warn("Failed writing protobuf store response", "err", err)
+	}
+}
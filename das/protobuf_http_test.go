@@ -0,0 +1,91 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestProtobufStoreAndRetrieve(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	storageService := NewMemoryBackedStorageService(ctx)
+	keyDir := t.TempDir()
+	_, _, err := GenerateAndStoreKeys(keyDir)
+	Require(t, err)
+	privKey, err := (&KeyConfig{KeyDir: keyDir}).BLSPrivKey()
+	Require(t, err)
+	localDas, err := NewSignAfterStoreDASWriterWithSeqInboxCaller(privKey, nil, storageService, "")
+	Require(t, err)
+
+	rpcListener, err := net.Listen("tcp", "localhost:0")
+	Require(t, err)
+	rpcServer, err := StartDASRPCServerOnListener(ctx, rpcListener, genericconf.HTTPServerTimeoutConfigDefault, nil, DefaultStoreBoundsConfig, storageService, localDas, storageService)
+	Require(t, err)
+	defer func() {
+		Require(t, rpcServer.Shutdown(ctx))
+	}()
+	restServer, restPort, err := NewRestfulDasServerOnRandomPort(LocalServerAddressForTest, storageService)
+	Require(t, err)
+	defer func() {
+		Require(t, restServer.Shutdown())
+	}()
+
+	message := testhelpers.RandomizeSlice(make([]byte, 1000))
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	body, err := grpcCodec{}.Marshal(&grpcStoreRequest{Chunk: message, Timeout: timeout})
+	Require(t, err)
+	storeURL := "http://" + rpcListener.Addr().String() + protobufStorePath
+	res, err := http.Post(storeURL, protobufContentType, bytes.NewReader(body))
+	Require(t, err)
+	resBody, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	Require(t, err)
+	if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != protobufContentType {
+		Fail(t, "unexpected protobuf store response", res.StatusCode, string(resBody))
+	}
+	var cert grpcStoreResponse
+	Require(t, grpcCodec{}.Unmarshal(resBody, &cert))
+	if common.BytesToHash(cert.DataHash) != dastree.Hash(message) || cert.Timeout != timeout || len(cert.Sig) == 0 {
+		Fail(t, "unexpected certificate", cert)
+	}
+
+	res, err = http.Post(storeURL, "application/json", bytes.NewReader(body))
+	Require(t, err)
+	res.Body.Close()
+	if res.StatusCode != http.StatusUnsupportedMediaType {
+		Fail(t, "expected store without the protobuf content type to be rejected", res.StatusCode)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+net.JoinHostPort(LocalServerAddressForTest, strconv.Itoa(restPort))+getByHashRequestPath+EncodeStorageServiceKey(dastree.Hash(message)), nil)
+	Require(t, err)
+	req.Header.Set("Accept", protobufContentType)
+	res, err = http.DefaultClient.Do(req)
+	Require(t, err)
+	resBody, err = io.ReadAll(res.Body)
+	res.Body.Close()
+	Require(t, err)
+	if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != protobufContentType {
+		Fail(t, "unexpected protobuf retrieve response", res.StatusCode)
+	}
+	var retrieved grpcRetrieveResponse
+	Require(t, grpcCodec{}.Unmarshal(resBody, &retrieved))
+	if !bytes.Equal(retrieved.Chunk, message) {
+		Fail(t, "retrieved data doesn't match the stored message")
+	}
+}
@@ -0,0 +1,373 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbstate"
+
+	flag "github.com/spf13/pflag"
+)
+
+var (
+	ipRateLimitedCounter     = metrics.NewRegisteredCounter("arb/das/ratelimit/ip/limited", nil)
+	signerRateLimitedCounter = metrics.NewRegisteredCounter("arb/das/ratelimit/signer/limited", nil)
+)
+
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+type RateLimitConfig struct {
+	PerIP     ClientRateLimitConfig `koanf:"per-ip"`
+	PerSigner ClientRateLimitConfig `koanf:"per-signer"`
+	// ClientIPHeader is the header a reverse proxy in front of the server
+	// puts the client's IP in, eg X-Forwarded-For. The last address in it is
+	// used, being the one the proxy itself added.
+	ClientIPHeader string `koanf:"client-ip-header"`
+}
+
+// ClientRateLimitConfig limits each client to RequestsPerSecond requests and
+// BytesPerSecond bytes, sent or received, allowing bursts of up to
+// RequestBurst requests and ByteBurst bytes. Zero rates are unlimited.
+type ClientRateLimitConfig struct {
+	RequestsPerSecond float64 `koanf:"requests-per-second" json:"requestsPerSecond"`
+	RequestBurst      float64 `koanf:"request-burst" json:"requestBurst"`
+	BytesPerSecond    float64 `koanf:"bytes-per-second" json:"bytesPerSecond"`
+	ByteBurst         float64 `koanf:"byte-burst" json:"byteBurst"`
+}
+
+var DefaultRateLimitConfig = RateLimitConfig{
+	PerIP:     DefaultClientRateLimitConfig,
+	PerSigner: DefaultClientRateLimitConfig,
+}
+
+var DefaultClientRateLimitConfig = ClientRateLimitConfig{
+	RequestBurst: 100,
+	ByteBurst:    64 << 20,
+}
+
+func RateLimitConfigAddOptions(prefix string, f *flag.FlagSet) {
+	ClientRateLimitConfigAddOptions(prefix+".per-ip", "client IP", f)
+	ClientRateLimitConfigAddOptions(prefix+".per-signer", "store signer address, of stores over HTTP-RPC or gRPC", f)
+	f.String(prefix+".client-ip-header", DefaultRateLimitConfig.ClientIPHeader, "header a trusted reverse proxy puts the client's IP in, eg X-Forwarded-For, whose last address is used instead of the connection's remote address")
+}
+
+func ClientRateLimitConfigAddOptions(prefix string, key string, f *flag.FlagSet) {
+	f.Float64(prefix+".requests-per-second", DefaultClientRateLimitConfig.RequestsPerSecond, "requests allowed per second per "+key+" (0 for unlimited)")
+	f.Float64(prefix+".request-burst", DefaultClientRateLimitConfig.RequestBurst, "requests allowed in a burst per "+key)
+	f.Float64(prefix+".bytes-per-second", DefaultClientRateLimitConfig.BytesPerSecond, "bytes sent or received allowed per second per "+key+" (0 for unlimited)")
+	f.Float64(prefix+".byte-burst", DefaultClientRateLimitConfig.ByteBurst, "bytes sent or received allowed in a burst per "+key)
+}
+
+func (c *ClientRateLimitConfig) enabled() bool {
+	return c.RequestsPerSecond > 0 || c.BytesPerSecond > 0
+}
+
+func (c *ClientRateLimitConfig) Validate() error {
+	if c.RequestsPerSecond < 0 || c.RequestBurst < 0 || c.BytesPerSecond < 0 || c.ByteBurst < 0 {
+		return errors.New("rate limits and bursts can't be negative")
+	}
+	return nil
+}
+
+// tokenBucket may go into debt, so a request larger than the burst is allowed
+// once the bucket is full and then delays the client's next requests.
+type tokenBucket struct {
+	rate    float64
+	burst   float64
+	tokens  float64
+	updated time.Time
+}
+
+func newTokenBucket(rate, burst float64, now time.Time) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, updated: now}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.updated).Seconds()*b.rate)
+	b.updated = now
+}
+
+// wait returns how long until the bucket is out of debt.
+func (b *tokenBucket) wait() time.Duration {
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+type clientRateLimit struct {
+	requests *tokenBucket
+	bytes    *tokenBucket
+	lastSeen time.Time
+}
+
+// clientRateLimiter keeps a pair of token buckets per client.
+type clientRateLimiter struct {
+	config    ClientRateLimitConfig
+	mutex     sync.Mutex
+	clients   map[string]*clientRateLimit
+	lastPrune time.Time
+}
+
+// clientRateLimitIdleTime is how long a client's buckets are kept without a
+// request, by when they'd be full again at any practical rate.
+const clientRateLimitIdleTime = 10 * time.Minute
+
+func newClientRateLimiter(config ClientRateLimitConfig) *clientRateLimiter {
+	if !config.enabled() {
+		return nil
+	}
+	return &clientRateLimiter{config: config, clients: make(map[string]*clientRateLimit), lastPrune: time.Now()}
+}
+
+func (l *clientRateLimiter) getConfig() ClientRateLimitConfig {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.config
+}
+
+// setConfig changes the limits at runtime. Clients start over with full
+// buckets at the new rates.
+func (l *clientRateLimiter) setConfig(config ClientRateLimitConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.config = config
+	l.clients = make(map[string]*clientRateLimit)
+	return nil
+}
+
+func (l *clientRateLimiter) clientLocked(key string, now time.Time) *clientRateLimit {
+	if now.Sub(l.lastPrune) > time.Minute {
+		for k, client := range l.clients {
+			if now.Sub(client.lastSeen) > clientRateLimitIdleTime {
+				delete(l.clients, k)
+			}
+		}
+		l.lastPrune = now
+	}
+	client, ok := l.clients[key]
+	if !ok {
+		client = &clientRateLimit{
+			requests: newTokenBucket(l.config.RequestsPerSecond, l.config.RequestBurst, now),
+			bytes:    newTokenBucket(l.config.BytesPerSecond, l.config.ByteBurst, now),
+		}
+		l.clients[key] = client
+	}
+	client.lastSeen = now
+	return client
+}
+
+// allow takes a request and size bytes from the client's buckets, or returns
+// how long the client should wait before retrying.
+func (l *clientRateLimiter) allow(key string, size int) (bool, time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	now := time.Now()
+	client := l.clientLocked(key, now)
+	for _, bucket := range []*tokenBucket{client.requests, client.bytes} {
+		if bucket == nil {
+			continue
+		}
+		bucket.refill(now)
+		if wait := bucket.wait(); wait > 0 {
+			return false, wait
+		}
+	}
+	if client.requests != nil {
+		client.requests.tokens--
+	}
+	l.chargeLocked(client, size)
+	return true, 0
+}
+
+// charge takes bytes transferred after the request was allowed.
+func (l *clientRateLimiter) charge(key string, size int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.chargeLocked(l.clientLocked(key, time.Now()), size)
+}
+
+func (l *clientRateLimiter) chargeLocked(client *clientRateLimit, size int) {
+	if client.bytes != nil {
+		client.bytes.tokens -= float64(size)
+	}
+}
+
+// IPRateLimiter limits the requests and bytes of each client IP of the REST,
+// HTTP-RPC and gRPC servers, answering with 429 Too Many Requests, or
+// RESOURCE_EXHAUSTED over gRPC.
+type IPRateLimiter struct {
+	limiter        *clientRateLimiter
+	clientIPHeader string
+}
+
+// NewIPRateLimiter returns nil if per IP limits aren't configured.
+func NewIPRateLimiter(config RateLimitConfig) *IPRateLimiter {
+	limiter := newClientRateLimiter(config.PerIP)
+	if limiter == nil {
+		return nil
+	}
+	return &IPRateLimiter{limiter: limiter, clientIPHeader: config.ClientIPHeader}
+}
+
+// Config returns the per IP limits in effect.
+func (l *IPRateLimiter) Config() ClientRateLimitConfig {
+	return l.limiter.getConfig()
+}
+
+// SetConfig changes the per IP limits; zero rates lift them. Limits that
+// weren't configured at startup can't be added, as there's no IPRateLimiter.
+func (l *IPRateLimiter) SetConfig(config ClientRateLimitConfig) error {
+	return l.limiter.setConfig(config)
+}
+
+func (l *IPRateLimiter) clientIP(r *http.Request) string {
+	var forwarded []string
+	if l.clientIPHeader != "" {
+		forwarded = r.Header.Values(l.clientIPHeader)
+	}
+	return clientIPFrom(forwarded, r.RemoteAddr)
+}
+
+// clientIPFrom returns the last address in the client IP header's values if
+// there is one, or else remoteAddr's host.
+func clientIPFrom(forwarded []string, remoteAddr string) string {
+	if len(forwarded) > 0 {
+		addrs := strings.Split(forwarded[len(forwarded)-1], ",")
+		if ip := strings.TrimSpace(addrs[len(addrs)-1]); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+type countingReadCloser struct {
+	io.ReadCloser
+	count int
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.count += n
+	return n, err
+}
+
+type countingResponseWriter struct {
+	http.ResponseWriter
+	count int
+}
+
+func (c *countingResponseWriter) Write(p []byte) (int, error) {
+	n, err := c.ResponseWriter.Write(p)
+	c.count += n
+	return n, err
+}
+
+func (c *countingResponseWriter) Flush() {
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets the HTTP-RPC server upgrade WebSocket connections, whose
+// traffic isn't counted.
+func (c *countingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := c.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer doesn't support hijacking")
+	}
+	return hijacker.Hijack()
+}
+
+// Handler wraps handler with the limits; a nil IPRateLimiter doesn't limit.
+func (l *IPRateLimiter) Handler(handler http.Handler) http.Handler {
+	if l == nil {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := l.clientIP(r)
+		allowed, wait := l.limiter.allow(ip, 0)
+		if !allowed {
+			ipRateLimitedCounter.Inc(1)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, ErrRateLimited.Error(), http.StatusTooManyRequests)
+			return
+		}
+		body := &countingReadCloser{ReadCloser: r.Body}
+		r.Body = body
+		counter := &countingResponseWriter{ResponseWriter: w}
+		handler.ServeHTTP(counter, r)
+		l.limiter.charge(ip, body.count+counter.count)
+	})
+}
+
+// SignerRateLimiter limits the stores of each store signer, recovered from
+// the store's signature, through the writer it wraps. Unsigned stores are
+// only limited per IP.
+type SignerRateLimiter struct {
+	DataAvailabilityServiceWriter
+	limiter *clientRateLimiter
+}
+
+// NewSignerRateLimiter returns writer itself if per signer limits aren't
+// configured.
+func NewSignerRateLimiter(writer DataAvailabilityServiceWriter, config RateLimitConfig) DataAvailabilityServiceWriter {
+	limiter := newClientRateLimiter(config.PerSigner)
+	if limiter == nil {
+		return writer
+	}
+	return &SignerRateLimiter{DataAvailabilityServiceWriter: writer, limiter: limiter}
+}
+
+// Config returns the per signer limits in effect.
+func (l *SignerRateLimiter) Config() ClientRateLimitConfig {
+	return l.limiter.getConfig()
+}
+
+// SetConfig changes the per signer limits; zero rates lift them.
+func (l *SignerRateLimiter) SetConfig(config ClientRateLimitConfig) error {
+	return l.limiter.setConfig(config)
+}
+
+func (l *SignerRateLimiter) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	if len(sig) > 0 {
+		signer, err := DasRecoverSigner(message, timeout, sig)
+		if err != nil {
+			return nil, err
+		}
+		if allowed, wait := l.limiter.allow(signer.Hex(), len(message)); !allowed {
+			signerRateLimitedCounter.Inc(1)
+			return nil, fmt.Errorf("%w for store signer %v, retry in %v", ErrRateLimited, signer, wait.Round(time.Millisecond))
+		}
+	}
+	return l.DataAvailabilityServiceWriter.Store(ctx, message, timeout, sig)
+}
+
+func (l *SignerRateLimiter) String() string {
+	return fmt.Sprintf("SignerRateLimiter{%v}", l.DataAvailabilityServiceWriter)
+}
@@ -0,0 +1,105 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/offchainlabs/nitro/util/signature"
+)
+
+func TestIPRateLimiter(t *testing.T) {
+	limiter := NewIPRateLimiter(RateLimitConfig{
+		PerIP:          ClientRateLimitConfig{RequestsPerSecond: 0.001, RequestBurst: 2},
+		ClientIPHeader: "X-Forwarded-For",
+	})
+	handler := limiter.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func(forwardedFor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	for i := 0; i < 2; i++ {
+		if res := request("10.0.0.1, 192.0.2.1"); res.Code != http.StatusOK {
+			Fail(t, "expected request within the burst to be allowed", i, res.Code)
+		}
+	}
+	res := request("10.0.0.2, 192.0.2.1")
+	if res.Code != http.StatusTooManyRequests || res.Header().Get("Retry-After") == "" {
+		Fail(t, "expected request over the limit to be rejected", res.Code)
+	}
+	// Only the address the trusted proxy added identifies the client.
+	if res := request("192.0.2.2"); res.Code != http.StatusOK {
+		Fail(t, "expected another client's request to be allowed", res.Code)
+	}
+
+	if NewIPRateLimiter(DefaultRateLimitConfig) != nil {
+		Fail(t, "expected no limiter by default")
+	}
+}
+
+func TestIPRateLimiterBytes(t *testing.T) {
+	limiter := NewIPRateLimiter(RateLimitConfig{PerIP: ClientRateLimitConfig{BytesPerSecond: 1, ByteBurst: 100}})
+	handler := limiter.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(make([]byte, 1000))
+	}))
+	request := func() int {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/get-by-hash/00", nil))
+		return recorder.Code
+	}
+	// The first response is larger than the burst, but is only charged once
+	// it's sent.
+	if code := request(); code != http.StatusOK {
+		Fail(t, "expected first request to be allowed", code)
+	}
+	if code := request(); code != http.StatusTooManyRequests {
+		Fail(t, "expected request after exceeding the byte limit to be rejected", code)
+	}
+}
+
+func TestSignerRateLimiter(t *testing.T) {
+	ctx := context.Background()
+	inner := &certStore{}
+	writer := NewSignerRateLimiter(inner, RateLimitConfig{PerSigner: ClientRateLimitConfig{RequestsPerSecond: 0.001, RequestBurst: 1}})
+
+	sign := func() []byte {
+		privateKey, err := crypto.GenerateKey()
+		Require(t, err)
+		sig, err := applyDasSigner(signature.DataSignerFromPrivateKey(privateKey), []byte("batch"), 1)
+		Require(t, err)
+		return sig
+	}
+	firstSig := sign()
+	_, err := writer.Store(ctx, []byte("batch"), 1, firstSig)
+	Require(t, err)
+	if _, err := writer.Store(ctx, []byte("batch"), 1, firstSig); !errors.Is(err, ErrRateLimited) {
+		Fail(t, "expected the signer's second store to be limited", err)
+	}
+	_, err = writer.Store(ctx, []byte("batch"), 1, sign())
+	Require(t, err)
+	// Unsigned stores are only limited per IP.
+	for i := 0; i < 2; i++ {
+		_, err = writer.Store(ctx, []byte("batch"), 1, nil)
+		Require(t, err)
+	}
+	if inner.calls != 4 {
+		Fail(t, "unexpected number of stores", inner.calls)
+	}
+
+	if NewSignerRateLimiter(inner, DefaultRateLimitConfig) != DataAvailabilityServiceWriter(inner) {
+		Fail(t, "expected no limiter by default")
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var ErrReadOnly = errors.New("cannot write to a read-only StorageService")
+
+// ReadOnlyStorageService serves reads from the wrapped StorageService and
+// rejects Puts and Syncs, for mirrors serving a replicated copy of another
+// daserver's data. Unlike readLimitedStorageService, writes are an expected
+// runtime condition rather than a logic error, so they return ErrReadOnly.
+type ReadOnlyStorageService struct {
+	StorageService
+}
+
+func NewReadOnlyStorageService(storageService StorageService) *ReadOnlyStorageService {
+	return &ReadOnlyStorageService{storageService}
+}
+
+func (r *ReadOnlyStorageService) Put(ctx context.Context, data []byte, expirationTime uint64) error {
+	logPut("das.ReadOnlyStorageService.Store", data, expirationTime, r)
+	return ErrReadOnly
+}
+
+func (r *ReadOnlyStorageService) putKeyValue(ctx context.Context, key common.Hash, value []byte) error {
+	return ErrReadOnly
+}
+
+func (r *ReadOnlyStorageService) Sync(ctx context.Context) error {
+	return ErrReadOnly
+}
+
+func (r *ReadOnlyStorageService) String() string {
+	return fmt.Sprintf("ReadOnlyStorageService(%v)", r.StorageService)
+}
@@ -0,0 +1,40 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+func TestReadOnlyStorageService(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	inner := NewMemoryBackedStorageService(ctx)
+	readOnly := NewReadOnlyStorageService(inner)
+
+	val1 := []byte("The first value")
+	Require(t, inner.Put(ctx, val1, timeout))
+	val, err := readOnly.GetByHash(ctx, dastree.Hash(val1))
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		Fail(t, val, val1)
+	}
+
+	val2 := []byte("The second value")
+	if err := readOnly.Put(ctx, val2, timeout); !errors.Is(err, ErrReadOnly) {
+		Fail(t, "expected ErrReadOnly from Put", err)
+	}
+	if err := readOnly.Sync(ctx); !errors.Is(err, ErrReadOnly) {
+		Fail(t, "expected ErrReadOnly from Sync", err)
+	}
+	if _, err := inner.GetByHash(ctx, dastree.Hash(val2)); !errors.Is(err, ErrNotFound) {
+		Fail(t, "Put reached the wrapped StorageService", err)
+	}
+}
@@ -80,6 +80,74 @@ func (s *simpleExploreExploitStrategy) newInstance() aggregatorStrategyInstance
 	return &basicStrategyInstance{readerSets: readerSets}
 }
 
+// Nearest First Strategy, which tries the readers in this region first, then
+// those in unknown regions, then those in other regions, and within each of
+// those tiers in order of measured latency. Readers without any stats yet are
+// tried first within their tier so their latency gets measured.
+type nearestFirstStrategy struct {
+	region string
+	// endpoint URL -> region
+	regions map[string]string
+
+	abstractAggregatorStrategy
+}
+
+type urlReader interface {
+	URL() string
+}
+
+func (s *nearestFirstStrategy) tier(reader arbstate.DataAvailabilityReader) int {
+	r, ok := reader.(urlReader)
+	if !ok {
+		return 1
+	}
+	region, ok := s.regions[r.URL()]
+	if !ok || s.region == "" {
+		return 1
+	}
+	if region == s.region {
+		return 0
+	}
+	return 2
+}
+
+func (s *nearestFirstStrategy) newInstance() aggregatorStrategyInstance {
+	s.RLock()
+	defer s.RUnlock()
+
+	readers := make([]arbstate.DataAvailabilityReader, len(s.readers))
+	copy(readers, s.readers)
+
+	sort.SliceStable(readers, func(i, j int) bool {
+		tierI, tierJ := s.tier(readers[i]), s.tier(readers[j])
+		if tierI != tierJ {
+			return tierI < tierJ
+		}
+		a, b := s.stats[readers[i]], s.stats[readers[j]]
+		if (len(a) == 0) != (len(b) == 0) {
+			return len(a) == 0
+		}
+		return a.successRatioWeightedMeanLatency() < b.successRatioWeightedMeanLatency()
+	})
+
+	// Fall back outward one reader at a time until the readers in this
+	// region are exhausted, then exponentially.
+	readerSets := make([][]arbstate.DataAvailabilityReader, 0)
+	i := 0
+	for ; i < len(readers) && s.tier(readers[i]) == 0; i++ {
+		readerSets = append(readerSets, []arbstate.DataAvailabilityReader{readers[i]})
+	}
+	for maxTake := 1; i < len(readers); maxTake = maxTake * 2 {
+		readerSet := make([]arbstate.DataAvailabilityReader, 0, maxTake)
+		for taken := 0; taken < maxTake && i < len(readers); i, taken = i+1, taken+1 {
+			readerSet = append(readerSet, readers[i])
+		}
+		readerSets = append(readerSets, readerSet)
+	}
+
+	return &basicStrategyInstance{readerSets: readerSets}
+}
+
 // Sequential Strategy for Testing
 type testingSequentialStrategy struct {
 	abstractAggregatorStrategy
@@ -114,3 +182,23 @@ func (si *basicStrategyInstance) nextReaders() []arbstate.DataAvailabilityReader
 	si.readerSets = si.readerSets[1:]
 	return next
 }
+
+// minParallelStrategyInstance merges consecutive reader sets of the wrapped
+// instance until each has at least minParallel readers, so that many readers
+// are requested concurrently even while a strategy would try one at a time.
+type minParallelStrategyInstance struct {
+	aggregatorStrategyInstance
+	minParallel int
+}
+
+func (si *minParallelStrategyInstance) nextReaders() []arbstate.DataAvailabilityReader {
+	var readers []arbstate.DataAvailabilityReader
+	for len(readers) == 0 || len(readers) < si.minParallel {
+		next := si.aggregatorStrategyInstance.nextReaders()
+		if len(next) == 0 {
+			break
+		}
+		readers = append(readers, next...)
+	}
+	return readers
+}
@@ -114,3 +114,74 @@ func TestDAS_SimpleExploreExploit(t *testing.T) {
 	}
 
 }
+
+type urlDummyReader struct {
+	dummyReader
+	url string
+}
+
+func (r *urlDummyReader) URL() string {
+	return r.url
+}
+
+func TestDAS_NearestFirst(t *testing.T) {
+	readers := []arbstate.DataAvailabilityReader{
+		&urlDummyReader{dummyReader{0}, "http://far-slow"},
+		&urlDummyReader{dummyReader{1}, "http://near-slow"},
+		&urlDummyReader{dummyReader{2}, "http://untagged"},
+		&urlDummyReader{dummyReader{3}, "http://far-fast"},
+		&urlDummyReader{dummyReader{4}, "http://near-fast"},
+		&urlDummyReader{dummyReader{5}, "http://near-unmeasured"},
+	}
+	stats := make(map[arbstate.DataAvailabilityReader]readerStats)
+	stats[readers[0]] = []readerStat{{5 * time.Second, true}}
+	stats[readers[1]] = []readerStat{{3 * time.Second, true}}
+	stats[readers[2]] = []readerStat{{1 * time.Second, true}}
+	stats[readers[3]] = []readerStat{{1 * time.Second, true}}
+	stats[readers[4]] = []readerStat{{1 * time.Second, true}, {1 * time.Second, false}}
+
+	regions, err := parseEndpointRegions([]string{
+		"us-east=http://near-slow",
+		"us-east=http://near-fast",
+		"us-east=http://near-unmeasured",
+		"eu-west=http://far-slow",
+		"eu-west=http://far-fast",
+	})
+	Require(t, err)
+	strategy := nearestFirstStrategy{region: "us-east", regions: regions}
+	strategy.update(readers, stats)
+
+	expectedSets := [][]int{{5}, {4}, {1}, {2}, {3, 0}}
+	si := strategy.newInstance()
+	for _, expected := range expectedSets {
+		was := si.nextReaders()
+		if len(was) != len(expected) {
+			Fail(t, fmt.Sprintf("Incorrect number of nextReaders %d, expected %d", len(was), len(expected)))
+		}
+		for i := range was {
+			if was[i].(*urlDummyReader).int != expected[i] {
+				Fail(t, fmt.Sprintf("expected %d, was %d", expected[i], was[i].(*urlDummyReader).int))
+			}
+		}
+	}
+	if next := si.nextReaders(); len(next) != 0 {
+		Fail(t, "expected no more readers", next)
+	}
+
+	if _, err := parseEndpointRegions([]string{"http://no-region"}); err == nil {
+		Fail(t, "endpoint region without a region should be rejected")
+	}
+}
+
+func TestDAS_MinParallel(t *testing.T) {
+	readers := []arbstate.DataAvailabilityReader{&dummyReader{0}, &dummyReader{1}, &dummyReader{2}, &dummyReader{3}, &dummyReader{4}}
+	strategy := testingSequentialStrategy{}
+	strategy.update(readers, make(map[arbstate.DataAvailabilityReader]readerStats))
+
+	si := &minParallelStrategyInstance{aggregatorStrategyInstance: strategy.newInstance(), minParallel: 2}
+	for _, expected := range []int{2, 2, 1, 0} {
+		if was := len(si.nextReaders()); was != expected {
+			Fail(t, fmt.Sprintf("Incorrect number of nextReaders %d, expected %d", was, expected))
+		}
+	}
+}
@@ -8,15 +8,16 @@ import (
 	"crypto/hmac"
 	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	"golang.org/x/crypto/sha3"
+	"golang.org/x/sync/singleflight"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/offchainlabs/nitro/arbstate"
 	"github.com/offchainlabs/nitro/das/dastree"
 	"github.com/offchainlabs/nitro/util/pretty"
-	"github.com/offchainlabs/nitro/util/redisutil"
 	flag "github.com/spf13/pflag"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -27,7 +28,14 @@ type RedisConfig struct {
 	Enable                 bool          `koanf:"enable"`
 	Url                    string        `koanf:"url"`
 	Expiration             time.Duration `koanf:"expiration"`
+	ExpirationFromTimeout  bool          `koanf:"expiration-from-timeout"`
 	KeyConfig              string        `koanf:"key-config"`
+	KeyPrefix              string        `koanf:"key-prefix"`
+	Username               string        `koanf:"username"`
+	Password               string        `koanf:"password"`
+	PoolSize               int           `koanf:"pool-size"`
+	MinIdleConns           int           `koanf:"min-idle-conns"`
+	PoolTimeout            time.Duration `koanf:"pool-timeout"`
 	SyncFromStorageService bool          `koanf:"sync-from-storage-service"`
 	SyncToStorageService   bool          `koanf:"sync-to-storage-service"`
 }
@@ -42,7 +50,14 @@ func RedisConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Bool(prefix+".enable", DefaultRedisConfig.Enable, "enable Redis caching of sequencer batch data")
 	f.String(prefix+".url", DefaultRedisConfig.Url, "Redis url")
 	f.Duration(prefix+".expiration", DefaultRedisConfig.Expiration, "Redis expiration")
+	f.Bool(prefix+".expiration-from-timeout", DefaultRedisConfig.ExpirationFromTimeout, "expire stored batches from Redis at their DAS timeout instead of after the fixed expiration; the fixed expiration is still used for entries filled from the base storage")
 	f.String(prefix+".key-config", DefaultRedisConfig.KeyConfig, "Redis key config")
+	f.String(prefix+".key-prefix", DefaultRedisConfig.KeyPrefix, "prefix for Redis keys, so deployments sharing a Redis don't see each other's data")
+	f.String(prefix+".username", DefaultRedisConfig.Username, "Redis ACL username, overrides any username in the url")
+	f.String(prefix+".password", DefaultRedisConfig.Password, "Redis password, overrides any password in the url")
+	f.Int(prefix+".pool-size", DefaultRedisConfig.PoolSize, "maximum number of Redis connections in the pool (0 uses the client default of 10 per CPU)")
+	f.Int(prefix+".min-idle-conns", DefaultRedisConfig.MinIdleConns, "minimum number of idle Redis connections to keep open")
+	f.Duration(prefix+".pool-timeout", DefaultRedisConfig.PoolTimeout, "time to wait for a Redis connection when all pooled connections are busy (0 uses the client default)")
 	f.Bool(prefix+".sync-from-storage-service", DefaultRedisConfig.SyncFromStorageService, "enable Redis to be used as a source for regular sync storage")
 	f.Bool(prefix+".sync-to-storage-service", DefaultRedisConfig.SyncToStorageService, "enable Redis to be used as a sink for regular sync storage")
 }
@@ -52,10 +67,14 @@ type RedisStorageService struct {
 	redisConfig        RedisConfig
 	signingKey         common.Hash
 	client             redis.UniversalClient
+	metrics            *cacheMetrics
+	// fills coalesces concurrent misses for the same key, so only one of them
+	// reads from the base storage.
+	fills singleflight.Group
 }
 
 func NewRedisStorageService(redisConfig RedisConfig, baseStorageService StorageService) (StorageService, error) {
-	redisClient, err := redisutil.RedisClientFromURL(redisConfig.Url)
+	redisClient, err := redisClientFromConfig(redisConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -68,9 +87,51 @@ func NewRedisStorageService(redisConfig RedisConfig, baseStorageService StorageS
 		redisConfig:        redisConfig,
 		signingKey:         signingKey,
 		client:             redisClient,
+		metrics:            newCacheMetrics("redis"),
 	}, nil
 }
 
+func redisClientFromConfig(redisConfig RedisConfig) (redis.UniversalClient, error) {
+	if redisConfig.Url == "" {
+		return nil, errors.New("redis-cache.url must be set")
+	}
+	redisOptions, err := redis.ParseURL(redisConfig.Url)
+	if err != nil {
+		return nil, err
+	}
+	if redisConfig.Username != "" {
+		redisOptions.Username = redisConfig.Username
+	}
+	if redisConfig.Password != "" {
+		redisOptions.Password = redisConfig.Password
+	}
+	if redisConfig.PoolSize > 0 {
+		redisOptions.PoolSize = redisConfig.PoolSize
+	}
+	if redisConfig.MinIdleConns > 0 {
+		redisOptions.MinIdleConns = redisConfig.MinIdleConns
+	}
+	if redisConfig.PoolTimeout > 0 {
+		redisOptions.PoolTimeout = redisConfig.PoolTimeout
+	}
+	return redis.NewClient(redisOptions), nil
+}
+
+// expirationForTimeout returns how long a batch stored with the given DAS
+// timeout should be kept in Redis, or false if it has already expired and
+// shouldn't be cached at all.
+func (rs *RedisStorageService) expirationForTimeout(timeout uint64) (time.Duration, bool) {
+	if !rs.redisConfig.ExpirationFromTimeout {
+		return rs.redisConfig.Expiration, true
+	}
+	if timeout > math.MaxInt64 {
+		// Zero expiration keeps the key in Redis until it is evicted.
+		return 0, true
+	}
+	expiration := time.Until(time.Unix(int64(timeout), 0))
+	return expiration, expiration > 0
+}
+
 func (rs *RedisStorageService) verifyMessageSignature(data []byte) ([]byte, error) {
 	if len(data) < 32 {
 		return nil, errors.New("data is too short to contain message signature")
@@ -86,10 +147,16 @@ func (rs *RedisStorageService) verifyMessageSignature(data []byte) ([]byte, erro
 	return message, nil
 }
 
+func (rs *RedisStorageService) redisKey(key common.Hash) string {
+	return rs.redisConfig.KeyPrefix + string(key.Bytes())
+}
+
 func (rs *RedisStorageService) getVerifiedData(ctx context.Context, key common.Hash) ([]byte, error) {
-	data, err := rs.client.Get(ctx, string(key.Bytes())).Bytes()
+	data, err := rs.client.Get(ctx, rs.redisKey(key)).Bytes()
 	if err != nil {
-		log.Error("das.RedisStorageService.getVerifiedData", "err", err)
+		if !errors.Is(err, redis.Nil) {
+			log.Error("das.RedisStorageService.getVerifiedData", "err", err)
+		}
 		return nil, err
 	}
 	data, err = rs.verifyMessageSignature(data)
@@ -108,20 +175,28 @@ func (rs *RedisStorageService) signMessage(message []byte) []byte {
 func (rs *RedisStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
 	log.Trace("das.RedisStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", rs)
 	ret, err := rs.getVerifiedData(ctx, key)
-	if err != nil {
-		ret, err = rs.baseStorageService.GetByHash(ctx, key)
+	if err == nil {
+		rs.metrics.hit()
+		return ret, nil
+	}
+	rs.metrics.miss()
+	filled, err, _ := rs.fills.Do(rs.redisKey(key), func() (interface{}, error) {
+		data, err := rs.baseStorageService.GetByHash(ctx, key)
 		if err != nil {
 			return nil, err
 		}
-
-		err = rs.client.Set(ctx, string(key.Bytes()), rs.signMessage(ret), rs.redisConfig.Expiration).Err()
+		// The data was read successfully, so failing to cache it only costs
+		// another read from the base storage later.
+		err = rs.client.Set(ctx, rs.redisKey(key), rs.signMessage(data), rs.redisConfig.Expiration).Err()
 		if err != nil {
-			return nil, err
+			log.Warn("das.RedisStorageService.GetByHash failed to cache data", "key", pretty.PrettyHash(key), "err", err)
 		}
-		return ret, err
+		return data, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	return ret, err
+	return filled.([]byte), nil
 }
 
 func (rs *RedisStorageService) Put(ctx context.Context, value []byte, timeout uint64) error {
@@ -130,8 +205,12 @@ func (rs *RedisStorageService) Put(ctx context.Context, value []byte, timeout ui
 	if err != nil {
 		return err
 	}
+	expiration, ok := rs.expirationForTimeout(timeout)
+	if !ok {
+		return nil
+	}
 	err = rs.client.Set(
-		ctx, string(dastree.Hash(value).Bytes()), rs.signMessage(value), rs.redisConfig.Expiration,
+		ctx, rs.redisKey(dastree.Hash(value)), rs.signMessage(value), expiration,
 	).Err()
 	if err != nil {
 		log.Error("das.RedisStorageService.Store", "err", err)
@@ -142,7 +221,7 @@ func (rs *RedisStorageService) Put(ctx context.Context, value []byte, timeout ui
 func (rs *RedisStorageService) putKeyValue(ctx context.Context, key common.Hash, value []byte) error {
 	// Expiration is set to zero here, since we want to keep the index inserted for iterable storage forever.
 	err := rs.client.Set(
-		ctx, string(key.Bytes()), rs.signMessage(value), 0,
+		ctx, rs.redisKey(key), rs.signMessage(value), 0,
 	).Err()
 	if err != nil {
 		log.Error("das.RedisStorageService.putKeyValue", "err", err)
@@ -98,3 +98,91 @@ func TestRedisStorageService(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestRedisStorageServiceExpirationFromTimeout(t *testing.T) {
+	ctx := context.Background()
+	server, err := miniredis.Run()
+	Require(t, err)
+	defer server.Close()
+	server.RequireUserAuth("das", "secret")
+
+	redisService, err := NewRedisStorageService(
+		RedisConfig{
+			Enable:                true,
+			Url:                   "redis://" + server.Addr(),
+			Expiration:            time.Hour,
+			ExpirationFromTimeout: true,
+			KeyConfig:             "b561f5d5d98debc783aa8a1472d67ec3bcd532a1c8d95e5cb23caa70c649f7c9",
+			Username:              "das",
+			Password:              "secret",
+			PoolSize:              2,
+			MinIdleConns:          1,
+		}, NewMemoryBackedStorageService(ctx))
+	Require(t, err)
+
+	val1 := []byte("The first value")
+	err = redisService.Put(ctx, val1, uint64(time.Now().Add(24*time.Hour).Unix()))
+	Require(t, err)
+	ttl := server.TTL(string(dastree.Hash(val1).Bytes()))
+	if ttl <= time.Hour || ttl > 24*time.Hour {
+		Fail(t, "unexpected TTL for batch stored with timeout", ttl)
+	}
+
+	// Already expired batches are still written to the base storage but not cached.
+	val2 := []byte("The second value")
+	err = redisService.Put(ctx, val2, uint64(time.Now().Add(-time.Hour).Unix()))
+	Require(t, err)
+	if server.Exists(string(dastree.Hash(val2).Bytes())) {
+		Fail(t, "expired batch was cached in Redis")
+	}
+}
+
+func TestRedisStorageServiceSharedCache(t *testing.T) {
+	ctx := context.Background()
+	server, err := miniredis.Run()
+	Require(t, err)
+	defer server.Close()
+
+	newReplica := func(keyPrefix string, base StorageService) StorageService {
+		redisService, err := NewRedisStorageService(
+			RedisConfig{
+				Enable:     true,
+				Url:        "redis://" + server.Addr(),
+				Expiration: time.Hour,
+				KeyConfig:  "b561f5d5d98debc783aa8a1472d67ec3bcd532a1c8d95e5cb23caa70c649f7c9",
+				KeyPrefix:  keyPrefix,
+			}, base)
+		Require(t, err)
+		return redisService
+	}
+
+	// The first replica reads through to its base storage and fills the cache.
+	val1 := []byte("The first value")
+	key1 := dastree.Hash(val1)
+	base := NewMemoryBackedStorageService(ctx)
+	Require(t, base.Put(ctx, val1, uint64(time.Now().Add(time.Hour).Unix())))
+	replica1 := newReplica("chain-a:", base)
+	val, err := replica1.GetByHash(ctx, key1)
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		t.Fatal(val, val1)
+	}
+	if !server.Exists("chain-a:" + string(key1.Bytes())) {
+		Fail(t, "read-through data wasn't cached under the key prefix")
+	}
+
+	// A second replica with an empty base storage is served from the shared cache.
+	replica2 := newReplica("chain-a:", NewMemoryBackedStorageService(ctx))
+	val, err = replica2.GetByHash(ctx, key1)
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		t.Fatal(val, val1)
+	}
+
+	// A replica with a different prefix doesn't see it.
+	otherChain := newReplica("chain-b:", NewMemoryBackedStorageService(ctx))
+	_, err = otherChain.GetByHash(ctx, key1)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+}
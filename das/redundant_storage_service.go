@@ -6,25 +6,53 @@ package das
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/offchainlabs/nitro/arbstate"
 	"github.com/offchainlabs/nitro/util/pretty"
+	flag "github.com/spf13/pflag"
 )
 
 // This is a redundant storage service, which replicates data across a set of StorageServices.
 // The implementation assumes that there won't be a large number of replicas.
 
+type RedundantStorageConfig struct {
+	WriteQuorum int `koanf:"write-quorum"`
+}
+
+var DefaultRedundantStorageConfig = RedundantStorageConfig{
+	WriteQuorum: 0,
+}
+
+func RedundantStorageConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Int(prefix+".write-quorum", DefaultRedundantStorageConfig.WriteQuorum, "when more than one storage backend is enabled, number of them that must store data for a Store to succeed (0 means all of them)")
+}
+
 type RedundantStorageService struct {
 	innerServices []StorageService
+	writeQuorum   int
 }
 
 func NewRedundantStorageService(ctx context.Context, services []StorageService) (StorageService, error) {
+	return NewRedundantStorageServiceWithWriteQuorum(ctx, services, len(services))
+}
+
+// NewRedundantStorageServiceWithWriteQuorum creates a RedundantStorageService
+// whose Puts succeed once writeQuorum of the services have stored the data.
+// Writes to the remaining services continue in the background.
+func NewRedundantStorageServiceWithWriteQuorum(ctx context.Context, services []StorageService, writeQuorum int) (StorageService, error) {
+	if writeQuorum == 0 {
+		writeQuorum = len(services)
+	}
+	if writeQuorum < 0 || writeQuorum > len(services) {
+		return nil, fmt.Errorf("write quorum %d must be between 1 and the number of storage services (%d)", writeQuorum, len(services))
+	}
 	innerServices := make([]StorageService, len(services))
 	copy(innerServices, services)
-	return &RedundantStorageService{innerServices}, nil
+	return &RedundantStorageService{innerServices, writeQuorum}, nil
 }
 
 type readResponse struct {
@@ -62,22 +90,34 @@ func (r *RedundantStorageService) GetByHash(ctx context.Context, key common.Hash
 
 func (r *RedundantStorageService) Put(ctx context.Context, data []byte, expirationTime uint64) error {
 	logPut("das.RedundantStorageService.Store", data, expirationTime, r)
-	var wg sync.WaitGroup
-	var errorMutex sync.Mutex
-	var anyError error
-	wg.Add(len(r.innerServices))
+	resultChan := make(chan error, len(r.innerServices))
 	for _, serv := range r.innerServices {
 		go func(s StorageService) {
 			err := s.Put(ctx, data, expirationTime)
 			if err != nil {
-				errorMutex.Lock()
-				anyError = err
-				errorMutex.Unlock()
+				log.Warn("das.RedundantStorageService.Store failed", "service", s, "err", err)
 			}
-			wg.Done()
+			resultChan <- err
 		}(serv)
 	}
-	wg.Wait()
+	successes, failures := 0, 0
+	maxFailures := len(r.innerServices) - r.writeQuorum
+	var anyError error
+	for range r.innerServices {
+		err := <-resultChan
+		if err == nil {
+			successes++
+			if successes >= r.writeQuorum {
+				return nil
+			}
+			continue
+		}
+		anyError = err
+		failures++
+		if failures > maxFailures {
+			return fmt.Errorf("only %d of the required %d storage services stored the data: %w", len(r.innerServices)-failures, r.writeQuorum, anyError)
+		}
+	}
 	return anyError
 }
 
@@ -156,7 +196,7 @@ func (r *RedundantStorageService) ExpirationPolicy(ctx context.Context) (arbstat
 }
 
 func (r *RedundantStorageService) String() string {
-	str := "RedundantStorageService("
+	str := fmt.Sprintf("RedundantStorageService(quorum=%d,", r.writeQuorum)
 	for _, serv := range r.innerServices {
 		str = str + serv.String() + ","
 	}
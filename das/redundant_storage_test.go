@@ -63,3 +63,42 @@ func TestRedundantStorageService(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+type failingPutStorageService struct {
+	StorageService
+}
+
+func (f failingPutStorageService) Put(ctx context.Context, data []byte, expirationTime uint64) error {
+	return errors.New("put failed")
+}
+
+func TestRedundantStorageServiceWriteQuorum(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	services := []StorageService{
+		NewMemoryBackedStorageService(ctx),
+		NewMemoryBackedStorageService(ctx),
+		failingPutStorageService{NewMemoryBackedStorageService(ctx)},
+	}
+
+	_, err := NewRedundantStorageServiceWithWriteQuorum(ctx, services, NumServices+1)
+	if err == nil {
+		t.Fatal("expected error for write quorum larger than the number of services")
+	}
+
+	allService, err := NewRedundantStorageService(ctx, services)
+	Require(t, err)
+	if err := allService.Put(ctx, []byte("value"), timeout); err == nil {
+		t.Fatal("expected Put to fail when a service fails and all must succeed")
+	}
+
+	quorumService, err := NewRedundantStorageServiceWithWriteQuorum(ctx, services, 2)
+	Require(t, err)
+	val1 := []byte("The first value")
+	Require(t, quorumService.Put(ctx, val1, timeout))
+	val, err := quorumService.GetByHash(ctx, dastree.Hash(val1))
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		t.Fatal(val, val1)
+	}
+}
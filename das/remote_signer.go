@@ -0,0 +1,247 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+
+	flag "github.com/spf13/pflag"
+)
+
+var (
+	remoteSignerRequestsCounter = metrics.NewRegisteredCounter("arb/das/remotesigner/requests", nil)
+	remoteSignerFailuresCounter = metrics.NewRegisteredCounter("arb/das/remotesigner/failures", nil)
+	remoteSignerDuration        = metrics.NewRegisteredHistogram("arb/das/remotesigner/duration", nil, metrics.NewBoundedHistogramSample())
+)
+
+// maxRemoteSignMessageSize bounds what the signer service signs; certificates'
+// signable fields and announcement hashes are far smaller.
+const maxRemoteSignMessageSize = 1024
+
+type RemoteSignerConfig struct {
+	URL             string        `koanf:"url"`
+	BearerTokenFile string        `koanf:"bearer-token-file"`
+	ClientCert      string        `koanf:"client-cert"`
+	ClientKey       string        `koanf:"client-key"`
+	RootCA          string        `koanf:"root-ca"`
+	PublicKey       string        `koanf:"public-key"`
+	Timeout         time.Duration `koanf:"timeout"`
+}
+
+var DefaultRemoteSignerConfig = RemoteSignerConfig{
+	Timeout: 5 * time.Second,
+}
+
+func RemoteSignerConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".url", DefaultRemoteSignerConfig.URL, "URL of a remote signer holding the BLS key, eg one run with 'daserver remote-signer', to sign with instead of key-dir or priv-key")
+	f.String(prefix+".bearer-token-file", DefaultRemoteSignerConfig.BearerTokenFile, "file with the bearer token to authenticate to the remote signer with")
+	f.String(prefix+".client-cert", DefaultRemoteSignerConfig.ClientCert, "PEM encoded TLS client certificate to authenticate to the remote signer with")
+	f.String(prefix+".client-key", DefaultRemoteSignerConfig.ClientKey, "PEM encoded private key of client-cert")
+	f.String(prefix+".root-ca", DefaultRemoteSignerConfig.RootCA, "PEM encoded CA certificates to verify the remote signer's TLS certificate with, instead of the system's")
+	f.String(prefix+".public-key", DefaultRemoteSignerConfig.PublicKey, "base64 BLS public key the remote signer must sign with, or a file containing it; if unset, whichever key it reports is trusted")
+	f.Duration(prefix+".timeout", DefaultRemoteSignerConfig.Timeout, "timeout of each request to the remote signer")
+}
+
+// RemoteBLSSigner signs by calling a BLSSignerService, so the BLS key can
+// live on an isolated host. Its signatures are verified before use, so a
+// misbehaving signer can't make the member hand out invalid certificates.
+type RemoteBLSSigner struct {
+	client  *rpc.Client
+	url     string
+	pubKey  blsSignatures.PublicKey
+	timeout time.Duration
+}
+
+func NewRemoteBLSSigner(ctx context.Context, config RemoteSignerConfig) (*RemoteBLSSigner, error) {
+	s, err := dialRemoteBLSSigner(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	callCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	var pubKeyBytes hexutil.Bytes
+	if err := s.client.CallContext(callCtx, &pubKeyBytes, "blssigner_publicKey"); err != nil {
+		return nil, fmt.Errorf("error getting public key from remote signer %s: %w", config.URL, err)
+	}
+	if config.PublicKey != "" {
+		if !bytes.Equal(blsSignatures.PublicKeyToBytes(s.pubKey), pubKeyBytes) {
+			return nil, fmt.Errorf("remote signer %s has public key %s, not the expected one", config.URL, base64.StdEncoding.EncodeToString(pubKeyBytes))
+		}
+		return s, nil
+	}
+	s.pubKey, err = blsSignatures.PublicKeyFromBytes(pubKeyBytes, false)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer %s reported an invalid public key: %w", config.URL, err)
+	}
+	return s, nil
+}
+
+// dialRemoteBLSSigner returns a signer with the configured public key, if
+// any, without contacting the remote signer.
+func dialRemoteBLSSigner(ctx context.Context, config RemoteSignerConfig) (*RemoteBLSSigner, error) {
+	backend := BackendConfig{
+		URL:        config.URL,
+		ClientCert: config.ClientCert,
+		ClientKey:  config.ClientKey,
+		RootCA:     config.RootCA,
+	}
+	if config.BearerTokenFile != "" {
+		token, err := os.ReadFile(config.BearerTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading remote signer bearer token: %w", err)
+		}
+		backend.BearerToken = strings.TrimSpace(string(token))
+	}
+	options, err := backend.dialOptions()
+	if err != nil {
+		return nil, err
+	}
+	client, err := rpc.DialOptions(ctx, config.URL, options...)
+	if err != nil {
+		return nil, err
+	}
+	s := &RemoteBLSSigner{client: client, url: config.URL, timeout: config.Timeout}
+	if config.PublicKey != "" {
+		expected, err := DecodeBase64BLSPublicKey([]byte(config.PublicKey))
+		if err != nil {
+			contents, readErr := os.ReadFile(config.PublicKey)
+			if readErr != nil {
+				return nil, fmt.Errorf("remote-signer.public-key is neither a base64 public key nor a readable file: %w", err)
+			}
+			if expected, err = DecodeBase64BLSPublicKey(bytes.TrimSpace(contents)); err != nil {
+				return nil, err
+			}
+		}
+		s.pubKey = *expected
+	}
+	return s, nil
+}
+
+func (s *RemoteBLSSigner) PublicKey() blsSignatures.PublicKey {
+	return s.pubKey
+}
+
+func (s *RemoteBLSSigner) Sign(ctx context.Context, message []byte) (blsSignatures.Signature, error) {
+	remoteSignerRequestsCounter.Inc(1)
+	start := time.Now()
+	defer func() {
+		remoteSignerDuration.Update(time.Since(start).Nanoseconds())
+	}()
+	sig, err := s.sign(ctx, message)
+	if err != nil {
+		remoteSignerFailuresCounter.Inc(1)
+		return nil, err
+	}
+	return sig, nil
+}
+
+func (s *RemoteBLSSigner) sign(ctx context.Context, message []byte) (blsSignatures.Signature, error) {
+	callCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	var sigBytes hexutil.Bytes
+	if err := s.client.CallContext(callCtx, &sigBytes, "blssigner_sign", hexutil.Bytes(message)); err != nil {
+		return nil, fmt.Errorf("remote signer %s: %w", s.url, err)
+	}
+	sig, err := blsSignatures.SignatureFromBytes(sigBytes)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer %s returned an invalid signature: %w", s.url, err)
+	}
+	verified, err := blsSignatures.VerifySignature(sig, message, s.pubKey)
+	if err != nil {
+		return nil, err
+	}
+	if !verified {
+		return nil, fmt.Errorf("remote signer %s returned a signature that doesn't verify against its public key", s.url)
+	}
+	return sig, nil
+}
+
+func (s *RemoteBLSSigner) String() string {
+	return fmt.Sprintf("RemoteBLSSigner{%s}", s.url)
+}
+
+// BLSSignerService serves a BLSSigner over JSON-RPC in the "blssigner"
+// namespace, for RemoteBLSSigner to call.
+type BLSSignerService struct {
+	signer BLSSigner
+}
+
+func (s *BLSSignerService) PublicKey(ctx context.Context) hexutil.Bytes {
+	return blsSignatures.PublicKeyToBytes(s.signer.PublicKey())
+}
+
+func (s *BLSSignerService) Sign(ctx context.Context, message hexutil.Bytes) (hexutil.Bytes, error) {
+	if len(message) > maxRemoteSignMessageSize {
+		return nil, fmt.Errorf("message of %d bytes is larger than the maximum of %d", len(message), maxRemoteSignMessageSize)
+	}
+	sig, err := s.signer.Sign(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("Signed for remote signer client", "message", hexutil.Encode(message))
+	return blsSignatures.SignatureToBytes(sig), nil
+}
+
+// StartBLSSignerServerOnListener serves signer to clients sending one of the
+// bearer tokens in tokens, which is required since anyone able to call the
+// service can sign certificates as the member.
+func StartBLSSignerServerOnListener(ctx context.Context, listener net.Listener, timeouts genericconf.HTTPServerTimeoutConfig, signer BLSSigner, tokens TokenAuthConfig) (*http.Server, error) {
+	if tokens.TokensFile == "" {
+		return nil, errors.New("the remote signer requires a tokens file")
+	}
+	bearerTokens, err := newBearerTokens(tokens)
+	if err != nil {
+		return nil, err
+	}
+	bearerTokens.Start(ctx)
+
+	rpcServer := rpc.NewServer()
+	if err := rpcServer.RegisterName("blssigner", &BLSSignerService{signer: signer}); err != nil {
+		return nil, err
+	}
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = withBearerToken(r)
+			if !bearerTokens.valid(r.Context()) {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, ErrInvalidBearerToken.Error(), http.StatusUnauthorized)
+				return
+			}
+			rpcServer.ServeHTTP(w, r)
+		}),
+		ReadTimeout:       timeouts.ReadTimeout,
+		ReadHeaderTimeout: timeouts.ReadHeaderTimeout,
+		WriteTimeout:      timeouts.WriteTimeout,
+		IdleTimeout:       timeouts.IdleTimeout,
+	}
+	go func() {
+		err := srv.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("das: remote signer server exited", "err", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		bearerTokens.StopOnly()
+		_ = srv.Shutdown(context.Background())
+	}()
+	return srv, nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestRemoteBLSSigner(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	keyDir := t.TempDir()
+	pubKey, _, err := GenerateAndStoreKeys(keyDir)
+	testhelpers.RequireImpl(t, err)
+	privKey, err := (&KeyConfig{KeyDir: keyDir}).BLSPrivKey()
+	testhelpers.RequireImpl(t, err)
+	localSigner, err := NewLocalBLSSigner(privKey)
+	testhelpers.RequireImpl(t, err)
+
+	tokenDir := t.TempDir()
+	tokensFile := filepath.Join(tokenDir, "tokens")
+	testhelpers.RequireImpl(t, os.WriteFile(tokensFile, []byte("token\n"), 0600))
+	tokenFile := filepath.Join(tokenDir, "token")
+	testhelpers.RequireImpl(t, os.WriteFile(tokenFile, []byte("token\n"), 0600))
+	wrongTokenFile := filepath.Join(tokenDir, "wrong-token")
+	testhelpers.RequireImpl(t, os.WriteFile(wrongTokenFile, []byte("wrong-token\n"), 0600))
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	testhelpers.RequireImpl(t, err)
+	_, err = StartBLSSignerServerOnListener(ctx, lis, genericconf.HTTPServerTimeoutConfigDefault, localSigner, TokenAuthConfig{TokensFile: tokensFile})
+	testhelpers.RequireImpl(t, err)
+
+	config := DefaultRemoteSignerConfig
+	config.URL = "http://" + lis.Addr().String()
+	config.BearerTokenFile = tokenFile
+	config.PublicKey = base64.StdEncoding.EncodeToString(blsSignatures.PublicKeyToBytes(*pubKey))
+	remoteSigner, err := NewRemoteBLSSigner(ctx, config)
+	testhelpers.RequireImpl(t, err)
+
+	// Certificates signed remotely verify against the member's key.
+	storageService := NewMemoryBackedStorageService(ctx)
+	writer, err := NewSignAfterStoreDASWriterWithSigner(remoteSigner, nil, storageService, "")
+	testhelpers.RequireImpl(t, err)
+	cert, err := writer.Store(ctx, testhelpers.RandomizeSlice(make([]byte, 100)), uint64(time.Now().Add(time.Hour).Unix()), nil)
+	testhelpers.RequireImpl(t, err)
+	verified, err := blsSignatures.VerifySignature(cert.Sig, cert.SerializeSignableFields(), *pubKey)
+	testhelpers.RequireImpl(t, err)
+	if !verified {
+		testhelpers.FailImpl(t, "remotely signed certificate doesn't verify")
+	}
+
+	wrongToken := config
+	wrongToken.BearerTokenFile = wrongTokenFile
+	if _, err := NewRemoteBLSSigner(ctx, wrongToken); err == nil {
+		testhelpers.FailImpl(t, "expected a signer with the wrong token to be rejected")
+	}
+
+	otherPubKey, _, err := GenerateAndStoreKeys(t.TempDir())
+	testhelpers.RequireImpl(t, err)
+	wrongKey := config
+	wrongKey.PublicKey = base64.StdEncoding.EncodeToString(blsSignatures.PublicKeyToBytes(*otherPubKey))
+	if _, err := NewRemoteBLSSigner(ctx, wrongKey); err == nil {
+		testhelpers.FailImpl(t, "expected a signer with an unexpected public key to be rejected")
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/arbstate"
+)
+
+var (
+	schedulerRunningGauge      = metrics.NewRegisteredGauge("arb/das/scheduler/running", nil)
+	schedulerQueuedGauge       = metrics.NewRegisteredGauge("arb/das/scheduler/queued", nil)
+	schedulerRejectedCounter   = metrics.NewRegisteredCounter("arb/das/scheduler/rejected", nil)
+	schedulerQueueWaitDuration = metrics.NewRegisteredHistogram("arb/das/scheduler/wait", nil, metrics.NewBoundedHistogramSample())
+)
+
+var ErrRequestQueueFull = errors.New("too many requests queued")
+
+type RequestPriorityConfig struct {
+	Enable                bool `koanf:"enable"`
+	MaxConcurrentRequests int  `koanf:"max-concurrent-requests"`
+	MaxQueuedRequests     int  `koanf:"max-queued-requests"`
+}
+
+var DefaultRequestPriorityConfig = RequestPriorityConfig{
+	Enable:                false,
+	MaxConcurrentRequests: 64,
+	MaxQueuedRequests:     1024,
+}
+
+func RequestPriorityConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultRequestPriorityConfig.Enable, "enable scheduling retrieval requests behind Store requests from the batch poster, so retrieval load can't delay batch posting")
+	f.Int(prefix+".max-concurrent-requests", DefaultRequestPriorityConfig.MaxConcurrentRequests, "retrieval requests are queued while this many requests, including Store requests, are being handled; Store requests are never queued")
+	f.Int(prefix+".max-queued-requests", DefaultRequestPriorityConfig.MaxQueuedRequests, "maximum number of queued retrieval requests, beyond which they are rejected")
+}
+
+// RequestScheduler admits requests in two lanes. Priority requests, Store
+// requests from the batch poster, are always admitted immediately. Other
+// requests are admitted in order of arrival while fewer than
+// MaxConcurrentRequests requests of either lane are running, so they only
+// ever use the capacity left over by priority requests.
+type RequestScheduler struct {
+	config RequestPriorityConfig
+
+	mutex   sync.Mutex
+	running int
+	queue   []chan struct{}
+}
+
+func NewRequestScheduler(config RequestPriorityConfig) (*RequestScheduler, error) {
+	if config.MaxConcurrentRequests < 1 {
+		return nil, fmt.Errorf("request-priority.max-concurrent-requests must be at least 1, got %d", config.MaxConcurrentRequests)
+	}
+	return &RequestScheduler{config: config}, nil
+}
+
+func (s *RequestScheduler) admitPriority() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.running++
+	schedulerRunningGauge.Update(int64(s.running))
+}
+
+func (s *RequestScheduler) admit(ctx context.Context) error {
+	s.mutex.Lock()
+	if s.running < s.config.MaxConcurrentRequests && len(s.queue) == 0 {
+		s.running++
+		schedulerRunningGauge.Update(int64(s.running))
+		s.mutex.Unlock()
+		return nil
+	}
+	if len(s.queue) >= s.config.MaxQueuedRequests {
+		s.mutex.Unlock()
+		schedulerRejectedCounter.Inc(1)
+		return ErrRequestQueueFull
+	}
+	admitted := make(chan struct{})
+	s.queue = append(s.queue, admitted)
+	schedulerQueuedGauge.Update(int64(len(s.queue)))
+	s.mutex.Unlock()
+
+	start := time.Now()
+	defer func() {
+		schedulerQueueWaitDuration.Update(time.Since(start).Nanoseconds())
+	}()
+	select {
+	case <-admitted:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i, queued := range s.queue {
+		if queued == admitted {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			schedulerQueuedGauge.Update(int64(len(s.queue)))
+			return ctx.Err()
+		}
+	}
+	// Admitted while being cancelled, so give the slot to the next request.
+	s.releaseLocked()
+	return ctx.Err()
+}
+
+func (s *RequestScheduler) release() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.releaseLocked()
+}
+
+func (s *RequestScheduler) releaseLocked() {
+	s.running--
+	for s.running < s.config.MaxConcurrentRequests && len(s.queue) > 0 {
+		close(s.queue[0])
+		s.queue = s.queue[1:]
+		s.running++
+	}
+	schedulerRunningGauge.Update(int64(s.running))
+	schedulerQueuedGauge.Update(int64(len(s.queue)))
+}
+
+// prioritizedWriter handles Store requests in the scheduler's priority lane.
+type prioritizedWriter struct {
+	DataAvailabilityServiceWriter
+	scheduler *RequestScheduler
+}
+
+func NewPrioritizedWriter(writer DataAvailabilityServiceWriter, scheduler *RequestScheduler) DataAvailabilityServiceWriter {
+	return &prioritizedWriter{writer, scheduler}
+}
+
+func (w *prioritizedWriter) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	w.scheduler.admitPriority()
+	defer w.scheduler.release()
+	return w.DataAvailabilityServiceWriter.Store(ctx, message, timeout, sig)
+}
+
+func (w *prioritizedWriter) String() string {
+	return fmt.Sprintf("prioritizedWriter(%v)", w.DataAvailabilityServiceWriter)
+}
+
+// deprioritizedReader queues retrievals behind the scheduler's priority lane.
+type deprioritizedReader struct {
+	DataAvailabilityServiceReader
+	scheduler *RequestScheduler
+}
+
+func NewDeprioritizedReader(reader DataAvailabilityServiceReader, scheduler *RequestScheduler) DataAvailabilityServiceReader {
+	return &deprioritizedReader{reader, scheduler}
+}
+
+func (r *deprioritizedReader) GetByHash(ctx context.Context, hash common.Hash) ([]byte, error) {
+	if err := r.scheduler.admit(ctx); err != nil {
+		return nil, err
+	}
+	defer r.scheduler.release()
+	return r.DataAvailabilityServiceReader.GetByHash(ctx, hash)
+}
+
+func (r *deprioritizedReader) String() string {
+	return fmt.Sprintf("deprioritizedReader(%v)", r.DataAvailabilityServiceReader)
+}
@@ -0,0 +1,65 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRequestScheduler(t *testing.T) {
+	ctx := context.Background()
+	scheduler, err := NewRequestScheduler(RequestPriorityConfig{
+		Enable:                true,
+		MaxConcurrentRequests: 1,
+		MaxQueuedRequests:     1,
+	})
+	Require(t, err)
+
+	// Priority requests are admitted even beyond the limit.
+	Require(t, scheduler.admit(ctx))
+	scheduler.admitPriority()
+
+	admitted := make(chan error, 1)
+	go func() {
+		admitted <- scheduler.admit(ctx)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if err := scheduler.admit(ctx); !errors.Is(err, ErrRequestQueueFull) {
+		Fail(t, "expected queue full error", err)
+	}
+
+	scheduler.release()
+	select {
+	case <-admitted:
+		Fail(t, "queued request admitted while at the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+	scheduler.release()
+	select {
+	case err := <-admitted:
+		Require(t, err)
+	case <-time.After(time.Second):
+		Fail(t, "queued request wasn't admitted after the running requests were released")
+	}
+
+	// A cancelled request leaves the queue.
+	cancelCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		admitted <- scheduler.admit(cancelCtx)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	if err := <-admitted; !errors.Is(err, context.Canceled) {
+		Fail(t, "expected cancelled request to fail", err)
+	}
+	scheduler.release()
+	Require(t, scheduler.admit(ctx))
+	scheduler.release()
+	if scheduler.running != 0 || len(scheduler.queue) != 0 {
+		Fail(t, "scheduler should be idle", scheduler.running, len(scheduler.queue))
+	}
+}
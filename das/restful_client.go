@@ -6,12 +6,20 @@ package das
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/offchainlabs/nitro/arbstate"
@@ -20,12 +28,14 @@ import (
 
 // RestfulDasClient implements DataAvailabilityReader
 type RestfulDasClient struct {
-	url string
+	url    string
+	client *http.Client
 }
 
 func NewRestfulDasClient(protocol string, host string, port int) *RestfulDasClient {
 	return &RestfulDasClient{
-		url: fmt.Sprintf("%s://%s:%d", protocol, host, port),
+		url:    fmt.Sprintf("%s://%s:%d", protocol, host, port),
+		client: http.DefaultClient,
 	}
 }
 
@@ -35,15 +45,51 @@ func NewRestfulDasClientFromURL(url string) (*RestfulDasClient, error) {
 
 	}
 	return &RestfulDasClient{
-		url: url,
+		url:    url,
+		client: http.DefaultClient,
+	}, nil
+}
+
+// defaultHTTP3Client is shared by HTTP/3 clients, like http.DefaultClient, so
+// their QUIC connections are reused.
+var defaultHTTP3Client = &http.Client{Transport: &http3.RoundTripper{}}
+
+// NewHTTP3RestfulDasClientFromURL makes a client that fetches over HTTP/3,
+// whose QUIC transport recovers from packet loss on long links better than
+// TCP. The server must serve HTTP/3 on the URL's port; tlsConfig may be nil
+// to verify it with the system's root CAs.
+func NewHTTP3RestfulDasClientFromURL(url string, tlsConfig *tls.Config) (*RestfulDasClient, error) {
+	if !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("protocol prefix 'https://' must be specified for an HTTP/3 RestfulDasClient; got '%s'", url)
+	}
+	client := defaultHTTP3Client
+	if tlsConfig != nil {
+		client = &http.Client{Transport: &http3.RoundTripper{TLSClientConfig: tlsConfig}}
+	}
+	return &RestfulDasClient{
+		url:    url,
+		client: client,
 	}, nil
 }
 
+func (c *RestfulDasClient) URL() string {
+	return c.url
+}
+
+func (c *RestfulDasClient) String() string {
+	return fmt.Sprintf("RestfulDasClient{url:%s}", c.url)
+}
+
 func (c *RestfulDasClient) GetByHash(ctx context.Context, hash common.Hash) ([]byte, error) {
-	res, err := http.Get(c.url + getByHashRequestPath + EncodeStorageServiceKey(hash))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+getByHashRequestPath+EncodeStorageServiceKey(hash), nil)
 	if err != nil {
 		return nil, err
 	}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
 	if res.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("HTTP error with status %d returned by server: %s", res.StatusCode, http.StatusText(res.StatusCode))
 	}
@@ -71,8 +117,82 @@ func (c *RestfulDasClient) GetByHash(ctx context.Context, hash common.Hash) ([]b
 	return decodedBytes, nil
 }
 
+// Has checks whether the server stores the data with the given hash without
+// fetching it, returning its size if so.
+func (c *RestfulDasClient) Has(ctx context.Context, hash common.Hash) (bool, uint64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.url+getByHashRequestPath+EncodeStorageServiceKey(hash), nil)
+	if err != nil {
+		return false, 0, err
+	}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return false, 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return false, 0, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return false, 0, fmt.Errorf("HTTP error with status %d returned by server: %s", res.StatusCode, http.StatusText(res.StatusCode))
+	}
+	size, err := strconv.ParseUint(res.Header.Get(dataSizeHeader), 10, 64)
+	if err != nil {
+		return false, 0, fmt.Errorf("invalid %s header: %w", dataSizeHeader, err)
+	}
+	return true, size, nil
+}
+
+// GetByHashes fetches the payloads for several hashes in one request, in the
+// same order, with nil for the payloads the server doesn't have. If the
+// server ends the response early, the payloads read so far are returned along
+// with the error.
+func (c *RestfulDasClient) GetByHashes(ctx context.Context, hashes []common.Hash) ([][]byte, error) {
+	body, err := json.Marshal(hashes)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+getByHashesRequestPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error with status %d returned by server: %s", res.StatusCode, http.StatusText(res.StatusCode))
+	}
+
+	var results [][]byte
+	var lengthPrefix [8]byte
+	for _, hash := range hashes {
+		if _, err := io.ReadFull(res.Body, lengthPrefix[:]); err != nil {
+			return results, fmt.Errorf("response ended after %d of %d payloads: %w", len(results), len(hashes), err)
+		}
+		length := binary.BigEndian.Uint64(lengthPrefix[:])
+		if length == getByHashesNotFound {
+			results = append(results, nil)
+			continue
+		}
+		if length > maxChunkedStoreSize {
+			return results, fmt.Errorf("payload of %d bytes is too large", length)
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(res.Body, data); err != nil {
+			return results, fmt.Errorf("response ended after %d of %d payloads: %w", len(results), len(hashes), err)
+		}
+		if !dastree.ValidHash(hash, data) {
+			return results, arbstate.ErrHashMismatch
+		}
+		results = append(results, data)
+	}
+	return results, nil
+}
+
 func (c *RestfulDasClient) HealthCheck(ctx context.Context) error {
-	res, err := http.Get(c.url + healthRequestPath)
+	res, err := c.client.Get(c.url + healthRequestPath)
 	if err != nil {
 		return err
 	}
@@ -83,7 +203,7 @@ func (c *RestfulDasClient) HealthCheck(ctx context.Context) error {
 }
 
 func (c *RestfulDasClient) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
-	res, err := http.Get(c.url + expirationPolicyRequestPath)
+	res, err := c.client.Get(c.url + expirationPolicyRequestPath)
 	if err != nil {
 		return -1, err
 	}
@@ -103,3 +223,108 @@ func (c *RestfulDasClient) ExpirationPolicy(ctx context.Context) (arbstate.Expir
 
 	return arbstate.StringToExpirationPolicy(response.ExpirationPolicy)
 }
+
+func (c *RestfulDasClient) get(ctx context.Context, requestPath string) (*RestfulDasServerResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+requestPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, requestPath)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error with status %d returned by server: %s", res.StatusCode, http.StatusText(res.StatusCode))
+	}
+
+	var response RestfulDasServerResponse
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// RecentHashes fetches the hashes the server has stored since the given time.
+func (c *RestfulDasClient) RecentHashes(ctx context.Context, since time.Time) ([]RecentHash, error) {
+	response, err := c.get(ctx, recentHashesRequestPath+"?since="+strconv.FormatInt(since.Unix(), 10))
+	if err != nil {
+		return nil, err
+	}
+	return response.RecentHashes, nil
+}
+
+// InventoryDigest fetches the digest of the server's stored keys.
+func (c *RestfulDasClient) InventoryDigest(ctx context.Context) (*InventoryDigest, error) {
+	response, err := c.get(ctx, inventoryDigestRequestPath)
+	if err != nil {
+		return nil, err
+	}
+	if response.InventoryDigest == nil {
+		return nil, errors.New("server returned no inventory digest")
+	}
+	return response.InventoryDigest, nil
+}
+
+// InventoryRange fetches the server's stored keys whose first byte is keyRange.
+func (c *RestfulDasClient) InventoryRange(ctx context.Context, keyRange uint8) ([]InventoryKey, error) {
+	response, err := c.get(ctx, inventoryRangeRequestPath+strconv.Itoa(int(keyRange)))
+	if err != nil {
+		return nil, err
+	}
+	return response.InventoryKeys, nil
+}
+
+// ProveCustody asks the server to answer a custody challenge from its local
+// storage.
+func (c *RestfulDasClient) ProveCustody(ctx context.Context, challenge CustodyChallenge) (common.Hash, error) {
+	query := url.Values{}
+	query.Set("nonce", challenge.Nonce.Hex())
+	query.Set("offset", strconv.FormatUint(challenge.Offset, 10))
+	query.Set("length", strconv.FormatUint(challenge.Length, 10))
+	response, err := c.get(ctx, custodyRequestPath+EncodeStorageServiceKey(challenge.Hash)+"?"+query.Encode())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if response.CustodyProof == nil {
+		return common.Hash{}, errors.New("server returned no custody proof")
+	}
+	return *response.CustodyProof, nil
+}
+
+// EndpointAnnouncement fetches the server's signed announcement of its URLs.
+func (c *RestfulDasClient) EndpointAnnouncement(ctx context.Context) (*EndpointAnnouncement, error) {
+	response, err := c.get(ctx, announcementRequestPath)
+	if err != nil {
+		return nil, err
+	}
+	if response.Announcement == nil {
+		return nil, errors.New("server returned no endpoint announcement")
+	}
+	return response.Announcement, nil
+}
+
+// ExpirationInfo fetches when the data with the given hash will expire and
+// which storage tier has it.
+func (c *RestfulDasClient) ExpirationInfo(ctx context.Context, hash common.Hash) (*ExpirationInfo, error) {
+	response, err := c.get(ctx, expirationInfoRequestPath+EncodeStorageServiceKey(hash))
+	if err != nil {
+		return nil, err
+	}
+	if response.ExpirationInfo == nil {
+		return nil, errors.New("server returned no expiration info")
+	}
+	return response.ExpirationInfo, nil
+}
+
+// Keysets lists the current and historical keysets the server knows of.
+func (c *RestfulDasClient) Keysets(ctx context.Context) ([]KeysetInfo, error) {
+	response, err := c.get(ctx, keysetsRequestPath)
+	if err != nil {
+		return nil, err
+	}
+	return response.Keysets, nil
+}
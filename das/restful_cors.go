@@ -0,0 +1,76 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+)
+
+type RestfulCORSConfig struct {
+	AllowedOrigins []string      `koanf:"allowed-origins"`
+	AllowedMethods []string      `koanf:"allowed-methods"`
+	MaxAge         time.Duration `koanf:"max-age"`
+}
+
+var DefaultRestfulCORSConfig = RestfulCORSConfig{
+	AllowedOrigins: []string{},
+	AllowedMethods: []string{http.MethodGet, http.MethodHead, http.MethodPost},
+	MaxAge:         time.Hour,
+}
+
+func RestfulCORSConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.StringSlice(prefix+".allowed-origins", DefaultRestfulCORSConfig.AllowedOrigins, "comma separated list of origins browsers may fetch from the REST server from, or * for any (CORS is disabled if empty)")
+	f.StringSlice(prefix+".allowed-methods", DefaultRestfulCORSConfig.AllowedMethods, "comma separated list of methods cross origin requests to the REST server may use")
+	f.Duration(prefix+".max-age", DefaultRestfulCORSConfig.MaxAge, "how long browsers may cache the response to a CORS preflight request")
+}
+
+// exposedHeaders are the response headers, beyond the CORS-safelisted ones,
+// that browser scripts may read.
+var exposedHeaders = strings.Join([]string{dataSizeHeader, "Content-Encoding"}, ", ")
+
+func (c *RestfulCORSConfig) allowedOrigin(origin string) string {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" {
+			return "*"
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// handleCORS sets the CORS headers for cross origin requests from allowed
+// origins, and answers preflight requests itself, returning true if it did.
+func (c *RestfulCORSConfig) handleCORS(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if len(c.AllowedOrigins) == 0 || origin == "" {
+		return false
+	}
+	w.Header().Add("Vary", "Origin")
+	allowedOrigin := c.allowedOrigin(origin)
+	if allowedOrigin == "" {
+		return false
+	}
+	w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
+	if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+		w.Header().Set("Access-Control-Expose-Headers", exposedHeaders)
+		return false
+	}
+
+	// Preflight request
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(c.AllowedMethods, ", "))
+	// Needed for the JSON body of get-by-hashes requests.
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+	if c.MaxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge.Seconds())))
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
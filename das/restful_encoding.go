@@ -0,0 +1,175 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	restZstdEncodedResponsesCounter = metrics.NewRegisteredCounter("arb/das/rest/encoding/zstd", nil)
+	restGzipEncodedResponsesCounter = metrics.NewRegisteredCounter("arb/das/rest/encoding/gzip", nil)
+)
+
+const (
+	zstdContentEncoding = "zstd"
+	gzipContentEncoding = "gzip"
+)
+
+var zstdEncoderPool = sync.Pool{
+	New: func() interface{} {
+		encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			panic(err)
+		}
+		return encoder
+	},
+}
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} {
+		writer, err := gzip.NewWriterLevel(nil, gzip.BestSpeed)
+		if err != nil {
+			panic(err)
+		}
+		return writer
+	},
+}
+
+// negotiateContentEncoding picks the encoding for the response from the
+// request's Accept-Encoding header, preferring zstd to gzip, or returns "" if
+// the client accepts neither.
+func negotiateContentEncoding(r *http.Request) string {
+	accepted := make(map[string]bool)
+	for _, header := range r.Header.Values("Accept-Encoding") {
+		for _, part := range strings.Split(header, ",") {
+			fields := strings.Split(part, ";")
+			coding := strings.ToLower(strings.TrimSpace(fields[0]))
+			acceptable := true
+			for _, param := range fields[1:] {
+				param = strings.TrimSpace(param)
+				if !strings.HasPrefix(param, "q=") {
+					continue
+				}
+				if q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil && q == 0 {
+					acceptable = false
+				}
+			}
+			accepted[coding] = acceptable
+		}
+	}
+	for _, coding := range []string{zstdContentEncoding, gzipContentEncoding} {
+		if acceptable, ok := accepted[coding]; ok {
+			if acceptable {
+				return coding
+			}
+			continue
+		}
+		if accepted["*"] {
+			return coding
+		}
+	}
+	return ""
+}
+
+// encodingResponseWriter compresses the body of successful responses on the
+// fly. Other responses are passed through unencoded, since they're small.
+type encodingResponseWriter struct {
+	http.ResponseWriter
+	encoding    string
+	encoder     io.WriteCloser
+	wroteHeader bool
+}
+
+// newEncodingResponseWriter returns w itself if the client doesn't accept a
+// supported encoding. The returned function must be called once the handler
+// is done, to flush the encoded body.
+func newEncodingResponseWriter(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, func()) {
+	if r.Method == http.MethodHead {
+		return w, func() {}
+	}
+	encoding := negotiateContentEncoding(r)
+	if encoding == "" {
+		return w, func() {}
+	}
+	encodingWriter := &encodingResponseWriter{ResponseWriter: w, encoding: encoding}
+	return encodingWriter, encodingWriter.Close
+}
+
+func (w *encodingResponseWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	// Set whatever the encoding, so caches don't serve one client's encoding
+	// to another.
+	w.Header().Add("Vary", "Accept-Encoding")
+	if statusCode == http.StatusOK {
+		w.Header().Set("Content-Encoding", w.encoding)
+		w.Header().Del("Content-Length")
+		switch w.encoding {
+		case zstdContentEncoding:
+			encoder, _ := zstdEncoderPool.Get().(*zstd.Encoder)
+			encoder.Reset(w.ResponseWriter)
+			w.encoder = encoder
+			restZstdEncodedResponsesCounter.Inc(1)
+		case gzipContentEncoding:
+			writer, _ := gzipWriterPool.Get().(*gzip.Writer)
+			writer.Reset(w.ResponseWriter)
+			w.encoder = writer
+			restGzipEncodedResponsesCounter.Inc(1)
+		}
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *encodingResponseWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.encoder == nil {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.encoder.Write(data)
+}
+
+// Flush sends what has been encoded so far, for streamed responses.
+func (w *encodingResponseWriter) Flush() {
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		if err := flusher.Flush(); err != nil {
+			log.Warn("Failed to flush encoded response", "encoding", w.encoding, "err", err)
+		}
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Close flushes the encoded body and returns the encoder to its pool.
+func (w *encodingResponseWriter) Close() {
+	if w.encoder == nil {
+		return
+	}
+	if err := w.encoder.Close(); err != nil {
+		log.Warn("Failed to finish encoding response", "encoding", w.encoding, "err", err)
+	}
+	switch encoder := w.encoder.(type) {
+	case *zstd.Encoder:
+		encoder.Reset(nil)
+		zstdEncoderPool.Put(encoder)
+	case *gzip.Writer:
+		gzipWriterPool.Put(encoder)
+	}
+	w.encoder = nil
+}
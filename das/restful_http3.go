@@ -0,0 +1,56 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/quic-go/quic-go/http3"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// StartHTTP3 also serves the REST API over HTTP/3 on the UDP port at addr,
+// usually the same as the TCP port, so clients can fetch large payloads
+// over QUIC. Responses over TCP then advertise it with an Alt-Svc header.
+func (rds *RestfulDasServer) StartHTTP3(addr string, tlsConfig *tls.Config) error {
+	if tlsConfig == nil {
+		return errors.New("HTTP/3 requires TLS")
+	}
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	server := &http3.Server{
+		Handler:   rds.server.Handler,
+		TLSConfig: tlsConfig,
+	}
+	rds.http3Server.Store(server)
+	go func() {
+		if err := server.Serve(conn); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Warn("REST HTTP/3 server stopped", "addr", addr, "err", err)
+		}
+	}()
+	return nil
+}
+
+func (rds *RestfulDasServer) advertiseHTTP3(w http.ResponseWriter, r *http.Request) {
+	server := rds.http3Server.Load()
+	if server == nil || r.ProtoMajor >= 3 {
+		return
+	}
+	if err := server.SetQuicHeaders(w.Header()); err != nil {
+		log.Debug("Couldn't set Alt-Svc header for HTTP/3", "err", err)
+	}
+}
+
+func (rds *RestfulDasServer) closeHTTP3() error {
+	if server := rds.http3Server.Load(); server != nil {
+		return server.Close()
+	}
+	return nil
+}
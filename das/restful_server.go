@@ -5,15 +5,22 @@ package das
 
 import (
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net"
 	"net/http"
 	"path"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/quic-go/quic-go/http3"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
@@ -34,28 +41,35 @@ type RestfulDasServer struct {
 	server               *http.Server
 	daReader             arbstate.DataAvailabilityReader
 	daHealthChecker      DataAvailabilityServiceHealthChecker
+	services             DaserverServices
+	cors                 RestfulCORSConfig
 	httpServerExitedChan chan interface{}
 	httpServerError      error
+	http3Server          atomic.Pointer[http3.Server]
 }
 
-func NewRestfulDasServer(address string, port uint64, restServerTimeouts genericconf.HTTPServerTimeoutConfig, daReader arbstate.DataAvailabilityReader, daHealthChecker DataAvailabilityServiceHealthChecker) (*RestfulDasServer, error) {
-	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", address, port))
+func NewRestfulDasServer(address string, port uint64, restServerTimeouts genericconf.HTTPServerTimeoutConfig, cors RestfulCORSConfig, ipLimiter *IPRateLimiter, daReader arbstate.DataAvailabilityReader, daHealthChecker DataAvailabilityServiceHealthChecker, services *DaserverServices) (*RestfulDasServer, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(address, strconv.FormatUint(port, 10)))
 	if err != nil {
 		return nil, err
 	}
-	return NewRestfulDasServerOnListener(listener, restServerTimeouts, daReader, daHealthChecker)
+	return NewRestfulDasServerOnListener(listener, restServerTimeouts, cors, ipLimiter, daReader, daHealthChecker, services)
 }
 
-func NewRestfulDasServerOnListener(listener net.Listener, restServerTimeouts genericconf.HTTPServerTimeoutConfig, daReader arbstate.DataAvailabilityReader, daHealthChecker DataAvailabilityServiceHealthChecker) (*RestfulDasServer, error) {
+func NewRestfulDasServerOnListener(listener net.Listener, restServerTimeouts genericconf.HTTPServerTimeoutConfig, cors RestfulCORSConfig, ipLimiter *IPRateLimiter, daReader arbstate.DataAvailabilityReader, daHealthChecker DataAvailabilityServiceHealthChecker, services *DaserverServices) (*RestfulDasServer, error) {
 
 	ret := &RestfulDasServer{
 		daReader:             daReader,
 		daHealthChecker:      daHealthChecker,
+		cors:                 cors,
 		httpServerExitedChan: make(chan interface{}),
 	}
+	if services != nil {
+		ret.services = *services
+	}
 
 	ret.server = &http.Server{
-		Handler:           ret,
+		Handler:           probeHandler(daHealthChecker, ipLimiter.Handler(ret)),
 		ReadTimeout:       restServerTimeouts.ReadTimeout,
 		ReadHeaderTimeout: restServerTimeouts.ReadHeaderTimeout,
 		WriteTimeout:      restServerTimeouts.WriteTimeout,
@@ -74,8 +88,15 @@ func NewRestfulDasServerOnListener(listener net.Listener, restServerTimeouts gen
 }
 
 type RestfulDasServerResponse struct {
-	Data             string `json:"data,omitempty"`
-	ExpirationPolicy string `json:"expirationPolicy,omitempty"`
+	Data             string                `json:"data,omitempty"`
+	ExpirationPolicy string                `json:"expirationPolicy,omitempty"`
+	RecentHashes     []RecentHash          `json:"recentHashes,omitempty"`
+	InventoryDigest  *InventoryDigest      `json:"inventoryDigest,omitempty"`
+	InventoryKeys    []InventoryKey        `json:"inventoryKeys,omitempty"`
+	CustodyProof     *common.Hash          `json:"custodyProof,omitempty"`
+	Announcement     *EndpointAnnouncement `json:"announcement,omitempty"`
+	ExpirationInfo   *ExpirationInfo       `json:"expirationInfo,omitempty"`
+	Keysets          []KeysetInfo          `json:"keysets,omitempty"`
 }
 
 var cacheControlKey = http.CanonicalHeaderKey("cache-control")
@@ -85,18 +106,52 @@ const cacheControlValueForSuccessfulGetByHash = "public, max-age=2419200, immuta
 const healthRequestPath = "/health"
 const expirationPolicyRequestPath = "/expiration-policy/"
 const getByHashRequestPath = "/get-by-hash/"
+const getByHashesRequestPath = "/get-by-hashes"
+const recentHashesRequestPath = "/recent-hashes"
+const inventoryDigestRequestPath = "/inventory-digest"
+const inventoryRangeRequestPath = "/inventory/"
+const custodyRequestPath = "/custody/"
+const announcementRequestPath = "/announcement"
+const expirationInfoRequestPath = "/expiration/"
+const keysetsRequestPath = "/keysets"
 
 func (rds *RestfulDasServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header()[cacheControlKey] = []string{cacheControlValueDefault}
 	requestPath := path.Clean(r.URL.Path)
 	log.Debug("Got request", "requestPath", requestPath)
+	rds.advertiseHTTP3(w, r)
+	if rds.cors.handleCORS(w, r) {
+		return
+	}
 	switch {
 	case strings.HasPrefix(requestPath, healthRequestPath):
 		rds.HealthHandler(w, r, requestPath)
 	case strings.HasPrefix(requestPath, expirationPolicyRequestPath):
 		rds.ExpirationPolicyHandler(w, r, requestPath)
 	case strings.HasPrefix(requestPath, getByHashRequestPath):
+		var finishEncoding func()
+		w, finishEncoding = newEncodingResponseWriter(w, r)
+		defer finishEncoding()
 		rds.GetByHashHandler(w, r, requestPath)
+	case strings.HasPrefix(requestPath, getByHashesRequestPath):
+		var finishEncoding func()
+		w, finishEncoding = newEncodingResponseWriter(w, r)
+		defer finishEncoding()
+		rds.GetByHashesHandler(w, r, requestPath)
+	case strings.HasPrefix(requestPath, recentHashesRequestPath):
+		rds.RecentHashesHandler(w, r, requestPath)
+	case strings.HasPrefix(requestPath, inventoryDigestRequestPath):
+		rds.InventoryDigestHandler(w, r, requestPath)
+	case strings.HasPrefix(requestPath, inventoryRangeRequestPath):
+		rds.InventoryRangeHandler(w, r, requestPath)
+	case strings.HasPrefix(requestPath, custodyRequestPath):
+		rds.CustodyHandler(w, r, requestPath)
+	case strings.HasPrefix(requestPath, announcementRequestPath):
+		rds.AnnouncementHandler(w, r, requestPath)
+	case strings.HasPrefix(requestPath, expirationInfoRequestPath):
+		rds.ExpirationInfoHandler(w, r, requestPath)
+	case strings.HasPrefix(requestPath, keysetsRequestPath):
+		rds.KeysetsHandler(w, r, requestPath)
 	default:
 		log.Warn("Unknown requestPath", "requestPath", requestPath)
 		w.WriteHeader(http.StatusBadRequest)
@@ -164,6 +219,10 @@ func (rds *RestfulDasServer) GetByHashHandler(w http.ResponseWriter, r *http.Req
 	}
 
 	responseData, err := rds.daReader.GetByHash(r.Context(), common.BytesToHash(hashBytes[:32]))
+	if errors.Is(err, ErrRequestQueueFull) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
 	if err != nil {
 		log.Warn("Unable to find data", "path", requestPath, "err", err, "remoteAddr", r.RemoteAddr)
 		w.WriteHeader(http.StatusNotFound)
@@ -171,22 +230,352 @@ func (rds *RestfulDasServer) GetByHashHandler(w http.ResponseWriter, r *http.Req
 	}
 	log.Trace("RestfulDasServer.ServeHTTP returning", "message", pretty.FirstFewBytes(responseData), "message length", len(responseData))
 
+	// The hash commits to the data, so a successful response never changes.
+	w.Header()[cacheControlKey] = []string{cacheControlValueForSuccessfulGetByHash}
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set(dataSizeHeader, strconv.Itoa(len(responseData)))
+	if r.Method == http.MethodHead {
+		// Lets sync and repair tools check what's stored without the data.
+		w.WriteHeader(http.StatusOK)
+		success = true
+		return
+	}
+	if wantsProtobufResponse(r) {
+		restGetByHashReturnedBytesGauge.Inc(int64(len(responseData)))
+		if err := writeProtobuf(w, &grpcRetrieveResponse{Chunk: responseData}); err != nil {
+			log.Warn("Failed writing response", "path", requestPath, "err", err)
+			return
+		}
+		success = true
+		return
+	}
+	if wantsBinaryResponse(r) {
+		w.Header().Set("Content-Type", binaryContentType)
+		restGetByHashReturnedBytesGauge.Inc(int64(len(responseData)))
+		if _, err := w.Write(responseData); err != nil {
+			log.Warn("Failed writing response", "path", requestPath, "err", err)
+			return
+		}
+		success = true
+		return
+	}
+
 	encodedResponseData := make([]byte, base64.StdEncoding.EncodedLen(len(responseData)))
 	base64.StdEncoding.Encode(encodedResponseData, responseData)
 	var response RestfulDasServerResponse
 	response.Data = string(encodedResponseData)
 	restGetByHashReturnedBytesGauge.Inc(int64(len(response.Data)))
 
+	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(response)
 	if err != nil {
 		log.Warn("Failed encoding and writing response", "path", requestPath, "err", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	w.Header()[cacheControlKey] = []string{cacheControlValueForSuccessfulGetByHash}
 	success = true
 }
 
+const binaryContentType = "application/octet-stream"
+
+// dataSizeHeader has the size of the payload, before any encoding, in
+// get-by-hash responses, including to HEAD requests.
+const dataSizeHeader = "X-Data-Size"
+
+// MaxRESTBatchHashes is the most hashes a /get-by-hashes request may ask for.
+const MaxRESTBatchHashes = 256
+
+// getByHashesNotFound is the length prefix of payloads the server doesn't have.
+const getByHashesNotFound = math.MaxUint64
+
+// GetByHashesHandler returns the payloads for a POSTed JSON array of hashes,
+// as a stream with each payload prefixed by its length as a big-endian
+// uint64, in the order of the hashes. Payloads that aren't found have a
+// length of 2^64-1 and no data. The stream is written as each payload is
+// read, so clients can start verifying before it ends.
+func (rds *RestfulDasServer) GetByHashesHandler(w http.ResponseWriter, r *http.Request, requestPath string) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var hashes []common.Hash
+	if err := json.NewDecoder(io.LimitReader(r.Body, MaxRESTBatchHashes*100)).Decode(&hashes); err != nil {
+		log.Warn("Failed to decode hashes", "path", requestPath, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if len(hashes) > MaxRESTBatchHashes {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	w.Header().Set("Content-Type", binaryContentType)
+	flusher, _ := w.(http.Flusher)
+	var lengthPrefix [8]byte
+	for i, hash := range hashes {
+		data, err := rds.daReader.GetByHash(r.Context(), hash)
+		if errors.Is(err, ErrRequestQueueFull) || r.Context().Err() != nil {
+			if i == 0 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			// Otherwise the status was already sent, so the stream ends
+			// early and the client retries the rest.
+			return
+		}
+		if err != nil {
+			binary.BigEndian.PutUint64(lengthPrefix[:], getByHashesNotFound)
+			data = nil
+		} else {
+			binary.BigEndian.PutUint64(lengthPrefix[:], uint64(len(data)))
+			restGetByHashReturnedBytesGauge.Inc(int64(len(data)))
+		}
+		if _, err := w.Write(lengthPrefix[:]); err != nil {
+			return
+		}
+		if _, err := w.Write(data); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// wantsBinaryResponse reports whether the client asked for the raw payload,
+// with "?encoding=binary" or by accepting only application/octet-stream,
+// rather than the default base64 encoded JSON response.
+func wantsBinaryResponse(r *http.Request) bool {
+	if r.URL.Query().Get("encoding") == "binary" {
+		return true
+	}
+	return strings.TrimSpace(strings.Split(r.Header.Get("Accept"), ";")[0]) == binaryContentType
+}
+
+// RecentHashesHandler lists the hashes stored since the unix time given by
+// the "since" query parameter, for gossip between committee members.
+func (rds *RestfulDasServer) RecentHashesHandler(w http.ResponseWriter, r *http.Request, requestPath string) {
+	recentHashesReader := rds.recentHashesReader()
+	if recentHashesReader == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var since int64
+	if sinceParam := r.URL.Query().Get("since"); sinceParam != "" {
+		var err error
+		since, err = strconv.ParseInt(sinceParam, 10, 64)
+		if err != nil {
+			log.Warn("Failed to parse since parameter", "path", requestPath, "err", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+	err := json.NewEncoder(w).Encode(RestfulDasServerResponse{RecentHashes: recentHashesReader.RecentHashes(time.Unix(since, 0))})
+	if err != nil {
+		log.Warn("Failed encoding and writing response", "path", requestPath, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// InventoryDigestHandler returns the digest of the stored keys, for
+// anti-entropy repair between committee members.
+func (rds *RestfulDasServer) InventoryDigestHandler(w http.ResponseWriter, r *http.Request, requestPath string) {
+	inventoryReader := rds.inventoryReader()
+	if inventoryReader == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	digest := inventoryReader.InventoryDigest()
+	err := json.NewEncoder(w).Encode(RestfulDasServerResponse{InventoryDigest: &digest})
+	if err != nil {
+		log.Warn("Failed encoding and writing response", "path", requestPath, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// InventoryRangeHandler lists the stored keys whose first byte is the one
+// given in the path.
+func (rds *RestfulDasServer) InventoryRangeHandler(w http.ResponseWriter, r *http.Request, requestPath string) {
+	inventoryReader := rds.inventoryReader()
+	if inventoryReader == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	keyRange, err := strconv.ParseUint(strings.TrimPrefix(requestPath, inventoryRangeRequestPath), 10, 8)
+	if err != nil {
+		log.Warn("Failed to parse inventory range", "path", requestPath, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	err = json.NewEncoder(w).Encode(RestfulDasServerResponse{InventoryKeys: inventoryReader.InventoryRange(uint8(keyRange))})
+	if err != nil {
+		log.Warn("Failed encoding and writing response", "path", requestPath, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// CustodyHandler answers a custody challenge for the data whose hash is given
+// in the path, with the nonce, offset and length of the slice to hash given
+// as query parameters.
+func (rds *RestfulDasServer) CustodyHandler(w http.ResponseWriter, r *http.Request, requestPath string) {
+	custodyProver := rds.custodyProver()
+	if custodyProver == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	hashBytes, err := DecodeStorageServiceKey(strings.TrimPrefix(requestPath, custodyRequestPath))
+	if err != nil || len(hashBytes) < 32 {
+		log.Warn("Failed to decode hex-encoded hash", "path", requestPath, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	query := r.URL.Query()
+	challenge := CustodyChallenge{
+		Hash:  common.BytesToHash(hashBytes[:32]),
+		Nonce: common.HexToHash(query.Get("nonce")),
+	}
+	challenge.Offset, err = strconv.ParseUint(query.Get("offset"), 10, 64)
+	if err == nil {
+		challenge.Length, err = strconv.ParseUint(query.Get("length"), 10, 64)
+	}
+	if err != nil {
+		log.Warn("Failed to parse custody challenge", "path", requestPath, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	proof, err := custodyProver.ProveCustody(r.Context(), challenge)
+	if errors.Is(err, ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Warn("Failed to answer custody challenge", "path", requestPath, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	err = json.NewEncoder(w).Encode(RestfulDasServerResponse{CustodyProof: &proof})
+	if err != nil {
+		log.Warn("Failed encoding and writing response", "path", requestPath, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// AnnouncementHandler serves this committee member's signed announcement of
+// its URLs.
+func (rds *RestfulDasServer) AnnouncementHandler(w http.ResponseWriter, r *http.Request, requestPath string) {
+	announcement := rds.endpointAnnouncement()
+	if announcement == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	err := json.NewEncoder(w).Encode(RestfulDasServerResponse{Announcement: announcement})
+	if err != nil {
+		log.Warn("Failed encoding and writing response", "path", requestPath, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// ExpirationInfoHandler returns when the data whose hash is given in the path
+// will expire and which storage tier has it, for operators.
+func (rds *RestfulDasServer) ExpirationInfoHandler(w http.ResponseWriter, r *http.Request, requestPath string) {
+	expirationInfoReader := rds.expirationInfoReader()
+	if expirationInfoReader == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	hashBytes, err := DecodeStorageServiceKey(strings.TrimPrefix(requestPath, expirationInfoRequestPath))
+	if err != nil || len(hashBytes) != 32 {
+		log.Warn("Failed to decode hex-encoded hash", "path", requestPath, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	info, err := expirationInfoReader.ExpirationInfo(r.Context(), common.BytesToHash(hashBytes))
+	if err != nil {
+		log.Warn("Unable to find expiration info", "path", requestPath, "err", err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	err = json.NewEncoder(w).Encode(RestfulDasServerResponse{ExpirationInfo: info})
+	if err != nil {
+		log.Warn("Failed encoding and writing response", "path", requestPath, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// KeysetsHandler lists the current and historical keysets the DAS can serve,
+// with their members' public keys and assumed-honest values.
+func (rds *RestfulDasServer) KeysetsHandler(w http.ResponseWriter, r *http.Request, requestPath string) {
+	keysetLister := rds.keysetLister()
+	if keysetLister == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	keysets, err := keysetLister.Keysets(r.Context())
+	if err != nil {
+		log.Warn("Unable to list keysets", "path", requestPath, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	err = json.NewEncoder(w).Encode(RestfulDasServerResponse{Keysets: keysets})
+	if err != nil {
+		log.Warn("Failed encoding and writing response", "path", requestPath, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// recentHashesReader, like the accessors below it, returns the daserver's
+// service if there is one, or else the reader itself if it implements the
+// service, eg a storage service in tests.
+func (rds *RestfulDasServer) recentHashesReader() RecentHashesReader {
+	if rds.services.RecentHashes != nil {
+		return rds.services.RecentHashes
+	}
+	reader, _ := rds.daReader.(RecentHashesReader)
+	return reader
+}
+
+func (rds *RestfulDasServer) inventoryReader() InventoryReader {
+	if rds.services.Inventory != nil {
+		return rds.services.Inventory
+	}
+	reader, _ := rds.daReader.(InventoryReader)
+	return reader
+}
+
+func (rds *RestfulDasServer) custodyProver() CustodyProver {
+	if rds.services.Custody != nil {
+		return rds.services.Custody
+	}
+	prover, _ := rds.daReader.(CustodyProver)
+	return prover
+}
+
+func (rds *RestfulDasServer) expirationInfoReader() ExpirationInfoReader {
+	if rds.services.Expiration != nil {
+		return rds.services.Expiration
+	}
+	reader, _ := rds.daReader.(ExpirationInfoReader)
+	return reader
+}
+
+func (rds *RestfulDasServer) keysetLister() KeysetLister {
+	if rds.services.Keysets != nil {
+		return rds.services.Keysets
+	}
+	lister, _ := rds.daReader.(KeysetLister)
+	return lister
+}
+
+func (rds *RestfulDasServer) endpointAnnouncement() *EndpointAnnouncement {
+	return rds.services.Announcement
+}
+
 func (rds *RestfulDasServer) GetServerExitedChan() <-chan interface{} { // channel will close when server terminates
 	return rds.httpServerExitedChan
 }
@@ -197,6 +586,9 @@ func (rds *RestfulDasServer) WaitForShutdown() error {
 }
 
 func (rds *RestfulDasServer) Shutdown() error {
+	if err := rds.closeHTTP3(); err != nil {
+		return err
+	}
 	err := rds.server.Close()
 	if err != nil {
 		return err
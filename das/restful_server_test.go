@@ -6,13 +6,22 @@ package das
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/ethereum/go-ethereum/common"
+
 	"github.com/offchainlabs/nitro/cmd/genericconf"
 	"github.com/offchainlabs/nitro/das/dastree"
 )
@@ -28,7 +37,7 @@ func NewRestfulDasServerOnRandomPort(address string, storageService StorageServi
 	if !ok {
 		return nil, 0, errors.New("attempt to listen on TCP returned non-TCP address")
 	}
-	rds, err := NewRestfulDasServerOnListener(listener, genericconf.HTTPServerTimeoutConfigDefault, storageService, storageService)
+	rds, err := NewRestfulDasServerOnListener(listener, genericconf.HTTPServerTimeoutConfigDefault, DefaultRestfulCORSConfig, nil, storageService, storageService, nil)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -65,6 +74,271 @@ func TestRestfulClientServer(t *testing.T) {
 		Fail(t, "Expected a 404 error")
 	}
 
+	has, size, err := client.Has(ctx, dataHash)
+	Require(t, err)
+	if !has || size != uint64(len(data)) {
+		Fail(t, "unexpected existence check", has, size)
+	}
+	has, _, err = client.Has(ctx, dastree.Hash([]byte("absent data")))
+	Require(t, err)
+	if has {
+		Fail(t, "absent data reported as stored")
+	}
+
 	err = server.Shutdown()
 	Require(t, err)
 }
+
+func TestRestfulServerBinaryGetByHash(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	storage := NewMemoryBackedStorageService(ctx)
+	data := []byte("Testing a restful server now.")
+	Require(t, storage.Put(ctx, data, uint64(time.Now().Add(time.Hour).Unix())))
+
+	server, port, err := NewRestfulDasServerOnRandomPort(LocalServerAddressForTest, storage)
+	Require(t, err)
+	defer func() {
+		Require(t, server.Shutdown())
+	}()
+
+	url := fmt.Sprintf("http://%s:%d/get-by-hash/%s", LocalServerAddressForTest, port, EncodeStorageServiceKey(dastree.Hash(data)))
+	get := func(url string, accept string) []byte {
+		t.Helper()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		Require(t, err)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		res, err := http.DefaultClient.Do(req)
+		Require(t, err)
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			Fail(t, "unexpected status", res.Status)
+		}
+		if res.Header.Get("Cache-Control") != cacheControlValueForSuccessfulGetByHash {
+			Fail(t, "unexpected cache control", res.Header.Get("Cache-Control"))
+		}
+		body, err := io.ReadAll(res.Body)
+		Require(t, err)
+		return body
+	}
+
+	if body := get(url+"?encoding=binary", ""); !bytes.Equal(body, data) {
+		Fail(t, "unexpected binary response", body)
+	}
+	if body := get(url, binaryContentType); !bytes.Equal(body, data) {
+		Fail(t, "unexpected binary response", body)
+	}
+	if body := get(url, ""); bytes.Equal(body, data) {
+		Fail(t, "expected the JSON response by default")
+	}
+}
+
+func TestRestfulServerGetByHashes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	storage := NewMemoryBackedStorageService(ctx)
+	var stored [][]byte
+	for i := 0; i < 3; i++ {
+		data := []byte(fmt.Sprintf("Testing a restful server now, payload %d.", i))
+		Require(t, storage.Put(ctx, data, uint64(time.Now().Add(time.Hour).Unix())))
+		stored = append(stored, data)
+	}
+
+	server, port, err := NewRestfulDasServerOnRandomPort(LocalServerAddressForTest, storage)
+	Require(t, err)
+	defer func() {
+		Require(t, server.Shutdown())
+	}()
+
+	client := NewRestfulDasClient("http", LocalServerAddressForTest, port)
+	hashes := []common.Hash{dastree.Hash(stored[2]), dastree.Hash([]byte("absent data")), dastree.Hash(stored[0]), dastree.Hash(stored[1])}
+	results, err := client.GetByHashes(ctx, hashes)
+	Require(t, err)
+	if len(results) != len(hashes) {
+		Fail(t, "unexpected number of results", len(results))
+	}
+	if !bytes.Equal(results[0], stored[2]) || results[1] != nil || !bytes.Equal(results[2], stored[0]) || !bytes.Equal(results[3], stored[1]) {
+		Fail(t, "unexpected results", results)
+	}
+
+	if _, err := client.GetByHashes(ctx, make([]common.Hash, MaxRESTBatchHashes+1)); err == nil {
+		Fail(t, "expected too many hashes to be rejected")
+	}
+}
+
+func TestRestfulServerContentEncoding(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	storage := NewMemoryBackedStorageService(ctx)
+	data := bytes.Repeat([]byte("Testing a restful server now. "), 1000)
+	Require(t, storage.Put(ctx, data, uint64(time.Now().Add(time.Hour).Unix())))
+
+	server, port, err := NewRestfulDasServerOnRandomPort(LocalServerAddressForTest, storage)
+	Require(t, err)
+	defer func() {
+		Require(t, server.Shutdown())
+	}()
+
+	// Keep the transport from negotiating and decoding gzip by itself.
+	httpClient := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+	url := fmt.Sprintf("http://%s:%d/get-by-hash/%s?encoding=binary", LocalServerAddressForTest, port, EncodeStorageServiceKey(dastree.Hash(data)))
+	for _, test := range []struct {
+		acceptEncoding string
+		encoding       string
+	}{
+		{"", ""},
+		{"gzip", gzipContentEncoding},
+		{"gzip, zstd", zstdContentEncoding},
+		{"zstd;q=0, gzip;q=0.5", gzipContentEncoding},
+		{"br", ""},
+		{"*", zstdContentEncoding},
+	} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		Require(t, err)
+		if test.acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", test.acceptEncoding)
+		}
+		res, err := httpClient.Do(req)
+		Require(t, err)
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		Require(t, err)
+		if res.StatusCode != http.StatusOK || res.Header.Get("Content-Encoding") != test.encoding {
+			Fail(t, "unexpected response to Accept-Encoding", test.acceptEncoding, res.Status, res.Header.Get("Content-Encoding"))
+		}
+
+		var decoded []byte
+		switch test.encoding {
+		case zstdContentEncoding:
+			decoder, err := zstd.NewReader(bytes.NewReader(body))
+			Require(t, err)
+			decoded, err = io.ReadAll(decoder)
+			decoder.Close()
+			Require(t, err)
+		case gzipContentEncoding:
+			reader, err := gzip.NewReader(bytes.NewReader(body))
+			Require(t, err)
+			decoded, err = io.ReadAll(reader)
+			Require(t, err)
+		default:
+			decoded = body
+		}
+		if !bytes.Equal(decoded, data) {
+			Fail(t, "unexpected decoded response to Accept-Encoding", test.acceptEncoding)
+		}
+		if test.encoding != "" && len(body) >= len(data) {
+			Fail(t, "expected response to be compressed", test.acceptEncoding, len(body))
+		}
+	}
+
+	// The client's default transport negotiates gzip transparently.
+	client := NewRestfulDasClient("http", LocalServerAddressForTest, port)
+	results, err := client.GetByHashes(ctx, []common.Hash{dastree.Hash(data)})
+	Require(t, err)
+	if len(results) != 1 || !bytes.Equal(results[0], data) {
+		Fail(t, "unexpected results", results)
+	}
+}
+
+func TestRestfulServerCORS(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	storage := NewMemoryBackedStorageService(ctx)
+	data := []byte("Testing a restful server now.")
+	Require(t, storage.Put(ctx, data, uint64(time.Now().Add(time.Hour).Unix())))
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:0", LocalServerAddressForTest))
+	Require(t, err)
+	cors := DefaultRestfulCORSConfig
+	cors.AllowedOrigins = []string{"https://explorer.example"}
+	server, err := NewRestfulDasServerOnListener(listener, genericconf.HTTPServerTimeoutConfigDefault, cors, nil, storage, storage, nil)
+	Require(t, err)
+	defer func() {
+		Require(t, server.Shutdown())
+	}()
+
+	url := "http://" + listener.Addr().String() + getByHashRequestPath + EncodeStorageServiceKey(dastree.Hash(data))
+	request := func(method string, origin string, preflight bool) *http.Response {
+		t.Helper()
+		req, err := http.NewRequestWithContext(ctx, method, url, nil)
+		Require(t, err)
+		req.Header.Set("Origin", origin)
+		if preflight {
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		}
+		res, err := http.DefaultClient.Do(req)
+		Require(t, err)
+		res.Body.Close()
+		return res
+	}
+
+	res := request(http.MethodOptions, "https://explorer.example", true)
+	if res.StatusCode != http.StatusNoContent || res.Header.Get("Access-Control-Allow-Origin") != "https://explorer.example" || !strings.Contains(res.Header.Get("Access-Control-Allow-Methods"), http.MethodGet) {
+		Fail(t, "unexpected preflight response", res.Status, res.Header)
+	}
+	res = request(http.MethodGet, "https://explorer.example", false)
+	if res.StatusCode != http.StatusOK || res.Header.Get("Access-Control-Allow-Origin") != "https://explorer.example" || !strings.Contains(res.Header.Get("Access-Control-Expose-Headers"), dataSizeHeader) {
+		Fail(t, "unexpected cross origin response", res.Status, res.Header)
+	}
+	res = request(http.MethodGet, "https://other.example", false)
+	if res.StatusCode != http.StatusOK || res.Header.Get("Access-Control-Allow-Origin") != "" {
+		Fail(t, "unexpected response to disallowed origin", res.Status, res.Header)
+	}
+}
+
+func TestRestfulServerHTTP3(t *testing.T) {
+	initTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	certDir := t.TempDir()
+	ca := issueTestCert(t, certDir, "ca", nil, 0)
+	serverCert := issueTestCert(t, certDir, "server", ca, x509.ExtKeyUsageServerAuth)
+	keyPair, err := tls.LoadX509KeyPair(serverCert.certFile, serverCert.keyFile)
+	Require(t, err)
+	serverTLSConfig := &tls.Config{Certificates: []tls.Certificate{keyPair}, MinVersion: tls.VersionTLS12}
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(ca.cert)
+	clientTLSConfig := &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}
+
+	storage := NewMemoryBackedStorageService(ctx)
+	data := []byte("Testing a restful server over HTTP/3.")
+	Require(t, storage.Put(ctx, data, uint64(time.Now().Add(time.Hour).Unix())))
+
+	listener, err := genericconf.TLSListen("127.0.0.1:0", serverTLSConfig)
+	Require(t, err)
+	server, err := NewRestfulDasServerOnListener(listener, genericconf.HTTPServerTimeoutConfigDefault, DefaultRestfulCORSConfig, nil, storage, storage, nil)
+	Require(t, err)
+	defer func() {
+		Require(t, server.Shutdown())
+	}()
+	Require(t, server.StartHTTP3(listener.Addr().String(), serverTLSConfig))
+
+	client, err := NewHTTP3RestfulDasClientFromURL("https://"+listener.Addr().String(), clientTLSConfig)
+	Require(t, err)
+	returnedData, err := client.GetByHash(ctx, dastree.Hash(data))
+	Require(t, err)
+	if !bytes.Equal(data, returnedData) {
+		Fail(t, "data returned over HTTP/3 doesn't match", returnedData)
+	}
+
+	// Responses over TCP advertise HTTP/3.
+	tcpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: clientTLSConfig}}
+	res, err := tcpClient.Get("https://" + listener.Addr().String() + healthRequestPath)
+	Require(t, err)
+	res.Body.Close()
+	if !strings.Contains(res.Header.Get("Alt-Svc"), "h3") {
+		Fail(t, "expected Alt-Svc header advertising HTTP/3", res.Header.Get("Alt-Svc"))
+	}
+
+	if _, err := NewHTTP3RestfulDasClientFromURL("http://"+listener.Addr().String(), nil); err == nil {
+		Fail(t, "expected HTTP/3 client without https to be an error")
+	}
+}
@@ -6,11 +6,18 @@ package das
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math/bits"
+	"net/http"
 	"net/url"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/offchainlabs/nitro/arbstate"
 	"github.com/offchainlabs/nitro/blsSignatures"
@@ -18,6 +25,8 @@ import (
 	"github.com/offchainlabs/nitro/util/metricsutil"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/offchainlabs/nitro/arbutil"
 )
 
@@ -25,67 +34,316 @@ type BackendConfig struct {
 	URL                 string `json:"url"`
 	PubKeyBase64Encoded string `json:"pubkey"`
 	SignerMask          uint64 `json:"signermask"`
+
+	// Optional per-backend Store policy. Durations are strings parsed by
+	// time.ParseDuration, eg "5s". See StorePolicy.
+	Timeout    string `json:"timeout,omitempty"`
+	Retries    int    `json:"retries,omitempty"`
+	Backoff    string `json:"backoff,omitempty"`
+	MaxBackoff string `json:"maxbackoff,omitempty"`
+
+	// Optional credentials for calling the backend, eg through an
+	// authenticated proxy. BasicAuth is "user:password"; the certificate,
+	// key and CA are file paths.
+	BearerToken string `json:"bearertoken,omitempty"`
+	BasicAuth   string `json:"basicauth,omitempty"`
+	ClientCert  string `json:"clientcert,omitempty"`
+	ClientKey   string `json:"clientkey,omitempty"`
+	RootCA      string `json:"rootca,omitempty"`
+}
+
+// dialOptions returns the RPC client options to authenticate to the backend.
+func (b *BackendConfig) dialOptions() ([]rpc.ClientOption, error) {
+	options, _, err := b.clientOptions()
+	return options, err
+}
+
+// dial connects to the backend, authenticating as configured.
+func (b *BackendConfig) dial(ctx context.Context) (*DASRPCClient, error) {
+	options, httpClient, err := b.clientOptions()
+	if err != nil {
+		return nil, err
+	}
+	client, err := NewDASRPCClientWithOptions(ctx, b.URL, options...)
+	if err != nil {
+		return nil, err
+	}
+	if httpClient != nil {
+		client.httpClient = httpClient
+	}
+	return client, nil
+}
+
+// clientOptions returns the RPC client options to authenticate to the
+// backend, and the HTTP client among them, if the backend needs its own.
+func (b *BackendConfig) clientOptions() ([]rpc.ClientOption, *http.Client, error) {
+	var options []rpc.ClientOption
+	if b.BearerToken != "" && b.BasicAuth != "" {
+		return nil, nil, fmt.Errorf("backend %s may only set one of bearertoken and basicauth", b.URL)
+	}
+	if b.BearerToken != "" {
+		options = append(options, rpc.WithHeader("Authorization", "Bearer "+b.BearerToken))
+	}
+	if b.BasicAuth != "" {
+		if !strings.Contains(b.BasicAuth, ":") {
+			return nil, nil, fmt.Errorf("backend %s basicauth must be of the form user:password", b.URL)
+		}
+		options = append(options, rpc.WithHeader("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(b.BasicAuth))))
+	}
+	if (b.ClientCert == "") != (b.ClientKey == "") {
+		return nil, nil, fmt.Errorf("backend %s must set both or neither of clientcert and clientkey", b.URL)
+	}
+	if (b.ClientCert != "" || b.RootCA != "") && strings.HasPrefix(b.URL, unixSocketURLPrefix) {
+		return nil, nil, fmt.Errorf("backend %s on a unix socket can't set clientcert or rootca", b.URL)
+	}
+	if b.ClientCert != "" || b.RootCA != "" {
+		tlsCfg := &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+		if b.ClientCert != "" {
+			clientCert, err := tls.LoadX509KeyPair(b.ClientCert, b.ClientKey)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error loading client certificate and private key for backend %s: %w", b.URL, err)
+			}
+			tlsCfg.Certificates = []tls.Certificate{clientCert}
+		}
+		if b.RootCA != "" {
+			rootCrt, err := os.ReadFile(b.RootCA)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error reading root CA for backend %s: %w", b.URL, err)
+			}
+			rootCertPool := x509.NewCertPool()
+			if !rootCertPool.AppendCertsFromPEM(rootCrt) {
+				return nil, nil, fmt.Errorf("no certificates found in root CA for backend %s", b.URL)
+			}
+			tlsCfg.RootCAs = rootCertPool
+		}
+		httpClient := &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: tlsCfg,
+			},
+		}
+		return append(options, rpc.WithHTTPClient(httpClient)), httpClient, nil
+	}
+	return options, nil, nil
+}
+
+func (b *BackendConfig) storePolicy() (StorePolicy, error) {
+	var policy StorePolicy
+	if b.Retries < 0 {
+		return policy, fmt.Errorf("backend %s has negative retries %d", b.URL, b.Retries)
+	}
+	policy.Retries = b.Retries
+	parse := func(name, value string) (time.Duration, error) {
+		if value == "" {
+			return 0, nil
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("backend %s has invalid %s %q: %w", b.URL, name, value, err)
+		}
+		if d < 0 {
+			return 0, fmt.Errorf("backend %s has negative %s %q", b.URL, name, value)
+		}
+		return d, nil
+	}
+	var err error
+	if policy.Timeout, err = parse("timeout", b.Timeout); err != nil {
+		return policy, err
+	}
+	if policy.Backoff, err = parse("backoff", b.Backoff); err != nil {
+		return policy, err
+	}
+	if policy.MaxBackoff, err = parse("maxbackoff", b.MaxBackoff); err != nil {
+		return policy, err
+	}
+	return policy, nil
 }
 
 func NewRPCAggregator(ctx context.Context, config DataAvailabilityConfig) (*Aggregator, error) {
-	services, err := ParseServices(config.RPCAggregator)
+	backends, services, err := parseConfiguredServices(config.RPCAggregator)
+	if err != nil {
+		return nil, err
+	}
+	aggregator, err := NewAggregator(ctx, config, services)
 	if err != nil {
 		return nil, err
 	}
-	return NewAggregator(ctx, config, services)
+	aggregator.lastBackends = backends
+	return aggregator, nil
 }
 
 func NewRPCAggregatorWithL1Info(config DataAvailabilityConfig, l1client arbutil.L1Interface, seqInboxAddress common.Address) (*Aggregator, error) {
-	services, err := ParseServices(config.RPCAggregator)
+	backends, services, err := parseConfiguredServices(config.RPCAggregator)
+	if err != nil {
+		return nil, err
+	}
+	aggregator, err := NewAggregatorWithL1Info(config, services, l1client, seqInboxAddress)
 	if err != nil {
 		return nil, err
 	}
-	return NewAggregatorWithL1Info(config, services, l1client, seqInboxAddress)
+	aggregator.lastBackends = backends
+	return aggregator, nil
 }
 
 func NewRPCAggregatorWithSeqInboxCaller(config DataAvailabilityConfig, seqInboxCaller *bridgegen.SequencerInboxCaller) (*Aggregator, error) {
-	services, err := ParseServices(config.RPCAggregator)
+	backends, services, err := parseConfiguredServices(config.RPCAggregator)
 	if err != nil {
 		return nil, err
 	}
-	return NewAggregatorWithSeqInboxCaller(config, services, seqInboxCaller)
+	aggregator, err := NewAggregatorWithSeqInboxCaller(config, services, seqInboxCaller)
+	if err != nil {
+		return nil, err
+	}
+	aggregator.lastBackends = backends
+	return aggregator, nil
+}
+
+// NewRPCAggregatorWithRegistry takes the backends' URLs, public keys and
+// signersMasks from the committee registry instead of the backend
+// configuration, and keeps them up to date with the registry once started.
+func NewRPCAggregatorWithRegistry(ctx context.Context, config DataAvailabilityConfig, registry *CommitteeRegistry) (*Aggregator, error) {
+	backends, err := registryBackends(ctx, config.RPCAggregator, registry)
+	if err != nil {
+		return nil, err
+	}
+	services, err := parseServicesJSON(backends)
+	if err != nil {
+		return nil, err
+	}
+	aggregator, err := NewAggregator(ctx, config, services)
+	if err != nil {
+		return nil, err
+	}
+	aggregator.lastBackends = backends
+	aggregator.registry = registry
+	aggregator.registryRefreshInterval = config.CommitteeRegistry.RefreshInterval
+	return aggregator, nil
+}
+
+func registryBackends(ctx context.Context, config AggregatorConfig, registry *CommitteeRegistry) (string, error) {
+	configured, err := backendsJSON(config)
+	if err != nil {
+		return "", err
+	}
+	members, err := registry.Members(ctx)
+	if err != nil {
+		return "", err
+	}
+	backends, err := registryBackendsJSON(members, configured)
+	if err != nil {
+		return "", err
+	}
+	return resolveDNSSRVBackends(ctx, backends)
+}
+
+// backendsJSON returns the JSON RPC backend configuration, from backends-file
+// if it is set.
+func backendsJSON(config AggregatorConfig) (string, error) {
+	if config.BackendsFile == "" {
+		return config.Backends, nil
+	}
+	if config.Backends != "" {
+		return "", errors.New("only one of rpc-aggregator.backends and rpc-aggregator.backends-file may be set")
+	}
+	contents, err := os.ReadFile(config.BackendsFile)
+	if err != nil {
+		return "", fmt.Errorf("couldn't read rpc-aggregator.backends-file: %w", err)
+	}
+	return string(contents), nil
 }
 
 func ParseServices(config AggregatorConfig) ([]ServiceDetails, error) {
-	var cs []BackendConfig
-	err := json.Unmarshal([]byte(config.Backends), &cs)
+	_, services, err := parseConfiguredServices(config)
+	return services, err
+}
+
+func parseConfiguredServices(config AggregatorConfig) (string, []ServiceDetails, error) {
+	backends, err := backendsJSON(config)
 	if err != nil {
+		return "", nil, err
+	}
+	backends, err = resolveDNSSRVBackends(context.Background(), backends)
+	if err != nil {
+		return "", nil, err
+	}
+	services, err := parseServicesJSON(backends)
+	return backends, services, err
+}
+
+func parseServicesJSON(backends string) ([]ServiceDetails, error) {
+	cs, services, err := parseBackendsJSON(backends)
+	if err != nil {
+		return nil, err
+	}
+	if err := dialServices(cs, services); err != nil {
 		return nil, err
 	}
+	return services, nil
+}
+
+// parseBackendsJSON checks the backend configuration without connecting to
+// the backends, returning the services to connect with dialServices.
+func parseBackendsJSON(backends string) ([]BackendConfig, []ServiceDetails, error) {
+	var cs []BackendConfig
+	err := json.Unmarshal([]byte(backends), &cs)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	var services []ServiceDetails
 
 	for _, b := range cs {
 		url, err := url.Parse(b.URL)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		metricName := metricsutil.CanonicalizeMetricName(url.Hostname())
 
-		service, err := NewDASRPCClient(b.URL)
-		if err != nil {
-			return nil, err
+		if _, err := b.dialOptions(); err != nil {
+			return nil, nil, err
 		}
 
 		pubKey, err := DecodeBase64BLSPublicKey([]byte(b.PubKeyBase64Encoded))
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
-		d, err := NewServiceDetails(service, *pubKey, b.SignerMask, metricName)
+		d, err := NewServiceDetails(nil, *pubKey, b.SignerMask, metricName)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+		d.policy, err = b.storePolicy()
+		if err != nil {
+			return nil, nil, err
 		}
 
 		services = append(services, *d)
 	}
 
-	return services, nil
+	return cs, services, nil
+}
+
+// dialServices connects each of the services to the backend it was parsed from.
+func dialServices(cs []BackendConfig, services []ServiceDetails) error {
+	for i := range cs {
+		service, err := cs[i].dial(context.Background())
+		if err != nil {
+			closeServices(services[:i])
+			return err
+		}
+		services[i].service = service
+	}
+	return nil
+}
+
+// closeServices closes the connections to the backends of services.
+func closeServices(services []ServiceDetails) {
+	for _, d := range services {
+		if client, ok := d.service.(*DASRPCClient); ok {
+			client.Close()
+		}
+	}
 }
 
 func KeysetHashFromServices(services []ServiceDetails, assumedHonest uint64) ([32]byte, []byte, error) {
@@ -117,3 +375,70 @@ func KeysetHashFromServices(services []ServiceDetails, assumedHonest uint64) ([3
 
 	return keysetHash, ksBuf.Bytes(), nil
 }
+
+// reloadBackends reloads the backends if the contents of backends-file or
+// the targets of the backends' DNS SRV URLs have changed, returning the time
+// until they should next be checked.
+func (a *Aggregator) reloadBackends(ctx context.Context) time.Duration {
+	backends, err := backendsJSON(a.config)
+	if err != nil {
+		log.Warn("das.Aggregator: failed to read backends file", "err", err)
+		return a.config.BackendsReloadInterval
+	}
+	backends, err = resolveDNSSRVBackends(ctx, backends)
+	if err != nil {
+		log.Warn("das.Aggregator: failed to resolve backends' DNS SRV URLs", "err", err)
+		return a.config.BackendsReloadInterval
+	}
+	if backends == a.lastBackends {
+		return a.config.BackendsReloadInterval
+	}
+	if err := a.reloadServices(backends); err != nil {
+		log.Error("das.Aggregator: failed to reload backends, keeping the current backends", "file", a.config.BackendsFile, "err", err)
+	}
+	return a.config.BackendsReloadInterval
+}
+
+// reloadFromRegistry reloads the backends if the committee registry, or the
+// configured policies and credentials, have changed.
+func (a *Aggregator) reloadFromRegistry(ctx context.Context) time.Duration {
+	backends, err := registryBackends(ctx, a.config, a.registry)
+	if err != nil {
+		log.Warn("das.Aggregator: failed to read committee registry", "err", err)
+		return a.registryRefreshInterval
+	}
+	if backends == a.lastBackends {
+		return a.registryRefreshInterval
+	}
+	if err := a.reloadServices(backends); err != nil {
+		log.Error("das.Aggregator: failed to reload backends from committee registry, keeping the current backends", "err", err)
+	}
+	return a.registryRefreshInterval
+}
+
+// reloadServices replaces the committee with the one configured by backends.
+// The new backends are only connected to once the configuration is accepted,
+// and the connections to the backends replaced are closed. A rejected
+// configuration is tried again on the next reload.
+func (a *Aggregator) reloadServices(backends string) error {
+	cs, services, err := parseBackendsJSON(backends)
+	if err != nil {
+		return err
+	}
+	if _, err := newAggregatorCommittee(a.config, services, nil); err != nil {
+		return err
+	}
+	if err := dialServices(cs, services); err != nil {
+		return err
+	}
+	previous := a.committee.Load()
+	if err := a.ReloadBackends(services); err != nil {
+		closeServices(services)
+		return err
+	}
+	// Only idle connections are closed, so stores still using the previous
+	// committee can finish.
+	closeServices(previous.services)
+	a.lastBackends = backends
+	return nil
+}
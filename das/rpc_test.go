@@ -9,11 +9,21 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+
 	"github.com/offchainlabs/nitro/blsSignatures"
 	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/das/dastree"
 	"github.com/offchainlabs/nitro/util/testhelpers"
 )
 
@@ -55,7 +65,7 @@ func TestRPC(t *testing.T) {
 	testhelpers.RequireImpl(t, err)
 	localDas, err := NewSignAfterStoreDASWriterWithSeqInboxCaller(privKey, nil, storageService, "")
 	testhelpers.RequireImpl(t, err)
-	dasServer, err := StartDASRPCServerOnListener(ctx, lis, genericconf.HTTPServerTimeoutConfigDefault, storageService, localDas, storageService)
+	dasServer, err := StartDASRPCServerOnListener(ctx, lis, genericconf.HTTPServerTimeoutConfigDefault, nil, DefaultStoreBoundsConfig, storageService, localDas, storageService)
 	defer func() {
 		if err := dasServer.Shutdown(ctx); err != nil {
 			panic(err)
@@ -98,3 +108,322 @@ func TestRPC(t *testing.T) {
 		testhelpers.FailImpl(t, "failed to getByHash correct message")
 	}
 }
+
+func TestRPCBackendCredentials(t *testing.T) {
+	ctx := context.Background()
+	authHeaders := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case authHeaders <- r.Header.Get("Authorization"):
+		default:
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	pubkey, _, err := blsSignatures.GenerateKeys()
+	testhelpers.RequireImpl(t, err)
+	beConfig := BackendConfig{
+		URL:                 server.URL,
+		PubKeyBase64Encoded: blsPubToBase64(&pubkey),
+		SignerMask:          1,
+		BearerToken:         "s3cr3t",
+	}
+	backendsJsonByte, err := json.Marshal([]BackendConfig{beConfig})
+	testhelpers.RequireImpl(t, err)
+	services, err := ParseServices(AggregatorConfig{Backends: string(backendsJsonByte)})
+	testhelpers.RequireImpl(t, err)
+
+	_, err = services[0].service.Store(ctx, []byte("message"), 0, nil)
+	if err == nil {
+		testhelpers.FailImpl(t, "expected Store to the unauthorized server to fail")
+	}
+	if header := <-authHeaders; header != "Bearer s3cr3t" {
+		testhelpers.FailImpl(t, "unexpected Authorization header: ", header)
+	}
+
+	invalid := []BackendConfig{
+		{URL: server.URL, BearerToken: "token", BasicAuth: "user:password"},
+		{URL: server.URL, BasicAuth: "user"},
+		{URL: server.URL, ClientCert: "cert.pem"},
+	}
+	for _, b := range invalid {
+		if _, err := b.dialOptions(); err == nil {
+			testhelpers.FailImpl(t, "expected invalid credentials to be rejected: ", b)
+		}
+	}
+}
+
+func TestRPCBatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lis, err := net.Listen("tcp", "localhost:0")
+	testhelpers.RequireImpl(t, err)
+
+	privKey, err := blsSignatures.GeneratePrivKeyString()
+	testhelpers.RequireImpl(t, err)
+	config := DataAvailabilityConfig{
+		Enable: true,
+		Key: KeyConfig{
+			PrivKey: privKey,
+		},
+		ParentChainNodeURL: "none",
+	}
+	storageService := NewMemoryBackedStorageService(ctx)
+	localDas, err := NewSignAfterStoreDASWriter(ctx, config, storageService)
+	testhelpers.RequireImpl(t, err)
+	dasServer, err := StartDASRPCServerOnListener(ctx, lis, genericconf.HTTPServerTimeoutConfigDefault, nil, DefaultStoreBoundsConfig, storageService, localDas, storageService)
+	testhelpers.RequireImpl(t, err)
+	defer func() {
+		if err := dasServer.Shutdown(ctx); err != nil {
+			panic(err)
+		}
+	}()
+	client, err := NewDASRPCClient("http://" + lis.Addr().String())
+	testhelpers.RequireImpl(t, err)
+
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	var items []StoreBatchItem
+	for i := 0; i < 3; i++ {
+		items = append(items, StoreBatchItem{Message: testhelpers.RandomizeSlice(make([]byte, 100)), Timeout: hexutil.Uint64(timeout)})
+	}
+	certs, err := client.StoreBatch(ctx, items)
+	testhelpers.RequireImpl(t, err)
+	if len(certs) != len(items) {
+		testhelpers.FailImpl(t, "unexpected number of certificates", len(certs))
+	}
+	for i, cert := range certs {
+		if cert.DataHash != dastree.Hash(items[i].Message) {
+			testhelpers.FailImpl(t, "certificate out of order", i)
+		}
+	}
+
+	absent := dastree.Hash([]byte("absent data"))
+	retrieved, err := client.RetrieveBatch(ctx, []common.Hash{certs[2].DataHash, absent, certs[0].DataHash})
+	testhelpers.RequireImpl(t, err)
+	if !bytes.Equal(retrieved[0], items[2].Message) || retrieved[1] != nil || !bytes.Equal(retrieved[2], items[0].Message) {
+		testhelpers.FailImpl(t, "failed to retrieve correct messages")
+	}
+
+	if _, err := client.StoreBatch(ctx, make([]StoreBatchItem, MaxRPCBatchItems+1)); err == nil {
+		testhelpers.FailImpl(t, "expected oversized batch to be rejected")
+	}
+}
+
+func TestRPCStoredSubscription(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lis, err := net.Listen("tcp", "localhost:0")
+	testhelpers.RequireImpl(t, err)
+
+	privKey, err := blsSignatures.GeneratePrivKeyString()
+	testhelpers.RequireImpl(t, err)
+	config := DataAvailabilityConfig{
+		Enable: true,
+		Key: KeyConfig{
+			PrivKey: privKey,
+		},
+		ParentChainNodeURL: "none",
+	}
+	storageService := NewMemoryBackedStorageService(ctx)
+	localDas, err := NewSignAfterStoreDASWriter(ctx, config, storageService)
+	testhelpers.RequireImpl(t, err)
+	dasServer, err := StartDASRPCServerOnListener(ctx, lis, genericconf.HTTPServerTimeoutConfigDefault, nil, DefaultStoreBoundsConfig, storageService, NewStoreNotifier(localDas), storageService)
+	testhelpers.RequireImpl(t, err)
+	defer func() {
+		if err := dasServer.Shutdown(ctx); err != nil {
+			panic(err)
+		}
+	}()
+
+	wsClient, err := rpc.DialContext(ctx, "ws://"+lis.Addr().String())
+	testhelpers.RequireImpl(t, err)
+	defer wsClient.Close()
+	stored := make(chan StoredData, 1)
+	sub, err := wsClient.Subscribe(ctx, "das", stored, "stored")
+	testhelpers.RequireImpl(t, err)
+	defer sub.Unsubscribe()
+
+	// Stores over HTTP-RPC are notified to WebSocket subscribers.
+	client, err := NewDASRPCClient("http://" + lis.Addr().String())
+	testhelpers.RequireImpl(t, err)
+	msg := testhelpers.RandomizeSlice(make([]byte, 100))
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	cert, err := client.Store(ctx, msg, timeout, nil)
+	testhelpers.RequireImpl(t, err)
+
+	select {
+	case data := <-stored:
+		if data.DataHash != cert.DataHash || uint64(data.Size) != uint64(len(msg)) || uint64(data.Timeout) != timeout {
+			testhelpers.FailImpl(t, "unexpected notification", data)
+		}
+	case err := <-sub.Err():
+		testhelpers.FailImpl(t, "subscription failed", err)
+	case <-time.After(5 * time.Second):
+		testhelpers.FailImpl(t, "no notification of the stored data")
+	}
+}
+
+func TestRPCChunkedStore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lis, err := net.Listen("tcp", "localhost:0")
+	testhelpers.RequireImpl(t, err)
+
+	privKey, err := blsSignatures.GeneratePrivKeyString()
+	testhelpers.RequireImpl(t, err)
+	config := DataAvailabilityConfig{
+		Enable: true,
+		Key: KeyConfig{
+			PrivKey: privKey,
+		},
+		ParentChainNodeURL: "none",
+	}
+	storageService := NewMemoryBackedStorageService(ctx)
+	localDas, err := NewSignAfterStoreDASWriter(ctx, config, storageService)
+	testhelpers.RequireImpl(t, err)
+	dasServer, err := StartDASRPCServerOnListener(ctx, lis, genericconf.HTTPServerTimeoutConfigDefault, nil, DefaultStoreBoundsConfig, storageService, localDas, storageService)
+	testhelpers.RequireImpl(t, err)
+	defer func() {
+		if err := dasServer.Shutdown(ctx); err != nil {
+			panic(err)
+		}
+	}()
+	client, err := NewDASRPCClient("http://" + lis.Addr().String())
+	testhelpers.RequireImpl(t, err)
+
+	msg := testhelpers.RandomizeSlice(make([]byte, 1000))
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	cert, err := client.StoreChunked(ctx, msg, timeout, nil, 300)
+	testhelpers.RequireImpl(t, err)
+	if cert.DataHash != dastree.Hash(msg) {
+		testhelpers.FailImpl(t, "unexpected data hash in certificate")
+	}
+	retrieved, err := storageService.GetByHash(ctx, cert.DataHash)
+	testhelpers.RequireImpl(t, err)
+	if !bytes.Equal(retrieved, msg) {
+		testhelpers.FailImpl(t, "failed to retrieve correct message")
+	}
+
+	// Chunks out of order and commits with the wrong hash are rejected.
+	var id hexutil.Uint64
+	testhelpers.RequireImpl(t, client.clnt.CallContext(ctx, &id, "das_startChunkedStore", hexutil.Uint64(timeout), hexutil.Bytes(nil)))
+	testhelpers.RequireImpl(t, client.clnt.CallContext(ctx, nil, "das_sendChunk", id, hexutil.Uint64(0), hexutil.Bytes(msg[:10])))
+	if err := client.clnt.CallContext(ctx, nil, "das_sendChunk", id, hexutil.Uint64(0), hexutil.Bytes(msg[:10])); err == nil {
+		testhelpers.FailImpl(t, "expected repeated chunk to be rejected")
+	}
+	var ret StoreResult
+	if err := client.clnt.CallContext(ctx, &ret, "das_commitChunkedStore", id, dastree.Hash(msg)); err == nil {
+		testhelpers.FailImpl(t, "expected commit with the wrong hash to be rejected")
+	}
+	if err := client.clnt.CallContext(ctx, &ret, "das_commitChunkedStore", id, dastree.Hash(msg[:10])); err == nil {
+		testhelpers.FailImpl(t, "expected a failed commit to end the chunked store")
+	}
+}
+
+func TestRPCChunkedStoreAuthAndBounds(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lis, err := net.Listen("tcp", "localhost:0")
+	testhelpers.RequireImpl(t, err)
+
+	privKey, err := blsSignatures.GeneratePrivKeyString()
+	testhelpers.RequireImpl(t, err)
+	config := DataAvailabilityConfig{
+		Enable: true,
+		Key: KeyConfig{
+			PrivKey: privKey,
+		},
+		ParentChainNodeURL: "none",
+	}
+	storageService := NewMemoryBackedStorageService(ctx)
+	localDas, err := NewSignAfterStoreDASWriter(ctx, config, storageService)
+	testhelpers.RequireImpl(t, err)
+	tokensFile := filepath.Join(t.TempDir(), "tokens")
+	testhelpers.RequireImpl(t, os.WriteFile(tokensFile, []byte("token\n"), 0600))
+	authenticator, err := NewTokenStoreAuthenticator(localDas, TokenAuthConfig{TokensFile: tokensFile})
+	testhelpers.RequireImpl(t, err)
+	// The authenticator is wrapped as in the daserver, so the server has to
+	// find it through the store notifier.
+	storeBounds := StoreBoundsConfig{MaxPayloadSize: 500}
+	dasServer, err := StartDASRPCServerOnListener(ctx, lis, genericconf.HTTPServerTimeoutConfigDefault, nil, storeBounds, storageService, NewStoreNotifier(authenticator), storageService)
+	testhelpers.RequireImpl(t, err)
+	defer func() {
+		testhelpers.RequireImpl(t, dasServer.Shutdown(ctx))
+	}()
+
+	dial := func(token string) *DASRPCClient {
+		t.Helper()
+		backend := BackendConfig{URL: "http://" + lis.Addr().String(), BearerToken: token}
+		options, err := backend.dialOptions()
+		testhelpers.RequireImpl(t, err)
+		client, err := NewDASRPCClientWithOptions(ctx, backend.URL, options...)
+		testhelpers.RequireImpl(t, err)
+		return client
+	}
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+
+	var id hexutil.Uint64
+	if err := dial("").clnt.CallContext(ctx, &id, "das_startChunkedStore", hexutil.Uint64(timeout), hexutil.Bytes(nil)); err == nil || !strings.Contains(err.Error(), ErrInvalidBearerToken.Error()) {
+		testhelpers.FailImpl(t, "expected chunked store without a token to be rejected at the start", err)
+	}
+	client := dial("token")
+	testhelpers.RequireImpl(t, client.clnt.CallContext(ctx, &id, "das_startChunkedStore", hexutil.Uint64(timeout), hexutil.Bytes(nil)))
+	if err := dial("").clnt.CallContext(ctx, nil, "das_sendChunk", id, hexutil.Uint64(0), hexutil.Bytes{1}); err == nil || !strings.Contains(err.Error(), ErrInvalidBearerToken.Error()) {
+		testhelpers.FailImpl(t, "expected chunk without a token to be rejected", err)
+	}
+
+	if _, err := client.StoreChunked(ctx, testhelpers.RandomizeSlice(make([]byte, 1000)), timeout, nil, 300); err == nil || !strings.Contains(err.Error(), "larger than the maximum of 500 bytes") {
+		testhelpers.FailImpl(t, "expected chunked store larger than max-payload-size to be rejected", err)
+	}
+	msg := testhelpers.RandomizeSlice(make([]byte, 400))
+	cert, err := client.StoreChunked(ctx, msg, timeout, nil, 300)
+	testhelpers.RequireImpl(t, err)
+	if cert.DataHash != dastree.Hash(msg) {
+		testhelpers.FailImpl(t, "unexpected data hash in certificate")
+	}
+}
+
+func TestRPCUnixSocket(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	socketPath := filepath.Join(t.TempDir(), "rpc.sock")
+	lis, err := genericconf.UnixSocketListen(socketPath)
+	testhelpers.RequireImpl(t, err)
+	if _, err := genericconf.UnixSocketListen(socketPath); err == nil {
+		testhelpers.FailImpl(t, "expected listening on a socket in use to fail")
+	}
+
+	storageService := NewMemoryBackedStorageService(ctx)
+	keyDir := t.TempDir()
+	_, _, err = GenerateAndStoreKeys(keyDir)
+	testhelpers.RequireImpl(t, err)
+	privKey, err := (&KeyConfig{KeyDir: keyDir}).BLSPrivKey()
+	testhelpers.RequireImpl(t, err)
+	localDas, err := NewSignAfterStoreDASWriterWithSeqInboxCaller(privKey, nil, storageService, "")
+	testhelpers.RequireImpl(t, err)
+	dasServer, err := StartDASRPCServerOnListener(ctx, lis, genericconf.HTTPServerTimeoutConfigDefault, nil, DefaultStoreBoundsConfig, storageService, localDas, storageService)
+	testhelpers.RequireImpl(t, err)
+
+	client, err := NewDASRPCClient("unix://" + socketPath)
+	testhelpers.RequireImpl(t, err)
+	msg := testhelpers.RandomizeSlice(make([]byte, 100))
+	cert, err := client.Store(ctx, msg, uint64(time.Now().Add(time.Hour).Unix()), nil)
+	testhelpers.RequireImpl(t, err)
+	retrieved, err := client.RetrieveBatch(ctx, []common.Hash{cert.DataHash})
+	testhelpers.RequireImpl(t, err)
+	if len(retrieved) != 1 || !bytes.Equal(retrieved[0], msg) {
+		testhelpers.FailImpl(t, "failed to retrieve stored message")
+	}
+
+	if _, err := (&BackendConfig{URL: "unix://" + socketPath, RootCA: "ca.crt"}).dialOptions(); err == nil {
+		testhelpers.FailImpl(t, "expected a unix socket backend with a root CA to be invalid")
+	}
+
+	testhelpers.RequireImpl(t, dasServer.Shutdown(ctx))
+	// Closing the listener removes the socket.
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		testhelpers.FailImpl(t, "expected socket to be removed", err)
+	}
+}
@@ -6,8 +6,10 @@ package das
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
+	"net/http"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -40,6 +42,9 @@ type S3StorageServiceConfig struct {
 	ObjectPrefix           string `koanf:"object-prefix"`
 	Region                 string `koanf:"region"`
 	SecretKey              string `koanf:"secret-key"`
+	Endpoint               string `koanf:"endpoint"`
+	UsePathStyle           bool   `koanf:"use-path-style"`
+	TLSInsecureSkipVerify  bool   `koanf:"tls-insecure-skip-verify"`
 	DiscardAfterTimeout    bool   `koanf:"discard-after-timeout"`
 	SyncFromStorageService bool   `koanf:"sync-from-storage-service"`
 	SyncToStorageService   bool   `koanf:"sync-to-storage-service"`
@@ -54,6 +59,9 @@ func S3ConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.String(prefix+".object-prefix", DefaultS3StorageServiceConfig.ObjectPrefix, "prefix to add to S3 objects")
 	f.String(prefix+".region", DefaultS3StorageServiceConfig.Region, "S3 region")
 	f.String(prefix+".secret-key", DefaultS3StorageServiceConfig.SecretKey, "S3 secret key")
+	f.String(prefix+".endpoint", DefaultS3StorageServiceConfig.Endpoint, "URL of an S3-compatible service such as MinIO, Cloudflare R2 or Backblaze B2, instead of AWS S3")
+	f.Bool(prefix+".use-path-style", DefaultS3StorageServiceConfig.UsePathStyle, "address buckets as endpoint/bucket rather than bucket.endpoint, as required by most MinIO setups")
+	f.Bool(prefix+".tls-insecure-skip-verify", DefaultS3StorageServiceConfig.TLSInsecureSkipVerify, "don't verify the S3 endpoint's TLS certificate (insecure, for testing only)")
 	f.Bool(prefix+".discard-after-timeout", DefaultS3StorageServiceConfig.DiscardAfterTimeout, "discard data after its expiry timeout")
 	f.Bool(prefix+".sync-from-storage-service", DefaultRedisConfig.SyncFromStorageService, "enable s3 to be used as a source for regular sync storage")
 	f.Bool(prefix+".sync-to-storage-service", DefaultRedisConfig.SyncToStorageService, "enable s3 to be used as a sink for regular sync storage")
@@ -62,6 +70,7 @@ func S3ConfigAddOptions(prefix string, f *flag.FlagSet) {
 type S3StorageService struct {
 	client              *s3.Client
 	bucket              string
+	endpoint            string
 	objectPrefix        string
 	uploader            S3Uploader
 	downloader          S3Downloader
@@ -69,13 +78,14 @@ type S3StorageService struct {
 }
 
 func NewS3StorageService(config S3StorageServiceConfig) (StorageService, error) {
-	client, err := buildS3Client(config.AccessKey, config.SecretKey, config.Region)
+	client, err := buildS3Client(config)
 	if err != nil {
 		return nil, err
 	}
 	return &S3StorageService{
 		client:              client,
 		bucket:              config.Bucket,
+		endpoint:            config.Endpoint,
 		objectPrefix:        config.ObjectPrefix,
 		uploader:            manager.NewUploader(client),
 		downloader:          manager.NewDownloader(client),
@@ -83,18 +93,31 @@ func NewS3StorageService(config S3StorageServiceConfig) (StorageService, error)
 	}, nil
 }
 
-func buildS3Client(accessKey, secretKey, region string) (*s3.Client, error) {
-	cfg, err := awsConfig.LoadDefaultConfig(context.TODO(), awsConfig.WithRegion(region), func(options *awsConfig.LoadOptions) error {
+func buildS3Client(config S3StorageServiceConfig) (*s3.Client, error) {
+	cfg, err := awsConfig.LoadDefaultConfig(context.TODO(), awsConfig.WithRegion(config.Region), func(options *awsConfig.LoadOptions) error {
 		// remain backward compatible with accessKey and secretKey credentials provided via cli flags
-		if accessKey != "" && secretKey != "" {
-			options.Credentials = credentials.NewStaticCredentialsProvider(accessKey, secretKey, "")
+		if config.AccessKey != "" && config.SecretKey != "" {
+			options.Credentials = credentials.NewStaticCredentialsProvider(config.AccessKey, config.SecretKey, "")
+		}
+		if config.TLSInsecureSkipVerify {
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			// #nosec G402
+			transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+			options.HTTPClient = &http.Client{Transport: transport}
 		}
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
-	return s3.NewFromConfig(cfg), nil
+	return s3.NewFromConfig(cfg, func(options *s3.Options) {
+		if config.Endpoint != "" {
+			options.EndpointResolver = s3.EndpointResolverFromURL(config.Endpoint, func(endpoint *aws.Endpoint) {
+				endpoint.HostnameImmutable = config.UsePathStyle
+			})
+		}
+		options.UsePathStyle = config.UsePathStyle
+	}), nil
 }
 
 func (s3s *S3StorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
@@ -153,7 +176,7 @@ func (s3s *S3StorageService) ExpirationPolicy(ctx context.Context) (arbstate.Exp
 }
 
 func (s3s *S3StorageService) String() string {
-	return fmt.Sprintf("S3StorageService(:%s)", s3s.bucket)
+	return fmt.Sprintf("S3StorageService(%s:%s)", s3s.endpoint, s3s.bucket)
 }
 
 func (s3s *S3StorageService) HealthCheck(ctx context.Context) error {
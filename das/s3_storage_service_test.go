@@ -8,6 +8,8 @@ import (
 	"context"
 	"errors"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -90,3 +92,28 @@ func TestS3StorageService(t *testing.T) {
 		t.Fatal(val, val1)
 	}
 }
+
+func TestS3StorageServiceCustomEndpoint(t *testing.T) {
+	ctx := context.Background()
+	var requestPath string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	s3Service, err := NewS3StorageService(S3StorageServiceConfig{
+		AccessKey:             "minioadmin",
+		SecretKey:             "minioadmin",
+		Bucket:                "das",
+		Region:                "auto",
+		Endpoint:              server.URL,
+		UsePathStyle:          true,
+		TLSInsecureSkipVerify: true,
+	})
+	Require(t, err)
+	Require(t, s3Service.HealthCheck(ctx))
+	if requestPath != "/das" {
+		Fail(t, "expected path style request for bucket, got path", requestPath)
+	}
+}
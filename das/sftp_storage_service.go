@@ -0,0 +1,296 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/pretty"
+
+	flag "github.com/spf13/pflag"
+)
+
+type SFTPStorageServiceConfig struct {
+	Enable                 bool          `koanf:"enable"`
+	Host                   string        `koanf:"host"`
+	User                   string        `koanf:"user"`
+	PrivateKeyFile         string        `koanf:"private-key-file"`
+	PrivateKeyPassphrase   string        `koanf:"private-key-passphrase"`
+	KnownHostsFile         string        `koanf:"known-hosts-file"`
+	InsecureIgnoreHostKey  bool          `koanf:"insecure-ignore-host-key"`
+	RemotePath             string        `koanf:"remote-path"`
+	Timeout                time.Duration `koanf:"timeout"`
+	SyncFromStorageService bool          `koanf:"sync-from-storage-service"`
+	SyncToStorageService   bool          `koanf:"sync-to-storage-service"`
+}
+
+var DefaultSFTPStorageServiceConfig = SFTPStorageServiceConfig{
+	Timeout: 10 * time.Second,
+}
+
+func SFTPStorageConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultSFTPStorageServiceConfig.Enable, "enable storage/retrieval of sequencer batch data from a remote directory over SFTP")
+	f.String(prefix+".host", DefaultSFTPStorageServiceConfig.Host, "SSH server address as host:port")
+	f.String(prefix+".user", DefaultSFTPStorageServiceConfig.User, "SSH user")
+	f.String(prefix+".private-key-file", DefaultSFTPStorageServiceConfig.PrivateKeyFile, "file containing the PEM encoded SSH private key used to authenticate")
+	f.String(prefix+".private-key-passphrase", DefaultSFTPStorageServiceConfig.PrivateKeyPassphrase, "passphrase for the SSH private key, if it is encrypted")
+	f.String(prefix+".known-hosts-file", DefaultSFTPStorageServiceConfig.KnownHostsFile, "known_hosts file used to verify the SSH server host key")
+	f.Bool(prefix+".insecure-ignore-host-key", DefaultSFTPStorageServiceConfig.InsecureIgnoreHostKey, "don't verify the SSH server host key (insecure, for testing only)")
+	f.String(prefix+".remote-path", DefaultSFTPStorageServiceConfig.RemotePath, "directory on the remote server in which to store data")
+	f.Duration(prefix+".timeout", DefaultSFTPStorageServiceConfig.Timeout, "timeout for establishing the SSH connection")
+	f.Bool(prefix+".sync-from-storage-service", DefaultSFTPStorageServiceConfig.SyncFromStorageService, "enable SFTP to be used as a source for regular sync storage")
+	f.Bool(prefix+".sync-to-storage-service", DefaultSFTPStorageServiceConfig.SyncToStorageService, "enable SFTP to be used as a sink for regular sync storage")
+}
+
+type SFTPStorageService struct {
+	remotePath string
+	host       string
+
+	// dial is used to (re)connect when the connection is lost.
+	dial   func() (*sftp.Client, io.Closer, error)
+	mutex  sync.Mutex
+	client *sftp.Client
+	conn   io.Closer
+}
+
+func NewSFTPStorageService(config SFTPStorageServiceConfig) (StorageService, error) {
+	if config.RemotePath == "" {
+		return nil, errors.New("sftp-storage.remote-path must be set")
+	}
+	sshConfig, err := sftpSSHClientConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	dial := func() (*sftp.Client, io.Closer, error) {
+		sshClient, err := ssh.Dial("tcp", config.Host, sshConfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error connecting to SSH server %s: %w", config.Host, err)
+		}
+		client, err := sftp.NewClient(sshClient)
+		if err != nil {
+			sshClient.Close()
+			return nil, nil, err
+		}
+		return client, sshClient, nil
+	}
+	ss := &SFTPStorageService{
+		remotePath: config.RemotePath,
+		host:       config.Host,
+		dial:       dial,
+	}
+	client, err := ss.getClient()
+	if err != nil {
+		return nil, err
+	}
+	if err := client.MkdirAll(ss.remotePath); err != nil {
+		return nil, fmt.Errorf("error creating remote directory %s: %w", ss.remotePath, err)
+	}
+	return ss, nil
+}
+
+func sftpSSHClientConfig(config SFTPStorageServiceConfig) (*ssh.ClientConfig, error) {
+	keyPEM, err := os.ReadFile(config.PrivateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading SSH private key: %w", err)
+	}
+	var signer ssh.Signer
+	if config.PrivateKeyPassphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(keyPEM, []byte(config.PrivateKeyPassphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(keyPEM)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing SSH private key: %w", err)
+	}
+	var hostKeyCallback ssh.HostKeyCallback
+	if config.InsecureIgnoreHostKey {
+		// #nosec G106
+		hostKeyCallback = ssh.InsecureIgnoreHostKey()
+	} else {
+		if config.KnownHostsFile == "" {
+			return nil, errors.New("sftp-storage.known-hosts-file must be set unless sftp-storage.insecure-ignore-host-key is set")
+		}
+		hostKeyCallback, err = knownhosts.New(config.KnownHostsFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading known hosts file: %w", err)
+		}
+	}
+	return &ssh.ClientConfig{
+		User:            config.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         config.Timeout,
+	}, nil
+}
+
+func (ss *SFTPStorageService) getClient() (*sftp.Client, error) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if ss.client != nil {
+		return ss.client, nil
+	}
+	client, conn, err := ss.dial()
+	if err != nil {
+		return nil, err
+	}
+	ss.client = client
+	ss.conn = conn
+	return client, nil
+}
+
+// checkConnection drops the client if err shows the connection was lost, so
+// the next request reconnects.
+func (ss *SFTPStorageService) checkConnection(client *sftp.Client, err error) {
+	if !errors.Is(err, sftp.ErrSSHFxConnectionLost) && !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+		return
+	}
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if ss.client != client {
+		return
+	}
+	ss.closeLocked()
+}
+
+func (ss *SFTPStorageService) closeLocked() error {
+	if ss.client == nil {
+		return nil
+	}
+	err := ss.client.Close()
+	if ss.conn != nil {
+		if connErr := ss.conn.Close(); err == nil {
+			err = connErr
+		}
+	}
+	ss.client = nil
+	ss.conn = nil
+	return err
+}
+
+// writeFile writes to a temporary file then renames it into place, so readers
+// never see partially written files.
+func (ss *SFTPStorageService) writeFile(key common.Hash, value []byte) error {
+	client, err := ss.getClient()
+	if err != nil {
+		return err
+	}
+	finalPath := path.Join(ss.remotePath, EncodeStorageServiceKey(key))
+	tmpPath := fmt.Sprintf("%s.tmp-%d", finalPath, time.Now().UnixNano())
+	err = func() error {
+		f, err := client.Create(tmpPath)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(value); err != nil {
+			_ = f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		err = client.PosixRename(tmpPath, finalPath)
+		if err != nil {
+			// Not all servers support the posix-rename extension, and a plain
+			// SFTP rename fails if the target exists.
+			_ = client.Remove(finalPath)
+			err = client.Rename(tmpPath, finalPath)
+		}
+		return err
+	}()
+	if err != nil {
+		_ = client.Remove(tmpPath)
+		ss.checkConnection(client, err)
+	}
+	return err
+}
+
+func (ss *SFTPStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.SFTPStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", ss)
+
+	client, err := ss.getClient()
+	if err != nil {
+		return nil, err
+	}
+	f, err := client.Open(path.Join(ss.remotePath, EncodeStorageServiceKey(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		ss.checkConnection(client, err)
+		log.Error("das.SFTPStorageService.GetByHash", "err", err)
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		ss.checkConnection(client, err)
+		log.Error("das.SFTPStorageService.GetByHash", "err", err)
+		return nil, err
+	}
+	return data, nil
+}
+
+func (ss *SFTPStorageService) Put(ctx context.Context, value []byte, timeout uint64) error {
+	logPut("das.SFTPStorageService.Store", value, timeout, ss)
+	err := ss.writeFile(dastree.Hash(value), value)
+	if err != nil {
+		log.Error("das.SFTPStorageService.Store", "err", err)
+	}
+	return err
+}
+
+func (ss *SFTPStorageService) putKeyValue(ctx context.Context, key common.Hash, value []byte) error {
+	err := ss.writeFile(key, value)
+	if err != nil {
+		log.Error("das.SFTPStorageService.putKeyValue", "err", err)
+	}
+	return err
+}
+
+func (ss *SFTPStorageService) Sync(ctx context.Context) error {
+	return nil
+}
+
+func (ss *SFTPStorageService) Close(ctx context.Context) error {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	return ss.closeLocked()
+}
+
+func (ss *SFTPStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	return arbstate.KeepForever, nil
+}
+
+func (ss *SFTPStorageService) String() string {
+	return fmt.Sprintf("SFTPStorageService(%s:%s)", ss.host, ss.remotePath)
+}
+
+func (ss *SFTPStorageService) HealthCheck(ctx context.Context) error {
+	client, err := ss.getClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.Stat(ss.remotePath)
+	if err != nil {
+		ss.checkConnection(client, err)
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+func TestSFTPStorageService(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+
+	// Serve an in-memory filesystem over a pipe in place of an SSH connection.
+	handlers := sftp.InMemHandler()
+	dial := func() (*sftp.Client, io.Closer, error) {
+		clientConn, serverConn := net.Pipe()
+		server := sftp.NewRequestServer(serverConn, handlers)
+		go func() {
+			_ = server.Serve()
+		}()
+		client, err := sftp.NewClientPipe(clientConn, clientConn)
+		if err != nil {
+			return nil, nil, err
+		}
+		return client, server, nil
+	}
+	sftpService := &SFTPStorageService{
+		remotePath: "/das",
+		host:       "test",
+		dial:       dial,
+	}
+	defer sftpService.Close(ctx)
+	client, err := sftpService.getClient()
+	Require(t, err)
+	Require(t, client.MkdirAll("/das"))
+	Require(t, sftpService.HealthCheck(ctx))
+
+	val1 := []byte("The first value")
+	val1CorrectKey := dastree.Hash(val1)
+	val2IncorrectKey := dastree.Hash(append(val1, 0))
+
+	_, err = sftpService.GetByHash(ctx, val1CorrectKey)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	err = sftpService.Put(ctx, val1, timeout)
+	Require(t, err)
+	// Storing the same data again replaces the existing file.
+	err = sftpService.Put(ctx, val1, timeout)
+	Require(t, err)
+
+	_, err = sftpService.GetByHash(ctx, val2IncorrectKey)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	val, err := sftpService.GetByHash(ctx, val1CorrectKey)
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		t.Fatal(val, val1)
+	}
+}
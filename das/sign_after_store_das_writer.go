@@ -7,9 +7,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"time"
 
 	flag "github.com/spf13/pflag"
@@ -27,15 +29,67 @@ import (
 )
 
 type KeyConfig struct {
-	KeyDir  string `koanf:"key-dir"`
-	PrivKey string `koanf:"priv-key"`
+	KeyDir         string                `koanf:"key-dir"`
+	PrivKey        string                `koanf:"priv-key"`
+	Passphrase     string                `koanf:"passphrase"`
+	PassphraseFile string                `koanf:"passphrase-file"`
+	RemoteSigner   RemoteSignerConfig    `koanf:"remote-signer"`
+	Vault          VaultConfig           `koanf:"vault"`
+	PKCS11         PKCS11Config          `koanf:"pkcs11"`
+	Threshold      ThresholdSignerConfig `koanf:"threshold"`
+	Rotations      string                `koanf:"rotations"`
+}
+
+// KeyRotationConfig is a key that replaces the configured key for signing once
+// the parent chain reaches ActivationHeight.
+type KeyRotationConfig struct {
+	KeyDir           string `json:"key-dir"`
+	PrivKey          string `json:"priv-key"`
+	PassphraseFile   string `json:"passphrase-file"`
+	ActivationHeight uint64 `json:"activation-height"`
+}
+
+type KeyRotation struct {
+	PrivKey          blsSignatures.PrivateKey
+	ActivationHeight uint64
+}
+
+// BLSKeyRotations returns the keys configured in Rotations.
+func (c *KeyConfig) BLSKeyRotations() ([]KeyRotation, error) {
+	if c.Rotations == "" {
+		return nil, nil
+	}
+	if c.RemoteSigner.URL != "" || c.Vault.Address != "" || c.PKCS11.Module != "" || c.Threshold.Shares != "" {
+		return nil, errors.New("'rotations' is only supported with keys given by key-dir or priv-key, not by remote-signer, vault, pkcs11 or threshold")
+	}
+	var configs []KeyRotationConfig
+	if err := json.Unmarshal([]byte(c.Rotations), &configs); err != nil {
+		return nil, fmt.Errorf("'rotations' was invalid: %w", err)
+	}
+	rotations := make([]KeyRotation, 0, len(configs))
+	for _, rc := range configs {
+		// Encrypted keys are decrypted with the main key's passphrase unless
+		// they have their own.
+		keyConfig := KeyConfig{KeyDir: rc.KeyDir, PrivKey: rc.PrivKey, Passphrase: c.Passphrase, PassphraseFile: c.PassphraseFile}
+		if rc.PassphraseFile != "" {
+			keyConfig.Passphrase, keyConfig.PassphraseFile = "", rc.PassphraseFile
+		}
+		privKey, err := keyConfig.BLSPrivKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key rotation at activation height %d: %w", rc.ActivationHeight, err)
+		}
+		rotations = append(rotations, KeyRotation{privKey, rc.ActivationHeight})
+	}
+	return rotations, nil
 }
 
 func (c *KeyConfig) BLSPrivKey() (blsSignatures.PrivateKey, error) {
 	var privKeyBytes []byte
+	keyDescription := "priv-key"
 	if len(c.PrivKey) != 0 {
 		privKeyBytes = []byte(c.PrivKey)
 	} else if len(c.KeyDir) != 0 {
+		keyDescription = c.KeyDir + "/" + DefaultPrivKeyFilename
 		var err error
 		privKeyBytes, err = os.ReadFile(c.KeyDir + "/" + DefaultPrivKeyFilename)
 		if err != nil {
@@ -47,6 +101,17 @@ func (c *KeyConfig) BLSPrivKey() (blsSignatures.PrivateKey, error) {
 	} else {
 		return nil, errors.New("must specify PrivKey or KeyDir")
 	}
+	if isBLSKeystore(privKeyBytes) {
+		passphrase, err := c.passphrase(keyDescription)
+		if err != nil {
+			return nil, err
+		}
+		privKey, err := DecryptBLSPrivateKey(privKeyBytes, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't decrypt BLS key %s: %w", keyDescription, err)
+		}
+		return privKey, nil
+	}
 	privKey, err := DecodeBase64BLSPrivateKey(privKeyBytes)
 	if err != nil {
 		return nil, fmt.Errorf("'priv-key' was invalid: %w", err)
@@ -54,11 +119,23 @@ func (c *KeyConfig) BLSPrivKey() (blsSignatures.PrivateKey, error) {
 	return privKey, nil
 }
 
-var DefaultKeyConfig = KeyConfig{}
+var DefaultKeyConfig = KeyConfig{
+	RemoteSigner: DefaultRemoteSignerConfig,
+	Vault:        DefaultVaultConfig,
+	PKCS11:       DefaultPKCS11Config,
+	Threshold:    DefaultThresholdSignerConfig,
+}
 
 func KeyConfigAddOptions(prefix string, f *flag.FlagSet) {
-	f.String(prefix+".key-dir", DefaultKeyConfig.KeyDir, fmt.Sprintf("the directory to read the bls keypair ('%s' and '%s') from; if using any of the DAS storage types exactly one of key-dir or priv-key must be specified", DefaultPubKeyFilename, DefaultPrivKeyFilename))
-	f.String(prefix+".priv-key", DefaultKeyConfig.PrivKey, "the base64 BLS private key to use for signing DAS certificates; if using any of the DAS storage types exactly one of key-dir or priv-key must be specified")
+	f.String(prefix+".key-dir", DefaultKeyConfig.KeyDir, fmt.Sprintf("the directory to read the bls keypair ('%s' and '%s') from; if using any of the DAS storage types exactly one of key-dir, priv-key, remote-signer.url, vault.address, pkcs11.module or threshold.shares must be specified", DefaultPubKeyFilename, DefaultPrivKeyFilename))
+	f.String(prefix+".priv-key", DefaultKeyConfig.PrivKey, "the base64 BLS private key to use for signing DAS certificates; if using any of the DAS storage types exactly one of key-dir, priv-key, remote-signer.url, vault.address, pkcs11.module or threshold.shares must be specified")
+	f.String(prefix+".passphrase", DefaultKeyConfig.Passphrase, "passphrase of the BLS key, if it's an encrypted keystore as written by 'datool keygen --encrypt'; it's visible to other users of the machine, so passphrase-file should be preferred; if neither is set, it's prompted for")
+	f.String(prefix+".passphrase-file", DefaultKeyConfig.PassphraseFile, "file with the passphrase of the BLS key, if it's an encrypted keystore")
+	RemoteSignerConfigAddOptions(prefix+".remote-signer", f)
+	VaultConfigAddOptions(prefix+".vault", f)
+	PKCS11ConfigAddOptions(prefix+".pkcs11", f)
+	ThresholdSignerConfigAddOptions(prefix+".threshold", f)
+	f.String(prefix+".rotations", DefaultKeyConfig.Rotations, "JSON list of keys that take over signing DAS certificates from the parent chain block \"activation-height\" on, each given by \"key-dir\" or \"priv-key\" and, if encrypted with a different passphrase than the key, \"passphrase-file\"; requires a parent chain connection, and the key itself to be given by key-dir or priv-key")
 }
 
 // SignAfterStoreDASWriter provides DAS signature functionality over a StorageService
@@ -72,26 +149,43 @@ func KeyConfigAddOptions(prefix string, f *flag.FlagSet) {
 // constructed, calls to Store(...) will try to verify the passed-in data's signature
 // is from the batch poster. If the contract details are not provided, then the
 // signature is not checked, which is useful for testing.
+//
+// Keys can be rotated by adding keys with activation heights, which are used
+// instead of the original key from those parent chain heights on. Every key
+// stays loaded, so the keyset the writer signs under changes at each activation
+// height without a restart, while the data it stored under previous keysets
+// is still served from the same storage.
 type SignAfterStoreDASWriter struct {
-	privKey        blsSignatures.PrivateKey
+	signer         BLSSigner
 	pubKey         *blsSignatures.PublicKey
 	keysetHash     [32]byte
 	keysetBytes    []byte
 	storageService StorageService
 	addrVerifier   *contracts.AddressVerifier
 
+	// Rotated keys in order of activation height.
+	rotations         []*signingKey
+	parentChainHeight func(context.Context) (uint64, error)
+
 	// Extra batch poster verifier, for local installations to have their
 	// own way of testing Stores.
 	extraBpVerifier func(message []byte, timeout uint64, sig []byte) bool
 }
 
 func NewSignAfterStoreDASWriter(ctx context.Context, config DataAvailabilityConfig, storageService StorageService) (*SignAfterStoreDASWriter, error) {
-	privKey, err := config.Key.BLSPrivKey()
+	signer, err := config.Key.BLSSigner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rotations, err := config.Key.BLSKeyRotations()
 	if err != nil {
 		return nil, err
 	}
 	if config.ParentChainNodeURL == "none" {
-		return NewSignAfterStoreDASWriterWithSeqInboxCaller(privKey, nil, storageService, config.ExtraSignatureCheckingPublicKey)
+		if len(rotations) > 0 {
+			return nil, errors.New("key rotations require a parent chain connection")
+		}
+		return NewSignAfterStoreDASWriterWithSigner(signer, nil, storageService, config.ExtraSignatureCheckingPublicKey)
 	}
 	l1client, err := GetL1Client(ctx, config.ParentChainConnectionAttempts, config.ParentChainNodeURL)
 	if err != nil {
@@ -101,15 +195,96 @@ func NewSignAfterStoreDASWriter(ctx context.Context, config DataAvailabilityConf
 	if err != nil {
 		return nil, err
 	}
-	if seqInboxAddress == nil {
-		return NewSignAfterStoreDASWriterWithSeqInboxCaller(privKey, nil, storageService, config.ExtraSignatureCheckingPublicKey)
+	var seqInboxCaller *bridgegen.SequencerInboxCaller
+	if seqInboxAddress != nil {
+		seqInboxCaller, err = bridgegen.NewSequencerInboxCaller(*seqInboxAddress, l1client)
+		if err != nil {
+			return nil, err
+		}
 	}
-
-	seqInboxCaller, err := bridgegen.NewSequencerInboxCaller(*seqInboxAddress, l1client)
+	writer, err := NewSignAfterStoreDASWriterWithSigner(signer, seqInboxCaller, storageService, config.ExtraSignatureCheckingPublicKey)
 	if err != nil {
 		return nil, err
 	}
-	return NewSignAfterStoreDASWriterWithSeqInboxCaller(privKey, seqInboxCaller, storageService, config.ExtraSignatureCheckingPublicKey)
+	if len(rotations) > 0 {
+		if err := writer.AddKeyRotations(rotations, l1client.BlockNumber); err != nil {
+			return nil, err
+		}
+	}
+	return writer, nil
+}
+
+type signingKey struct {
+	signer           BLSSigner
+	pubKey           blsSignatures.PublicKey
+	keysetHash       [32]byte
+	activationHeight uint64
+}
+
+// singleKeyKeyset returns the keyset of a DAS that signs on its own.
+func singleKeyKeyset(publicKey blsSignatures.PublicKey) ([32]byte, []byte, error) {
+	keyset := &arbstate.DataAvailabilityKeyset{
+		AssumedHonest: 1,
+		PubKeys:       []blsSignatures.PublicKey{publicKey},
+	}
+	ksBuf := bytes.NewBuffer([]byte{})
+	if err := keyset.Serialize(ksBuf); err != nil {
+		return [32]byte{}, nil, err
+	}
+	ksHash, err := keyset.Hash()
+	if err != nil {
+		return [32]byte{}, nil, err
+	}
+	return ksHash, ksBuf.Bytes(), nil
+}
+
+// AddKeyRotations adds keys that replace the writer's key for signing from
+// their activation heights on, as reported by parentChainHeight.
+func (d *SignAfterStoreDASWriter) AddKeyRotations(rotations []KeyRotation, parentChainHeight func(context.Context) (uint64, error)) error {
+	keys := append([]*signingKey{}, d.rotations...)
+	for _, r := range rotations {
+		if r.ActivationHeight == 0 {
+			return errors.New("key rotations must have a non-zero activation height")
+		}
+		signer, err := NewLocalBLSSigner(r.PrivKey)
+		if err != nil {
+			return err
+		}
+		pubKey := signer.PublicKey()
+		keysetHash, _, err := singleKeyKeyset(pubKey)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, &signingKey{signer, pubKey, keysetHash, r.ActivationHeight})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].activationHeight < keys[j].activationHeight })
+	for i := 1; i < len(keys); i++ {
+		if keys[i].activationHeight == keys[i-1].activationHeight {
+			return fmt.Errorf("more than one key rotation at activation height %d", keys[i].activationHeight)
+		}
+	}
+	d.rotations = keys
+	d.parentChainHeight = parentChainHeight
+	return nil
+}
+
+// currentSigningKey returns the key to sign with at the current parent chain height.
+func (d *SignAfterStoreDASWriter) currentSigningKey(ctx context.Context) (*signingKey, error) {
+	key := &signingKey{d.signer, *d.pubKey, d.keysetHash, 0}
+	if len(d.rotations) == 0 {
+		return key, nil
+	}
+	height, err := d.parentChainHeight(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get parent chain height to choose signing key: %w", err)
+	}
+	for _, rotation := range d.rotations {
+		if rotation.activationHeight > height {
+			break
+		}
+		key = rotation
+	}
+	return key, nil
 }
 
 func NewSignAfterStoreDASWriterWithSeqInboxCaller(
@@ -118,20 +293,24 @@ func NewSignAfterStoreDASWriterWithSeqInboxCaller(
 	storageService StorageService,
 	extraSignatureCheckingPublicKey string,
 ) (*SignAfterStoreDASWriter, error) {
-	publicKey, err := blsSignatures.PublicKeyFromPrivateKey(privKey)
+	signer, err := NewLocalBLSSigner(privKey)
 	if err != nil {
 		return nil, err
 	}
+	return NewSignAfterStoreDASWriterWithSigner(signer, seqInboxCaller, storageService, extraSignatureCheckingPublicKey)
+}
 
-	keyset := &arbstate.DataAvailabilityKeyset{
-		AssumedHonest: 1,
-		PubKeys:       []blsSignatures.PublicKey{publicKey},
-	}
-	ksBuf := bytes.NewBuffer([]byte{})
-	if err := keyset.Serialize(ksBuf); err != nil {
-		return nil, err
-	}
-	ksHash, err := keyset.Hash()
+// NewSignAfterStoreDASWriterWithSigner is like
+// NewSignAfterStoreDASWriterWithSeqInboxCaller, but signs with signer, which
+// needn't hold the private key in the process.
+func NewSignAfterStoreDASWriterWithSigner(
+	signer BLSSigner,
+	seqInboxCaller *bridgegen.SequencerInboxCaller,
+	storageService StorageService,
+	extraSignatureCheckingPublicKey string,
+) (*SignAfterStoreDASWriter, error) {
+	publicKey := signer.PublicKey()
+	ksHash, ksBytes, err := singleKeyKeyset(publicKey)
 	if err != nil {
 		return nil, err
 	}
@@ -168,10 +347,10 @@ func NewSignAfterStoreDASWriterWithSeqInboxCaller(
 	}
 
 	return &SignAfterStoreDASWriter{
-		privKey:         privKey,
+		signer:          signer,
 		pubKey:          &publicKey,
 		keysetHash:      ksHash,
-		keysetBytes:     ksBuf.Bytes(),
+		keysetBytes:     ksBytes,
 		storageService:  storageService,
 		addrVerifier:    addrVerifier,
 		extraBpVerifier: extraBpVerifier,
@@ -201,6 +380,11 @@ func (d *SignAfterStoreDASWriter) Store(
 		}
 	}
 
+	key, err := d.currentSigningKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	c = &arbstate.DataAvailabilityCertificate{
 		Timeout:     timeout,
 		DataHash:    dastree.Hash(message),
@@ -209,7 +393,7 @@ func (d *SignAfterStoreDASWriter) Store(
 	}
 
 	fields := c.SerializeSignableFields()
-	c.Sig, err = blsSignatures.SignMessage(d.privKey, fields)
+	c.Sig, err = key.signer.Sign(ctx, fields)
 	if err != nil {
 		return nil, err
 	}
@@ -223,7 +407,7 @@ func (d *SignAfterStoreDASWriter) Store(
 		return nil, err
 	}
 
-	c.KeysetHash = d.keysetHash
+	c.KeysetHash = key.keysetHash
 
 	return c, nil
 }
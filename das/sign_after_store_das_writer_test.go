@@ -0,0 +1,80 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+func TestSignAfterStoreDASWriterKeyRotation(t *testing.T) {
+	ctx := context.Background()
+
+	oldPrivKey, err := blsSignatures.GeneratePrivKeyString()
+	Require(t, err)
+	newPrivKey, err := blsSignatures.GeneratePrivKeyString()
+	Require(t, err)
+	config := DataAvailabilityConfig{
+		Enable:             true,
+		Key:                KeyConfig{PrivKey: oldPrivKey},
+		ParentChainNodeURL: "none",
+	}
+	storageService := NewMemoryBackedStorageService(ctx)
+	writer, err := NewSignAfterStoreDASWriter(ctx, config, storageService)
+	Require(t, err)
+
+	rotationKeyConfig := KeyConfig{Rotations: `[{"priv-key":"` + newPrivKey + `","activation-height":10}]`}
+	rotations, err := rotationKeyConfig.BLSKeyRotations()
+	Require(t, err)
+	var height uint64 = 5
+	Require(t, writer.AddKeyRotations(rotations, func(context.Context) (uint64, error) { return height, nil }))
+	newPubKey, err := blsSignatures.PublicKeyFromPrivateKey(rotations[0].PrivKey)
+	Require(t, err)
+	newKeysetHash, _, err := singleKeyKeyset(newPubKey)
+	Require(t, err)
+
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	oldMessage := []byte("stored before the rotation")
+	cert, err := writer.Store(ctx, oldMessage, timeout, nil)
+	Require(t, err)
+	if cert.KeysetHash != writer.keysetHash {
+		Fail(t, "expected the original keyset before the activation height")
+	}
+	verified, err := blsSignatures.VerifySignature(cert.Sig, cert.SerializeSignableFields(), *writer.pubKey)
+	Require(t, err)
+	if !verified {
+		Fail(t, "certificate wasn't signed with the original key")
+	}
+
+	height = 10
+	cert, err = writer.Store(ctx, []byte("stored after the rotation"), timeout, nil)
+	Require(t, err)
+	if cert.KeysetHash != newKeysetHash {
+		Fail(t, "expected the rotated keyset from the activation height")
+	}
+	verified, err = blsSignatures.VerifySignature(cert.Sig, cert.SerializeSignableFields(), newPubKey)
+	Require(t, err)
+	if !verified {
+		Fail(t, "certificate wasn't signed with the rotated key")
+	}
+
+	// Data stored under the previous keyset is still served.
+	_, err = storageService.GetByHash(ctx, dastree.Hash(oldMessage))
+	Require(t, err)
+
+	if err := writer.AddKeyRotations([]KeyRotation{{rotations[0].PrivKey, 10}}, nil); err == nil {
+		Fail(t, "expected duplicate activation heights to be rejected")
+	}
+
+	// Rotations only replace keys held in the process.
+	remoteKeyConfig := rotationKeyConfig
+	remoteKeyConfig.RemoteSigner.URL = "http://localhost:9000"
+	if _, err := remoteKeyConfig.BLSKeyRotations(); err == nil {
+		Fail(t, "expected rotations of a remote signer's key to be rejected")
+	}
+}
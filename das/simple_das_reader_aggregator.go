@@ -29,11 +29,15 @@ type RestfulClientAggregatorConfig struct {
 	Urls                         []string                           `koanf:"urls"`
 	OnlineUrlList                string                             `koanf:"online-url-list"`
 	OnlineUrlListFetchInterval   time.Duration                      `koanf:"online-url-list-fetch-interval"`
+	DNSSRVRefreshInterval        time.Duration                      `koanf:"dns-srv-refresh-interval"`
 	Strategy                     string                             `koanf:"strategy"`
 	StrategyUpdateInterval       time.Duration                      `koanf:"strategy-update-interval"`
 	WaitBeforeTryNext            time.Duration                      `koanf:"wait-before-try-next"`
+	MinParallelRequests          int                                `koanf:"min-parallel-requests"`
 	MaxPerEndpointStats          int                                `koanf:"max-per-endpoint-stats"`
+	HTTP3                        bool                               `koanf:"http3"`
 	SimpleExploreExploitStrategy SimpleExploreExploitStrategyConfig `koanf:"simple-explore-exploit-strategy"`
+	NearestFirstStrategy         NearestFirstStrategyConfig         `koanf:"nearest-first-strategy"`
 	SyncToStorage                SyncToStorageConfig                `koanf:"sync-to-storage"`
 }
 
@@ -41,11 +45,14 @@ var DefaultRestfulClientAggregatorConfig = RestfulClientAggregatorConfig{
 	Urls:                         []string{},
 	OnlineUrlList:                "",
 	OnlineUrlListFetchInterval:   1 * time.Hour,
+	DNSSRVRefreshInterval:        5 * time.Minute,
 	Strategy:                     "simple-explore-exploit",
 	StrategyUpdateInterval:       10 * time.Second,
 	WaitBeforeTryNext:            2 * time.Second,
+	MinParallelRequests:          1,
 	MaxPerEndpointStats:          20,
 	SimpleExploreExploitStrategy: DefaultSimpleExploreExploitStrategyConfig,
+	NearestFirstStrategy:         DefaultNearestFirstStrategyConfig,
 	SyncToStorage:                DefaultSyncToStorageConfig,
 }
 
@@ -59,32 +66,84 @@ var DefaultSimpleExploreExploitStrategyConfig = SimpleExploreExploitStrategyConf
 	ExploitIterations: 1000,
 }
 
+type NearestFirstStrategyConfig struct {
+	Region          string   `koanf:"region"`
+	EndpointRegions []string `koanf:"endpoint-regions"`
+}
+
+var DefaultNearestFirstStrategyConfig = NearestFirstStrategyConfig{
+	Region:          "",
+	EndpointRegions: []string{},
+}
+
+// parseEndpointRegions parses region=url tags into a map from url to region.
+func parseEndpointRegions(tags []string) (map[string]string, error) {
+	regions := make(map[string]string)
+	for _, tag := range tags {
+		region, url, found := strings.Cut(tag, "=")
+		if !found || region == "" || url == "" {
+			return nil, fmt.Errorf("invalid endpoint region '%s', expected <region>=<url>", tag)
+		}
+		regions[url] = region
+	}
+	return regions, nil
+}
+
 func RestfulClientAggregatorConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Bool(prefix+".enable", DefaultRestfulClientAggregatorConfig.Enable, "enable retrieval of sequencer batch data from a list of remote REST endpoints; if other DAS storage types are enabled, this mode is used as a fallback")
-	f.StringSlice(prefix+".urls", DefaultRestfulClientAggregatorConfig.Urls, "list of URLs including 'http://' or 'https://' prefixes and port numbers to REST DAS endpoints; additive with the online-url-list option")
+	f.StringSlice(prefix+".urls", DefaultRestfulClientAggregatorConfig.Urls, "list of URLs including 'http://' or 'https://' prefixes and port numbers to REST DAS endpoints, or 'srv+http://' or 'srv+https://' URLs naming DNS SRV records whose targets are all used; additive with the online-url-list option")
 	f.String(prefix+".online-url-list", DefaultRestfulClientAggregatorConfig.OnlineUrlList, "a URL to a list of URLs of REST das endpoints that is checked at startup; additive with the url option")
 	f.Duration(prefix+".online-url-list-fetch-interval", DefaultRestfulClientAggregatorConfig.OnlineUrlListFetchInterval, "time interval to periodically fetch url list from online-url-list")
-	f.String(prefix+".strategy", DefaultRestfulClientAggregatorConfig.Strategy, "strategy to use to determine order and parallelism of calling REST endpoint URLs; valid options are 'simple-explore-exploit'")
+	f.Duration(prefix+".dns-srv-refresh-interval", DefaultRestfulClientAggregatorConfig.DNSSRVRefreshInterval, "time interval to periodically re-resolve the DNS SRV records of srv+ urls")
+	f.String(prefix+".strategy", DefaultRestfulClientAggregatorConfig.Strategy, "strategy to use to determine order and parallelism of calling REST endpoint URLs; valid options are 'simple-explore-exploit' and 'nearest-first'")
 	f.Duration(prefix+".strategy-update-interval", DefaultRestfulClientAggregatorConfig.StrategyUpdateInterval, "how frequently to update the strategy with endpoint latency and error rate data")
 	f.Duration(prefix+".wait-before-try-next", DefaultRestfulClientAggregatorConfig.WaitBeforeTryNext, "time to wait until trying the next set of REST endpoints while waiting for a response; the next set of REST endpoints is determined by the strategy selected")
+	f.Int(prefix+".min-parallel-requests", DefaultRestfulClientAggregatorConfig.MinParallelRequests, "minimum number of REST endpoints to request from concurrently in each set, taking the next endpoints in the strategy's order; the first response matching the requested hash is returned and the other requests are cancelled (1 to request each of the strategy's sets as it is)")
+	f.Bool(prefix+".http3", DefaultRestfulClientAggregatorConfig.HTTP3, "fetch from the REST endpoints over HTTP/3, which recovers from packet loss on long links better; all the endpoints must be 'https://' URLs serving HTTP/3")
 	f.Int(prefix+".max-per-endpoint-stats", DefaultRestfulClientAggregatorConfig.MaxPerEndpointStats, "number of stats entries (latency and success rate) to keep for each REST endpoint; controls whether strategy is faster or slower to respond to changing conditions")
 	SimpleExploreExploitStrategyConfigAddOptions(prefix+".simple-explore-exploit-strategy", f)
+	NearestFirstStrategyConfigAddOptions(prefix+".nearest-first-strategy", f)
 	SyncToStorageConfigAddOptions(prefix+".sync-to-storage", f)
 }
 
+func (c *RestfulClientAggregatorConfig) newClient(url string) (*RestfulDasClient, error) {
+	if c.HTTP3 {
+		return NewHTTP3RestfulDasClientFromURL(url, nil)
+	}
+	return NewRestfulDasClientFromURL(url)
+}
+
 func SimpleExploreExploitStrategyConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Int(prefix+".explore-iterations", DefaultSimpleExploreExploitStrategyConfig.ExploreIterations, "number of consecutive GetByHash calls to the aggregator where each call will cause it to randomly select from REST endpoints until one returns successfully, before switching to exploit mode")
 	f.Int(prefix+".exploit-iterations", DefaultSimpleExploreExploitStrategyConfig.ExploitIterations, "number of consecutive GetByHash calls to the aggregator where each call will cause it to select from REST endpoints in order of best latency and success rate, before switching to explore mode")
 }
 
+func NearestFirstStrategyConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".region", DefaultNearestFirstStrategyConfig.Region, "region of this node; REST endpoints tagged with this region are tried first, then untagged endpoints, then endpoints in other regions, each in order of measured latency")
+	f.StringSlice(prefix+".endpoint-regions", DefaultNearestFirstStrategyConfig.EndpointRegions, "list of <region>=<url> tags giving the region of REST endpoints")
+}
+
 func NewRestfulClientAggregator(ctx context.Context, config *RestfulClientAggregatorConfig) (*SimpleDASReaderAggregator, error) {
+	return NewRestfulClientAggregatorWithRegistry(ctx, config, nil, 0)
+}
+
+// NewRestfulClientAggregatorWithRegistry also reads the REST URLs of the
+// committee members from the committee registry, if it isn't nil, every
+// registryRefreshInterval.
+func NewRestfulClientAggregatorWithRegistry(ctx context.Context, config *RestfulClientAggregatorConfig, registry *CommitteeRegistry, registryRefreshInterval time.Duration) (*SimpleDASReaderAggregator, error) {
 	a := SimpleDASReaderAggregator{
-		config: config,
-		stats:  make(map[arbstate.DataAvailabilityReader]readerStats),
+		config:                  config,
+		stats:                   make(map[arbstate.DataAvailabilityReader]readerStats),
+		registry:                registry,
+		registryRefreshInterval: registryRefreshInterval,
 	}
 
+	configUrls, err := resolveDNSSRVURLs(ctx, config.Urls)
+	if err != nil {
+		return nil, err
+	}
 	combinedUrls := make(map[string]bool)
-	for _, url := range config.Urls {
+	for _, url := range configUrls {
 		combinedUrls[url] = true
 	}
 	if config.OnlineUrlList != DefaultRestfulClientAggregatorConfig.OnlineUrlList {
@@ -96,8 +155,17 @@ func NewRestfulClientAggregator(ctx context.Context, config *RestfulClientAggreg
 			combinedUrls[url] = true
 		}
 	}
+	if registry != nil {
+		registryUrls, err := registry.RESTURLs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, url := range registryUrls {
+			combinedUrls[url] = true
+		}
+	}
 	if len(combinedUrls) == 0 {
-		return nil, errors.New("no URLs were specified with either of rest-aggregator.urls or rest-aggregator.online-url-list")
+		return nil, errors.New("no URLs were specified with any of rest-aggregator.urls, rest-aggregator.online-url-list or committee-registry")
 	}
 
 	urls := make([]string, 0, len(combinedUrls))
@@ -108,7 +176,7 @@ func NewRestfulClientAggregator(ctx context.Context, config *RestfulClientAggreg
 	log.Info("REST Aggregator URLs", "urls", urls)
 
 	for _, url := range urls {
-		reader, err := NewRestfulDasClientFromURL(url)
+		reader, err := config.newClient(url)
 		if err != nil {
 			return nil, err
 		}
@@ -123,6 +191,15 @@ func NewRestfulClientAggregator(ctx context.Context, config *RestfulClientAggreg
 			exploreIterations: uint32(config.SimpleExploreExploitStrategy.ExploreIterations),
 			exploitIterations: uint32(config.SimpleExploreExploitStrategy.ExploitIterations),
 		}
+	case "nearest-first":
+		regions, err := parseEndpointRegions(config.NearestFirstStrategy.EndpointRegions)
+		if err != nil {
+			return nil, err
+		}
+		a.strategy = &nearestFirstStrategy{
+			region:  config.NearestFirstStrategy.Region,
+			regions: regions,
+		}
 	case "testing-sequential":
 		a.strategy = &testingSequentialStrategy{}
 	default:
@@ -176,6 +253,9 @@ type SimpleDASReaderAggregator struct {
 	strategy aggregatorStrategy
 
 	statMessages chan readerStatMessage
+
+	registry                *CommitteeRegistry
+	registryRefreshInterval time.Duration
 }
 
 func (a *SimpleDASReaderAggregator) GetByHash(ctx context.Context, hash common.Hash) ([]byte, error) {
@@ -194,6 +274,12 @@ func (a *SimpleDASReaderAggregator) GetByHash(ctx context.Context, hash common.H
 
 	go func() {
 		si := a.strategy.newInstance()
+		if a.config.MinParallelRequests > 1 {
+			si = &minParallelStrategyInstance{
+				aggregatorStrategyInstance: si,
+				minParallel:                a.config.MinParallelRequests,
+			}
+		}
 		for readers := si.nextReaders(); len(readers) != 0 && subCtx.Err() == nil; readers = si.nextReaders() {
 			wg := sync.WaitGroup{}
 			waitChan := make(chan interface{})
@@ -202,7 +288,7 @@ func (a *SimpleDASReaderAggregator) GetByHash(ctx context.Context, hash common.H
 				go func(reader arbstate.DataAvailabilityReader) {
 					defer wg.Done()
 					data, err := a.tryGetByHash(subCtx, hash, reader)
-					if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+					if err != nil && errors.Is(subCtx.Err(), context.Canceled) {
 						// Don't record a stats data point when a different
 						// client returned faster than this one.
 						return
@@ -258,6 +344,11 @@ func (a *SimpleDASReaderAggregator) tryGetByHash(
 		}
 	}
 	stat.latency = time.Since(start)
+	if err != nil && ctx.Err() != nil {
+		// The request was cancelled because another reader responded first,
+		// which says nothing about this reader.
+		return result, err
+	}
 
 	select {
 	case a.statMessages <- stat:
@@ -272,15 +363,27 @@ func (a *SimpleDASReaderAggregator) tryGetByHash(
 func (a *SimpleDASReaderAggregator) Start(ctx context.Context) {
 	a.StopWaiter.Start(ctx, a)
 	onlineUrlsChan := StartRestfulServerListFetchDaemon(a.StopWaiter.GetContext(), a.config.OnlineUrlList, a.config.OnlineUrlListFetchInterval)
-
-	updateRestfulDasClients := func(urls []string) {
+	registryUrlsChan := StartCommitteeRegistryURLFetchDaemon(a.StopWaiter.GetContext(), a.registry, a.registryRefreshInterval)
+	srvUrlsChan := StartDNSSRVResolveDaemon(a.StopWaiter.GetContext(), a.config.Urls, a.config.DNSSRVRefreshInterval)
+
+	// The latest URLs from each source are kept, so an update from one
+	// source doesn't drop the others'.
+	var staticUrls, onlineUrls, registryUrls, srvUrls []string
+	for _, url := range a.config.Urls {
+		if !isDNSSRVURL(url) {
+			staticUrls = append(staticUrls, url)
+		}
+	}
+	updateRestfulDasClients := func() {
 		a.readersMutex.Lock()
 		defer a.readersMutex.Unlock()
-		combinedUrls := a.config.Urls
-		combinedUrls = append(combinedUrls, urls...)
+		combinedUrls := append([]string{}, staticUrls...)
+		combinedUrls = append(combinedUrls, srvUrls...)
+		combinedUrls = append(combinedUrls, onlineUrls...)
+		combinedUrls = append(combinedUrls, registryUrls...)
 		combinedReaders := make(map[arbstate.DataAvailabilityReader]bool)
 		for _, url := range combinedUrls {
-			reader, err := NewRestfulDasClientFromURL(url)
+			reader, err := a.config.newClient(url)
 			if err != nil {
 				return
 			}
@@ -321,8 +424,15 @@ func (a *SimpleDASReaderAggregator) Start(ctx context.Context) {
 				// Strategy update happens in same goroutine as updates to the stats
 				// to avoid needing extra synchronization.
 				a.strategy.update(a.readers, a.stats)
-			case onlineUrls := <-onlineUrlsChan:
-				updateRestfulDasClients(onlineUrls)
+			case urls := <-onlineUrlsChan:
+				onlineUrls = urls
+				updateRestfulDasClients()
+			case urls := <-registryUrlsChan:
+				registryUrls = urls
+				updateRestfulDasClients()
+			case urls := <-srvUrlsChan:
+				srvUrls = urls
+				updateRestfulDasClients()
 			}
 		}
 	})
@@ -7,6 +7,8 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"strings"
 	"testing"
@@ -84,3 +86,50 @@ func TestSimpleDASReaderAggregator(t *testing.T) { //nolint
 	Require(t, err)
 
 }
+
+func TestSimpleDASReaderAggregatorParallel(t *testing.T) {
+	initTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// These endpoints never respond, so if they were requested one at a time
+	// the data would only be retrieved after twice WaitBeforeTryNext.
+	var urls []string
+	for i := 0; i < 2; i++ {
+		hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		defer hanging.Close()
+		urls = append(urls, hanging.URL)
+	}
+
+	storage := NewMemoryBackedStorageService(ctx)
+	server, port, err := NewRestfulDasServerOnRandomPort(LocalServerAddressForTest, storage)
+	Require(t, err)
+	urls = append(urls, "http://localhost:"+strconv.Itoa(port))
+
+	data := []byte("Testing data that is only on the last REST endpoint.")
+	Require(t, storage.Put(ctx, data, uint64(time.Now().Add(time.Hour).Unix())))
+
+	config := RestfulClientAggregatorConfig{
+		Urls:                   urls,
+		Strategy:               "testing-sequential",
+		StrategyUpdateInterval: time.Second,
+		WaitBeforeTryNext:      time.Minute,
+		MinParallelRequests:    3,
+		MaxPerEndpointStats:    10,
+	}
+	agg, err := NewRestfulClientAggregator(ctx, &config)
+	Require(t, err)
+
+	getCtx, getCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer getCancel()
+	returnedData, err := agg.GetByHash(getCtx, dastree.Hash(data))
+	Require(t, err)
+	if !bytes.Equal(data, returnedData) {
+		Fail(t, fmt.Sprintf("Returned data '%s' does not match expected '%s'", returnedData, data))
+	}
+
+	Require(t, server.Shutdown())
+}
@@ -0,0 +1,71 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import "context"
+
+// StorageUsage is how much a storage backend holds.
+type StorageUsage struct {
+	Entries uint64 `json:"entries"`
+	Bytes   uint64 `json:"bytes"`
+}
+
+// StorageUsageReporter is implemented by storage backends that can count
+// what they hold. Counting may scan the whole backend, so it's only done when
+// asked for.
+type StorageUsageReporter interface {
+	StorageUsage(ctx context.Context) (*StorageUsage, error)
+}
+
+// StorageBackend is one of the persistent storage backends, named by its
+// config section, eg local-file-storage, before any encryption or compression.
+type StorageBackend struct {
+	Name    string
+	Service StorageService
+}
+
+type StorageBackendStats struct {
+	Name       string        `json:"name"`
+	Healthy    bool          `json:"healthy"`
+	Error      string        `json:"error,omitempty"`
+	Usage      *StorageUsage `json:"usage,omitempty"`
+	UsageError string        `json:"usageError,omitempty"`
+}
+
+// StorageBackendsStats health checks each of the backends and counts what
+// the ones that can report it hold.
+func StorageBackendsStats(ctx context.Context, backends []StorageBackend) []StorageBackendStats {
+	stats := make([]StorageBackendStats, 0, len(backends))
+	for _, backend := range backends {
+		s := StorageBackendStats{Name: backend.Name, Healthy: true}
+		if err := backend.Service.HealthCheck(ctx); err != nil {
+			s.Healthy = false
+			s.Error = err.Error()
+		}
+		if reporter := storageUsageReporter(backend.Service); reporter != nil {
+			usage, err := reporter.StorageUsage(ctx)
+			if err != nil {
+				s.UsageError = err.Error()
+			} else {
+				s.Usage = usage
+			}
+		}
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// storageUsageReporter looks through the wrappers that make a backend
+// iterable.
+func storageUsageReporter(s StorageService) StorageUsageReporter {
+	switch s := s.(type) {
+	case StorageUsageReporter:
+		return s
+	case *IterableStorageService:
+		return storageUsageReporter(s.IterationCompatibleStorageService)
+	case *IterationCompatibleStorageServiceAdaptor:
+		return storageUsageReporter(s.StorageService)
+	}
+	return nil
+}
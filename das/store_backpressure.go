@@ -0,0 +1,318 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbstate"
+
+	flag "github.com/spf13/pflag"
+)
+
+var (
+	backpressureRunningGauge       = metrics.NewRegisteredGauge("arb/das/store/backpressure/running", nil)
+	backpressureQueuedGauge        = metrics.NewRegisteredGauge("arb/das/store/backpressure/queued", nil)
+	backpressureRejectedCounter    = metrics.NewRegisteredCounter("arb/das/store/backpressure/rejected", nil)
+	backpressureQueueWaitHistogram = metrics.NewRegisteredHistogram("arb/das/store/backpressure/wait", nil, metrics.NewBoundedHistogramSample())
+)
+
+var (
+	ErrStoreQueueFull    = errors.New("too many stores are queued")
+	ErrStoreQueueTimeout = errors.New("store waited too long to be handled")
+)
+
+type StoreBackpressureConfig struct {
+	Enable              bool          `koanf:"enable"`
+	MaxConcurrentStores int           `koanf:"max-concurrent-stores"`
+	MaxQueuedStores     int           `koanf:"max-queued-stores"`
+	MaxQueueWait        time.Duration `koanf:"max-queue-wait"`
+	MinRetryAfter       time.Duration `koanf:"min-retry-after"`
+}
+
+var DefaultStoreBackpressureConfig = StoreBackpressureConfig{
+	Enable:              false,
+	MaxConcurrentStores: 16,
+	MaxQueuedStores:     64,
+	MaxQueueWait:        5 * time.Second,
+	MinRetryAfter:       time.Second,
+}
+
+func StoreBackpressureConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultStoreBackpressureConfig.Enable, "enable rejecting stores with an overloaded error, answered over HTTP with 429 Too Many Requests and Retry-After, once storage falls behind, instead of letting them pile up and time out")
+	f.Int(prefix+".max-concurrent-stores", DefaultStoreBackpressureConfig.MaxConcurrentStores, "stores are queued while this many are being handled")
+	f.Int(prefix+".max-queued-stores", DefaultStoreBackpressureConfig.MaxQueuedStores, "most stores that may be queued, beyond which they're rejected")
+	f.Duration(prefix+".max-queue-wait", DefaultStoreBackpressureConfig.MaxQueueWait, "how long a store may be queued before it's rejected")
+	f.Duration(prefix+".min-retry-after", DefaultStoreBackpressureConfig.MinRetryAfter, "shortest time rejected clients are told to wait before retrying; it's longer when stores are slow")
+}
+
+func (c *StoreBackpressureConfig) Validate() error {
+	if c.Enable && (c.MaxConcurrentStores < 1 || c.MaxQueuedStores < 0 || c.MaxQueueWait <= 0) {
+		return errors.New("store-backpressure requires positive max-concurrent-stores and max-queue-wait")
+	}
+	return nil
+}
+
+// overloadedErrorCode is the JSON-RPC "limit exceeded" error code.
+const overloadedErrorCode = -32005
+
+// OverloadedError is returned for stores rejected because the server can't
+// keep up, telling the client when it's worth retrying.
+type OverloadedError struct {
+	Reason     error
+	RetryAfter time.Duration
+	Queued     int
+}
+
+func (e *OverloadedError) Error() string {
+	return fmt.Sprintf("server overloaded: %v, retry after %v", e.Reason, e.RetryAfter)
+}
+
+func (e *OverloadedError) Unwrap() error {
+	return e.Reason
+}
+
+func (e *OverloadedError) ErrorCode() int {
+	return overloadedErrorCode
+}
+
+type overloadedErrorData struct {
+	RetryAfter int `json:"retryAfter"`
+	Queued     int `json:"queued"`
+}
+
+func (e *OverloadedError) ErrorData() interface{} {
+	return overloadedErrorData{RetryAfter: e.retryAfterSeconds(), Queued: e.Queued}
+}
+
+func (e *OverloadedError) retryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// overloadedErrorFromHTTP recovers the OverloadedError from the JSON-RPC
+// error in the body of an HTTP-RPC server's 429 response.
+func overloadedErrorFromHTTP(err error) error {
+	var httpErr rpc.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusTooManyRequests {
+		return err
+	}
+	var response struct {
+		Error *struct {
+			Message string              `json:"message"`
+			Data    overloadedErrorData `json:"data"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(httpErr.Body, &response) != nil || response.Error == nil {
+		return err
+	}
+	return &OverloadedError{
+		Reason:     errors.New(response.Error.Message),
+		RetryAfter: time.Duration(response.Error.Data.RetryAfter) * time.Second,
+		Queued:     response.Error.Data.Queued,
+	}
+}
+
+type overloadedKey struct{}
+
+// withOverloadNote lets the HTTP-RPC server answer a request with 429 Too
+// Many Requests if one of its stores is rejected as overloaded.
+func withOverloadNote(r *http.Request) (*http.Request, *overloadNote) {
+	note := &overloadNote{}
+	return r.WithContext(context.WithValue(r.Context(), overloadedKey{}, note)), note
+}
+
+type overloadNote struct {
+	mutex sync.Mutex
+	err   *OverloadedError
+}
+
+// noteOverloaded records err in the request's overload note if it's an
+// OverloadedError.
+func noteOverloaded(ctx context.Context, err error) {
+	var overloaded *OverloadedError
+	note, _ := ctx.Value(overloadedKey{}).(*overloadNote)
+	if note == nil || !errors.As(err, &overloaded) {
+		return
+	}
+	note.mutex.Lock()
+	defer note.mutex.Unlock()
+	note.err = overloaded
+}
+
+func (n *overloadNote) overloaded() *OverloadedError {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.err
+}
+
+// overloadResponseWriter turns the HTTP-RPC server's 200 OK into 429 Too Many
+// Requests with Retry-After if the request was rejected as overloaded.
+type overloadResponseWriter struct {
+	http.ResponseWriter
+	note        *overloadNote
+	wroteHeader bool
+}
+
+func (w *overloadResponseWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if overloaded := w.note.overloaded(); overloaded != nil {
+			w.Header().Set("Retry-After", strconv.Itoa(overloaded.retryAfterSeconds()))
+			if code == http.StatusOK {
+				code = http.StatusTooManyRequests
+			}
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *overloadResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *overloadResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// StoreBackpressure limits how many stores the writer it wraps handles at
+// once, queuing the rest for a bounded time and rejecting them with an
+// OverloadedError when the queue is full or they've waited too long.
+type StoreBackpressure struct {
+	DataAvailabilityServiceWriter
+	config StoreBackpressureConfig
+
+	mutex   sync.Mutex
+	running int
+	queue   []chan struct{}
+	// Moving average of how long stores take, to estimate when the queue
+	// will have room.
+	storeDuration time.Duration
+}
+
+// NewStoreBackpressure returns writer itself if backpressure isn't enabled.
+func NewStoreBackpressure(writer DataAvailabilityServiceWriter, config StoreBackpressureConfig) (DataAvailabilityServiceWriter, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if !config.Enable {
+		return writer, nil
+	}
+	return &StoreBackpressure{DataAvailabilityServiceWriter: writer, config: config}, nil
+}
+
+// overloadedLocked must be called with the mutex held.
+func (b *StoreBackpressure) overloadedLocked(reason error) *OverloadedError {
+	backpressureRejectedCounter.Inc(1)
+	retryAfter := b.storeDuration * time.Duration(len(b.queue)+1) / time.Duration(b.config.MaxConcurrentStores)
+	if retryAfter < b.config.MinRetryAfter {
+		retryAfter = b.config.MinRetryAfter
+	}
+	return &OverloadedError{Reason: reason, RetryAfter: retryAfter, Queued: len(b.queue)}
+}
+
+func (b *StoreBackpressure) admit(ctx context.Context) error {
+	b.mutex.Lock()
+	if b.running < b.config.MaxConcurrentStores && len(b.queue) == 0 {
+		b.running++
+		backpressureRunningGauge.Update(int64(b.running))
+		b.mutex.Unlock()
+		return nil
+	}
+	if len(b.queue) >= b.config.MaxQueuedStores {
+		err := b.overloadedLocked(ErrStoreQueueFull)
+		b.mutex.Unlock()
+		return err
+	}
+	admitted := make(chan struct{})
+	b.queue = append(b.queue, admitted)
+	backpressureQueuedGauge.Update(int64(len(b.queue)))
+	b.mutex.Unlock()
+
+	start := time.Now()
+	defer func() {
+		backpressureQueueWaitHistogram.Update(time.Since(start).Nanoseconds())
+	}()
+	timer := time.NewTimer(b.config.MaxQueueWait)
+	defer timer.Stop()
+	var err error
+	select {
+	case <-admitted:
+		return nil
+	case <-timer.C:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for i, queued := range b.queue {
+		if queued == admitted {
+			b.queue = append(b.queue[:i], b.queue[i+1:]...)
+			backpressureQueuedGauge.Update(int64(len(b.queue)))
+			if err == nil {
+				err = b.overloadedLocked(ErrStoreQueueTimeout)
+			}
+			return err
+		}
+	}
+	// Admitted as it gave up. One that timed out may as well use the slot,
+	// but a cancelled one gives it to the next store.
+	if err != nil {
+		b.releaseLocked()
+	}
+	return err
+}
+
+func (b *StoreBackpressure) release(duration time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.storeDuration == 0 {
+		b.storeDuration = duration
+	} else {
+		b.storeDuration = (b.storeDuration*7 + duration) / 8
+	}
+	b.releaseLocked()
+}
+
+func (b *StoreBackpressure) releaseLocked() {
+	b.running--
+	for b.running < b.config.MaxConcurrentStores && len(b.queue) > 0 {
+		close(b.queue[0])
+		b.queue = b.queue[1:]
+		b.running++
+	}
+	backpressureRunningGauge.Update(int64(b.running))
+	backpressureQueuedGauge.Update(int64(len(b.queue)))
+}
+
+func (b *StoreBackpressure) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	if err := b.admit(ctx); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	defer func() {
+		b.release(time.Since(start))
+	}()
+	return b.DataAvailabilityServiceWriter.Store(ctx, message, timeout, sig)
+}
+
+func (b *StoreBackpressure) String() string {
+	return fmt.Sprintf("StoreBackpressure{%v}", b.DataAvailabilityServiceWriter)
+}
@@ -0,0 +1,89 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestStoreBackpressure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	storageService := NewMemoryBackedStorageService(ctx)
+	keyDir := t.TempDir()
+	_, _, err := GenerateAndStoreKeys(keyDir)
+	testhelpers.RequireImpl(t, err)
+	privKey, err := (&KeyConfig{KeyDir: keyDir}).BLSPrivKey()
+	testhelpers.RequireImpl(t, err)
+	localDas, err := NewSignAfterStoreDASWriterWithSeqInboxCaller(privKey, nil, storageService, "")
+	testhelpers.RequireImpl(t, err)
+
+	blocking := &blockingWriter{localDas, make(chan struct{}, 1), make(chan struct{})}
+	writer, err := NewStoreBackpressure(blocking, StoreBackpressureConfig{
+		Enable:              true,
+		MaxConcurrentStores: 1,
+		MaxQueuedStores:     1,
+		MaxQueueWait:        200 * time.Millisecond,
+		MinRetryAfter:       2 * time.Second,
+	})
+	testhelpers.RequireImpl(t, err)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	testhelpers.RequireImpl(t, err)
+	dasServer, err := StartDASRPCServerOnListener(ctx, lis, genericconf.HTTPServerTimeoutConfigDefault, nil, DefaultStoreBoundsConfig, storageService, writer, storageService)
+	testhelpers.RequireImpl(t, err)
+	defer func() {
+		testhelpers.RequireImpl(t, dasServer.Shutdown(ctx))
+	}()
+	client, err := NewDASRPCClient("http://" + lis.Addr().String())
+	testhelpers.RequireImpl(t, err)
+
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	store := func() error {
+		_, err := client.Store(ctx, testhelpers.RandomizeSlice(make([]byte, 100)), timeout, nil)
+		return err
+	}
+
+	// The first store holds the only slot until it's released.
+	firstErr := make(chan error, 1)
+	go func() { firstErr <- store() }()
+	<-blocking.started
+
+	// The second waits in the queue until it gives up, during which a third
+	// finds the queue full.
+	secondErr := make(chan error, 1)
+	go func() { secondErr <- store() }()
+	time.Sleep(50 * time.Millisecond)
+	var overloaded *OverloadedError
+	if err := store(); !errors.As(err, &overloaded) || overloaded.Queued != 1 || overloaded.RetryAfter < 2*time.Second {
+		testhelpers.FailImpl(t, "expected a store to be rejected with the queue full, got", err)
+	}
+	if err := <-secondErr; !errors.As(err, &overloaded) || overloaded.RetryAfter < 2*time.Second {
+		testhelpers.FailImpl(t, "expected a queued store to time out as overloaded, got", err)
+	}
+
+	close(blocking.release)
+	testhelpers.RequireImpl(t, <-firstErr)
+	testhelpers.RequireImpl(t, store())
+}
+
+func TestAggregatorBacksOffOverloadedBackend(t *testing.T) {
+	health := newBackendHealth(DefaultCircuitBreakerConfig, "test_overloaded")
+	health.recordFailure(&OverloadedError{Reason: ErrStoreQueueFull, RetryAfter: time.Minute})
+	now := time.Now()
+	if health.available(now) {
+		testhelpers.FailImpl(t, "overloaded backend should be skipped until its retry after")
+	}
+	if !health.available(now.Add(2 * time.Minute)) {
+		testhelpers.FailImpl(t, "overloaded backend should be tried again after its retry after")
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbstate"
+
+	flag "github.com/spf13/pflag"
+)
+
+var storeBoundsRejectedCounter = metrics.NewRegisteredCounter("arb/das/store/bounds/rejected", nil)
+
+type StoreBoundsConfig struct {
+	MaxPayloadSize int           `koanf:"max-payload-size"`
+	MinTimeout     time.Duration `koanf:"min-timeout"`
+	MaxTimeout     time.Duration `koanf:"max-timeout"`
+}
+
+var DefaultStoreBoundsConfig = StoreBoundsConfig{}
+
+func StoreBoundsConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Int(prefix+".max-payload-size", DefaultStoreBoundsConfig.MaxPayloadSize, "largest payload in bytes accepted by Store, eg the chain's maximum batch size (0 for no limit)")
+	f.Duration(prefix+".min-timeout", DefaultStoreBoundsConfig.MinTimeout, "shortest time from now until a stored payload's timeout accepted by Store (0 for no limit)")
+	f.Duration(prefix+".max-timeout", DefaultStoreBoundsConfig.MaxTimeout, "longest time from now until a stored payload's timeout accepted by Store (0 for no limit)")
+}
+
+func (c *StoreBoundsConfig) enabled() bool {
+	return c.MaxPayloadSize > 0 || c.MinTimeout > 0 || c.MaxTimeout > 0
+}
+
+func (c *StoreBoundsConfig) Validate() error {
+	if c.MaxPayloadSize < 0 || c.MinTimeout < 0 || c.MaxTimeout < 0 {
+		return errors.New("store bounds can't be negative")
+	}
+	if c.MaxTimeout > 0 && c.MinTimeout > c.MaxTimeout {
+		return fmt.Errorf("store-bounds.min-timeout %v is longer than max-timeout %v", c.MinTimeout, c.MaxTimeout)
+	}
+	return nil
+}
+
+// PayloadTooLargeError is returned by Store for a payload larger than the
+// configured maximum.
+type PayloadTooLargeError struct {
+	Size    int
+	MaxSize int
+}
+
+func (e *PayloadTooLargeError) Error() string {
+	return fmt.Sprintf("payload of %d bytes is larger than the maximum of %d bytes", e.Size, e.MaxSize)
+}
+
+// storeBoundsErrorCode is the JSON-RPC invalid params error code, which the
+// RPC server reports the store bounds errors with.
+const storeBoundsErrorCode = -32602
+
+func (e *PayloadTooLargeError) ErrorCode() int {
+	return storeBoundsErrorCode
+}
+
+// TimeoutOutOfBoundsError is returned by Store for a timeout outside of the
+// configured bounds, which are relative to when the store was received.
+type TimeoutOutOfBoundsError struct {
+	Timeout    time.Time
+	MinTimeout time.Time
+	MaxTimeout time.Time
+}
+
+func (e *TimeoutOutOfBoundsError) Error() string {
+	if e.Timeout.Before(e.MinTimeout) {
+		return fmt.Sprintf("store timeout %v is earlier than the minimum of %v", e.Timeout, e.MinTimeout)
+	}
+	return fmt.Sprintf("store timeout %v is later than the maximum of %v", e.Timeout, e.MaxTimeout)
+}
+
+func (e *TimeoutOutOfBoundsError) ErrorCode() int {
+	return storeBoundsErrorCode
+}
+
+// StoreBoundsChecker rejects stores outside of the configured payload size and
+// timeout bounds before they reach the writer it wraps.
+type StoreBoundsChecker struct {
+	DataAvailabilityServiceWriter
+	config StoreBoundsConfig
+}
+
+// NewStoreBoundsChecker returns writer itself if no bounds are configured.
+func NewStoreBoundsChecker(writer DataAvailabilityServiceWriter, config StoreBoundsConfig) (DataAvailabilityServiceWriter, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if !config.enabled() {
+		return writer, nil
+	}
+	return &StoreBoundsChecker{DataAvailabilityServiceWriter: writer, config: config}, nil
+}
+
+func (c *StoreBoundsChecker) check(message []byte, timeout uint64) error {
+	if c.config.MaxPayloadSize > 0 && len(message) > c.config.MaxPayloadSize {
+		return &PayloadTooLargeError{Size: len(message), MaxSize: c.config.MaxPayloadSize}
+	}
+	if c.config.MinTimeout == 0 && c.config.MaxTimeout == 0 {
+		return nil
+	}
+	now := time.Now()
+	// Timeouts too large for time.Unix are later than any maximum.
+	timeoutTime := time.Unix(int64(timeout), 0)
+	if timeout > uint64(1<<62) {
+		timeoutTime = time.Unix(1<<62, 0)
+	}
+	err := &TimeoutOutOfBoundsError{Timeout: timeoutTime}
+	if c.config.MinTimeout > 0 {
+		err.MinTimeout = now.Add(c.config.MinTimeout).Truncate(time.Second)
+		if timeoutTime.Before(err.MinTimeout) {
+			return err
+		}
+	}
+	if c.config.MaxTimeout > 0 {
+		err.MaxTimeout = now.Add(c.config.MaxTimeout)
+		if timeoutTime.After(err.MaxTimeout) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *StoreBoundsChecker) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	if err := c.check(message, timeout); err != nil {
+		storeBoundsRejectedCounter.Inc(1)
+		return nil, err
+	}
+	return c.DataAvailabilityServiceWriter.Store(ctx, message, timeout, sig)
+}
+
+func (c *StoreBoundsChecker) String() string {
+	return fmt.Sprintf("StoreBoundsChecker{%v}", c.DataAvailabilityServiceWriter)
+}
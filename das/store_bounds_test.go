@@ -0,0 +1,50 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestStoreBoundsChecker(t *testing.T) {
+	ctx := context.Background()
+	inner := &certStore{}
+	writer, err := NewStoreBoundsChecker(inner, StoreBoundsConfig{MaxPayloadSize: 10, MinTimeout: time.Hour, MaxTimeout: 24 * time.Hour})
+	Require(t, err)
+
+	inTwoHours := uint64(time.Now().Add(2 * time.Hour).Unix())
+	_, err = writer.Store(ctx, make([]byte, 10), inTwoHours, nil)
+	Require(t, err)
+
+	var payloadTooLarge *PayloadTooLargeError
+	if _, err := writer.Store(ctx, make([]byte, 11), inTwoHours, nil); !errors.As(err, &payloadTooLarge) || payloadTooLarge.Size != 11 {
+		Fail(t, "expected oversized payload to be rejected", err)
+	}
+	for _, timeout := range []uint64{
+		uint64(time.Now().Add(time.Minute).Unix()),
+		uint64(time.Now().Add(48 * time.Hour).Unix()),
+		math.MaxUint64,
+	} {
+		var timeoutOutOfBounds *TimeoutOutOfBoundsError
+		if _, err := writer.Store(ctx, []byte("batch"), timeout, nil); !errors.As(err, &timeoutOutOfBounds) {
+			Fail(t, "expected timeout to be rejected", timeout, err)
+		}
+	}
+	if inner.calls != 1 {
+		Fail(t, "unexpected number of stores", inner.calls)
+	}
+
+	if _, err := NewStoreBoundsChecker(inner, StoreBoundsConfig{MinTimeout: time.Hour, MaxTimeout: time.Minute}); err == nil {
+		Fail(t, "expected min-timeout longer than max-timeout to be invalid")
+	}
+	writer, err = NewStoreBoundsChecker(inner, DefaultStoreBoundsConfig)
+	Require(t, err)
+	if writer != DataAvailabilityServiceWriter(inner) {
+		Fail(t, "expected no checker by default")
+	}
+}
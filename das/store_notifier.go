@@ -0,0 +1,90 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbstate"
+)
+
+var storeNotificationsDroppedCounter = metrics.NewRegisteredCounter("arb/das/notifications/stored/dropped", nil)
+
+// StoredData is the notification sent to subscribers each time a Store
+// succeeds.
+type StoredData struct {
+	DataHash common.Hash    `json:"dataHash"`
+	Size     hexutil.Uint64 `json:"size"`
+	Timeout  hexutil.Uint64 `json:"timeout"`
+}
+
+// StoreNotifier notifies subscribers of the data stored through the writer it
+// wraps, so mirrors and indexers can follow the DAS without polling.
+type StoreNotifier struct {
+	DataAvailabilityServiceWriter
+
+	mutex       sync.Mutex
+	subscribers map[chan<- StoredData]struct{}
+}
+
+func NewStoreNotifier(writer DataAvailabilityServiceWriter) *StoreNotifier {
+	return &StoreNotifier{
+		DataAvailabilityServiceWriter: writer,
+		subscribers:                   make(map[chan<- StoredData]struct{}),
+	}
+}
+
+func (n *StoreNotifier) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	cert, err := n.DataAvailabilityServiceWriter.Store(ctx, message, timeout, sig)
+	if err != nil {
+		return nil, err
+	}
+	n.notify(StoredData{
+		DataHash: cert.DataHash,
+		Size:     hexutil.Uint64(len(message)),
+		Timeout:  hexutil.Uint64(timeout),
+	})
+	return cert, nil
+}
+
+func (n *StoreNotifier) AuthorizeStore(ctx context.Context) error {
+	return authorizeStore(ctx, n.DataAvailabilityServiceWriter)
+}
+
+// notify never blocks Store: subscribers that fall behind miss notifications
+// rather than delaying the batch poster.
+func (n *StoreNotifier) notify(stored StoredData) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	for ch := range n.subscribers {
+		select {
+		case ch <- stored:
+		default:
+			storeNotificationsDroppedCounter.Inc(1)
+		}
+	}
+}
+
+// SubscribeStored sends a notification to ch for each subsequent successful
+// Store until the returned function is called to unsubscribe.
+func (n *StoreNotifier) SubscribeStored(ch chan<- StoredData) func() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.subscribers[ch] = struct{}{}
+	return func() {
+		n.mutex.Lock()
+		defer n.mutex.Unlock()
+		delete(n.subscribers, ch)
+	}
+}
+
+func (n *StoreNotifier) String() string {
+	return fmt.Sprintf("StoreNotifier{%v}", n.DataAvailabilityServiceWriter)
+}
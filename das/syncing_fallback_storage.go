@@ -204,14 +204,7 @@ func (s *l1SyncService) processBatchDelivered(ctx context.Context, batchDelivere
 		return nil
 	}
 
-	header := make([]byte, 40)
-	binary.BigEndian.PutUint64(header[:8], deliveredEvent.TimeBounds.MinTimestamp)
-	binary.BigEndian.PutUint64(header[8:16], deliveredEvent.TimeBounds.MaxTimestamp)
-	binary.BigEndian.PutUint64(header[16:24], deliveredEvent.TimeBounds.MinBlockNumber)
-	binary.BigEndian.PutUint64(header[24:32], deliveredEvent.TimeBounds.MaxBlockNumber)
-	binary.BigEndian.PutUint64(header[32:40], deliveredEvent.AfterDelayedMessagesRead.Uint64())
-
-	data = append(header, data...)
+	data = sequencerMessageFromDASData(deliveredEvent, data)
 	preimages := make(map[arbutil.PreimageType]map[common.Hash][]byte)
 	if _, err = arbstate.RecoverPayloadFromDasBatch(ctx, deliveredEvent.BatchSequenceNumber.Uint64(), data, s.dataSource, preimages, arbstate.KeysetValidate); err != nil {
 		log.Error("recover payload failed", "txhash", batchDeliveredLog.TxHash, "data", data)
@@ -244,6 +237,19 @@ func (s *l1SyncService) processBatchDelivered(ctx context.Context, batchDelivere
 	return nil
 }
 
+// sequencerMessageFromDASData prepends the batch's header to the DAS data
+// posted for it, giving the sequencer message the batch's payload is
+// recovered from.
+func sequencerMessageFromDASData(deliveredEvent *bridgegen.SequencerInboxSequencerBatchDelivered, data []byte) []byte {
+	header := make([]byte, 40)
+	binary.BigEndian.PutUint64(header[:8], deliveredEvent.TimeBounds.MinTimestamp)
+	binary.BigEndian.PutUint64(header[8:16], deliveredEvent.TimeBounds.MaxTimestamp)
+	binary.BigEndian.PutUint64(header[16:24], deliveredEvent.TimeBounds.MinBlockNumber)
+	binary.BigEndian.PutUint64(header[24:32], deliveredEvent.TimeBounds.MaxBlockNumber)
+	binary.BigEndian.PutUint64(header[32:40], deliveredEvent.AfterDelayedMessagesRead.Uint64())
+	return append(header, data...)
+}
+
 func FindDASDataFromLog(
 	ctx context.Context,
 	inboxContract *bridgegen.SequencerInbox,
@@ -442,8 +448,18 @@ func NewSyncingFallbackStorageService(ctx context.Context,
 	}, nil
 }
 
+// Close waits until ctx is done for the sync in progress to stop, so that it
+// doesn't write to storage that's been closed.
 func (s *SyncingFallbackStorageService) Close(ctx context.Context) error {
 	s.syncService.StopOnly()
+	waitChan, err := s.syncService.GetWaitChannel()
+	if err == nil {
+		select {
+		case <-waitChan:
+		case <-ctx.Done():
+			log.Warn("Timed out waiting for the L1 sync to stop", "err", ctx.Err())
+		}
+	}
 	s.FallbackStorageService.Close(ctx)
 	return nil
 }
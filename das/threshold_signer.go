@@ -0,0 +1,186 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/blsSignatures"
+
+	flag "github.com/spf13/pflag"
+)
+
+var thresholdShareFailuresCounter = metrics.NewRegisteredCounter("arb/das/thresholdsigner/sharefailures", nil)
+
+type ThresholdSignerConfig struct {
+	Threshold      int           `koanf:"threshold"`
+	Shares         string        `koanf:"shares"`
+	GroupPublicKey string        `koanf:"group-public-key"`
+	Timeout        time.Duration `koanf:"timeout"`
+}
+
+var DefaultThresholdSignerConfig = ThresholdSignerConfig{
+	Timeout: 5 * time.Second,
+}
+
+func ThresholdSignerConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Int(prefix+".threshold", DefaultThresholdSignerConfig.Threshold, "number of key shares whose signatures are combined into the member's signature, as chosen in 'datool dkg'")
+	f.String(prefix+".shares", DefaultThresholdSignerConfig.Shares, "JSON list of the key shares of the member's key, each with the \"index\" it was generated with by 'datool dkg' and either the \"url\" of a 'daserver remote-signer' holding it, with its \"public-key\" and \"bearer-token-file\", \"client-cert\", \"client-key\" and \"root-ca\" as in remote-signer, or a local \"key-dir\"; if set, certificates are signed by combining the shares' signatures instead of with key-dir or priv-key")
+	f.String(prefix+".group-public-key", DefaultThresholdSignerConfig.GroupPublicKey, "base64 public key of the member, as written by 'datool dkg finalize', or a file containing it")
+	f.Duration(prefix+".timeout", DefaultThresholdSignerConfig.Timeout, "timeout for collecting enough signature shares")
+}
+
+// ThresholdShareConfig is a key share, held either by a remote signer or in
+// a local key directory.
+type ThresholdShareConfig struct {
+	Index           uint64 `json:"index"`
+	URL             string `json:"url"`
+	PublicKey       string `json:"public-key"`
+	BearerTokenFile string `json:"bearer-token-file"`
+	ClientCert      string `json:"client-cert"`
+	ClientKey       string `json:"client-key"`
+	RootCA          string `json:"root-ca"`
+	KeyDir          string `json:"key-dir"`
+}
+
+type thresholdShare struct {
+	index  uint64
+	signer BLSSigner
+}
+
+// ThresholdBLSSigner signs for a member whose key was split into shares by
+// 'datool dkg', combining the signatures of any threshold of the shares, so
+// no one machine holds the member's key.
+type ThresholdBLSSigner struct {
+	shares    []thresholdShare
+	threshold int
+	pubKey    blsSignatures.PublicKey
+	timeout   time.Duration
+}
+
+// NewThresholdBLSSigner doesn't contact the shares' remote signers, so the
+// member can start while some of them are down. keyConfig supplies the
+// passphrase of encrypted local shares.
+func NewThresholdBLSSigner(ctx context.Context, config ThresholdSignerConfig, keyConfig *KeyConfig) (*ThresholdBLSSigner, error) {
+	var shareConfigs []ThresholdShareConfig
+	if err := json.Unmarshal([]byte(config.Shares), &shareConfigs); err != nil {
+		return nil, fmt.Errorf("'threshold.shares' was invalid: %w", err)
+	}
+	if config.Threshold < 1 || config.Threshold > len(shareConfigs) {
+		return nil, fmt.Errorf("threshold.threshold must be between 1 and the number of shares, %d", len(shareConfigs))
+	}
+	if config.GroupPublicKey == "" {
+		return nil, errors.New("threshold.group-public-key must be set")
+	}
+	pubKey, err := DecodeBase64BLSPublicKey([]byte(config.GroupPublicKey))
+	if err != nil {
+		contents, readErr := os.ReadFile(config.GroupPublicKey)
+		if readErr != nil {
+			return nil, fmt.Errorf("threshold.group-public-key is neither a base64 public key nor a readable file: %w", err)
+		}
+		if pubKey, err = DecodeBase64BLSPublicKey(bytes.TrimSpace(contents)); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &ThresholdBLSSigner{threshold: config.Threshold, pubKey: *pubKey, timeout: config.Timeout}
+	indexes := make(map[uint64]bool)
+	for _, sc := range shareConfigs {
+		if sc.Index == 0 || indexes[sc.Index] {
+			return nil, fmt.Errorf("threshold share indexes must be distinct and start at 1, got %d", sc.Index)
+		}
+		indexes[sc.Index] = true
+		var signer BLSSigner
+		if sc.KeyDir != "" {
+			shareKeyConfig := KeyConfig{KeyDir: sc.KeyDir, Passphrase: keyConfig.Passphrase, PassphraseFile: keyConfig.PassphraseFile}
+			signer, err = shareKeyConfig.BLSSigner(ctx)
+		} else {
+			// Without its public key, a compromised share's signer could
+			// report a key of its choosing and spoil every combination.
+			if sc.URL == "" || sc.PublicKey == "" {
+				return nil, fmt.Errorf("threshold share %d must set key-dir, or url and public-key", sc.Index)
+			}
+			signer, err = dialRemoteBLSSigner(ctx, RemoteSignerConfig{
+				URL:             sc.URL,
+				BearerTokenFile: sc.BearerTokenFile,
+				ClientCert:      sc.ClientCert,
+				ClientKey:       sc.ClientKey,
+				RootCA:          sc.RootCA,
+				PublicKey:       sc.PublicKey,
+				Timeout:         config.Timeout,
+			})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold share %d: %w", sc.Index, err)
+		}
+		s.shares = append(s.shares, thresholdShare{sc.Index, signer})
+	}
+	return s, nil
+}
+
+func (s *ThresholdBLSSigner) PublicKey() blsSignatures.PublicKey {
+	return s.pubKey
+}
+
+// Sign asks every share to sign, and combines the first threshold signature
+// shares to arrive, each checked against its share's public key.
+func (s *ThresholdBLSSigner) Sign(ctx context.Context, message []byte) (blsSignatures.Signature, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	type shareResult struct {
+		index uint64
+		sig   blsSignatures.Signature
+		err   error
+	}
+	results := make(chan shareResult, len(s.shares))
+	for _, share := range s.shares {
+		go func(share thresholdShare) {
+			sig, err := share.signer.Sign(ctx, message)
+			results <- shareResult{share.index, sig, err}
+		}(share)
+	}
+
+	sigShares := make(map[uint64]blsSignatures.Signature)
+	var errs []error
+	for range s.shares {
+		result := <-results
+		if result.err != nil {
+			thresholdShareFailuresCounter.Inc(1)
+			log.Warn("Threshold share failed to sign", "index", result.index, "err", result.err)
+			errs = append(errs, fmt.Errorf("share %d: %w", result.index, result.err))
+			continue
+		}
+		sigShares[result.index] = result.sig
+		if len(sigShares) < s.threshold {
+			continue
+		}
+		sig, err := blsSignatures.CombineSignatureShares(sigShares)
+		if err != nil {
+			return nil, err
+		}
+		verified, err := blsSignatures.VerifySignature(sig, message, s.pubKey)
+		if err != nil {
+			return nil, err
+		}
+		if !verified {
+			return nil, errors.New("combined threshold signature doesn't verify against the group public key; are the shares' indexes and public keys from the same key generation?")
+		}
+		return sig, nil
+	}
+	return nil, fmt.Errorf("only %d of the %d signature shares needed were made: %w", len(sigShares), s.threshold, errors.Join(errs...))
+}
+
+func (s *ThresholdBLSSigner) String() string {
+	return fmt.Sprintf("ThresholdBLSSigner{%d of %d}", s.threshold, len(s.shares))
+}
@@ -0,0 +1,127 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+
+	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestThresholdBLSSigner(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Generate a member key split into 3 shares, any 2 of which can sign, as
+	// 'datool dkg' does.
+	const threshold, participants = 2, 3
+	var commitments [][]*bls12381.PointG2
+	var dealings []*blsSignatures.Dealing
+	for i := 0; i < participants; i++ {
+		dealing, err := blsSignatures.Deal(threshold, participants)
+		testhelpers.RequireImpl(t, err)
+		dealings = append(dealings, dealing)
+		commitments = append(commitments, dealing.Commitments)
+	}
+	groupKey := blsSignatures.GroupPublicKeyPoint(commitments)
+	keyShares := make([]blsSignatures.PrivateKey, participants)
+	proofShares := make(map[uint64]blsSignatures.Signature)
+	for j := range keyShares {
+		var shares []blsSignatures.PrivateKey
+		for _, dealing := range dealings {
+			shares = append(shares, dealing.Shares[j])
+		}
+		keyShares[j] = blsSignatures.CombineKeyShares(shares)
+		proofShare, err := blsSignatures.KeyValidityProofShare(groupKey, keyShares[j])
+		testhelpers.RequireImpl(t, err)
+		proofShares[uint64(j+1)] = proofShare
+	}
+	proof, err := blsSignatures.CombineSignatureShares(proofShares)
+	testhelpers.RequireImpl(t, err)
+	groupPubKey, err := blsSignatures.NewPublicKey(groupKey, proof)
+	testhelpers.RequireImpl(t, err)
+
+	tokensFile := filepath.Join(t.TempDir(), "tokens")
+	testhelpers.RequireImpl(t, os.WriteFile(tokensFile, []byte("token\n"), 0600))
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	testhelpers.RequireImpl(t, os.WriteFile(tokenFile, []byte("token\n"), 0600))
+	encodedPubKeyShare := func(j int) string {
+		pubKey, err := blsSignatures.PublicKeyFromPrivateKey(keyShares[j])
+		testhelpers.RequireImpl(t, err)
+		return base64.StdEncoding.EncodeToString(blsSignatures.PublicKeyToBytes(pubKey))
+	}
+
+	// Share 1 is held by a remote signer, share 2 locally, and share 3 by a
+	// remote signer that's down.
+	lis, err := net.Listen("tcp", "localhost:0")
+	testhelpers.RequireImpl(t, err)
+	share1Signer, err := NewLocalBLSSigner(keyShares[0])
+	testhelpers.RequireImpl(t, err)
+	_, err = StartBLSSignerServerOnListener(ctx, lis, genericconf.HTTPServerTimeoutConfigDefault, share1Signer, TokenAuthConfig{TokensFile: tokensFile})
+	testhelpers.RequireImpl(t, err)
+	share2Dir := t.TempDir()
+	share2PubKey, err := blsSignatures.PublicKeyFromPrivateKey(keyShares[1])
+	testhelpers.RequireImpl(t, err)
+	testhelpers.RequireImpl(t, StoreKeys(share2Dir, share2PubKey, keyShares[1]))
+	down, err := net.Listen("tcp", "localhost:0")
+	testhelpers.RequireImpl(t, err)
+	testhelpers.RequireImpl(t, down.Close())
+
+	shares, err := json.Marshal([]ThresholdShareConfig{
+		{Index: 1, URL: "http://" + lis.Addr().String(), PublicKey: encodedPubKeyShare(0), BearerTokenFile: tokenFile},
+		{Index: 2, KeyDir: share2Dir},
+		{Index: 3, URL: "http://" + down.Addr().String(), PublicKey: encodedPubKeyShare(2), BearerTokenFile: tokenFile},
+	})
+	testhelpers.RequireImpl(t, err)
+	config := ThresholdSignerConfig{
+		Threshold:      threshold,
+		Shares:         string(shares),
+		GroupPublicKey: base64.StdEncoding.EncodeToString(blsSignatures.PublicKeyToBytes(groupPubKey)),
+		Timeout:        5 * time.Second,
+	}
+	keyConfig := &KeyConfig{Threshold: config}
+	signer, err := keyConfig.BLSSigner(ctx)
+	testhelpers.RequireImpl(t, err)
+
+	// Certificates signed with the shares verify against the member's key.
+	storageService := NewMemoryBackedStorageService(ctx)
+	writer, err := NewSignAfterStoreDASWriterWithSigner(signer, nil, storageService, "")
+	testhelpers.RequireImpl(t, err)
+	cert, err := writer.Store(ctx, testhelpers.RandomizeSlice(make([]byte, 100)), uint64(time.Now().Add(time.Hour).Unix()), nil)
+	testhelpers.RequireImpl(t, err)
+	verified, err := blsSignatures.VerifySignature(cert.Sig, cert.SerializeSignableFields(), groupPubKey)
+	testhelpers.RequireImpl(t, err)
+	if !verified {
+		testhelpers.FailImpl(t, "threshold signed certificate doesn't verify against the group public key")
+	}
+
+	// Without enough shares up, signing fails.
+	allShares := config
+	allShares.Threshold = participants
+	allSigner, err := NewThresholdBLSSigner(ctx, allShares, keyConfig)
+	testhelpers.RequireImpl(t, err)
+	if _, err := allSigner.Sign(ctx, []byte("message")); err == nil {
+		testhelpers.FailImpl(t, "expected signing to fail with a share down")
+	}
+
+	// A share that isn't pinned to its public key is rejected.
+	unpinned, err := json.Marshal([]ThresholdShareConfig{{Index: 1, URL: "http://" + lis.Addr().String()}, {Index: 2, KeyDir: share2Dir}})
+	testhelpers.RequireImpl(t, err)
+	unpinnedConfig := config
+	unpinnedConfig.Shares = string(unpinned)
+	if _, err := NewThresholdBLSSigner(ctx, unpinnedConfig, keyConfig); err == nil {
+		testhelpers.FailImpl(t, "expected a remote share without a public key to be rejected")
+	}
+}
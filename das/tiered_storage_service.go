@@ -0,0 +1,280 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/pretty"
+	flag "github.com/spf13/pflag"
+)
+
+type TieredStorageConfig struct {
+	Enable            bool     `koanf:"enable"`
+	Tiers             []string `koanf:"tiers"`
+	WriteAll          bool     `koanf:"write-all"`
+	DurableTier       string   `koanf:"durable-tier"`
+	Backfill          bool     `koanf:"backfill"`
+	BackfillQueueSize int      `koanf:"backfill-queue-size"`
+}
+
+var DefaultTieredStorageConfig = TieredStorageConfig{
+	WriteAll:          true,
+	Backfill:          true,
+	BackfillQueueSize: 1024,
+}
+
+func TieredStorageConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultTieredStorageConfig.Enable, "read from the enabled storage backends in order, rather than from all of them at once")
+	f.StringSlice(prefix+".tiers", DefaultTieredStorageConfig.Tiers, "order in which to read from the enabled storage backends, eg memory-storage,local-file-storage,s3-storage; every enabled backend must be listed")
+	f.Bool(prefix+".write-all", DefaultTieredStorageConfig.WriteAll, "write to every tier; if false, only the durable tier is written to and the others are filled on reads")
+	f.String(prefix+".durable-tier", DefaultTieredStorageConfig.DurableTier, "tier written to when write-all is false (defaults to the last tier)")
+	f.Bool(prefix+".backfill", DefaultTieredStorageConfig.Backfill, "when data is found in a tier, copy it into the tiers before it")
+	f.Int(prefix+".backfill-queue-size", DefaultTieredStorageConfig.BackfillQueueSize, "number of backfills that can wait to be written in the background; backfills are dropped while the queue is full (0 to backfill during the read instead)")
+}
+
+// TieredStorageService composes StorageServices in order, for example
+// memory, then local disk, then S3. Reads try each tier in turn until one
+// has the data. Data found in a later tier is backfilled into the earlier
+// ones, in the background if there is a backfill queue, so later reads of it
+// are fast.
+type TieredStorageService struct {
+	tiers       []StorageService
+	writeTiers  []StorageService
+	backfill    bool
+	description string
+
+	backfillQueue    chan tieredBackfill
+	backfillMutex    sync.Mutex
+	backfilling      map[common.Hash]struct{}
+	stopBackfill     context.CancelFunc
+	backfillDone     chan struct{}
+	stopBackfillOnce sync.Once
+}
+
+type tieredBackfill struct {
+	key   common.Hash
+	found int
+	data  []byte
+}
+
+func NewTieredStorageService(tiers []StorageService, writeTiers []StorageService, backfill bool, backfillQueueSize int) (*TieredStorageService, error) {
+	if len(tiers) == 0 {
+		return nil, errors.New("tiered storage requires at least one tier")
+	}
+	if len(writeTiers) == 0 {
+		return nil, errors.New("tiered storage requires at least one tier to write to")
+	}
+	var names []string
+	for _, tier := range tiers {
+		names = append(names, tier.String())
+	}
+	t := &TieredStorageService{
+		tiers:       tiers,
+		writeTiers:  writeTiers,
+		backfill:    backfill,
+		description: strings.Join(names, " -> "),
+	}
+	if backfill && backfillQueueSize > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.backfillQueue = make(chan tieredBackfill, backfillQueueSize)
+		t.backfilling = make(map[common.Hash]struct{})
+		t.stopBackfill = cancel
+		t.backfillDone = make(chan struct{})
+		go t.backfillLoop(ctx)
+	}
+	return t, nil
+}
+
+// NewTieredStorageServiceFromConfig orders the enabled storage services, named
+// by their config prefix, according to the configured tiers.
+func NewTieredStorageServiceFromConfig(config TieredStorageConfig, services []StorageService, names []string) (*TieredStorageService, error) {
+	byName := make(map[string]StorageService)
+	for i, name := range names {
+		byName[name] = services[i]
+	}
+	var tiers []StorageService
+	for _, name := range config.Tiers {
+		s, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("tiered-storage.tiers lists %s, which isn't enabled", name)
+		}
+		delete(byName, name)
+		tiers = append(tiers, s)
+	}
+	for name := range byName {
+		return nil, fmt.Errorf("enabled storage %s isn't listed in tiered-storage.tiers", name)
+	}
+	if len(tiers) == 0 {
+		return nil, errors.New("tiered-storage.tiers must be set")
+	}
+
+	writeTiers := tiers
+	if !config.WriteAll {
+		durable := tiers[len(tiers)-1]
+		if config.DurableTier != "" {
+			found := false
+			for i, name := range config.Tiers {
+				if name == config.DurableTier {
+					durable = tiers[i]
+					found = true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("tiered-storage.durable-tier %s isn't one of the tiers", config.DurableTier)
+			}
+		}
+		writeTiers = []StorageService{durable}
+	}
+	return NewTieredStorageService(tiers, writeTiers, config.Backfill, config.BackfillQueueSize)
+}
+
+func (t *TieredStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.TieredStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", t)
+	var lastErr error
+	for i, tier := range t.tiers {
+		data, err := tier.GetByHash(ctx, key)
+		if err != nil {
+			if !errors.Is(err, ErrNotFound) {
+				log.Warn("das.TieredStorageService.GetByHash tier failed", "tier", tier, "err", err)
+			}
+			lastErr = err
+			continue
+		}
+		if t.backfill && i > 0 && dastree.ValidHash(key, data) {
+			if t.backfillQueue == nil {
+				t.backfillTiers(ctx, i, data)
+			} else {
+				t.queueBackfill(tieredBackfill{key, i, data})
+			}
+		}
+		return data, nil
+	}
+	return nil, lastErr
+}
+
+// tierWith returns the first tier that has the data with the given hash.
+func (t *TieredStorageService) tierWith(ctx context.Context, key common.Hash) (StorageService, error) {
+	lastErr := ErrNotFound
+	for _, tier := range t.tiers {
+		if _, err := tier.GetByHash(ctx, key); err != nil {
+			lastErr = err
+			continue
+		}
+		return tier, nil
+	}
+	return nil, lastErr
+}
+
+// backfillTiers copies data found in tier found into the tiers before it. The
+// original timeout isn't known, so data is kept as long as the tier allows.
+func (t *TieredStorageService) backfillTiers(ctx context.Context, found int, data []byte) {
+	for _, tier := range t.tiers[:found] {
+		if err := tier.Put(ctx, data, ^uint64(0)); err != nil {
+			log.Warn("das.TieredStorageService failed to backfill tier", "tier", tier, "err", err)
+		}
+	}
+}
+
+// queueBackfill queues data to be backfilled, unless it already is or the
+// queue is full.
+func (t *TieredStorageService) queueBackfill(b tieredBackfill) {
+	t.backfillMutex.Lock()
+	defer t.backfillMutex.Unlock()
+	if _, ok := t.backfilling[b.key]; ok {
+		return
+	}
+	select {
+	case t.backfillQueue <- b:
+		t.backfilling[b.key] = struct{}{}
+	default:
+		log.Debug("das.TieredStorageService backfill queue is full, dropping backfill", "key", pretty.PrettyHash(b.key))
+	}
+}
+
+func (t *TieredStorageService) backfillLoop(ctx context.Context) {
+	defer close(t.backfillDone)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case b := <-t.backfillQueue:
+			t.backfillTiers(ctx, b.found, b.data)
+			t.backfillMutex.Lock()
+			delete(t.backfilling, b.key)
+			t.backfillMutex.Unlock()
+		}
+	}
+}
+
+func (t *TieredStorageService) Put(ctx context.Context, data []byte, expirationTime uint64) error {
+	logPut("das.TieredStorageService.Store", data, expirationTime, t)
+	return t.forEach(t.writeTiers, func(s StorageService) error {
+		return s.Put(ctx, data, expirationTime)
+	})
+}
+
+func (t *TieredStorageService) forEach(services []StorageService, f func(StorageService) error) error {
+	var wg sync.WaitGroup
+	var errorMutex sync.Mutex
+	var anyError error
+	wg.Add(len(services))
+	for _, serv := range services {
+		go func(s StorageService) {
+			defer wg.Done()
+			if err := f(s); err != nil {
+				errorMutex.Lock()
+				anyError = err
+				errorMutex.Unlock()
+			}
+		}(serv)
+	}
+	wg.Wait()
+	return anyError
+}
+
+func (t *TieredStorageService) Sync(ctx context.Context) error {
+	return t.forEach(t.tiers, func(s StorageService) error {
+		return s.Sync(ctx)
+	})
+}
+
+func (t *TieredStorageService) Close(ctx context.Context) error {
+	if t.stopBackfill != nil {
+		t.stopBackfillOnce.Do(func() {
+			t.stopBackfill()
+			<-t.backfillDone
+		})
+	}
+	return t.forEach(t.tiers, func(s StorageService) error {
+		return s.Close(ctx)
+	})
+}
+
+// ExpirationPolicy is that of the tiers written to, combined as for
+// RedundantStorageService, since data is only guaranteed to be in those.
+func (t *TieredStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	return (&RedundantStorageService{innerServices: t.writeTiers}).ExpirationPolicy(ctx)
+}
+
+func (t *TieredStorageService) String() string {
+	return "TieredStorageService(" + t.description + ")"
+}
+
+func (t *TieredStorageService) HealthCheck(ctx context.Context) error {
+	for _, tier := range t.tiers {
+		if err := tier.HealthCheck(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
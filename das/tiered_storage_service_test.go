@@ -0,0 +1,106 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+func TestTieredStorageService(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	fast := NewMemoryBackedStorageService(ctx)
+	durable := NewMemoryBackedStorageService(ctx)
+
+	config := DefaultTieredStorageConfig
+	config.Tiers = []string{"fast", "durable"}
+	config.WriteAll = false
+	config.BackfillQueueSize = 0
+	tiered, err := NewTieredStorageServiceFromConfig(config, []StorageService{durable, fast}, []string{"durable", "fast"})
+	Require(t, err)
+
+	val := []byte("The first value")
+	key := dastree.Hash(val)
+	Require(t, tiered.Put(ctx, val, timeout))
+	if _, err := fast.GetByHash(ctx, key); !errors.Is(err, ErrNotFound) {
+		Fail(t, "data was written to a tier other than the durable tier", err)
+	}
+
+	res, err := tiered.GetByHash(ctx, key)
+	Require(t, err)
+	if !bytes.Equal(res, val) {
+		Fail(t, "wrong data returned", res)
+	}
+	res, err = fast.GetByHash(ctx, key)
+	Require(t, err)
+	if !bytes.Equal(res, val) {
+		Fail(t, "data wasn't backfilled into the earlier tier", res)
+	}
+
+	_, err = tiered.GetByHash(ctx, dastree.Hash([]byte("missing")))
+	if !errors.Is(err, ErrNotFound) {
+		Fail(t, "expected ErrNotFound", err)
+	}
+}
+
+func TestTieredStorageServiceBackgroundBackfill(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+	fast := NewMemoryBackedStorageService(ctx)
+	durable := NewMemoryBackedStorageService(ctx)
+	tiered, err := NewTieredStorageService([]StorageService{fast, durable}, []StorageService{durable}, true, 16)
+	Require(t, err)
+
+	val := []byte("The first value")
+	key := dastree.Hash(val)
+	Require(t, tiered.Put(ctx, val, timeout))
+	res, err := tiered.GetByHash(ctx, key)
+	Require(t, err)
+	if !bytes.Equal(res, val) {
+		Fail(t, "wrong data returned", res)
+	}
+
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		res, err = fast.GetByHash(ctx, key)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			Fail(t, "data wasn't backfilled into the earlier tier", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !bytes.Equal(res, val) {
+		Fail(t, "wrong data backfilled", res)
+	}
+	Require(t, tiered.Close(ctx))
+}
+
+func TestTieredStorageServiceConfig(t *testing.T) {
+	ctx := context.Background()
+	services := []StorageService{NewMemoryBackedStorageService(ctx), NewMemoryBackedStorageService(ctx)}
+	names := []string{"a", "b"}
+
+	config := DefaultTieredStorageConfig
+	config.Tiers = []string{"a"}
+	if _, err := NewTieredStorageServiceFromConfig(config, services, names); err == nil {
+		Fail(t, "expected error for enabled storage missing from tiers")
+	}
+	config.Tiers = []string{"a", "b", "c"}
+	if _, err := NewTieredStorageServiceFromConfig(config, services, names); err == nil {
+		Fail(t, "expected error for tier that isn't enabled")
+	}
+	config.Tiers = []string{"a", "b"}
+	config.WriteAll = false
+	config.DurableTier = "c"
+	if _, err := NewTieredStorageServiceFromConfig(config, services, names); err == nil {
+		Fail(t, "expected error for unknown durable tier")
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+
+	flag "github.com/spf13/pflag"
+)
+
+var tokenStoreRejectedCounter = metrics.NewRegisteredCounter("arb/das/rpc/store/token/rejected", nil)
+
+var ErrInvalidBearerToken = errors.New("storing requires a valid bearer token")
+
+type TokenAuthConfig struct {
+	TokensFile     string        `koanf:"tokens-file"`
+	ReloadInterval time.Duration `koanf:"reload-interval"`
+}
+
+var DefaultTokenAuthConfig = TokenAuthConfig{
+	ReloadInterval: time.Minute,
+}
+
+func TokenAuthConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".tokens-file", DefaultTokenAuthConfig.TokensFile, "file with one bearer token per line; if set, only HTTP-RPC and gRPC clients sending one of them in the Authorization header may store data")
+	f.Duration(prefix+".reload-interval", DefaultTokenAuthConfig.ReloadInterval, "how often to reload tokens-file, so tokens can be rotated without a restart (0 to disable reloading)")
+}
+
+type bearerTokenKey struct{}
+
+// withBearerToken records the request's bearer token, if any, in its context.
+func withBearerToken(r *http.Request) *http.Request {
+	return r.WithContext(contextWithBearerToken(r.Context(), r.Header.Get("Authorization")))
+}
+
+// contextWithBearerToken records the bearer token in an Authorization header,
+// if any, in ctx.
+func contextWithBearerToken(ctx context.Context, authorization string) context.Context {
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok || token == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, bearerTokenKey{}, token)
+}
+
+// readTokensFile returns the hashes of the tokens in the file, ignoring blank
+// lines and lines starting with #.
+func readTokensFile(path string) (map[[32]byte]struct{}, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make(map[[32]byte]struct{})
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tokens[sha256.Sum256([]byte(line))] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("no tokens in %s", path)
+	}
+	return tokens, nil
+}
+
+// bearerTokens holds the hashes of the tokens in a tokens file, so comparing
+// them doesn't leak the tokens through timing, reloading the file
+// periodically once started.
+type bearerTokens struct {
+	stopwaiter.StopWaiter
+	config TokenAuthConfig
+	tokens atomic.Pointer[map[[32]byte]struct{}]
+}
+
+func newBearerTokens(config TokenAuthConfig) (*bearerTokens, error) {
+	tokens, err := readTokensFile(config.TokensFile)
+	if err != nil {
+		return nil, err
+	}
+	t := &bearerTokens{config: config}
+	t.tokens.Store(&tokens)
+	return t, nil
+}
+
+func (t *bearerTokens) Start(ctx context.Context) {
+	t.StopWaiter.Start(ctx, t)
+	if t.config.ReloadInterval > 0 {
+		t.CallIteratively(t.reload)
+	}
+}
+
+func (t *bearerTokens) reload(ctx context.Context) time.Duration {
+	tokens, err := readTokensFile(t.config.TokensFile)
+	if err != nil {
+		log.Error("das: failed to reload bearer tokens, keeping the current tokens", "file", t.config.TokensFile, "err", err)
+		return t.config.ReloadInterval
+	}
+	t.tokens.Store(&tokens)
+	return t.config.ReloadInterval
+}
+
+// valid reports whether the request's context has one of the tokens, as
+// recorded by withBearerToken.
+func (t *bearerTokens) valid(ctx context.Context) bool {
+	token, _ := ctx.Value(bearerTokenKey{}).(string)
+	_, ok := (*t.tokens.Load())[sha256.Sum256([]byte(token))]
+	return token != "" && ok
+}
+
+// TokenStoreAuthenticator only lets clients sending one of the configured
+// bearer tokens store data through the writer it wraps, for deployments where
+// TLS client certificates aren't practical.
+type TokenStoreAuthenticator struct {
+	DataAvailabilityServiceWriter
+	tokens *bearerTokens
+}
+
+func NewTokenStoreAuthenticator(writer DataAvailabilityServiceWriter, config TokenAuthConfig) (*TokenStoreAuthenticator, error) {
+	tokens, err := newBearerTokens(config)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenStoreAuthenticator{
+		DataAvailabilityServiceWriter: writer,
+		tokens:                        tokens,
+	}, nil
+}
+
+func (a *TokenStoreAuthenticator) Start(ctx context.Context) {
+	a.tokens.Start(ctx)
+}
+
+func (a *TokenStoreAuthenticator) Close(ctx context.Context) error {
+	a.tokens.StopOnly()
+	return nil
+}
+
+func (a *TokenStoreAuthenticator) AuthorizeStore(ctx context.Context) error {
+	if !a.tokens.valid(ctx) && !isQueuedStore(ctx) {
+		tokenStoreRejectedCounter.Inc(1)
+		return ErrInvalidBearerToken
+	}
+	return authorizeStore(ctx, a.DataAvailabilityServiceWriter)
+}
+
+func (a *TokenStoreAuthenticator) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	if err := a.AuthorizeStore(ctx); err != nil {
+		return nil, err
+	}
+	return a.DataAvailabilityServiceWriter.Store(ctx, message, timeout, sig)
+}
+
+func (a *TokenStoreAuthenticator) String() string {
+	return fmt.Sprintf("TokenStoreAuthenticator{%v}", a.DataAvailabilityServiceWriter)
+}
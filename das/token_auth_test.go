@@ -0,0 +1,80 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestRPCStoreTokenAuth(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tokensFile := filepath.Join(t.TempDir(), "tokens")
+	testhelpers.RequireImpl(t, os.WriteFile(tokensFile, []byte("# batch poster\nfirst-token\n\n"), 0600))
+	storageService := NewMemoryBackedStorageService(ctx)
+	keyDir := t.TempDir()
+	_, _, err := GenerateAndStoreKeys(keyDir)
+	testhelpers.RequireImpl(t, err)
+	privKey, err := (&KeyConfig{KeyDir: keyDir}).BLSPrivKey()
+	testhelpers.RequireImpl(t, err)
+	localDas, err := NewSignAfterStoreDASWriterWithSeqInboxCaller(privKey, nil, storageService, "")
+	testhelpers.RequireImpl(t, err)
+	// Tokens are reloaded by hand below.
+	authenticator, err := NewTokenStoreAuthenticator(localDas, TokenAuthConfig{TokensFile: tokensFile})
+	testhelpers.RequireImpl(t, err)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	testhelpers.RequireImpl(t, err)
+	dasServer, err := StartDASRPCServerOnListener(ctx, lis, genericconf.HTTPServerTimeoutConfigDefault, nil, DefaultStoreBoundsConfig, storageService, authenticator, storageService)
+	testhelpers.RequireImpl(t, err)
+	defer func() {
+		testhelpers.RequireImpl(t, dasServer.Shutdown(ctx))
+	}()
+
+	store := func(token string) error {
+		t.Helper()
+		backend := BackendConfig{URL: "http://" + lis.Addr().String(), BearerToken: token}
+		options, err := backend.dialOptions()
+		testhelpers.RequireImpl(t, err)
+		client, err := NewDASRPCClientWithOptions(ctx, backend.URL, options...)
+		testhelpers.RequireImpl(t, err)
+		_, err = client.Store(ctx, testhelpers.RandomizeSlice(make([]byte, 100)), uint64(time.Now().Add(time.Hour).Unix()), nil)
+		return err
+	}
+
+	testhelpers.RequireImpl(t, store("first-token"))
+	for _, token := range []string{"", "wrong-token", "# batch poster"} {
+		if err := store(token); err == nil || !strings.Contains(err.Error(), ErrInvalidBearerToken.Error()) {
+			testhelpers.FailImpl(t, "expected store to be rejected with token", token, err)
+		}
+	}
+
+	// Rotate the token.
+	testhelpers.RequireImpl(t, os.WriteFile(tokensFile, []byte("second-token\n"), 0600))
+	authenticator.tokens.reload(ctx)
+	testhelpers.RequireImpl(t, store("second-token"))
+	if err := store("first-token"); err == nil {
+		testhelpers.FailImpl(t, "expected store with the rotated out token to be rejected")
+	}
+
+	// A bad tokens file keeps the current tokens.
+	testhelpers.RequireImpl(t, os.WriteFile(tokensFile, []byte("\n"), 0600))
+	authenticator.tokens.reload(ctx)
+	testhelpers.RequireImpl(t, store("second-token"))
+
+	if _, err := NewTokenStoreAuthenticator(localDas, TokenAuthConfig{TokensFile: filepath.Join(t.TempDir(), "absent")}); !errors.Is(err, os.ErrNotExist) {
+		testhelpers.FailImpl(t, "expected missing tokens file to be an error", err)
+	}
+}
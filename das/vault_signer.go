@@ -0,0 +1,384 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/blsSignatures"
+
+	flag "github.com/spf13/pflag"
+)
+
+type VaultAppRoleConfig struct {
+	MountPath    string `koanf:"mount-path"`
+	RoleID       string `koanf:"role-id"`
+	SecretIDFile string `koanf:"secret-id-file"`
+}
+
+type VaultConfig struct {
+	Address      string             `koanf:"address"`
+	Namespace    string             `koanf:"namespace"`
+	RootCA       string             `koanf:"root-ca"`
+	TokenFile    string             `koanf:"token-file"`
+	AppRole      VaultAppRoleConfig `koanf:"approle"`
+	SecretPath   string             `koanf:"secret-path"`
+	SecretField  string             `koanf:"secret-field"`
+	TransitMount string             `koanf:"transit-mount"`
+	TransitKey   string             `koanf:"transit-key"`
+	Timeout      time.Duration      `koanf:"timeout"`
+}
+
+var DefaultVaultConfig = VaultConfig{
+	AppRole: VaultAppRoleConfig{
+		MountPath: "approle",
+	},
+	SecretField:  "priv-key",
+	TransitMount: "transit",
+	Timeout:      10 * time.Second,
+}
+
+func VaultConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".address", DefaultVaultConfig.Address, "address of a HashiCorp Vault server to get the BLS key from, or sign with, instead of key-dir or priv-key, eg https://vault.example.com:8200")
+	f.String(prefix+".namespace", DefaultVaultConfig.Namespace, "Vault Enterprise namespace")
+	f.String(prefix+".root-ca", DefaultVaultConfig.RootCA, "PEM encoded CA certificates to verify Vault's TLS certificate with, instead of the system's")
+	f.String(prefix+".token-file", DefaultVaultConfig.TokenFile, "file with the Vault token, eg a Vault Agent sink; it's read again whenever Vault rejects the token")
+	f.String(prefix+".approle.mount-path", DefaultVaultConfig.AppRole.MountPath, "path the AppRole auth method is mounted at")
+	f.String(prefix+".approle.role-id", DefaultVaultConfig.AppRole.RoleID, "AppRole role ID to log in with, instead of token-file")
+	f.String(prefix+".approle.secret-id-file", DefaultVaultConfig.AppRole.SecretIDFile, "file with the AppRole secret ID")
+	f.String(prefix+".secret-path", DefaultVaultConfig.SecretPath, "path of the KV secret holding the base64 BLS private key, eg secret/data/das/bls for a KV version 2 engine mounted at secret")
+	f.String(prefix+".secret-field", DefaultVaultConfig.SecretField, "field of the secret holding the BLS private key")
+	f.String(prefix+".transit-mount", DefaultVaultConfig.TransitMount, "path the transit-compatible engine signing with transit-key is mounted at")
+	f.String(prefix+".transit-key", DefaultVaultConfig.TransitKey, "name of a BLS key in a transit-compatible secrets engine to sign with, so the private key never leaves Vault, instead of reading it from secret-path")
+	f.Duration(prefix+".timeout", DefaultVaultConfig.Timeout, "timeout of each request to Vault")
+}
+
+func (c *VaultConfig) Validate() error {
+	if c.Address == "" {
+		return nil
+	}
+	if (c.TokenFile == "") == (c.AppRole.RoleID == "") {
+		return errors.New("exactly one of vault.token-file and vault.approle.role-id must be set")
+	}
+	if c.AppRole.RoleID != "" && c.AppRole.SecretIDFile == "" {
+		return errors.New("vault.approle.role-id requires vault.approle.secret-id-file")
+	}
+	if (c.SecretPath == "") == (c.TransitKey == "") {
+		return errors.New("exactly one of vault.secret-path and vault.transit-key must be set")
+	}
+	return nil
+}
+
+// vaultClient makes authenticated requests to Vault's HTTP API, logging in
+// again whenever the token expires or is rejected.
+type vaultClient struct {
+	config VaultConfig
+	client *http.Client
+
+	mutex sync.Mutex
+	token string
+	// When to log in again, zero if the token isn't known to expire.
+	renewAt time.Time
+}
+
+func newVaultClient(config VaultConfig) (*vaultClient, error) {
+	if !(strings.HasPrefix(config.Address, "http://") || strings.HasPrefix(config.Address, "https://")) {
+		return nil, fmt.Errorf("protocol prefix 'http://' or 'https://' must be specified for the Vault address; got '%s'", config.Address)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.RootCA != "" {
+		rootCrt, err := os.ReadFile(config.RootCA)
+		if err != nil {
+			return nil, fmt.Errorf("error reading Vault root CA: %w", err)
+		}
+		rootCertPool := x509.NewCertPool()
+		if !rootCertPool.AppendCertsFromPEM(rootCrt) {
+			return nil, errors.New("no certificates found in Vault root CA")
+		}
+		transport.TLSClientConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			RootCAs:    rootCertPool,
+		}
+	}
+	return &vaultClient{
+		config: config,
+		client: &http.Client{Transport: transport, Timeout: config.Timeout},
+	}, nil
+}
+
+type vaultAuthResponse struct {
+	Auth *struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int64  `json:"lease_duration"`
+	} `json:"auth"`
+}
+
+// loginLocked must be called with the mutex held.
+func (c *vaultClient) loginLocked(ctx context.Context) error {
+	if c.config.TokenFile != "" {
+		token, err := os.ReadFile(c.config.TokenFile)
+		if err != nil {
+			return fmt.Errorf("error reading Vault token: %w", err)
+		}
+		c.token = strings.TrimSpace(string(token))
+		c.renewAt = time.Time{}
+		return nil
+	}
+	secretID, err := os.ReadFile(c.config.AppRole.SecretIDFile)
+	if err != nil {
+		return fmt.Errorf("error reading Vault AppRole secret ID: %w", err)
+	}
+	login := map[string]string{
+		"role_id":   c.config.AppRole.RoleID,
+		"secret_id": strings.TrimSpace(string(secretID)),
+	}
+	var response vaultAuthResponse
+	if err := c.request(ctx, http.MethodPost, "auth/"+strings.Trim(c.config.AppRole.MountPath, "/")+"/login", "", login, &response); err != nil {
+		return fmt.Errorf("error logging in to Vault with AppRole: %w", err)
+	}
+	if response.Auth == nil || response.Auth.ClientToken == "" {
+		return errors.New("Vault AppRole login returned no token")
+	}
+	c.token = response.Auth.ClientToken
+	c.renewAt = time.Time{}
+	if response.Auth.LeaseDuration > 0 {
+		// Log in again well before the token expires.
+		lease := time.Duration(response.Auth.LeaseDuration) * time.Second
+		c.renewAt = time.Now().Add(lease * 2 / 3)
+	}
+	log.Info("Logged in to Vault", "address", c.config.Address, "lease", response.Auth.LeaseDuration)
+	return nil
+}
+
+func (c *vaultClient) currentToken(ctx context.Context) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.token == "" || (!c.renewAt.IsZero() && time.Now().After(c.renewAt)) {
+		if err := c.loginLocked(ctx); err != nil {
+			return "", err
+		}
+	}
+	return c.token, nil
+}
+
+// invalidateToken makes the next request log in again, unless another one
+// already has since token was rejected.
+func (c *vaultClient) invalidateToken(token string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.token == token {
+		c.token = ""
+	}
+}
+
+type vaultPermissionDeniedError struct {
+	err error
+}
+
+func (e *vaultPermissionDeniedError) Error() string {
+	return e.err.Error()
+}
+
+// Do makes a request with the client's token, logging in again and retrying
+// once if Vault rejects it.
+func (c *vaultClient) Do(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	for attempt := 0; ; attempt++ {
+		token, err := c.currentToken(ctx)
+		if err != nil {
+			return err
+		}
+		err = c.request(ctx, method, path, token, body, result)
+		var denied *vaultPermissionDeniedError
+		if attempt > 0 || !errors.As(err, &denied) {
+			return err
+		}
+		log.Warn("Vault rejected token, logging in again", "address", c.config.Address)
+		c.invalidateToken(token)
+	}
+}
+
+func (c *vaultClient) request(ctx context.Context, method, path, token string, body interface{}, result interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.config.Address, "/")+"/v1/"+strings.TrimPrefix(path, "/"), reqBody)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if c.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.config.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(io.LimitReader(res.Body, 1<<16)).Decode(&vaultErr)
+		err := fmt.Errorf("Vault returned HTTP status %d for %s: %s", res.StatusCode, path, strings.Join(vaultErr.Errors, "; "))
+		if res.StatusCode == http.StatusForbidden {
+			return &vaultPermissionDeniedError{err}
+		}
+		return err
+	}
+	return json.NewDecoder(res.Body).Decode(result)
+}
+
+// readVaultBLSPrivKey reads the BLS private key from a KV secret, of either
+// engine version, so it's only ever held in memory.
+func readVaultBLSPrivKey(ctx context.Context, client *vaultClient, config VaultConfig) (blsSignatures.PrivateKey, error) {
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := client.Do(ctx, http.MethodGet, config.SecretPath, nil, &response); err != nil {
+		return nil, err
+	}
+	fields := response.Data
+	if inner, ok := fields["data"].(map[string]interface{}); ok {
+		if _, ok := fields["metadata"]; ok {
+			fields = inner
+		}
+	}
+	encoded, ok := fields[config.SecretField].(string)
+	if !ok {
+		return nil, fmt.Errorf("Vault secret %s has no field %s", config.SecretPath, config.SecretField)
+	}
+	privKey, err := DecodeBase64BLSPrivateKey([]byte(strings.TrimSpace(encoded)))
+	if err != nil {
+		return nil, fmt.Errorf("Vault secret %s field %s isn't a BLS private key: %w", config.SecretPath, config.SecretField, err)
+	}
+	return privKey, nil
+}
+
+// VaultBLSSigner signs with a key kept in a Vault secrets engine with the
+// transit engine's API, which never exports it. Vault's transit engine itself
+// doesn't support BLS keys, so this needs a plugin that does.
+type VaultBLSSigner struct {
+	client *vaultClient
+	mount  string
+	key    string
+	pubKey blsSignatures.PublicKey
+}
+
+func newVaultBLSSigner(ctx context.Context, client *vaultClient, config VaultConfig) (*VaultBLSSigner, error) {
+	s := &VaultBLSSigner{
+		client: client,
+		mount:  strings.Trim(config.TransitMount, "/"),
+		key:    config.TransitKey,
+	}
+	var response struct {
+		Data struct {
+			LatestVersion int `json:"latest_version"`
+			Keys          map[string]struct {
+				PublicKey string `json:"public_key"`
+			} `json:"keys"`
+		} `json:"data"`
+	}
+	if err := client.Do(ctx, http.MethodGet, s.mount+"/keys/"+s.key, nil, &response); err != nil {
+		return nil, err
+	}
+	latest, ok := response.Data.Keys[strconv.Itoa(response.Data.LatestVersion)]
+	if !ok || latest.PublicKey == "" {
+		return nil, fmt.Errorf("Vault key %s/keys/%s has no public key", s.mount, s.key)
+	}
+	pubKey, err := DecodeBase64BLSPublicKey([]byte(latest.PublicKey))
+	if err != nil {
+		return nil, fmt.Errorf("Vault key %s/keys/%s isn't a BLS key: %w", s.mount, s.key, err)
+	}
+	s.pubKey = *pubKey
+	return s, nil
+}
+
+func (s *VaultBLSSigner) PublicKey() blsSignatures.PublicKey {
+	return s.pubKey
+}
+
+func (s *VaultBLSSigner) Sign(ctx context.Context, message []byte) (blsSignatures.Signature, error) {
+	var response struct {
+		Data struct {
+			Signature string `json:"signature"`
+		} `json:"data"`
+	}
+	request := map[string]string{"input": base64.StdEncoding.EncodeToString(message)}
+	if err := s.client.Do(ctx, http.MethodPost, s.mount+"/sign/"+s.key, request, &response); err != nil {
+		return nil, err
+	}
+	// Signatures are formatted vault:v<key version>:<base64 signature>.
+	encoded := response.Data.Signature
+	if i := strings.LastIndex(encoded, ":"); i >= 0 {
+		encoded = encoded[i+1:]
+	}
+	sigBytes, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("Vault returned a malformed signature: %w", err)
+	}
+	sig, err := blsSignatures.SignatureFromBytes(sigBytes)
+	if err != nil {
+		return nil, fmt.Errorf("Vault returned an invalid signature: %w", err)
+	}
+	// The key may have been rotated in Vault since the public key was read,
+	// in which case the signature is for a keyset the writer doesn't have.
+	verified, err := blsSignatures.VerifySignature(sig, message, s.pubKey)
+	if err != nil {
+		return nil, err
+	}
+	if !verified {
+		return nil, fmt.Errorf("Vault key %s/keys/%s signed with a different key than it reported, was it rotated?", s.mount, s.key)
+	}
+	return sig, nil
+}
+
+func (s *VaultBLSSigner) String() string {
+	return fmt.Sprintf("VaultBLSSigner{%s/keys/%s}", s.mount, s.key)
+}
+
+// NewVaultBLSSigner returns a signer with the key read from Vault, or one
+// that has Vault sign if a transit key is configured.
+func NewVaultBLSSigner(ctx context.Context, config VaultConfig) (BLSSigner, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	client, err := newVaultClient(config)
+	if err != nil {
+		return nil, err
+	}
+	if config.TransitKey != "" {
+		return newVaultBLSSigner(ctx, client, config)
+	}
+	privKey, err := readVaultBLSPrivKey(ctx, client, config)
+	if err != nil {
+		return nil, err
+	}
+	return NewLocalBLSSigner(privKey)
+}
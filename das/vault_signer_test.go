@@ -0,0 +1,150 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+// fakeVault serves the parts of Vault's API the signer uses: AppRole login, a
+// KV version 2 secret, and a transit-style BLS key.
+type fakeVault struct {
+	t       *testing.T
+	privKey blsSignatures.PrivateKey
+	pubKey  blsSignatures.PublicKey
+
+	mutex  sync.Mutex
+	logins int
+	tokens map[string]bool
+}
+
+func (v *fakeVault) revokeTokens() {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.tokens = map[string]bool{}
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	respond := func(response interface{}) {
+		testhelpers.RequireImpl(v.t, json.NewEncoder(w).Encode(response))
+	}
+	if r.URL.Path == "/v1/auth/approle/login" {
+		var login map[string]string
+		testhelpers.RequireImpl(v.t, json.NewDecoder(r.Body).Decode(&login))
+		if login["role_id"] != "role" || login["secret_id"] != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		v.mutex.Lock()
+		v.logins++
+		token := fmt.Sprintf("token-%d", v.logins)
+		v.tokens[token] = true
+		v.mutex.Unlock()
+		respond(map[string]interface{}{"auth": map[string]interface{}{"client_token": token, "lease_duration": 3600}})
+		return
+	}
+	v.mutex.Lock()
+	valid := v.tokens[r.Header.Get("X-Vault-Token")]
+	v.mutex.Unlock()
+	if !valid {
+		w.WriteHeader(http.StatusForbidden)
+		respond(map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	}
+	switch r.URL.Path {
+	case "/v1/secret/data/das":
+		encoded := base64.StdEncoding.EncodeToString(blsSignatures.PrivateKeyToBytes(v.privKey))
+		respond(map[string]interface{}{"data": map[string]interface{}{"data": map[string]string{"priv-key": encoded}, "metadata": map[string]interface{}{}}})
+	case "/v1/transit/keys/das":
+		encoded := base64.StdEncoding.EncodeToString(blsSignatures.PublicKeyToBytes(v.pubKey))
+		respond(map[string]interface{}{"data": map[string]interface{}{"latest_version": 1, "keys": map[string]interface{}{"1": map[string]string{"public_key": encoded}}}})
+	case "/v1/transit/sign/das":
+		var request map[string]string
+		testhelpers.RequireImpl(v.t, json.NewDecoder(r.Body).Decode(&request))
+		message, err := base64.StdEncoding.DecodeString(request["input"])
+		testhelpers.RequireImpl(v.t, err)
+		sig, err := blsSignatures.SignMessage(v.privKey, message)
+		testhelpers.RequireImpl(v.t, err)
+		respond(map[string]interface{}{"data": map[string]string{"signature": "vault:v1:" + base64.StdEncoding.EncodeToString(blsSignatures.SignatureToBytes(sig))}})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestVaultBLSSigner(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pubKey, privKey, err := blsSignatures.GenerateKeys()
+	testhelpers.RequireImpl(t, err)
+	vault := &fakeVault{t: t, privKey: privKey, pubKey: pubKey, tokens: map[string]bool{}}
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	secretIDFile := filepath.Join(t.TempDir(), "secret-id")
+	testhelpers.RequireImpl(t, os.WriteFile(secretIDFile, []byte("secret\n"), 0600))
+	config := DefaultVaultConfig
+	config.Address = server.URL
+	config.AppRole.RoleID = "role"
+	config.AppRole.SecretIDFile = secretIDFile
+
+	checkSigner := func(signer BLSSigner) {
+		t.Helper()
+		if !bytes.Equal(blsSignatures.PublicKeyToBytes(signer.PublicKey()), blsSignatures.PublicKeyToBytes(pubKey)) {
+			testhelpers.FailImpl(t, "signer has the wrong public key")
+		}
+		message := testhelpers.RandomizeSlice(make([]byte, 32))
+		sig, err := signer.Sign(ctx, message)
+		testhelpers.RequireImpl(t, err)
+		verified, err := blsSignatures.VerifySignature(sig, message, pubKey)
+		testhelpers.RequireImpl(t, err)
+		if !verified {
+			testhelpers.FailImpl(t, "signature doesn't verify")
+		}
+	}
+
+	// The key can be read from a KV secret.
+	secretConfig := config
+	secretConfig.SecretPath = "secret/data/das"
+	signer, err := NewVaultBLSSigner(ctx, secretConfig)
+	testhelpers.RequireImpl(t, err)
+	checkSigner(signer)
+
+	// Or Vault can sign with it, logging in again once the token is revoked.
+	transitConfig := config
+	transitConfig.TransitKey = "das"
+	signer, err = NewVaultBLSSigner(ctx, transitConfig)
+	testhelpers.RequireImpl(t, err)
+	checkSigner(signer)
+	vault.revokeTokens()
+	checkSigner(signer)
+	vault.mutex.Lock()
+	logins := vault.logins
+	vault.mutex.Unlock()
+	if logins != 3 {
+		testhelpers.FailImpl(t, "expected the signer to log in again after its token was revoked, logins:", logins)
+	}
+
+	wrongSecret := transitConfig
+	wrongSecret.AppRole.SecretIDFile = filepath.Join(t.TempDir(), "missing")
+	if _, err := NewVaultBLSSigner(ctx, wrongSecret); err == nil {
+		testhelpers.FailImpl(t, "expected a signer without a secret ID to fail")
+	}
+	if _, err := NewVaultBLSSigner(ctx, config); err == nil {
+		testhelpers.FailImpl(t, "expected a signer with neither secret-path nor transit-key to fail")
+	}
+}
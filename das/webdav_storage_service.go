@@ -0,0 +1,232 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/pretty"
+
+	flag "github.com/spf13/pflag"
+)
+
+type WebDAVStorageServiceConfig struct {
+	Enable                 bool          `koanf:"enable"`
+	Url                    string        `koanf:"url"`
+	Username               string        `koanf:"username"`
+	Password               string        `koanf:"password"`
+	Timeout                time.Duration `koanf:"timeout"`
+	TLSCAFile              string        `koanf:"tls-ca-file"`
+	TLSCertFile            string        `koanf:"tls-cert-file"`
+	TLSKeyFile             string        `koanf:"tls-key-file"`
+	TLSInsecureSkipVerify  bool          `koanf:"tls-insecure-skip-verify"`
+	SyncFromStorageService bool          `koanf:"sync-from-storage-service"`
+	SyncToStorageService   bool          `koanf:"sync-to-storage-service"`
+}
+
+var DefaultWebDAVStorageServiceConfig = WebDAVStorageServiceConfig{
+	Timeout: 30 * time.Second,
+}
+
+func WebDAVStorageConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultWebDAVStorageServiceConfig.Enable, "enable storage/retrieval of sequencer batch data from a WebDAV server")
+	f.String(prefix+".url", DefaultWebDAVStorageServiceConfig.Url, "URL of the WebDAV collection in which to store data, eg https://cloud.example.com/remote.php/dav/files/das/batches/")
+	f.String(prefix+".username", DefaultWebDAVStorageServiceConfig.Username, "WebDAV basic auth username")
+	f.String(prefix+".password", DefaultWebDAVStorageServiceConfig.Password, "WebDAV basic auth password")
+	f.Duration(prefix+".timeout", DefaultWebDAVStorageServiceConfig.Timeout, "timeout for each WebDAV request")
+	f.String(prefix+".tls-ca-file", DefaultWebDAVStorageServiceConfig.TLSCAFile, "CA file used to verify the WebDAV server certificate, in addition to the system roots")
+	f.String(prefix+".tls-cert-file", DefaultWebDAVStorageServiceConfig.TLSCertFile, "client TLS certificate file for the WebDAV server")
+	f.String(prefix+".tls-key-file", DefaultWebDAVStorageServiceConfig.TLSKeyFile, "client TLS key file for the WebDAV server")
+	f.Bool(prefix+".tls-insecure-skip-verify", DefaultWebDAVStorageServiceConfig.TLSInsecureSkipVerify, "don't verify the WebDAV server certificate (insecure, for testing only)")
+	f.Bool(prefix+".sync-from-storage-service", DefaultWebDAVStorageServiceConfig.SyncFromStorageService, "enable WebDAV to be used as a source for regular sync storage")
+	f.Bool(prefix+".sync-to-storage-service", DefaultWebDAVStorageServiceConfig.SyncToStorageService, "enable WebDAV to be used as a sink for regular sync storage")
+}
+
+type WebDAVStorageService struct {
+	baseUrl  string
+	username string
+	password string
+	client   *http.Client
+}
+
+func NewWebDAVStorageService(ctx context.Context, config WebDAVStorageServiceConfig) (StorageService, error) {
+	if !(strings.HasPrefix(config.Url, "http://") || strings.HasPrefix(config.Url, "https://")) {
+		return nil, fmt.Errorf("protocol prefix 'http://' or 'https://' must be specified for webdav-storage.url; got '%s'", config.Url)
+	}
+	tlsConfig, err := webDAVTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	ws := &WebDAVStorageService{
+		baseUrl:  strings.TrimSuffix(config.Url, "/") + "/",
+		username: config.Username,
+		password: config.Password,
+		client: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}
+	if err := ws.ensureCollection(ctx); err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+func webDAVTLSConfig(config WebDAVStorageServiceConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// #nosec G402
+		InsecureSkipVerify: config.TLSInsecureSkipVerify,
+	}
+	if config.TLSCAFile != "" {
+		pem, err := os.ReadFile(config.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading WebDAV CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in WebDAV CA file %s", config.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if config.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.TLSCertFile, config.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading WebDAV client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func (ws *WebDAVStorageService) do(ctx context.Context, method string, url string, body []byte, headers map[string]string) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	if ws.username != "" {
+		req.SetBasicAuth(ws.username, ws.password)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return ws.client.Do(req)
+}
+
+// ensureCollection creates the collection data is stored in if it doesn't
+// already exist. Its parent collection must exist.
+func (ws *WebDAVStorageService) ensureCollection(ctx context.Context) error {
+	if err := ws.HealthCheck(ctx); err == nil {
+		return nil
+	}
+	res, err := ws.do(ctx, "MKCOL", ws.baseUrl, nil, nil)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusMethodNotAllowed {
+		return fmt.Errorf("HTTP error with status %d returned by WebDAV server creating collection %s: %s", res.StatusCode, ws.baseUrl, http.StatusText(res.StatusCode))
+	}
+	return nil
+}
+
+func (ws *WebDAVStorageService) put(ctx context.Context, key common.Hash, value []byte) error {
+	res, err := ws.do(ctx, http.MethodPut, ws.baseUrl+EncodeStorageServiceKey(key), value, map[string]string{"Content-Type": "application/octet-stream"})
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP error with status %d returned by WebDAV server: %s", res.StatusCode, http.StatusText(res.StatusCode))
+	}
+	return nil
+}
+
+func (ws *WebDAVStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.WebDAVStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", ws)
+
+	res, err := ws.do(ctx, http.MethodGet, ws.baseUrl+EncodeStorageServiceKey(key), nil, nil)
+	if err != nil {
+		log.Error("das.WebDAVStorageService.GetByHash", "err", err)
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		err = fmt.Errorf("HTTP error with status %d returned by WebDAV server: %s", res.StatusCode, http.StatusText(res.StatusCode))
+		log.Error("das.WebDAVStorageService.GetByHash", "err", err)
+		return nil, err
+	}
+	return io.ReadAll(res.Body)
+}
+
+func (ws *WebDAVStorageService) Put(ctx context.Context, value []byte, timeout uint64) error {
+	logPut("das.WebDAVStorageService.Store", value, timeout, ws)
+	err := ws.put(ctx, dastree.Hash(value), value)
+	if err != nil {
+		log.Error("das.WebDAVStorageService.Store", "err", err)
+	}
+	return err
+}
+
+func (ws *WebDAVStorageService) putKeyValue(ctx context.Context, key common.Hash, value []byte) error {
+	err := ws.put(ctx, key, value)
+	if err != nil {
+		log.Error("das.WebDAVStorageService.putKeyValue", "err", err)
+	}
+	return err
+}
+
+func (ws *WebDAVStorageService) Sync(ctx context.Context) error {
+	return nil
+}
+
+func (ws *WebDAVStorageService) Close(ctx context.Context) error {
+	ws.client.CloseIdleConnections()
+	return nil
+}
+
+func (ws *WebDAVStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	return arbstate.KeepForever, nil
+}
+
+func (ws *WebDAVStorageService) String() string {
+	return fmt.Sprintf("WebDAVStorageService(%s)", ws.baseUrl)
+}
+
+func (ws *WebDAVStorageService) HealthCheck(ctx context.Context) error {
+	res, err := ws.do(ctx, "PROPFIND", ws.baseUrl, nil, map[string]string{"Depth": "0"})
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusMultiStatus {
+		return fmt.Errorf("HTTP error with status %d returned by WebDAV server for collection %s: %s", res.StatusCode, ws.baseUrl, http.StatusText(res.StatusCode))
+	}
+	return nil
+}
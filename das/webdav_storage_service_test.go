@@ -0,0 +1,76 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/net/webdav"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+func TestWebDAVStorageService(t *testing.T) {
+	ctx := context.Background()
+	timeout := uint64(time.Now().Add(time.Hour).Unix())
+
+	handler := &webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "das" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	_, err := NewWebDAVStorageService(ctx, WebDAVStorageServiceConfig{
+		Url:     server.URL + "/batches",
+		Timeout: time.Second,
+	})
+	if err == nil {
+		Fail(t, "expected unauthenticated WebDAV storage to fail")
+	}
+
+	webdavService, err := NewWebDAVStorageService(ctx, WebDAVStorageServiceConfig{
+		Url:      server.URL + "/batches",
+		Username: "das",
+		Password: "secret",
+		Timeout:  time.Second,
+	})
+	Require(t, err)
+	Require(t, webdavService.HealthCheck(ctx))
+
+	val1 := []byte("The first value")
+	val1CorrectKey := dastree.Hash(val1)
+	val2IncorrectKey := dastree.Hash(append(val1, 0))
+
+	_, err = webdavService.GetByHash(ctx, val1CorrectKey)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	err = webdavService.Put(ctx, val1, timeout)
+	Require(t, err)
+
+	_, err = webdavService.GetByHash(ctx, val2IncorrectKey)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	val, err := webdavService.GetByHash(ctx, val1CorrectKey)
+	Require(t, err)
+	if !bytes.Equal(val, val1) {
+		t.Fatal(val, val1)
+	}
+}
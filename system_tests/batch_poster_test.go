@@ -4,12 +4,15 @@
 package arbtest
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"math/big"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -22,6 +25,9 @@ import (
 	"github.com/offchainlabs/nitro/arbnode"
 	"github.com/offchainlabs/nitro/arbnode/dataposter"
 	"github.com/offchainlabs/nitro/arbnode/dataposter/externalsignertest"
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das"
+	"github.com/offchainlabs/nitro/das/dastree"
 	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
 	"github.com/offchainlabs/nitro/solgen/go/upgrade_executorgen"
 	"github.com/offchainlabs/nitro/util/redisutil"
@@ -260,6 +266,189 @@ func TestBatchPosterLargeTx(t *testing.T) {
 	}
 }
 
+// slowDASWriter never manages to store anything, as with a committee that
+// can't sign in time.
+type slowDASWriter struct {
+	timedOut atomic.Int64
+}
+
+func (w *slowDASWriter) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	<-ctx.Done()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		w.timedOut.Add(1)
+	}
+	return nil, ctx.Err()
+}
+
+func (w *slowDASWriter) String() string {
+	return "slowDASWriter"
+}
+
+func TestBatchPosterDASStoreTimeout(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, true)
+	builder.nodeConfig.BatchPoster.Enable = false
+	cleanup := builder.Build(t)
+	defer cleanup()
+	testClientB, cleanupB := builder.Build2ndNode(t, &SecondNodeParams{})
+	defer cleanupB()
+
+	builder.L2Info.GenerateAccount("User2")
+	tx := builder.L2Info.PrepareTx("Owner", "User2", builder.L2Info.TransferGas, big.NewInt(1e12), nil)
+	err := builder.L2.Client.SendTransaction(ctx, tx)
+	Require(t, err)
+	_, err = builder.L2.EnsureTxSucceeded(tx)
+	Require(t, err)
+
+	seqInbox, err := arbnode.NewSequencerInbox(builder.L1.Client, builder.L2.ConsensusNode.DeployInfo.SequencerInbox, 0)
+	Require(t, err)
+	batchCount, err := seqInbox.GetBatchCount(ctx, nil)
+	Require(t, err)
+	parentChainID, err := builder.L1.Client.ChainID(ctx)
+	Require(t, err)
+	seqTxOpts := builder.L1Info.GetDefaultTransactOpts("Sequencer", ctx)
+	daWriter := &slowDASWriter{}
+	startBatchPoster := func(disableFallback bool) *arbnode.BatchPoster {
+		t.Helper()
+		batchPosterConfig := builder.nodeConfig.BatchPoster
+		batchPosterConfig.Enable = true
+		batchPosterConfig.DASStoreTimeout = 100 * time.Millisecond
+		batchPosterConfig.DisableDasFallbackStoreDataOnChain = disableFallback
+		batchPoster, err := arbnode.NewBatchPoster(ctx,
+			&arbnode.BatchPosterOpts{
+				DataPosterDB:  nil,
+				L1Reader:      builder.L2.ConsensusNode.L1Reader,
+				Inbox:         builder.L2.ConsensusNode.InboxTracker,
+				Streamer:      builder.L2.ConsensusNode.TxStreamer,
+				SyncMonitor:   builder.L2.ConsensusNode.SyncMonitor,
+				Config:        func() *arbnode.BatchPosterConfig { return &batchPosterConfig },
+				DeployInfo:    builder.L2.ConsensusNode.DeployInfo,
+				TransactOpts:  &seqTxOpts,
+				DAWriter:      daWriter,
+				ParentChainID: parentChainID,
+			},
+		)
+		Require(t, err)
+		batchPoster.Start(ctx)
+		return batchPoster
+	}
+
+	// With the fallback disabled, nothing is posted while the DAS store keeps
+	// timing out.
+	batchPoster := startBatchPoster(true)
+	for i := 0; daWriter.timedOut.Load() < 2; i++ {
+		if i > 1000 {
+			Fatal(t, "DAS store didn't time out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	batchPoster.StopAndWait()
+	newBatchCount, err := seqInbox.GetBatchCount(ctx, nil)
+	Require(t, err)
+	if newBatchCount != batchCount {
+		Fatal(t, "batch posted on chain although the fallback is disabled, batch count went from", batchCount, "to", newBatchCount)
+	}
+
+	// Otherwise the batch is posted on chain once the DAS store times out.
+	timedOut := daWriter.timedOut.Load()
+	batchPoster = startBatchPoster(false)
+	defer batchPoster.StopAndWait()
+	for i := 0; i < 30; i++ {
+		builder.L1.SendWaitTestTransactions(t, []*types.Transaction{
+			builder.L1Info.PrepareTx("Faucet", "User", 30000, big.NewInt(1e12), nil),
+		})
+	}
+	_, err = WaitForTx(ctx, testClientB.Client, tx.Hash(), time.Second*30)
+	Require(t, err)
+	if daWriter.timedOut.Load() == timedOut {
+		Fatal(t, "batch posted on chain without trying the DAS first")
+	}
+	l2balance, err := testClientB.Client.BalanceAt(ctx, builder.L2Info.GetAddress("User2"), nil)
+	Require(t, err)
+	if l2balance.Cmp(big.NewInt(1e12)) != 0 {
+		Fatal(t, "Unexpected balance:", l2balance)
+	}
+}
+
+func TestBatchPosterDASDualWrite(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chainConfig, nodeConfig, lifecycleManager, _, dasSignerKey := setupConfigWithDAS(t, ctx, "files")
+	defer lifecycleManager.StopAndWaitUntil(time.Second)
+	nodeConfig.BatchPoster.DASDualWriteUntilBatch = 3
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, true)
+	builder.nodeConfig = nodeConfig
+	builder.chainConfig = chainConfig
+	builder.L2Info = nil
+	cleanup := builder.Build(t)
+	defer cleanup()
+	authorizeDASKeyset(t, ctx, dasSignerKey, builder.L1Info, builder.L1.Client)
+	builder.L2Info.GenerateAccount("User2")
+
+	seqInbox, err := arbnode.NewSequencerInbox(builder.L1.Client, builder.L2.ConsensusNode.DeployInfo.SequencerInbox, 0)
+	Require(t, err)
+	for i := 0; ; i++ {
+		batchCount, err := seqInbox.GetBatchCount(ctx, nil)
+		Require(t, err)
+		if batchCount > nodeConfig.BatchPoster.DASDualWriteUntilBatch+1 {
+			break
+		}
+		if i > 100 {
+			Fatal(t, "only", batchCount, "batches were posted")
+		}
+		tx := builder.L2Info.PrepareTx("Owner", "User2", builder.L2Info.TransferGas, big.NewInt(1e12), nil)
+		err = builder.L2.Client.SendTransaction(ctx, tx)
+		Require(t, err)
+		_, err = builder.L2.EnsureTxSucceeded(tx)
+		Require(t, err)
+		builder.L1.SendWaitTestTransactions(t, []*types.Transaction{
+			builder.L1Info.PrepareTx("Faucet", "User", 30000, big.NewInt(1e12), nil),
+		})
+	}
+
+	l1Block, err := builder.L1.Client.BlockNumber(ctx)
+	Require(t, err)
+	batches, err := seqInbox.LookupBatchesInRange(ctx, common.Big0, new(big.Int).SetUint64(l1Block))
+	Require(t, err)
+	restClient, err := das.NewRestfulDasClientFromURL(nodeConfig.DataAvailability.RestAggregator.Urls[0])
+	Require(t, err)
+	var dualWritten, certificates int
+	for _, batch := range batches {
+		data, err := batch.Serialize(ctx, builder.L1.Client)
+		Require(t, err)
+		// Skip the header, and batches without data.
+		if len(data) <= 40 {
+			continue
+		}
+		payload := data[40:]
+		if batch.SequenceNumber >= nodeConfig.BatchPoster.DASDualWriteUntilBatch {
+			if !arbstate.IsDASMessageHeaderByte(payload[0]) {
+				Fatal(t, "batch", batch.SequenceNumber, "was posted in full, but is past das-dual-write-until-batch")
+			}
+			certificates++
+			continue
+		}
+		if arbstate.IsDASMessageHeaderByte(payload[0]) {
+			Fatal(t, "batch", batch.SequenceNumber, "was posted as a DAS certificate, but is before das-dual-write-until-batch")
+		}
+		stored, err := restClient.GetByHash(ctx, dastree.Hash(payload))
+		Require(t, err, "dual-written batch", batch.SequenceNumber, "isn't in the DAS")
+		if !bytes.Equal(stored, payload) {
+			Fatal(t, "DAS has different data for dual-written batch", batch.SequenceNumber)
+		}
+		dualWritten++
+	}
+	if dualWritten == 0 || certificates == 0 {
+		Fatal(t, "expected both dual-written and DAS batches, got", dualWritten, "dual-written and", certificates, "DAS batches")
+	}
+}
+
 func TestBatchPosterKeepsUp(t *testing.T) {
 	t.Skip("This test is for manual inspection and would be unreliable in CI even if automated")
 	ctx, cancel := context.WithCancel(context.Background())
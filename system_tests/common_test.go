@@ -1034,16 +1034,16 @@ func setupConfigWithDAS(
 	var daWriter das.DataAvailabilityServiceWriter
 	var daHealthChecker das.DataAvailabilityServiceHealthChecker
 	if dasModeString != "onchain" {
-		daReader, daWriter, daHealthChecker, lifecycleManager, err = das.CreateDAComponentsForDaserver(ctx, dasConfig, nil, nil)
+		daReader, daWriter, daHealthChecker, lifecycleManager, err = das.CreateDAComponentsForDaserver(ctx, dasConfig, nil, nil, nil)
 
 		Require(t, err)
 		rpcLis, err := net.Listen("tcp", "localhost:0")
 		Require(t, err)
 		restLis, err := net.Listen("tcp", "localhost:0")
 		Require(t, err)
-		_, err = das.StartDASRPCServerOnListener(ctx, rpcLis, genericconf.HTTPServerTimeoutConfigDefault, daReader, daWriter, daHealthChecker)
+		_, err = das.StartDASRPCServerOnListener(ctx, rpcLis, genericconf.HTTPServerTimeoutConfigDefault, nil, das.DefaultStoreBoundsConfig, daReader, daWriter, daHealthChecker)
 		Require(t, err)
-		_, err = das.NewRestfulDasServerOnListener(restLis, genericconf.HTTPServerTimeoutConfigDefault, daReader, daHealthChecker)
+		_, err = das.NewRestfulDasServerOnListener(restLis, genericconf.HTTPServerTimeoutConfigDefault, das.DefaultRestfulCORSConfig, nil, daReader, daHealthChecker, nil)
 		Require(t, err)
 
 		beConfigA := das.BackendConfig{
@@ -0,0 +1,82 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package containers
+
+// FrequencySketch estimates how many times keys have been seen recently, for
+// TinyLFU cache admission. It is a count-min sketch with four rows of 4-bit
+// saturating counters, all of which are halved once the sketch has counted
+// ten times as many keys as it has counters per row, so that keys that are
+// no longer popular are forgotten. Keys are given as 64-bit hashes.
+// Not thread safe!
+type FrequencySketch struct {
+	rows       [frequencySketchDepth][]uint8
+	mask       uint64
+	additions  uint64
+	resetAfter uint64
+}
+
+const (
+	frequencySketchDepth = 4
+	frequencySketchMax   = 15
+)
+
+var frequencySketchSeeds = [frequencySketchDepth]uint64{
+	0xc3a5c85c97cb3127, 0xb492b66fbe98f273, 0x9ae16a3b2f90404f, 0xcbf29ce484222325,
+}
+
+// NewFrequencySketch returns a sketch sized for a cache of the given capacity.
+func NewFrequencySketch(capacity int) *FrequencySketch {
+	width := uint64(16)
+	for width < uint64(capacity) {
+		width *= 2
+	}
+	s := &FrequencySketch{
+		mask:       width - 1,
+		resetAfter: 10 * width,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+func (s *FrequencySketch) index(row int, hash uint64) uint64 {
+	h := hash * frequencySketchSeeds[row]
+	h ^= h >> 31
+	return h & s.mask
+}
+
+// Increment records an occurrence of the key.
+func (s *FrequencySketch) Increment(hash uint64) {
+	for i := range s.rows {
+		idx := s.index(i, hash)
+		if s.rows[i][idx] < frequencySketchMax {
+			s.rows[i][idx]++
+		}
+	}
+	s.additions++
+	if s.additions >= s.resetAfter {
+		s.reset()
+	}
+}
+
+// Estimate returns an upper bound on the recent occurrences of the key.
+func (s *FrequencySketch) Estimate(hash uint64) uint8 {
+	estimate := uint8(frequencySketchMax)
+	for i := range s.rows {
+		if count := s.rows[i][s.index(i, hash)]; count < estimate {
+			estimate = count
+		}
+	}
+	return estimate
+}
+
+func (s *FrequencySketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] /= 2
+		}
+	}
+	s.additions /= 2
+}
@@ -0,0 +1,39 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package containers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestFrequencySketch(t *testing.T) {
+	s := NewFrequencySketch(100)
+	for i := 0; i < 5; i++ {
+		s.Increment(1)
+	}
+	s.Increment(2)
+	if s.Estimate(1) < 5 {
+		testhelpers.FailImpl(t, fmt.Sprintf("Estimate of key seen 5 times is %d", s.Estimate(1)))
+	}
+	if s.Estimate(1) <= s.Estimate(2) {
+		testhelpers.FailImpl(t, fmt.Sprintf("Frequent key estimated at %d, infrequent key at %d", s.Estimate(1), s.Estimate(2)))
+	}
+	for i := 0; i < 100; i++ {
+		s.Increment(1)
+	}
+	if s.Estimate(1) != frequencySketchMax {
+		testhelpers.FailImpl(t, fmt.Sprintf("Counter didn't saturate: %d", s.Estimate(1)))
+	}
+
+	// Counters are halved as other keys are counted.
+	for i := uint64(1000); i < 1000+s.resetAfter; i++ {
+		s.Increment(i)
+	}
+	if s.Estimate(1) >= frequencySketchMax {
+		testhelpers.FailImpl(t, fmt.Sprintf("Counter wasn't aged: %d", s.Estimate(1)))
+	}
+}